@@ -25,6 +25,7 @@ const (
 	BearerPropertyBearerType = BearerInterface + ".BearerType" // readable   u
 	BearerPropertyProperties = BearerInterface + ".Properties" // readable   a{sv}
 
+	BearerPropertyConnectionError = BearerInterface + ".ConnectionError" // readable   (ss), since ModemManager 1.18
 )
 
 // Bearer interface provides access to specific actions that may be performed on available bearers.
@@ -97,6 +98,10 @@ type Bearer interface {
 	// A MMBearerType
 	GetBearerType() (MMBearerType, error)
 
+	// The D-Bus error name and message of the last failed connection attempt, both empty if the
+	// last attempt succeeded or none was made. Not available before ModemManager 1.18.
+	GetConnectionError() (name string, message string, err error)
+
 	// List of properties used when creating the bearer.
 	GetProperties() (BearerProperty, error)
 
@@ -117,7 +122,7 @@ type Bearer interface {
 // NewBearer returns new Bearer Interface
 func NewBearer(objectPath dbus.ObjectPath) (Bearer, error) {
 	var be bearer
-	return &be, be.init(ModemManagerInterface, objectPath)
+	return &be, be.init(ModemManagerBusName, objectPath)
 }
 
 type bearer struct {
@@ -201,25 +206,50 @@ func (bp BearerProperty) String() string {
 		", Number: " + bp.Number
 }
 
-// BearerStats represents all stats according to the bearer
+// BearerStats represents all stats according to the bearer. Keys a daemon does not report are left zero;
+// the attempt, total and speed values need ModemManager 1.14 or later, StartDate and the speeds 1.20.
 type BearerStats struct {
-	RxBytes  uint64 `json:"rx-bytes"` // Number of bytes received without error, given as an unsigned 64-bit integer value (signature "t").
-	TxBytes  uint64 `json:"tx-bytes"` // Number bytes transmitted without error, given as an unsigned 64-bit integer value (signature "t").
-	Duration uint32 `json:"duration"` // Duration of the connection, in seconds, given as an unsigned integer value (signature "u").
+	RxBytes        uint64 `json:"rx-bytes"`        // Number of bytes received without error, given as an unsigned 64-bit integer value (signature "t").
+	TxBytes        uint64 `json:"tx-bytes"`        // Number bytes transmitted without error, given as an unsigned 64-bit integer value (signature "t").
+	Duration       uint32 `json:"duration"`        // Duration of the connection, in seconds, given as an unsigned integer value (signature "u").
+	StartDate      uint64 `json:"start-date"`      // Timestamp when the connection was established, in seconds since the epoch (signature "t").
+	Attempts       uint32 `json:"attempts"`        // Total number of connection attempts done with this bearer (signature "u").
+	FailedAttempts uint32 `json:"failed-attempts"` // Number of failed connection attempts done with this bearer (signature "u").
+	TotalDuration  uint32 `json:"total-duration"`  // Total duration of all connections of this bearer, in seconds (signature "u").
+	TotalRxBytes   uint64 `json:"total-rx-bytes"`  // Total bytes received in all connections of this bearer (signature "t").
+	TotalTxBytes   uint64 `json:"total-tx-bytes"`  // Total bytes transmitted in all connections of this bearer (signature "t").
+	UplinkSpeed    uint64 `json:"uplink-speed"`    // Uplink bit rate negotiated with the network, in bits per second (signature "t").
+	DownlinkSpeed  uint64 `json:"downlink-speed"`  // Downlink bit rate negotiated with the network, in bits per second (signature "t").
 }
 
 // MarshalJSON returns a byte array
 func (bs BearerStats) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]interface{}{
-		"RxBytes":  bs.RxBytes,
-		"TxBytes":  bs.TxBytes,
-		"Duration": bs.Duration,
+		"RxBytes":        bs.RxBytes,
+		"TxBytes":        bs.TxBytes,
+		"Duration":       bs.Duration,
+		"StartDate":      bs.StartDate,
+		"Attempts":       bs.Attempts,
+		"FailedAttempts": bs.FailedAttempts,
+		"TotalDuration":  bs.TotalDuration,
+		"TotalRxBytes":   bs.TotalRxBytes,
+		"TotalTxBytes":   bs.TotalTxBytes,
+		"UplinkSpeed":    bs.UplinkSpeed,
+		"DownlinkSpeed":  bs.DownlinkSpeed,
 	})
 }
 func (bs BearerStats) String() string {
 	return "RxBytes: " + fmt.Sprint(bs.RxBytes) +
 		", TxBytes: " + fmt.Sprint(bs.TxBytes) +
-		", Duration: " + fmt.Sprint(bs.Duration)
+		", Duration: " + fmt.Sprint(bs.Duration) +
+		", StartDate: " + fmt.Sprint(bs.StartDate) +
+		", Attempts: " + fmt.Sprint(bs.Attempts) +
+		", FailedAttempts: " + fmt.Sprint(bs.FailedAttempts) +
+		", TotalDuration: " + fmt.Sprint(bs.TotalDuration) +
+		", TotalRxBytes: " + fmt.Sprint(bs.TotalRxBytes) +
+		", TotalTxBytes: " + fmt.Sprint(bs.TotalTxBytes) +
+		", UplinkSpeed: " + fmt.Sprint(bs.UplinkSpeed) +
+		", DownlinkSpeed: " + fmt.Sprint(bs.DownlinkSpeed)
 }
 func (be bearer) GetObjectPath() dbus.ObjectPath {
 	return be.obj.Path()
@@ -245,6 +275,18 @@ func (be bearer) GetSuspended() (bool, error) {
 	return be.getBoolProperty(BearerPropertySuspended)
 }
 
+func (be bearer) GetConnectionError() (name string, message string, err error) {
+	res, err := be.getPairProperty(BearerPropertyConnectionError)
+	if err != nil {
+		return
+	}
+	if name, err = decode[string](BearerPropertyConnectionError+".name", res.a); err != nil {
+		return
+	}
+	message, err = decode[string](BearerPropertyConnectionError+".message", res.b)
+	return
+}
+
 func (be bearer) GetIp4Config() (bi BearerIpConfig, err error) {
 	tmpMap, err := be.getMapStringVariantProperty(BearerPropertyIp4Config)
 	if err != nil {
@@ -359,24 +401,36 @@ func (be bearer) GetStats() (br BearerStats, err error) {
 	if err != nil {
 		return br, err
 	}
+	return decodeBearerStats(tmpMap), nil
+}
+
+// decodeBearerStats decodes the Stats dictionary. Unknown keys and values of an unexpected type are ignored.
+func decodeBearerStats(tmpMap map[string]dbus.Variant) (br BearerStats) {
+	uint32Fields := map[string]*uint32{
+		"duration":        &br.Duration,
+		"attempts":        &br.Attempts,
+		"failed-attempts": &br.FailedAttempts,
+		"total-duration":  &br.TotalDuration,
+	}
+	uint64Fields := map[string]*uint64{
+		"rx-bytes":       &br.RxBytes,
+		"tx-bytes":       &br.TxBytes,
+		"start-date":     &br.StartDate,
+		"total-rx-bytes": &br.TotalRxBytes,
+		"total-tx-bytes": &br.TotalTxBytes,
+		"uplink-speed":   &br.UplinkSpeed,
+		"downlink-speed": &br.DownlinkSpeed,
+	}
 	for key, element := range tmpMap {
-		switch key {
-		case "duration":
-			tmpValue, ok := element.Value().(uint32)
-			if ok {
-				br.Duration = tmpValue
+		if field, ok := uint32Fields[key]; ok {
+			if tmpValue, ok := element.Value().(uint32); ok {
+				*field = tmpValue
 			}
-		case "rx-bytes":
-			tmpValue, ok := element.Value().(uint64)
-			if ok {
-				br.RxBytes = tmpValue
-			}
-		case "tx-bytes":
-			tmpValue, ok := element.Value().(uint64)
-			if ok {
-				br.TxBytes = tmpValue
+		}
+		if field, ok := uint64Fields[key]; ok {
+			if tmpValue, ok := element.Value().(uint64); ok {
+				*field = tmpValue
 			}
-
 		}
 	}
 	return
@@ -446,7 +500,7 @@ func (be bearer) GetProperties() (bp BearerProperty, err error) {
 	return
 }
 
-func (be bearer) SubscribePropertiesChanged() <-chan *dbus.Signal {
+func (be *bearer) SubscribePropertiesChanged() <-chan *dbus.Signal {
 	if be.sigChan != nil {
 		return be.sigChan
 	}
@@ -460,7 +514,7 @@ func (be bearer) ParsePropertiesChanged(v *dbus.Signal) (interfaceName string, c
 	return be.parsePropertiesChanged(v)
 }
 
-func (be bearer) Unsubscribe() {
+func (be *bearer) Unsubscribe() {
 	be.conn.RemoveSignal(be.sigChan)
 	be.sigChan = nil
 }
@@ -0,0 +1,61 @@
+package modemmanager
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestDecodeBearerStats(t *testing.T) {
+	// ModemManager 1.20 reports every key
+	stats := decodeBearerStats(map[string]dbus.Variant{
+		"duration":        dbus.MakeVariant(uint32(3600)),
+		"rx-bytes":        dbus.MakeVariant(uint64(1 << 33)),
+		"tx-bytes":        dbus.MakeVariant(uint64(4096)),
+		"start-date":      dbus.MakeVariant(uint64(1700000000)),
+		"attempts":        dbus.MakeVariant(uint32(5)),
+		"failed-attempts": dbus.MakeVariant(uint32(2)),
+		"total-duration":  dbus.MakeVariant(uint32(86400)),
+		"total-rx-bytes":  dbus.MakeVariant(uint64(1 << 34)),
+		"total-tx-bytes":  dbus.MakeVariant(uint64(8192)),
+		"uplink-speed":    dbus.MakeVariant(uint64(50000000)),
+		"downlink-speed":  dbus.MakeVariant(uint64(150000000)),
+	})
+	want := BearerStats{
+		RxBytes: 1 << 33, TxBytes: 4096, Duration: 3600, StartDate: 1700000000,
+		Attempts: 5, FailedAttempts: 2, TotalDuration: 86400, TotalRxBytes: 1 << 34, TotalTxBytes: 8192,
+		UplinkSpeed: 50000000, DownlinkSpeed: 150000000,
+	}
+	if stats != want {
+		t.Errorf("decodeBearerStats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestDecodeBearerStatsOldDaemon(t *testing.T) {
+	// ModemManager before 1.14 only reports the current connection
+	stats := decodeBearerStats(map[string]dbus.Variant{
+		"duration": dbus.MakeVariant(uint32(60)),
+		"rx-bytes": dbus.MakeVariant(uint64(100)),
+		"tx-bytes": dbus.MakeVariant(uint64(200)),
+	})
+	if want := (BearerStats{Duration: 60, RxBytes: 100, TxBytes: 200}); stats != want {
+		t.Errorf("decodeBearerStats = %+v, want %+v", stats, want)
+	}
+
+	if stats := decodeBearerStats(nil); stats != (BearerStats{}) {
+		t.Errorf("decodeBearerStats(nil) = %+v, want zero", stats)
+	}
+}
+
+func TestDecodeBearerStatsIgnoresUnexpectedTypes(t *testing.T) {
+	stats := decodeBearerStats(map[string]dbus.Variant{
+		"duration":    dbus.MakeVariant(uint64(60)),
+		"attempts":    dbus.MakeVariant("3"),
+		"rx-bytes":    dbus.MakeVariant(uint64(100)),
+		"future-key":  dbus.MakeVariant(uint32(1)),
+		"uplink-rate": dbus.MakeVariant(uint64(1)),
+	})
+	if want := (BearerStats{RxBytes: 100}); stats != want {
+		t.Errorf("decodeBearerStats = %+v, want %+v", stats, want)
+	}
+}
@@ -138,7 +138,7 @@ type Call interface {
 // NewCall returns new Call Interface
 func NewCall(objectPath dbus.ObjectPath) (Call, error) {
 	var ca call
-	return &ca, ca.init(ModemManagerInterface, objectPath)
+	return &ca, ca.init(ModemManagerBusName, objectPath)
 }
 
 type call struct {
@@ -261,11 +261,11 @@ func (ca call) GetAudioFormat() (af AudioFormat, err error) {
 	return
 }
 
-func (ca call) SubscribeDtmfReceived() <-chan *dbus.Signal {
+func (ca *call) SubscribeDtmfReceived() <-chan *dbus.Signal {
 	if ca.sigChan != nil {
 		return ca.sigChan
 	}
-	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", CallSignalStateChanged, fmt.Sprint(ca.GetObjectPath()))
+	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", CallSignalDtmfReceived, fmt.Sprint(ca.GetObjectPath()))
 	ca.conn.BusObject().Call(dbusMethodAddMatch, 0, rule)
 	ca.sigChan = make(chan *dbus.Signal, 10)
 	ca.conn.Signal(ca.sigChan)
@@ -286,7 +286,7 @@ func (ca call) ParseDtmfReceived(v *dbus.Signal) (dtmf string, err error) {
 
 }
 
-func (ca call) SubscribeStateChanged() <-chan *dbus.Signal {
+func (ca *call) SubscribeStateChanged() <-chan *dbus.Signal {
 	if ca.sigChan != nil {
 		return ca.sigChan
 	}
@@ -325,7 +325,7 @@ func (ca call) ParseStateChanged(v *dbus.Signal) (oldState MMCallState, newState
 	return
 }
 
-func (ca call) SubscribePropertiesChanged() <-chan *dbus.Signal {
+func (ca *call) SubscribePropertiesChanged() <-chan *dbus.Signal {
 	if ca.sigChan != nil {
 		return ca.sigChan
 	}
@@ -340,7 +340,7 @@ func (ca call) ParsePropertiesChanged(v *dbus.Signal) (interfaceName string, cha
 	return ca.parsePropertiesChanged(v)
 }
 
-func (ca call) Unsubscribe() {
+func (ca *call) Unsubscribe() {
 	ca.conn.RemoveSignal(ca.sigChan)
 	ca.sigChan = nil
 }
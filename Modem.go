@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/godbus/dbus/v5"
 	"reflect"
+	"strings"
 )
 
 // Paths of methods and properties
@@ -13,9 +14,9 @@ const (
 	ModemInterface = ModemManagerInterface + ".Modem"
 
 	/* Methods */
-	ModemEnable  = ModemInterface + ".Enable"
-	ModemDisable = ModemEnable
-	// Deprecated ModemListBearers            = ModemInterface + ".ListBearers"
+	ModemEnable                 = ModemInterface + ".Enable"
+	ModemDisable                = ModemEnable
+	ModemListBearers            = ModemInterface + ".ListBearers" // deprecated, see ListModemBearers
 	ModemCreateBearer           = ModemInterface + ".CreateBearer"
 	ModemDeleteBearer           = ModemInterface + ".DeleteBearer"
 	ModemReset                  = ModemInterface + ".Reset"
@@ -109,6 +110,7 @@ type Modem interface {
 	// Disable the Modem: When disabled, the modem enters low-power state and no network-related operations are available.
 	Disable() error
 	// Deprecated: List configured packet data bearers (EPS Bearers, PDP Contexts, or CDMA2000 Packet Data Sessions).
+	// Not part of the interface, use ListModemBearers to fall back to it.
 	// ListBearers() ([]Bearer, error)
 
 	// Create a new packet data bearer using the given characteristics.
@@ -309,7 +311,7 @@ type Modem interface {
 // NewModem returns new Modem Interface
 func NewModem(objectPath dbus.ObjectPath) (Modem, error) {
 	var m modem
-	return &m, m.init(ModemManagerInterface, objectPath)
+	return &m, m.init(ModemManagerBusName, objectPath)
 }
 
 type modem struct {
@@ -331,20 +333,118 @@ func (po Port) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// Mode represents the modem access technology modes
+// ModeMask is a bitmask of MMModemMode values
+type ModeMask uint32
+
+// NewModeMask returns the bitmask of the given modes
+func NewModeMask(modes ...MMModemMode) ModeMask {
+	var mask ModeMask
+	for _, mode := range modes {
+		mask |= ModeMask(mode)
+	}
+	return mask
+}
+
+// Has returns true if all bits of mode are set in the mask
+func (mm ModeMask) Has(mode MMModemMode) bool {
+	return mode != MmModemModeNone && uint32(mm)&uint32(mode) == uint32(mode)
+}
+
+// Modes returns the single modes set in the mask. MmModemModeAny is returned as is.
+func (mm ModeMask) Modes() []MMModemMode {
+	if MMModemMode(mm) == MmModemModeAny {
+		return []MMModemMode{MmModemModeAny}
+	}
+	var tmp MMModemMode
+	return tmp.BitmaskToSlice(uint32(mm))
+}
+
+// Strings returns the names of the modes set in the mask
+func (mm ModeMask) Strings() []string {
+	modes := mm.Modes()
+	res := make([]string, len(modes))
+	for i, mode := range modes {
+		res[i] = mode.String()
+	}
+	return res
+}
+
+func (mm ModeMask) String() string {
+	if mm == 0 {
+		return MmModemModeNone.String()
+	}
+	return strings.Join(mm.Strings(), ", ")
+}
+
+// Mode represents the modem access technology modes, a pair of allowed modes and the preferred mode
 type Mode struct {
-	AllowedModes  []MMModemMode // allowed modes.
-	PreferredMode MMModemMode   // preferred access technology
+	Allowed   ModeMask    // bitmask of allowed modes
+	Preferred MMModemMode // preferred access technology among the allowed ones, or MmModemModeNone
 }
 
 // MarshalJSON returns a byte array
 func (mo Mode) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]interface{}{
-		"AllowedModes":  mo.AllowedModes,
-		"PreferredMode": mo.PreferredMode,
+		"AllowedModes":  mo.Allowed.Strings(),
+		"PreferredMode": mo.Preferred.String(),
 	})
 }
 
+func (mo Mode) String() string {
+	return "allowed: " + mo.Allowed.String() + "; preferred: " + mo.Preferred.String()
+}
+
+// modePair is the D-Bus (uu) encoding of a Mode
+type modePair struct {
+	Allowed   uint32
+	Preferred uint32
+}
+
+func (mo Mode) toDBus() modePair {
+	return modePair{Allowed: uint32(mo.Allowed), Preferred: uint32(mo.Preferred)}
+}
+
+// decodeMode decodes a (uu) tuple as returned by godbus
+func decodeMode(value interface{}) (mode Mode, err error) {
+	values, ok := value.([]interface{})
+	if !ok || len(values) != 2 {
+		return mode, fmt.Errorf("unexpected mode tuple %v", value)
+	}
+	allowed, ok := values[0].(uint32)
+	if !ok {
+		return mode, fmt.Errorf("unexpected allowed modes %v", values[0])
+	}
+	preferred, ok := values[1].(uint32)
+	if !ok {
+		return mode, fmt.Errorf("unexpected preferred mode %v", values[1])
+	}
+	return Mode{Allowed: ModeMask(allowed), Preferred: MMModemMode(preferred)}, nil
+}
+
+// decodeModes decodes an a(uu) array as returned by godbus
+func decodeModes(value interface{}) ([]Mode, error) {
+	var tuples []interface{}
+	switch v := value.(type) {
+	case [][]interface{}:
+		for _, tuple := range v {
+			tuples = append(tuples, tuple)
+		}
+	case []interface{}:
+		tuples = v
+	default:
+		return nil, fmt.Errorf("unexpected mode list %v", value)
+	}
+	modes := make([]Mode, 0, len(tuples))
+	for _, tuple := range tuples {
+		mode, err := decodeMode(tuple)
+		if err != nil {
+			return nil, err
+		}
+		modes = append(modes, mode)
+	}
+	return modes, nil
+}
+
 func (m modem) GetObjectPath() dbus.ObjectPath {
 	return m.obj.Path()
 }
@@ -444,11 +544,7 @@ func (m modem) SetCurrentCapabilities(capabilities []MMModemCapability) error {
 }
 
 func (m modem) SetCurrentModes(property Mode) error {
-	// todo: untested
-	var mode MMModemMode
-	var resSlice = []uint32{mode.SliceToBitmask(property.AllowedModes),
-		mode.SliceToBitmask([]MMModemMode{property.PreferredMode})}
-	return m.call(ModemSetCurrentModes, resSlice)
+	return m.call(ModemSetCurrentModes, property.toDBus())
 }
 
 func (m modem) SetCurrentBands(bands []MMModemBand) error {
@@ -485,6 +581,52 @@ func (m modem) GetBearers() ([]Bearer, error) {
 	return bearers, nil
 }
 
+// ListBearers calls the deprecated ListBearers method, which older ModemManager
+// versions offer instead of the Bearers property. See ListModemBearers.
+func (m modem) ListBearers() ([]Bearer, error) {
+	var bearerPaths []dbus.ObjectPath
+	if err := m.callWithReturn(&bearerPaths, ModemListBearers); err != nil {
+		return nil, err
+	}
+	var bearers []Bearer
+	for idx := range bearerPaths {
+		bearer, err := NewBearer(bearerPaths[idx])
+		if err != nil {
+			return nil, err
+		}
+		bearers = append(bearers, bearer)
+	}
+	return bearers, nil
+}
+
+// ListModemBearers returns the bearers of a modem. It reads the Bearers
+// property through GetBearers and falls back to the deprecated ListBearers
+// method if that fails, so it works with ModemManager versions and Modem
+// implementations that only offer one of the two. The error of GetBearers is
+// returned if neither succeeds.
+func ListModemBearers(m interface{}) ([]Bearer, error) {
+	var err error
+	if getter, ok := m.(interface{ GetBearers() ([]Bearer, error) }); ok {
+		var bearers []Bearer
+		if bearers, err = getter.GetBearers(); err == nil {
+			return bearers, nil
+		}
+	}
+	if lister, ok := m.(interface{ ListBearers() ([]Bearer, error) }); ok {
+		bearers, listErr := lister.ListBearers()
+		if listErr == nil {
+			return bearers, nil
+		}
+		if err == nil {
+			err = listErr
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("%T offers neither GetBearers nor ListBearers", m)
+	}
+	return nil, err
+}
+
 func (m modem) GetSupportedCapabilities() (capabilities [][]MMModemCapability, err error) {
 	caps, err := m.getSliceUint32Property(ModemPropertySupportedCapabilities)
 	if err != nil {
@@ -567,14 +709,13 @@ func (m modem) GetPorts() (ports []Port, err error) {
 		return nil, err
 	}
 	for _, pair := range res {
-		newA, ok := pair.a.(string)
-		if !ok {
-			return nil, errors.New("wrong type != string")
+		newA, err := decode[string](ModemPropertyPorts+".name", pair.a)
+		if err != nil {
+			return nil, err
 		}
-		newB, ok := pair.b.(uint32)
-		if !ok {
-
-			return nil, errors.New("wrong type != uin32")
+		newB, err := decode[uint32](ModemPropertyPorts+".type", pair.b)
+		if err != nil {
+			return nil, err
 		}
 		ports = append(ports, Port{PortName: newA, PortType: MMModemPortType(newB)})
 	}
@@ -638,7 +779,11 @@ func (m modem) GetSignalQuality() (percent uint32, recent bool, err error) {
 	if err != nil {
 		return
 	}
-	return res.a.(uint32), res.b.(bool), nil
+	if percent, err = decode[uint32](ModemPropertySignalQuality+".quality", res.a); err != nil {
+		return
+	}
+	recent, err = decode[bool](ModemPropertySignalQuality+".recent", res.b)
+	return
 }
 
 func (m modem) GetOwnNumbers() ([]string, error) {
@@ -654,31 +799,20 @@ func (m modem) GetPowerState() (MMModemPowerState, error) {
 	return MMModemPowerState(res), nil
 }
 
-func (m modem) GetSupportedModes() (modes []Mode, err error) {
-
-	res, err := m.getSliceSlicePairProperty(ModemPropertySupportedModes)
-
+func (m modem) GetSupportedModes() ([]Mode, error) {
+	res, err := m.getProperty(ModemPropertySupportedModes)
 	if err != nil {
 		return nil, err
 	}
-	var tmp MMModemMode
-	for _, x := range res {
-		modes = append(modes, Mode{AllowedModes: tmp.BitmaskToSlice(x.a.(uint32)), PreferredMode: MMModemMode(x.b.(uint32))})
-
-	}
-	return
-
+	return decodeModes(res)
 }
-func (m modem) GetCurrentModes() (mode Mode, err error) {
-	res, err := m.getPairProperty(ModemPropertyCurrentModes)
+
+func (m modem) GetCurrentModes() (Mode, error) {
+	res, err := m.getProperty(ModemPropertyCurrentModes)
 	if err != nil {
-		return mode, err
+		return Mode{}, err
 	}
-	var tmp MMModemMode
-	mode.PreferredMode = MMModemMode(res.b.(uint32))
-	mode.AllowedModes = tmp.BitmaskToSlice(res.a.(uint32))
-
-	return
+	return decodeMode(res)
 }
 
 func (m modem) GetSupportedBands() (bands []MMModemBand, err error) {
@@ -716,14 +850,20 @@ func (m modem) GetSupportedIpFamilies() ([]MMBearerIpFamily, error) {
 	return ipFam.BitmaskToSlice(res), nil
 }
 
-func (m modem) SubscribeStateChanged() <-chan *dbus.Signal {
-	if m.sigChan != nil {
-		return m.sigChan
-	}
+func (m *modem) SubscribeStateChanged() <-chan *dbus.Signal {
 	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", ModemSignalStateChanged, fmt.Sprint(m.GetObjectPath()))
+	return m.subscribeRule(rule)
+}
+
+// subscribeRule adds a match rule and returns the signal channel of the
+// modem. Both subscriptions share the channel, so the rule is added even if
+// the channel already exists.
+func (m *modem) subscribeRule(rule string) <-chan *dbus.Signal {
 	m.conn.BusObject().Call(dbusMethodAddMatch, 0, rule)
-	m.sigChan = make(chan *dbus.Signal, 10)
-	m.conn.Signal(m.sigChan)
+	if m.sigChan == nil {
+		m.sigChan = make(chan *dbus.Signal, 10)
+		m.conn.Signal(m.sigChan)
+	}
 	return m.sigChan
 }
 func (m modem) ParseStateChanged(v *dbus.Signal) (oldState MMModemState, newState MMModemState, reason MMModemStateChangeReason, err error) {
@@ -754,21 +894,15 @@ func (m modem) ParseStateChanged(v *dbus.Signal) (oldState MMModemState, newStat
 
 	return
 }
-func (m modem) SubscribePropertiesChanged() <-chan *dbus.Signal {
-	if m.sigChan != nil {
-		return m.sigChan
-	}
+func (m *modem) SubscribePropertiesChanged() <-chan *dbus.Signal {
 	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", dbusPropertiesChanged, fmt.Sprint(m.GetObjectPath()))
-	m.conn.BusObject().Call(dbusMethodAddMatch, 0, rule)
-	m.sigChan = make(chan *dbus.Signal, 10)
-	m.conn.Signal(m.sigChan)
-	return m.sigChan
+	return m.subscribeRule(rule)
 }
 func (m modem) ParsePropertiesChanged(v *dbus.Signal) (interfaceName string, changedProperties map[string]dbus.Variant, invalidatedProperties []string, err error) {
 	return m.parsePropertiesChanged(v)
 }
 
-func (m modem) Unsubscribe() {
+func (m *modem) Unsubscribe() {
 	m.conn.RemoveSignal(m.sigChan)
 	m.sigChan = nil
 }
@@ -783,7 +917,7 @@ func (m modem) MarshalJSON() ([]byte, error) {
 		return nil, err
 	}
 	var bearersJson [][]byte
-	bearers, err := m.GetBearers()
+	bearers, err := ListModemBearers(m)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +112,7 @@ type Modem3gpp interface {
 func NewModem3gpp(objectPath dbus.ObjectPath) (Modem3gpp, error) {
 	var m3gpp modem3gpp
 	scanResults = NetworkScanResult{Recent: false}
-	return &m3gpp, m3gpp.init(ModemManagerInterface, objectPath)
+	return &m3gpp, m3gpp.init(ModemManagerBusName, objectPath)
 }
 
 type modem3gpp struct {
@@ -381,26 +381,25 @@ func (m modem3gpp) GetPco() (data []RawPcoData, err error) {
 	if err != nil {
 		return nil, err
 	}
-	res, ok := tmpRes.([][]interface{})
-	if ok {
-		for _, seq := range res {
-			if len(seq) == 3 {
-				sessionId, ok := seq[0].(uint32)
+	res, err := decode[[][]interface{}](Modem3gppPropertyPco, tmpRes)
+	if err != nil {
+		return nil, err
+	}
+	for _, seq := range res {
+		if len(seq) == 3 {
+			sessionId, ok := seq[0].(uint32)
+			if ok {
+				complete, ok := seq[1].(bool)
 				if ok {
-					complete, ok := seq[1].(bool)
+					rawData, ok := seq[2].([]byte)
 					if ok {
-						rawData, ok := seq[2].([]byte)
-						if ok {
-							data = append(data, RawPcoData{SessionId: sessionId, Complete: complete, RawData: rawData})
-						}
+						data = append(data, RawPcoData{SessionId: sessionId, Complete: complete, RawData: rawData})
 					}
 				}
 			}
 		}
-		return
 	}
-
-	return nil, errors.New("wrong type")
+	return
 }
 
 func (m modem3gpp) GetInitialEpsBearer() (Bearer, error) {
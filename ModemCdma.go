@@ -27,6 +27,10 @@ const (
 
 	/* Signal */
 	ModemCdmaSignalActivationStateChanged = "ActivationStateChanged"
+
+	/* Values of Sid and Nid while not registered */
+	ModemCdmaSidUnknown = 99999
+	ModemCdmaNidUnknown = 99999
 )
 
 // ModemCdma interface provides access to specific actions that may be performed in modems with CDMA capabilities.
@@ -89,7 +93,7 @@ type ModemCdma interface {
 // NewModemCdma returns new ModemCdma Interface
 func NewModemCdma(objectPath dbus.ObjectPath) (ModemCdma, error) {
 	var mc modemCdma
-	return &mc, mc.init(ModemManagerInterface, objectPath)
+	return &mc, mc.init(ModemManagerBusName, objectPath)
 }
 
 type modemCdma struct {
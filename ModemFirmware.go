@@ -2,7 +2,6 @@ package modemmanager
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/godbus/dbus/v5"
 )
@@ -54,7 +53,7 @@ type ModemFirmware interface {
 // NewModemFirmware returns new ModemFirmware Interface
 func NewModemFirmware(objectPath dbus.ObjectPath) (ModemFirmware, error) {
 	var fi modemFirmware
-	return &fi, fi.init(ModemManagerInterface, objectPath)
+	return &fi, fi.init(ModemManagerBusName, objectPath)
 }
 
 type modemFirmware struct {
@@ -194,14 +193,14 @@ func (fi modemFirmware) GetUpdateSettings() (property UpdateSettingsProperty, er
 		return
 	}
 	var tmp MMModemFirmwareUpdateMethod
-	bitmask, ok := res.GetLeft().(uint32)
-	if !ok {
-		return property, errors.New("wrong type")
+	bitmask, err := decode[uint32](ModemFirmwarePropertyUpdateSettings+".methods", res.GetLeft())
+	if err != nil {
+		return property, err
 	}
 	property.UpdateMethods = tmp.BitmaskToSlice(bitmask)
-	resMap, ok := res.GetRight().(map[string]dbus.Variant)
-	if !ok {
-		return property, errors.New("wrong type")
+	resMap, err := decode[map[string]dbus.Variant](ModemFirmwarePropertyUpdateSettings+".settings", res.GetRight())
+	if err != nil {
+		return property, err
 	}
 	for key, element := range resMap {
 		switch key {
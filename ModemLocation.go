@@ -117,7 +117,7 @@ type ModemLocation interface {
 // NewModemLocation returns new ModemLocation Interface
 func NewModemLocation(objectPath dbus.ObjectPath) (ModemLocation, error) {
 	var lo modemLocation
-	return &lo, lo.init(ModemManagerInterface, objectPath)
+	return &lo, lo.init(ModemManagerBusName, objectPath)
 }
 
 type modemLocation struct {
@@ -353,9 +353,13 @@ func (lo modemLocation) createLocation(res map[uint32]dbus.Variant) (locs Curren
 								if err != nil {
 									return locs, err
 								}
+								// The date is missing, take today's and yesterday's
+								// for a fix from just before midnight
 								now := time.Now().UTC()
-								// workaround as date is missing
-								t = t.AddDate(now.Year(), int(now.Month()), now.Day())
+								t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+								if t.Sub(now) > time.Hour {
+									t = t.AddDate(0, 0, -1)
+								}
 								gpsRaw.UtcTime = t
 							}
 						case "altitude":
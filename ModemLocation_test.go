@@ -0,0 +1,43 @@
+package modemmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestCreateLocationGpsRaw(t *testing.T) {
+	var lo modemLocation
+	before := time.Now().UTC()
+	locs, err := lo.createLocation(map[uint32]dbus.Variant{
+		uint32(MmModemLocationSourceGpsRaw): dbus.MakeVariant(map[string]interface{}{
+			"utc-time":  "000001.50",
+			"latitude":  52.520008,
+			"longitude": 13.404954,
+		}),
+		uint32(MmModemLocationSource3gppLacCi): dbus.MakeVariant("262,01,0,1A2B3C,6FFE"),
+	})
+	if err != nil {
+		t.Fatalf("createLocation: %v", err)
+	}
+
+	gps := locs.GpsRaw
+	if gps.Latitude != 52.520008 || gps.Longitude != 13.404954 || gps.Altitude != 0 {
+		t.Errorf("GpsRaw = %+v", gps)
+	}
+	// The fix carries the time of day only, the date is today's or, as the
+	// time is just after midnight, yesterday's
+	y, m, d := before.Date()
+	today := time.Date(y, m, d, 0, 0, 1, 500e6, time.UTC)
+	if !gps.UtcTime.Equal(today) && !gps.UtcTime.Equal(today.AddDate(0, 0, -1)) {
+		t.Errorf("UtcTime = %v, want %v or the day before", gps.UtcTime, today)
+	}
+	if gps.UtcTime.After(time.Now().UTC().Add(time.Hour)) {
+		t.Errorf("UtcTime = %v is in the future", gps.UtcTime)
+	}
+
+	if want := (ThreeGppLacCiLocation{Mcc: "262", Mnc: "01", Lac: "0", Ci: "1A2B3C", Tac: "6FFE"}); locs.ThreeGppLacCi != want {
+		t.Errorf("ThreeGppLacCi = %+v, want %+v", locs.ThreeGppLacCi, want)
+	}
+}
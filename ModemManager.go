@@ -9,11 +9,6 @@ import (
 
 // Paths of methods and properties
 const (
-	ModemManagerInterface = "org.freedesktop.ModemManager1"
-
-	ModemManagerObjectPath     = "/org/freedesktop/ModemManager1"
-	modemManagerMainObjectPath = "/org/freedesktop/ModemManager/"
-
 	/* Methods */
 	ModemManagerScanDevices       = ModemManagerInterface + ".ScanDevices"
 	ModemManagerSetLogging        = ModemManagerInterface + ".SetLogging"
@@ -75,7 +70,7 @@ type ModemManager interface {
 // NewModemManager returns new ModemManager Interface
 func NewModemManager() (ModemManager, error) {
 	var mm modemManager
-	return &mm, mm.init(ModemManagerInterface, ModemManagerObjectPath)
+	return &mm, mm.init(ModemManagerBusName, ModemManagerObjectPath)
 }
 
 type modemManager struct {
@@ -102,7 +97,7 @@ func (ep EventProperties) MarshalJSON() ([]byte, error) {
 }
 
 func (mm modemManager) GetModems() (modems []Modem, err error) {
-	devPaths, err := mm.getManagedObjects(ModemManagerInterface, ModemManagerObjectPath)
+	devPaths, err := mm.getManagedObjects(ModemManagerBusName, ModemManagerObjectPath)
 	if err != nil {
 		return nil, err
 	}
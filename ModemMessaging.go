@@ -53,6 +53,9 @@ type ModemMessaging interface {
 	CreateSms(number string, text string, optionalParameters ...Pair) (Sms, error)
 	CreateMms(number string, data []byte, optionalParameters ...Pair) (Sms, error)
 
+	// Creates a new message object from typed properties, see SmsProperties.
+	CreateSmsWithProperties(properties SmsProperties) (Sms, error)
+
 	/* PROPERTIES */
 
 	// The list of SMS object paths.
@@ -86,10 +89,58 @@ type ModemMessaging interface {
 	Unsubscribe()
 }
 
+// SmsProperties are the properties of a message created with CreateSmsWithProperties.
+// Unlike the optional parameters of CreateSms, values keep their D-Bus types.
+type SmsProperties struct {
+	Number                string // Number to which the message is addressed. Mandatory.
+	Text                  string // Message text. Either Text or Data is mandatory.
+	Data                  []byte // Message data, for binary messages. Either Text or Data is mandatory.
+	Smsc                  string // SMSC address, the default SMSC is used if empty.
+	Validity              uint32 // Relative validity in minutes, the network default is used if 0.
+	Class                 *int32 // 3GPP message class (0 = flash), not set if nil.
+	DeliveryReportRequest bool   // Whether to request a delivery report.
+}
+
+// ToMap returns the properties as expected by the Create method of the Messaging interface.
+func (sp SmsProperties) ToMap() (map[string]interface{}, error) {
+	if sp.Number == "" {
+		return nil, errors.New("number is mandatory")
+	}
+	if sp.Text == "" && len(sp.Data) == 0 {
+		return nil, errors.New("either text or data is mandatory")
+	}
+	if sp.Text != "" && len(sp.Data) > 0 {
+		return nil, errors.New("text and data are mutually exclusive")
+	}
+	myMap := make(map[string]interface{})
+	myMap["number"] = sp.Number
+	if sp.Text != "" {
+		myMap["text"] = sp.Text
+	} else {
+		myMap["data"] = sp.Data
+	}
+	if sp.Smsc != "" {
+		myMap["smsc"] = sp.Smsc
+	}
+	if sp.Validity > 0 {
+		myMap["validity"] = struct {
+			Type  uint32
+			Value dbus.Variant
+		}{uint32(MmSmsValidityTypeRelative), dbus.MakeVariant(sp.Validity)}
+	}
+	if sp.Class != nil {
+		myMap["class"] = *sp.Class
+	}
+	if sp.DeliveryReportRequest {
+		myMap["delivery-report-request"] = true
+	}
+	return myMap, nil
+}
+
 // NewModemMessaging returns new ModemMessagingInterface
 func NewModemMessaging(objectPath dbus.ObjectPath) (ModemMessaging, error) {
 	var me modemMessaging
-	return &me, me.init(ModemManagerInterface, objectPath)
+	return &me, me.init(ModemManagerBusName, objectPath)
 }
 
 type modemMessaging struct {
@@ -144,6 +195,19 @@ func (me modemMessaging) CreateSms(number string, text string, optionalParameter
 	return singleSms, nil
 }
 
+func (me modemMessaging) CreateSmsWithProperties(properties SmsProperties) (Sms, error) {
+	myMap, err := properties.ToMap()
+	if err != nil {
+		return nil, err
+	}
+	var path dbus.ObjectPath
+	err = me.callWithReturn(&path, ModemMessagingCreate, &myMap)
+	if err != nil {
+		return nil, err
+	}
+	return NewSms(path)
+}
+
 func (me modemMessaging) CreateMms(number string, data []byte, optionalParameters ...Pair) (Sms, error) {
 	// todo: untested
 	type dynMap interface{}
@@ -200,14 +264,20 @@ func (me modemMessaging) GetDefaultStorage() (storage MMSmsStorage, err error) {
 	return MMSmsStorage(s), nil
 }
 
-func (me modemMessaging) SubscribeAdded() <-chan *dbus.Signal {
-	if me.sigChan != nil {
-		return me.sigChan
-	}
+func (me *modemMessaging) SubscribeAdded() <-chan *dbus.Signal {
 	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", ModemMessagingSignalAdded, fmt.Sprint(me.GetObjectPath()))
+	return me.subscribeRule(rule)
+}
+
+// subscribeRule adds a match rule and returns the signal channel of the
+// messaging interface. Both subscriptions share the channel, so the rule is
+// added even if the channel already exists.
+func (me *modemMessaging) subscribeRule(rule string) <-chan *dbus.Signal {
 	me.conn.BusObject().Call(dbusMethodAddMatch, 0, rule)
-	me.sigChan = make(chan *dbus.Signal, 10)
-	me.conn.Signal(me.sigChan)
+	if me.sigChan == nil {
+		me.sigChan = make(chan *dbus.Signal, 10)
+		me.conn.Signal(me.sigChan)
+	}
 	return me.sigChan
 }
 
@@ -234,18 +304,12 @@ func (me modemMessaging) ParseAdded(v *dbus.Signal) (sms Sms, received bool, err
 	return
 }
 
-func (me modemMessaging) SubscribeDeleted() <-chan *dbus.Signal {
-	if me.sigChan != nil {
-		return me.sigChan
-	}
+func (me *modemMessaging) SubscribeDeleted() <-chan *dbus.Signal {
 	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", ModemMessagingSignalDeleted, fmt.Sprint(me.GetObjectPath()))
-	me.conn.BusObject().Call(dbusMethodAddMatch, 0, rule)
-	me.sigChan = make(chan *dbus.Signal, 10)
-	me.conn.Signal(me.sigChan)
-	return me.sigChan
+	return me.subscribeRule(rule)
 }
 
-func (me modemMessaging) Unsubscribe() {
+func (me *modemMessaging) Unsubscribe() {
 	me.conn.RemoveSignal(me.sigChan)
 	me.sigChan = nil
 }
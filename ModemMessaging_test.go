@@ -0,0 +1,63 @@
+package modemmanager
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestSmsPropertiesToMap(t *testing.T) {
+	class := int32(0)
+	m, err := SmsProperties{
+		Number:                "+1234567890",
+		Text:                  "Hello",
+		Smsc:                  "+491710760000",
+		Validity:              60,
+		Class:                 &class,
+		DeliveryReportRequest: true,
+	}.ToMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m["number"] != "+1234567890" || m["text"] != "Hello" || m["smsc"] != "+491710760000" {
+		t.Errorf("unexpected string properties: %v", m)
+	}
+	if v, ok := m["class"].(int32); !ok || v != 0 {
+		t.Errorf("class = %#v, want int32(0)", m["class"])
+	}
+	if v, ok := m["delivery-report-request"].(bool); !ok || !v {
+		t.Errorf("delivery-report-request = %#v, want true", m["delivery-report-request"])
+	}
+	if sig := dbus.SignatureOf(m["validity"]).String(); sig != "(uv)" {
+		t.Errorf("validity signature = %s, want (uv)", sig)
+	}
+	if _, ok := m["data"]; ok {
+		t.Error("data set for a text message")
+	}
+}
+
+func TestSmsPropertiesToMapOmitsUnset(t *testing.T) {
+	m, err := SmsProperties{Number: "+1", Data: []byte{0x01}}.ToMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"text", "smsc", "validity", "class", "delivery-report-request"} {
+		if _, ok := m[key]; ok {
+			t.Errorf("%s set although not given", key)
+		}
+	}
+}
+
+func TestSmsPropertiesToMapValidation(t *testing.T) {
+	tests := []SmsProperties{
+		{Text: "no number"},
+		{Number: "+1"},
+		{Number: "+1", Text: "both", Data: []byte{0x01}},
+	}
+	for _, sp := range tests {
+		if _, err := sp.ToMap(); err == nil {
+			t.Errorf("ToMap(%+v) expected error", sp)
+		}
+	}
+}
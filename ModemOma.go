@@ -84,7 +84,7 @@ type ModemOma interface {
 // NewModemOma returns new ModemOma Interface
 func NewModemOma(objectPath dbus.ObjectPath) (ModemOma, error) {
 	var om modemOma
-	return &om, om.init(ModemManagerInterface, objectPath)
+	return &om, om.init(ModemManagerBusName, objectPath)
 }
 
 type modemOma struct {
@@ -150,14 +150,14 @@ func (om modemOma) GetPendingNetworkInitiatedSessions() (result []ModemOmaInitia
 	}
 	for _, e := range res {
 		var tmp ModemOmaInitiatedSession
-		sType, ok := e.GetLeft().(uint32)
-		if !ok {
-			return nil, errors.New("wrong type")
+		sType, err := decode[uint32](ModemOmaPropertyPendingNetworkInitiatedSessions+".type", e.GetLeft())
+		if err != nil {
+			return nil, err
 		}
 		tmp.SessionType = MMOmaSessionType(sType)
-		sId, ok := e.GetLeft().(uint32)
-		if !ok {
-			return nil, errors.New("wrong type")
+		sId, err := decode[uint32](ModemOmaPropertyPendingNetworkInitiatedSessions+".id", e.GetRight())
+		if err != nil {
+			return nil, err
 		}
 		tmp.SessionId = sId
 		result = append(result, tmp)
@@ -174,7 +174,7 @@ func (om modemOma) GetSessionType() (MMOmaSessionType, error) {
 }
 
 func (om modemOma) GetSessionState() (MMOmaSessionState, error) {
-	res, err := om.getUint32Property(ModemOmaPropertySessionState)
+	res, err := om.getInt32Property(ModemOmaPropertySessionState)
 	if err != nil {
 		return MmOmaSessionStateUnknown, err
 	}
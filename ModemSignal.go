@@ -63,7 +63,7 @@ type ModemSignal interface {
 // NewModemSignal returns new ModemSignal Interface
 func NewModemSignal(objectPath dbus.ObjectPath) (ModemSignal, error) {
 	var si modemSignal
-	return &si, si.init(ModemManagerInterface, objectPath)
+	return &si, si.init(ModemManagerBusName, objectPath)
 }
 
 type modemSignal struct {
@@ -118,7 +118,7 @@ func (ss SimpleStatus) MarshalJSON() ([]byte, error) {
 // NewModemSimple returns new ModemSimple Interface
 func NewModemSimple(objectPath dbus.ObjectPath) (ModemSimple, error) {
 	var ms modemSimple
-	return &ms, ms.init(ModemManagerInterface, objectPath)
+	return &ms, ms.init(ModemManagerBusName, objectPath)
 }
 
 type modemSimple struct {
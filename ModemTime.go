@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/godbus/dbus/v5"
+	"strconv"
 	"time"
 )
 
@@ -56,7 +57,7 @@ type ModemTime interface {
 // NewModemTime returns new ModemTime Interface
 func NewModemTime(objectPath dbus.ObjectPath) (ModemTime, error) {
 	var ti modemTime
-	return &ti, ti.init(ModemManagerInterface, objectPath)
+	return &ti, ti.init(ModemManagerBusName, objectPath)
 }
 
 type modemTime struct {
@@ -96,13 +97,61 @@ func (ti modemTime) GetNetworkTime() (time.Time, error) {
 	if err != nil {
 		return time.Now(), err
 	}
-	t, err := time.Parse(time.RFC3339Nano, tmpTime)
+	t, err := ParseNetworkTime(tmpTime)
 	if err != nil {
 		return time.Now(), err
 	}
 	return t, err
 }
 
+// networkTimeLayouts are the ISO 8601 variants reported by ModemManager
+var networkTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z07",
+	"2006-01-02T15:04:05",
+}
+
+// ParseNetworkTime parses a network time string as returned by GetNetworkTime or
+// the NetworkTimeChanged signal. Besides ISO 8601 it accepts the
+// "yy/MM/dd,hh:mm:ss±zz" format of AT+CCLK, used by older modems, where zz is the
+// UTC offset in quarters of an hour. Times without UTC offset are returned as UTC.
+func ParseNetworkTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("network time is unknown")
+	}
+	for _, layout := range networkTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return parseCclkTime(value)
+}
+
+func parseCclkTime(value string) (time.Time, error) {
+	// yy/MM/dd,hh:mm:ss±zz, optionally quoted
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+	const base = len("06/01/02,15:04:05")
+	if len(value) < base {
+		return time.Time{}, fmt.Errorf("unsupported network time format %q", value)
+	}
+	t, err := time.Parse("06/01/02,15:04:05", value[:base])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unsupported network time format %q", value)
+	}
+	zone := value[base:]
+	if zone == "" {
+		return t, nil
+	}
+	quarters, err := strconv.Atoi(zone)
+	if err != nil || (zone[0] != '+' && zone[0] != '-') || quarters < -96 || quarters > 96 {
+		return time.Time{}, fmt.Errorf("invalid timezone in network time %q", value)
+	}
+	offset := quarters * 15 * 60
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.FixedZone("", offset)), nil
+}
+
 func (ti modemTime) GetNetworkTimezone() (mTz ModemTimeZone, err error) {
 	tmpMap, err := ti.getMapStringVariantProperty(ModemTimePropertyNetworkTimezone)
 	if err != nil {
@@ -152,7 +201,7 @@ func (ti modemTime) ParseNetworkTimeChanged(v *dbus.Signal) (networkTime time.Ti
 		err = errors.New("error by parsing time string")
 		return
 	}
-	return time.Parse(time.RFC3339Nano, tmpTime)
+	return ParseNetworkTime(tmpTime)
 }
 
 func (ti modemTime) Unsubscribe() {
@@ -0,0 +1,40 @@
+package modemmanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNetworkTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-03-01T12:30:45+01:00", time.Date(2024, 3, 1, 11, 30, 45, 0, time.UTC)},
+		{"2024-03-01T12:30:45.250Z", time.Date(2024, 3, 1, 12, 30, 45, 250e6, time.UTC)},
+		{"2024-03-01T12:30:45+01", time.Date(2024, 3, 1, 11, 30, 45, 0, time.UTC)},
+		{"2024-03-01T12:30:45", time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)},
+		{"24/03/01,12:30:45+04", time.Date(2024, 3, 1, 11, 30, 45, 0, time.UTC)},
+		{"24/03/01,12:30:45-22", time.Date(2024, 3, 1, 18, 0, 45, 0, time.UTC)},
+		{`"24/03/01,12:30:45+00"`, time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)},
+		{"24/03/01,12:30:45", time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseNetworkTime(tt.in)
+		if err != nil {
+			t.Errorf("ParseNetworkTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseNetworkTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseNetworkTimeInvalid(t *testing.T) {
+	for _, in := range []string{"", "yesterday", "24/03/01", "24/03/01,12:30:45+x4", "24/03/01,12:30:45+99", "24/13/01,12:30:45+00"} {
+		if _, err := ParseNetworkTime(in); err == nil {
+			t.Errorf("ParseNetworkTime(%q) succeeded, want error", in)
+		}
+	}
+}
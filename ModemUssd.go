@@ -68,7 +68,7 @@ type Ussd interface {
 // NewUssd returns new ModemUssd Interface
 func NewUssd(objectPath dbus.ObjectPath) (Ussd, error) {
 	var mu ussd
-	return &mu, mu.init(ModemManagerInterface, objectPath)
+	return &mu, mu.init(ModemManagerBusName, objectPath)
 }
 
 type ussd struct {
@@ -108,6 +108,9 @@ type ModemVoice interface {
 
 	ParseCallAdded(v *dbus.Signal) (Call, error)
 
+	// ParseCallDeleted returns the object path of the deleted call
+	ParseCallDeleted(v *dbus.Signal) (dbus.ObjectPath, error)
+
 	Unsubscribe()
 }
 
@@ -115,7 +118,7 @@ type ModemVoice interface {
 func NewModemVoice(objectPath dbus.ObjectPath, modem modem) (ModemVoice, error) {
 	var vo modemVoice
 	vo.modem = modem
-	return &vo, vo.init(ModemManagerInterface, objectPath)
+	return &vo, vo.init(ModemManagerBusName, objectPath)
 }
 
 type modemVoice struct {
@@ -212,24 +215,22 @@ func (m modemVoice) GetEmergencyOnly() (bool, error) {
 	return m.getBoolProperty(ModemVoicePropertyEmergencyOnly)
 }
 
-func (m modemVoice) SubscribeCallAdded() <-chan *dbus.Signal {
-	if m.sigChan != nil {
-		return m.sigChan
-	}
-	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", ModemVoiceSignalCallAdded, fmt.Sprint(m.modem.GetObjectPath()))
-	m.conn.BusObject().Call(dbusMethodAddMatch, 0, rule)
-	m.sigChan = make(chan *dbus.Signal, 10)
-	m.conn.Signal(m.sigChan)
-	return m.sigChan
+func (m *modemVoice) SubscribeCallAdded() <-chan *dbus.Signal {
+	return m.subscribeSignal(ModemVoiceSignalCallAdded)
 }
-func (m modemVoice) SubscribeCallDeleted() <-chan *dbus.Signal {
-	if m.sigChan != nil {
-		return m.sigChan
-	}
-	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", ModemVoiceSignalCallDeleted, fmt.Sprint(m.modem.GetObjectPath()))
+
+func (m *modemVoice) SubscribeCallDeleted() <-chan *dbus.Signal {
+	return m.subscribeSignal(ModemVoiceSignalCallDeleted)
+}
+
+// subscribeSignal adds a match rule for member. All voice signals share one channel.
+func (m *modemVoice) subscribeSignal(member string) <-chan *dbus.Signal {
+	rule := fmt.Sprintf("type='signal', member='%s',path_namespace='%s'", member, fmt.Sprint(m.modem.GetObjectPath()))
 	m.conn.BusObject().Call(dbusMethodAddMatch, 0, rule)
-	m.sigChan = make(chan *dbus.Signal, 10)
-	m.conn.Signal(m.sigChan)
+	if m.sigChan == nil {
+		m.sigChan = make(chan *dbus.Signal, 10)
+		m.conn.Signal(m.sigChan)
+	}
 	return m.sigChan
 }
 
@@ -252,7 +253,21 @@ func (m modemVoice) ParseCallAdded(v *dbus.Signal) (call Call, err error) {
 	return NewCall(path)
 }
 
-func (m modemVoice) Unsubscribe() {
+func (m modemVoice) ParseCallDeleted(v *dbus.Signal) (path dbus.ObjectPath, err error) {
+	if strings.Contains(v.Name, ModemVoiceSignalCallDeleted) == false {
+		return "", errors.New("error by parsing calldeleted signal")
+	}
+	if len(v.Body) != 1 {
+		return "", errors.New("error by parsing calldeleted signal")
+	}
+	path, ok := v.Body[0].(dbus.ObjectPath)
+	if !ok {
+		return "", errors.New("error by parsing object path")
+	}
+	return path, nil
+}
+
+func (m *modemVoice) Unsubscribe() {
 	m.conn.RemoveSignal(m.sigChan)
 	m.sigChan = nil
 }
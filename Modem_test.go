@@ -0,0 +1,194 @@
+package modemmanager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// tuple builds a (uu) value the way godbus decodes it from a variant
+func tuple(allowed, preferred uint32) []interface{} {
+	return []interface{}{allowed, preferred}
+}
+
+func TestDecodeSupportedModes(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    []string
+	}{
+		{
+			// Quectel EC25: 2G/3G/4G with each technology preferable
+			name: "EC25",
+			payload: [][]interface{}{
+				tuple(0x02, 0), tuple(0x04, 0), tuple(0x08, 0),
+				tuple(0x06, 0x04), tuple(0x06, 0x02),
+				tuple(0x0a, 0x08), tuple(0x0a, 0x02),
+				tuple(0x0c, 0x08), tuple(0x0c, 0x04),
+				tuple(0x0e, 0x08), tuple(0x0e, 0x04), tuple(0x0e, 0x02),
+			},
+			want: []string{
+				"allowed: 2g; preferred: None",
+				"allowed: 3g; preferred: None",
+				"allowed: 4g; preferred: None",
+				"allowed: 2g, 3g; preferred: 3g",
+				"allowed: 2g, 3g; preferred: 2g",
+				"allowed: 2g, 4g; preferred: 4g",
+				"allowed: 2g, 4g; preferred: 2g",
+				"allowed: 3g, 4g; preferred: 4g",
+				"allowed: 3g, 4g; preferred: 3g",
+				"allowed: 2g, 3g, 4g; preferred: 4g",
+				"allowed: 2g, 3g, 4g; preferred: 3g",
+				"allowed: 2g, 3g, 4g; preferred: 2g",
+			},
+		},
+		{
+			// Sierra Wireless EM7455: 3G/4G only, generic []interface{} array
+			name: "EM7455",
+			payload: []interface{}{
+				tuple(0x04, 0), tuple(0x08, 0),
+				tuple(0x0c, 0), tuple(0x0c, 0x08), tuple(0x0c, 0x04),
+			},
+			want: []string{
+				"allowed: 3g; preferred: None",
+				"allowed: 4g; preferred: None",
+				"allowed: 3g, 4g; preferred: None",
+				"allowed: 3g, 4g; preferred: 4g",
+				"allowed: 3g, 4g; preferred: 3g",
+			},
+		},
+		{
+			name:    "POTS",
+			payload: [][]interface{}{tuple(0xffffffff, 0)},
+			want:    []string{"allowed: Any; preferred: None"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modes, err := decodeModes(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(modes))
+			for i, mode := range modes {
+				got[i] = mode.String()
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded modes:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCurrentModes(t *testing.T) {
+	mode, err := decodeMode(tuple(0x0c, 0x08))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode.Allowed != NewModeMask(MmModemMode3g, MmModemMode4g) || mode.Preferred != MmModemMode4g {
+		t.Errorf("decodeMode = %+v", mode)
+	}
+	if !mode.Allowed.Has(MmModemMode4g) || mode.Allowed.Has(MmModemMode2g) || mode.Allowed.Has(MmModemModeNone) {
+		t.Errorf("Has() on %v gives wrong results", mode.Allowed)
+	}
+}
+
+func TestDecodeModeInvalid(t *testing.T) {
+	for _, payload := range []interface{}{
+		nil,
+		uint32(4),
+		[]interface{}{uint32(4)},
+		[]interface{}{int32(4), uint32(0)},
+		[]interface{}{uint32(4), "4g"},
+	} {
+		if _, err := decodeMode(payload); err == nil {
+			t.Errorf("decodeMode(%#v) succeeded, want error", payload)
+		}
+	}
+	if _, err := decodeModes([]uint32{4, 0}); err == nil {
+		t.Error("decodeModes accepted an au array")
+	}
+}
+
+func TestModeDBusEncoding(t *testing.T) {
+	mode := Mode{Allowed: NewModeMask(MmModemMode3g, MmModemMode4g), Preferred: MmModemMode4g}
+	value := mode.toDBus()
+	if sig := dbus.SignatureOf(value).String(); sig != "(uu)" {
+		t.Errorf("SetCurrentModes argument signature = %s, want (uu)", sig)
+	}
+	if value.Allowed != 0x0c || value.Preferred != 0x08 {
+		t.Errorf("encoded mode = %+v, want {12 8}", value)
+	}
+}
+
+func TestModeMaskStrings(t *testing.T) {
+	if got := NewModeMask(MmModemMode4g, MmModemMode2g).Strings(); !reflect.DeepEqual(got, []string{"2g", "4g"}) {
+		t.Errorf("Strings() = %v", got)
+	}
+	if got := ModeMask(MmModemModeAny).Strings(); !reflect.DeepEqual(got, []string{"Any"}) {
+		t.Errorf("Strings() of Any = %v", got)
+	}
+	if got := ModeMask(0).String(); got != "None" {
+		t.Errorf("String() of empty mask = %q", got)
+	}
+}
+
+// bearerGetter and bearerLister enumerate bearers through only one of the
+// Bearers property and the ListBearers method
+type bearerGetter struct {
+	bearers []Bearer
+	err     error
+}
+
+func (g bearerGetter) GetBearers() ([]Bearer, error) { return g.bearers, g.err }
+
+type bearerLister struct {
+	bearers []Bearer
+	err     error
+}
+
+func (l bearerLister) ListBearers() ([]Bearer, error) { return l.bearers, l.err }
+
+// bearerGetterLister offers both, the property failing
+type bearerGetterLister struct {
+	bearerGetter
+	bearerLister
+}
+
+func TestListModemBearers(t *testing.T) {
+	one := []Bearer{&bearer{}}
+	unknownMethod := dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}
+	unknownProperty := dbus.Error{Name: "org.freedesktop.DBus.Error.InvalidArgs"}
+
+	tests := []struct {
+		name    string
+		modem   interface{}
+		want    int
+		wantErr error
+	}{
+		{"property only", bearerGetter{bearers: one}, 1, nil},
+		{"property error", bearerGetter{err: unknownProperty}, 0, unknownProperty},
+		{"method only", bearerLister{bearers: one}, 1, nil},
+		{"method error", bearerLister{err: unknownMethod}, 0, unknownMethod},
+		{"fallback to method", bearerGetterLister{bearerGetter{err: unknownProperty}, bearerLister{bearers: one}}, 1, nil},
+		{"both fail", bearerGetterLister{bearerGetter{err: unknownProperty}, bearerLister{err: unknownMethod}}, 0, unknownProperty},
+		{"no bearers is not an error", bearerGetter{}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bearers, err := ListModemBearers(tt.modem)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(bearers) != tt.want {
+				t.Errorf("got %d bearers, want %d", len(bearers), tt.want)
+			}
+		})
+	}
+
+	if _, err := ListModemBearers(struct{}{}); err == nil {
+		t.Error("ListModemBearers succeeded on a value without either method")
+	}
+}
@@ -36,7 +36,9 @@ type Sim interface {
 	SendPin(pin string) error
 
 	// Send the PUK and a new PIN to unlock the SIM card.
-	SendPuk(pin string, puk string) error
+	//		IN s puk: A string containing the PUK code.
+	//		IN s pin: A string containing the new PIN code.
+	SendPuk(puk string, pin string) error
 
 	// Enable or disable the PIN checking.
 	//		IN s pin: A string containing the PIN code.
@@ -86,7 +88,7 @@ type Sim interface {
 // NewSim returns new Sim Interface
 func NewSim(objectPath dbus.ObjectPath) (Sim, error) {
 	var sm sim
-	return &sm, sm.init(ModemManagerInterface, objectPath)
+	return &sm, sm.init(ModemManagerBusName, objectPath)
 }
 
 type sim struct {
@@ -101,8 +103,8 @@ func (sm sim) SendPin(pin string) error {
 	return sm.call(SimSendPin, &pin)
 }
 
-func (sm sim) SendPuk(pin string, puk string) error {
-	return sm.call(SimSendSendPuk, &pin, &puk)
+func (sm sim) SendPuk(puk string, pin string) error {
+	return sm.call(SimSendSendPuk, &puk, &pin)
 }
 
 func (sm sim) EnablePin(pin string, enable bool) error {
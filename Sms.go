@@ -139,7 +139,7 @@ type Sms interface {
 // NewSms returns new Sms Interface
 func NewSms(objectPath dbus.ObjectPath) (Sms, error) {
 	var ss sms
-	return &ss, ss.init(ModemManagerInterface, objectPath)
+	return &ss, ss.init(ModemManagerBusName, objectPath)
 }
 
 type sms struct {
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/maltegrosse/go-modemmanager/exporter"
+	"go.yaml.in/yaml/v2"
+)
+
+// fileConfig is the structure of -config-file. Each key sets the flag of the
+// same meaning, so it is validated like the flag.
+type fileConfig struct {
+	ListenAddresses     []string          `yaml:"listen_addresses"`
+	MetricsPath         string            `yaml:"metrics_path"`
+	SignalRate          string            `yaml:"signal_rate"`
+	SignalRateOverrides map[string]string `yaml:"signal_rate_overrides"` // by equipment or device identifier
+	Collectors          map[string]bool   `yaml:"collectors"`
+	ModemIdentifier     string            `yaml:"modem_identifier"`
+	CollectionMode      string            `yaml:"collection_mode"`
+}
+
+// collectorFlags are the flags set by the collectors key of the config file
+var collectorFlags = map[string]string{
+	"access_technologies": "collect-access-technologies",
+	"bands":               "collect-bands",
+	"own_numbers":         "collect-own-numbers",
+}
+
+// configSetting is a config file key and the flag values it stands for
+type configSetting struct {
+	key    string
+	flag   string
+	values []string
+	check  func(string) error // validation beyond the flag's own parsing, may be nil
+}
+
+// applyConfigFile sets the flags of fs from the YAML config file at path.
+// Flags given on the command line take precedence; a repeatable flag given
+// there replaces the list of the file. Errors name the offending key.
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var config fileConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	settings, err := config.settings()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for _, setting := range settings {
+		for _, value := range setting.values {
+			if setting.check != nil {
+				if err := setting.check(value); err != nil {
+					return fmt.Errorf("%s: %s: %w", path, setting.key, err)
+				}
+			}
+			if given[setting.flag] {
+				continue
+			}
+			if err := fs.Set(setting.flag, value); err != nil {
+				return fmt.Errorf("%s: %s: %w", path, setting.key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// settings returns the keys present in the config, in a fixed order
+func (c fileConfig) settings() ([]configSetting, error) {
+	var settings []configSetting
+	add := func(key, flag string, check func(string) error, values ...string) {
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			return
+		}
+		settings = append(settings, configSetting{key: key, flag: flag, values: values, check: check})
+	}
+
+	add("listen_addresses", "listen-address", nil, c.ListenAddresses...)
+	add("metrics_path", "metrics-path", checkMetricsPath, c.MetricsPath)
+	add("signal_rate", "signal-rate", nil, c.SignalRate)
+
+	for _, id := range slices.Sorted(maps.Keys(c.SignalRateOverrides)) {
+		add("signal_rate_overrides."+id, "signal-rate-override", nil, id+"="+c.SignalRateOverrides[id])
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Collectors)) {
+		flag, ok := collectorFlags[name]
+		if !ok {
+			known := slices.Sorted(maps.Keys(collectorFlags))
+			return nil, fmt.Errorf("collectors.%s: unknown collector, expected one of %s", name, strings.Join(known, ", "))
+		}
+		add("collectors."+name, flag, nil, strconv.FormatBool(c.Collectors[name]))
+	}
+
+	add("modem_identifier", "modem-identifier", func(s string) error {
+		_, err := exporter.ParseModemIdentifier(s)
+		return err
+	}, c.ModemIdentifier)
+	add("collection_mode", "collection-mode", func(s string) error {
+		_, err := exporter.ParseCollectionMode(s)
+		return err
+	}, c.CollectionMode)
+	return settings, nil
+}
+
+func checkMetricsPath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return errors.New("must start with /")
+	}
+	return nil
+}
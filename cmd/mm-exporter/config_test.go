@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFlags are the flags the config file sets, on their own flag set
+type testFlags struct {
+	fs          *flag.FlagSet
+	listen      listenAddressList
+	overrides   signalRateOverrides
+	metricsPath *string
+	signalRate  *time.Duration
+	bands       *bool
+	ownNumbers  *bool
+	identifier  *string
+	collection  *string
+}
+
+func newTestFlags(t *testing.T, args ...string) *testFlags {
+	t.Helper()
+	f := &testFlags{fs: flag.NewFlagSet("test", flag.ContinueOnError), overrides: signalRateOverrides{}}
+	f.fs.SetOutput(io.Discard)
+	f.fs.Var(&f.listen, "listen-address", "")
+	f.fs.Var(f.overrides, "signal-rate-override", "")
+	f.metricsPath = f.fs.String("metrics-path", "/metrics", "")
+	f.signalRate = f.fs.Duration("signal-rate", 5*time.Second, "")
+	f.bands = f.fs.Bool("collect-bands", false, "")
+	f.fs.Bool("collect-access-technologies", true, "")
+	f.ownNumbers = f.fs.Bool("collect-own-numbers", true, "")
+	f.identifier = f.fs.String("modem-identifier", "device-id", "")
+	f.collection = f.fs.String("collection-mode", "poll", "")
+	if err := f.fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func writeConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mm-exporter.yml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	path := writeConfig(t, `
+listen_addresses: [":9539", "unix:///run/mm-exporter.sock"]
+metrics_path: /modems
+signal_rate: 10s
+signal_rate_overrides:
+  "860000000000000": 1s
+collectors:
+  bands: true
+  own_numbers: false
+modem_identifier: imei
+collection_mode: events
+`)
+
+	f := newTestFlags(t, "-signal-rate=2s", "-modem-identifier=imsi")
+	if err := applyConfigFile(f.fs, path); err != nil {
+		t.Fatalf("applyConfigFile() error = %v", err)
+	}
+
+	if got := f.listen.String(); got != ":9539,unix:///run/mm-exporter.sock" {
+		t.Errorf("listen addresses = %q", got)
+	}
+	if *f.metricsPath != "/modems" || *f.collection != "events" || !*f.bands || *f.ownNumbers {
+		t.Errorf("file values not applied: metrics path %q, collection %q, bands %v, own numbers %v", *f.metricsPath, *f.collection, *f.bands, *f.ownNumbers)
+	}
+	if got := f.overrides.String(); got != "860000000000000=1s" {
+		t.Errorf("signal rate overrides = %q", got)
+	}
+
+	// The command line takes precedence
+	if *f.signalRate != 2*time.Second {
+		t.Errorf("signal rate = %v, want 2s from the command line", *f.signalRate)
+	}
+	if *f.identifier != "imsi" {
+		t.Errorf("modem identifier = %q, want imsi from the command line", *f.identifier)
+	}
+}
+
+func TestApplyConfigFileRepeatableFlagReplaced(t *testing.T) {
+	path := writeConfig(t, "listen_addresses: [\":9539\", \":9540\"]\n")
+	f := newTestFlags(t, "-listen-address=127.0.0.1:9539")
+	if err := applyConfigFile(f.fs, path); err != nil {
+		t.Fatalf("applyConfigFile() error = %v", err)
+	}
+	if got := f.listen.String(); got != "127.0.0.1:9539" {
+		t.Errorf("listen addresses = %q, want only the command line", got)
+	}
+}
+
+func TestApplyConfigFileInvalid(t *testing.T) {
+	tests := []struct {
+		config string
+		want   string
+	}{
+		{"signal_rat: 5s\n", "line 1: field signal_rat not found"},
+		{"signal_rate: fast\n", "signal_rate: parse error"},
+		{"signal_rate_overrides:\n  dev: soon\n", "signal_rate_overrides.dev:"},
+		{"collectors:\n  sms: true\n", "collectors.sms: unknown collector, expected one of access_technologies, bands, own_numbers"},
+		{"collectors:\n  bands: maybe\n", "line 2: cannot unmarshal"},
+		{"metrics_path: metrics\n", "metrics_path: must start with /"},
+		{"modem_identifier: serial\n", `modem_identifier: unknown modem identifier "serial"`},
+		{"collection_mode: push\n", `collection_mode: unknown collection mode "push"`},
+	}
+
+	for _, tt := range tests {
+		path := writeConfig(t, tt.config)
+		err := applyConfigFile(newTestFlags(t).fs, path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("config %q: error = %v, want %q", tt.config, err, tt.want)
+		}
+		if err != nil && !strings.HasPrefix(err.Error(), path+": ") {
+			t.Errorf("config %q: error %q does not name the file", tt.config, err)
+		}
+	}
+
+	// Values given on the command line do not excuse invalid file values
+	path := writeConfig(t, "modem_identifier: serial\n")
+	if err := applyConfigFile(newTestFlags(t, "-modem-identifier=imei").fs, path); err == nil {
+		t.Error("invalid modem_identifier accepted while overridden on the command line")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager/exporter"
+)
+
+// healthTimeout bounds the ModemManager call of /health and /ready
+const healthTimeout = 2 * time.Second
+
+// healthStatus is the JSON body of /health and /ready
+type healthStatus struct {
+	Status string `json:"status"` // "ok" or "unavailable"
+	Error  string `json:"error,omitempty"`
+}
+
+// healthHandler serves /health, or /ready with ready. It answers 200 if
+// ModemManager answers within healthTimeout and, for /ready, a scrape read
+// the modems since startup, and 503 with the failure otherwise.
+func healthHandler(mmExporter *exporter.Exporter, ready bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := healthStatus{Status: "ok"}
+		ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+		err := mmExporter.CheckHealth(ctx)
+		cancel()
+		switch {
+		case err != nil:
+			status = healthStatus{Status: "unavailable", Error: "ModemManager: " + err.Error()}
+		case ready && !mmExporter.Ready():
+			status = healthStatus{Status: "unavailable", Error: "no successful scrape yet"}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if status.Error != "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			logger.Debug("Writing the health status failed", "err", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager/exporter"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHealthAndReady(t *testing.T) {
+	manager := mocks.NewMockModemManager()
+	mmExporter := exporter.NewExporter(exporter.ModemManagerSource{Manager: manager})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(mmExporter)
+
+	check := func(step string, ready bool, wantCode int, want healthStatus) {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		healthHandler(mmExporter, ready).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		var got healthStatus
+		if err := json.NewDecoder(recorder.Body).Decode(&got); err != nil {
+			t.Fatalf("%s: decoding the body: %v", step, err)
+		}
+		if recorder.Code != wantCode || got != want {
+			t.Errorf("%s: ready %v answered %d %+v, want %d %+v", step, ready, recorder.Code, got, wantCode, want)
+		}
+	}
+	ok := healthStatus{Status: "ok"}
+	down := healthStatus{Status: "unavailable", Error: "ModemManager: dbus: connection closed"}
+
+	check("healthy before the first scrape", false, http.StatusOK, ok)
+	check("healthy before the first scrape", true, http.StatusServiceUnavailable, healthStatus{Status: "unavailable", Error: "no successful scrape yet"})
+
+	manager.GetVersionError = errors.New("dbus: connection closed")
+	registry.Gather()
+	check("failing", false, http.StatusServiceUnavailable, down)
+	check("failing", true, http.StatusServiceUnavailable, down)
+
+	manager.GetVersionError = nil
+	registry.Gather()
+	check("healthy after a scrape", false, http.StatusOK, ok)
+	check("healthy after a scrape", true, http.StatusOK, ok)
+
+	manager.GetVersionError = errors.New("dbus: connection closed")
+	check("failing after a scrape", false, http.StatusServiceUnavailable, down)
+	check("failing after a scrape", true, http.StatusServiceUnavailable, down)
+}
+
+func TestHealthWithoutConnection(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	healthHandler(exporter.NewExporter(nil), false).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 while not connected", recorder.Code)
+	}
+}
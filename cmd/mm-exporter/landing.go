@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager/exporter"
+)
+
+// landingPageTimeout bounds the ModemManager calls of the landing page, so
+// that a hanging modem delays it by at most this long
+const landingPageTimeout = 2 * time.Second
+
+// landingPage is rendered with html/template, which escapes the modem
+// values; operator names are broadcast by the network and not trusted.
+var landingPage = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>ModemManager Exporter</title>
+	<style>
+		body { font-family: Arial, sans-serif; margin: 40px; }
+		h1 { color: #333; }
+		.info { background: #f0f0f0; padding: 15px; border-radius: 5px; }
+		.links { margin-top: 20px; }
+		a { color: #0066cc; text-decoration: none; }
+		a:hover { text-decoration: underline; }
+		table { border-collapse: collapse; margin-top: 20px; }
+		th, td { border: 1px solid #ccc; padding: 5px 10px; text-align: left; }
+	</style>
+</head>
+<body>
+	<h1>ModemManager Exporter</h1>
+	<div class="info">
+		<p><strong>Version:</strong> {{.Version}}</p>
+		<p><strong>ModemManager Version:</strong> {{.MMVersion}}</p>
+		<p><strong>Signal Refresh Rate:</strong> {{.SignalRate}}</p>
+	</div>
+{{- if .Modems}}
+	<table>
+		<tr><th>Modem</th><th>State</th><th>Operator</th><th>Signal</th><th>Signal Monitoring</th></tr>
+{{- range .Modems}}
+		<tr><td>{{.DeviceID}}</td><td>{{.State}}</td><td>{{.Operator}}</td><td>{{if .SignalValid}}{{.SignalQuality}}%{{end}}</td><td>{{.SignalSetup}}</td></tr>
+{{- end}}
+	</table>
+{{- end}}
+	<div class="links">
+		<p><a href="{{.MetricsPath}}">Metrics</a></p>
+	</div>
+</body>
+</html>
+`))
+
+// landingPageData is the data of landingPage. Without Modems the page only
+// shows the exporter information.
+type landingPageData struct {
+	Version     string
+	MMVersion   string
+	SignalRate  time.Duration
+	MetricsPath string
+	Modems      []exporter.ModemOverview
+}
+
+// landingPageHandler serves landingPage. With modems it lists the modems of
+// mmExporter, read on every request, unless ModemManager is not connected or
+// does not answer within landingPageTimeout.
+func landingPageHandler(mmExporter *exporter.Exporter, conn *connection, modems bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page := landingPageData{
+			Version:     build.String(),
+			SignalRate:  *signalRate,
+			MetricsPath: *metricsPath,
+		}
+		mmVersion, ok := conn.get()
+		page.MMVersion = mmVersion
+		if !ok {
+			page.MMVersion = "not connected"
+		}
+		if ok && modems {
+			ctx, cancel := context.WithTimeout(r.Context(), landingPageTimeout)
+			overview, err := mmExporter.Overview(ctx)
+			cancel()
+			if err != nil {
+				logger.Debug("Listing modems for the landing page failed", "err", err)
+			}
+			page.Modems = overview
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := landingPage.Execute(w, page); err != nil {
+			logger.Debug("Writing the landing page failed", "err", err)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager/exporter"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestLandingPage(t *testing.T) {
+	manager := mocks.NewMockModemManager()
+	modem := manager.ModemsValue[0].(*mocks.MockModem)
+	modem3gpp := mocks.NewMockModem3gpp()
+	modem3gpp.OperatorNameValue = `<script>alert("pwned")</script>`
+	modem.Modem3gppValue = modem3gpp
+	mmExporter := exporter.NewExporter(exporter.ModemManagerSource{Manager: manager})
+
+	render := func(conn *connection, modems bool) string {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		landingPageHandler(mmExporter, conn, modems).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", recorder.Code)
+		}
+		return recorder.Body.String()
+	}
+
+	var connected connection
+	connected.set("1.22.0")
+	page := render(&connected, true)
+	for _, want := range []string{
+		"<td>mock-0000</td>",
+		"<td>75%</td>",
+		"&lt;script&gt;alert(&#34;pwned&#34;)&lt;/script&gt;",
+		"<strong>ModemManager Version:</strong> 1.22.0",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<script>") {
+		t.Errorf("operator name not escaped:\n%s", page)
+	}
+
+	// Static page without a connection and with the modem list disabled
+	for name, page := range map[string]string{
+		"not connected": render(&connection{}, true),
+		"disabled":      render(&connected, false),
+	} {
+		if strings.Contains(page, "<table>") {
+			t.Errorf("%s: page lists modems:\n%s", name, page)
+		}
+	}
+}
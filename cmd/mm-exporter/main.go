@@ -1,67 +1,193 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/maltegrosse/go-modemmanager"
 	"github.com/maltegrosse/go-modemmanager/exporter"
+	"github.com/maltegrosse/go-modemmanager/internal/buildinfo"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-const (
-	version = "1.0.0"
-)
+// logRepeatInterval is how often an identical message is logged at most
+const logRepeatInterval = 5 * time.Minute
 
 var (
-	listenAddress = flag.String("listen-address", ":9539", "Address on which to expose metrics and web interface")
-	metricsPath   = flag.String("metrics-path", "/metrics", "Path under which to expose metrics")
-	signalRate    = flag.Duration("signal-rate", 5*time.Second, "How frequently ModemManager should poll each modem for extended signal strength data (0 to disable)")
-	showVersion   = flag.Bool("version", false, "Show version information and exit")
+	socketMode   = flag.String("unix-socket-mode", "0660", "Permissions of unix sockets given with -listen-address, in octal")
+	metricsPath  = flag.String("metrics-path", "/metrics", "Path under which to expose metrics")
+	signalRate   = flag.Duration("signal-rate", 5*time.Second, "How frequently ModemManager should poll each modem for extended signal strength data (0 to disable)")
+	failedGrace  = flag.Duration("failed-modem-grace", 0, "Reduce modems failed for longer than this to a minimal metric set (0 to disable)")
+	bandMetrics  = flag.Bool("collect-bands", false, "Export per-band metrics (current bands can add 40+ series per modem)")
+	accessTechs  = flag.Bool("collect-access-technologies", true, "Export modem_access_technology_active for every known access technology (15 series per modem)")
+	ownNumbers   = flag.Bool("collect-own-numbers", true, "Export the phone numbers (MSISDN) of the SIMs; set to false where they are considered sensitive")
+	bearerPaths  = flag.Bool("bearer-path-labels", false, "Label bearer metrics with the bearer object path, which changes on every reconnect, instead of APN and IP type")
+	timestamps   = flag.Bool("emit-timestamps", false, "Attach the time each modem was read to its metrics (see README before enabling)")
+	collection   = flag.String("collection-mode", "poll", "How modems are read: poll on every scrape, or events to serve snapshots refreshed on property changes")
+	identifier   = flag.String("modem-identifier", "device-id", "Value of the device_id label: device-id, imei, imsi or equipment-id (falls back to device-id when unreadable)")
+	precision    = flag.String("location-precision", "full", "GPS position to export: full, rounded to -location-decimals, or presence-only for just the fix status")
+	decimals     = flag.Int("location-decimals", 2, "Decimal places of latitude and longitude kept with -location-precision=rounded (2 is about 1 km)")
+	refresh      = flag.Duration("snapshot-refresh", time.Minute, "With -collection-mode=events, re-read each modem at least this often (0 for property changes only)")
+	disableGzip  = flag.Bool("disable-compression", false, "Disable gzip compression of metrics responses")
+	showVersion  = flag.Bool("version", false, "Show version information and exit")
+	once         = flag.Bool("once", false, "Collect once, write the metrics to -output-file and exit instead of serving HTTP")
+	outputFile   = flag.String("output-file", "", "File to write the metrics to with -once or -interval, e.g. for the node_exporter textfile collector")
+	interval     = flag.Duration("interval", 0, "Rewrite -output-file this often instead of serving HTTP (0 to disable)")
+	startupWait  = flag.Duration("startup-timeout", 0, "How long to retry connecting to ModemManager at startup before exiting (0 to retry forever)")
+	tlsCert      = flag.String("tls-cert", "", "Certificate file to serve HTTPS with, together with -tls-key")
+	tlsKey       = flag.String("tls-key", "", "Private key file of -tls-cert")
+	authUser     = flag.String("basic-auth-user", "", "User required with basic auth for every endpoint but /health, together with -basic-auth-password-file")
+	authPassword = flag.String("basic-auth-password-file", "", "File holding the password of -basic-auth-user")
+	logLevel     = flag.String("log.level", "info", "Minimum level of logged messages: debug, info, warn or error")
+	logFormat    = flag.String("log.format", "text", "Format of logged messages: text or json")
+	drainTimeout = flag.Duration("shutdown-timeout", 10*time.Second, "How long to wait for in-flight requests on SIGINT or SIGTERM before closing their connections")
+	pageModems   = flag.Bool("landing-page-modems", true, "List the modems with their state, operator and signal on the landing page, read from ModemManager on every visit")
+	configFile   = flag.String("config-file", "", "YAML file with settings; flags given on the command line take precedence, see README")
+	checkConfig  = flag.Bool("check-config", false, "Validate the flags and -config-file, then exit")
+	debugPprof   = flag.Bool("debug.pprof", false, "Serve the Go runtime profiles under /debug/pprof/")
+	debugModems  = flag.Bool("debug.modems", false, "Serve the state the exporter keeps about each modem as JSON under /debug/modems")
+
+	rateOverrides   = signalRateOverrides{}
+	listenAddresses listenAddressList
+
+	logger = slog.Default()
+	build  = buildinfo.Get()
 )
 
+func init() {
+	flag.Var(&listenAddresses, "listen-address", "Address on which to expose metrics and web interface, host:port or unix:///path/to.sock (repeatable, default :9539)")
+	flag.Var(rateOverrides, "signal-rate-override", "Signal refresh rate of a single modem as <equipment-id-or-device-id>=<duration>, overriding -signal-rate (repeatable)")
+}
+
+// listenAddressList collects repeated -listen-address flags
+type listenAddressList []string
+
+func (l *listenAddressList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listenAddressList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// signalRateOverrides collects repeated -signal-rate-override flags
+type signalRateOverrides map[string]time.Duration
+
+func (o signalRateOverrides) String() string {
+	overrides := make([]string, 0, len(o))
+	for id, rate := range o {
+		overrides = append(overrides, id+"="+rate.String())
+	}
+	sort.Strings(overrides)
+	return strings.Join(overrides, ",")
+}
+
+func (o signalRateOverrides) Set(s string) error {
+	id, rate, err := exporter.ParseSignalRateOverride(s)
+	if err != nil {
+		return err
+	}
+	o[id] = rate
+	return nil
+}
+
 func main() {
 	flag.Parse()
 
 	if *showVersion {
-		fmt.Printf("mm-exporter version %s\n", version)
+		fmt.Printf("mm-exporter version %s\n", build)
 		os.Exit(0)
 	}
 
-	log.Printf("Starting ModemManager Exporter v%s", version)
-	log.Printf("Listening on %s", *listenAddress)
-	log.Printf("Metrics path: %s", *metricsPath)
-	log.Printf("Signal refresh rate: %s", *signalRate)
+	if *configFile != "" {
+		if err := applyConfigFile(flag.CommandLine, *configFile); err != nil {
+			log.Fatalf("Invalid -config-file: %v", err)
+		}
+	}
+	if len(listenAddresses) == 0 {
+		listenAddresses = listenAddressList{":9539"}
+	}
+	mode, err := strconv.ParseUint(*socketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		log.Fatalf("Invalid -unix-socket-mode %q, want octal permissions such as 0660", *socketMode)
+	}
 
-	// Connect to ModemManager
-	mm, err := modemmanager.NewModemManager()
-	if err != nil {
-		log.Fatalf("Failed to connect to ModemManager: %v", err)
+	if logger, err = newLogger(os.Stderr, *logLevel, *logFormat); err != nil {
+		log.Fatalf("Invalid logging flags: %v", err)
 	}
-	log.Println("Successfully connected to ModemManager")
 
-	// Get ModemManager version
-	mmVersion, err := mm.GetVersion()
+	collectionMode, err := exporter.ParseCollectionMode(*collection)
 	if err != nil {
-		log.Printf("Warning: Failed to get ModemManager version: %v", err)
-	} else {
-		log.Printf("ModemManager version: %s", mmVersion)
+		log.Fatalf("Invalid -collection-mode: %v", err)
 	}
-
-	// Setup signal monitoring for each modem
-	if *signalRate > 0 {
-		if err := setupSignalMonitoring(mm, *signalRate); err != nil {
-			log.Printf("Warning: Failed to setup signal monitoring: %v", err)
+	modemIdentifier, err := exporter.ParseModemIdentifier(*identifier)
+	if err != nil {
+		log.Fatalf("Invalid -modem-identifier: %v", err)
+	}
+	locationPrecision, err := exporter.ParseLocationPrecision(*precision)
+	if err != nil {
+		log.Fatalf("Invalid -location-precision: %v", err)
+	}
+	if *decimals < 0 {
+		log.Fatal("-location-decimals must not be negative")
+	}
+	textfile := *once || *interval > 0
+	switch {
+	case *once && *interval > 0:
+		log.Fatal("-once and -interval are mutually exclusive")
+	case textfile && *outputFile == "":
+		log.Fatal("-once and -interval require -output-file")
+	case !textfile && *outputFile != "":
+		log.Fatal("-output-file requires -once or -interval")
+	case (*tlsCert == "") != (*tlsKey == ""):
+		log.Fatal("-tls-cert and -tls-key must be given together")
+	case (*authUser == "") != (*authPassword == ""):
+		log.Fatal("-basic-auth-user and -basic-auth-password-file must be given together")
+	}
+	var password string
+	if *authPassword != "" {
+		if password, err = readPassword(*authPassword); err != nil {
+			log.Fatalf("Invalid -basic-auth-password-file: %v", err)
 		}
 	}
+	if *checkConfig {
+		fmt.Println("Configuration OK")
+		os.Exit(0)
+	}
+
+	logger.Info("Starting ModemManager Exporter", "version", build.Version, "revision", build.Revision, "dirty", build.Dirty)
+	if textfile {
+		logger.Info("Writing metrics to file", "path", *outputFile)
+	} else {
+		logger.Info("Listening", "addresses", listenAddresses.String(), "metrics_path", *metricsPath)
+	}
+	logger.Info("Signal refresh rate", "rate", *signalRate)
+	if len(rateOverrides) > 0 {
+		logger.Info("Signal refresh rate overrides", "overrides", rateOverrides.String())
+	}
+	logger.Info("Collection mode", "mode", string(collectionMode))
 
 	// Create Prometheus registry
 	registry := prometheus.NewRegistry()
@@ -71,130 +197,436 @@ func main() {
 		collectors.NewBuildInfoCollector(),
 		collectors.NewGoCollector(),
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		newBuildInfoMetric(build),
 	)
 
-	// Register ModemManager exporter
-	mmExporter := exporter.NewExporter(mm)
+	// Register ModemManager exporter. It reports modemmanager_up 0 until
+	// connectModemManager gives it a source.
+	mmExporter := exporter.NewExporter(nil,
+		exporter.WithLogger(logger),
+		exporter.WithFailedModemGrace(*failedGrace),
+		exporter.WithBandMetrics(*bandMetrics),
+		exporter.WithAccessTechnologySeries(*accessTechs),
+		exporter.WithOwnNumbers(*ownNumbers),
+		exporter.WithBearerPathLabels(*bearerPaths),
+		exporter.WithTimestamps(*timestamps),
+		exporter.WithCollectionMode(collectionMode),
+		exporter.WithSnapshotRefresh(*refresh),
+		exporter.WithModemIdentifier(modemIdentifier),
+		exporter.WithSignalRateOverrides(rateOverrides),
+		exporter.WithLocationPrecision(locationPrecision, *decimals),
+		exporter.WithNameOwnerChanges(exporter.SubscribeNameOwnerChanged),
+		exporter.WithReconnect(func() (exporter.ModemSource, error) {
+			mm, err := modemmanager.NewModemManager()
+			if err != nil {
+				return nil, err
+			}
+			return exporter.ModemManagerSource{Manager: mm}, nil
+		}),
+	)
 	registry.MustRegister(mmExporter)
 
-	log.Println("Registered all collectors")
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Write the metrics to a file instead of serving them, with the same
+	// exporter but without the Go and process metrics of the serving process
+	if textfile {
+		if _, err := connectModemManager(ctx, mmExporter); err != nil {
+			log.Fatalf("Failed to connect to ModemManager: %v", err)
+		}
+		os.Exit(runTextfile(ctx, mmExporter))
+	}
+
+	// Connect in the background, so the HTTP server reports modemmanager_up 0
+	// while ModemManager is not up yet during boot. Once connected, count
+	// modem state transitions between scrapes, set up signal polling of
+	// hot-plugged modems and, with -collection-mode=events, keep the modem
+	// snapshots.
+	var conn connection
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	eventsDone := make(chan struct{})
+	go func() {
+		defer close(eventsDone)
+		mmVersion, err := connectModemManager(ctx, mmExporter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return // shutting down
+			}
+			log.Fatalf("Failed to connect to ModemManager: %v", err)
+		}
+		conn.set(mmVersion)
+		mmExporter.Start(eventsCtx)
+	}()
 
-	// Setup HTTP handlers
-	http.Handle(*metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{
-		ErrorLog:      log.New(os.Stderr, "", log.LstdFlags),
-		ErrorHandling: promhttp.ContinueOnError,
+	// Register exposition size metrics
+	expositionStats := exporter.NewExpositionStats()
+	registry.MustRegister(expositionStats)
+
+	logger.Debug("Registered all collectors")
+
+	// Setup HTTP handlers on an own mux, as imported packages may register
+	// handlers on http.DefaultServeMux
+	mux := http.NewServeMux()
+	mux.Handle(*metricsPath, expositionStats.WrapHandler(promhttp.HandlerFor(expositionStats.WrapGatherer(registry), promhttp.HandlerOpts{
+		ErrorLog:           slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		ErrorHandling:      promhttp.ContinueOnError,
+		DisableCompression: *disableGzip,
+	})))
+
+	// Scrape a single modem per target, e.g. /probe?modem=0
+	mux.Handle("/probe", mmExporter.ProbeHandler(promhttp.HandlerOpts{
+		ErrorLog:           slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		ErrorHandling:      promhttp.ContinueOnError,
+		DisableCompression: *disableGzip,
 	}))
 
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		fmt.Fprintf(w, `<!DOCTYPE html>
-<html>
-<head>
-	<title>ModemManager Exporter</title>
-	<style>
-		body { font-family: Arial, sans-serif; margin: 40px; }
-		h1 { color: #333; }
-		.info { background: #f0f0f0; padding: 15px; border-radius: 5px; }
-		.links { margin-top: 20px; }
-		a { color: #0066cc; text-decoration: none; }
-		a:hover { text-decoration: underline; }
-	</style>
-</head>
-<body>
-	<h1>ModemManager Exporter</h1>
-	<div class="info">
-		<p><strong>Version:</strong> %s</p>
-		<p><strong>ModemManager Version:</strong> %s</p>
-		<p><strong>Signal Refresh Rate:</strong> %s</p>
-	</div>
-	<div class="links">
-		<p><a href="%s">Metrics</a></p>
-	</div>
-</body>
-</html>
-`, version, mmVersion, *signalRate, *metricsPath)
-	})
+	mux.Handle("/", landingPageHandler(mmExporter, &conn, *pageModems))
+
+	// Debug endpoints, off by default as profiles and modem identities are
+	// not meant for every client of the metrics endpoint
+	if *debugPprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		logger.Warn("Debug endpoint enabled", "path", "/debug/pprof/")
+	}
+	if *debugModems {
+		mux.Handle("/debug/modems", mmExporter.DebugHandler())
+		logger.Warn("Debug endpoint enabled", "path", "/debug/modems")
+	}
 
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, "OK\n")
-	})
+	// 503 while ModemManager does not answer, so that load balancers route
+	// elsewhere; /ready also until the first scrape read the modems
+	mux.Handle("/health", healthHandler(mmExporter, false))
+	mux.Handle("/ready", healthHandler(mmExporter, true))
 
-	// Setup graceful shutdown
-	done := make(chan bool, 1)
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	// Require basic auth for everything but /health and /ready, which load
+	// balancers check without credentials
+	var handler http.Handler = mux
+	if *authUser != "" {
+		handler = withBasicAuth(mux, *authUser, password, "/health", "/ready")
+	}
 
 	server := &http.Server{
-		Addr:         *listenAddress,
-		Handler:      http.DefaultServeMux,
+		Handler:      handler,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	var listeners []net.Listener
+	for _, address := range listenAddresses {
+		listener, err := listen(address, os.FileMode(mode))
+		if err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+		// Unix sockets are protected by their permissions instead
+		if _, ok := listener.(*net.TCPListener); ok && *tlsCert != "" {
+			if listener, err = tlsListener(listener, *tlsCert, *tlsKey); err != nil {
+				log.Fatalf("Failed to set up TLS: %v", err)
+			}
+		}
+		listeners = append(listeners, listener)
+	}
 
-	go func() {
-		<-quit
-		log.Println("Shutting down server...")
+	logger.Info("Server is ready to handle requests", "addresses", listenAddresses.String())
+	if err := serve(ctx, server, listeners, *drainTimeout); err != nil {
+		log.Fatalf("Server failed: %v", err)
+	}
 
-		if err := server.Close(); err != nil {
-			log.Printf("Error closing server: %v", err)
-		}
-		close(done)
-	}()
+	stopEvents()
+	<-eventsDone
+	logger.Info("Server stopped")
+}
 
-	log.Printf("Server is ready to handle requests at %s", *listenAddress)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Failed to start server: %v", err)
+// newBuildInfoMetric returns modemmanager_exporter_build_info, which unlike
+// go_build_info of the Go collector carries the revision of mm-exporter
+func newBuildInfoMetric(info buildinfo.Info) prometheus.Collector {
+	metric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "modemmanager_exporter_build_info",
+		Help: "Build information of mm-exporter, always 1",
+		ConstLabels: prometheus.Labels{
+			"version":   info.Version,
+			"revision":  info.Revision,
+			"dirty":     strconv.FormatBool(info.Dirty),
+			"goversion": info.GoVersion,
+		},
+	})
+	metric.Set(1)
+	return metric
+}
+
+// newLogger returns a logger writing to w at level in format, dropping
+// identical messages repeated within logRepeatInterval. The text format
+// keeps the output of the log package, with the level after the time.
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	switch level {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "info":
+		lvl = slog.LevelInfo
+	case "warn":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return nil, fmt.Errorf("unknown log level %q, want debug, info, warn or error", level)
 	}
 
-	<-done
-	log.Println("Server stopped")
+	var handler slog.Handler
+	switch format {
+	case "text":
+		log.SetOutput(w)
+		slog.SetLogLoggerLevel(lvl)
+		handler = slog.Default().Handler()
+	case "json":
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})
+	default:
+		return nil, fmt.Errorf("unknown log format %q, want text or json", format)
+	}
+	return slog.New(exporter.NewRateLimitHandler(handler, logRepeatInterval)), nil
 }
 
-func setupSignalMonitoring(mm modemmanager.ModemManager, rate time.Duration) error {
-	modems, err := mm.GetModems()
+// readPassword reads a password from a file, without a trailing newline
+func readPassword(path string) (string, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to get modems: %w", err)
+		return "", err
+	}
+	password := strings.TrimRight(string(content), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("%s is empty", path)
 	}
+	return password, nil
+}
 
-	if len(modems) == 0 {
-		log.Println("No modems found")
-		return nil
+// withBasicAuth requires user and password on requests to next, except for
+// the open paths.
+func withBasicAuth(next http.Handler, user, password string, open ...string) http.Handler {
+	wantUser := sha256.Sum256([]byte(user))
+	wantPassword := sha256.Sum256([]byte(password))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(open, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Compare hashes in constant time, so neither length nor content leaks
+		gotUser, gotPassword, ok := r.BasicAuth()
+		userHash := sha256.Sum256([]byte(gotUser))
+		passwordHash := sha256.Sum256([]byte(gotPassword))
+		userOK := subtle.ConstantTimeCompare(userHash[:], wantUser[:]) == 1
+		passwordOK := subtle.ConstantTimeCompare(passwordHash[:], wantPassword[:]) == 1
+		if !ok || !userOK || !passwordOK {
+			w.Header().Set("WWW-Authenticate", `Basic realm="mm-exporter", charset="UTF-8"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tlsListener returns a listener serving TLS with the certificate and key
+// files on the connections of listener.
+func tlsListener(listener net.Listener, certFile, keyFile string) (net.Listener, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
 	}
+	return tls.NewListener(listener, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
 
-	log.Printf("Setting up signal monitoring for %d modem(s)", len(modems))
+// connection is the state of the connection to ModemManager
+type connection struct {
+	mu        sync.Mutex
+	connected bool
+	version   string
+}
 
-	for _, modem := range modems {
-		deviceID, err := modem.GetDeviceIdentifier()
-		if err != nil {
-			log.Printf("Warning: Failed to get device identifier: %v", err)
-			continue
+func (c *connection) set(version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected, c.version = true, version
+}
+
+// get returns the ModemManager version, and false while not connected
+func (c *connection) get() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version, c.connected
+}
+
+// connectModemManager waits until ModemManager answers on D-Bus, retrying
+// for up to -startup-timeout, and gives the exporter its connection. It then
+// sets up signal monitoring and returns the ModemManager version.
+func connectModemManager(ctx context.Context, mmExporter *exporter.Exporter) (string, error) {
+	if *startupWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *startupWait)
+		defer cancel()
+	}
+
+	var mm modemmanager.ModemManager
+	var mmVersion string
+	err := retryWithBackoff(ctx, func() error {
+		var err error
+		if mm, err = modemmanager.NewModemManager(); err != nil {
+			return err
 		}
+		mmVersion, err = mm.GetVersion()
+		return err
+	}, time.Second, 30*time.Second)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("Successfully connected to ModemManager", "version", mmVersion)
+	mmExporter.SetSource(exporter.ModemManagerSource{Manager: mm})
 
-		model, err := modem.GetModel()
-		if err != nil {
-			model = "unknown"
+	// Setup signal monitoring for each modem
+	if *signalRate > 0 || len(rateOverrides) > 0 {
+		if err := mmExporter.SetupSignalMonitoring(*signalRate); err != nil {
+			logger.Warn("Failed to setup signal monitoring", "err", err)
 		}
+	}
+	return mmVersion, nil
+}
 
-		log.Printf("Configuring modem %s (%s)", deviceID, model)
+// retryWithBackoff calls attempt until it succeeds or ctx is done, waiting
+// initial after the first failure and twice as long after each further one,
+// up to max.
+func retryWithBackoff(ctx context.Context, attempt func() error, initial, max time.Duration) error {
+	backoff := initial
+	for {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+		logger.Warn("Waiting for ModemManager", "err", err, "retry_in", backoff)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w, last error: %v", ctx.Err(), err)
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, max)
+	}
+}
 
-		// Get signal interface
-		signal, err := modem.GetSignal()
-		if err != nil {
-			log.Printf("Warning: Signal interface not available for modem %s: %v", deviceID, err)
-			continue
+// listen listens on a host:port address, or on a unix socket given as
+// unix:///path/to.sock. A socket file left behind by an earlier run is
+// removed first, and the new one gets the permissions mode.
+func listen(address string, mode os.FileMode) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, "unix://")
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != os.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// serve serves HTTP on every listener until ctx is done or one of them
+// fails. It then stops accepting connections and waits up to drain for
+// in-flight requests, such as slow scrapes, before closing the connections
+// still open.
+func serve(ctx context.Context, server *http.Server, listeners []net.Listener, drain time.Duration) error {
+	served := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func() {
+			served <- server.Serve(listener)
+		}()
+	}
+
+	running := len(listeners)
+	var failed error
+	select {
+	case failed = <-served:
+		running--
+		server.Close()
+	case <-ctx.Done():
+		logger.Info("Shutting down server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), drain)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("Requests still running, closing their connections", "timeout", drain, "err", err)
+			if err := server.Close(); err != nil {
+				logger.Error("Error closing server", "err", err)
+			}
+		}
+	}
+	for ; running > 0; running-- {
+		if err := <-served; failed == nil {
+			failed = err
 		}
+	}
+	if failed == http.ErrServerClosed {
+		return nil
+	}
+	return failed
+}
 
-		// Setup signal refresh rate
-		rateSeconds := uint32(rate.Seconds())
-		if err := signal.Setup(rateSeconds); err != nil {
-			log.Printf("Warning: Failed to setup signal monitoring for modem %s: %v", deviceID, err)
-			continue
+// runTextfile writes the metrics of the exporter to -output-file once, or
+// every -interval until interrupted, and returns the exit status.
+func runTextfile(ctx context.Context, mmExporter *exporter.Exporter) int {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(mmExporter)
+
+	if *once {
+		if err := writeTextfile(registry); err != nil {
+			logger.Error(err.Error())
+			return 1
 		}
+		return 0
+	}
 
-		log.Printf("Signal monitoring enabled for modem %s (refresh rate: %s)", deviceID, rate)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if err := writeTextfile(registry); err != nil {
+			logger.Warn(err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
 	}
+}
 
+// writeTextfile collects the metrics once and writes them to -output-file. A
+// scrape with errors is still written, but reported as an error.
+func writeTextfile(registry *prometheus.Registry) error {
+	families, err := registry.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	if err := exporter.WriteTextfile(*outputFile, families); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *outputFile, err)
+	}
+	if exporter.ScrapeFailed(families) {
+		return fmt.Errorf("scrape had errors, see modemmanager_scrape_last_errors in %s", *outputFile)
+	}
 	return nil
 }
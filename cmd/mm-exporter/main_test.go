@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager/internal/buildinfo"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// startServe runs serve on a random local port with handler at /slow and
+// returns the URL of the handler and the result of serve.
+func startServe(t *testing.T, ctx context.Context, handler http.HandlerFunc, drain time.Duration) (string, <-chan error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", handler)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, &http.Server{Handler: mux}, []net.Listener{listener}, drain)
+	}()
+	return "http://" + listener.Addr().String() + "/slow", served
+}
+
+type response struct {
+	body string
+	err  error
+}
+
+func get(url string) <-chan response {
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- response{string(body), err}
+	}()
+	return responses
+}
+
+func TestServeDrainsRequestsOnSIGTERM(t *testing.T) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	started := make(chan struct{})
+	url, served := startServe(t, ctx, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprint(w, "scraped")
+	}, 5*time.Second)
+
+	responses := get(url)
+	<-started
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := <-responses; resp.err != nil || resp.body != "scraped" {
+		t.Errorf("in-flight request = %q, %v, want it to complete", resp.body, resp.err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("serve = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after SIGTERM")
+	}
+	if resp := <-get(url); resp.err == nil {
+		t.Error("request accepted after shutdown")
+	}
+}
+
+func TestServeClosesAfterDrainTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	url, served := startServe(t, ctx, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}, 50*time.Millisecond)
+
+	responses := get(url)
+	<-started
+	cancel()
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("serve = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after the drain timeout")
+	}
+	if resp := <-responses; resp.err == nil {
+		t.Error("request still answered after its connection was closed")
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	attempts := 0
+	err := retryWithBackoff(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("ModemManager not up yet")
+		}
+		return nil
+	}, time.Millisecond, 2*time.Millisecond)
+	if err != nil || attempts != 3 {
+		t.Errorf("retryWithBackoff = %v after %d attempts, want nil after 3", err, attempts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = retryWithBackoff(ctx, func() error {
+		return errors.New("no system bus")
+	}, time.Millisecond, 5*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("retryWithBackoff = %v, want the deadline to end it", err)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, "warn", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("Collection mode", "mode", "poll")
+	logger.Warn("Failed to setup signal monitoring", "err", "timeout")
+	logger.Warn("Failed to setup signal monitoring", "err", "timeout")
+	if got := strings.Count(out.String(), "\n"); got != 1 || !strings.Contains(out.String(), `"level":"WARN"`) {
+		t.Errorf("logged %d lines, want the warning once:\n%s", got, out.String())
+	}
+
+	for _, flags := range [][2]string{{"verbose", "text"}, {"info", "logfmt"}} {
+		if _, err := newLogger(&out, flags[0], flags[1]); err == nil {
+			t.Errorf("newLogger(%q, %q) succeeded", flags[0], flags[1])
+		}
+	}
+}
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 and its
+// key to dir and returns the file names and a pool trusting it.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mm-exporter"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+func TestServeTLSWithBasicAuth(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, pool := writeCertificate(t, dir)
+	passwordFile := filepath.Join(dir, "password")
+	if err := os.WriteFile(passwordFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	password, err := readPassword(passwordFile)
+	if err != nil || password != "s3cret" {
+		t.Fatalf("readPassword = %q, %v", password, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "modemmanager_up 1") })
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "OK") })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "https://" + listener.Addr().String()
+	if listener, err = tlsListener(listener, certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, &http.Server{Handler: withBasicAuth(mux, "prometheus", password, "/health")}, []net.Listener{listener}, time.Second)
+	}()
+	defer func() {
+		cancel()
+		<-served
+	}()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	tests := []struct {
+		name     string
+		path     string
+		user     string
+		password string
+		want     int
+	}{
+		{name: "no credentials", path: "/metrics", want: http.StatusUnauthorized},
+		{name: "wrong password", path: "/metrics", user: "prometheus", password: "guess", want: http.StatusUnauthorized},
+		{name: "wrong user", path: "/metrics", user: "admin", password: "s3cret", want: http.StatusUnauthorized},
+		{name: "credentials", path: "/metrics", user: "prometheus", password: "s3cret", want: http.StatusOK},
+		{name: "health without credentials", path: "/health", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, base+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate missing")
+			}
+		})
+	}
+
+	// Plain HTTP is not served
+	if resp, err := http.Get("http" + strings.TrimPrefix(base, "https") + "/health"); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Error("health answered over plain HTTP")
+		}
+	}
+}
+
+func TestServeUnixSocketAndTCP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mm-exporter.sock")
+
+	// A socket left behind by a killed exporter
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	unixListener, err := listen("unix://"+path, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	tcpListener, err := listen("127.0.0.1:0", 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "modemmanager_up 1") })
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, &http.Server{Handler: mux}, []net.Listener{unixListener, tcpListener}, time.Second)
+	}()
+
+	overSocket := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+	for name, get := range map[string]func() (*http.Response, error){
+		"unix": func() (*http.Response, error) { return overSocket.Get("http://localhost/metrics") },
+		"tcp":  func() (*http.Response, error) { return http.Get("http://" + tcpListener.Addr().String() + "/metrics") },
+	} {
+		resp, err := get()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "modemmanager_up 1" {
+			t.Errorf("%s: body %q", name, body)
+		}
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("serve = %v, want nil", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket not removed on shutdown: %v", err)
+	}
+	if _, err := net.Dial("tcp", tcpListener.Addr().String()); err == nil {
+		t.Error("TCP listener still open after shutdown")
+	}
+}
+
+func TestListenRefusesToReplaceFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if listener, err := listen("unix://"+path, 0o660); err == nil {
+		listener.Close()
+		t.Error("listen replaced a regular file")
+	}
+}
+
+func TestBuildInfoMetric(t *testing.T) {
+	info := buildinfo.Info{Version: "v1.2.3", Revision: "abc123", Dirty: true, GoVersion: "go1.23.4"}
+	want := `# HELP modemmanager_exporter_build_info Build information of mm-exporter, always 1
+# TYPE modemmanager_exporter_build_info gauge
+modemmanager_exporter_build_info{dirty="true",goversion="go1.23.4",revision="abc123",version="v1.2.3"} 1
+`
+	if err := testutil.CollectAndCompare(newBuildInfoMetric(info), strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Antenna modes accepted by `modem antenna set`
+const (
+	antennaMain = "main" // main antenna path only
+	antennaDiv  = "div"  // diversity antenna path only
+	antennaAuto = "auto" // main and diversity paths
+)
+
+// antennaTable describes how to query and select the antenna path on the
+// modules of one manufacturer.
+type antennaTable struct {
+	vendor   string            // lower-case substring of the reported manufacturer
+	query    string            // AT command returning the current setting
+	response *regexp.Regexp    // first submatch is the raw setting in the query response
+	values   map[string]string // raw setting -> antenna mode
+	set      map[string]string // antenna mode -> AT command
+}
+
+// antennaTables lists the supported modules. Commands follow the vendor AT
+// command manuals; modes a vendor cannot select are left out of set.
+var antennaTables = []antennaTable{
+	{
+		// Quectel LTE modules: AT+QCFG="divctl" takes a bitmask of the
+		// receive paths, 1 = main (PRx), 2 = diversity (DRx).
+		vendor:   "quectel",
+		query:    `AT+QCFG="divctl","lte"`,
+		response: regexp.MustCompile(`\+QCFG:\s*"divctl","lte",(\d+)`),
+		values:   map[string]string{"1": antennaMain, "2": antennaDiv, "3": antennaAuto},
+		set: map[string]string{
+			antennaMain: `AT+QCFG="divctl","lte",1`,
+			antennaDiv:  `AT+QCFG="divctl","lte",2`,
+			antennaAuto: `AT+QCFG="divctl","lte",3`,
+		},
+	},
+	{
+		// Telit modules: AT#RXDIV enables or disables receive diversity
+		vendor:   "telit",
+		query:    "AT#RXDIV?",
+		response: regexp.MustCompile(`#RXDIV:\s*(\d+)`),
+		values:   map[string]string{"0": antennaMain, "1": antennaAuto},
+		set: map[string]string{
+			antennaMain: "AT#RXDIV=0",
+			antennaAuto: "AT#RXDIV=1",
+		},
+	},
+}
+
+var (
+	modemAntennaCmd = &cobra.Command{
+		Use:   "antenna",
+		Short: "Query or select the antenna path",
+		Long: `Query or select the receive antenna path (main, diversity or both) on modules
+that support antenna selection through AT commands.
+
+Supported manufacturers: Quectel, Telit. Other modules are refused unless
+--at-command gives the AT command to send explicitly.
+
+AT commands go through the ModemManager Command() API, which requires
+ModemManager to run in debug mode.`,
+	}
+
+	modemAntennaGetCmd = &cobra.Command{
+		Use:   "get",
+		Short: "Show the selected antenna path",
+		Example: `  # Show the antenna path of modem 0
+  mmctl modem antenna get -m 0`,
+		Args: cobra.NoArgs,
+		RunE: runModemAntennaGet,
+	}
+
+	modemAntennaSetCmd = &cobra.Command{
+		Use:   "set main|div|auto",
+		Short: "Select the antenna path",
+		Long: `Select the receive antenna path and report the RSSI change after a settling
+period.
+
+Warning: this changes the RF configuration of the module, which usually
+persists across reboots. A wrong setting on a single-antenna enclosure can
+leave the modem without usable signal.`,
+		Example: `  # Use main and diversity antennas
+  mmctl modem antenna set auto -m 0 --yes
+
+  # Unknown module: send an explicit command
+  mmctl modem antenna set main -m 0 --yes --at-command 'AT^ANTSEL=0'`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{antennaMain, antennaDiv, antennaAuto},
+		RunE:      runModemAntennaSet,
+	}
+
+	// Flags
+	antennaATCommand string
+	antennaSettle    time.Duration
+	antennaConfirm   bool
+)
+
+func init() {
+	modemCmd.AddCommand(modemAntennaCmd)
+	modemAntennaCmd.AddCommand(modemAntennaGetCmd)
+	modemAntennaCmd.AddCommand(modemAntennaSetCmd)
+
+	modemAntennaCmd.PersistentFlags().StringVar(&antennaATCommand, "at-command", "", "AT command to send instead of the built-in table")
+	modemAntennaCmd.PersistentFlags().Uint32VarP(&commandTimeout, "timeout", "t", 10, "AT command timeout in seconds")
+	modemAntennaSetCmd.Flags().DurationVar(&antennaSettle, "settle", 10*time.Second, "Time to wait before measuring the RSSI after the change")
+	modemAntennaSetCmd.Flags().BoolVar(&antennaConfirm, "yes", false, "Confirm changing the antenna configuration")
+}
+
+// findAntennaTable returns the command table for a manufacturer
+func findAntennaTable(manufacturer string) (antennaTable, bool) {
+	manufacturer = strings.ToLower(manufacturer)
+	for _, table := range antennaTables {
+		if strings.Contains(manufacturer, table.vendor) {
+			return table, true
+		}
+	}
+	return antennaTable{}, false
+}
+
+// parseAntennaResponse extracts the antenna mode from a query response
+func (t antennaTable) parseAntennaResponse(resp string) (string, error) {
+	match := t.response.FindStringSubmatch(resp)
+	if match == nil {
+		return "", fmt.Errorf("unexpected response to %s: %q", t.query, strings.TrimSpace(resp))
+	}
+	mode, ok := t.values[match[1]]
+	if !ok {
+		return "", fmt.Errorf("unknown antenna setting %s in response to %s", match[1], t.query)
+	}
+	return mode, nil
+}
+
+// parseCSQ converts an AT+CSQ response to RSSI in dBm (3GPP TS 27.007 section 8.5)
+func parseCSQ(resp string) (int, error) {
+	idx := strings.Index(resp, "+CSQ:")
+	if idx < 0 {
+		return 0, fmt.Errorf("unexpected AT+CSQ response %q", strings.TrimSpace(resp))
+	}
+	fields := strings.SplitN(strings.TrimSpace(resp[idx+len("+CSQ:"):]), ",", 2)
+	rssi, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected AT+CSQ response %q", strings.TrimSpace(resp))
+	}
+	if rssi < 0 || rssi > 31 {
+		return 0, fmt.Errorf("RSSI not known or not detectable")
+	}
+	return -113 + 2*rssi, nil
+}
+
+// antennaTableFor resolves the command table of a modem. Unknown modules are
+// refused unless --at-command is given.
+func antennaTableFor(modem modemmanager.Modem) (antennaTable, bool, error) {
+	manufacturer, _ := modem.GetManufacturer()
+	table, ok := findAntennaTable(manufacturer)
+	if !ok && antennaATCommand == "" {
+		return table, false, fmt.Errorf("no antenna commands known for manufacturer %q; use --at-command to send one explicitly", manufacturer)
+	}
+	return table, ok, nil
+}
+
+func runModemAntennaGet(cmd *cobra.Command, args []string) error {
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	table, known, err := antennaTableFor(modem)
+	if err != nil {
+		return err
+	}
+
+	query := table.query
+	if antennaATCommand != "" {
+		query = antennaATCommand
+	}
+	resp, err := sendAT(modem, query)
+	if err != nil {
+		return fmt.Errorf("failed to query antenna configuration: %w", err)
+	}
+
+	// An explicit command has no parser, show the raw response
+	mode := ""
+	if known && antennaATCommand == "" {
+		if mode, err = table.parseAntennaResponse(resp); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		out := map[string]interface{}{"command": query, "response": strings.TrimSpace(resp)}
+		if mode != "" {
+			out["mode"] = mode
+		}
+		return encoder.Encode(out)
+	}
+
+	if mode == "" {
+		fmt.Println(strings.TrimSpace(resp))
+		return nil
+	}
+	fmt.Printf("Antenna: %s\n", mode)
+	return nil
+}
+
+func runModemAntennaSet(cmd *cobra.Command, args []string) error {
+	mode := args[0]
+	if mode != antennaMain && mode != antennaDiv && mode != antennaAuto {
+		return fmt.Errorf("invalid antenna mode %q, expected main, div or auto", mode)
+	}
+	if err := confirmModification("this changes the antenna configuration of the module.", antennaConfirm); err != nil {
+		return err
+	}
+
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	table, _, err := antennaTableFor(modem)
+	if err != nil {
+		return err
+	}
+
+	command := antennaATCommand
+	if command == "" {
+		var ok bool
+		if command, ok = table.set[mode]; !ok {
+			return fmt.Errorf("antenna mode %q is not supported on %s modules", mode, table.vendor)
+		}
+	}
+
+	before, beforeErr := readRSSI(modem)
+
+	progress.Stage("configuring", mode)
+	if _, err := sendAT(modem, command); err != nil {
+		return fmt.Errorf("failed to set antenna configuration: %w", err)
+	}
+
+	progress.Stage("settling", antennaSettle.String())
+	<-clk.After(antennaSettle)
+	after, afterErr := readRSSI(modem)
+
+	result := map[string]interface{}{"mode": mode, "command": command}
+	if beforeErr == nil && afterErr == nil {
+		result["rssi_before_dbm"] = before
+		result["rssi_after_dbm"] = after
+		result["rssi_delta_db"] = after - before
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	fmt.Printf("Antenna set to %s\n", mode)
+	if beforeErr == nil && afterErr == nil {
+		fmt.Printf("RSSI: %d dBm -> %d dBm (%+d dB)\n", before, after, after-before)
+	} else {
+		fmt.Println("RSSI change: not available")
+	}
+	return nil
+}
+
+func readRSSI(modem modemmanager.Modem) (int, error) {
+	resp, err := sendAT(modem, "AT+CSQ")
+	if err != nil {
+		return 0, err
+	}
+	return parseCSQ(resp)
+}
+
+// sendAT sends an AT command through ModemManager
+func sendAT(modem modemmanager.Modem, at string) (string, error) {
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Sending command: %s\n", at)
+	}
+	return modem.Command(at, commandTimeout)
+}
+
+// confirmModification prints a warning for operations that change persistent
+// modem or SIM state and refuses to continue unless confirmed with --yes.
+func confirmModification(warning string, confirmed bool) error {
+	fmt.Fprintln(os.Stderr, "Warning: "+warning)
+	if !confirmed {
+		return fmt.Errorf("refusing to continue without --yes")
+	}
+	return nil
+}
+
+// promptYesNo asks question on stderr and reads the answer from the
+// terminal; only y and yes confirm. Tests replace it.
+var promptYesNo = func(question string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, errNoTerminal
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// confirmDisruption prints what a disruptive operation is about to do and
+// continues if confirmed with --yes or, in a terminal, at a y/N prompt.
+func confirmDisruption(plan []string, confirmed bool) error {
+	fmt.Fprintln(os.Stderr, "This will:")
+	for _, step := range plan {
+		fmt.Fprintf(os.Stderr, "  - %s\n", step)
+	}
+	if confirmed {
+		return nil
+	}
+	ok, err := promptYesNo("Continue?")
+	if errors.Is(err, errNoTerminal) {
+		return fmt.Errorf("refusing to continue without --yes")
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("aborted")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import "testing"
+
+func TestFindAntennaTable(t *testing.T) {
+	tests := []struct {
+		manufacturer string
+		vendor       string
+		ok           bool
+	}{
+		{"Quectel", "quectel", true},
+		{"QUECTEL Incorporated", "quectel", true},
+		{"Telit", "telit", true},
+		{"Sierra Wireless, Incorporated", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		table, ok := findAntennaTable(tt.manufacturer)
+		if ok != tt.ok || table.vendor != tt.vendor {
+			t.Errorf("findAntennaTable(%q) = %q, %v; want %q, %v", tt.manufacturer, table.vendor, ok, tt.vendor, tt.ok)
+		}
+	}
+}
+
+func TestParseAntennaResponse(t *testing.T) {
+	quectel, _ := findAntennaTable("Quectel")
+	telit, _ := findAntennaTable("Telit")
+
+	tests := []struct {
+		table antennaTable
+		resp  string
+		want  string
+	}{
+		{quectel, "\r\n+QCFG: \"divctl\",\"lte\",3\r\n\r\nOK\r\n", antennaAuto},
+		{quectel, "+QCFG: \"divctl\",\"lte\",1", antennaMain},
+		{quectel, "+QCFG: \"divctl\",\"lte\",2\r\nOK", antennaDiv},
+		{telit, "#RXDIV: 1,0\r\n\r\nOK", antennaAuto},
+		{telit, "#RXDIV: 0,0", antennaMain},
+	}
+	for _, tt := range tests {
+		got, err := tt.table.parseAntennaResponse(tt.resp)
+		if err != nil {
+			t.Errorf("%s: parse %q: %v", tt.table.vendor, tt.resp, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: parse %q = %q, want %q", tt.table.vendor, tt.resp, got, tt.want)
+		}
+	}
+
+	for _, resp := range []string{"ERROR", "+QCFG: \"divctl\",\"lte\",7", "+QCFG: \"band\",0,80000"} {
+		if _, err := quectel.parseAntennaResponse(resp); err == nil {
+			t.Errorf("quectel: parse %q succeeded, want error", resp)
+		}
+	}
+}
+
+func TestAntennaTablesComplete(t *testing.T) {
+	for _, table := range antennaTables {
+		if len(table.set) == 0 {
+			t.Errorf("%s: no settable modes", table.vendor)
+		}
+		for mode := range table.set {
+			if mode != antennaMain && mode != antennaDiv && mode != antennaAuto {
+				t.Errorf("%s: unknown mode %q in set table", table.vendor, mode)
+			}
+		}
+		for raw, mode := range table.values {
+			if _, ok := table.set[mode]; !ok {
+				t.Errorf("%s: query value %s maps to %q, which cannot be set", table.vendor, raw, mode)
+			}
+		}
+	}
+}
+
+func TestParseCSQ(t *testing.T) {
+	tests := []struct {
+		resp string
+		want int
+	}{
+		{"+CSQ: 20,99\r\n\r\nOK", -73},
+		{"+CSQ: 0,0", -113},
+		{"\r\n+CSQ: 31,99\r\nOK\r\n", -51},
+	}
+	for _, tt := range tests {
+		got, err := parseCSQ(tt.resp)
+		if err != nil || got != tt.want {
+			t.Errorf("parseCSQ(%q) = %d, %v; want %d", tt.resp, got, err, tt.want)
+		}
+	}
+	for _, resp := range []string{"+CSQ: 99,99", "ERROR", "+CSQ: x,0"} {
+		if _, err := parseCSQ(resp); err == nil {
+			t.Errorf("parseCSQ(%q) succeeded, want error", resp)
+		}
+	}
+}
@@ -0,0 +1,584 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bearerCmd = &cobra.Command{
+		Use:   "bearer",
+		Short: "Manage data bearers",
+		Long: `List, inspect, create and delete the bearers of a modem and watch their
+traffic counters.
+
+A bearer holds the settings of one data connection (APN, IP type,
+credentials). 'mmctl connect' creates and connects one in a single step;
+these commands give access to the individual steps. Bearers are selected
+with --bearer, by object path or by index in 'mmctl bearer list'. With a
+single bearer it can be left out.`,
+	}
+
+	bearerListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List bearers",
+		Example: `  # List the bearers of modem 0
+  mmctl bearer list -m 0`,
+		Args: cobra.NoArgs,
+		RunE: runBearerList,
+	}
+
+	bearerShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Show the settings, IP configuration and statistics of a bearer",
+		Example: `  # Show the second bearer
+  mmctl bearer show -m 0 --bearer 1`,
+		Args: cobra.NoArgs,
+		RunE: runBearerShow,
+	}
+
+	bearerCreateCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create a bearer",
+		Example: `  # Create a dual-stack bearer and connect it
+  mmctl bearer create -m 0 --apn internet --ip-type ipv4v6 --connect`,
+		Args: cobra.NoArgs,
+		RunE: runBearerCreate,
+	}
+
+	bearerDeleteCmd = &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a bearer, disconnecting it first",
+		Example: `  # Delete the bearer with index 1
+  mmctl bearer delete -m 0 --bearer 1`,
+		Args: cobra.NoArgs,
+		RunE: runBearerDelete,
+	}
+
+	bearerStatsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Show the traffic counters of a bearer",
+		Long: `Show the received and transmitted bytes of a connected bearer.
+
+With --watch a line is printed per update until Ctrl-C, with the throughput
+since the previous one. ModemManager refreshes the counters every few
+seconds, so updates come at that pace whatever --interval is; reads that
+return unchanged counters are skipped.`,
+		Example: `  # Watch the throughput of the only bearer
+  mmctl bearer stats -m 0 --watch
+
+  # One JSON object per update
+  mmctl bearer stats -m 0 --watch --json`,
+		Args: cobra.NoArgs,
+		RunE: runBearerStats,
+	}
+
+	// Flags
+	bearerSpec         string
+	bearerAPN          string
+	bearerIPType       string
+	bearerUser         string
+	bearerPassword     string
+	bearerAllowRoaming bool
+	bearerConnect      bool
+	bearerWatch        bool
+	bearerInterval     time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(bearerCmd)
+	bearerCmd.AddCommand(bearerListCmd)
+	bearerCmd.AddCommand(bearerShowCmd)
+	bearerCmd.AddCommand(bearerCreateCmd)
+	bearerCmd.AddCommand(bearerDeleteCmd)
+	bearerCmd.AddCommand(bearerStatsCmd)
+
+	for _, cmd := range []*cobra.Command{bearerShowCmd, bearerDeleteCmd, bearerStatsCmd} {
+		cmd.Flags().StringVar(&bearerSpec, "bearer", "", "Bearer, by object path or index")
+	}
+
+	bearerCreateCmd.Flags().StringVarP(&bearerAPN, "apn", "a", "", "Access Point Name (required)")
+	bearerCreateCmd.MarkFlagRequired("apn")
+	bearerCreateCmd.Flags().StringVar(&bearerIPType, "ip-type", "ipv4", "IP type (ipv4, ipv6, ipv4v6)")
+	bearerCreateCmd.Flags().StringVarP(&bearerUser, "user", "u", "", "Username for authentication")
+	bearerCreateCmd.Flags().StringVarP(&bearerPassword, "password", "P", "", "Password for authentication")
+	bearerCreateCmd.Flags().BoolVar(&bearerAllowRoaming, "allow-roaming", false, "Allow connection while roaming")
+	bearerCreateCmd.Flags().BoolVar(&bearerConnect, "connect", false, "Connect the bearer once it is created")
+
+	bearerStatsCmd.Flags().BoolVar(&bearerWatch, "watch", false, "Print the counters and throughput on every update until Ctrl-C")
+	bearerStatsCmd.Flags().DurationVar(&bearerInterval, "interval", time.Second, "How often to read the counters with --watch")
+}
+
+// parseIPFamily parses an --ip-type value
+func parseIPFamily(name string) (modemmanager.MMBearerIpFamily, error) {
+	switch name {
+	case "ipv4":
+		return modemmanager.MmBearerIpFamilyIpv4, nil
+	case "ipv6":
+		return modemmanager.MmBearerIpFamilyIpv6, nil
+	case "ipv4v6":
+		return modemmanager.MmBearerIpFamilyIpv4v6, nil
+	}
+	return 0, fmt.Errorf("invalid IP type: %s (must be ipv4, ipv6, or ipv4v6)", name)
+}
+
+// getBearers returns the selected modem and its bearers
+func getBearers() (modemmanager.Modem, []modemmanager.Bearer, error) {
+	modem, err := getModem()
+	if err != nil {
+		return nil, nil, err
+	}
+	bearers, err := modemmanager.ListModemBearers(modem)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get bearers: %w", err)
+	}
+	return modem, bearers, nil
+}
+
+// selectBearer returns the bearer given by spec as in selectBearers, or the
+// only bearer if spec is empty
+func selectBearer(bearers []modemmanager.Bearer, spec string) (modemmanager.Bearer, error) {
+	selected, err := selectBearers(bearers, spec, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) > 1 {
+		paths := make([]string, len(bearers))
+		for i, bearer := range bearers {
+			paths[i] = fmt.Sprintf("  %d: %s", i, bearer.GetObjectPath())
+		}
+		return nil, fmt.Errorf("%d bearers found, select one with --bearer:\n%s", len(bearers), strings.Join(paths, "\n"))
+	}
+	return selected[0], nil
+}
+
+// bearerSummary is a bearer in bearer list
+type bearerSummary struct {
+	Index     int    `json:"index"`
+	Path      string `json:"path"`
+	APN       string `json:"apn"`
+	IPType    string `json:"ip_type"`
+	Connected bool   `json:"connected"`
+	Interface string `json:"interface,omitempty"`
+}
+
+func runBearerList(cmd *cobra.Command, args []string) error {
+	_, bearers, err := getBearers()
+	if err != nil {
+		return err
+	}
+
+	summaries := []bearerSummary{}
+	for i, bearer := range bearers {
+		summary := bearerSummary{Index: i, Path: string(bearer.GetObjectPath())}
+		if props, err := bearer.GetProperties(); err == nil {
+			summary.APN = props.APN
+			summary.IPType = strings.ToLower(props.IPType.String())
+		}
+		summary.Connected, _ = bearer.GetConnected()
+		if summary.Connected {
+			summary.Interface, _ = bearer.GetInterface()
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summaries)
+	}
+	if len(summaries) == 0 {
+		fmt.Println("No bearers found")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tPATH\tAPN\tIP TYPE\tCONNECTED\tINTERFACE")
+	for _, s := range summaries {
+		connected := "no"
+		if s.Connected {
+			connected = "yes"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", s.Index, s.Path, orDash(s.APN), orDash(s.IPType), connected, orDash(s.Interface))
+	}
+	return w.Flush()
+}
+
+// ipConfigJSON is an IP configuration in bearer show
+type ipConfigJSON struct {
+	Method  string   `json:"method"`
+	Address string   `json:"address,omitempty"`
+	Prefix  uint32   `json:"prefix,omitempty"`
+	Gateway string   `json:"gateway,omitempty"`
+	DNS     []string `json:"dns"`
+	MTU     uint32   `json:"mtu,omitempty"`
+}
+
+func newIPConfigJSON(config modemmanager.BearerIpConfig) *ipConfigJSON {
+	dns := []string{}
+	for _, server := range []string{config.Dns1, config.Dns2, config.Dns3} {
+		if server != "" {
+			dns = append(dns, server)
+		}
+	}
+	return &ipConfigJSON{
+		Method:  strings.ToLower(config.Method.String()),
+		Address: config.Address,
+		Prefix:  config.Prefix,
+		Gateway: config.Gateway,
+		DNS:     dns,
+		MTU:     config.Mtu,
+	}
+}
+
+// bearerDetails is a bearer in bearer show
+type bearerDetails struct {
+	Path            string        `json:"path"`
+	Type            string        `json:"type"`
+	Connected       bool          `json:"connected"`
+	Suspended       bool          `json:"suspended"`
+	Interface       string        `json:"interface,omitempty"`
+	IPTimeout       uint32        `json:"ip_timeout_seconds"`
+	APN             string        `json:"apn"`
+	IPType          string        `json:"ip_type"`
+	User            string        `json:"user,omitempty"`
+	AllowRoaming    bool          `json:"allow_roaming"`
+	ConnectionError string        `json:"connection_error,omitempty"`
+	IPv4            *ipConfigJSON `json:"ipv4,omitempty"`
+	IPv6            *ipConfigJSON `json:"ipv6,omitempty"`
+	RxBytes         uint64        `json:"rx_bytes"`
+	TxBytes         uint64        `json:"tx_bytes"`
+	DurationSeconds uint32        `json:"duration_seconds"`
+}
+
+func readBearerDetails(bearer modemmanager.Bearer) bearerDetails {
+	details := bearerDetails{Path: string(bearer.GetObjectPath())}
+	if bearerType, err := bearer.GetBearerType(); err == nil {
+		details.Type = strings.ToLower(bearerType.String())
+	}
+	details.Connected, _ = bearer.GetConnected()
+	details.Suspended, _ = bearer.GetSuspended()
+	details.IPTimeout, _ = bearer.GetIpTimeout()
+	if props, err := bearer.GetProperties(); err == nil {
+		details.APN = props.APN
+		details.IPType = strings.ToLower(props.IPType.String())
+		details.User = props.User
+		details.AllowRoaming = props.AllowRoaming
+	}
+	if name, message, err := bearer.GetConnectionError(); err == nil && name != "" {
+		details.ConnectionError = name
+		if message != "" {
+			details.ConnectionError = message
+		}
+		if connErr, ok := modemmanager.ConnectionErrorFromName(name); ok {
+			details.ConnectionError, _ = connErr.Describe()
+		}
+	}
+	if !details.Connected {
+		return details
+	}
+	details.Interface, _ = bearer.GetInterface()
+	if config, err := bearer.GetIp4Config(); err == nil && config.Method != modemmanager.MmBearerIpMethodUnknown {
+		details.IPv4 = newIPConfigJSON(config)
+	}
+	if config, err := bearer.GetIp6Config(); err == nil && config.Method != modemmanager.MmBearerIpMethodUnknown {
+		details.IPv6 = newIPConfigJSON(config)
+	}
+	if stats, err := bearer.GetStats(); err == nil {
+		details.RxBytes = stats.RxBytes
+		details.TxBytes = stats.TxBytes
+		details.DurationSeconds = stats.Duration
+	}
+	return details
+}
+
+func runBearerShow(cmd *cobra.Command, args []string) error {
+	_, bearers, err := getBearers()
+	if err != nil {
+		return err
+	}
+	bearer, err := selectBearer(bearers, bearerSpec)
+	if err != nil {
+		return err
+	}
+	details := readBearerDetails(bearer)
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(details)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Path:\t%s\n", details.Path)
+	fmt.Fprintf(w, "Type:\t%s\n", orDash(details.Type))
+	status := "✗ Disconnected"
+	if details.Connected {
+		status = "✓ Connected"
+	}
+	if details.Suspended {
+		status += " (suspended)"
+	}
+	fmt.Fprintf(w, "Status:\t%s\n", status)
+	if details.ConnectionError != "" {
+		fmt.Fprintf(w, "Last error:\t%s\n", details.ConnectionError)
+	}
+	fmt.Fprintf(w, "Interface:\t%s\n", orDash(details.Interface))
+	fmt.Fprintf(w, "IP timeout:\t%ds\n", details.IPTimeout)
+	fmt.Fprintf(w, "\nAPN:\t%s\n", orDash(details.APN))
+	fmt.Fprintf(w, "IP type:\t%s\n", orDash(details.IPType))
+	fmt.Fprintf(w, "User:\t%s\n", orDash(details.User))
+	roaming := "not allowed"
+	if details.AllowRoaming {
+		roaming = "allowed"
+	}
+	fmt.Fprintf(w, "Roaming:\t%s\n", roaming)
+	for _, config := range []struct {
+		name   string
+		config *ipConfigJSON
+	}{{"IPv4", details.IPv4}, {"IPv6", details.IPv6}} {
+		if config.config == nil {
+			continue
+		}
+		c := config.config
+		fmt.Fprintf(w, "\n%s:\n", config.name)
+		fmt.Fprintf(w, "  Method:\t%s\n", c.Method)
+		if c.Address != "" {
+			fmt.Fprintf(w, "  Address:\t%s/%d\n", c.Address, c.Prefix)
+		}
+		fmt.Fprintf(w, "  Gateway:\t%s\n", orDash(c.Gateway))
+		fmt.Fprintf(w, "  DNS:\t%s\n", orDash(strings.Join(c.DNS, ", ")))
+		if c.MTU > 0 {
+			fmt.Fprintf(w, "  MTU:\t%d\n", c.MTU)
+		}
+	}
+	if details.Connected {
+		fmt.Fprintf(w, "\nRX:\t%s\n", humanBytes(details.RxBytes))
+		fmt.Fprintf(w, "TX:\t%s\n", humanBytes(details.TxBytes))
+		fmt.Fprintf(w, "Duration:\t%s\n", humanDuration(time.Duration(details.DurationSeconds)*time.Second))
+	}
+	return w.Flush()
+}
+
+func runBearerCreate(cmd *cobra.Command, args []string) error {
+	ipFamily, err := parseIPFamily(bearerIPType)
+	if err != nil {
+		return err
+	}
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+
+	bearer, err := modem.CreateBearer(modemmanager.BearerProperty{
+		APN:          bearerAPN,
+		IPType:       ipFamily,
+		User:         bearerUser,
+		Password:     bearerPassword,
+		AllowRoaming: bearerAllowRoaming,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create bearer: %w", err)
+	}
+	if !jsonOutput {
+		fmt.Printf("✓ Created bearer %s\n", bearer.GetObjectPath())
+	}
+
+	if bearerConnect {
+		if err := bearer.Connect(); err != nil {
+			return fmt.Errorf("failed to connect bearer %s: %w%s", bearer.GetObjectPath(), err, describeBearerFailure(modem, bearer))
+		}
+		if !jsonOutput {
+			iface, _ := bearer.GetInterface()
+			fmt.Printf("✓ Connected bearer %s (interface %s)\n", bearer.GetObjectPath(), orDash(iface))
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(readBearerDetails(bearer))
+	}
+	return nil
+}
+
+func runBearerDelete(cmd *cobra.Command, args []string) error {
+	modem, bearers, err := getBearers()
+	if err != nil {
+		return err
+	}
+	bearer, err := selectBearer(bearers, bearerSpec)
+	if err != nil {
+		return err
+	}
+	if err := modem.DeleteBearer(bearer); err != nil {
+		return fmt.Errorf("failed to delete bearer %s: %w", bearer.GetObjectPath(), err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{"path": bearer.GetObjectPath(), "deleted": true})
+	}
+	fmt.Printf("✓ Deleted bearer %s\n", bearer.GetObjectPath())
+	return nil
+}
+
+// bearerSample is one reading of the traffic counters in bearer stats
+type bearerSample struct {
+	Time            time.Time `json:"time"`
+	RxBytes         uint64    `json:"rx_bytes"`
+	TxBytes         uint64    `json:"tx_bytes"`
+	DurationSeconds uint32    `json:"duration_seconds"`
+	// Throughput since the previous sample, unknown for the first one and
+	// after the counters restarted
+	RxBytesPerSecond *float64 `json:"rx_bytes_per_second,omitempty"`
+	TxBytesPerSecond *float64 `json:"tx_bytes_per_second,omitempty"`
+}
+
+// nextBearerSample turns stats read at now into a sample with the
+// throughput since prev. It returns false if the counters did not change
+// since prev because ModemManager has not refreshed them yet; the duration
+// keeps counting on every refresh of a connected bearer.
+func nextBearerSample(prev *bearerSample, stats modemmanager.BearerStats, now time.Time) (bearerSample, bool) {
+	sample := bearerSample{Time: now, RxBytes: stats.RxBytes, TxBytes: stats.TxBytes, DurationSeconds: stats.Duration}
+	if prev == nil {
+		return sample, true
+	}
+	if stats.Duration > 0 && stats.Duration == prev.DurationSeconds &&
+		stats.RxBytes == prev.RxBytes && stats.TxBytes == prev.TxBytes {
+		return sample, false
+	}
+	elapsed := now.Sub(prev.Time).Seconds()
+	if elapsed > 0 && stats.RxBytes >= prev.RxBytes && stats.TxBytes >= prev.TxBytes {
+		rx := float64(stats.RxBytes-prev.RxBytes) / elapsed
+		tx := float64(stats.TxBytes-prev.TxBytes) / elapsed
+		sample.RxBytesPerSecond, sample.TxBytesPerSecond = &rx, &tx
+	}
+	return sample, true
+}
+
+// watchBearerStats calls emit with every changed sample read until ctx is
+// done. An error on the first read is returned, later ones are skipped.
+func watchBearerStats(ctx context.Context, read func() (modemmanager.BearerStats, error), interval time.Duration, emit func(bearerSample) error) error {
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev *bearerSample
+	for first := true; ; first = false {
+		stats, err := read()
+		switch {
+		case err != nil && first:
+			return fmt.Errorf("failed to get bearer statistics: %w", err)
+		case err != nil:
+		default:
+			if sample, changed := nextBearerSample(prev, stats, clk.Now()); changed {
+				if err := emit(sample); err != nil {
+					return err
+				}
+				prev = &sample
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C():
+		}
+	}
+}
+
+// formatBearerSample formats a sample as one line of bearer stats --watch
+func formatBearerSample(sample bearerSample) string {
+	line := fmt.Sprintf("%s  RX %s  TX %s", sample.Time.Format("15:04:05"), humanBytes(sample.RxBytes), humanBytes(sample.TxBytes))
+	if sample.RxBytesPerSecond != nil {
+		line += fmt.Sprintf("  ↓ %s  ↑ %s", humanBitRate(*sample.RxBytesPerSecond), humanBitRate(*sample.TxBytesPerSecond))
+	}
+	return line
+}
+
+// humanBytes formats a byte count with decimal units, e.g. "512 B" or "1.5 MB"
+func humanBytes(n uint64) string {
+	if n < 1000 {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	for _, unit := range []string{"kB", "MB", "GB", "TB"} {
+		value /= 1000
+		if value < 1000 || unit == "TB" {
+			return fmt.Sprintf("%.1f %s", value, unit)
+		}
+	}
+	return ""
+}
+
+// humanBitRate formats a rate in bytes per second as bits per second, e.g.
+// "800 bit/s" or "1.5 Mbit/s"
+func humanBitRate(bytesPerSecond float64) string {
+	value := bytesPerSecond * 8
+	if value < 1000 {
+		return fmt.Sprintf("%.0f bit/s", value)
+	}
+	for _, unit := range []string{"kbit/s", "Mbit/s", "Gbit/s"} {
+		value /= 1000
+		if value < 1000 || unit == "Gbit/s" {
+			return fmt.Sprintf("%.1f %s", value, unit)
+		}
+	}
+	return ""
+}
+
+func runBearerStats(cmd *cobra.Command, args []string) error {
+	if bearerInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	_, bearers, err := getBearers()
+	if err != nil {
+		return err
+	}
+	bearer, err := selectBearer(bearers, bearerSpec)
+	if err != nil {
+		return err
+	}
+	if connected, err := bearer.GetConnected(); err == nil && !connected {
+		return fmt.Errorf("bearer %s is not connected", bearer.GetObjectPath())
+	}
+
+	if !bearerWatch {
+		stats, err := bearer.GetStats()
+		if err != nil {
+			return fmt.Errorf("failed to get bearer statistics: %w", err)
+		}
+		if jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			sample, _ := nextBearerSample(nil, stats, clk.Now())
+			return encoder.Encode(sample)
+		}
+		fmt.Printf("RX:        %s (%d bytes)\n", humanBytes(stats.RxBytes), stats.RxBytes)
+		fmt.Printf("TX:        %s (%d bytes)\n", humanBytes(stats.TxBytes), stats.TxBytes)
+		fmt.Printf("Duration:  %s\n", humanDuration(time.Duration(stats.Duration)*time.Second))
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	encoder := json.NewEncoder(os.Stdout)
+	return watchBearerStats(ctx, bearer.GetStats, bearerInterval, func(sample bearerSample) error {
+		if jsonOutput {
+			return encoder.Encode(sample)
+		}
+		fmt.Println(formatBearerSample(sample))
+		return nil
+	})
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestParseIPFamily(t *testing.T) {
+	if family, err := parseIPFamily("ipv4v6"); err != nil || family != modemmanager.MmBearerIpFamilyIpv4v6 {
+		t.Errorf("parseIPFamily(ipv4v6) = %v, %v", family, err)
+	}
+	if _, err := parseIPFamily("ip"); err == nil {
+		t.Error("parseIPFamily(ip) succeeded")
+	}
+}
+
+func TestSelectBearer(t *testing.T) {
+	first, second := mocks.NewMockBearer(), mocks.NewMockBearer()
+	second.ObjectPathValue = modemmanager.BearerObjectPathPrefix + "1"
+
+	if bearer, err := selectBearer([]modemmanager.Bearer{first}, ""); err != nil || bearer != first {
+		t.Errorf("only bearer: %v, %v", bearer, err)
+	}
+	if bearer, err := selectBearer([]modemmanager.Bearer{first, second}, "1"); err != nil || bearer != second {
+		t.Errorf("index 1: %v, %v", bearer, err)
+	}
+	_, err := selectBearer([]modemmanager.Bearer{first, second}, "")
+	if err == nil || !strings.HasPrefix(err.Error(), "2 bearers found, select one with --bearer:\n  0: /org/freedesktop/ModemManager1/Bearer/0\n") {
+		t.Errorf("two bearers: error = %v", err)
+	}
+	if _, err := selectBearer(nil, ""); err == nil {
+		t.Error("no bearers: no error")
+	}
+}
+
+func TestReadBearerDetails(t *testing.T) {
+	bearer := mocks.NewMockBearer()
+	details := readBearerDetails(bearer)
+	if details.Connected || details.IPv4 != nil || details.APN != "internet" || details.IPType != "ipv4" {
+		t.Errorf("disconnected bearer: %+v", details)
+	}
+
+	bearer.ConnectedValue = true
+	bearer.StatsValue.Duration = 90
+	details = readBearerDetails(bearer)
+	if details.Interface != "wwan0" || details.RxBytes != 1024000 || details.DurationSeconds != 90 {
+		t.Errorf("connected bearer: %+v", details)
+	}
+	if details.IPv4 == nil || details.IPv4.Method != "static" || strings.Join(details.IPv4.DNS, ",") != "8.8.8.8,8.8.4.4" {
+		t.Errorf("IPv4 = %+v", details.IPv4)
+	}
+	if details.IPv6 != nil {
+		t.Errorf("IPv6 = %+v, want none", details.IPv6)
+	}
+}
+
+func TestCreateAndDeleteBearer(t *testing.T) {
+	modem := mocks.NewMockModem()
+	bearer, err := modem.CreateBearer(modemmanager.BearerProperty{APN: "iot", IPType: modemmanager.MmBearerIpFamilyIpv4v6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bearers, _ := modemmanager.ListModemBearers(modem)
+	if selected, err := selectBearer(bearers, "1"); err != nil || selected != bearer {
+		t.Fatalf("created bearer not listed: %v, %v", selected, err)
+	}
+	if details := readBearerDetails(bearer); details.APN != "iot" || details.IPType != "ipv4v6" {
+		t.Errorf("created bearer: %+v", details)
+	}
+	if err := modem.DeleteBearer(bearer); err != nil {
+		t.Fatal(err)
+	}
+	if bearers, _ := modemmanager.ListModemBearers(modem); len(bearers) != 1 {
+		t.Errorf("%d bearers left after deleting", len(bearers))
+	}
+}
+
+func TestNextBearerSample(t *testing.T) {
+	start := time.Unix(1000, 0)
+	stats := modemmanager.BearerStats{RxBytes: 1000, TxBytes: 500, Duration: 10}
+	first, changed := nextBearerSample(nil, stats, start)
+	if !changed || first.RxBytesPerSecond != nil {
+		t.Fatalf("first sample = %+v, %v", first, changed)
+	}
+
+	// Not refreshed yet
+	if _, changed := nextBearerSample(&first, stats, start.Add(time.Second)); changed {
+		t.Error("unchanged counters taken as a new sample")
+	}
+
+	stats = modemmanager.BearerStats{RxBytes: 251000, TxBytes: 10500, Duration: 15}
+	sample, changed := nextBearerSample(&first, stats, start.Add(5*time.Second))
+	if !changed || sample.RxBytesPerSecond == nil || *sample.RxBytesPerSecond != 50000 || *sample.TxBytesPerSecond != 2000 {
+		t.Errorf("sample = %+v, want 50000 and 2000 B/s", sample)
+	}
+	if got := formatBearerSample(sample); !strings.HasSuffix(got, "RX 251.0 kB  TX 10.5 kB  ↓ 400.0 kbit/s  ↑ 16.0 kbit/s") {
+		t.Errorf("formatBearerSample = %q", got)
+	}
+
+	// A reconnect restarts the counters
+	stats = modemmanager.BearerStats{RxBytes: 100, TxBytes: 100, Duration: 1}
+	if sample, changed := nextBearerSample(&first, stats, start.Add(10*time.Second)); !changed || sample.RxBytesPerSecond != nil {
+		t.Errorf("after restart = %+v, %v, want no throughput", sample, changed)
+	}
+
+	// Without duration every read counts, idle reads show zero throughput
+	idle := modemmanager.BearerStats{RxBytes: 1000, TxBytes: 500}
+	prev, _ := nextBearerSample(nil, idle, start)
+	if sample, changed := nextBearerSample(&prev, idle, start.Add(time.Second)); !changed || *sample.RxBytesPerSecond != 0 {
+		t.Errorf("idle without duration = %+v, %v", sample, changed)
+	}
+}
+
+func TestWatchBearerStats(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+	readings := []modemmanager.BearerStats{
+		{RxBytes: 1000, TxBytes: 100, Duration: 10},
+		{RxBytes: 1000, TxBytes: 100, Duration: 10},
+		{RxBytes: 3000, TxBytes: 300, Duration: 12},
+	}
+	reads := make(chan struct{}, 10)
+	read := func() (modemmanager.BearerStats, error) {
+		stats := readings[0]
+		if len(readings) > 1 {
+			readings = readings[1:]
+		}
+		reads <- struct{}{}
+		return stats, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var samples []bearerSample
+	done := make(chan error, 1)
+	go func() {
+		done <- watchBearerStats(ctx, read, time.Second, func(sample bearerSample) error {
+			samples = append(samples, sample)
+			return nil
+		})
+	}()
+	for i := 0; i < 3; i++ {
+		<-reads
+		if i == 2 {
+			cancel()
+		} else {
+			fake.Advance(time.Second)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[1].RxBytesPerSecond == nil || *samples[1].RxBytesPerSecond != 1000 {
+		t.Errorf("samples = %+v, want the unchanged read skipped and 1000 B/s", samples)
+	}
+}
+
+func TestHumanBytesAndBitRate(t *testing.T) {
+	for n, want := range map[uint64]string{0: "0 B", 999: "999 B", 1500: "1.5 kB", 2500000: "2.5 MB", 7e12: "7.0 TB"} {
+		if got := humanBytes(n); got != want {
+			t.Errorf("humanBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+	for rate, want := range map[float64]string{0: "0 bit/s", 100: "800 bit/s", 187500: "1.5 Mbit/s"} {
+		if got := humanBitRate(rate); got != want {
+			t.Errorf("humanBitRate(%v) = %q, want %q", rate, got, want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+// capabilitiesWaitInterval is how often set-capabilities --wait looks for
+// the modem
+const capabilitiesWaitInterval = 2 * time.Second
+
+var (
+	modemCapabilitiesCmd = &cobra.Command{
+		Use:   "capabilities",
+		Short: "Show current and supported capabilities",
+		Example: `  # Show the technology families modem 0 can be switched between
+  mmctl modem capabilities -m 0`,
+		Args: cobra.NoArgs,
+		RunE: runModemCapabilities,
+	}
+
+	modemSetCapabilitiesCmd = &cobra.Command{
+		Use:   "set-capabilities",
+		Short: "Switch the generic access technology families",
+		Long: `Switch the modem to another combination of access technology families, e.g.
+from gsm-umts,lte to lte only. Only the combinations listed by
+'mmctl modem capabilities' are accepted.
+
+Capabilities are pots, cdma-evdo, gsm-umts, lte, lte-advanced and iridium, in
+any case. Many modems reset to apply the change and show up again on the bus
+as a new modem; with --wait the command blocks until it is back.`,
+		Example: `  # LTE only, waiting for the modem to come back
+  mmctl modem set-capabilities -m 0 --caps lte --wait`,
+		Args: cobra.NoArgs,
+		RunE: runModemSetCapabilities,
+	}
+
+	// Flags
+	capabilitiesList    string
+	capabilitiesWait    bool
+	capabilitiesTimeout time.Duration
+)
+
+func init() {
+	modemCmd.AddCommand(modemCapabilitiesCmd)
+	modemCmd.AddCommand(modemSetCapabilitiesCmd)
+
+	modemSetCapabilitiesCmd.Flags().StringVar(&capabilitiesList, "caps", "", "Comma separated capabilities, e.g. gsm-umts,lte (required)")
+	modemSetCapabilitiesCmd.Flags().BoolVar(&capabilitiesWait, "wait", false, "Wait until the modem is back with the new capabilities")
+	modemSetCapabilitiesCmd.Flags().DurationVar(&capabilitiesTimeout, "timeout", 2*time.Minute, "Maximum time to wait with --wait")
+	modemSetCapabilitiesCmd.MarkFlagRequired("caps")
+}
+
+// capabilityNames are the capabilities as printed
+var capabilityNames = map[modemmanager.MMModemCapability]string{
+	modemmanager.MmModemCapabilityPots:        "pots",
+	modemmanager.MmModemCapabilityCdmaEvdo:    "cdma-evdo",
+	modemmanager.MmModemCapabilityGsmUmts:     "gsm-umts",
+	modemmanager.MmModemCapabilityLte:         "lte",
+	modemmanager.MmModemCapabilityLteAdvanced: "lte-advanced",
+	modemmanager.MmModemCapabilityIridium:     "iridium",
+	modemmanager.MmModemCapabilityAny:         "any",
+}
+
+// parseCapabilities parses the --caps value. Case, dashes and underscores
+// are ignored, so GsmUmts and gsm_umts are gsm-umts.
+func parseCapabilities(list string) ([]modemmanager.MMModemCapability, error) {
+	normalise := strings.NewReplacer("-", "", "_", "", " ", "")
+	var caps []modemmanager.MMModemCapability
+	seen := make(map[modemmanager.MMModemCapability]bool)
+	for _, name := range strings.Split(list, ",") {
+		key := normalise.Replace(strings.ToLower(name))
+		if key == "" {
+			continue
+		}
+		found := false
+		for capability, printed := range capabilityNames {
+			if capability != modemmanager.MmModemCapabilityAny && normalise.Replace(printed) == key {
+				found = true
+				if !seen[capability] {
+					seen[capability] = true
+					caps = append(caps, capability)
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown capability %q, expected pots, cdma-evdo, gsm-umts, lte, lte-advanced or iridium", strings.TrimSpace(name))
+		}
+	}
+	if len(caps) == 0 {
+		return nil, errors.New("no capabilities given")
+	}
+	sortCapabilities(caps)
+	return caps, nil
+}
+
+func sortCapabilities(caps []modemmanager.MMModemCapability) {
+	sort.Slice(caps, func(i, j int) bool { return caps[i] < caps[j] })
+}
+
+// formatCapabilities prints a combination, e.g. "gsm-umts, lte"
+func formatCapabilities(caps []modemmanager.MMModemCapability) string {
+	names := capabilityList(caps)
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func capabilityList(caps []modemmanager.MMModemCapability) []string {
+	sorted := append([]modemmanager.MMModemCapability(nil), caps...)
+	sortCapabilities(sorted)
+	names := make([]string, len(sorted))
+	for i, capability := range sorted {
+		name, ok := capabilityNames[capability]
+		if !ok {
+			name = strings.ToLower(capability.String())
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// sameCapabilities reports whether a and b hold the same capabilities
+func sameCapabilities(a, b []modemmanager.MMModemCapability) bool {
+	return formatCapabilities(a) == formatCapabilities(b)
+}
+
+// capabilitiesSupported reports whether caps is one of the supported combinations
+func capabilitiesSupported(supported [][]modemmanager.MMModemCapability, caps []modemmanager.MMModemCapability) bool {
+	for _, combination := range supported {
+		if sameCapabilities(combination, caps) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForCapabilities waits until find returns a modem with the capabilities
+// caps. Errors while the modem comes back are retried.
+func waitForCapabilities(find func() (modemmanager.Modem, error), caps []modemmanager.MMModemCapability, timeout, interval time.Duration) (modemmanager.Modem, error) {
+	var back modemmanager.Modem
+	err := pollUntil(context.Background(), nil, timeout, interval, func() (bool, error) {
+		modem, err := find()
+		if err != nil || modem == nil {
+			return false, nil
+		}
+		current, err := modem.GetCurrentCapabilities()
+		if err != nil {
+			return false, nil
+		}
+		if !sameCapabilities(current, caps) {
+			return false, fmt.Errorf("modem came back with capabilities %s instead of %s", formatCapabilities(current), formatCapabilities(caps))
+		}
+		back = modem
+		return true, nil
+	})
+	if errors.Is(err, errWaitTimeout) {
+		return nil, &exitError{code: exitTimeout, err: fmt.Errorf("modem not back with capabilities %s after %s", formatCapabilities(caps), timeout)}
+	}
+	return back, err
+}
+
+func runModemCapabilities(cmd *cobra.Command, args []string) error {
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	current, err := modem.GetCurrentCapabilities()
+	if err != nil {
+		return fmt.Errorf("failed to get current capabilities: %w", err)
+	}
+	supported, err := modem.GetSupportedCapabilities()
+	if err != nil {
+		return fmt.Errorf("failed to get supported capabilities: %w", err)
+	}
+
+	if jsonOutput {
+		out := struct {
+			Current   []string   `json:"current"`
+			Supported [][]string `json:"supported"`
+		}{Current: capabilityList(current), Supported: [][]string{}}
+		for _, combination := range supported {
+			out.Supported = append(out.Supported, capabilityList(combination))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	}
+
+	fmt.Printf("Current:   %s\n", formatCapabilities(current))
+	fmt.Println("Supported:")
+	for _, combination := range supported {
+		fmt.Printf("  %s\n", formatCapabilities(combination))
+	}
+	return nil
+}
+
+func runModemSetCapabilities(cmd *cobra.Command, args []string) error {
+	caps, err := parseCapabilities(capabilitiesList)
+	if err != nil {
+		return err
+	}
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	supported, err := modem.GetSupportedCapabilities()
+	if err != nil {
+		return fmt.Errorf("failed to get supported capabilities: %w", err)
+	}
+	if !capabilitiesSupported(supported, caps) {
+		lines := make([]string, len(supported))
+		for i, combination := range supported {
+			lines[i] = "  " + formatCapabilities(combination)
+		}
+		return fmt.Errorf("capabilities %s not supported by the modem, supported combinations:\n%s", formatCapabilities(caps), strings.Join(lines, "\n"))
+	}
+	if current, err := modem.GetCurrentCapabilities(); err == nil && sameCapabilities(current, caps) {
+		if jsonOutput {
+			return encodeCapabilitiesSet(modem.GetObjectPath(), caps, false)
+		}
+		fmt.Printf("Capabilities are already %s\n", formatCapabilities(caps))
+		return nil
+	}
+
+	// The modem is matched by its physical device once it is back
+	device, _ := modem.GetDevice()
+	if capabilitiesWait && device == "" {
+		return errors.New("cannot wait for the modem, its device path is unknown")
+	}
+
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Warning: many modems reset to apply new capabilities; connections are dropped.")
+	progress.Stage("setting", fmt.Sprintf("capabilities %s", formatCapabilities(caps)))
+	if err := modem.SetCurrentCapabilities(caps); err != nil {
+		return fmt.Errorf("failed to set capabilities: %w", err)
+	}
+
+	path := modem.GetObjectPath()
+	if capabilitiesWait {
+		progress.Stage("waiting", "for the modem to come back")
+		back, err := waitForCapabilities(func() (modemmanager.Modem, error) { return findResetModem(path, device) }, caps, capabilitiesTimeout, capabilitiesWaitInterval)
+		if err != nil {
+			return err
+		}
+		path = back.GetObjectPath()
+	}
+
+	if jsonOutput {
+		return encodeCapabilitiesSet(path, caps, true)
+	}
+	if capabilitiesWait {
+		fmt.Printf("✓ Modem is back as %s with capabilities %s\n", path, formatCapabilities(caps))
+	} else {
+		fmt.Printf("✓ Capabilities set to %s, the modem may reset\n", formatCapabilities(caps))
+	}
+	return nil
+}
+
+func encodeCapabilitiesSet(path dbus.ObjectPath, caps []modemmanager.MMModemCapability, changed bool) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"modem":        path,
+		"capabilities": capabilityList(caps),
+		"changed":      changed,
+	})
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	tests := map[string]string{
+		"lte":              "lte",
+		"LTE, GsmUmts":     "gsm-umts, lte",
+		"gsm_umts,lte,LTE": "gsm-umts, lte",
+		"Lte-Advanced":     "lte-advanced",
+		"cdma-evdo":        "cdma-evdo",
+	}
+	for list, want := range tests {
+		caps, err := parseCapabilities(list)
+		if err != nil || formatCapabilities(caps) != want {
+			t.Errorf("parseCapabilities(%q) = %v, %v, want %s", list, caps, err, want)
+		}
+	}
+	for _, bad := range []string{"", "5g", "lte,any"} {
+		if caps, err := parseCapabilities(bad); err == nil {
+			t.Errorf("parseCapabilities(%q) = %v, want an error", bad, caps)
+		}
+	}
+}
+
+func TestCapabilitiesSupported(t *testing.T) {
+	supported := [][]modemmanager.MMModemCapability{
+		{modemmanager.MmModemCapabilityLte, modemmanager.MmModemCapabilityGsmUmts},
+		{modemmanager.MmModemCapabilityLte},
+	}
+	if !capabilitiesSupported(supported, []modemmanager.MMModemCapability{modemmanager.MmModemCapabilityGsmUmts, modemmanager.MmModemCapabilityLte}) {
+		t.Error("listed combination in another order refused")
+	}
+	if capabilitiesSupported(supported, []modemmanager.MMModemCapability{modemmanager.MmModemCapabilityGsmUmts}) {
+		t.Error("unlisted combination accepted")
+	}
+}
+
+func TestWaitForCapabilities(t *testing.T) {
+	lte := []modemmanager.MMModemCapability{modemmanager.MmModemCapabilityLte}
+	back := mocks.NewMockModem()
+	back.CurrentCapabilitiesValue = lte
+
+	fake := useFakeClock(t, time.Unix(0, 0))
+	reads := make(chan struct{}, 10)
+	n := 0
+	find := func() (modemmanager.Modem, error) {
+		n++
+		defer func() { reads <- struct{}{} }()
+		if n == 1 {
+			return nil, nil
+		}
+		return back, nil
+	}
+	done := make(chan error, 1)
+	var modem modemmanager.Modem
+	go func() {
+		var err error
+		modem, err = waitForCapabilities(find, lte, time.Minute, time.Second)
+		done <- err
+	}()
+	<-reads
+	fake.Advance(time.Second)
+	if err := <-done; err != nil || modem != back {
+		t.Errorf("waitForCapabilities = %v, %v", modem, err)
+	}
+
+	gsm := []modemmanager.MMModemCapability{modemmanager.MmModemCapabilityGsmUmts, modemmanager.MmModemCapabilityLte}
+	if _, err := waitForCapabilities(func() (modemmanager.Modem, error) { return back, nil }, gsm, time.Minute, time.Second); err == nil ||
+		err.Error() != "modem came back with capabilities lte instead of gsm-umts, lte" {
+		t.Errorf("wrong capabilities: error = %v", err)
+	}
+
+	// The deadlines of the calls above are still pending
+	fake = useFakeClock(t, time.Unix(0, 0))
+	go func() {
+		_, err := waitForCapabilities(func() (modemmanager.Modem, error) { return nil, nil }, lte, 5*time.Second, time.Second)
+		done <- err
+	}()
+	fake.BlockUntilTimers(2)
+	fake.Advance(5 * time.Second)
+	if err := <-done; ExitCode(err) != exitTimeout || !strings.Contains(err.Error(), "not back with capabilities lte after 5s") {
+		t.Errorf("timeout: error = %v", err)
+	}
+}
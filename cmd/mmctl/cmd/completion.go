@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	completionCmd = &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate or install shell completion scripts",
+		Long: `Generate the completion script for the given shell on stdout, or install it
+for the current user with --install.
+
+--install detects the shell from $SHELL when none is given and writes the
+script to the user completion directory of the shell:
+
+  bash  $XDG_DATA_HOME/bash-completion/completions/mmctl
+  zsh   ~/.zsh/completions/_mmctl
+  fish  $XDG_CONFIG_HOME/fish/completions/mmctl.fish
+
+An existing script is kept as <file>.bak.`,
+		Example: `  # Load completion into the current bash session
+  source <(mmctl completion bash)
+
+  # Install completion for the login shell
+  mmctl completion --install
+
+  # Show where the zsh script would go
+  mmctl completion zsh --install --dry-run`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		RunE:      runCompletion,
+	}
+
+	// Flags
+	completionInstallFlag bool
+	completionDryRun      bool
+)
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+
+	completionCmd.Flags().BoolVar(&completionInstallFlag, "install", false, "Install the script to the user completion directory")
+	completionCmd.Flags().BoolVar(&completionDryRun, "dry-run", false, "With --install, print the target path and actions without writing")
+}
+
+// completionInstall describes where the completion script of a shell goes
+type completionInstall struct {
+	shell string
+	path  string
+	rc    string // line to add to the rc file, if the directory is not searched by default
+}
+
+// detectShell returns the shell named by $SHELL
+func detectShell(getenv func(string) string) (string, error) {
+	shell := filepath.Base(getenv("SHELL"))
+	switch shell {
+	case "bash", "zsh", "fish":
+		return shell, nil
+	default:
+		return "", fmt.Errorf("cannot detect shell from $SHELL=%q, pass bash, zsh or fish as argument", getenv("SHELL"))
+	}
+}
+
+// completionTarget returns the user-level install location for a shell
+func completionTarget(shell string, getenv func(string) string) (completionInstall, error) {
+	home := getenv("HOME")
+	if home == "" {
+		return completionInstall{}, fmt.Errorf("$HOME is not set")
+	}
+	xdgDir := func(variable, fallback string) string {
+		if dir := getenv(variable); filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(home, fallback)
+	}
+
+	switch shell {
+	case "bash":
+		// Loaded on demand by bash-completion 2.x
+		return completionInstall{
+			shell: shell,
+			path:  filepath.Join(xdgDir("XDG_DATA_HOME", ".local/share"), "bash-completion", "completions", "mmctl"),
+		}, nil
+	case "zsh":
+		return completionInstall{
+			shell: shell,
+			path:  filepath.Join(home, ".zsh", "completions", "_mmctl"),
+			rc:    "fpath=(~/.zsh/completions $fpath); autoload -Uz compinit && compinit",
+		}, nil
+	case "fish":
+		return completionInstall{
+			shell: shell,
+			path:  filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), "fish", "completions", "mmctl.fish"),
+		}, nil
+	default:
+		return completionInstall{}, fmt.Errorf("--install is not supported for %s, redirect the generated script instead", shell)
+	}
+}
+
+// generateCompletion writes the completion script of a shell
+func generateCompletion(shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(w, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(w)
+	case "fish":
+		return rootCmd.GenFishCompletion(w, true)
+	case "powershell":
+		return rootCmd.GenPowerShellCompletionWithDesc(w)
+	default:
+		return fmt.Errorf("unsupported shell %q, expected bash, zsh, fish or powershell", shell)
+	}
+}
+
+// writeCompletion installs script at target.path, keeping an existing file as
+// .bak, and reports each action to out. With dryRun nothing is written.
+func writeCompletion(target completionInstall, script []byte, dryRun bool, out io.Writer) error {
+	if _, err := os.Stat(target.path); err == nil {
+		if dryRun {
+			fmt.Fprintf(out, "Would back up %s to %s.bak\n", target.path, target.path)
+		} else {
+			if err := os.Rename(target.path, target.path+".bak"); err != nil {
+				return fmt.Errorf("failed to back up existing completion script: %w", err)
+			}
+			fmt.Fprintf(out, "Backed up %s to %s.bak\n", target.path, target.path)
+		}
+	}
+
+	if dryRun {
+		fmt.Fprintf(out, "Would write %s completion to %s\n", target.shell, target.path)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(target.path), 0o755); err != nil {
+			return fmt.Errorf("failed to create completion directory: %w", err)
+		}
+		if err := os.WriteFile(target.path, script, 0o644); err != nil {
+			return fmt.Errorf("failed to write completion script: %w", err)
+		}
+		fmt.Fprintf(out, "Wrote %s completion to %s\n", target.shell, target.path)
+	}
+
+	if target.rc != "" {
+		fmt.Fprintf(out, "Add this line to your ~/.%src if it is not there yet:\n  %s\n", target.shell, target.rc)
+	}
+	return nil
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	shell := ""
+	if len(args) > 0 {
+		shell = args[0]
+	}
+
+	if !completionInstallFlag {
+		if shell == "" {
+			return fmt.Errorf("shell required, expected bash, zsh, fish or powershell")
+		}
+		return generateCompletion(shell, os.Stdout)
+	}
+
+	if shell == "" {
+		var err error
+		if shell, err = detectShell(os.Getenv); err != nil {
+			return err
+		}
+	}
+	target, err := completionTarget(shell, os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	var script bytes.Buffer
+	if err := generateCompletion(shell, &script); err != nil {
+		return err
+	}
+	return writeCompletion(target, script.Bytes(), completionDryRun, os.Stdout)
+}
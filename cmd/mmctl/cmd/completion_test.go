@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func envFunc(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestDetectShell(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  string
+		ok    bool
+	}{
+		{"/bin/bash", "bash", true},
+		{"/usr/bin/zsh", "zsh", true},
+		{"/usr/local/bin/fish", "fish", true},
+		{"/bin/sh", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := detectShell(envFunc(map[string]string{"SHELL": tt.shell}))
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("detectShell(SHELL=%q) = %q, %v; want %q", tt.shell, got, err, tt.want)
+		}
+	}
+}
+
+func TestCompletionTarget(t *testing.T) {
+	tests := []struct {
+		shell  string
+		env    map[string]string
+		want   string
+		wantRC bool
+	}{
+		{"bash", map[string]string{"HOME": "/home/u"}, "/home/u/.local/share/bash-completion/completions/mmctl", false},
+		{"bash", map[string]string{"HOME": "/home/u", "XDG_DATA_HOME": "/data"}, "/data/bash-completion/completions/mmctl", false},
+		// Relative XDG paths are invalid per the spec and ignored
+		{"bash", map[string]string{"HOME": "/home/u", "XDG_DATA_HOME": "data"}, "/home/u/.local/share/bash-completion/completions/mmctl", false},
+		{"zsh", map[string]string{"HOME": "/home/u", "XDG_DATA_HOME": "/data"}, "/home/u/.zsh/completions/_mmctl", true},
+		{"fish", map[string]string{"HOME": "/home/u"}, "/home/u/.config/fish/completions/mmctl.fish", false},
+		{"fish", map[string]string{"HOME": "/home/u", "XDG_CONFIG_HOME": "/cfg"}, "/cfg/fish/completions/mmctl.fish", false},
+	}
+	for _, tt := range tests {
+		target, err := completionTarget(tt.shell, envFunc(tt.env))
+		if err != nil {
+			t.Errorf("completionTarget(%s, %v): %v", tt.shell, tt.env, err)
+			continue
+		}
+		if target.path != tt.want {
+			t.Errorf("completionTarget(%s, %v) = %s, want %s", tt.shell, tt.env, target.path, tt.want)
+		}
+		if (target.rc != "") != tt.wantRC {
+			t.Errorf("completionTarget(%s) rc hint = %q", tt.shell, target.rc)
+		}
+	}
+
+	if _, err := completionTarget("powershell", envFunc(map[string]string{"HOME": "/home/u"})); err == nil {
+		t.Error("completionTarget(powershell) succeeded, want error")
+	}
+	if _, err := completionTarget("bash", envFunc(nil)); err == nil {
+		t.Error("completionTarget without HOME succeeded, want error")
+	}
+}
+
+func TestWriteCompletion(t *testing.T) {
+	home := t.TempDir()
+	target, err := completionTarget("zsh", envFunc(map[string]string{"HOME": home}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeCompletion(target, []byte("new"), true, &out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(target.path)); !os.IsNotExist(err) {
+		t.Errorf("dry run created %s", filepath.Dir(target.path))
+	}
+	if !strings.Contains(out.String(), "Would write zsh completion to "+target.path) {
+		t.Errorf("dry run output = %q", out.String())
+	}
+
+	if err := writeCompletion(target, []byte("old"), false, &out); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := writeCompletion(target, []byte("new"), false, &out); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target.path); string(data) != "new" {
+		t.Errorf("installed script = %q, want new", data)
+	}
+	if data, _ := os.ReadFile(target.path + ".bak"); string(data) != "old" {
+		t.Errorf("backup = %q, want old", data)
+	}
+	if !strings.Contains(out.String(), "Backed up") || !strings.Contains(out.String(), target.rc) {
+		t.Errorf("install output = %q, want backup note and rc hint", out.String())
+	}
+}
+
+func TestGenerateCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		var buf bytes.Buffer
+		if err := generateCompletion(shell, &buf); err != nil || !strings.Contains(buf.String(), "mmctl") {
+			t.Errorf("generateCompletion(%s): %v, %d bytes", shell, err, buf.Len())
+		}
+	}
+	if err := generateCompletion("tcsh", &bytes.Buffer{}); err == nil {
+		t.Error("generateCompletion(tcsh) succeeded, want error")
+	}
+}
@@ -1,16 +1,27 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
+	"github.com/godbus/dbus/v5"
 	"github.com/maltegrosse/go-modemmanager"
 	"github.com/spf13/cobra"
 )
 
+// connectPollInterval is how often connect re-reads the bearer when no
+// property change signal arrives
+const connectPollInterval = 2 * time.Second
+
 var (
 	connectCmd = &cobra.Command{
 		Use:   "connect",
@@ -18,7 +29,13 @@ var (
 		Long: `Create a data connection to the mobile network.
 
 This command creates a bearer connection and activates it. You can specify
-connection parameters like APN, username, and password.`,
+connection parameters like APN, username, and password.
+
+A modem that is enabled but still searching for a network is first waited
+for until it registered, unless --no-wait is given. It then waits for the
+bearer to connect, as attaching can take tens of seconds on NB-IoT and
+congested networks. Both waits share --timeout. Ctrl-C while waiting
+disconnects the bearer again; a second Ctrl-C exits immediately.`,
 		Example: `  # Simple connect with APN
   mmctl connect -m 0 --apn internet
 
@@ -26,16 +43,27 @@ connection parameters like APN, username, and password.`,
   mmctl connect -m 0 --apn internet --user myuser --password mypass
 
   # Connect with specific IP type
-  mmctl connect -m 0 --apn internet --ip-type ipv4v6`,
+  mmctl connect -m 0 --apn internet --ip-type ipv4v6
+
+  # Allow a slow NB-IoT attach
+  mmctl connect -m 0 --apn iot --timeout 2m --verbose`,
 		RunE: runConnect,
 	}
 
 	disconnectCmd = &cobra.Command{
 		Use:   "disconnect",
 		Short: "Disconnect from mobile network",
-		Long:  `Disconnect an active data connection.`,
+		Long: `Disconnect active data connections.
+
+All connected bearers of the modem are disconnected unless --bearer selects
+one, by object path or by its index in the bearer list of 'mmctl status'. It
+fails if none of the selected bearers is connected.`,
 		Example: `  # Disconnect modem 0
-  mmctl disconnect -m 0`,
+  mmctl disconnect -m 0
+
+  # Disconnect only the second bearer
+  mmctl disconnect -m 0 --bearer 1
+  mmctl disconnect -m 0 --bearer /org/freedesktop/ModemManager1/Bearer/4`,
 		RunE: runDisconnect,
 	}
 
@@ -52,11 +80,17 @@ connection parameters like APN, username, and password.`,
 	}
 
 	// Connect flags
-	apn          string
-	username     string
-	password     string
-	ipType       string
-	allowRoaming bool
+	apn            string
+	username       string
+	password       string
+	ipType         string
+	allowRoaming   bool
+	connectTimeout time.Duration
+	connectNoWait  bool
+
+	// Disconnect flags
+	disconnectAll    bool
+	disconnectBearer string
 )
 
 func init() {
@@ -71,9 +105,21 @@ func init() {
 	connectCmd.Flags().StringVarP(&password, "password", "P", "", "Password for authentication")
 	connectCmd.Flags().StringVar(&ipType, "ip-type", "ipv4", "IP type (ipv4, ipv6, ipv4v6)")
 	connectCmd.Flags().BoolVar(&allowRoaming, "allow-roaming", false, "Allow connection while roaming")
+	connectCmd.Flags().DurationVar(&connectTimeout, "timeout", 60*time.Second, "Maximum time to wait for registration and the bearer to connect")
+	connectCmd.Flags().BoolVar(&connectNoWait, "no-wait", false, "Connect right away instead of waiting for the modem to register")
+
+	// Disconnect command flags
+	disconnectCmd.Flags().BoolVar(&disconnectAll, "all", false, "Disconnect all connected bearers (default)")
+	disconnectCmd.Flags().StringVar(&disconnectBearer, "bearer", "", "Disconnect only this bearer, by object path or index")
+	disconnectCmd.MarkFlagsMutuallyExclusive("all", "bearer")
 }
 
 func runConnect(cmd *cobra.Command, args []string) error {
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+
 	modem, err := getModem()
 	if err != nil {
 		return err
@@ -96,17 +142,9 @@ func runConnect(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Parse IP type
-	var ipFamily modemmanager.MMBearerIpFamily
-	switch ipType {
-	case "ipv4":
-		ipFamily = modemmanager.MmBearerIpFamilyIpv4
-	case "ipv6":
-		ipFamily = modemmanager.MmBearerIpFamilyIpv6
-	case "ipv4v6":
-		ipFamily = modemmanager.MmBearerIpFamilyIpv4v6
-	default:
-		return fmt.Errorf("invalid IP type: %s (must be ipv4, ipv6, or ipv4v6)", ipType)
+	ipFamily, err := parseIPFamily(ipType)
+	if err != nil {
+		return err
 	}
 
 	// Create connection properties
@@ -118,28 +156,59 @@ func runConnect(cmd *cobra.Command, args []string) error {
 		AllowedRoaming: allowRoaming,
 	}
 
+	// Ctrl-C disconnects the bearer, restore the default behaviour after the
+	// first one so that a second one exits right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Connecting fails right away while the modem is still searching
+	start := clk.Now()
+	if !connectNoWait {
+		signals := modem.SubscribeStateChanged()
+		err := waitForRegistration(ctx, modem, signals, connectTimeout, connectPollInterval, func(state modemmanager.MMModemState) {
+			progress.Stage("waiting-for-registration", strings.ToLower(state.String()))
+		})
+		modem.Unsubscribe()
+		if errors.Is(err, context.Canceled) {
+			return errors.New("interrupted while waiting for registration")
+		} else if err != nil {
+			return err
+		}
+	}
+
 	// Connect
-	fmt.Println("Connecting...")
+	progress.Stage("connecting", apn)
 	bearer, err := simple.Connect(props)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
 
 	// Wait for connection to establish
-	if verbose {
-		fmt.Println("Waiting for connection to establish...")
-	}
-	time.Sleep(2 * time.Second)
-
-	// Get connection status
-	connected, err := bearer.GetConnected()
-	if err != nil {
-		return fmt.Errorf("failed to get connection status: %w", err)
-	}
-
-	if !connected {
-		return fmt.Errorf("connection failed - bearer not connected")
+	progress.Stage("waiting-for-bearer", string(bearer.GetObjectPath()))
+	signals := bearer.SubscribePropertiesChanged()
+	defer bearer.Unsubscribe()
+	err = waitForBearer(ctx, bearer, signals, connectTimeout-clk.Now().Sub(start), connectPollInterval, func(elapsed time.Duration) {
+		if verbose {
+			fmt.Printf("Waiting for the bearer to connect (%s)...\n", humanDuration(elapsed))
+		}
+	})
+	switch {
+	case errors.Is(err, context.Canceled):
+		// Do not leave a half-established connection behind
+		if err := simple.Disconnect(bearer); err != nil {
+			return fmt.Errorf("interrupted, failed to disconnect bearer %s: %w", bearer.GetObjectPath(), err)
+		}
+		return fmt.Errorf("interrupted, disconnected bearer %s", bearer.GetObjectPath())
+	case errors.Is(err, errBearerTimeout):
+		return &exitError{code: exitTimeout, err: fmt.Errorf("%w after %s%s", err, connectTimeout, describeBearerFailure(modem, bearer))}
+	case err != nil:
+		return err
 	}
+	progress.Stage("connected", "")
 
 	fmt.Println("✓ Connected successfully!")
 
@@ -193,6 +262,104 @@ func runConnect(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// waitForRegistration waits until an enabled or searching modem registered
+// with a network, re-reading its state on every signal and interval tick. It
+// returns right away in other states, where connecting either registers the
+// modem or fails with a meaningful error. report is called with every state
+// seen while waiting. It gives up after timeout or once ctx is done.
+func waitForRegistration(ctx context.Context, modem modemmanager.Modem, signals <-chan *dbus.Signal, timeout, interval time.Duration, report func(state modemmanager.MMModemState)) error {
+	last := modemmanager.MmModemStateUnknown
+	var lastErr error
+	err := pollUntil(ctx, signals, timeout, interval, func() (bool, error) {
+		state, err := modem.GetState()
+		switch {
+		case err != nil:
+			// Retried, the state can be briefly unavailable
+			lastErr = err
+		case state != modemmanager.MmModemStateEnabled && state != modemmanager.MmModemStateSearching:
+			return true, nil
+		case state != last:
+			last = state
+			report(state)
+		}
+		return false, nil
+	})
+	if errors.Is(err, errWaitTimeout) {
+		err := fmt.Errorf("modem not registered after %s (modem %s)", timeout, strings.ToLower(last.String()))
+		if lastErr != nil {
+			err = fmt.Errorf("%w (last error: %v)", err, lastErr)
+		}
+		return &exitError{code: exitTimeout, err: err}
+	}
+	return err
+}
+
+// errBearerTimeout is returned by waitForBearer when the timeout expired
+var errBearerTimeout = errors.New("bearer not connected")
+
+// waitForBearer waits until the bearer is connected, re-reading it on every
+// signal and interval tick. It gives up after timeout or once ctx is done.
+// report is called with the time waited before each re-read.
+func waitForBearer(ctx context.Context, bearer modemmanager.Bearer, signals <-chan *dbus.Signal, timeout, interval time.Duration, report func(elapsed time.Duration)) error {
+	start := clk.Now()
+	first := true
+	err := pollUntil(ctx, signals, timeout, interval, func() (bool, error) {
+		if !first {
+			report(clk.Now().Sub(start))
+		}
+		first = false
+		// Read errors are retried, the bearer may be updating
+		connected, err := bearer.GetConnected()
+		return err == nil && connected, nil
+	})
+	if errors.Is(err, errWaitTimeout) {
+		return errBearerTimeout
+	}
+	return err
+}
+
+// describeBearerFailure returns why the bearer did not connect as far as
+// ModemManager tells, e.g. ": no carrier (modem registered)", or ""
+func describeBearerFailure(modem modemmanager.Modem, bearer modemmanager.Bearer) string {
+	var details []string
+	if name, message, err := bearer.GetConnectionError(); err == nil && name != "" {
+		if connErr, ok := modemmanager.ConnectionErrorFromName(name); ok {
+			text, _ := connErr.Describe()
+			details = append(details, text)
+		} else if message != "" {
+			details = append(details, message)
+		} else {
+			details = append(details, name)
+		}
+	}
+
+	var state []string
+	if s, err := modem.GetState(); err == nil {
+		state = append(state, "modem "+strings.ToLower(s.String()))
+		if s == modemmanager.MmModemStateFailed {
+			if reason, err := modem.GetStateFailedReason(); err == nil {
+				text, _ := reason.Describe()
+				state = append(state, text)
+			}
+		}
+	}
+	if modem3gpp, err := modem.Get3gpp(); err == nil {
+		if registration, err := modem3gpp.GetRegistrationState(); err == nil {
+			state = append(state, "registration "+strings.ToLower(registration.String()))
+		}
+	}
+
+	switch {
+	case len(details) > 0 && len(state) > 0:
+		return ": " + strings.Join(details, ", ") + " (" + strings.Join(state, ", ") + ")"
+	case len(details) > 0:
+		return ": " + strings.Join(details, ", ")
+	case len(state) > 0:
+		return " (" + strings.Join(state, ", ") + ")"
+	}
+	return ""
+}
+
 func runDisconnect(cmd *cobra.Command, args []string) error {
 	modem, err := getModem()
 	if err != nil {
@@ -206,35 +373,90 @@ func runDisconnect(cmd *cobra.Command, args []string) error {
 	}
 
 	if verbose {
-		fmt.Printf("Disconnecting modem %d...\n", modemIndex)
+		fmt.Printf("Disconnecting modem %s...\n", modem.GetObjectPath())
 	}
 
 	// Get bearers to disconnect
-	bearers, err := modem.GetBearers()
+	bearers, err := modemmanager.ListModemBearers(modem)
 	if err != nil {
 		return fmt.Errorf("failed to get bearers: %w", err)
 	}
+	bearers, err = selectBearers(bearers, disconnectBearer, disconnectAll)
+	if err != nil {
+		return err
+	}
 
+	return disconnectBearers(simple, bearers)
+}
+
+// selectBearers returns the bearer given by spec, an object path or an index
+// into bearers, or all bearers if all is set or spec is empty
+func selectBearers(bearers []modemmanager.Bearer, spec string, all bool) ([]modemmanager.Bearer, error) {
+	if all && spec != "" {
+		return nil, fmt.Errorf("--all and --bearer %s select different bearers, give only one", spec)
+	}
 	if len(bearers) == 0 {
-		return fmt.Errorf("no active bearers found")
+		return nil, fmt.Errorf("no bearers found")
+	}
+	if all || spec == "" {
+		return bearers, nil
 	}
 
-	// Disconnect each bearer
+	if index, err := strconv.Atoi(spec); err == nil {
+		if index < 0 || index >= len(bearers) {
+			return nil, fmt.Errorf("bearer index %d out of range (0-%d)", index, len(bearers)-1)
+		}
+		return bearers[index : index+1], nil
+	}
+	for _, bearer := range bearers {
+		if string(bearer.GetObjectPath()) == spec {
+			return []modemmanager.Bearer{bearer}, nil
+		}
+	}
+	paths := make([]string, len(bearers))
+	for i, bearer := range bearers {
+		paths[i] = fmt.Sprintf("  %d: %s", i, bearer.GetObjectPath())
+	}
+	return nil, fmt.Errorf("no bearer at path %s, available bearers:\n%s", spec, strings.Join(paths, "\n"))
+}
+
+// disconnectBearers disconnects the connected ones of bearers and prints
+// each one disconnected. It fails if none was connected or one could not be
+// disconnected.
+func disconnectBearers(simple modemmanager.ModemSimple, bearers []modemmanager.Bearer) error {
+	var disconnected, down, failed int
 	for _, bearer := range bearers {
 		connected, err := bearer.GetConnected()
 		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read bearer %s: %v\n", bearer.GetObjectPath(), err)
+			failed++
 			continue
 		}
-
-		if connected {
-			if err := simple.Disconnect(bearer); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to disconnect bearer: %v\n", err)
-			} else {
-				fmt.Println("✓ Disconnected successfully")
+		if !connected {
+			if verbose {
+				fmt.Printf("Bearer %s is not connected\n", bearer.GetObjectPath())
 			}
+			down++
+			continue
+		}
+
+		if err := simple.Disconnect(bearer); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to disconnect bearer %s: %v\n", bearer.GetObjectPath(), err)
+			failed++
+			continue
 		}
+		fmt.Printf("✓ Disconnected bearer %s\n", bearer.GetObjectPath())
+		disconnected++
 	}
 
+	switch {
+	case failed > 0:
+		return fmt.Errorf("failed to disconnect %d of %d bearers", failed, len(bearers))
+	case disconnected == 0 && down == 1:
+		return fmt.Errorf("no connected bearer found, bearer %s is already disconnected", bearers[0].GetObjectPath())
+	case disconnected == 0:
+		return fmt.Errorf("no connected bearer found, all %d bearers are already disconnected", down)
+	}
 	return nil
 }
 
@@ -251,7 +473,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get bearers
-	bearers, err := modem.GetBearers()
+	bearers, err := modemmanager.ListModemBearers(modem)
 	if err != nil {
 		return fmt.Errorf("failed to get bearers: %w", err)
 	}
@@ -334,11 +556,27 @@ func runStatus(cmd *cobra.Command, args []string) error {
 				}
 
 				if stats, err := bearer.GetStats(); err == nil {
-					info["stats"] = map[string]interface{}{
-						"bytes_rx": stats.RxBytes,
-						"bytes_tx": stats.TxBytes,
-						"duration": fmt.Sprintf("%ds", stats.Duration),
+					statsInfo := map[string]interface{}{
+						"bytes_rx":         stats.RxBytes,
+						"bytes_tx":         stats.TxBytes,
+						"duration":         fmt.Sprintf("%ds", stats.Duration),
+						"duration_seconds": stats.Duration,
+					}
+					// Lifetime statistics, only reported by ModemManager 1.14 and later
+					if stats.Attempts > 0 {
+						statsInfo["attempts"] = stats.Attempts
+						statsInfo["failed_attempts"] = stats.FailedAttempts
+						statsInfo["total_duration_seconds"] = stats.TotalDuration
+						statsInfo["total_bytes_rx"] = stats.TotalRxBytes
+						statsInfo["total_bytes_tx"] = stats.TotalTxBytes
+					}
+					if stats.UplinkSpeed > 0 {
+						statsInfo["uplink_speed_bps"] = stats.UplinkSpeed
+					}
+					if stats.DownlinkSpeed > 0 {
+						statsInfo["downlink_speed_bps"] = stats.DownlinkSpeed
 					}
+					info["stats"] = statsInfo
 				}
 			}
 
@@ -411,9 +649,22 @@ func runStatus(cmd *cobra.Command, args []string) error {
 				if txBytes, ok := stats["bytes_tx"].(uint64); ok {
 					fmt.Fprintf(w, "  TX:\t%d bytes\n", txBytes)
 				}
-				if duration, ok := stats["duration"].(string); ok {
+				if seconds, ok := stats["duration_seconds"].(uint32); ok {
+					duration := humanDuration(time.Duration(seconds) * time.Second)
+					if verbose {
+						duration = fmt.Sprintf("%s (%ds)", duration, seconds)
+					}
 					fmt.Fprintf(w, "  Duration:\t%s\n", duration)
 				}
+				if attempts, ok := stats["attempts"].(uint32); ok {
+					fmt.Fprintf(w, "  Attempts:\t%d (%v failed)\n", attempts, stats["failed_attempts"])
+				}
+				if speed, ok := stats["downlink_speed_bps"].(uint64); ok {
+					fmt.Fprintf(w, "  Downlink:\t%d bps\n", speed)
+				}
+				if speed, ok := stats["uplink_speed_bps"].(uint64); ok {
+					fmt.Fprintf(w, "  Uplink:\t%d bps\n", speed)
+				}
 			}
 		}
 	} else {
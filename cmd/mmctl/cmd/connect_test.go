@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+// fakeBearer is a bearer whose Connected property can change while
+// waitForBearer reads it
+type fakeBearer struct {
+	*mocks.MockBearer
+	connected atomic.Bool
+	reads     chan struct{}
+}
+
+func (b *fakeBearer) GetConnected() (bool, error) {
+	b.reads <- struct{}{}
+	return b.connected.Load(), nil
+}
+
+// startBearerWait runs waitForBearer with a fake clock and returns its result
+func startBearerWait(t *testing.T, ctx context.Context, bearer *fakeBearer, signals <-chan *dbus.Signal) (*clock.Fake, <-chan error) {
+	t.Helper()
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	done := make(chan error, 1)
+	go func() {
+		done <- waitForBearer(ctx, bearer, signals, time.Minute, 2*time.Second, func(time.Duration) {})
+	}()
+	return fake, done
+}
+
+func TestWaitForBearerSignal(t *testing.T) {
+	bearer := &fakeBearer{MockBearer: mocks.NewMockBearer(), reads: make(chan struct{})}
+	signals := make(chan *dbus.Signal)
+	_, done := startBearerWait(t, context.Background(), bearer, signals)
+
+	<-bearer.reads
+	bearer.connected.Store(true)
+	signals <- &dbus.Signal{Name: "org.freedesktop.DBus.Properties.PropertiesChanged"}
+	<-bearer.reads
+
+	if err := <-done; err != nil {
+		t.Errorf("waitForBearer error: %v", err)
+	}
+}
+
+func TestWaitForBearerTimeout(t *testing.T) {
+	bearer := &fakeBearer{MockBearer: mocks.NewMockBearer(), reads: make(chan struct{}, 100)}
+	fake, done := startBearerWait(t, context.Background(), bearer, nil)
+
+	fake.BlockUntilTimers(2)
+	fake.Advance(time.Minute)
+	if err := <-done; !errors.Is(err, errBearerTimeout) {
+		t.Errorf("waitForBearer error = %v, want %v", err, errBearerTimeout)
+	}
+}
+
+func TestWaitForBearerInterrupted(t *testing.T) {
+	bearer := &fakeBearer{MockBearer: mocks.NewMockBearer(), reads: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	_, done := startBearerWait(t, ctx, bearer, nil)
+
+	<-bearer.reads
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("waitForBearer error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestDescribeBearerFailure(t *testing.T) {
+	modem := mocks.NewMockModem()
+	bearer := mocks.NewMockBearer()
+	if got, want := describeBearerFailure(modem, bearer), " (modem registered, registration home)"; got != want {
+		t.Errorf("without connection error = %q, want %q", got, want)
+	}
+
+	bearer.ConnectionErrorName = modemmanager.ErrorConnectionPrefix + "NoCarrier"
+	if got := describeBearerFailure(modem, bearer); !strings.HasPrefix(got, ": ") || !strings.HasSuffix(got, " (modem registered, registration home)") {
+		t.Errorf("with connection error = %q", got)
+	}
+
+	bearer.ConnectionErrorName = "org.freedesktop.ModemManager1.Error.MobileEquipment.ServiceOptionNotSubscribed"
+	bearer.ConnectionErrorMessage = "Service option not subscribed"
+	modem.StateValue = modemmanager.MmModemStateFailed
+	modem.Get3gppError = errors.New("not supported")
+	if got := describeBearerFailure(modem, bearer); !strings.HasPrefix(got, ": Service option not subscribed (modem failed") {
+		t.Errorf("with 3GPP error = %q", got)
+	}
+}
+
+// advancingModem is a modem whose state moves to the next one in states
+// whenever the fake clock passes its step
+type advancingModem struct {
+	*mocks.MockModem
+	mu     sync.Mutex
+	fake   *clock.Fake
+	start  time.Time
+	step   time.Duration
+	states []modemmanager.MMModemState
+	reads  chan struct{}
+}
+
+func (m *advancingModem) GetState() (modemmanager.MMModemState, error) {
+	m.mu.Lock()
+	i := min(int(m.fake.Now().Sub(m.start)/m.step), len(m.states)-1)
+	state := m.states[i]
+	m.mu.Unlock()
+	m.reads <- struct{}{}
+	return state, nil
+}
+
+func TestWaitForRegistration(t *testing.T) {
+	const (
+		enabled    = modemmanager.MmModemStateEnabled
+		searching  = modemmanager.MmModemStateSearching
+		registered = modemmanager.MmModemStateRegistered
+		disabled   = modemmanager.MmModemStateDisabled
+	)
+	tests := []struct {
+		name       string
+		states     []modemmanager.MMModemState
+		advances   int // interval ticks needed before the wait ends
+		wantStates []modemmanager.MMModemState
+		wantErr    string
+	}{
+		{name: "registered", states: []modemmanager.MMModemState{registered}},
+		{name: "connected", states: []modemmanager.MMModemState{modemmanager.MmModemStateConnected}},
+		{name: "disabled", states: []modemmanager.MMModemState{disabled}},
+		{
+			name:       "searching then registered",
+			states:     []modemmanager.MMModemState{enabled, searching, searching, registered},
+			advances:   3,
+			wantStates: []modemmanager.MMModemState{enabled, searching},
+		},
+		{
+			name:       "searching then failed",
+			states:     []modemmanager.MMModemState{searching, modemmanager.MmModemStateFailed},
+			advances:   1,
+			wantStates: []modemmanager.MMModemState{searching},
+		},
+		{
+			name:       "never registers",
+			states:     []modemmanager.MMModemState{searching},
+			advances:   30,
+			wantStates: []modemmanager.MMModemState{searching},
+			wantErr:    "modem not registered after 1m0s (modem searching)",
+		},
+	}
+
+	for _, tt := range tests {
+		fake := clock.NewFake(time.Unix(0, 0))
+		prev := clk
+		clk = fake
+		modem := &advancingModem{MockModem: mocks.NewMockModem(), fake: fake, start: fake.Now(), step: 2 * time.Second, states: tt.states, reads: make(chan struct{}, 100)}
+
+		var seen []modemmanager.MMModemState
+		done := make(chan error, 1)
+		go func() {
+			done <- waitForRegistration(context.Background(), modem, nil, time.Minute, 2*time.Second, func(state modemmanager.MMModemState) {
+				seen = append(seen, state)
+			})
+		}()
+		for i := 0; i < tt.advances; i++ {
+			<-modem.reads
+			fake.BlockUntilTimers(2)
+			fake.Advance(2 * time.Second)
+		}
+		err := <-done
+		clk = prev
+
+		if tt.wantErr != "" {
+			var exit *exitError
+			if !errors.As(err, &exit) || exit.code != exitTimeout || err.Error() != tt.wantErr {
+				t.Errorf("%s: error = %v, want exit code %d and %q", tt.name, err, exitTimeout, tt.wantErr)
+			}
+		} else if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+		if fmt.Sprint(seen) != fmt.Sprint(tt.wantStates) {
+			t.Errorf("%s: reported %v, want %v", tt.name, seen, tt.wantStates)
+		}
+	}
+}
+
+func TestWaitForRegistrationInterrupted(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	modem := &advancingModem{MockModem: mocks.NewMockModem(), fake: fake, start: fake.Now(), step: time.Second, states: []modemmanager.MMModemState{modemmanager.MmModemStateSearching}, reads: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- waitForRegistration(ctx, modem, nil, time.Minute, 2*time.Second, func(modemmanager.MMModemState) {})
+	}()
+
+	<-modem.reads
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("waitForRegistration error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// testBearers returns mock bearers 3, 4, ... with the given connection states
+func testBearers(connected ...bool) []modemmanager.Bearer {
+	bearers := make([]modemmanager.Bearer, len(connected))
+	for i, c := range connected {
+		bearer := mocks.NewMockBearer()
+		bearer.ObjectPathValue = dbus.ObjectPath(fmt.Sprintf("%s%d", modemmanager.BearerObjectPathPrefix, i+3))
+		bearer.ConnectedValue = c
+		bearers[i] = bearer
+	}
+	return bearers
+}
+
+func TestSelectBearers(t *testing.T) {
+	bearers := testBearers(true, false, true)
+
+	tests := []struct {
+		spec    string
+		all     bool
+		bearers []modemmanager.Bearer
+		want    []modemmanager.Bearer
+		wantErr string
+	}{
+		{spec: "", bearers: bearers, want: bearers},
+		{spec: "", all: true, bearers: bearers, want: bearers},
+		{spec: "1", bearers: bearers, want: bearers[1:2]},
+		{spec: "1", all: true, bearers: bearers, wantErr: "--all and --bearer 1 select different bearers, give only one"},
+		{spec: "/org/freedesktop/ModemManager1/Bearer/5", bearers: bearers, want: bearers[2:3]},
+		{spec: "", wantErr: "no bearers found"},
+		{spec: "3", bearers: bearers, wantErr: "bearer index 3 out of range (0-2)"},
+		{spec: "-1", bearers: bearers, wantErr: "bearer index -1 out of range (0-2)"},
+		{
+			spec: "/org/freedesktop/ModemManager1/Bearer/0", bearers: bearers,
+			wantErr: "no bearer at path /org/freedesktop/ModemManager1/Bearer/0, available bearers:\n" +
+				"  0: /org/freedesktop/ModemManager1/Bearer/3\n" +
+				"  1: /org/freedesktop/ModemManager1/Bearer/4\n" +
+				"  2: /org/freedesktop/ModemManager1/Bearer/5",
+		},
+	}
+	for _, tt := range tests {
+		got, err := selectBearers(tt.bearers, tt.spec, tt.all)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("selectBearers(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("selectBearers(%q) error = %v", tt.spec, err)
+		} else if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("selectBearers(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestDisconnectBearers(t *testing.T) {
+	tests := []struct {
+		name          string
+		bearers       []modemmanager.Bearer
+		disconnectErr error
+		wantErr       string
+	}{
+		{name: "some connected", bearers: testBearers(true, false, true)},
+		{name: "one down", bearers: testBearers(false), wantErr: "no connected bearer found, bearer /org/freedesktop/ModemManager1/Bearer/3 is already disconnected"},
+		{name: "all down", bearers: testBearers(false, false), wantErr: "no connected bearer found, all 2 bearers are already disconnected"},
+		{name: "failing", bearers: testBearers(true, false), disconnectErr: errors.New("busy"), wantErr: "failed to disconnect 1 of 2 bearers"},
+	}
+	for _, tt := range tests {
+		simple := mocks.NewMockModemSimple()
+		simple.DisconnectError = tt.disconnectErr
+		err := disconnectBearers(simple, tt.bearers)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
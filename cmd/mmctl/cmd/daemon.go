@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/buildinfo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Manage the ModemManager daemon",
+		Long: `Operations on the ModemManager daemon itself rather than on a modem.
+
+Changing the daemon needs root or a polkit policy allowing it.`,
+		Example: `  # Show the ModemManager version
+  mmctl daemon version
+
+  # Look for new modems
+  sudo mmctl daemon scan`,
+	}
+
+	daemonScanCmd = &cobra.Command{
+		Use:   "scan",
+		Short: "Probe for new modem devices",
+		Long:  `Ask ModemManager to look for modem devices again, e.g. after plugging in a modem it missed.`,
+		Example: `  # Re-probe devices
+  sudo mmctl daemon scan`,
+		Args: cobra.NoArgs,
+		RunE: runDaemonScan,
+	}
+
+	daemonSetLoggingCmd = &cobra.Command{
+		Use:   "set-logging",
+		Short: "Set the log level of the daemon",
+		Long:  `Set the log level of ModemManager until it restarts: debug, info, warn or err.`,
+		Example: `  # Debug logs while reproducing a problem
+  sudo mmctl daemon set-logging --level debug`,
+		Args: cobra.NoArgs,
+		RunE: runDaemonSetLogging,
+	}
+
+	daemonVersionCmd = &cobra.Command{
+		Use:   "version",
+		Short: "Show the ModemManager version",
+		Example: `  # Show the version of the running daemon
+  mmctl daemon version --json`,
+		Args: cobra.NoArgs,
+		RunE: runDaemonVersion,
+	}
+
+	daemonReportKernelEventCmd = &cobra.Command{
+		Use:   "report-kernel-event",
+		Short: "Report a device added or removed by the kernel",
+		Long: `Tell ModemManager that the kernel added or removed a device port. This is only
+accepted when ModemManager runs without udev, e.g. with --no-udev on
+embedded systems.`,
+		Example: `  # Report a new serial port
+  sudo mmctl daemon report-kernel-event --action add --name ttyUSB2 --subsystem tty`,
+		Args: cobra.NoArgs,
+		RunE: runDaemonReportKernelEvent,
+	}
+
+	// Flags
+	daemonLoggingLevel   string
+	kernelEventAction    string
+	kernelEventName      string
+	kernelEventSubsystem string
+	kernelEventUID       string
+)
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonScanCmd)
+	daemonCmd.AddCommand(daemonSetLoggingCmd)
+	daemonCmd.AddCommand(daemonVersionCmd)
+	daemonCmd.AddCommand(daemonReportKernelEventCmd)
+
+	daemonSetLoggingCmd.Flags().StringVar(&daemonLoggingLevel, "level", "", "Log level: debug, info, warn or err (required)")
+	daemonSetLoggingCmd.MarkFlagRequired("level")
+
+	daemonReportKernelEventCmd.Flags().StringVar(&kernelEventAction, "action", "", "Kernel action: add or remove (required)")
+	daemonReportKernelEventCmd.Flags().StringVar(&kernelEventName, "name", "", "Device name, e.g. ttyUSB2 or wwan0 (required)")
+	daemonReportKernelEventCmd.Flags().StringVar(&kernelEventSubsystem, "subsystem", "", "Device subsystem, e.g. tty, net or usbmisc (required)")
+	daemonReportKernelEventCmd.Flags().StringVar(&kernelEventUID, "uid", "", "Unique ID of the physical device (default: its sysfs path)")
+	daemonReportKernelEventCmd.MarkFlagRequired("action")
+	daemonReportKernelEventCmd.MarkFlagRequired("name")
+	daemonReportKernelEventCmd.MarkFlagRequired("subsystem")
+}
+
+// connectModemManager connects to the daemon. Tests replace it.
+var connectModemManager = func() (modemmanager.ModemManager, error) {
+	mm, err := modemmanager.NewModemManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ModemManager: %w", err)
+	}
+	return mm, nil
+}
+
+// daemonError adds a hint on what to do to the common failures of the
+// daemon operation op
+func daemonError(op string, err error) error {
+	switch dbusErrorName(err) {
+	case modemmanager.ErrorCorePrefix + "Unauthorized",
+		"org.freedesktop.DBus.Error.AccessDenied":
+		return fmt.Errorf("not authorized to %s, run mmctl as root or adjust the polkit policy: %w", op, err)
+	case "org.freedesktop.DBus.Error.ServiceUnknown",
+		"org.freedesktop.DBus.Error.NameHasNoOwner":
+		return fmt.Errorf("failed to %s, ModemManager is not running: %w", op, err)
+	case modemmanager.ErrorCorePrefix + "Unsupported":
+		return fmt.Errorf("ModemManager does not support this, failed to %s: %w", op, err)
+	case modemmanager.ErrorCorePrefix + "InvalidArgs":
+		return fmt.Errorf("ModemManager rejected the arguments, failed to %s: %w", op, err)
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
+
+// loggingLevels maps the --level names to the daemon levels
+var loggingLevels = map[string]modemmanager.MMLoggingLevel{
+	"debug": modemmanager.MMLoggingLevelDebug,
+	"info":  modemmanager.MMLoggingLevelInfo,
+	"warn":  modemmanager.MMLoggingLevelWarning,
+	"err":   modemmanager.MMLoggingLevelError,
+}
+
+// parseLoggingLevel resolves the --level value; warning and error are
+// accepted as well
+func parseLoggingLevel(name string) (modemmanager.MMLoggingLevel, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "warning":
+		name = "warn"
+	case "error":
+		name = "err"
+	}
+	if level, ok := loggingLevels[name]; ok {
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown log level %q, expected debug, info, warn or err", name)
+}
+
+// kernelEvent builds the event reported by report-kernel-event
+func kernelEvent(action, name, subsystem, uid string) (modemmanager.EventProperties, error) {
+	event := modemmanager.EventProperties{
+		Name:      strings.TrimSpace(name),
+		Subsystem: strings.TrimSpace(subsystem),
+		Uid:       strings.TrimSpace(uid),
+	}
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case string(modemmanager.MMKernelPropertyActionAdd):
+		event.Action = modemmanager.MMKernelPropertyActionAdd
+	case string(modemmanager.MMKernelPropertyActionRemove):
+		event.Action = modemmanager.MMKernelPropertyActionRemove
+	default:
+		return event, fmt.Errorf("unknown action %q, expected add or remove", action)
+	}
+	if event.Name == "" || event.Subsystem == "" {
+		return event, fmt.Errorf("--name and --subsystem must not be empty")
+	}
+	return event, nil
+}
+
+func runDaemonScan(cmd *cobra.Command, args []string) error {
+	mm, err := connectModemManager()
+	if err != nil {
+		return err
+	}
+	if err := mm.ScanDevices(); err != nil {
+		return daemonError("scan for devices", err)
+	}
+	fmt.Println("✓ Requested a scan for modem devices")
+	return nil
+}
+
+func runDaemonSetLogging(cmd *cobra.Command, args []string) error {
+	level, err := parseLoggingLevel(daemonLoggingLevel)
+	if err != nil {
+		return err
+	}
+	mm, err := connectModemManager()
+	if err != nil {
+		return err
+	}
+	if err := mm.SetLogging(level); err != nil {
+		return daemonError("set the log level", err)
+	}
+	fmt.Printf("✓ Log level set to %s\n", strings.ToLower(string(level)))
+	return nil
+}
+
+func runDaemonVersion(cmd *cobra.Command, args []string) error {
+	mm, err := connectModemManager()
+	if err != nil {
+		return err
+	}
+	version, err := mm.GetVersion()
+	if err != nil {
+		return daemonError("get the version", err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"modemmanager": version,
+			"mmctl":        buildinfo.Get().String(),
+		})
+	}
+	fmt.Printf("ModemManager %s\n", version)
+	return nil
+}
+
+func runDaemonReportKernelEvent(cmd *cobra.Command, args []string) error {
+	event, err := kernelEvent(kernelEventAction, kernelEventName, kernelEventSubsystem, kernelEventUID)
+	if err != nil {
+		return err
+	}
+	mm, err := connectModemManager()
+	if err != nil {
+		return err
+	}
+	if err := mm.ReportKernelEvent(event); err != nil {
+		if dbusErrorName(err) == modemmanager.ErrorCorePrefix+"Unsupported" {
+			return fmt.Errorf("ModemManager does not accept kernel events while it monitors udev itself: %w", err)
+		}
+		return daemonError("report the kernel event", err)
+	}
+	fmt.Printf("✓ Reported %s of %s/%s\n", event.Action, event.Subsystem, event.Name)
+	return nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+// useMockModemManager makes connectModemManager return mm
+func useMockModemManager(t *testing.T, mm *mocks.MockModemManager) {
+	t.Helper()
+	prev := connectModemManager
+	connectModemManager = func() (modemmanager.ModemManager, error) { return mm, nil }
+	t.Cleanup(func() { connectModemManager = prev })
+}
+
+func TestParseLoggingLevel(t *testing.T) {
+	tests := map[string]modemmanager.MMLoggingLevel{
+		"debug":   modemmanager.MMLoggingLevelDebug,
+		"Info":    modemmanager.MMLoggingLevelInfo,
+		"warn":    modemmanager.MMLoggingLevelWarning,
+		"warning": modemmanager.MMLoggingLevelWarning,
+		" ERR ":   modemmanager.MMLoggingLevelError,
+		"error":   modemmanager.MMLoggingLevelError,
+	}
+	for name, want := range tests {
+		if level, err := parseLoggingLevel(name); err != nil || level != want {
+			t.Errorf("parseLoggingLevel(%q) = %v, %v, want %v", name, level, err, want)
+		}
+	}
+	if level, err := parseLoggingLevel("trace"); err == nil {
+		t.Errorf("parseLoggingLevel(trace) = %v, want an error", level)
+	}
+}
+
+func TestKernelEvent(t *testing.T) {
+	event, err := kernelEvent("ADD", "ttyUSB2", "tty", "")
+	if err != nil || event.Action != modemmanager.MMKernelPropertyActionAdd || event.Name != "ttyUSB2" || event.Subsystem != "tty" {
+		t.Errorf("kernelEvent = %+v, %v", event, err)
+	}
+	for _, bad := range [][3]string{{"change", "ttyUSB2", "tty"}, {"remove", " ", "tty"}, {"remove", "wwan0", ""}} {
+		if _, err := kernelEvent(bad[0], bad[1], bad[2], ""); err == nil {
+			t.Errorf("kernelEvent(%q) accepted", bad)
+		}
+	}
+}
+
+func TestDaemonError(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{modemmanager.ErrorCorePrefix + "Unauthorized", "not authorized to scan for devices, run mmctl as root or adjust the polkit policy: "},
+		{"org.freedesktop.DBus.Error.AccessDenied", "not authorized to scan for devices"},
+		{"org.freedesktop.DBus.Error.ServiceUnknown", "failed to scan for devices, ModemManager is not running: "},
+		{modemmanager.ErrorCorePrefix + "Failed", "failed to scan for devices: "},
+	}
+	for _, tt := range tests {
+		err := daemonError("scan for devices", dbus.Error{Name: tt.name, Body: []interface{}{"details"}})
+		if !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("daemonError(%s) = %q, want prefix %q", tt.name, err, tt.want)
+		}
+		if dbusErrorName(err) != tt.name {
+			t.Errorf("daemonError(%s) does not wrap the D-Bus error", tt.name)
+		}
+	}
+}
+
+func TestRunDaemonCommands(t *testing.T) {
+	mm := mocks.NewMockModemManager()
+	useMockModemManager(t, mm)
+
+	daemonLoggingLevel = "debug"
+	t.Cleanup(func() { daemonLoggingLevel = "" })
+	if err := runDaemonSetLogging(nil, nil); err != nil || mm.LoggingLevel != modemmanager.MMLoggingLevelDebug {
+		t.Errorf("set-logging: error = %v, level %q", err, mm.LoggingLevel)
+	}
+
+	kernelEventAction, kernelEventName, kernelEventSubsystem = "remove", "wwan0", "net"
+	t.Cleanup(func() { kernelEventAction, kernelEventName, kernelEventSubsystem = "", "", "" })
+	if err := runDaemonReportKernelEvent(nil, nil); err != nil || len(mm.KernelEvents) != 1 || mm.KernelEvents[0].Action != modemmanager.MMKernelPropertyActionRemove {
+		t.Errorf("report-kernel-event: error = %v, events %+v", err, mm.KernelEvents)
+	}
+
+	mm.ReportEventError = dbus.Error{Name: modemmanager.ErrorCorePrefix + "Unsupported"}
+	if err := runDaemonReportKernelEvent(nil, nil); err == nil || !strings.Contains(err.Error(), "monitors udev itself") {
+		t.Errorf("udev in use: error = %v", err)
+	}
+
+	mm.ScanDevicesError = dbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"}
+	if err := runDaemonScan(nil, nil); err == nil || !strings.Contains(err.Error(), "polkit") {
+		t.Errorf("scan denied: error = %v", err)
+	}
+}
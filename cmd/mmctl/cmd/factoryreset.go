@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	modemFactoryResetCmd = &cobra.Command{
+		Use:   "factory-reset",
+		Short: "Reset the modem to its factory defaults",
+		Long: `Reset the modem to its factory defaults with the carrier supplied code.
+
+Settings stored in the modem, such as the default bearer, allowed modes and
+bands, are lost and the modem usually restarts, dropping any connection.
+mmctl prints what will happen and asks for confirmation, or continues
+directly with --yes.`,
+		Example: `  # Factory reset modem 0
+  mmctl modem factory-reset -m 0 --code 000000
+
+  # Without a prompt, e.g. from a script
+  mmctl modem factory-reset -m 0 --code 000000 --yes`,
+		Args: cobra.NoArgs,
+		RunE: runModemFactoryReset,
+	}
+
+	// Flags
+	factoryResetCode    string
+	factoryResetConfirm bool
+)
+
+func init() {
+	modemCmd.AddCommand(modemFactoryResetCmd)
+
+	modemFactoryResetCmd.Flags().StringVar(&factoryResetCode, "code", "", "Carrier supplied factory reset code (required)")
+	modemFactoryResetCmd.Flags().BoolVar(&factoryResetConfirm, "yes", false, "Reset without asking for confirmation")
+	modemFactoryResetCmd.MarkFlagRequired("code")
+}
+
+func runModemFactoryReset(cmd *cobra.Command, args []string) error {
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+
+	plan := []string{
+		fmt.Sprintf("reset modem %s to its factory defaults", modemDescription(modem)),
+		"erase the settings stored in the modem",
+		"drop any connection while the modem restarts",
+	}
+	if err := confirmDisruption(plan, factoryResetConfirm); err != nil {
+		return err
+	}
+
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+	progress.Stage("resetting", fmt.Sprintf("modem %s to factory defaults", modem.GetObjectPath()))
+	if err := modem.FactoryReset(factoryResetCode); err != nil {
+		return fmt.Errorf("failed to factory reset modem: %w", err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"modem":         modem.GetObjectPath(),
+			"factory_reset": true,
+		})
+	}
+	fmt.Printf("✓ Modem %s reset to factory defaults\n", modem.GetObjectPath())
+	return nil
+}
@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+// firmwareWaitInterval is how often select --wait looks for the modem
+const firmwareWaitInterval = 2 * time.Second
+
+var (
+	firmwareCmd = &cobra.Command{
+		Use:   "firmware",
+		Short: "Inspect and select firmware images",
+		Long: `List the firmware images installed in a modem and select which one to run.
+
+Most modems run a single image and list none; multi-carrier modules (e.g.
+Sierra Wireless and other Gobi-based devices) carry one image per carrier.
+Firmware updates themselves are done by fwupd; the list shows the update
+method it will use.`,
+	}
+
+	firmwareListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List installed firmware images",
+		Example: `  # Show the images of modem 0 and how its firmware is updated
+  mmctl firmware list -m 0`,
+		Args: cobra.NoArgs,
+		RunE: runFirmwareList,
+	}
+
+	firmwareSelectCmd = &cobra.Command{
+		Use:   "select",
+		Short: "Switch to another installed firmware image",
+		Long: `Select another installed firmware image. The modem resets right away to boot
+the new image, dropping any connection, and shows up again on the bus as a
+new modem with another index.
+
+With --wait the command blocks until the modem is back with the new image
+selected.`,
+		Example: `  # Switch to the AT&T image and wait for the modem to come back
+  mmctl firmware select -m 0 --id 05.05.58.00_ATT --wait`,
+		Args: cobra.NoArgs,
+		RunE: runFirmwareSelect,
+	}
+
+	// Flags
+	firmwareID      string
+	firmwareWait    bool
+	firmwareTimeout time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(firmwareCmd)
+	firmwareCmd.AddCommand(firmwareListCmd)
+	firmwareCmd.AddCommand(firmwareSelectCmd)
+
+	firmwareSelectCmd.Flags().StringVar(&firmwareID, "id", "", "Unique ID of the firmware image to select (required)")
+	firmwareSelectCmd.Flags().BoolVar(&firmwareWait, "wait", false, "Wait until the modem is back with the new image active")
+	firmwareSelectCmd.Flags().DurationVar(&firmwareTimeout, "timeout", 3*time.Minute, "Maximum time to wait with --wait")
+	firmwareSelectCmd.MarkFlagRequired("id")
+}
+
+// firmwareImageTypeNames are the image types as printed for humans
+var firmwareImageTypeNames = map[modemmanager.MMFirmwareImageType]string{
+	modemmanager.MmFirmwareImageTypeUnknown: "unknown",
+	modemmanager.MmFirmwareImageTypeGeneric: "generic",
+	modemmanager.MmFirmwareImageTypeGobi:    "gobi",
+}
+
+// firmwareUpdateMethodNames are the update methods as named by ModemManager
+var firmwareUpdateMethodNames = map[modemmanager.MMModemFirmwareUpdateMethod]string{
+	modemmanager.MmModemFirmwareUpdateMethodFastboot: "fastboot",
+	modemmanager.MmModemFirmwareUpdateMethodQmiPdc:   "qmi-pdc",
+}
+
+// firmwareUpdateMethods names the update methods, leaving out none
+func firmwareUpdateMethods(methods []modemmanager.MMModemFirmwareUpdateMethod) []string {
+	names := []string{}
+	for _, method := range methods {
+		if method == modemmanager.MmModemFirmwareUpdateMethodNone {
+			continue
+		}
+		name, ok := firmwareUpdateMethodNames[method]
+		if !ok {
+			name = strings.ToLower(method.String())
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// getFirmware returns the selected modem, its Firmware interface and the
+// installed images
+func getFirmware() (modemmanager.Modem, modemmanager.ModemFirmware, []modemmanager.FirmwareProperty, error) {
+	modem, err := getModem()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	firmware, err := modem.GetFirmware()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("modem does not support firmware management: %w", err)
+	}
+	images, err := listFirmwareImages(firmware)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return modem, firmware, images, nil
+}
+
+// listFirmwareImages lists the installed images. Plugins without image
+// support answer Unsupported, which is the same as an empty list.
+func listFirmwareImages(firmware modemmanager.ModemFirmware) ([]modemmanager.FirmwareProperty, error) {
+	images, err := firmware.List()
+	if err != nil {
+		if dbusErrorName(err) == modemmanager.ErrorCorePrefix+"Unsupported" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list firmware images: %w", err)
+	}
+	return images, nil
+}
+
+// selectedFirmware returns the unique ID of the selected image, empty if none
+func selectedFirmware(images []modemmanager.FirmwareProperty) string {
+	for _, image := range images {
+		if image.Selected {
+			return image.UniqueId
+		}
+	}
+	return ""
+}
+
+// firmwareImageList lists the unique IDs of images, one per line
+func firmwareImageList(images []modemmanager.FirmwareProperty) string {
+	lines := make([]string, len(images))
+	for i, image := range images {
+		lines[i] = "  " + image.UniqueId
+		if image.Selected {
+			lines[i] += " (selected)"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// firmwareImageJSON is an installed image in the JSON output of firmware list
+type firmwareImageJSON struct {
+	UniqueID string `json:"unique_id"`
+	Type     string `json:"type"`
+	Version  string `json:"version,omitempty"`
+	Selected bool   `json:"selected"`
+}
+
+func runFirmwareList(cmd *cobra.Command, args []string) error {
+	_, firmware, images, err := getFirmware()
+	if err != nil {
+		return err
+	}
+	// Update settings are optional, modems without update support have none
+	settings, settingsErr := firmware.GetUpdateSettings()
+	methods := []string{}
+	if settingsErr == nil {
+		methods = firmwareUpdateMethods(settings.UpdateMethods)
+	}
+
+	if jsonOutput {
+		out := struct {
+			Images        []firmwareImageJSON `json:"images"`
+			Version       string              `json:"version,omitempty"`
+			UpdateMethods []string            `json:"update_methods"`
+			DeviceIDs     []string            `json:"device_ids,omitempty"`
+			FastbootAt    string              `json:"fastboot_at,omitempty"`
+		}{Images: []firmwareImageJSON{}, Version: settings.Version, UpdateMethods: methods, DeviceIDs: settings.DeviceIds, FastbootAt: settings.FastbootAt}
+		for _, image := range images {
+			out.Images = append(out.Images, firmwareImageJSON{
+				UniqueID: image.UniqueId,
+				Type:     firmwareImageTypeNames[image.ImageType],
+				Version:  image.GobiPriVersion,
+				Selected: image.Selected,
+			})
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	}
+
+	fmt.Printf("Firmware version: %s\n", orDash(settings.Version))
+	switch {
+	case len(methods) == 0:
+		fmt.Println("Update method:    none")
+	case settings.FastbootAt != "":
+		fmt.Printf("Update method:    %s (fastboot via %s)\n", strings.Join(methods, ", "), settings.FastbootAt)
+	default:
+		fmt.Printf("Update method:    %s\n", strings.Join(methods, ", "))
+	}
+	if verbose && len(settings.DeviceIds) > 0 {
+		fmt.Printf("Device IDs:       %s\n", strings.Join(settings.DeviceIds, ", "))
+	}
+	fmt.Println()
+
+	if len(images) == 0 {
+		fmt.Println("No firmware images listed: the modem runs a single image and has none to select.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SELECTED\tUNIQUE ID\tTYPE\tVERSION")
+	for _, image := range images {
+		selected := ""
+		if image.Selected {
+			selected = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", selected, image.UniqueId, firmwareImageTypeNames[image.ImageType], orDash(image.GobiPriVersion))
+	}
+	return w.Flush()
+}
+
+func runFirmwareSelect(cmd *cobra.Command, args []string) error {
+	modem, firmware, images, err := getFirmware()
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return errors.New("the modem lists no firmware images to select")
+	}
+	found := false
+	for _, image := range images {
+		found = found || image.UniqueId == firmwareID
+	}
+	if !found {
+		return fmt.Errorf("no firmware image %s, installed images:\n%s", firmwareID, firmwareImageList(images))
+	}
+	if selectedFirmware(images) == firmwareID {
+		if jsonOutput {
+			return encodeFirmwareSelect(modem.GetObjectPath(), false)
+		}
+		fmt.Printf("Firmware image %s is already selected\n", firmwareID)
+		return nil
+	}
+
+	// The modem is matched by its physical device once it is back
+	device, _ := modem.GetDevice()
+	if firmwareWait && device == "" {
+		return errors.New("cannot wait for the modem, its device path is unknown")
+	}
+
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Warning: the modem resets to boot firmware image %s; connections are dropped.\n", firmwareID)
+	progress.Stage("selecting", fmt.Sprintf("firmware image %s", firmwareID))
+	if err := firmware.Select(firmwareID); err != nil {
+		return fmt.Errorf("failed to select firmware image: %w", err)
+	}
+
+	path := modem.GetObjectPath()
+	if firmwareWait {
+		progress.Stage("waiting", "for the modem to come back")
+		back, err := waitForFirmware(func() (modemmanager.Modem, error) { return findResetModem(path, device) }, firmwareID, firmwareTimeout, firmwareWaitInterval)
+		if err != nil {
+			return err
+		}
+		path = back.GetObjectPath()
+	}
+
+	if jsonOutput {
+		return encodeFirmwareSelect(path, true)
+	}
+	if firmwareWait {
+		fmt.Printf("✓ Modem is back as %s running firmware image %s\n", path, firmwareID)
+	} else {
+		fmt.Printf("✓ Selected firmware image %s, the modem is resetting\n", firmwareID)
+	}
+	return nil
+}
+
+func encodeFirmwareSelect(path dbus.ObjectPath, changed bool) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"modem":     path,
+		"unique_id": firmwareID,
+		"changed":   changed,
+	})
+}
+
+// findResetModem returns the modem on device that replaced the one at path,
+// or nil while there is none
+func findResetModem(path dbus.ObjectPath, device string) (modemmanager.Modem, error) {
+	mm, err := modemmanager.NewModemManager()
+	if err != nil {
+		return nil, err
+	}
+	modems, err := mm.GetModems()
+	if err != nil {
+		return nil, err
+	}
+	for _, modem := range modems {
+		if modem.GetObjectPath() == path {
+			continue
+		}
+		if d, err := modem.GetDevice(); err == nil && d == device {
+			return modem, nil
+		}
+	}
+	return nil, nil
+}
+
+// waitForFirmware waits until find returns a modem with the image id
+// selected. Errors while the modem comes back are retried.
+func waitForFirmware(find func() (modemmanager.Modem, error), id string, timeout, interval time.Duration) (modemmanager.Modem, error) {
+	deadline := clk.After(timeout)
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if modem, err := find(); err == nil && modem != nil {
+			if firmware, err := modem.GetFirmware(); err == nil {
+				if images, err := firmware.List(); err == nil && len(images) > 0 {
+					selected := selectedFirmware(images)
+					if selected == id {
+						return modem, nil
+					}
+					return nil, fmt.Errorf("modem came back with firmware image %s selected instead of %s", orDash(selected), id)
+				}
+			}
+		}
+
+		select {
+		case <-deadline:
+			return nil, &exitError{code: exitTimeout, err: fmt.Errorf("modem not back with firmware image %s after %s", id, timeout)}
+		case <-ticker.C():
+		}
+	}
+}
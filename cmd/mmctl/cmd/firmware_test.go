@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestFirmwareUpdateMethods(t *testing.T) {
+	got := firmwareUpdateMethods([]modemmanager.MMModemFirmwareUpdateMethod{
+		modemmanager.MmModemFirmwareUpdateMethodNone,
+		modemmanager.MmModemFirmwareUpdateMethodFastboot,
+		modemmanager.MmModemFirmwareUpdateMethodQmiPdc,
+	})
+	if strings.Join(got, ",") != "fastboot,qmi-pdc" {
+		t.Errorf("firmwareUpdateMethods = %v", got)
+	}
+	if got := firmwareUpdateMethods(nil); got == nil || len(got) != 0 {
+		t.Errorf("firmwareUpdateMethods(nil) = %#v, want an empty list", got)
+	}
+}
+
+func TestListFirmwareImages(t *testing.T) {
+	firmware := mocks.NewMockModemFirmware()
+	images, err := listFirmwareImages(firmware)
+	if err != nil || len(images) != 2 || selectedFirmware(images) != "05.05.58.00_VZW" {
+		t.Errorf("listFirmwareImages = %v, %v", images, err)
+	}
+
+	firmware.ListError = &dbus.Error{Name: modemmanager.ErrorCorePrefix + "Unsupported"}
+	if images, err := listFirmwareImages(firmware); err != nil || len(images) != 0 {
+		t.Errorf("Unsupported: listFirmwareImages = %v, %v, want an empty list", images, err)
+	}
+	firmware.ListError = &dbus.Error{Name: modemmanager.ErrorCorePrefix + "Failed"}
+	if _, err := listFirmwareImages(firmware); err == nil {
+		t.Error("listFirmwareImages succeeded on a failure")
+	}
+}
+
+func TestWaitForFirmware(t *testing.T) {
+	back := mocks.NewMockModem()
+	back.ObjectPathValue = modemmanager.ModemPathFromIndex(1)
+	firmware := mocks.NewMockModemFirmware()
+	firmware.Select("05.05.58.00_ATT")
+	back.FirmwareValue = firmware
+
+	// The modem is gone for two reads, then probing for one
+	reads := 0
+	readDone := make(chan struct{}, 10)
+	find := func() (modemmanager.Modem, error) {
+		reads++
+		defer func() { readDone <- struct{}{} }()
+		switch {
+		case reads <= 2:
+			return nil, nil
+		case reads == 3:
+			return mocks.NewMockModem(), nil
+		}
+		return back, nil
+	}
+
+	fake := useFakeClock(t, time.Unix(0, 0))
+	done := make(chan error, 1)
+	var modem modemmanager.Modem
+	go func() {
+		var err error
+		modem, err = waitForFirmware(find, "05.05.58.00_ATT", time.Minute, time.Second)
+		done <- err
+	}()
+	var err error
+loop:
+	for {
+		select {
+		case err = <-done:
+			break loop
+		case <-readDone:
+			// The timers are set up before the first read
+			fake.Advance(time.Second)
+		}
+	}
+	if err != nil || modem != back {
+		t.Errorf("waitForFirmware = %v, %v", modem, err)
+	}
+
+	if _, err := waitForFirmware(func() (modemmanager.Modem, error) { return back, nil }, "05.05.58.00_VZW", time.Minute, time.Second); err == nil ||
+		err.Error() != "modem came back with firmware image 05.05.58.00_ATT selected instead of 05.05.58.00_VZW" {
+		t.Errorf("wrong image: error = %v", err)
+	}
+}
+
+func TestWaitForFirmwareTimeout(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+	done := make(chan error, 1)
+	go func() {
+		_, err := waitForFirmware(func() (modemmanager.Modem, error) { return nil, nil }, "05.05.58.00_ATT", 5*time.Second, time.Second)
+		done <- err
+	}()
+	fake.BlockUntilTimers(2)
+	fake.Advance(5 * time.Second)
+	err := <-done
+	if ExitCode(err) != exitTimeout || !strings.Contains(err.Error(), "not back with firmware image 05.05.58.00_ATT after 5s") {
+		t.Errorf("error = %v", err)
+	}
+}
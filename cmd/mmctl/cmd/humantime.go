@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// humanDuration formats a duration with its two most significant units,
+// e.g. "45s", "3m 20s", "2h 5m" or "1d 4h". Durations below one second are
+// shown as "<1s".
+func humanDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	if d < time.Second {
+		return "<1s"
+	}
+
+	days := d / day
+	hours := (d % day) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	seconds := (d % time.Minute) / time.Second
+
+	switch {
+	case days > 0:
+		return twoUnits(int64(days), "d", int64(hours), "h")
+	case hours > 0:
+		return twoUnits(int64(hours), "h", int64(minutes), "m")
+	case minutes > 0:
+		return twoUnits(int64(minutes), "m", int64(seconds), "s")
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// humanRelative formats t relative to now, e.g. "3m ago", "2h ago",
+// "1d 4h ago" or "in 5m" for timestamps in the future. Below an hour only the
+// largest unit is shown, as the exact seconds are rarely of interest.
+func humanRelative(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	if d < time.Second {
+		return "just now"
+	}
+
+	var s string
+	switch {
+	case d < time.Minute:
+		s = fmt.Sprintf("%ds", d/time.Second)
+	case d < time.Hour:
+		s = fmt.Sprintf("%dm", d/time.Minute)
+	case d < day:
+		s = fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		s = twoUnits(int64(d/day), "d", int64((d%day)/time.Hour), "h")
+	}
+
+	if future {
+		return "in " + s
+	}
+	return s + " ago"
+}
+
+// humanTimestamp formats t for tables: relative by default, with the absolute
+// time appended in verbose mode.
+func humanTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	rel := humanRelative(t, clk.Now())
+	if verbose {
+		return fmt.Sprintf("%s (%s)", rel, t.Format("2006-01-02 15:04:05"))
+	}
+	return rel
+}
+
+func twoUnits(major int64, majorUnit string, minor int64, minorUnit string) string {
+	if minor == 0 {
+		return fmt.Sprintf("%d%s", major, majorUnit)
+	}
+	return fmt.Sprintf("%d%s %d%s", major, majorUnit, minor, minorUnit)
+}
+
+// parseAge parses an age such as "30d", "2w" or any Go duration like "36h"
+func parseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	units := map[string]time.Duration{"d": day, "w": 7 * day}
+	for suffix, unit := range units {
+		if count, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 0 {
+				break
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 12h, 30d or 2w", value)
+	}
+	return d, nil
+}
+
+// parseSince parses a point in time given as a date (2024-01-01, local
+// time), an RFC 3339 timestamp or an age before now such as "7d"
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if age, err := parseAge(value); err == nil {
+		return now.Add(-age), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected a date like 2024-01-01, an RFC 3339 timestamp or an age like 7d", value)
+}
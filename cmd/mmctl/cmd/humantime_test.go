@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "<1s"},
+		{400 * time.Millisecond, "<1s"},
+		{time.Second, "1s"},
+		{45*time.Second + 900*time.Millisecond, "45s"},
+		{time.Minute, "1m"},
+		{3*time.Minute + 20*time.Second, "3m 20s"},
+		{2*time.Hour + 5*time.Minute + 59*time.Second, "2h 5m"},
+		{24 * time.Hour, "1d"},
+		{28*time.Hour + 30*time.Minute, "1d 4h"},
+		{400*24*time.Hour + 23*time.Hour, "400d 23h"},
+		{-90 * time.Second, "1m 30s"},
+	}
+	for _, tt := range tests {
+		if got := humanDuration(tt.in); got != tt.want {
+			t.Errorf("humanDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHumanRelative(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{0, "just now"},
+		{500 * time.Millisecond, "just now"},
+		{-500 * time.Millisecond, "just now"},
+		{12 * time.Second, "12s ago"},
+		{3*time.Minute + 59*time.Second, "3m ago"},
+		{2*time.Hour + 40*time.Minute, "2h ago"},
+		{24 * time.Hour, "1d ago"},
+		{28 * time.Hour, "1d 4h ago"},
+		{45*24*time.Hour + time.Hour, "45d 1h ago"},
+		{-5 * time.Minute, "in 5m"},
+		{-26 * time.Hour, "in 1d 2h"},
+	}
+	for _, tt := range tests {
+		if got := humanRelative(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("humanRelative(now-%v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d":   30 * day,
+		"2w":    14 * day,
+		"36h":   36 * time.Hour,
+		"90m":   90 * time.Minute,
+		" 0d ":  0,
+		"1h30m": 90 * time.Minute,
+	}
+	for value, want := range tests {
+		if got, err := parseAge(value); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "d", "-3d", "1.5d", "-1h", "month"} {
+		if got, err := parseAge(bad); err == nil {
+			t.Errorf("parseAge(%q) = %v, want an error", bad, got)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"2024-01-01":           time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		"2024-03-01T08:00:00Z": time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+		"7d":                   now.Add(-7 * day),
+		"12h":                  now.Add(-12 * time.Hour),
+	}
+	for value, want := range tests {
+		if got, err := parseSince(value, now); err != nil || !got.Equal(want) {
+			t.Errorf("parseSince(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	if got, err := parseSince("yesterday", now); err == nil {
+		t.Errorf("parseSince(yesterday) = %v, want an error", got)
+	}
+}
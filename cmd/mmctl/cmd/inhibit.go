@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inhibitCmd = &cobra.Command{
+		Use:   "inhibit",
+		Short: "Stop ModemManager from using a modem",
+		Long: `Inhibit a modem device so that ModemManager stops using it, e.g. while its
+firmware is flashed with an external tool. The modem disappears from the bus
+until the inhibition is released.
+
+ModemManager releases the inhibition as soon as the process that requested it
+exits, so --enable keeps running until Ctrl-C or until --duration has passed,
+then releases the device. The device is given by its uid, which is the Device
+property of the modem; mmctl reads it from the selected modem unless --uid is
+given. --disable lifts an inhibition; as the modem is not on the bus then, it
+needs --uid.
+
+Inhibiting asks for confirmation, or continues directly with --yes.`,
+		Example: `  # Inhibit modem 0 until Ctrl-C
+  mmctl inhibit -m 0 --enable
+
+  # Inhibit for at most 5 minutes, without a prompt
+  mmctl inhibit -m 0 --enable --duration 5m --yes
+
+  # Lift an inhibition
+  mmctl inhibit --disable --uid /sys/devices/platform/soc/usb1/1-1`,
+		Args: cobra.NoArgs,
+		RunE: runInhibit,
+	}
+
+	// Flags
+	inhibitEnable   bool
+	inhibitDisable  bool
+	inhibitUID      string
+	inhibitDuration time.Duration
+	inhibitConfirm  bool
+)
+
+func init() {
+	rootCmd.AddCommand(inhibitCmd)
+
+	inhibitCmd.Flags().BoolVar(&inhibitEnable, "enable", false, "Inhibit the device until Ctrl-C or --duration")
+	inhibitCmd.Flags().BoolVar(&inhibitDisable, "disable", false, "Lift the inhibition of the device")
+	inhibitCmd.Flags().StringVar(&inhibitUID, "uid", "", "Device uid, the Device property of the modem (default: that of the selected modem)")
+	inhibitCmd.Flags().DurationVar(&inhibitDuration, "duration", 0, "Release the device after this long (default: on Ctrl-C only)")
+	inhibitCmd.Flags().BoolVar(&inhibitConfirm, "yes", false, "Inhibit without asking for confirmation")
+	inhibitCmd.MarkFlagsMutuallyExclusive("enable", "disable")
+	inhibitCmd.MarkFlagsOneRequired("enable", "disable")
+}
+
+// holdInhibition inhibits the device uid, calls inhibited and releases the
+// device again once ctx is done or, if duration is set, after duration
+func holdInhibition(ctx context.Context, mm modemmanager.ModemManager, uid string, duration time.Duration, inhibited func()) error {
+	if err := mm.InhibitDevice(uid, true); err != nil {
+		return fmt.Errorf("failed to inhibit device %s: %w", uid, err)
+	}
+	inhibited()
+
+	var deadline <-chan time.Time
+	if duration > 0 {
+		deadline = clk.After(duration)
+	}
+	select {
+	case <-ctx.Done():
+	case <-deadline:
+	}
+
+	if err := mm.InhibitDevice(uid, false); err != nil {
+		return fmt.Errorf("failed to release device %s: %w", uid, err)
+	}
+	return nil
+}
+
+// inhibitTarget returns the device uid to inhibit and a description of it
+func inhibitTarget() (string, string, error) {
+	if inhibitUID != "" {
+		return inhibitUID, "device " + inhibitUID, nil
+	}
+	modem, err := getModem()
+	if err != nil {
+		if inhibitDisable {
+			return "", "", fmt.Errorf("%w (an inhibited modem is not on the bus, give its device with --uid)", err)
+		}
+		return "", "", err
+	}
+	uid, err := modem.GetDevice()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get device of the modem: %w", err)
+	}
+	if uid == "" {
+		return "", "", errors.New("the device of the modem is unknown, give it with --uid")
+	}
+	return uid, fmt.Sprintf("device %s of modem %s", uid, modemDescription(modem)), nil
+}
+
+func runInhibit(cmd *cobra.Command, args []string) error {
+	uid, target, err := inhibitTarget()
+	if err != nil {
+		return err
+	}
+	mm, err := connectModemManager()
+	if err != nil {
+		return err
+	}
+
+	if inhibitDisable {
+		if err := mm.InhibitDevice(uid, false); err != nil {
+			return fmt.Errorf("failed to release device %s: %w", uid, err)
+		}
+		return encodeInhibit(uid, 0, fmt.Sprintf("✓ Released %s", target))
+	}
+
+	release := "release it on Ctrl-C"
+	if inhibitDuration > 0 {
+		release = fmt.Sprintf("release it after %s or on Ctrl-C", inhibitDuration)
+	}
+	plan := []string{
+		fmt.Sprintf("make ModemManager stop using %s", target),
+		"drop any connection and remove the modem from the bus",
+		release,
+	}
+	if err := confirmDisruption(plan, inhibitConfirm); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := clk.Now()
+	err = holdInhibition(ctx, mm, uid, inhibitDuration, func() {
+		// Stdout only carries the final result with --json
+		out := os.Stdout
+		if jsonOutput {
+			out = os.Stderr
+		}
+		fmt.Fprintf(out, "✓ Inhibited %s, %s\n", uid, release)
+	})
+	if err != nil {
+		return err
+	}
+	held := clk.Now().Sub(start).Round(time.Second)
+	return encodeInhibit(uid, held, fmt.Sprintf("✓ Released %s after %s", uid, humanDuration(held)))
+}
+
+// encodeInhibit prints the released device, as JSON with --json and else as
+// the line done
+func encodeInhibit(uid string, held time.Duration, done string) error {
+	if !jsonOutput {
+		fmt.Println(done)
+		return nil
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"uid":          uid,
+		"inhibited":    false,
+		"held_seconds": held.Seconds(),
+	})
+}
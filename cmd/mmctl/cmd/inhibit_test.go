@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+// fakeYesNo replaces promptYesNo with one giving answer and err
+func fakeYesNo(t *testing.T, answer bool, err error) *int {
+	t.Helper()
+	asked := 0
+	prev := promptYesNo
+	promptYesNo = func(question string) (bool, error) {
+		asked++
+		return answer, err
+	}
+	t.Cleanup(func() { promptYesNo = prev })
+	return &asked
+}
+
+func TestConfirmDisruption(t *testing.T) {
+	plan := []string{"inhibit the modem"}
+
+	asked := fakeYesNo(t, false, nil)
+	if err := confirmDisruption(plan, true); err != nil || *asked != 0 {
+		t.Errorf("--yes: error = %v, asked %d times", err, *asked)
+	}
+	if err := confirmDisruption(plan, false); err == nil || err.Error() != "aborted" {
+		t.Errorf("answered no: error = %v, want aborted", err)
+	}
+
+	fakeYesNo(t, true, nil)
+	if err := confirmDisruption(plan, false); err != nil {
+		t.Errorf("answered yes: error = %v", err)
+	}
+
+	fakeYesNo(t, false, errNoTerminal)
+	if err := confirmDisruption(plan, false); err == nil || err.Error() != "refusing to continue without --yes" {
+		t.Errorf("no terminal: error = %v", err)
+	}
+}
+
+func TestHoldInhibition(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+	mm := mocks.NewMockModemManager()
+	const uid = "/sys/devices/usb1/1-1"
+
+	inhibited := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- holdInhibition(context.Background(), mm, uid, 5*time.Minute, func() { inhibited <- struct{}{} })
+	}()
+	<-inhibited
+	fake.BlockUntilTimers(1)
+	if !mm.Inhibited[uid] {
+		t.Error("device not inhibited")
+	}
+	fake.Advance(5 * time.Minute)
+	if err := <-done; err != nil || mm.Inhibited[uid] {
+		t.Errorf("after the duration: error = %v, inhibited %v", err, mm.Inhibited[uid])
+	}
+
+	// Without a duration until cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- holdInhibition(ctx, mm, uid, 0, func() { inhibited <- struct{}{} })
+	}()
+	<-inhibited
+	cancel()
+	if err := <-done; err != nil || mm.Inhibited[uid] {
+		t.Errorf("cancelled: error = %v, inhibited %v", err, mm.Inhibited[uid])
+	}
+
+	mm.InhibitDeviceError = errors.New("busy")
+	if err := holdInhibition(context.Background(), mm, uid, time.Minute, func() { t.Error("inhibited callback called") }); err == nil {
+		t.Error("inhibit error ignored")
+	}
+}
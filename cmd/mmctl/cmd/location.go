@@ -0,0 +1,678 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	locationCmd = &cobra.Command{
+		Use:   "location",
+		Short: "Manage location sources and read the modem location",
+		Long: `Enable GPS and 3GPP cell location sources and read the location they report.
+
+Sources:
+  3gpp            MCC, MNC, location area and cell ID of the serving cell
+  gps-raw         GPS latitude, longitude and altitude
+  gps-nmea        GPS NMEA sentences
+  cdma-bs         CDMA base station position
+  gps-unmanaged   GPS module setup only, read the NMEA port yourself
+  agps-msa        mobile station assisted A-GPS, with gps-raw or gps-nmea
+  agps-msb        mobile station based A-GPS, with gps-raw or gps-nmea`,
+	}
+
+	locationStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Show supported and enabled location sources",
+		Example: `  # Show the location sources of modem 0
+  mmctl location status -m 0`,
+		RunE: runLocationStatus,
+	}
+
+	locationEnableCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "Enable location sources",
+		Long: `Enable the location sources given by --sources in addition to the ones
+already enabled.
+
+With --signal ModemManager emits every location update as a D-Bus signal,
+which any client on the bus can listen to. Without --signal or --no-signal
+the current setting is kept.`,
+		Example: `  # Enable GPS and cell location with updates as signals
+  mmctl location enable -m 0 --sources gps-raw,3gpp --signal`,
+		RunE: runLocationEnable,
+	}
+
+	locationDisableCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Disable location sources",
+		Long:  `Disable the location sources given by --sources, or all of them without it.`,
+		Example: `  # Turn the GPS off but keep cell location
+  mmctl location disable -m 0 --sources gps-raw
+
+  # Stop gathering location information
+  mmctl location disable -m 0`,
+		RunE: runLocationDisable,
+	}
+
+	locationGetCmd = &cobra.Command{
+		Use:   "get",
+		Short: "Print the current location",
+		Long: `Print the GPS position and the serving cell, as far as the enabled sources
+report them.
+
+A GPS module can take several minutes to get its first fix after it was
+enabled. With --wait-fix the location is read every 2 seconds until there is a
+GPS position, for up to --timeout.`,
+		Example: `  # Print the location of modem 0
+  mmctl location get -m 0
+
+  # Wait up to two minutes for a GPS fix
+  mmctl location get -m 0 --wait-fix --timeout 120s --json`,
+		RunE: runLocationGet,
+	}
+
+	locationFollowCmd = &cobra.Command{
+		Use:   "follow",
+		Short: "Print a line per location update until interrupted",
+		Long: `Print a line whenever the location changes, until Ctrl-C.
+
+If the location sources were enabled with --signal, updates are taken from the
+D-Bus signals as they arrive. Otherwise the location is read every --interval.
+With --json every update is printed as a JSON object on a line of its own.
+
+Formats:
+  text   a line per update, or a JSON object per line with --json
+  gpx    a GPX 1.1 track of the GPS fixes (gps-raw source), rewritten every
+         --save-interval and on exit when --output is given, otherwise
+         printed on exit
+  nmea   the NMEA sentences of the gps-nmea source as they arrive.
+         ModemManager keeps the latest sentence of each type, sentences
+         unchanged since the previous update are not repeated`,
+		Example: `  # Follow the GPS position of modem 0
+  mmctl location follow -m 0 --interval 2s
+
+  # Record a drive test track
+  mmctl location follow -m 0 --format gpx --output track.gpx
+
+  # Log the raw NMEA sentences
+  mmctl location follow -m 0 --format nmea --output drive.nmea`,
+		RunE: runLocationFollow,
+	}
+
+	// Flags
+	locationSources  string
+	locationSignal   bool
+	locationNoSignal bool
+	locationWaitFix  bool
+	locationTimeout  time.Duration
+	locationInterval time.Duration
+	locationFormat   string
+	locationOutput   string
+	locationSave     time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(locationCmd)
+	locationCmd.AddCommand(locationStatusCmd)
+	locationCmd.AddCommand(locationEnableCmd)
+	locationCmd.AddCommand(locationDisableCmd)
+	locationCmd.AddCommand(locationGetCmd)
+	locationCmd.AddCommand(locationFollowCmd)
+
+	locationEnableCmd.Flags().StringVar(&locationSources, "sources", "", "Comma-separated location sources to enable")
+	locationEnableCmd.Flags().BoolVar(&locationSignal, "signal", false, "Emit location updates as D-Bus signals")
+	locationEnableCmd.Flags().BoolVar(&locationNoSignal, "no-signal", false, "Do not emit location updates as D-Bus signals")
+	locationEnableCmd.MarkFlagRequired("sources")
+	locationEnableCmd.MarkFlagsMutuallyExclusive("signal", "no-signal")
+
+	locationDisableCmd.Flags().StringVar(&locationSources, "sources", "", "Comma-separated location sources to disable, all if empty")
+
+	locationGetCmd.Flags().BoolVar(&locationWaitFix, "wait-fix", false, "Wait for a GPS fix")
+	locationGetCmd.Flags().DurationVar(&locationTimeout, "timeout", 120*time.Second, "Maximum time to wait for a GPS fix")
+
+	locationFollowCmd.Flags().DurationVar(&locationInterval, "interval", 2*time.Second, "Time between location reads without signals")
+	locationFollowCmd.Flags().StringVar(&locationFormat, "format", "text", "Output format: text, gpx or nmea")
+	locationFollowCmd.Flags().StringVarP(&locationOutput, "output", "o", "", "File to write the gpx or nmea output to, stdout if empty")
+	locationFollowCmd.Flags().DurationVar(&locationSave, "save-interval", 30*time.Second, "Time between rewrites of the gpx --output file")
+}
+
+// locationSourceNames maps the source names accepted by --sources, in
+// bitmask order
+var locationSourceNames = []struct {
+	name   string
+	source modemmanager.MMModemLocationSource
+}{
+	{"3gpp", modemmanager.MmModemLocationSource3gppLacCi},
+	{"gps-raw", modemmanager.MmModemLocationSourceGpsRaw},
+	{"gps-nmea", modemmanager.MmModemLocationSourceGpsNmea},
+	{"cdma-bs", modemmanager.MmModemLocationSourceCdmaBs},
+	{"gps-unmanaged", modemmanager.MmModemLocationSourceGpsUnmanaged},
+	{"agps-msa", modemmanager.MmModemLocationSourceAgpsMsa},
+	{"agps-msb", modemmanager.MmModemLocationSourceAgpsMsb},
+}
+
+// locationReport is the location as printed by get and follow
+type locationReport struct {
+	Gps    *gpsPosition  `json:"gps,omitempty"`
+	Cell   *cellLocation `json:"cell,omitempty"`
+	CdmaBs *gpsPosition  `json:"cdma_bs,omitempty"`
+}
+
+type gpsPosition struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude,omitempty"`
+	UtcTime   string  `json:"utc_time,omitempty"`
+}
+
+type cellLocation struct {
+	Mcc string `json:"mcc"`
+	Mnc string `json:"mnc"`
+	Lac string `json:"lac"`
+	Ci  string `json:"ci"`
+	Tac string `json:"tac"`
+}
+
+// getModemLocation returns the selected modem and its Location interface
+func getModemLocation() (modemmanager.Modem, modemmanager.ModemLocation, error) {
+	modem, err := getModem()
+	if err != nil {
+		return nil, nil, err
+	}
+	location, err := modem.GetLocation()
+	if err != nil {
+		return nil, nil, fmt.Errorf("modem does not support location: %w", err)
+	}
+	return modem, location, nil
+}
+
+func runLocationStatus(cmd *cobra.Command, args []string) error {
+	_, location, err := getModemLocation()
+	if err != nil {
+		return err
+	}
+	capabilities, err := location.GetCapabilities()
+	if err != nil {
+		return fmt.Errorf("failed to get location capabilities: %w", err)
+	}
+	enabled, err := location.GetEnabledLocationSources()
+	if err != nil {
+		return fmt.Errorf("failed to get enabled location sources: %w", err)
+	}
+	signals, _ := location.GetSignalsLocation()
+	rate, _ := location.GetGpsRefreshRate()
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"capabilities":     locationSourceList(capabilities),
+			"enabled":          locationSourceList(enabled),
+			"signals_location": signals,
+			"gps_refresh_rate": rate,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "Capabilities:\t%s\n", orDash(strings.Join(locationSourceList(capabilities), ", ")))
+	fmt.Fprintf(w, "Enabled:\t%s\n", orDash(strings.Join(locationSourceList(enabled), ", ")))
+	fmt.Fprintf(w, "Signals:\t%t\n", signals)
+	fmt.Fprintf(w, "GPS refresh rate:\t%s\n", humanDuration(time.Duration(rate)*time.Second))
+	return nil
+}
+
+func runLocationEnable(cmd *cobra.Command, args []string) error {
+	requested, err := parseLocationSources(locationSources)
+	if err != nil {
+		return err
+	}
+	_, location, err := getModemLocation()
+	if err != nil {
+		return err
+	}
+	var withSignals *bool
+	if cmd.Flags().Changed("signal") || cmd.Flags().Changed("no-signal") {
+		withSignals = &locationSignal
+	}
+	if err := enableLocationSources(location, requested, withSignals); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Enabled location sources %s\n", strings.Join(locationSourceList(requested), ", "))
+	for _, source := range requested {
+		if source == modemmanager.MmModemLocationSourceGpsRaw || source == modemmanager.MmModemLocationSourceGpsNmea {
+			fmt.Println("A first GPS fix can take a few minutes, wait for it with: mmctl location get --wait-fix")
+			break
+		}
+	}
+	return nil
+}
+
+func runLocationDisable(cmd *cobra.Command, args []string) error {
+	var requested []modemmanager.MMModemLocationSource
+	if locationSources != "" {
+		var err error
+		if requested, err = parseLocationSources(locationSources); err != nil {
+			return err
+		}
+	}
+	_, location, err := getModemLocation()
+	if err != nil {
+		return err
+	}
+	if err := disableLocationSources(location, requested); err != nil {
+		return err
+	}
+
+	if requested == nil {
+		fmt.Println("✓ Location gathering disabled")
+	} else {
+		fmt.Printf("✓ Disabled location sources %s\n", strings.Join(locationSourceList(requested), ", "))
+	}
+	return nil
+}
+
+func runLocationGet(cmd *cobra.Command, args []string) error {
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+	_, location, err := getModemLocation()
+	if err != nil {
+		return err
+	}
+
+	var report locationReport
+	if locationWaitFix {
+		enabled, err := location.GetEnabledLocationSources()
+		if err != nil {
+			return fmt.Errorf("failed to get enabled location sources: %w", err)
+		}
+		if !containsLocationSource(enabled, modemmanager.MmModemLocationSourceGpsRaw) {
+			return errors.New("gps-raw location source is not enabled, enable it with 'mmctl location enable --sources gps-raw'")
+		}
+		progress.Stage("waiting-for-fix", "up to "+humanDuration(locationTimeout))
+		stop := progress.Spinner("Waiting for a GPS fix")
+		report, err = waitForFix(location.GetCurrentLocation, locationTimeout, 2*time.Second)
+		stop()
+		if err != nil {
+			return err
+		}
+	} else {
+		current, err := location.GetCurrentLocation()
+		if err != nil {
+			return fmt.Errorf("failed to get location: %w", err)
+		}
+		report = newLocationReport(current)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	if report.Gps == nil && report.Cell == nil && report.CdmaBs == nil {
+		fmt.Println("No location available, enable a source with 'mmctl location enable' or wait for a GPS fix with --wait-fix")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	if gps := report.Gps; gps != nil {
+		fmt.Fprintf(w, "Latitude:\t%.6f\n", gps.Latitude)
+		fmt.Fprintf(w, "Longitude:\t%.6f\n", gps.Longitude)
+		fmt.Fprintf(w, "Altitude:\t%.1f m\n", gps.Altitude)
+		fmt.Fprintf(w, "UTC:\t%s\n", orDash(gps.UtcTime))
+	}
+	if cell := report.Cell; cell != nil {
+		fmt.Fprintf(w, "MCC/MNC:\t%s/%s\n", cell.Mcc, cell.Mnc)
+		fmt.Fprintf(w, "LAC:\t%s\n", orDash(cell.Lac))
+		fmt.Fprintf(w, "TAC:\t%s\n", orDash(cell.Tac))
+		fmt.Fprintf(w, "Cell ID:\t%s\n", orDash(cell.Ci))
+	}
+	if cdma := report.CdmaBs; cdma != nil {
+		fmt.Fprintf(w, "CDMA base station:\t%.6f, %.6f\n", cdma.Latitude, cdma.Longitude)
+	}
+	return nil
+}
+
+func runLocationFollow(cmd *cobra.Command, args []string) error {
+	if locationInterval <= 0 {
+		return fmt.Errorf("--interval must be positive, got %s", locationInterval)
+	}
+	var source modemmanager.MMModemLocationSource
+	switch locationFormat {
+	case "text":
+		if locationOutput != "" {
+			return errors.New("--output needs --format gpx or nmea")
+		}
+	case "gpx":
+		source = modemmanager.MmModemLocationSourceGpsRaw
+		if locationSave <= 0 {
+			return fmt.Errorf("--save-interval must be positive, got %s", locationSave)
+		}
+	case "nmea":
+		source = modemmanager.MmModemLocationSourceGpsNmea
+	default:
+		return fmt.Errorf("invalid format: %s (must be text, gpx or nmea)", locationFormat)
+	}
+	if jsonOutput && locationFormat != "text" {
+		return fmt.Errorf("--json cannot be combined with --format %s", locationFormat)
+	}
+
+	modem, location, err := getModemLocation()
+	if err != nil {
+		return err
+	}
+	if source != modemmanager.MmModemLocationSourceNone {
+		enabled, err := location.GetEnabledLocationSources()
+		if err != nil {
+			return fmt.Errorf("failed to get enabled location sources: %w", err)
+		}
+		if !containsLocationSource(enabled, source) {
+			name := locationSourceList([]modemmanager.MMModemLocationSource{source})[0]
+			return fmt.Errorf("%s location source is not enabled, enable it with 'mmctl location enable --sources %s'", name, name)
+		}
+	}
+
+	// The Location property is only kept up to date, and announced in a
+	// PropertiesChanged signal, when the sources were set up with signals
+	read := location.GetCurrentLocation
+	var signals <-chan *dbus.Signal
+	if on, err := location.GetSignalsLocation(); err == nil && on {
+		read = location.GetLocation
+		signals = modem.SubscribePropertiesChanged()
+		defer modem.Unsubscribe()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var recorder locationRecorder
+	switch locationFormat {
+	case "gpx":
+		recorder, err = newGpxRecorder(locationOutput, os.Stdout, locationSave)
+	case "nmea":
+		recorder, err = newNmeaRecorder(locationOutput, os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+	if recorder != nil {
+		err := followLocation(ctx, read, signals, locationInterval, recorder.Add)
+		// Write what was recorded also when following failed
+		if closeErr := recorder.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	return followLocation(ctx, read, signals, locationInterval, locationChanges(func(report locationReport) error {
+		if jsonOutput {
+			return encoder.Encode(report)
+		}
+		fmt.Printf("%s  %s\n", clk.Now().Format("15:04:05"), formatLocationLine(report))
+		return nil
+	}))
+}
+
+// parseLocationSources parses a comma-separated list of source names
+func parseLocationSources(spec string) ([]modemmanager.MMModemLocationSource, error) {
+	var sources []modemmanager.MMModemLocationSource
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, s := range locationSourceNames {
+			if s.name == name {
+				sources = append(sources, s.source)
+				found = true
+			}
+		}
+		if !found {
+			names := make([]string, len(locationSourceNames))
+			for i, s := range locationSourceNames {
+				names[i] = s.name
+			}
+			return nil, fmt.Errorf("unknown location source %q (must be one of %s)", name, strings.Join(names, ", "))
+		}
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("no location sources given")
+	}
+	return sortLocationSources(sources), nil
+}
+
+// sortLocationSources returns sources in bitmask order without duplicates
+func sortLocationSources(sources []modemmanager.MMModemLocationSource) []modemmanager.MMModemLocationSource {
+	var s modemmanager.MMModemLocationSource
+	return s.BitmaskToSlice(s.SliceToBitmask(sources))
+}
+
+// locationSourceList returns the --sources names of sources
+func locationSourceList(sources []modemmanager.MMModemLocationSource) []string {
+	names := []string{}
+	for _, source := range sortLocationSources(sources) {
+		for _, s := range locationSourceNames {
+			if s.source == source {
+				names = append(names, s.name)
+			}
+		}
+	}
+	return names
+}
+
+func containsLocationSource(sources []modemmanager.MMModemLocationSource, source modemmanager.MMModemLocationSource) bool {
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLocationSources validates the set of sources passed to Setup the way
+// ModemManager does, to fail with the reason rather than a generic error
+func checkLocationSources(sources, capabilities []modemmanager.MMModemLocationSource) error {
+	for _, source := range sources {
+		if !containsLocationSource(capabilities, source) {
+			return fmt.Errorf("modem does not support location source %s (supported: %s)",
+				strings.Join(locationSourceList([]modemmanager.MMModemLocationSource{source}), ""),
+				orDash(strings.Join(locationSourceList(capabilities), ", ")))
+		}
+	}
+	msa := containsLocationSource(sources, modemmanager.MmModemLocationSourceAgpsMsa)
+	msb := containsLocationSource(sources, modemmanager.MmModemLocationSourceAgpsMsb)
+	gps := containsLocationSource(sources, modemmanager.MmModemLocationSourceGpsRaw) ||
+		containsLocationSource(sources, modemmanager.MmModemLocationSourceGpsNmea)
+	switch {
+	case msa && msb:
+		return errors.New("agps-msa and agps-msb cannot be enabled together")
+	case (msa || msb) && !gps:
+		return errors.New("A-GPS needs gps-raw or gps-nmea enabled as well")
+	}
+	return nil
+}
+
+// enableLocationSources adds sources to the enabled ones. A nil withSignals
+// keeps the current signals setting.
+func enableLocationSources(location modemmanager.ModemLocation, sources []modemmanager.MMModemLocationSource, withSignals *bool) error {
+	capabilities, err := location.GetCapabilities()
+	if err != nil {
+		return fmt.Errorf("failed to get location capabilities: %w", err)
+	}
+	enabled, err := location.GetEnabledLocationSources()
+	if err != nil {
+		return fmt.Errorf("failed to get enabled location sources: %w", err)
+	}
+	merged := sortLocationSources(append(append([]modemmanager.MMModemLocationSource{}, enabled...), sources...))
+	if err := checkLocationSources(merged, capabilities); err != nil {
+		return err
+	}
+
+	signalLocation, _ := location.GetSignalsLocation()
+	if withSignals != nil {
+		signalLocation = *withSignals
+	}
+	if err := location.Setup(merged, signalLocation); err != nil {
+		return fmt.Errorf("failed to enable location sources: %w", err)
+	}
+	return nil
+}
+
+// disableLocationSources removes sources from the enabled ones, or disables
+// all of them if sources is nil
+func disableLocationSources(location modemmanager.ModemLocation, sources []modemmanager.MMModemLocationSource) error {
+	var remaining []modemmanager.MMModemLocationSource
+	if sources != nil {
+		enabled, err := location.GetEnabledLocationSources()
+		if err != nil {
+			return fmt.Errorf("failed to get enabled location sources: %w", err)
+		}
+		for _, source := range enabled {
+			if !containsLocationSource(sources, source) {
+				remaining = append(remaining, source)
+			}
+		}
+		if err := checkLocationSources(remaining, enabled); err != nil {
+			return err
+		}
+	}
+
+	signalLocation, _ := location.GetSignalsLocation()
+	if err := location.Setup(remaining, signalLocation); err != nil {
+		return fmt.Errorf("failed to disable location sources: %w", err)
+	}
+	return nil
+}
+
+// newLocationReport keeps the parts of location that a source reported
+func newLocationReport(location modemmanager.CurrentLocation) locationReport {
+	var report locationReport
+	if gps := location.GpsRaw; gps.Latitude != 0 || gps.Longitude != 0 {
+		report.Gps = &gpsPosition{Latitude: gps.Latitude, Longitude: gps.Longitude, Altitude: gps.Altitude}
+		// ModemManager reports the time of day only
+		if !gps.UtcTime.IsZero() {
+			report.Gps.UtcTime = gps.UtcTime.Format("15:04:05")
+		}
+	}
+	if cell := location.ThreeGppLacCi; cell.Mcc != "" {
+		report.Cell = &cellLocation{Mcc: cell.Mcc, Mnc: cell.Mnc, Lac: cell.Lac, Ci: cell.Ci, Tac: cell.Tac}
+	}
+	if cdma := location.CdmaBs; cdma.Latitude != 0 || cdma.Longitude != 0 {
+		report.CdmaBs = &gpsPosition{Latitude: cdma.Latitude, Longitude: cdma.Longitude}
+	}
+	return report
+}
+
+// formatLocationLine formats report as a single line for follow
+func formatLocationLine(report locationReport) string {
+	var parts []string
+	if gps := report.Gps; gps != nil {
+		parts = append(parts, fmt.Sprintf("%.6f,%.6f alt %.1f m", gps.Latitude, gps.Longitude, gps.Altitude))
+		if gps.UtcTime != "" {
+			parts = append(parts, "utc "+gps.UtcTime)
+		}
+	}
+	if cell := report.Cell; cell != nil {
+		parts = append(parts, fmt.Sprintf("cell %s/%s lac %s tac %s ci %s", cell.Mcc, cell.Mnc, orDash(cell.Lac), orDash(cell.Tac), orDash(cell.Ci)))
+	}
+	if cdma := report.CdmaBs; cdma != nil {
+		parts = append(parts, fmt.Sprintf("cdma-bs %.6f,%.6f", cdma.Latitude, cdma.Longitude))
+	}
+	if len(parts) == 0 {
+		return "no location"
+	}
+	return strings.Join(parts, "  ")
+}
+
+// waitForFix reads the location every interval until it has a GPS position,
+// and gives up after timeout
+func waitForFix(read func() (modemmanager.CurrentLocation, error), timeout, interval time.Duration) (locationReport, error) {
+	deadline := clk.After(timeout)
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	var report locationReport
+	for {
+		if location, err := read(); err == nil {
+			report = newLocationReport(location)
+			if report.Gps != nil {
+				return report, nil
+			}
+		}
+
+		select {
+		case <-deadline:
+			return report, &exitError{code: exitTimeout, err: fmt.Errorf("no GPS fix after %s", timeout)}
+		case <-ticker.C():
+		}
+	}
+}
+
+// followLocation calls emit with every location read until ctx is done. With
+// signals the location is read on every signal, otherwise every interval.
+func followLocation(ctx context.Context, read func() (modemmanager.CurrentLocation, error), signals <-chan *dbus.Signal, interval time.Duration, emit func(modemmanager.CurrentLocation) error) error {
+	var tick <-chan time.Time
+	if signals == nil {
+		ticker := clk.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C()
+	}
+
+	for first := true; ; first = false {
+		location, err := read()
+		switch {
+		case err != nil && first:
+			return fmt.Errorf("failed to get location: %w", err)
+		case err != nil:
+			// Skip reads failing while the modem changes state
+		default:
+			if err := emit(location); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-signals:
+			if !ok {
+				return &exitError{code: exitModemGone, err: errModemGone}
+			}
+		case <-tick:
+		}
+	}
+}
+
+// locationChanges returns an emit function for followLocation that calls emit
+// whenever the report differs from the last one emitted
+func locationChanges(emit func(locationReport) error) func(modemmanager.CurrentLocation) error {
+	var last *locationReport
+	return func(location modemmanager.CurrentLocation) error {
+		report := newLocationReport(location)
+		if last != nil && reflect.DeepEqual(*last, report) {
+			return nil
+		}
+		last = &report
+		return emit(report)
+	}
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestParseLocationSources(t *testing.T) {
+	sources, err := parseLocationSources("gps-raw, 3GPP,gps-raw")
+	want := []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSource3gppLacCi, modemmanager.MmModemLocationSourceGpsRaw}
+	if err != nil || !reflect.DeepEqual(sources, want) {
+		t.Errorf("parseLocationSources = %v, %v, want %v", sources, err, want)
+	}
+
+	for spec, wantErr := range map[string]string{
+		"gps":  `unknown location source "gps" (must be one of 3gpp, gps-raw, gps-nmea, cdma-bs, gps-unmanaged, agps-msa, agps-msb)`,
+		" , ":  "no location sources given",
+		"glns": `unknown location source "glns"`,
+	} {
+		if _, err := parseLocationSources(spec); err == nil || !strings.HasPrefix(err.Error(), wantErr) {
+			t.Errorf("parseLocationSources(%q) error = %v, want %q", spec, err, wantErr)
+		}
+	}
+}
+
+func TestEnableLocationSources(t *testing.T) {
+	location := mocks.NewMockModemLocation()
+	location.EnabledValue = []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSource3gppLacCi}
+	location.SignalsLocationValue = true
+
+	if err := enableLocationSources(location, []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSourceGpsRaw}, nil); err != nil {
+		t.Fatalf("enableLocationSources error = %v", err)
+	}
+	if got := locationSourceList(location.EnabledValue); !reflect.DeepEqual(got, []string{"3gpp", "gps-raw"}) {
+		t.Errorf("enabled sources = %v, want 3gpp and gps-raw", got)
+	}
+	if !location.SignalsLocationValue {
+		t.Error("signals were turned off without --no-signal")
+	}
+
+	off := false
+	if err := enableLocationSources(location, []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSourceGpsNmea}, &off); err != nil || location.SignalsLocationValue {
+		t.Errorf("enableLocationSources error = %v, signals = %t, want them off", err, location.SignalsLocationValue)
+	}
+
+	err := enableLocationSources(location, []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSourceCdmaBs}, nil)
+	if want := "modem does not support location source cdma-bs (supported: 3gpp, gps-raw, gps-nmea)"; err == nil || err.Error() != want {
+		t.Errorf("enableLocationSources error = %v, want %q", err, want)
+	}
+}
+
+func TestCheckLocationSources(t *testing.T) {
+	all := modemmanager.MmModemLocationSourceNone.GetAllSources()
+	msa, msb := modemmanager.MmModemLocationSourceAgpsMsa, modemmanager.MmModemLocationSourceAgpsMsb
+	raw := modemmanager.MmModemLocationSourceGpsRaw
+
+	tests := []struct {
+		sources []modemmanager.MMModemLocationSource
+		wantErr string
+	}{
+		{sources: []modemmanager.MMModemLocationSource{raw, msa}},
+		{sources: nil},
+		{sources: []modemmanager.MMModemLocationSource{raw, msa, msb}, wantErr: "agps-msa and agps-msb cannot be enabled together"},
+		{sources: []modemmanager.MMModemLocationSource{msb}, wantErr: "A-GPS needs gps-raw or gps-nmea enabled as well"},
+	}
+	for _, tt := range tests {
+		err := checkLocationSources(tt.sources, all)
+		if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+			t.Errorf("checkLocationSources(%v) error = %v, want %q", tt.sources, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDisableLocationSources(t *testing.T) {
+	location := mocks.NewMockModemLocation()
+	location.EnabledValue = []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSource3gppLacCi, modemmanager.MmModemLocationSourceGpsRaw}
+	location.SignalsLocationValue = true
+
+	if err := disableLocationSources(location, []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSourceGpsRaw}); err != nil {
+		t.Fatalf("disableLocationSources error = %v", err)
+	}
+	if got := locationSourceList(location.EnabledValue); !reflect.DeepEqual(got, []string{"3gpp"}) || !location.SignalsLocationValue {
+		t.Errorf("enabled sources = %v, signals = %t, want 3gpp with signals", got, location.SignalsLocationValue)
+	}
+
+	if err := disableLocationSources(location, nil); err != nil || len(location.EnabledValue) != 0 {
+		t.Errorf("disableLocationSources error = %v, enabled sources = %v, want none", err, location.EnabledValue)
+	}
+}
+
+func TestNewLocationReport(t *testing.T) {
+	var location modemmanager.CurrentLocation
+	if report := newLocationReport(location); report != (locationReport{}) {
+		t.Errorf("newLocationReport of no location = %+v", report)
+	}
+
+	location.GpsRaw = modemmanager.GpsRawLocation{
+		UtcTime:   time.Date(2026, 10, 16, 12, 30, 15, 0, time.UTC),
+		Latitude:  52.520008,
+		Longitude: 13.404954,
+		Altitude:  34,
+	}
+	location.ThreeGppLacCi = modemmanager.ThreeGppLacCiLocation{Mcc: "262", Mnc: "01", Lac: "0", Ci: "1A2B3C", Tac: "6FFE"}
+	report := newLocationReport(location)
+	if want := (gpsPosition{Latitude: 52.520008, Longitude: 13.404954, Altitude: 34, UtcTime: "12:30:15"}); report.Gps == nil || *report.Gps != want {
+		t.Errorf("gps = %+v, want %+v", report.Gps, want)
+	}
+	if report.Cell == nil || report.Cell.Ci != "1A2B3C" || report.CdmaBs != nil {
+		t.Errorf("cell = %+v, cdma = %+v", report.Cell, report.CdmaBs)
+	}
+	if got, want := formatLocationLine(report), "52.520008,13.404954 alt 34.0 m  utc 12:30:15  cell 262/01 lac 0 tac 6FFE ci 1A2B3C"; got != want {
+		t.Errorf("formatLocationLine = %q, want %q", got, want)
+	}
+}
+
+// locationSequence returns the locations one read at a time, repeating the
+// last one, and sends on reads after every read
+type locationSequence struct {
+	locations []modemmanager.CurrentLocation
+	reads     chan struct{}
+}
+
+func (s *locationSequence) read() (modemmanager.CurrentLocation, error) {
+	location := s.locations[0]
+	if len(s.locations) > 1 {
+		s.locations = s.locations[1:]
+	}
+	s.reads <- struct{}{}
+	return location, nil
+}
+
+func TestWaitForFix(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	fix := modemmanager.CurrentLocation{GpsRaw: modemmanager.GpsRawLocation{Latitude: 1, Longitude: 2}}
+	seq := &locationSequence{locations: []modemmanager.CurrentLocation{{}, {}, fix}, reads: make(chan struct{}, 10)}
+	done := make(chan error, 1)
+	var report locationReport
+	go func() {
+		var err error
+		report, err = waitForFix(seq.read, time.Minute, 2*time.Second)
+		done <- err
+	}()
+	for i := 0; i < 2; i++ {
+		<-seq.reads
+		fake.BlockUntilTimers(2)
+		fake.Advance(2 * time.Second)
+	}
+	if err := <-done; err != nil || report.Gps == nil || report.Gps.Latitude != 1 {
+		t.Errorf("waitForFix = %+v, %v, want the fix", report, err)
+	}
+
+	seq = &locationSequence{locations: []modemmanager.CurrentLocation{{}}, reads: make(chan struct{}, 100)}
+	go func() {
+		_, err := waitForFix(seq.read, 10*time.Second, 2*time.Second)
+		done <- err
+	}()
+	<-seq.reads
+	fake.BlockUntilTimers(2)
+	fake.Advance(10 * time.Second)
+	err := <-done
+	var exit *exitError
+	if !errors.As(err, &exit) || exit.code != exitTimeout || err.Error() != "no GPS fix after 10s" {
+		t.Errorf("waitForFix error = %v, want a timeout", err)
+	}
+}
+
+func TestFollowLocation(t *testing.T) {
+	at := func(lat float64) modemmanager.CurrentLocation {
+		return modemmanager.CurrentLocation{GpsRaw: modemmanager.GpsRawLocation{Latitude: lat, Longitude: 13}}
+	}
+	seq := &locationSequence{locations: []modemmanager.CurrentLocation{at(52), at(52), at(53)}, reads: make(chan struct{}, 10)}
+	signals := make(chan *dbus.Signal)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var emitted []float64
+	done := make(chan error, 1)
+	go func() {
+		done <- followLocation(ctx, seq.read, signals, time.Second, locationChanges(func(report locationReport) error {
+			emitted = append(emitted, report.Gps.Latitude)
+			return nil
+		}))
+	}()
+	<-seq.reads
+	signals <- &dbus.Signal{}
+	<-seq.reads
+	signals <- &dbus.Signal{}
+	<-seq.reads
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("followLocation error = %v", err)
+	}
+	if !reflect.DeepEqual(emitted, []float64{52, 53}) {
+		t.Errorf("emitted latitudes %v, want one line per change", emitted)
+	}
+
+	close(signals)
+	err := followLocation(context.Background(), seq.read, signals, time.Second, func(modemmanager.CurrentLocation) error { return nil })
+	var exit *exitError
+	if !errors.As(err, &exit) || exit.code != exitModemGone {
+		t.Errorf("followLocation error = %v, want the modem gone", err)
+	}
+}
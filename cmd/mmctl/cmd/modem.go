@@ -130,6 +130,7 @@ func init() {
 	modemCommandCmd.Flags().Uint32VarP(&commandTimeout, "timeout", "t", 10, "Command timeout in seconds")
 }
 
+// getModem returns the modem selected with --modem or --path
 func getModem() (modemmanager.Modem, error) {
 	mm, err := modemmanager.NewModemManager()
 	if err != nil {
@@ -141,19 +142,117 @@ func getModem() (modemmanager.Modem, error) {
 		return nil, fmt.Errorf("failed to get modems: %w", err)
 	}
 
+	return selectModem(modems, modemSelector{index: modemIndex, path: modemPath, imei: modemIMEI, deviceID: modemDeviceID})
+}
+
+// modemSelector holds the modem selection flags; a negative index and empty
+// strings are unset
+type modemSelector struct {
+	index    int
+	path     string
+	imei     string // equipment identifier or a unique prefix of it
+	deviceID string // device identifier or a unique prefix of it
+}
+
+// selectModem returns the modem chosen by at most one of the fields of sel.
+// With none set, it returns the only modem and refuses to guess between
+// several.
+func selectModem(modems []modemmanager.Modem, sel modemSelector) (modemmanager.Modem, error) {
+	set := 0
+	for _, given := range []bool{sel.index >= 0, sel.path != "", sel.imei != "", sel.deviceID != ""} {
+		if given {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("only one of --modem, --path, --imei and --device-id can be given")
+	}
 	if len(modems) == 0 {
 		return nil, fmt.Errorf("no modems found")
 	}
 
-	if modemIndex < 0 {
-		modemIndex = 0
+	switch {
+	case sel.path != "":
+		for _, modem := range modems {
+			if string(modem.GetObjectPath()) == sel.path {
+				return modem, nil
+			}
+		}
+		return nil, fmt.Errorf("no modem at path %s, available modems:\n%s", sel.path, modemList(modems))
+	case sel.imei != "":
+		return matchModemIdentifier(modems, "IMEI", sel.imei, modemmanager.Modem.GetEquipmentIdentifier)
+	case sel.deviceID != "":
+		return matchModemIdentifier(modems, "device ID", sel.deviceID, modemmanager.Modem.GetDeviceIdentifier)
+	case sel.index >= len(modems):
+		return nil, fmt.Errorf("modem index %d out of range (0-%d)", sel.index, len(modems)-1)
+	case sel.index >= 0:
+		return modems[sel.index], nil
+	case len(modems) > 1:
+		return nil, fmt.Errorf("%d modems found, select one with --modem, --path, --imei or --device-id:\n%s", len(modems), modemList(modems))
+	default:
+		return modems[0], nil
 	}
+}
 
-	if modemIndex >= len(modems) {
-		return nil, fmt.Errorf("modem index %d out of range (0-%d)", modemIndex, len(modems)-1)
+// matchModemIdentifier returns the modem whose identifier equals want or,
+// failing that, the only one starting with it
+func matchModemIdentifier(modems []modemmanager.Modem, name, want string, identifier func(modemmanager.Modem) (string, error)) (modemmanager.Modem, error) {
+	var matches []modemmanager.Modem
+	for _, modem := range modems {
+		id, err := identifier(modem)
+		if err != nil {
+			continue
+		}
+		if id == want {
+			return modem, nil
+		}
+		if strings.HasPrefix(id, want) {
+			matches = append(matches, modem)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no modem with %s %s, available modems:\n%s", name, want, modemList(modems))
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%s %s matches %d modems, give more digits:\n%s", name, want, len(matches), modemList(matches))
+	}
+}
+
+// modemList lists the index, D-Bus path and identifiers of each modem, one
+// per line. Indexes are only meaningful for the full list of modems.
+func modemList(modems []modemmanager.Modem) string {
+	lines := make([]string, len(modems))
+	for i, modem := range modems {
+		lines[i] = fmt.Sprintf("  %d: %s", i, modem.GetObjectPath())
+		if imei, err := modem.GetEquipmentIdentifier(); err == nil && imei != "" {
+			lines[i] += " imei=" + imei
+		}
+		if deviceID, err := modem.GetDeviceIdentifier(); err == nil && deviceID != "" {
+			lines[i] += " device-id=" + deviceID
+		}
 	}
+	return strings.Join(lines, "\n")
+}
 
-	return modems[modemIndex], nil
+// modemDescription names a modem by its path, manufacturer, model and IMEI
+// as far as they are known
+func modemDescription(modem modemmanager.Modem) string {
+	var details []string
+	manufacturer, _ := modem.GetManufacturer()
+	model, _ := modem.GetModel()
+	if name := strings.TrimSpace(manufacturer + " " + model); name != "" {
+		details = append(details, name)
+	}
+	if imei, err := modem.GetEquipmentIdentifier(); err == nil && imei != "" {
+		details = append(details, "IMEI "+imei)
+	}
+	if len(details) == 0 {
+		return string(modem.GetObjectPath())
+	}
+	return fmt.Sprintf("%s (%s)", modem.GetObjectPath(), strings.Join(details, ", "))
 }
 
 func runModemInfo(cmd *cobra.Command, args []string) error {
@@ -184,6 +283,12 @@ func runModemInfo(cmd *cobra.Command, args []string) error {
 	// State information
 	if state, err := modem.GetState(); err == nil {
 		info["state"] = state.String()
+		if state == modemmanager.MmModemStateFailed {
+			if reason, err := modem.GetStateFailedReason(); err == nil {
+				text, _ := reason.Describe()
+				info["failed_reason"] = text
+			}
+		}
 	}
 	if powerState, err := modem.GetPowerState(); err == nil {
 		info["power_state"] = powerState.String()
@@ -220,13 +325,9 @@ func runModemInfo(cmd *cobra.Command, args []string) error {
 
 	// Modes
 	if modes, err := modem.GetCurrentModes(); err == nil {
-		modeStrs := make([]string, len(modes.AllowedModes))
-		for i, mode := range modes.AllowedModes {
-			modeStrs[i] = mode.String()
-		}
 		info["current_modes"] = map[string]interface{}{
-			"allowed":   modeStrs,
-			"preferred": modes.PreferredMode.String(),
+			"allowed":   modes.Allowed.Strings(),
+			"preferred": modes.Preferred.String(),
 		}
 	}
 
@@ -311,7 +412,7 @@ func runModemInfo(cmd *cobra.Command, args []string) error {
 	// Print in order
 	keys := []string{
 		"manufacturer", "model", "revision", "equipment_identifier",
-		"device_identifier", "state", "power_state", "unlock_required",
+		"device_identifier", "state", "failed_reason", "power_state", "unlock_required",
 		"signal_quality", "access_technologies", "current_capabilities",
 		"current_modes", "current_bands", "own_numbers", "sim", "3gpp",
 	}
@@ -326,15 +427,17 @@ func runModemInfo(cmd *cobra.Command, args []string) error {
 }
 
 func runModemEnable(cmd *cobra.Command, args []string) error {
-	modem, err := getModem()
+	progress, err := newProgress()
 	if err != nil {
 		return err
 	}
 
-	if verbose {
-		fmt.Printf("Enabling modem %d...\n", modemIndex)
+	modem, err := getModem()
+	if err != nil {
+		return err
 	}
 
+	progress.Stage("enabling", fmt.Sprintf("modem %s", modem.GetObjectPath()))
 	if err := modem.Enable(); err != nil {
 		return fmt.Errorf("failed to enable modem: %w", err)
 	}
@@ -344,15 +447,17 @@ func runModemEnable(cmd *cobra.Command, args []string) error {
 }
 
 func runModemDisable(cmd *cobra.Command, args []string) error {
-	modem, err := getModem()
+	progress, err := newProgress()
 	if err != nil {
 		return err
 	}
 
-	if verbose {
-		fmt.Printf("Disabling modem %d...\n", modemIndex)
+	modem, err := getModem()
+	if err != nil {
+		return err
 	}
 
+	progress.Stage("disabling", fmt.Sprintf("modem %s", modem.GetObjectPath()))
 	if err := modem.Disable(); err != nil {
 		return fmt.Errorf("failed to disable modem: %w", err)
 	}
@@ -362,15 +467,17 @@ func runModemDisable(cmd *cobra.Command, args []string) error {
 }
 
 func runModemReset(cmd *cobra.Command, args []string) error {
-	modem, err := getModem()
+	progress, err := newProgress()
 	if err != nil {
 		return err
 	}
 
-	if verbose {
-		fmt.Printf("Resetting modem %d...\n", modemIndex)
+	modem, err := getModem()
+	if err != nil {
+		return err
 	}
 
+	progress.Stage("resetting", fmt.Sprintf("modem %s", modem.GetObjectPath()))
 	if err := modem.Reset(); err != nil {
 		return fmt.Errorf("failed to reset modem: %w", err)
 	}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestSelectModem(t *testing.T) {
+	modems := make([]modemmanager.Modem, 3)
+	for i, ids := range [][2]string{
+		{"351234567890123", "8d3e7a01"},
+		{"351234567890456", "8d3e7b02"},
+		{"869999000000001", "f00dbeef"},
+	} {
+		modem := mocks.NewMockModem()
+		modem.ObjectPathValue = modemmanager.ModemPathFromIndex(i + 3)
+		modem.EquipmentIdentifierValue, modem.DeviceIdentifierValue = ids[0], ids[1]
+		modems[i] = modem
+	}
+	single := modems[:1]
+	none := modemSelector{index: -1}
+
+	tests := []struct {
+		name    string
+		modems  []modemmanager.Modem
+		sel     modemSelector
+		want    modemmanager.Modem
+		wantErr string
+	}{
+		{name: "only modem", modems: single, sel: none, want: modems[0]},
+		{name: "index", modems: modems, sel: modemSelector{index: 1}, want: modems[1]},
+		{name: "path", modems: modems, sel: modemSelector{index: -1, path: "/org/freedesktop/ModemManager1/Modem/5"}, want: modems[2]},
+		{name: "imei", modems: modems, sel: modemSelector{index: -1, imei: "351234567890456"}, want: modems[1]},
+		{name: "imei prefix", modems: modems, sel: modemSelector{index: -1, imei: "869"}, want: modems[2]},
+		{name: "device id prefix", modems: modems, sel: modemSelector{index: -1, deviceID: "8d3e7b"}, want: modems[1]},
+		{name: "no modems", sel: none, wantErr: "no modems found"},
+		{name: "index out of range", modems: modems, sel: modemSelector{index: 3}, wantErr: "modem index 3 out of range (0-2)"},
+		{
+			name: "unknown path", modems: modems, sel: modemSelector{index: -1, path: "/org/freedesktop/ModemManager1/Modem/0"},
+			wantErr: "no modem at path /org/freedesktop/ModemManager1/Modem/0, available modems:\n" +
+				"  0: /org/freedesktop/ModemManager1/Modem/3 imei=351234567890123 device-id=8d3e7a01\n" +
+				"  1: /org/freedesktop/ModemManager1/Modem/4 imei=351234567890456 device-id=8d3e7b02\n" +
+				"  2: /org/freedesktop/ModemManager1/Modem/5 imei=869999000000001 device-id=f00dbeef",
+		},
+		{name: "unknown imei", modems: modems, sel: modemSelector{index: -1, imei: "352"}, wantErr: "no modem with IMEI 352, available modems:\n  0: "},
+		{
+			name: "ambiguous imei prefix", modems: modems, sel: modemSelector{index: -1, imei: "3512"},
+			wantErr: "IMEI 3512 matches 2 modems, give more digits:\n" +
+				"  0: /org/freedesktop/ModemManager1/Modem/3 imei=351234567890123 device-id=8d3e7a01\n" +
+				"  1: /org/freedesktop/ModemManager1/Modem/4 imei=351234567890456 device-id=8d3e7b02",
+		},
+		{name: "ambiguous device id prefix", modems: modems, sel: modemSelector{index: -1, deviceID: "8d3e"}, wantErr: "device ID 8d3e matches 2 modems"},
+		{name: "index and path", modems: modems, sel: modemSelector{index: 0, path: "/org/freedesktop/ModemManager1/Modem/3"}, wantErr: "only one of --modem, --path, --imei and --device-id can be given"},
+		{name: "imei and device id", modems: modems, sel: modemSelector{index: -1, imei: "351", deviceID: "8d3e"}, wantErr: "only one of"},
+		{name: "ambiguous", modems: modems, sel: none, wantErr: "3 modems found, select one with --modem, --path, --imei or --device-id:\n  0: /org/freedesktop/ModemManager1/Modem/3"},
+	}
+
+	for _, tt := range tests {
+		got, err := selectModem(tt.modems, tt.sel)
+		if tt.wantErr != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("%s: selected %s, want %s", tt.name, got.GetObjectPath(), tt.want.GetObjectPath())
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	modemModesCmd = &cobra.Command{
+		Use:   "modes",
+		Short: "Show current and supported access modes",
+		Example: `  # Show which of 2G, 3G and 4G modem 0 may use
+  mmctl modem modes -m 0`,
+		Args: cobra.NoArgs,
+		RunE: runModemModes,
+	}
+
+	modemSetModesCmd = &cobra.Command{
+		Use:   "set-modes",
+		Short: "Set the allowed and preferred access modes",
+		Long: `Set the access modes the modem may use and the one it prefers among them.
+
+Modes are cs, 2g, 3g and 4g, or any to let the modem choose. Only the
+combinations listed by 'mmctl modem modes' are accepted.`,
+		Example: `  # Allow 3G and 4G, preferring 4G
+  mmctl modem set-modes -m 0 --allowed 3g,4g --preferred 4g
+
+  # LTE only
+  mmctl modem set-modes -m 0 --allowed 4g`,
+		Args: cobra.NoArgs,
+		RunE: runModemSetModes,
+	}
+
+	modemBandsCmd = &cobra.Command{
+		Use:   "bands",
+		Short: "Show current and supported radio bands",
+		Example: `  # Show the bands modem 0 may use
+  mmctl modem bands -m 0`,
+		Args: cobra.NoArgs,
+		RunE: runModemBands,
+	}
+
+	modemSetBandsCmd = &cobra.Command{
+		Use:   "set-bands",
+		Short: "Set the radio bands the modem may use",
+		Long: `Restrict the modem to a set of radio bands, or allow all with --bands any.
+
+Bands are named as by 'mmctl modem bands', e.g. egsm, dcs, utran-1 or
+eutran-20, in any case. LTE bands may also be given as B<n>, e.g. B20.`,
+		Example: `  # Restrict to the common European LTE bands
+  mmctl modem set-bands -m 0 --bands B3,B7,B20
+
+  # Allow every supported band again
+  mmctl modem set-bands -m 0 --bands any`,
+		Args: cobra.NoArgs,
+		RunE: runModemSetBands,
+	}
+
+	// Flags
+	modesAllowed   string
+	modesPreferred string
+	bandsList      string
+)
+
+func init() {
+	modemCmd.AddCommand(modemModesCmd)
+	modemCmd.AddCommand(modemSetModesCmd)
+	modemCmd.AddCommand(modemBandsCmd)
+	modemCmd.AddCommand(modemSetBandsCmd)
+
+	modemSetModesCmd.Flags().StringVar(&modesAllowed, "allowed", "", "Comma separated allowed modes: cs, 2g, 3g, 4g or any (required)")
+	modemSetModesCmd.Flags().StringVar(&modesPreferred, "preferred", "none", "Preferred mode among the allowed ones, or none")
+	modemSetModesCmd.MarkFlagRequired("allowed")
+	modemSetBandsCmd.Flags().StringVar(&bandsList, "bands", "", "Comma separated bands, e.g. eutran-3,B20, or any (required)")
+	modemSetBandsCmd.MarkFlagRequired("bands")
+}
+
+// parseMode resolves a single mode name such as "4g"
+func parseMode(name string) (modemmanager.MMModemMode, error) {
+	name = strings.TrimSpace(name)
+	var m modemmanager.MMModemMode
+	for _, mode := range append(m.GetAllModes(), modemmanager.MmModemModeAny, modemmanager.MmModemModeNone) {
+		if strings.EqualFold(mode.String(), name) {
+			return mode, nil
+		}
+	}
+	return modemmanager.MmModemModeNone, fmt.Errorf("unknown mode %q, expected cs, 2g, 3g, 4g or any", name)
+}
+
+// parseModes parses the --allowed and --preferred values
+func parseModes(allowed, preferred string) (modemmanager.Mode, error) {
+	var mode modemmanager.Mode
+	names := strings.Split(allowed, ",")
+	for _, name := range names {
+		m, err := parseMode(name)
+		if err != nil {
+			return mode, err
+		}
+		switch {
+		case m == modemmanager.MmModemModeNone:
+			return mode, fmt.Errorf("mode none cannot be allowed")
+		case m == modemmanager.MmModemModeAny && len(names) > 1:
+			return mode, fmt.Errorf("mode any cannot be combined with other modes")
+		}
+		mode.Allowed |= modemmanager.NewModeMask(m)
+	}
+
+	p, err := parseMode(preferred)
+	if err != nil {
+		return mode, err
+	}
+	if p == modemmanager.MmModemModeAny || p != modemmanager.MmModemModeNone && !mode.Allowed.Has(p) {
+		return mode, fmt.Errorf("preferred mode %s must be one of the allowed modes", modeName(p))
+	}
+	mode.Preferred = p
+	return mode, nil
+}
+
+func modeName(mode modemmanager.MMModemMode) string {
+	return strings.ToLower(mode.String())
+}
+
+// formatMode prints a mode combination, e.g. "3g, 4g (preferred 4g)"
+func formatMode(mode modemmanager.Mode) string {
+	s := strings.ToLower(mode.Allowed.String())
+	if mode.Preferred != modemmanager.MmModemModeNone {
+		s += " (preferred " + modeName(mode.Preferred) + ")"
+	}
+	return s
+}
+
+// modeSupported reports whether the modem lists the combination. Allowing
+// any mode without preference restores the default and is always accepted.
+func modeSupported(supported []modemmanager.Mode, mode modemmanager.Mode) bool {
+	if modemmanager.MMModemMode(mode.Allowed) == modemmanager.MmModemModeAny && mode.Preferred == modemmanager.MmModemModeNone {
+		return true
+	}
+	for _, s := range supported {
+		if s == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// bandNamePrefixes are the band name prefixes followed by a number, with the
+// dash inserted in printed names
+var bandNamePrefixes = map[string]string{"eutran": "eutran-", "utran": "utran-", "cdmabc": "cdma-bc"}
+
+// bandName prints a band, e.g. eutran-3 for MmModemBandEutran3
+func bandName(band modemmanager.MMModemBand) string {
+	name := strings.ToLower(band.String())
+	for prefix, printed := range bandNamePrefixes {
+		if rest := strings.TrimPrefix(name, prefix); rest != name && rest != "" && strings.Trim(rest, "0123456789") == "" {
+			return printed + rest
+		}
+	}
+	return name
+}
+
+// bandsByName maps normalised band names to bands
+var bandsByName = func() map[string]modemmanager.MMModemBand {
+	bands := make(map[string]modemmanager.MMModemBand)
+	for band := modemmanager.MmModemBandEgsm; band <= modemmanager.MmModemBandAny; band++ {
+		// Gaps in the enum have no name
+		if name := band.String(); !strings.HasPrefix(name, "MMModemBand(") {
+			bands[strings.ToLower(name)] = band
+		}
+	}
+	return bands
+}()
+
+// lteBandShorthand matches LTE bands given as B<n>
+var lteBandShorthand = regexp.MustCompile(`^b([0-9]+)$`)
+
+// parseBand resolves a band name. Case, dashes and underscores are ignored,
+// and B<n> is E-UTRAN band n.
+func parseBand(name string) (modemmanager.MMModemBand, error) {
+	key := strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(name))
+	if m := lteBandShorthand.FindStringSubmatch(key); m != nil {
+		key = "eutran" + strings.TrimLeft(m[1], "0")
+	}
+	if band, ok := bandsByName[key]; ok {
+		return band, nil
+	}
+	return modemmanager.MmModemBandUnknown, fmt.Errorf("unknown band %q", strings.TrimSpace(name))
+}
+
+// parseBands parses the --bands value, a comma separated list or any
+func parseBands(list string) ([]modemmanager.MMModemBand, error) {
+	var bands []modemmanager.MMModemBand
+	seen := make(map[modemmanager.MMModemBand]bool)
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		band, err := parseBand(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[band] {
+			seen[band] = true
+			bands = append(bands, band)
+		}
+	}
+	switch {
+	case len(bands) == 0:
+		return nil, fmt.Errorf("no bands given")
+	case seen[modemmanager.MmModemBandAny] && len(bands) > 1:
+		return nil, fmt.Errorf("band any cannot be combined with other bands")
+	}
+	return bands, nil
+}
+
+// bandNames prints bands in enum order
+func bandNames(bands []modemmanager.MMModemBand) []string {
+	sorted := append([]modemmanager.MMModemBand(nil), bands...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	names := make([]string, len(sorted))
+	for i, band := range sorted {
+		names[i] = bandName(band)
+	}
+	return names
+}
+
+// unsupportedBands returns the bands missing from supported. Any is always
+// accepted, so is everything if the modem lists any.
+func unsupportedBands(supported, bands []modemmanager.MMModemBand) []modemmanager.MMModemBand {
+	ok := map[modemmanager.MMModemBand]bool{modemmanager.MmModemBandAny: true}
+	for _, band := range supported {
+		if band == modemmanager.MmModemBandAny {
+			return nil
+		}
+		ok[band] = true
+	}
+	var missing []modemmanager.MMModemBand
+	for _, band := range bands {
+		if !ok[band] {
+			missing = append(missing, band)
+		}
+	}
+	return missing
+}
+
+// modeJSON is a mode combination in JSON output
+type modeJSON struct {
+	Allowed   []string `json:"allowed"`
+	Preferred string   `json:"preferred"`
+}
+
+func newModeJSON(mode modemmanager.Mode) modeJSON {
+	allowed := mode.Allowed.Strings()
+	for i := range allowed {
+		allowed[i] = strings.ToLower(allowed[i])
+	}
+	return modeJSON{Allowed: allowed, Preferred: modeName(mode.Preferred)}
+}
+
+func runModemModes(cmd *cobra.Command, args []string) error {
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	current, err := modem.GetCurrentModes()
+	if err != nil {
+		return fmt.Errorf("failed to get current modes: %w", err)
+	}
+	supported, err := modem.GetSupportedModes()
+	if err != nil {
+		return fmt.Errorf("failed to get supported modes: %w", err)
+	}
+
+	if jsonOutput {
+		out := struct {
+			Current   modeJSON   `json:"current"`
+			Supported []modeJSON `json:"supported"`
+		}{Current: newModeJSON(current), Supported: []modeJSON{}}
+		for _, mode := range supported {
+			out.Supported = append(out.Supported, newModeJSON(mode))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	}
+
+	fmt.Printf("Current:   %s\n", formatMode(current))
+	fmt.Println("Supported:")
+	for _, mode := range supported {
+		fmt.Printf("  %s\n", formatMode(mode))
+	}
+	return nil
+}
+
+func runModemSetModes(cmd *cobra.Command, args []string) error {
+	mode, err := parseModes(modesAllowed, modesPreferred)
+	if err != nil {
+		return err
+	}
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	supported, err := modem.GetSupportedModes()
+	if err != nil {
+		return fmt.Errorf("failed to get supported modes: %w", err)
+	}
+	if !modeSupported(supported, mode) {
+		lines := make([]string, len(supported))
+		for i, s := range supported {
+			lines[i] = "  " + formatMode(s)
+		}
+		return fmt.Errorf("modes %s not supported by the modem, supported combinations:\n%s", formatMode(mode), strings.Join(lines, "\n"))
+	}
+
+	if err := modem.SetCurrentModes(mode); err != nil {
+		return fmt.Errorf("failed to set modes: %w", err)
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newModeJSON(mode))
+	}
+	fmt.Printf("✓ Modes set to %s\n", formatMode(mode))
+	return nil
+}
+
+func runModemBands(cmd *cobra.Command, args []string) error {
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	current, err := modem.GetCurrentBands()
+	if err != nil {
+		return fmt.Errorf("failed to get current bands: %w", err)
+	}
+	supported, err := modem.GetSupportedBands()
+	if err != nil {
+		return fmt.Errorf("failed to get supported bands: %w", err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string][]string{
+			"current":   bandNames(current),
+			"supported": bandNames(supported),
+		})
+	}
+	fmt.Printf("Current:   %s\n", orDash(strings.Join(bandNames(current), ", ")))
+	fmt.Printf("Supported: %s\n", orDash(strings.Join(bandNames(supported), ", ")))
+	return nil
+}
+
+func runModemSetBands(cmd *cobra.Command, args []string) error {
+	bands, err := parseBands(bandsList)
+	if err != nil {
+		return err
+	}
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	supported, err := modem.GetSupportedBands()
+	if err != nil {
+		return fmt.Errorf("failed to get supported bands: %w", err)
+	}
+	if missing := unsupportedBands(supported, bands); len(missing) > 0 {
+		return fmt.Errorf("bands %s not supported by the modem, supported bands: %s", strings.Join(bandNames(missing), ", "), strings.Join(bandNames(supported), ", "))
+	}
+
+	if err := modem.SetCurrentBands(bands); err != nil {
+		return fmt.Errorf("failed to set bands: %w", err)
+	}
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string][]string{"bands": bandNames(bands)})
+	}
+	fmt.Printf("✓ Bands set to %s\n", strings.Join(bandNames(bands), ", "))
+	return nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+func TestParseModes(t *testing.T) {
+	mode, err := parseModes("3G, 4g", "4G")
+	if err != nil || mode.Allowed != modemmanager.NewModeMask(modemmanager.MmModemMode3g, modemmanager.MmModemMode4g) || mode.Preferred != modemmanager.MmModemMode4g {
+		t.Errorf("parseModes(3G, 4g / 4G) = %v, %v", mode, err)
+	}
+	if got := formatMode(mode); got != "3g, 4g (preferred 4g)" {
+		t.Errorf("formatMode = %q", got)
+	}
+	if mode, err := parseModes("any", "none"); err != nil || modemmanager.MMModemMode(mode.Allowed) != modemmanager.MmModemModeAny {
+		t.Errorf("parseModes(any) = %v, %v", mode, err)
+	}
+
+	for _, bad := range [][2]string{{"3g,5g", "none"}, {"3g", "4g"}, {"any,4g", "none"}, {"none", "none"}, {"4g", "any"}} {
+		if mode, err := parseModes(bad[0], bad[1]); err == nil {
+			t.Errorf("parseModes(%s / %s) = %v, want an error", bad[0], bad[1], mode)
+		}
+	}
+}
+
+func TestModeSupported(t *testing.T) {
+	supported := []modemmanager.Mode{
+		{Allowed: modemmanager.NewModeMask(modemmanager.MmModemMode4g)},
+		{Allowed: modemmanager.NewModeMask(modemmanager.MmModemMode3g, modemmanager.MmModemMode4g), Preferred: modemmanager.MmModemMode4g},
+	}
+	if !modeSupported(supported, modemmanager.Mode{Allowed: modemmanager.NewModeMask(modemmanager.MmModemMode3g, modemmanager.MmModemMode4g), Preferred: modemmanager.MmModemMode4g}) {
+		t.Error("listed combination refused")
+	}
+	if modeSupported(supported, modemmanager.Mode{Allowed: modemmanager.NewModeMask(modemmanager.MmModemMode3g, modemmanager.MmModemMode4g)}) {
+		t.Error("combination without the listed preference accepted")
+	}
+	if !modeSupported(supported, modemmanager.Mode{Allowed: modemmanager.ModeMask(modemmanager.MmModemModeAny)}) {
+		t.Error("any refused")
+	}
+}
+
+func TestParseBand(t *testing.T) {
+	tests := map[string]modemmanager.MMModemBand{
+		"eutran-3":  modemmanager.MmModemBandEutran3,
+		"EUTRAN_20": modemmanager.MmModemBandEutran20,
+		"B7":        modemmanager.MmModemBandEutran7,
+		"b03":       modemmanager.MmModemBandEutran3,
+		"utran-1":   modemmanager.MmModemBandUtran1,
+		"Utran10":   modemmanager.MmModemBandUtran10,
+		"egsm":      modemmanager.MmModemBandEgsm,
+		"G850":      modemmanager.MmModemBandG850,
+		"cdma-bc0":  modemmanager.MmModemBandCdmaBc0,
+		"any":       modemmanager.MmModemBandAny,
+	}
+	for name, want := range tests {
+		if got, err := parseBand(name); err != nil || got != want {
+			t.Errorf("parseBand(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "unknown", "B", "B15", "eutran-200", "lte"} {
+		if band, err := parseBand(bad); err == nil {
+			t.Errorf("parseBand(%q) = %v, want an error", bad, band)
+		}
+	}
+}
+
+func TestParseBands(t *testing.T) {
+	bands, err := parseBands("B20, eutran-3,b3,EUTRAN-7")
+	if err != nil || fmt.Sprint(bandNames(bands)) != "[eutran-3 eutran-7 eutran-20]" {
+		t.Errorf("parseBands = %v, %v", bands, err)
+	}
+	if _, err := parseBands("any,B3"); err == nil {
+		t.Error("any combined with a band accepted")
+	}
+	if _, err := parseBands(" , "); err == nil {
+		t.Error("empty band list accepted")
+	}
+}
+
+func TestBandName(t *testing.T) {
+	tests := map[modemmanager.MMModemBand]string{
+		modemmanager.MmModemBandEutran3:  "eutran-3",
+		modemmanager.MmModemBandUtran10:  "utran-10",
+		modemmanager.MmModemBandCdmaBc12: "cdma-bc12",
+		modemmanager.MmModemBandDcs:      "dcs",
+		modemmanager.MmModemBandAny:      "any",
+	}
+	for band, want := range tests {
+		if got := bandName(band); got != want {
+			t.Errorf("bandName(%v) = %q, want %q", band, got, want)
+		}
+		// Printed names parse back
+		if parsed, err := parseBand(want); err != nil || parsed != band {
+			t.Errorf("parseBand(%q) = %v, %v", want, parsed, err)
+		}
+	}
+}
+
+func TestUnsupportedBands(t *testing.T) {
+	supported := []modemmanager.MMModemBand{modemmanager.MmModemBandEutran3, modemmanager.MmModemBandEutran20}
+	missing := unsupportedBands(supported, []modemmanager.MMModemBand{modemmanager.MmModemBandEutran3, modemmanager.MmModemBandEutran7, modemmanager.MmModemBandAny})
+	if strings.Join(bandNames(missing), ",") != "eutran-7" {
+		t.Errorf("unsupportedBands = %v, want eutran-7", bandNames(missing))
+	}
+	if missing := unsupportedBands([]modemmanager.MMModemBand{modemmanager.MmModemBandAny}, []modemmanager.MMModemBand{modemmanager.MmModemBandEutran7}); len(missing) != 0 {
+		t.Errorf("modem supporting any: unsupportedBands = %v", missing)
+	}
+}
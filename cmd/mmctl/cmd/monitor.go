@@ -0,0 +1,579 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+// monitorPollInterval is how often monitor looks for added and removed
+// modems and bearers
+const monitorPollInterval = 2 * time.Second
+
+// Interfaces accepted by --interfaces
+const (
+	monitorModem     = "modem"
+	monitorMessaging = "messaging"
+	monitor3gpp      = "3gpp"
+	monitorBearer    = "bearer"
+)
+
+// Full names of the signals monitor handles
+const (
+	monitorStateChanged      = modemmanager.ModemInterface + "." + modemmanager.ModemSignalStateChanged
+	monitorPropertiesChanged = "org.freedesktop.DBus.Properties.PropertiesChanged"
+	monitorSmsAdded          = modemmanager.ModemMessagingInterface + "." + modemmanager.ModemMessagingSignalAdded
+)
+
+var (
+	monitorCmd = &cobra.Command{
+		Use:   "monitor",
+		Short: "Print modem events as they happen",
+		Long: `Subscribe to the signals of a modem and print a timestamped line per event:
+state transitions with their reason, changed properties, registration changes
+and new SMS.
+
+Interfaces (--interfaces, comma separated):
+  modem      StateChanged and property changes of the modem
+  messaging  SMS received or created
+  3gpp       registration state, operator and other 3GPP properties
+  bearer     connection, interface and IP settings of the bearers
+
+Without a modem selection all modems are monitored, and modems appearing on
+or leaving the bus are reported. A selected modem that disappears ends the
+command with exit code 3.
+
+With --json-lines (or --json) every event is printed as one JSON object per
+line.`,
+		Example: `  # Follow modem 0 while reproducing a connection drop
+  mmctl monitor -m 0 --interfaces modem,3gpp,bearer
+
+  # Log the events of all modems for an hour as JSON lines
+  mmctl monitor --json-lines --duration 1h >> modem-events.jsonl`,
+		Args: cobra.NoArgs,
+		RunE: runMonitor,
+	}
+
+	// Flags
+	monitorInterfaces []string
+	monitorJSONLines  bool
+	monitorDuration   time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(monitorCmd)
+
+	monitorCmd.Flags().StringSliceVar(&monitorInterfaces, "interfaces", []string{monitorModem, monitorMessaging}, "Interfaces to monitor: modem, messaging, 3gpp, bearer")
+	monitorCmd.Flags().BoolVar(&monitorJSONLines, "json-lines", false, "Print one JSON object per event")
+	monitorCmd.Flags().DurationVar(&monitorDuration, "duration", 0, "Stop after this long (0 runs until interrupted)")
+}
+
+// parseMonitorInterfaces validates the --interfaces values
+func parseMonitorInterfaces(names []string) (map[string]bool, error) {
+	interfaces := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case monitorModem, monitorMessaging, monitor3gpp, monitorBearer:
+			interfaces[name] = true
+		default:
+			return nil, fmt.Errorf("unknown interface %q, expected modem, messaging, 3gpp or bearer", name)
+		}
+	}
+	if len(interfaces) == 0 {
+		return nil, fmt.Errorf("--interfaces needs at least one interface")
+	}
+	return interfaces, nil
+}
+
+// monitorEvent is one line of monitor output
+type monitorEvent struct {
+	Time        time.Time         `json:"time"`
+	Modem       dbus.ObjectPath   `json:"modem"`
+	Event       string            `json:"event"` // modem-added, modem-removed, state, properties or sms
+	Interface   string            `json:"interface,omitempty"`
+	Object      dbus.ObjectPath   `json:"object,omitempty"` // the bearer or SMS
+	OldState    string            `json:"old_state,omitempty"`
+	NewState    string            `json:"new_state,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	Properties  map[string]string `json:"properties,omitempty"`
+	Invalidated []string          `json:"invalidated,omitempty"`
+	Received    bool              `json:"received,omitempty"`
+	Number      string            `json:"number,omitempty"`
+	Text        string            `json:"text,omitempty"`
+}
+
+// stateChangeReasonName prints a state change reason, e.g. UserRequested as
+// user-requested. Reasons newer than the library are printed by number.
+func stateChangeReasonName(reason modemmanager.MMModemStateChangeReason) string {
+	printed := reason.String()
+	if strings.HasPrefix(printed, "MMModemStateChangeReason(") {
+		return fmt.Sprintf("reason-%d", uint32(reason))
+	}
+	var name strings.Builder
+	for i, r := range printed {
+		if unicode.IsUpper(r) && i > 0 {
+			name.WriteByte('-')
+		}
+		name.WriteRune(unicode.ToLower(r))
+	}
+	return name.String()
+}
+
+func newStateEvent(path dbus.ObjectPath, oldState, newState modemmanager.MMModemState, reason modemmanager.MMModemStateChangeReason) monitorEvent {
+	return monitorEvent{
+		Time:     clk.Now(),
+		Modem:    path,
+		Event:    "state",
+		OldState: strings.ToLower(oldState.String()),
+		NewState: strings.ToLower(newState.String()),
+		Reason:   stateChangeReasonName(reason),
+	}
+}
+
+// newPropertiesEvent converts a PropertiesChanged signal. The modem State
+// property is left out as StateChanged reports it with the reason; ok is
+// false if nothing is left to report.
+func newPropertiesEvent(path, object dbus.ObjectPath, iface, dbusInterface string, changed map[string]dbus.Variant, invalidated []string) (monitorEvent, bool) {
+	ev := monitorEvent{
+		Time:        clk.Now(),
+		Modem:       path,
+		Event:       "properties",
+		Interface:   iface,
+		Object:      object,
+		Properties:  make(map[string]string),
+		Invalidated: invalidated,
+	}
+	for name, value := range changed {
+		full := dbusInterface + "." + name
+		if full == modemmanager.ModemPropertyState {
+			continue
+		}
+		ev.Properties[name] = formatPropertyValue(full, value)
+	}
+	return ev, len(ev.Properties) > 0 || len(ev.Invalidated) > 0
+}
+
+// formatPropertyValue prints the value of the property named full (interface
+// and property name) with enums by name
+func formatPropertyValue(full string, value dbus.Variant) string {
+	switch v := value.Value().(type) {
+	case uint32:
+		switch full {
+		case modemmanager.ModemPropertyPowerState:
+			return strings.ToLower(modemmanager.MMModemPowerState(v).String())
+		case modemmanager.Modem3gppPropertyRegistrationState:
+			return strings.ToLower(modemmanager.MMModem3gppRegistrationState(v).String())
+		case modemmanager.ModemPropertyAccessTechnologies:
+			var tech modemmanager.MMModemAccessTechnology
+			names := []string{}
+			for _, t := range tech.BitmaskToSlice(v) {
+				names = append(names, strings.ToLower(t.String()))
+			}
+			if len(names) == 0 {
+				return "unknown"
+			}
+			return strings.Join(names, ",")
+		}
+	case []interface{}:
+		// SignalQuality is (ub): the percentage and whether it is recent
+		if full == modemmanager.ModemPropertySignalQuality && len(v) == 2 {
+			if quality, ok := v[0].(uint32); ok {
+				return fmt.Sprintf("%d%%", quality)
+			}
+		}
+	}
+	return fmt.Sprint(value.Value())
+}
+
+// shortPath drops the ModemManager prefix from an object path
+func shortPath(path dbus.ObjectPath) string {
+	return strings.TrimPrefix(string(path), modemmanager.ModemManagerObjectPath+"/")
+}
+
+// formatMonitorEvent prints an event as one line of text
+func formatMonitorEvent(ev monitorEvent) string {
+	line := ev.Time.Local().Format("15:04:05.000") + " " + shortPath(ev.Modem)
+	switch ev.Event {
+	case "modem-added":
+		return line + " added"
+	case "modem-removed":
+		return line + " removed"
+	case "state":
+		line += fmt.Sprintf(" state %s → %s", ev.OldState, ev.NewState)
+		if ev.Reason != "" {
+			line += " (" + ev.Reason + ")"
+		}
+		return line
+	case "sms":
+		direction := "created to"
+		if ev.Received {
+			direction = "received from"
+		}
+		return fmt.Sprintf("%s sms %s %s %s: %s", line, shortPath(ev.Object), direction, orDash(ev.Number), strings.ReplaceAll(ev.Text, "\n", " "))
+	}
+
+	line += " " + ev.Interface
+	if ev.Object != "" {
+		line += " " + shortPath(ev.Object)
+	}
+	names := make([]string, 0, len(ev.Properties))
+	for name := range ev.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		line += fmt.Sprintf(" %s=%s", name, ev.Properties[name])
+	}
+	for _, name := range ev.Invalidated {
+		line += " " + name + "=?"
+	}
+	return line
+}
+
+// monitorWatch holds the running signal subscriptions of one object
+type monitorWatch struct {
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newMonitorWatch() *monitorWatch {
+	return &monitorWatch{stop: make(chan struct{})}
+}
+
+func (w *monitorWatch) close() {
+	close(w.stop)
+	w.wg.Wait()
+}
+
+// run forwards the signals of ch to handle until the watch is closed, then
+// calls unsubscribe
+func (w *monitorWatch) run(ch <-chan *dbus.Signal, unsubscribe func(), handle func(*dbus.Signal)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer unsubscribe()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case sig, ok := <-ch:
+				if !ok {
+					return
+				}
+				handle(sig)
+			}
+		}
+	}()
+}
+
+// monitoredModem is a modem being monitored and its bearers
+type monitoredModem struct {
+	modem   modemmanager.Modem
+	watch   *monitorWatch
+	bearers map[dbus.ObjectPath]*monitorWatch
+}
+
+func (m *monitoredModem) close() {
+	for _, w := range m.bearers {
+		w.close()
+	}
+	m.watch.close()
+}
+
+// monitor tracks the subscriptions of all monitored modems. Watches send
+// their events to events; a change of the bearer list is signalled on
+// resync.
+type monitor struct {
+	interfaces map[string]bool
+	events     chan monitorEvent
+	resync     chan struct{}
+	modems     map[dbus.ObjectPath]*monitoredModem
+}
+
+func newMonitor(interfaces map[string]bool) *monitor {
+	return &monitor{
+		interfaces: interfaces,
+		events:     make(chan monitorEvent, 16),
+		resync:     make(chan struct{}, 1),
+		modems:     make(map[dbus.ObjectPath]*monitoredModem),
+	}
+}
+
+// emit sends an event unless the watch is closed meanwhile
+func (m *monitor) emit(w *monitorWatch, ev monitorEvent) {
+	select {
+	case m.events <- ev:
+	case <-w.stop:
+	}
+}
+
+// sync subscribes to new modems and drops the subscriptions of modems that
+// are gone, returning the matching modem-added and modem-removed events.
+// With announce false the modems are subscribed silently.
+func (m *monitor) sync(modems []modemmanager.Modem, announce bool) []monitorEvent {
+	var events []monitorEvent
+	current := make(map[dbus.ObjectPath]bool)
+	for _, modem := range modems {
+		current[modem.GetObjectPath()] = true
+	}
+	for path, watched := range m.modems {
+		if !current[path] {
+			watched.close()
+			delete(m.modems, path)
+			events = append(events, monitorEvent{Time: clk.Now(), Modem: path, Event: "modem-removed"})
+		}
+	}
+	for _, modem := range modems {
+		path := modem.GetObjectPath()
+		if _, ok := m.modems[path]; ok {
+			continue
+		}
+		m.modems[path] = m.watchModem(modem)
+		if announce {
+			events = append(events, monitorEvent{Time: clk.Now(), Modem: path, Event: "modem-added"})
+		}
+	}
+	if m.interfaces[monitorBearer] {
+		for _, watched := range m.modems {
+			m.syncBearers(watched)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Modem < events[j].Modem })
+	return events
+}
+
+// watchModem subscribes to the modem signals and, if enabled, to its
+// messaging signals
+func (m *monitor) watchModem(modem modemmanager.Modem) *monitoredModem {
+	path := modem.GetObjectPath()
+	w := newMonitorWatch()
+	watched := &monitoredModem{modem: modem, watch: w, bearers: make(map[dbus.ObjectPath]*monitorWatch)}
+
+	// The library delivers both signals on one channel per modem
+	signals := modem.SubscribeStateChanged()
+	modem.SubscribePropertiesChanged()
+	w.run(signals, modem.Unsubscribe, func(sig *dbus.Signal) {
+		// The channel receives every signal routed to the connection
+		if sig.Path != path {
+			return
+		}
+		switch sig.Name {
+		case monitorStateChanged:
+			if !m.interfaces[monitorModem] {
+				return
+			}
+			oldState, newState, reason, err := modem.ParseStateChanged(sig)
+			if err != nil {
+				return
+			}
+			m.emit(w, newStateEvent(path, oldState, newState, reason))
+		case monitorPropertiesChanged:
+			dbusInterface, changed, invalidated, err := modem.ParsePropertiesChanged(sig)
+			if err != nil {
+				return
+			}
+			if _, ok := changed["Bearers"]; ok && dbusInterface == modemmanager.ModemInterface {
+				select {
+				case m.resync <- struct{}{}:
+				default:
+				}
+			}
+			iface := ""
+			switch {
+			case dbusInterface == modemmanager.ModemInterface && m.interfaces[monitorModem]:
+				iface = monitorModem
+			case dbusInterface == modemmanager.Modem3gppInterface && m.interfaces[monitor3gpp]:
+				iface = monitor3gpp
+			default:
+				return
+			}
+			if ev, ok := newPropertiesEvent(path, "", iface, dbusInterface, changed, invalidated); ok {
+				m.emit(w, ev)
+			}
+		}
+	})
+
+	if m.interfaces[monitorMessaging] {
+		if messaging, err := modem.GetMessaging(); err == nil {
+			w.run(messaging.SubscribeAdded(), messaging.Unsubscribe, func(sig *dbus.Signal) {
+				if sig.Path != path || sig.Name != monitorSmsAdded {
+					return
+				}
+				sms, received, err := messaging.ParseAdded(sig)
+				if err != nil {
+					return
+				}
+				ev := monitorEvent{Time: clk.Now(), Modem: path, Event: "sms", Object: sms.GetObjectPath(), Received: received}
+				ev.Number, _ = sms.GetNumber()
+				ev.Text, _ = sms.GetText()
+				m.emit(w, ev)
+			})
+		}
+	}
+	return watched
+}
+
+// syncBearers subscribes to new bearers of a modem and drops the
+// subscriptions of deleted ones
+func (m *monitor) syncBearers(watched *monitoredModem) {
+	bearers, err := modemmanager.ListModemBearers(watched.modem)
+	if err != nil {
+		return
+	}
+	modemPath := watched.modem.GetObjectPath()
+	current := make(map[dbus.ObjectPath]bool)
+	for _, bearer := range bearers {
+		path := bearer.GetObjectPath()
+		current[path] = true
+		if _, ok := watched.bearers[path]; ok {
+			continue
+		}
+		w := newMonitorWatch()
+		watched.bearers[path] = w
+		bearer := bearer
+		w.run(bearer.SubscribePropertiesChanged(), bearer.Unsubscribe, func(sig *dbus.Signal) {
+			if sig.Path != path || sig.Name != monitorPropertiesChanged {
+				return
+			}
+			dbusInterface, changed, invalidated, err := bearer.ParsePropertiesChanged(sig)
+			if err != nil || dbusInterface != modemmanager.BearerInterface {
+				return
+			}
+			if ev, ok := newPropertiesEvent(modemPath, path, monitorBearer, dbusInterface, changed, invalidated); ok {
+				m.emit(w, ev)
+			}
+		})
+	}
+	for path, w := range watched.bearers {
+		if !current[path] {
+			w.close()
+			delete(watched.bearers, path)
+		}
+	}
+}
+
+// close drops all subscriptions
+func (m *monitor) close() {
+	for path, watched := range m.modems {
+		watched.close()
+		delete(m.modems, path)
+	}
+}
+
+// runMonitorLoop prints the events of the modems returned by list until ctx
+// is done or duration (if positive) has passed. With selected set the modems
+// are those of a modem selection, and the loop ends with exitModemGone once
+// they are all gone.
+func runMonitorLoop(ctx context.Context, m *monitor, list func() ([]modemmanager.Modem, error), selected bool, duration, interval time.Duration, print func(monitorEvent) error) error {
+	defer m.close()
+
+	var deadline <-chan time.Time
+	if duration > 0 {
+		deadline = clk.After(duration)
+	}
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	for first := true; ; first = false {
+		modems, err := list()
+		if err != nil {
+			// An unreachable daemon has no modems, a restarted one
+			// exports them under new paths
+			modems = nil
+		}
+		for _, ev := range m.sync(modems, !first) {
+			if err := print(ev); err != nil {
+				return err
+			}
+		}
+		if selected && len(m.modems) == 0 {
+			return &exitError{code: exitModemGone, err: errModemGone}
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-deadline:
+				return nil
+			case ev := <-m.events:
+				if err := print(ev); err != nil {
+					return err
+				}
+			case <-m.resync:
+				for _, watched := range m.modems {
+					m.syncBearers(watched)
+				}
+			case <-ticker.C():
+				break wait
+			}
+		}
+	}
+}
+
+func runMonitor(cmd *cobra.Command, args []string) error {
+	interfaces, err := parseMonitorInterfaces(monitorInterfaces)
+	if err != nil {
+		return err
+	}
+	if monitorDuration < 0 {
+		return fmt.Errorf("--duration must not be negative")
+	}
+	mm, err := modemmanager.NewModemManager()
+	if err != nil {
+		return fmt.Errorf("failed to connect to ModemManager: %w", err)
+	}
+
+	// A selected modem is followed by its path; otherwise every modem is
+	list := mm.GetModems
+	selected := modemIndex >= 0 || modemPath != "" || modemIMEI != "" || modemDeviceID != ""
+	if selected {
+		modem, err := getModem()
+		if err != nil {
+			return err
+		}
+		path := modem.GetObjectPath()
+		list = func() ([]modemmanager.Modem, error) {
+			modems, err := mm.GetModems()
+			if err != nil {
+				return nil, err
+			}
+			for _, modem := range modems {
+				if modem.GetObjectPath() == path {
+					return []modemmanager.Modem{modem}, nil
+				}
+			}
+			return nil, nil
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	encoder := json.NewEncoder(os.Stdout)
+	lines := monitorJSONLines || jsonOutput
+	if !lines {
+		fmt.Fprintln(os.Stderr, "Monitoring modem events, press Ctrl-C to stop.")
+	}
+	return runMonitorLoop(ctx, newMonitor(interfaces), list, selected, monitorDuration, monitorPollInterval, func(ev monitorEvent) error {
+		if lines {
+			return encoder.Encode(ev)
+		}
+		_, err := fmt.Println(formatMonitorEvent(ev))
+		return err
+	})
+}
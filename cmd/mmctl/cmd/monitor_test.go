@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestParseMonitorInterfaces(t *testing.T) {
+	interfaces, err := parseMonitorInterfaces([]string{"modem", " 3GPP", "bearer"})
+	if err != nil || len(interfaces) != 3 || !interfaces[monitor3gpp] || interfaces[monitorMessaging] {
+		t.Errorf("parseMonitorInterfaces = %v, %v", interfaces, err)
+	}
+	if _, err := parseMonitorInterfaces([]string{"modem", "sim"}); err == nil {
+		t.Error("unknown interface accepted")
+	}
+	if _, err := parseMonitorInterfaces(nil); err == nil {
+		t.Error("no interfaces accepted")
+	}
+}
+
+func TestNewPropertiesEvent(t *testing.T) {
+	useFakeClock(t, time.Date(2024, 3, 1, 11, 30, 0, 0, time.UTC))
+	path := modemmanager.ModemPathFromIndex(0)
+
+	changed := map[string]dbus.Variant{
+		"State":              dbus.MakeVariant(int32(modemmanager.MmModemStateRegistered)),
+		"SignalQuality":      dbus.MakeVariant([]interface{}{uint32(75), true}),
+		"AccessTechnologies": dbus.MakeVariant(uint32(modemmanager.MmModemAccessTechnologyLte)),
+		"PowerState":         dbus.MakeVariant(uint32(modemmanager.MmModemPowerStateOn)),
+		"Manufacturer":       dbus.MakeVariant("Quectel"),
+	}
+	ev, ok := newPropertiesEvent(path, "", monitorModem, modemmanager.ModemInterface, changed, nil)
+	if !ok {
+		t.Fatal("no event")
+	}
+	want := map[string]string{"SignalQuality": "75%", "AccessTechnologies": "lte", "PowerState": "on", "Manufacturer": "Quectel"}
+	if len(ev.Properties) != len(want) {
+		t.Errorf("Properties = %v, want %v", ev.Properties, want)
+	}
+	for name, value := range want {
+		if ev.Properties[name] != value {
+			t.Errorf("%s = %q, want %q", name, ev.Properties[name], value)
+		}
+	}
+
+	// Only the state changed, which StateChanged reports
+	if _, ok := newPropertiesEvent(path, "", monitorModem, modemmanager.ModemInterface, map[string]dbus.Variant{"State": changed["State"]}, nil); ok {
+		t.Error("state-only change reported")
+	}
+
+	registration := map[string]dbus.Variant{"RegistrationState": dbus.MakeVariant(uint32(modemmanager.MmModem3gppRegistrationStateRoaming))}
+	ev, _ = newPropertiesEvent(path, "", monitor3gpp, modemmanager.Modem3gppInterface, registration, nil)
+	if ev.Properties["RegistrationState"] != "roaming" {
+		t.Errorf("RegistrationState = %q, want roaming", ev.Properties["RegistrationState"])
+	}
+}
+
+func TestStateChangeReasonName(t *testing.T) {
+	tests := map[modemmanager.MMModemStateChangeReason]string{
+		modemmanager.MmModemStateChangeReasonUnknown:       "unknown",
+		modemmanager.MmModemStateChangeReasonUserRequested: "user-requested",
+		modemmanager.MmModemStateChangeReasonFailure:       "failure",
+		modemmanager.MMModemStateChangeReason(9):           "reason-9",
+	}
+	for reason, want := range tests {
+		if got := stateChangeReasonName(reason); got != want {
+			t.Errorf("stateChangeReasonName(%d) = %q, want %q", reason, got, want)
+		}
+	}
+}
+
+func TestFormatMonitorEvent(t *testing.T) {
+	at := time.Date(2024, 3, 1, 11, 30, 0, 250e6, time.Local)
+	modem := modemmanager.ModemPathFromIndex(0)
+	tests := []struct {
+		ev   monitorEvent
+		want string
+	}{
+		{monitorEvent{Time: at, Modem: modem, Event: "modem-added"}, "11:30:00.250 Modem/0 added"},
+		{newStateEvent(modem, modemmanager.MmModemStateRegistered, modemmanager.MmModemStateConnecting, modemmanager.MmModemStateChangeReasonUserRequested),
+			"11:30:00.250 Modem/0 state registered → connecting (user-requested)"},
+		{monitorEvent{Time: at, Modem: modem, Event: "properties", Interface: monitorBearer, Object: modemmanager.BearerObjectPathPrefix + "2",
+			Properties: map[string]string{"Interface": "wwan0", "Connected": "true"}, Invalidated: []string{"Stats"}},
+			"11:30:00.250 Modem/0 bearer Bearer/2 Connected=true Interface=wwan0 Stats=?"},
+		{monitorEvent{Time: at, Modem: modem, Event: "sms", Object: modemmanager.SmsObjectPathPrefix + "4", Received: true, Number: "+491234", Text: "see\nyou"},
+			"11:30:00.250 Modem/0 sms SMS/4 received from +491234: see you"},
+	}
+	for _, test := range tests {
+		test.ev.Time = at
+		if got := formatMonitorEvent(test.ev); got != test.want {
+			t.Errorf("formatMonitorEvent = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestMonitorSync(t *testing.T) {
+	first, second := mocks.NewMockModem(), mocks.NewMockModem()
+	second.ObjectPathValue = modemmanager.ModemPathFromIndex(1)
+	m := newMonitor(map[string]bool{monitorModem: true, monitorBearer: true})
+	defer m.close()
+
+	if events := m.sync([]modemmanager.Modem{first}, false); len(events) != 0 {
+		t.Errorf("initial sync announced %v", events)
+	}
+	if len(m.modems[first.ObjectPathValue].bearers) != 1 {
+		t.Errorf("bearers of the first modem not watched")
+	}
+
+	events := m.sync([]modemmanager.Modem{second}, true)
+	if len(events) != 2 || events[0].Event != "modem-removed" || events[0].Modem != first.ObjectPathValue ||
+		events[1].Event != "modem-added" || events[1].Modem != second.ObjectPathValue {
+		t.Errorf("events = %+v, want the first modem removed and the second added", events)
+	}
+	if first.Unsubscribed != 1 {
+		t.Errorf("removed modem unsubscribed %d times, want once", first.Unsubscribed)
+	}
+}
+
+func TestRunMonitorLoop(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+	modem := mocks.NewMockModem()
+	modem.SignalChan = make(chan *dbus.Signal, 1)
+
+	// The modem is there for the first two reads
+	reads := 0
+	readDone := make(chan struct{}, 10)
+	list := func() ([]modemmanager.Modem, error) {
+		reads++
+		defer func() { readDone <- struct{}{} }()
+		if reads <= 2 {
+			return []modemmanager.Modem{modem}, nil
+		}
+		return nil, nil
+	}
+
+	printed := make(chan monitorEvent, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- runMonitorLoop(context.Background(), newMonitor(map[string]bool{monitorModem: true}), list, true, 0, time.Second, func(ev monitorEvent) error {
+			printed <- ev
+			return nil
+		})
+	}()
+
+	<-readDone
+	// Signals of other objects are ignored
+	modem.SignalChan <- &dbus.Signal{Path: modemmanager.ModemPathFromIndex(7), Name: monitorStateChanged}
+	modem.SignalChan <- &dbus.Signal{Path: modem.ObjectPathValue, Name: monitorStateChanged}
+	if ev := <-printed; ev.Event != "state" || ev.NewState != "enabled" || ev.Reason != "user-requested" {
+		t.Errorf("event = %+v", ev)
+	}
+	fake.Advance(time.Second)
+	<-readDone
+	fake.Advance(time.Second)
+	<-readDone
+
+	err := <-done
+	if ExitCode(err) != exitModemGone {
+		t.Errorf("error = %v, want the modem gone", err)
+	}
+	if ev := <-printed; ev.Event != "modem-removed" {
+		t.Errorf("last event = %+v, want modem-removed", ev)
+	}
+	if modem.Unsubscribed != 1 {
+		t.Errorf("Unsubscribe called %d times, want once", modem.Unsubscribed)
+	}
+}
+
+func TestRunMonitorLoopDuration(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+	list := func() ([]modemmanager.Modem, error) { return nil, nil }
+	done := make(chan error, 1)
+	go func() {
+		done <- runMonitorLoop(context.Background(), newMonitor(map[string]bool{monitorModem: true}), list, false, time.Minute, time.Hour, func(monitorEvent) error { return nil })
+	}()
+	fake.BlockUntilTimers(2)
+	fake.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Errorf("error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := runMonitorLoop(ctx, newMonitor(map[string]bool{monitorModem: true}), list, false, 0, time.Hour, func(monitorEvent) error { return nil }); err != nil {
+		t.Errorf("cancelled: error = %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	modemTimeCmd = &cobra.Command{
+		Use:   "time",
+		Short: "Show the network time and how far the host clock is off",
+		Long: `Read the time broadcast by the mobile network (NITZ) and compare it with the
+host clock. The modem must be registered with a network that sends the time.
+
+With --set-system-clock the host clock is set to the network time, unless it
+is off by less than --max-drift. Setting the clock requires root or the
+CAP_SYS_TIME capability. Network time has a resolution of one second, so a
+--max-drift below 2s mostly chases rounding.`,
+		Example: `  # Show the network time and the host clock offset
+  mmctl modem time -m 0
+
+  # Set the host clock if it is off by a minute or more
+  sudo mmctl modem time -m 0 --set-system-clock --max-drift 1m`,
+		Args: cobra.NoArgs,
+		RunE: runModemTime,
+	}
+
+	// Flags
+	timeSetSystemClock bool
+	timeMaxDrift       time.Duration
+)
+
+func init() {
+	modemCmd.AddCommand(modemTimeCmd)
+
+	modemTimeCmd.Flags().BoolVar(&timeSetSystemClock, "set-system-clock", false, "Set the host clock to the network time (requires root)")
+	modemTimeCmd.Flags().DurationVar(&timeMaxDrift, "max-drift", 2*time.Second, "Leave the host clock alone if it is off by less than this")
+}
+
+// networkTimeReading is the network time together with the host clock at the
+// moment it was read
+type networkTimeReading struct {
+	Network  time.Time
+	Host     time.Time
+	Timezone *modemmanager.ModemTimeZone // nil if the network sent none
+}
+
+// Delta returns how far the host clock is ahead of the network time, negative
+// if it is behind
+func (r networkTimeReading) Delta() time.Duration {
+	return r.Host.Sub(r.Network)
+}
+
+// readNetworkTime reads the network time and timezone. The host time is taken
+// halfway through the D-Bus call to cancel out its latency.
+func readNetworkTime(modemTime modemmanager.ModemTime) (networkTimeReading, error) {
+	before := clk.Now()
+	network, err := modemTime.GetNetworkTime()
+	after := clk.Now()
+	if err != nil {
+		return networkTimeReading{}, fmt.Errorf("network time not available (the modem must be registered with a network that sends it): %w", err)
+	}
+	reading := networkTimeReading{Network: network, Host: before.Add(after.Sub(before) / 2)}
+
+	if tz, err := modemTime.GetNetworkTimezone(); err == nil {
+		reading.Timezone = &tz
+		// Legacy time strings may come without UTC offset and are parsed as
+		// UTC; they are local time of the network timezone
+		if network.Location() == time.UTC && tz.Offset != 0 {
+			zone := time.FixedZone("", int(tz.Offset)*60)
+			reading.Network = time.Date(network.Year(), network.Month(), network.Day(),
+				network.Hour(), network.Minute(), network.Second(), network.Nanosecond(), zone)
+		}
+	}
+	return reading, nil
+}
+
+// setSystemClock sets the realtime clock of the host; tests replace it
+var setSystemClock = func(t time.Time) error {
+	ts := unix.NsecToTimespec(t.UnixNano())
+	return unix.ClockSettime(unix.CLOCK_REALTIME, &ts)
+}
+
+// syncSystemClock sets the host clock to the network time of reading unless
+// the host is off by less than maxDrift. It returns whether the clock was set.
+func syncSystemClock(reading networkTimeReading, maxDrift time.Duration) (bool, error) {
+	delta := reading.Delta()
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta < maxDrift {
+		return false, nil
+	}
+	// Account for the time passed since the network time was read
+	target := reading.Network.Add(clk.Now().Sub(reading.Host))
+	if err := setSystemClock(target); err != nil {
+		if errors.Is(err, unix.EPERM) {
+			return false, fmt.Errorf("setting the system clock requires root (or CAP_SYS_TIME): %w", err)
+		}
+		return false, fmt.Errorf("failed to set the system clock: %w", err)
+	}
+	return true, nil
+}
+
+// formatUtcOffset formats an offset in minutes as ±hh:mm
+func formatUtcOffset(minutes int32) string {
+	sign := '+'
+	if minutes < 0 {
+		sign = '-'
+		minutes = -minutes
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, minutes/60, minutes%60)
+}
+
+// describeClockDelta tells how far the host clock is off the network time
+func describeClockDelta(delta time.Duration) string {
+	switch {
+	case delta > -time.Second && delta < time.Second:
+		return "host clock in sync with the network"
+	case delta > 0:
+		return fmt.Sprintf("host clock %s ahead of the network", humanDuration(delta))
+	default:
+		return fmt.Sprintf("host clock %s behind the network", humanDuration(delta))
+	}
+}
+
+func runModemTime(cmd *cobra.Command, args []string) error {
+	if timeMaxDrift < 0 {
+		return fmt.Errorf("--max-drift must not be negative")
+	}
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	modemTime, err := modem.GetTime()
+	if err != nil {
+		return fmt.Errorf("modem does not support network time: %w", err)
+	}
+	reading, err := readNetworkTime(modemTime)
+	if err != nil {
+		return err
+	}
+
+	clockSet := false
+	if timeSetSystemClock {
+		if clockSet, err = syncSystemClock(reading, timeMaxDrift); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		out := map[string]interface{}{
+			"network_time":  reading.Network.Format(time.RFC3339),
+			"host_time":     reading.Host.In(reading.Network.Location()).Format(time.RFC3339Nano),
+			"delta_seconds": reading.Delta().Seconds(),
+		}
+		if reading.Timezone != nil {
+			out["utc_offset_minutes"] = reading.Timezone.Offset
+			out["dst_offset_minutes"] = reading.Timezone.DstOffset
+			out["leap_seconds"] = reading.Timezone.LeapSeconds
+		}
+		if timeSetSystemClock {
+			out["system_clock_set"] = clockSet
+		}
+		return encoder.Encode(out)
+	}
+
+	fmt.Printf("Network time:  %s\n", reading.Network.Format("2006-01-02 15:04:05 -07:00"))
+	if tz := reading.Timezone; tz != nil {
+		fmt.Printf("UTC offset:    %s (DST %s)\n", formatUtcOffset(tz.Offset), formatUtcOffset(tz.DstOffset))
+		fmt.Printf("Leap seconds:  %d\n", tz.LeapSeconds)
+	}
+	fmt.Printf("Host time:     %s\n", reading.Host.In(reading.Network.Location()).Format("2006-01-02 15:04:05.000 -07:00"))
+	fmt.Printf("Delta:         %s\n", describeClockDelta(reading.Delta()))
+
+	if timeSetSystemClock {
+		if clockSet {
+			fmt.Println("✓ System clock set to the network time")
+		} else {
+			fmt.Printf("System clock within --max-drift (%s), not changed\n", timeMaxDrift)
+		}
+	}
+	return nil
+}
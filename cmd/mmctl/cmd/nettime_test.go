@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+	"golang.org/x/sys/unix"
+)
+
+func useFakeClock(t *testing.T, now time.Time) *clock.Fake {
+	fake := clock.NewFake(now)
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+	return fake
+}
+
+func TestReadNetworkTime(t *testing.T) {
+	// Network time is 11:30:45 UTC
+	useFakeClock(t, time.Date(2024, 3, 1, 11, 30, 50, 0, time.UTC))
+
+	modemTime := mocks.NewMockModemTime()
+	reading, err := readNetworkTime(modemTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reading.Delta() != 5*time.Second {
+		t.Errorf("Delta = %v, want 5s", reading.Delta())
+	}
+	if reading.Timezone == nil || reading.Timezone.Offset != 60 {
+		t.Errorf("Timezone = %v", reading.Timezone)
+	}
+
+	// A time without UTC offset is local time of the network timezone
+	modemTime.NetworkTimeValue, _ = modemmanager.ParseNetworkTime("24/03/01,12:30:45")
+	if reading, _ = readNetworkTime(modemTime); reading.Delta() != 5*time.Second {
+		t.Errorf("Delta without UTC offset = %v, want 5s", reading.Delta())
+	}
+	modemTime.TimezoneError = errors.New("no timezone")
+	if reading, _ = readNetworkTime(modemTime); reading.Timezone != nil || reading.Delta() != -time.Hour+5*time.Second {
+		t.Errorf("without timezone: Timezone = %v, Delta = %v", reading.Timezone, reading.Delta())
+	}
+
+	modemTime.NetworkTimeError = errors.New("network time is unknown")
+	if _, err := readNetworkTime(modemTime); err == nil || !strings.Contains(err.Error(), "must be registered") {
+		t.Errorf("error = %v, want a hint to register", err)
+	}
+}
+
+func TestSyncSystemClock(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, 3, 1, 11, 31, 0, 0, time.UTC))
+	var set []time.Time
+	var setErr error
+	prev := setSystemClock
+	setSystemClock = func(t time.Time) error {
+		set = append(set, t)
+		return setErr
+	}
+	t.Cleanup(func() { setSystemClock = prev })
+
+	network := time.Date(2024, 3, 1, 11, 30, 0, 0, time.UTC)
+	reading := networkTimeReading{Network: network, Host: clk.Now()}
+
+	if changed, err := syncSystemClock(reading, 2*time.Minute); changed || err != nil || len(set) != 0 {
+		t.Errorf("within --max-drift: changed = %v, err = %v, set %v", changed, err, set)
+	}
+
+	fake.Advance(3 * time.Second)
+	if changed, err := syncSystemClock(reading, 30*time.Second); !changed || err != nil {
+		t.Errorf("beyond --max-drift: changed = %v, err = %v", changed, err)
+	}
+	if len(set) != 1 || !set[0].Equal(network.Add(3*time.Second)) {
+		t.Errorf("clock set to %v, want the network time plus the time since reading it", set)
+	}
+
+	setErr = unix.EPERM
+	if _, err := syncSystemClock(reading, 0); err == nil || !strings.Contains(err.Error(), "requires root") {
+		t.Errorf("error = %v, want a hint to run as root", err)
+	}
+}
+
+func TestFormatUtcOffset(t *testing.T) {
+	for minutes, want := range map[int32]string{0: "+00:00", 60: "+01:00", 330: "+05:30", -210: "-03:30"} {
+		if got := formatUtcOffset(minutes); got != want {
+			t.Errorf("formatUtcOffset(%d) = %q, want %q", minutes, got, want)
+		}
+	}
+}
+
+func TestDescribeClockDelta(t *testing.T) {
+	tests := map[time.Duration]string{
+		400 * time.Millisecond: "host clock in sync with the network",
+		200 * time.Second:      "host clock 3m 20s ahead of the network",
+		-5 * time.Second:       "host clock 5s behind the network",
+	}
+	for delta, want := range tests {
+		if got := describeClockDelta(delta); got != want {
+			t.Errorf("describeClockDelta(%v) = %q, want %q", delta, got, want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+// EF_FPLMN (forbidden PLMNs) file ID on the USIM, 3GPP TS 31.102
+const efFPLMN = 0x6F7B
+
+// AT+CRSM commands (3GPP TS 27.007 section 8.18)
+const (
+	crsmReadBinary   = 176
+	crsmUpdateBinary = 214
+)
+
+var (
+	networkCmd = &cobra.Command{
+		Use:   "network",
+		Short: "Manage network registration settings",
+		Long: `Inspect and change network related settings of a modem and its SIM.
+
+Use a subcommand to perform a specific operation.`,
+	}
+
+	networkForbiddenCmd = &cobra.Command{
+		Use:   "forbidden",
+		Short: "Manage the SIM forbidden PLMN list",
+		Long: `Inspect or clear the forbidden PLMN list (EF_FPLMN) stored on the SIM.
+
+Networks that rejected a registration attempt are added to this list and are
+skipped during automatic network selection until the list is cleared.
+
+ModemManager does not expose SIM file access, so the list is accessed with
+AT+CRSM restricted SIM access commands. These go through the ModemManager
+Command() API, which requires ModemManager to run in debug mode.`,
+	}
+
+	networkForbiddenListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List forbidden networks",
+		Example: `  # List forbidden networks of modem 0
+  mmctl network forbidden list -m 0`,
+		RunE: runNetworkForbiddenList,
+	}
+
+	networkForbiddenClearCmd = &cobra.Command{
+		Use:   "clear",
+		Short: "Clear the forbidden network list",
+		Long: `Clear the forbidden PLMN list stored on the SIM.
+
+Warning: this writes to the SIM card. The change persists across modems and
+reboots and cannot be undone by mmctl.`,
+		Example: `  # Clear forbidden networks of modem 0
+  mmctl network forbidden clear -m 0 --yes`,
+		RunE: runNetworkForbiddenClear,
+	}
+
+	// Flags
+	forbiddenClearConfirm bool
+)
+
+func init() {
+	rootCmd.AddCommand(networkCmd)
+
+	networkCmd.AddCommand(networkForbiddenCmd)
+	networkForbiddenCmd.AddCommand(networkForbiddenListCmd)
+	networkForbiddenCmd.AddCommand(networkForbiddenClearCmd)
+
+	networkForbiddenCmd.PersistentFlags().Uint32VarP(&commandTimeout, "timeout", "t", 10, "AT command timeout in seconds")
+	networkForbiddenClearCmd.Flags().BoolVar(&forbiddenClearConfirm, "yes", false, "Confirm modifying the SIM")
+}
+
+// forbiddenEntry is a forbidden PLMN with an operator name when known
+type forbiddenEntry struct {
+	plmn
+	Operator string `json:"operator,omitempty"`
+}
+
+func runNetworkForbiddenList(cmd *cobra.Command, args []string) error {
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+
+	data, err := readFPLMN(modem)
+	if err != nil {
+		return err
+	}
+	list, err := decodePLMNList(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode forbidden PLMN list: %w", err)
+	}
+
+	names := knownOperatorNames(modem)
+	entries := make([]forbiddenEntry, len(list))
+	for i, p := range list {
+		entries[i] = forbiddenEntry{plmn: p, Operator: names[p.String()]}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No forbidden networks")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "MCC\tMNC\tOperator\n")
+	fmt.Fprintf(w, "---\t---\t--------\n")
+	for _, e := range entries {
+		operator := e.Operator
+		if operator == "" {
+			operator = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.MCC, e.MNC, operator)
+	}
+
+	return nil
+}
+
+func runNetworkForbiddenClear(cmd *cobra.Command, args []string) error {
+	if err := confirmModification("this modifies the forbidden PLMN list stored on the SIM card.", forbiddenClearConfirm); err != nil {
+		return err
+	}
+
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+
+	// Read first so the update covers the full file size of this SIM
+	data, err := readFPLMN(modem)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("SIM returned an empty forbidden PLMN list")
+	}
+
+	empty, err := encodePLMNList(nil, len(data))
+	if err != nil {
+		return err
+	}
+	at := fmt.Sprintf("AT+CRSM=%d,%d,0,0,%d,\"%X\"", crsmUpdateBinary, efFPLMN, len(empty), empty)
+	if err := runCRSM(modem, at, nil); err != nil {
+		return fmt.Errorf("failed to clear forbidden PLMN list: %w", err)
+	}
+
+	fmt.Println("Forbidden network list cleared")
+	return nil
+}
+
+// readFPLMN reads the raw EF_FPLMN contents; a length of 0 reads the whole file
+func readFPLMN(modem modemmanager.Modem) ([]byte, error) {
+	at := fmt.Sprintf("AT+CRSM=%d,%d,0,0,0", crsmReadBinary, efFPLMN)
+	var data []byte
+	if err := runCRSM(modem, at, &data); err != nil {
+		return nil, fmt.Errorf("failed to read forbidden PLMN list: %w", err)
+	}
+	return data, nil
+}
+
+// runCRSM sends an AT+CRSM command and checks the SIM status words
+func runCRSM(modem modemmanager.Modem, at string, data *[]byte) error {
+	resp, err := sendAT(modem, at)
+	if err != nil {
+		return err
+	}
+	sw1, sw2, payload, err := parseCRSMResponse(resp)
+	if err != nil {
+		return err
+	}
+	// 0x90 0x00 is normal completion, 0x91 signals pending proactive commands
+	if sw1 != 0x90 && sw1 != 0x91 {
+		return fmt.Errorf("SIM returned status %02X%02X", sw1, sw2)
+	}
+	if data != nil {
+		*data = payload
+	}
+	return nil
+}
+
+// knownOperatorNames maps the PLMNs the modem knows a name for, the SIM home
+// operator and the current serving network, to their operator names.
+func knownOperatorNames(modem modemmanager.Modem) map[string]string {
+	names := make(map[string]string)
+	if sim, err := modem.GetSim(); err == nil {
+		id, errID := sim.GetOperatorIdentifier()
+		name, errName := sim.GetOperatorName()
+		if errID == nil && errName == nil && name != "" {
+			names[id] = name
+		}
+	}
+	if modem3gpp, err := modem.Get3gpp(); err == nil {
+		code, errCode := modem3gpp.GetOperatorCode()
+		name, errName := modem3gpp.GetOperatorName()
+		if errCode == nil && errName == nil && name != "" {
+			names[code] = name
+		}
+	}
+	return names
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// plmnEntrySize is the size of one PLMN entry in SIM elementary files such
+// as EF_FPLMN (3GPP TS 31.102, coded as in TS 24.008 section 10.5.1.13).
+const plmnEntrySize = 3
+
+// plmn is a decoded MCC/MNC pair
+type plmn struct {
+	MCC string `json:"mcc"`
+	MNC string `json:"mnc"`
+}
+
+// String returns the PLMN in the "MCCMNC" form used by ModemManager operator codes
+func (p plmn) String() string {
+	return p.MCC + p.MNC
+}
+
+// decodePLMN decodes one nibble-swapped BCD PLMN entry:
+//
+//	byte 0: MCC digit 2 | MCC digit 1
+//	byte 1: MNC digit 3 | MCC digit 3
+//	byte 2: MNC digit 2 | MNC digit 1
+//
+// MNC digit 3 is 0xF for two-digit MNCs. An entry of all 0xFF marks an unused
+// slot and is reported with ok == false.
+func decodePLMN(b []byte) (p plmn, ok bool, err error) {
+	if len(b) != plmnEntrySize {
+		return plmn{}, false, fmt.Errorf("PLMN entry must be %d bytes, got %d", plmnEntrySize, len(b))
+	}
+	if b[0] == 0xff && b[1] == 0xff && b[2] == 0xff {
+		return plmn{}, false, nil
+	}
+
+	nibbles := []byte{
+		b[0] & 0x0f, b[0] >> 4, b[1] & 0x0f, // MCC
+		b[2] & 0x0f, b[2] >> 4, b[1] >> 4, // MNC
+	}
+	digits := make([]byte, 0, len(nibbles))
+	for i, n := range nibbles {
+		if n == 0x0f && i == 5 {
+			break
+		}
+		if n > 9 {
+			return plmn{}, false, fmt.Errorf("invalid BCD digit 0x%X in PLMN entry %X", n, b)
+		}
+		digits = append(digits, '0'+n)
+	}
+
+	return plmn{MCC: string(digits[:3]), MNC: string(digits[3:])}, true, nil
+}
+
+// encodePLMN is the inverse of decodePLMN
+func encodePLMN(p plmn) ([]byte, error) {
+	if len(p.MCC) != 3 || !isDigits(p.MCC) {
+		return nil, fmt.Errorf("MCC must be 3 digits, got %q", p.MCC)
+	}
+	if (len(p.MNC) != 2 && len(p.MNC) != 3) || !isDigits(p.MNC) {
+		return nil, fmt.Errorf("MNC must be 2 or 3 digits, got %q", p.MNC)
+	}
+
+	d := func(s string, i int) byte { return s[i] - '0' }
+	mnc3 := byte(0x0f)
+	if len(p.MNC) == 3 {
+		mnc3 = d(p.MNC, 2)
+	}
+
+	return []byte{
+		d(p.MCC, 1)<<4 | d(p.MCC, 0),
+		mnc3<<4 | d(p.MCC, 2),
+		d(p.MNC, 1)<<4 | d(p.MNC, 0),
+	}, nil
+}
+
+// encodePLMNList is the inverse of decodePLMNList: it encodes list into a
+// PLMN list file of size bytes, marking the slots left over as unused
+func encodePLMNList(list []plmn, size int) ([]byte, error) {
+	if size%plmnEntrySize != 0 {
+		return nil, fmt.Errorf("PLMN list length %d is not a multiple of %d", size, plmnEntrySize)
+	}
+	if len(list)*plmnEntrySize > size {
+		return nil, fmt.Errorf("%d PLMNs do not fit into a list of %d entries", len(list), size/plmnEntrySize)
+	}
+	data := bytes.Repeat([]byte{0xff}, size)
+	for i, p := range list {
+		entry, err := encodePLMN(p)
+		if err != nil {
+			return nil, err
+		}
+		copy(data[i*plmnEntrySize:], entry)
+	}
+	return data, nil
+}
+
+// decodePLMNList decodes the contents of a PLMN list file, skipping unused slots
+func decodePLMNList(data []byte) ([]plmn, error) {
+	if len(data)%plmnEntrySize != 0 {
+		return nil, fmt.Errorf("PLMN list length %d is not a multiple of %d", len(data), plmnEntrySize)
+	}
+	var list []plmn
+	for i := 0; i < len(data); i += plmnEntrySize {
+		p, ok, err := decodePLMN(data[i : i+plmnEntrySize])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			list = append(list, p)
+		}
+	}
+	return list, nil
+}
+
+// parseCRSMResponse parses a `+CRSM: <sw1>,<sw2>[,"<response>"]` reply to an
+// AT+CRSM restricted SIM access command (3GPP TS 27.007 section 8.18).
+func parseCRSMResponse(resp string) (sw1, sw2 int, data []byte, err error) {
+	idx := strings.Index(resp, "+CRSM:")
+	if idx < 0 {
+		return 0, 0, nil, fmt.Errorf("unexpected AT+CRSM response %q", resp)
+	}
+	line := strings.TrimSpace(resp[idx+len("+CRSM:"):])
+	if nl := strings.IndexAny(line, "\r\n"); nl >= 0 {
+		line = line[:nl]
+	}
+
+	fields := strings.SplitN(line, ",", 3)
+	if len(fields) < 2 {
+		return 0, 0, nil, fmt.Errorf("unexpected AT+CRSM response %q", resp)
+	}
+	if sw1, err = strconv.Atoi(strings.TrimSpace(fields[0])); err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid SW1 in AT+CRSM response %q", resp)
+	}
+	if sw2, err = strconv.Atoi(strings.TrimSpace(fields[1])); err != nil {
+		return 0, 0, nil, fmt.Errorf("invalid SW2 in AT+CRSM response %q", resp)
+	}
+	if len(fields) == 3 {
+		payload := strings.Trim(strings.TrimSpace(fields[2]), `"`)
+		if data, err = hex.DecodeString(payload); err != nil {
+			return 0, 0, nil, fmt.Errorf("invalid data in AT+CRSM response %q: %w", resp, err)
+		}
+	}
+	return sw1, sw2, data, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDecodePLMN(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want plmn
+		ok   bool
+	}{
+		{[]byte{0x62, 0xf2, 0x10}, plmn{"262", "01"}, true},
+		{[]byte{0x13, 0x00, 0x62}, plmn{"310", "260"}, true},
+		{[]byte{0x02, 0xf8, 0x39}, plmn{"208", "93"}, true},
+		{[]byte{0x00, 0xf1, 0x10}, plmn{"001", "01"}, true},
+		{[]byte{0xff, 0xff, 0xff}, plmn{}, false},
+	}
+	for _, tt := range tests {
+		got, ok, err := decodePLMN(tt.in)
+		if err != nil {
+			t.Fatalf("decodePLMN(%X): %v", tt.in, err)
+		}
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("decodePLMN(%X) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDecodePLMNInvalid(t *testing.T) {
+	for _, in := range [][]byte{
+		{0x62, 0xf2},       // too short
+		{0x6a, 0xf2, 0x10}, // non-decimal MCC digit
+		{0x62, 0xf2, 0x1f}, // filler in MNC digit 1
+	} {
+		if _, _, err := decodePLMN(in); err == nil {
+			t.Errorf("decodePLMN(%X) succeeded, want error", in)
+		}
+	}
+}
+
+func TestEncodePLMNRoundTrip(t *testing.T) {
+	for _, p := range []plmn{{"262", "01"}, {"310", "260"}, {"208", "93"}, {"999", "999"}} {
+		b, err := encodePLMN(p)
+		if err != nil {
+			t.Fatalf("encodePLMN(%+v): %v", p, err)
+		}
+		got, ok, err := decodePLMN(b)
+		if err != nil || !ok || got != p {
+			t.Errorf("round trip of %+v via %X = %+v, %v, %v", p, b, got, ok, err)
+		}
+	}
+
+	b, _ := encodePLMN(plmn{"262", "01"})
+	if !bytes.Equal(b, []byte{0x62, 0xf2, 0x10}) {
+		t.Errorf("encodePLMN(262/01) = %X, want 62F210", b)
+	}
+}
+
+func TestEncodePLMNInvalid(t *testing.T) {
+	for _, p := range []plmn{{"26", "01"}, {"2620", "01"}, {"262", "1"}, {"262", "0a"}, {"", ""}} {
+		if _, err := encodePLMN(p); err == nil {
+			t.Errorf("encodePLMN(%+v) succeeded, want error", p)
+		}
+	}
+}
+
+func TestDecodePLMNList(t *testing.T) {
+	data := []byte{0x62, 0xf2, 0x10, 0xff, 0xff, 0xff, 0x13, 0x00, 0x62, 0xff, 0xff, 0xff}
+	got, err := decodePLMNList(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []plmn{{"262", "01"}, {"310", "260"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodePLMNList = %+v, want %+v", got, want)
+	}
+
+	if _, err := decodePLMNList(data[:4]); err == nil {
+		t.Error("decodePLMNList accepted a truncated list")
+	}
+}
+
+func TestEncodePLMNList(t *testing.T) {
+	list := []plmn{{"262", "01"}, {"310", "260"}}
+	data, err := encodePLMNList(list, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x62, 0xf2, 0x10, 0x13, 0x00, 0x62, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if !bytes.Equal(data, want) {
+		t.Errorf("encodePLMNList = %X, want %X", data, want)
+	}
+	if got, err := decodePLMNList(data); err != nil || !reflect.DeepEqual(got, list) {
+		t.Errorf("round trip = %+v, %v", got, err)
+	}
+
+	// The cleared list of forbidden network clear
+	if data, err := encodePLMNList(nil, 6); err != nil || !bytes.Equal(data, bytes.Repeat([]byte{0xff}, 6)) {
+		t.Errorf("encodePLMNList(nil, 6) = %X, %v", data, err)
+	}
+
+	if _, err := encodePLMNList(list, 3); err == nil {
+		t.Error("encodePLMNList accepted more PLMNs than slots")
+	}
+	if _, err := encodePLMNList(nil, 4); err == nil {
+		t.Error("encodePLMNList accepted a length that is not a multiple of 3")
+	}
+	if _, err := encodePLMNList([]plmn{{"26", "01"}}, 3); err == nil {
+		t.Error("encodePLMNList accepted an invalid PLMN")
+	}
+}
+
+func TestParseCRSMResponse(t *testing.T) {
+	sw1, sw2, data, err := parseCRSMResponse("+CRSM: 144,0,\"62F210FFFFFF\"\r\n\r\nOK")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sw1 != 144 || sw2 != 0 || !bytes.Equal(data, []byte{0x62, 0xf2, 0x10, 0xff, 0xff, 0xff}) {
+		t.Errorf("got %d, %d, %X", sw1, sw2, data)
+	}
+
+	sw1, sw2, data, err = parseCRSMResponse("+CRSM: 106,130")
+	if err != nil || sw1 != 106 || sw2 != 130 || data != nil {
+		t.Errorf("status-only response = %d, %d, %X, %v", sw1, sw2, data, err)
+	}
+
+	for _, resp := range []string{"ERROR", "+CRSM: 144", "+CRSM: x,0", "+CRSM: 144,0,\"ZZ\""} {
+		if _, _, _, err := parseCRSMResponse(resp); err == nil {
+			t.Errorf("parseCRSMResponse(%q) succeeded, want error", resp)
+		}
+	}
+}
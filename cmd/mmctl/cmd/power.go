@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+// powerPollInterval is how often the power state is re-read while waiting
+const powerPollInterval = time.Second
+
+var (
+	modemPowerCmd = &cobra.Command{
+		Use:   "power",
+		Short: "Show or set the power state",
+		Long: `Show the power state of the modem, or set it with --state.
+
+In low power mode the radio is off (airplane mode) and the modem draws little
+current. ModemManager only changes the power state of a disabled modem. After
+setting low or on mmctl waits until the modem reports the new state; off is
+not waited for, as the modem may drop off the bus. Some modems cannot be
+powered on again without a hardware toggle, so off needs --yes.`,
+		Example: `  # Show the power state of modem 0
+  mmctl modem power -m 0
+
+  # Put a disabled modem into low power mode
+  mmctl modem power -m 0 --state low
+
+  # Power the modem off
+  mmctl modem power -m 0 --state off --yes`,
+		Args: cobra.NoArgs,
+		RunE: runModemPower,
+	}
+
+	// Flags
+	powerState   string
+	powerConfirm bool
+	powerTimeout time.Duration
+)
+
+func init() {
+	modemCmd.AddCommand(modemPowerCmd)
+
+	modemPowerCmd.Flags().StringVar(&powerState, "state", "", "Power state to set: low, on or off")
+	modemPowerCmd.Flags().BoolVar(&powerConfirm, "yes", false, "Confirm powering the modem off")
+	modemPowerCmd.Flags().DurationVar(&powerTimeout, "timeout", 30*time.Second, "Maximum time to wait for the new power state")
+}
+
+// parsePowerState resolves the --state value
+func parsePowerState(name string) (modemmanager.MMModemPowerState, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "low":
+		return modemmanager.MmModemPowerStateLow, nil
+	case "on":
+		return modemmanager.MmModemPowerStateOn, nil
+	case "off":
+		return modemmanager.MmModemPowerStateOff, nil
+	}
+	return modemmanager.MmModemPowerStateUnknown, fmt.Errorf("unknown power state %q, expected low, on or off", name)
+}
+
+func powerStateName(state modemmanager.MMModemPowerState) string {
+	return strings.ToLower(state.String())
+}
+
+// waitForPowerState re-reads the power state on every signal and interval
+// tick until it is want, and gives up after timeout
+func waitForPowerState(read func() (modemmanager.MMModemPowerState, error), signals <-chan *dbus.Signal, want modemmanager.MMModemPowerState, timeout, interval time.Duration) error {
+	last := modemmanager.MmModemPowerStateUnknown
+	err := pollUntil(context.Background(), signals, timeout, interval, func() (bool, error) {
+		// Read errors are retried, the modem may be switching
+		state, err := read()
+		if err == nil {
+			last = state
+		}
+		return err == nil && state == want, nil
+	})
+	if errors.Is(err, errWaitTimeout) {
+		return &exitError{code: exitTimeout, err: fmt.Errorf("power state still %s after %s, wanted %s", powerStateName(last), timeout, powerStateName(want))}
+	}
+	return err
+}
+
+func runModemPower(cmd *cobra.Command, args []string) error {
+	var want modemmanager.MMModemPowerState
+	if powerState != "" {
+		var err error
+		if want, err = parsePowerState(powerState); err != nil {
+			return err
+		}
+	}
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	current, err := modem.GetPowerState()
+	if err != nil {
+		return fmt.Errorf("failed to get power state: %w", err)
+	}
+
+	if powerState == "" || current == want {
+		if jsonOutput {
+			return encodePowerState(modem.GetObjectPath(), current, false)
+		}
+		if powerState != "" {
+			fmt.Printf("Modem is already in power state %s\n", powerStateName(current))
+		} else {
+			fmt.Printf("Power state: %s\n", powerStateName(current))
+		}
+		return nil
+	}
+
+	if want == modemmanager.MmModemPowerStateOff {
+		if err := confirmModification("some modems cannot be powered on again without a hardware power toggle", powerConfirm); err != nil {
+			return err
+		}
+	}
+	state, _ := modem.GetState()
+	if want != modemmanager.MmModemPowerStateOn && state >= modemmanager.MmModemStateConnected {
+		fmt.Fprintf(os.Stderr, "Warning: the modem is connected, power state %s drops the connection.\n", powerStateName(want))
+	}
+
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+	progress.Stage("setting", fmt.Sprintf("power state %s", powerStateName(want)))
+	signals := modem.SubscribePropertiesChanged()
+	defer modem.Unsubscribe()
+	if err := modem.SetPowerState(want); err != nil {
+		if state > modemmanager.MmModemStateDisabled {
+			return fmt.Errorf("failed to set power state: %w (the modem is %s, disable it first with 'mmctl modem disable')", err, strings.ToLower(state.String()))
+		}
+		return fmt.Errorf("failed to set power state: %w", err)
+	}
+
+	if want != modemmanager.MmModemPowerStateOff {
+		progress.Stage("waiting", fmt.Sprintf("for power state %s", powerStateName(want)))
+		if err := waitForPowerState(modem.GetPowerState, signals, want, powerTimeout, powerPollInterval); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		return encodePowerState(modem.GetObjectPath(), want, true)
+	}
+	fmt.Printf("✓ Power state set to %s\n", powerStateName(want))
+	return nil
+}
+
+func encodePowerState(path dbus.ObjectPath, state modemmanager.MMModemPowerState, changed bool) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"modem":       path,
+		"power_state": powerStateName(state),
+		"changed":     changed,
+	})
+}
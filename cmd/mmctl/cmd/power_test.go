@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+func TestParsePowerState(t *testing.T) {
+	for name, want := range map[string]modemmanager.MMModemPowerState{"low": modemmanager.MmModemPowerStateLow, "On": modemmanager.MmModemPowerStateOn, " off": modemmanager.MmModemPowerStateOff} {
+		if got, err := parsePowerState(name); err != nil || got != want {
+			t.Errorf("parsePowerState(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := parsePowerState("unknown"); err == nil {
+		t.Error("parsePowerState(unknown) succeeded")
+	}
+}
+
+func TestWaitForPowerState(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+	states := make(chan modemmanager.MMModemPowerState, 1)
+	reads := make(chan struct{}, 10)
+	last := modemmanager.MmModemPowerStateOn
+	read := func() (modemmanager.MMModemPowerState, error) {
+		defer func() { reads <- struct{}{} }()
+		select {
+		case last = <-states:
+		default:
+		}
+		if last == modemmanager.MmModemPowerStateUnknown {
+			return last, errors.New("switching")
+		}
+		return last, nil
+	}
+
+	signals := make(chan *dbus.Signal)
+	done := make(chan error, 1)
+	go func() {
+		done <- waitForPowerState(read, signals, modemmanager.MmModemPowerStateLow, time.Minute, time.Second)
+	}()
+	<-reads
+	states <- modemmanager.MmModemPowerStateUnknown
+	fake.Advance(time.Second)
+	<-reads
+	states <- modemmanager.MmModemPowerStateLow
+	signals <- &dbus.Signal{}
+	<-reads
+	if err := <-done; err != nil {
+		t.Errorf("waitForPowerState = %v", err)
+	}
+
+	go func() {
+		done <- waitForPowerState(func() (modemmanager.MMModemPowerState, error) { return modemmanager.MmModemPowerStateOn, nil }, nil, modemmanager.MmModemPowerStateLow, 5*time.Second, time.Second)
+	}()
+	fake.BlockUntilTimers(2)
+	fake.Advance(5 * time.Second)
+	err := <-done
+	if ExitCode(err) != exitTimeout || err.Error() != "power state still on after 5s, wanted low" {
+		t.Errorf("timeout: error = %v", err)
+	}
+}
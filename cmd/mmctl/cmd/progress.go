@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// Progress output modes accepted by --progress
+const (
+	progressHuman = "human"
+	progressJSON  = "json"
+	progressNone  = "none"
+)
+
+// progressEvent is a single newline-delimited event emitted in JSON progress mode.
+type progressEvent struct {
+	Stage   string  `json:"stage"`
+	Elapsed float64 `json:"elapsed"`
+	Detail  string  `json:"detail,omitempty"`
+}
+
+// progressReporter reports the stages of long-running commands. In human
+// mode stages are printed as status lines on stdout; in JSON mode they are
+// written as events to stderr so stdout only carries the final result.
+type progressReporter struct {
+	mode     string
+	stdout   io.Writer
+	stderr   io.Writer
+	terminal bool // stdout is a terminal, spinners are drawn
+	start    time.Time
+	now      func() time.Time
+}
+
+// Spinner frames and how often they are redrawn
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+const spinnerInterval = 250 * time.Millisecond
+
+// newProgressReporter returns a reporter for the given mode.
+func newProgressReporter(mode string, stdout, stderr io.Writer, now func() time.Time) (*progressReporter, error) {
+	switch mode {
+	case progressHuman, progressJSON, progressNone:
+	default:
+		return nil, fmt.Errorf("invalid progress mode: %s (must be human, json, or none)", mode)
+	}
+	return &progressReporter{
+		mode:     mode,
+		stdout:   stdout,
+		stderr:   stderr,
+		terminal: isTerminal(stdout),
+		start:    now(),
+		now:      now,
+	}, nil
+}
+
+// isTerminal reports whether w is a character device such as a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// newProgress returns a reporter for the --progress flag writing to the
+// process stdout and stderr.
+func newProgress() (*progressReporter, error) {
+	return newProgressReporter(progressMode, os.Stdout, os.Stderr, time.Now)
+}
+
+// Stage reports that the command entered the given stage. detail is optional.
+func (p *progressReporter) Stage(stage, detail string) {
+	switch p.mode {
+	case progressJSON:
+		elapsed := p.now().Sub(p.start).Seconds()
+		event := progressEvent{
+			Stage:   stage,
+			Elapsed: math.Round(elapsed*10) / 10,
+			Detail:  detail,
+		}
+		// Encoder appends the newline
+		_ = json.NewEncoder(p.stderr).Encode(event)
+	case progressHuman:
+		line := strings.ToUpper(stage[:1]) + strings.ReplaceAll(stage[1:], "-", " ")
+		if detail != "" {
+			line += " (" + detail + ")"
+		}
+		fmt.Fprintf(p.stdout, "%s...\n", line)
+	}
+}
+
+// Spinner draws an animated line with label and the time spent so far until
+// the returned function is called, for stages that take long without
+// reporting progress. It only draws in human mode on a terminal and clears
+// the line again when stopped.
+func (p *progressReporter) Spinner(label string) (stop func()) {
+	if p.mode != progressHuman || !p.terminal {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	start := p.now()
+	ticker := clk.NewTicker(spinnerInterval)
+	go func() {
+		defer close(finished)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			fmt.Fprintf(p.stdout, "\r%s %s %s", spinnerFrames[frame%len(spinnerFrames)], label, humanDuration(p.now().Sub(start)))
+			select {
+			case <-done:
+				fmt.Fprint(p.stdout, "\r\033[K")
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+)
+
+// fakeNow returns a clock that advances by step on every call after the first.
+func fakeNow(step time.Duration) func() time.Time {
+	t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := true
+	return func() time.Time {
+		if !first {
+			t = t.Add(step)
+		}
+		first = false
+		return t
+	}
+}
+
+func TestProgressReporterJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	p, err := newProgressReporter(progressJSON, &stdout, &stderr, fakeNow(1250*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Stage("registering", "searching")
+	p.Stage("connected", "")
+
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want empty in JSON progress mode", stdout.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d events, want 2: %q", len(lines), stderr.String())
+	}
+
+	var ev progressEvent
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Stage != "registering" || ev.Detail != "searching" || ev.Elapsed != 1.3 {
+		t.Errorf("first event = %+v", ev)
+	}
+	if lines[1] != `{"stage":"connected","elapsed":2.5}` {
+		t.Errorf("second event = %s", lines[1])
+	}
+}
+
+func TestProgressReporterHuman(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	p, err := newProgressReporter(progressHuman, &stdout, &stderr, fakeNow(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Stage("connecting", "")
+	p.Stage("waiting-for-bearer", "2s")
+
+	want := "Connecting...\nWaiting for bearer (2s)...\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("stderr = %q, want empty", stderr.String())
+	}
+}
+
+func TestProgressReporterNoneAndInvalid(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	p, err := newProgressReporter(progressNone, &stdout, &stderr, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Stage("connecting", "")
+	if stdout.Len() != 0 || stderr.Len() != 0 {
+		t.Errorf("none mode wrote output: %q %q", stdout.String(), stderr.String())
+	}
+
+	if _, err := newProgressReporter("xml", &stdout, &stderr, time.Now); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+}
+
+func TestProgressReporterSpinner(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	var stdout, stderr bytes.Buffer
+	p, err := newProgressReporter(progressHuman, &stdout, &stderr, fake.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Spinner("Scanning")()
+	if stdout.Len() != 0 {
+		t.Errorf("spinner drew %q without a terminal", stdout.String())
+	}
+
+	p.terminal = true
+	stop := p.Spinner("Scanning")
+	fake.BlockUntilTimers(1)
+	fake.Advance(time.Second)
+	stop()
+
+	got := stdout.String()
+	if !strings.HasPrefix(got, "\r| Scanning ") || !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("spinner output = %q", got)
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	networkRegisterCmd = &cobra.Command{
+		Use:   "register",
+		Short: "Register with a network",
+		Long: `Register with the operator given by --operator, as listed by 'mmctl network
+scan', or let the modem select the network automatically without it.
+
+It waits up to --timeout until the modem is registered at home or roaming and
+fails right away if the network denies the registration.`,
+		Example: `  # Register with operator 26201 manually
+  mmctl network register -m 0 --operator 26201
+
+  # Return to automatic network selection
+  mmctl network register -m 0`,
+		RunE: runNetworkRegister,
+	}
+
+	// Flags
+	registerOperator string
+	registerTimeout  time.Duration
+)
+
+func init() {
+	networkCmd.AddCommand(networkRegisterCmd)
+
+	networkRegisterCmd.Flags().StringVar(&registerOperator, "operator", "", "Operator code (MCCMNC) to register with, automatic selection if empty")
+	networkRegisterCmd.Flags().DurationVar(&registerTimeout, "timeout", 120*time.Second, "Maximum time to wait for the registration")
+}
+
+func runNetworkRegister(cmd *cobra.Command, args []string) error {
+	if err := validateOperatorCode(registerOperator); err != nil {
+		return err
+	}
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	if state, err := modem.GetState(); err == nil && state == modemmanager.MmModemStateLocked {
+		return fmt.Errorf("modem is SIM-locked, unlock the SIM before registering")
+	}
+	modem3gpp, err := modem.Get3gpp()
+	if err != nil {
+		return fmt.Errorf("modem does not support 3GPP registration: %w", err)
+	}
+
+	target := "automatic"
+	if registerOperator != "" {
+		target = registerOperator
+	}
+	progress.Stage("registering", target)
+
+	signals := modem.SubscribePropertiesChanged()
+	defer modem.Unsubscribe()
+	result := make(chan error, 1)
+	go func() {
+		result <- modem3gpp.Register(registerOperator)
+	}()
+	state, err := waitForNetworkRegistration(modem3gpp, signals, result, registerTimeout, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	progress.Stage("registered", registrationClass(state))
+
+	code, _ := modem3gpp.GetOperatorCode()
+	name, _ := modem3gpp.GetOperatorName()
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"registration_state": state.String(),
+			"operator_code":      code,
+			"operator_name":      name,
+		})
+	}
+
+	operator := code
+	if name != "" {
+		operator = fmt.Sprintf("%s (%s)", name, code)
+	}
+	fmt.Printf("✓ Registered with %s, %s\n", operator, strings.ToLower(state.String()))
+	return nil
+}
+
+// validateOperatorCode accepts an empty code or an MCCMNC of 5 or 6 digits
+func validateOperatorCode(code string) error {
+	if code == "" {
+		return nil
+	}
+	valid := len(code) == 5 || len(code) == 6
+	for _, c := range code {
+		valid = valid && c >= '0' && c <= '9'
+	}
+	if !valid {
+		return fmt.Errorf("invalid operator code %q (must be the 5 or 6 digit MCCMNC)", code)
+	}
+	return nil
+}
+
+// waitForNetworkRegistration waits until the modem is registered at home or
+// roaming, re-reading the registration state on every signal and interval
+// tick. It fails once the state is denied, when the Register call sent on
+// result failed, or after timeout.
+func waitForNetworkRegistration(modem3gpp modemmanager.Modem3gpp, signals <-chan *dbus.Signal, result <-chan error, timeout, interval time.Duration) (modemmanager.MMModem3gppRegistrationState, error) {
+	deadline := clk.After(timeout)
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	state := modemmanager.MmModem3gppRegistrationStateUnknown
+	var registerErr error
+	for {
+		if s, err := modem3gpp.GetRegistrationState(); err == nil {
+			state = s
+		}
+		switch {
+		case registrationClass(state) != "":
+			return state, nil
+		case state == modemmanager.MmModem3gppRegistrationStateDenied:
+			err := errors.New("registration denied by the network")
+			if registerErr != nil {
+				err = fmt.Errorf("%w: %v", err, registerErr)
+			}
+			return state, err
+		case registerErr != nil:
+			return state, fmt.Errorf("registration failed (%s): %w", strings.ToLower(state.String()), registerErr)
+		}
+
+		select {
+		case <-deadline:
+			return state, &exitError{code: exitTimeout, err: fmt.Errorf("not registered after %s (registration %s)", timeout, strings.ToLower(state.String()))}
+		case err := <-result:
+			// Read the state once more, it tells whether the network denied
+			registerErr = err
+			result = nil
+		case _, ok := <-signals:
+			if !ok {
+				signals = nil
+			}
+		case <-ticker.C():
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestValidateOperatorCode(t *testing.T) {
+	for _, code := range []string{"", "26201", "310260"} {
+		if err := validateOperatorCode(code); err != nil {
+			t.Errorf("validateOperatorCode(%q) = %v", code, err)
+		}
+	}
+	for _, code := range []string{"2620", "3102601", "2620a", "262 01"} {
+		if err := validateOperatorCode(code); err == nil {
+			t.Errorf("validateOperatorCode(%q) accepted an invalid code", code)
+		}
+	}
+}
+
+// registeringModem is a 3GPP interface whose registration state can change
+// while waitForNetworkRegistration reads it
+type registeringModem struct {
+	*mocks.MockModem3gpp
+	state atomic.Int32
+	reads chan struct{}
+}
+
+func newRegisteringModem(state modemmanager.MMModem3gppRegistrationState) *registeringModem {
+	m := &registeringModem{MockModem3gpp: mocks.NewMockModem3gpp(), reads: make(chan struct{}, 100)}
+	m.set(state)
+	return m
+}
+
+func (m *registeringModem) set(state modemmanager.MMModem3gppRegistrationState) {
+	m.state.Store(int32(state))
+}
+
+func (m *registeringModem) GetRegistrationState() (modemmanager.MMModem3gppRegistrationState, error) {
+	m.reads <- struct{}{}
+	return modemmanager.MMModem3gppRegistrationState(m.state.Load()), nil
+}
+
+type registrationResult struct {
+	state modemmanager.MMModem3gppRegistrationState
+	err   error
+}
+
+// startRegistrationWait runs waitForNetworkRegistration with a fake clock
+func startRegistrationWait(t *testing.T, modem *registeringModem, signals <-chan *dbus.Signal, result <-chan error) (*clock.Fake, <-chan registrationResult) {
+	t.Helper()
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	done := make(chan registrationResult, 1)
+	go func() {
+		state, err := waitForNetworkRegistration(modem, signals, result, time.Minute, 2*time.Second)
+		done <- registrationResult{state, err}
+	}()
+	return fake, done
+}
+
+func TestWaitForNetworkRegistration(t *testing.T) {
+	modem := newRegisteringModem(modemmanager.MmModem3gppRegistrationStateSearching)
+	signals := make(chan *dbus.Signal)
+	_, done := startRegistrationWait(t, modem, signals, make(chan error))
+
+	<-modem.reads
+	modem.set(modemmanager.MmModem3gppRegistrationStateRoaming)
+	signals <- &dbus.Signal{Name: "org.freedesktop.DBus.Properties.PropertiesChanged"}
+
+	if res := <-done; res.err != nil || res.state != modemmanager.MmModem3gppRegistrationStateRoaming {
+		t.Errorf("waitForNetworkRegistration = %v, %v, want roaming", res.state, res.err)
+	}
+}
+
+func TestWaitForNetworkRegistrationDenied(t *testing.T) {
+	modem := newRegisteringModem(modemmanager.MmModem3gppRegistrationStateSearching)
+	result := make(chan error, 1)
+	_, done := startRegistrationWait(t, modem, nil, result)
+
+	<-modem.reads
+	modem.set(modemmanager.MmModem3gppRegistrationStateDenied)
+	result <- errors.New("network not allowed")
+
+	res := <-done
+	if res.err == nil || res.err.Error() != "registration denied by the network: network not allowed" {
+		t.Errorf("waitForNetworkRegistration error = %v, want denied", res.err)
+	}
+}
+
+func TestWaitForNetworkRegistrationFailed(t *testing.T) {
+	modem := newRegisteringModem(modemmanager.MmModem3gppRegistrationStateIdle)
+	result := make(chan error, 1)
+	result <- errors.New("no network")
+	_, done := startRegistrationWait(t, modem, nil, result)
+
+	res := <-done
+	if res.err == nil || res.err.Error() != "registration failed (idle): no network" {
+		t.Errorf("waitForNetworkRegistration error = %v", res.err)
+	}
+}
+
+func TestWaitForNetworkRegistrationTimeout(t *testing.T) {
+	modem := newRegisteringModem(modemmanager.MmModem3gppRegistrationStateSearching)
+	fake, done := startRegistrationWait(t, modem, nil, make(chan error))
+
+	fake.BlockUntilTimers(2)
+	fake.Advance(time.Minute)
+
+	res := <-done
+	var exit *exitError
+	if !errors.As(res.err, &exit) || exit.code != exitTimeout || !strings.HasSuffix(res.err.Error(), "(registration searching)") {
+		t.Errorf("waitForNetworkRegistration error = %v, want a timeout while searching", res.err)
+	}
+}
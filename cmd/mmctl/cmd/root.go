@@ -1,18 +1,26 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/maltegrosse/go-modemmanager/internal/buildinfo"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Global flags
-	jsonOutput bool
-	verbose    bool
-	modemIndex int
-	modemPath  string
-	version    = "0.1.0"
+	jsonOutput    bool
+	verbose       bool
+	modemIndex    int
+	modemPath     string
+	modemIMEI     string
+	modemDeviceID string
+	progressMode  string
+
+	// Time source for waits, replaced in tests
+	clk clock.Clock = clock.Real
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -32,7 +40,7 @@ It provides a user-friendly way to manage cellular modems, including:
 
 This tool uses the go-modemmanager library to communicate with ModemManager
 via D-Bus.`,
-	Version: version,
+	Version: buildinfo.Get().String(),
 	Example: `  # List all modems
   mmctl list
 
@@ -52,6 +60,32 @@ via D-Bus.`,
   mmctl modem signal -i 0`,
 }
 
+// Exit codes for failures scripts may want to tell apart. All other errors
+// exit with 1.
+const (
+	exitTimeout   = 2 // a wait did not complete in time
+	exitModemGone = 3 // the modem disappeared while waiting
+	exitFailed    = 4 // the modem entered the failed state while waiting
+)
+
+// exitError carries a specific exit code for an error
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the process exit code for an error returned by Execute
+func ExitCode(err error) int {
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	return 1
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
@@ -63,14 +97,17 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "j", false, "Output in JSON format")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 	rootCmd.PersistentFlags().IntVarP(&modemIndex, "modem", "m", -1, "Modem index (alternative to --path)")
-	rootCmd.PersistentFlags().StringVarP(&modemPath, "path", "p", "", "Modem D-Bus path")
+	rootCmd.PersistentFlags().StringVarP(&modemPath, "path", "p", "", "Modem D-Bus path (alternative to --modem)")
+	rootCmd.PersistentFlags().StringVar(&modemIMEI, "imei", "", "Modem IMEI or equipment identifier, or a unique prefix of it (alternative to --modem)")
+	rootCmd.PersistentFlags().StringVar(&modemDeviceID, "device-id", "", "ModemManager device identifier, or a unique prefix of it (alternative to --modem)")
+	rootCmd.PersistentFlags().StringVar(&progressMode, "progress", progressHuman, "Progress output for long operations (human, json, none)")
 
-	// Disable completion command
+	// Replaced by completionCmd, which can also install the script
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 }
 
 // Helper function to print version info
 func printVersion() {
-	fmt.Printf("mmctl version %s\n", version)
+	fmt.Printf("mmctl version %s\n", buildinfo.Get())
 	fmt.Println("Built with go-modemmanager library")
 }
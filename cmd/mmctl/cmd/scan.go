@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	networkScanCmd = &cobra.Command{
+		Use:   "scan",
+		Short: "Scan for available 3GPP networks",
+		Long: `Scan for the mobile networks in range and list them with their availability
+and access technology.
+
+A scan takes one to three minutes, during which the modem may drop its current
+registration. The modem must be enabled but does not need to be registered.
+Networks are listed current first, then available, unknown and forbidden ones.`,
+		Example: `  # Scan with modem 0
+  mmctl network scan -m 0
+
+  # Allow a slow scan and print JSON
+  mmctl network scan -m 0 --timeout 5m --json`,
+		RunE: runNetworkScan,
+	}
+
+	// Flags
+	scanTimeout time.Duration
+)
+
+func init() {
+	networkCmd.AddCommand(networkScanCmd)
+
+	networkScanCmd.Flags().DurationVar(&scanTimeout, "timeout", 3*time.Minute, "Maximum time to wait for the scan")
+}
+
+// scannedNetwork is a scan result as printed by network scan
+type scannedNetwork struct {
+	OperatorLong     string   `json:"operator_long"`
+	OperatorShort    string   `json:"operator_short"`
+	OperatorCode     string   `json:"operator_code"`
+	Status           string   `json:"status"`
+	AccessTechnology []string `json:"access_technology"`
+}
+
+func runNetworkScan(cmd *cobra.Command, args []string) error {
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	if err := checkScanState(modem); err != nil {
+		return err
+	}
+	modem3gpp, err := modem.Get3gpp()
+	if err != nil {
+		return fmt.Errorf("modem does not support 3GPP network scans: %w", err)
+	}
+
+	progress.Stage("scanning", "up to "+humanDuration(scanTimeout))
+	stop := progress.Spinner("Scanning")
+	networks, err := scanNetworks(modem3gpp, scanTimeout)
+	stop()
+	if err != nil {
+		return err
+	}
+	sortScanResults(networks)
+
+	results := make([]scannedNetwork, len(networks))
+	for i, n := range networks {
+		techs := n.AccessTechnology.BitmaskToSlice(uint32(n.AccessTechnology))
+		names := make([]string, len(techs))
+		for j, tech := range techs {
+			names[j] = tech.String()
+		}
+		results[i] = scannedNetwork{
+			OperatorLong:     n.OperatorLong,
+			OperatorShort:    n.OperatorShort,
+			OperatorCode:     n.OperatorCode,
+			Status:           n.Status.String(),
+			AccessTechnology: names,
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No networks found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "Operator\tShort\tMCCMNC\tStatus\tTechnology\n")
+	fmt.Fprintf(w, "--------\t-----\t------\t------\t----------\n")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", orDash(r.OperatorLong), orDash(r.OperatorShort), orDash(r.OperatorCode), r.Status, orDash(strings.Join(r.AccessTechnology, ", ")))
+	}
+	return nil
+}
+
+// orDash returns s, or "-" for an empty table cell
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// checkScanState fails with a hint if the modem state does not allow a scan.
+// Unreadable states are left to the scan to report.
+func checkScanState(modem modemmanager.Modem) error {
+	state, err := modem.GetState()
+	if err != nil {
+		return nil
+	}
+	switch {
+	case state == modemmanager.MmModemStateFailed:
+		if reason, err := modem.GetStateFailedReason(); err == nil {
+			text, _ := reason.Describe()
+			return fmt.Errorf("modem failed, cannot scan: %s", text)
+		}
+		return fmt.Errorf("modem failed, cannot scan")
+	case state == modemmanager.MmModemStateLocked:
+		return fmt.Errorf("modem is locked, unlock the SIM before scanning")
+	case state < modemmanager.MmModemStateEnabled:
+		return fmt.Errorf("modem is %s, enable it with 'mmctl modem enable' before scanning", strings.ToLower(state.String()))
+	}
+	return nil
+}
+
+// scanNetworks runs a scan, giving up after timeout. The modem then keeps
+// scanning in the background, as the D-Bus call cannot be cancelled.
+func scanNetworks(modem3gpp modemmanager.Modem3gpp, timeout time.Duration) ([]modemmanager.Network3Gpp, error) {
+	networks, err := withTimeout(timeout, modem3gpp.Scan)
+	switch {
+	case errors.Is(err, errCallTimeout):
+		return nil, &exitError{code: exitTimeout, err: fmt.Errorf("scan did not finish within %s, the modem may still be scanning", timeout)}
+	case err != nil:
+		return nil, scanError(err)
+	}
+	return networks, nil
+}
+
+// scanError adds a hint on what to do to the common scan failures
+func scanError(err error) error {
+	switch dbusErrorName(err) {
+	case modemmanager.ErrorCorePrefix + "WrongState":
+		return fmt.Errorf("modem cannot scan in its current state, make sure it is enabled: %w", err)
+	case modemmanager.ErrorCorePrefix + "InProgress",
+		modemmanager.ErrorCorePrefix + "Retry":
+		return fmt.Errorf("modem is busy, possibly with another scan, try again in a minute: %w", err)
+	case modemmanager.ErrorCorePrefix + "Unsupported",
+		"org.freedesktop.DBus.Error.UnknownMethod":
+		return fmt.Errorf("modem does not support network scans: %w", err)
+	case modemmanager.ErrorCorePrefix + "Unauthorized",
+		"org.freedesktop.DBus.Error.AccessDenied":
+		return fmt.Errorf("not authorized to scan, run mmctl as root or allow it in the polkit policy: %w", err)
+	}
+	return fmt.Errorf("scan failed: %w", err)
+}
+
+// dbusErrorName returns the D-Bus error name of err, or "" if it is not a D-Bus error
+func dbusErrorName(err error) string {
+	var dbusErr dbus.Error
+	var dbusErrPtr *dbus.Error
+	switch {
+	case errors.As(err, &dbusErr):
+		return dbusErr.Name
+	case errors.As(err, &dbusErrPtr):
+		return dbusErrPtr.Name
+	}
+	return ""
+}
+
+// scanStatusOrder sorts current networks first and forbidden ones last
+var scanStatusOrder = map[modemmanager.MMModem3gppNetworkAvailability]int{
+	modemmanager.MmModem3gppNetworkAvailabilityCurrent:   0,
+	modemmanager.MmModem3gppNetworkAvailabilityAvailable: 1,
+	modemmanager.MmModem3gppNetworkAvailabilityUnknown:   2,
+	modemmanager.MmModem3gppNetworkAvailabilityForbidden: 3,
+}
+
+// sortScanResults sorts networks by availability, then by the newest access
+// technology, then by operator code. Scan results carry no signal levels.
+func sortScanResults(networks []modemmanager.Network3Gpp) {
+	sort.SliceStable(networks, func(i, j int) bool {
+		a, b := networks[i], networks[j]
+		if scanStatusOrder[a.Status] != scanStatusOrder[b.Status] {
+			return scanStatusOrder[a.Status] < scanStatusOrder[b.Status]
+		}
+		// Technology flags are assigned in order of introduction
+		if a.AccessTechnology != b.AccessTechnology {
+			return a.AccessTechnology > b.AccessTechnology
+		}
+		return a.OperatorCode < b.OperatorCode
+	})
+}
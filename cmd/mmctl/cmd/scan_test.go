@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestSortScanResults(t *testing.T) {
+	networks := []modemmanager.Network3Gpp{
+		{OperatorCode: "26203", Status: modemmanager.MmModem3gppNetworkAvailabilityForbidden, AccessTechnology: modemmanager.MmModemAccessTechnologyLte},
+		{OperatorCode: "26202", Status: modemmanager.MmModem3gppNetworkAvailabilityAvailable, AccessTechnology: modemmanager.MmModemAccessTechnologyUmts},
+		{OperatorCode: "26201", Status: modemmanager.MmModem3gppNetworkAvailabilityAvailable, AccessTechnology: modemmanager.MmModemAccessTechnologyLte},
+		{OperatorCode: "26207", Status: modemmanager.MmModem3gppNetworkAvailabilityUnknown, AccessTechnology: modemmanager.MmModemAccessTechnologyLte},
+		{OperatorCode: "26203", Status: modemmanager.MmModem3gppNetworkAvailabilityAvailable, AccessTechnology: modemmanager.MmModemAccessTechnologyLte},
+		{OperatorCode: "26202", Status: modemmanager.MmModem3gppNetworkAvailabilityCurrent, AccessTechnology: modemmanager.MmModemAccessTechnologyGsm},
+	}
+	sortScanResults(networks)
+
+	var got []string
+	for _, n := range networks {
+		got = append(got, n.OperatorCode+" "+n.Status.String()+" "+n.AccessTechnology.String())
+	}
+	want := []string{
+		"26202 Current Gsm",
+		"26201 Available Lte",
+		"26203 Available Lte",
+		"26202 Available Umts",
+		"26207 Unknown Lte",
+		"26203 Forbidden Lte",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("sorted:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestScanError(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{modemmanager.ErrorCorePrefix + "WrongState", "modem cannot scan in its current state, make sure it is enabled: "},
+		{modemmanager.ErrorCorePrefix + "InProgress", "modem is busy, possibly with another scan, try again in a minute: "},
+		{modemmanager.ErrorCorePrefix + "Unsupported", "modem does not support network scans: "},
+		{"org.freedesktop.DBus.Error.AccessDenied", "not authorized to scan"},
+		{modemmanager.ErrorMobileEquipmentPrefix + "NoNetwork", "scan failed: "},
+	}
+	for _, tt := range tests {
+		err := scanError(dbus.Error{Name: tt.name, Body: []interface{}{"details"}})
+		if !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("scanError(%s) = %q, want prefix %q", tt.name, err, tt.want)
+		}
+		if dbusErrorName(err) != tt.name {
+			t.Errorf("scanError(%s) does not wrap the D-Bus error", tt.name)
+		}
+	}
+	if got := scanError(errors.New("timeout")).Error(); got != "scan failed: timeout" {
+		t.Errorf("scanError(non D-Bus) = %q", got)
+	}
+}
+
+func TestCheckScanState(t *testing.T) {
+	tests := []struct {
+		state   modemmanager.MMModemState
+		wantErr string
+	}{
+		{state: modemmanager.MmModemStateEnabled},
+		{state: modemmanager.MmModemStateSearching},
+		{state: modemmanager.MmModemStateConnected},
+		{state: modemmanager.MmModemStateDisabled, wantErr: "modem is disabled, enable it with 'mmctl modem enable' before scanning"},
+		{state: modemmanager.MmModemStateLocked, wantErr: "modem is locked, unlock the SIM before scanning"},
+		{state: modemmanager.MmModemStateFailed, wantErr: "modem failed, cannot scan: "},
+	}
+	for _, tt := range tests {
+		modem := mocks.NewMockModem()
+		modem.StateValue = tt.state
+		err := checkScanState(modem)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)) {
+			t.Errorf("checkScanState(%v) = %v, want %q", tt.state, err, tt.wantErr)
+		}
+	}
+}
+
+// blockingScan is a 3GPP interface whose scan never finishes
+type blockingScan struct {
+	*mocks.MockModem3gpp
+}
+
+func (blockingScan) Scan() ([]modemmanager.Network3Gpp, error) {
+	select {}
+}
+
+func TestScanNetworks(t *testing.T) {
+	networks, err := scanNetworks(mocks.NewMockModem3gpp(), time.Minute)
+	if err != nil || len(networks) != 1 || networks[0].OperatorCode != "310260" {
+		t.Errorf("scanNetworks = %v, %v, want the mock network", networks, err)
+	}
+
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := scanNetworks(blockingScan{mocks.NewMockModem3gpp()}, time.Minute)
+		done <- err
+	}()
+	fake.BlockUntilTimers(1)
+	fake.Advance(time.Minute)
+
+	var exit *exitError
+	if err := <-done; !errors.As(err, &exit) || exit.code != exitTimeout {
+		t.Errorf("scanNetworks error = %v, want a timeout exit error", err)
+	}
+}
@@ -0,0 +1,458 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Environment variables read for codes not given as flags, which keeps them
+// out of the shell history
+const (
+	envSimPin    = "MMCTL_SIM_PIN"
+	envSimPuk    = "MMCTL_SIM_PUK"
+	envSimNewPin = "MMCTL_SIM_NEW_PIN"
+)
+
+var (
+	simCmd = &cobra.Command{
+		Use:   "sim",
+		Short: "Manage the SIM card",
+		Long: `Inspect the SIM card, unlock it and manage its PIN.
+
+Codes not given as flags are read from the environment variables
+MMCTL_SIM_PIN, MMCTL_SIM_PUK and MMCTL_SIM_NEW_PIN, or typed at a hidden
+prompt, so that they do not end up in the shell history.`,
+	}
+
+	simInfoCmd = &cobra.Command{
+		Use:   "info",
+		Short: "Show SIM card information",
+		Example: `  # Show the SIM of modem 0
+  mmctl sim info -m 0`,
+		RunE: runSimInfo,
+	}
+
+	simUnlockCmd = &cobra.Command{
+		Use:   "unlock",
+		Short: "Unlock the SIM with the PIN or PUK",
+		Long: `Unlock the SIM with its PIN, or with the PUK and a new PIN once the PIN is
+blocked, then wait until the modem leaves the locked state.
+
+The PUK is used if --puk is given or the SIM requires it. A wrong PUK on the
+last attempt blocks the SIM permanently, so mmctl refuses to send it with a
+single attempt left unless --force is given.`,
+		Example: `  # Unlock with the PIN typed at a prompt
+  mmctl sim unlock -m 0
+
+  # Unlock with the PIN from the environment
+  MMCTL_SIM_PIN=1234 mmctl sim unlock -m 0
+
+  # Unblock with the PUK and set a new PIN
+  mmctl sim unlock -m 0 --puk 12345678 --new-pin 4321`,
+		RunE: runSimUnlock,
+	}
+
+	simLockCmd = &cobra.Command{
+		Use:   "lock",
+		Short: "Enable or disable the PIN check",
+		Example: `  # Require the PIN after every power cycle
+  mmctl sim lock -m 0 --enable
+
+  # Stop asking for the PIN
+  mmctl sim lock -m 0 --disable`,
+		RunE: runSimLock,
+	}
+
+	simChangePinCmd = &cobra.Command{
+		Use:   "change-pin",
+		Short: "Change the SIM PIN",
+		Example: `  # Change the PIN, typing both at a prompt
+  mmctl sim change-pin -m 0`,
+		RunE: runSimChangePin,
+	}
+
+	// Flags
+	simPin           string
+	simPuk           string
+	simNewPin        string
+	simForce         bool
+	simUnlockTimeout time.Duration
+	simLockEnable    bool
+	simLockDisable   bool
+)
+
+func init() {
+	rootCmd.AddCommand(simCmd)
+	simCmd.AddCommand(simInfoCmd)
+	simCmd.AddCommand(simUnlockCmd)
+	simCmd.AddCommand(simLockCmd)
+	simCmd.AddCommand(simChangePinCmd)
+
+	simUnlockCmd.Flags().StringVar(&simPin, "pin", "", "SIM PIN (or "+envSimPin+")")
+	simUnlockCmd.Flags().StringVar(&simPuk, "puk", "", "SIM PUK (or "+envSimPuk+")")
+	simUnlockCmd.Flags().StringVar(&simNewPin, "new-pin", "", "New PIN to set with the PUK (or "+envSimNewPin+")")
+	simUnlockCmd.Flags().BoolVar(&simForce, "force", false, "Send the PUK even on the last attempt")
+	simUnlockCmd.Flags().DurationVar(&simUnlockTimeout, "timeout", 30*time.Second, "Maximum time to wait for the modem to leave the locked state")
+	simUnlockCmd.MarkFlagsMutuallyExclusive("pin", "puk")
+
+	simLockCmd.Flags().BoolVar(&simLockEnable, "enable", false, "Enable the PIN check")
+	simLockCmd.Flags().BoolVar(&simLockDisable, "disable", false, "Disable the PIN check")
+	simLockCmd.Flags().StringVar(&simPin, "pin", "", "SIM PIN (or "+envSimPin+")")
+	simLockCmd.MarkFlagsMutuallyExclusive("enable", "disable")
+	simLockCmd.MarkFlagsOneRequired("enable", "disable")
+
+	simChangePinCmd.Flags().StringVar(&simPin, "old", "", "Current PIN (or "+envSimPin+")")
+	simChangePinCmd.Flags().StringVar(&simNewPin, "new", "", "New PIN (or "+envSimNewPin+")")
+}
+
+// getSim returns the selected modem and its SIM
+func getSim() (modemmanager.Modem, modemmanager.Sim, error) {
+	modem, err := getModem()
+	if err != nil {
+		return nil, nil, err
+	}
+	sim, err := modem.GetSim()
+	if err != nil {
+		return nil, nil, fmt.Errorf("no SIM available: %w", err)
+	}
+	return modem, sim, nil
+}
+
+func runSimInfo(cmd *cobra.Command, args []string) error {
+	modem, sim, err := getSim()
+	if err != nil {
+		return err
+	}
+
+	info := make(map[string]interface{})
+	if iccid, err := sim.GetSimIdentifier(); err == nil {
+		info["iccid"] = iccid
+	}
+	if imsi, err := sim.GetImsi(); err == nil {
+		info["imsi"] = imsi
+	}
+	if opID, err := sim.GetOperatorIdentifier(); err == nil {
+		info["operator_id"] = opID
+	}
+	if opName, err := sim.GetOperatorName(); err == nil {
+		info["operator_name"] = opName
+	}
+	if lock, err := modem.GetUnlockRequired(); err == nil {
+		info["unlock_required"] = lock.String()
+	}
+	retries := make(map[string]uint32)
+	for lock, left := range unlockRetries(modem) {
+		retries[lock.String()] = left
+	}
+	info["unlock_retries"] = retries
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "SIM Information\n")
+	fmt.Fprintf(w, "===============\n\n")
+	for _, field := range []struct{ label, key string }{
+		{"ICCID", "iccid"},
+		{"IMSI", "imsi"},
+		{"Operator ID", "operator_id"},
+		{"Operator", "operator_name"},
+		{"Unlock required", "unlock_required"},
+	} {
+		if value, ok := info[field.key].(string); ok && value != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", field.label, value)
+		}
+	}
+	for _, lock := range []modemmanager.MMModemLock{modemmanager.MmModemLockSimPin, modemmanager.MmModemLockSimPuk, modemmanager.MmModemLockSimPin2, modemmanager.MmModemLockSimPuk2} {
+		if left, ok := retries[lock.String()]; ok {
+			fmt.Fprintf(w, "%s attempts left:\t%d\n", lockCodeName(lock), left)
+		}
+	}
+	return nil
+}
+
+func runSimUnlock(cmd *cobra.Command, args []string) error {
+	progress, err := newProgress()
+	if err != nil {
+		return err
+	}
+	modem, sim, err := getSim()
+	if err != nil {
+		return err
+	}
+
+	lock, lockErr := modem.GetUnlockRequired()
+	usePuk := simPuk != "" || lock == modemmanager.MmModemLockSimPuk
+	if !usePuk && lockErr == nil && lock == modemmanager.MmModemLockNone {
+		fmt.Println("SIM is not locked")
+		return nil
+	}
+
+	if usePuk {
+		if err := checkPukAttempts(unlockRetries(modem), simForce); err != nil {
+			return err
+		}
+		puk, err := secretCode(simPuk, "--puk", envSimPuk, "PUK")
+		if err != nil {
+			return err
+		}
+		if err := validateCode("PUK", puk, 8, 8); err != nil {
+			return err
+		}
+		newPin, err := newSecretCode(simNewPin, "--new-pin", envSimNewPin, "New PIN")
+		if err != nil {
+			return err
+		}
+		if err := validateCode("PIN", newPin, 4, 8); err != nil {
+			return err
+		}
+		progress.Stage("sending-puk", "")
+		if err := sim.SendPuk(puk, newPin); err != nil {
+			return attemptsError(modem, modemmanager.MmModemLockSimPuk, "failed to send PUK", err)
+		}
+	} else {
+		pin, err := secretCode(simPin, "--pin", envSimPin, "PIN")
+		if err != nil {
+			return err
+		}
+		if err := validateCode("PIN", pin, 4, 8); err != nil {
+			return err
+		}
+		progress.Stage("sending-pin", "")
+		if err := sim.SendPin(pin); err != nil {
+			return attemptsError(modem, modemmanager.MmModemLockSimPin, "failed to send PIN", err)
+		}
+	}
+
+	progress.Stage("waiting-for-unlock", "")
+	signals := modem.SubscribeStateChanged()
+	defer modem.Unsubscribe()
+	if err := waitForUnlock(modem, signals, simUnlockTimeout, time.Second); err != nil {
+		return err
+	}
+	fmt.Println("✓ SIM unlocked")
+	return nil
+}
+
+func runSimLock(cmd *cobra.Command, args []string) error {
+	modem, sim, err := getSim()
+	if err != nil {
+		return err
+	}
+	pin, err := secretCode(simPin, "--pin", envSimPin, "PIN")
+	if err != nil {
+		return err
+	}
+	if err := validateCode("PIN", pin, 4, 8); err != nil {
+		return err
+	}
+
+	if err := sim.EnablePin(pin, simLockEnable); err != nil {
+		return attemptsError(modem, modemmanager.MmModemLockSimPin, "failed to change the PIN check", err)
+	}
+	if simLockEnable {
+		fmt.Println("✓ PIN check enabled")
+	} else {
+		fmt.Println("✓ PIN check disabled")
+	}
+	return nil
+}
+
+func runSimChangePin(cmd *cobra.Command, args []string) error {
+	modem, sim, err := getSim()
+	if err != nil {
+		return err
+	}
+	oldPin, err := secretCode(simPin, "--old", envSimPin, "Current PIN")
+	if err != nil {
+		return err
+	}
+	if err := validateCode("PIN", oldPin, 4, 8); err != nil {
+		return err
+	}
+	newPin, err := newSecretCode(simNewPin, "--new", envSimNewPin, "New PIN")
+	if err != nil {
+		return err
+	}
+	if err := validateCode("new PIN", newPin, 4, 8); err != nil {
+		return err
+	}
+
+	if err := sim.ChangePin(oldPin, newPin); err != nil {
+		return attemptsError(modem, modemmanager.MmModemLockSimPin, "failed to change PIN", err)
+	}
+	fmt.Println("✓ PIN changed")
+	return nil
+}
+
+// errNoTerminal is returned by promptSecret when stdin is not a terminal
+var errNoTerminal = errors.New("stdin is not a terminal")
+
+// promptSecret asks for a code on stderr and reads it from the terminal
+// without echo. Tests replace it.
+var promptSecret = func(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errNoTerminal
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", name)
+	code, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(code), err
+}
+
+// secretCode returns value if the flag was given, else the environment
+// variable env, else the code typed at a prompt
+func secretCode(value, flag, env, name string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if value := os.Getenv(env); value != "" {
+		return value, nil
+	}
+	code, err := promptSecret(name)
+	if errors.Is(err, errNoTerminal) {
+		return "", fmt.Errorf("no %s given, pass %s, set %s or run in a terminal to type it", strings.ToLower(name), flag, env)
+	}
+	return code, err
+}
+
+// newSecretCode is secretCode for a code being set, which is asked for twice
+// at the prompt to rule out typos
+func newSecretCode(value, flag, env, name string) (string, error) {
+	if value != "" || os.Getenv(env) != "" {
+		return secretCode(value, flag, env, name)
+	}
+	code, err := secretCode(value, flag, env, name)
+	if err != nil {
+		return "", err
+	}
+	repeated, err := promptSecret("Repeat " + strings.ToLower(name))
+	if err != nil {
+		return "", err
+	}
+	if repeated != code {
+		return "", fmt.Errorf("the %ss do not match", strings.ToLower(name))
+	}
+	return code, nil
+}
+
+// validateCode checks that code has between min and max digits
+func validateCode(name, code string, min, max int) error {
+	valid := len(code) >= min && len(code) <= max
+	for _, c := range code {
+		valid = valid && c >= '0' && c <= '9'
+	}
+	switch {
+	case valid:
+		return nil
+	case min == max:
+		return fmt.Errorf("invalid %s (must be %d digits)", name, min)
+	default:
+		return fmt.Errorf("invalid %s (must be %d to %d digits)", name, min, max)
+	}
+}
+
+// unlockRetries returns the attempts left per lock, empty if the modem does
+// not report them
+func unlockRetries(modem modemmanager.Modem) map[modemmanager.MMModemLock]uint32 {
+	retries := make(map[modemmanager.MMModemLock]uint32)
+	pairs, err := modem.GetUnlockRetries()
+	if err != nil {
+		return retries
+	}
+	for _, pair := range pairs {
+		lock, okLock := pair.GetLeft().(modemmanager.MMModemLock)
+		left, okLeft := pair.GetRight().(uint32)
+		if okLock && okLeft {
+			retries[lock] = left
+		}
+	}
+	return retries
+}
+
+// checkPukAttempts refuses to send a PUK on the last attempt unless forced,
+// as a wrong one blocks the SIM for good
+func checkPukAttempts(retries map[modemmanager.MMModemLock]uint32, force bool) error {
+	left, ok := retries[modemmanager.MmModemLockSimPuk]
+	switch {
+	case !ok || left > 1:
+		return nil
+	case left == 0:
+		return fmt.Errorf("no PUK attempts left, the SIM is blocked permanently")
+	case !force:
+		return fmt.Errorf("only 1 PUK attempt left and a wrong PUK blocks the SIM permanently, check the PUK and repeat with --force")
+	}
+	return nil
+}
+
+// lockCodeName returns the name of the code that removes lock
+func lockCodeName(lock modemmanager.MMModemLock) string {
+	switch lock {
+	case modemmanager.MmModemLockSimPin:
+		return "PIN"
+	case modemmanager.MmModemLockSimPin2:
+		return "PIN2"
+	case modemmanager.MmModemLockSimPuk:
+		return "PUK"
+	case modemmanager.MmModemLockSimPuk2:
+		return "PUK2"
+	}
+	return lock.String()
+}
+
+// attemptsError adds the attempts left for lock to a failed PIN or PUK
+// operation, if the modem reports them
+func attemptsError(modem modemmanager.Modem, lock modemmanager.MMModemLock, action string, err error) error {
+	left, ok := unlockRetries(modem)[lock]
+	switch {
+	case !ok:
+		return fmt.Errorf("%s: %w", action, err)
+	case left == 0 && lock == modemmanager.MmModemLockSimPin:
+		return fmt.Errorf("%s: %w (no PIN attempts left, unlock the SIM with the PUK)", action, err)
+	case left == 0 && lock == modemmanager.MmModemLockSimPuk:
+		return fmt.Errorf("%s: %w (no PUK attempts left, the SIM is blocked permanently)", action, err)
+	case left == 1:
+		return fmt.Errorf("%s: %w (1 %s attempt left)", action, err, lockCodeName(lock))
+	}
+	return fmt.Errorf("%s: %w (%d %s attempts left)", action, err, left, lockCodeName(lock))
+}
+
+// waitForUnlock waits until the modem left the locked state, re-reading it on
+// every signal and interval tick, and gives up after timeout
+func waitForUnlock(modem modemmanager.Modem, signals <-chan *dbus.Signal, timeout, interval time.Duration) error {
+	deadline := clk.After(timeout)
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if state, err := modem.GetState(); err == nil && state != modemmanager.MmModemStateLocked {
+			return nil
+		}
+
+		select {
+		case <-deadline:
+			return &exitError{code: exitTimeout, err: fmt.Errorf("modem still locked after %s", timeout)}
+		case _, ok := <-signals:
+			if !ok {
+				signals = nil
+			}
+		case <-ticker.C():
+		}
+	}
+}
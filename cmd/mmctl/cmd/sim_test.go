@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+// fakePrompt replaces promptSecret with one answering from answers
+func fakePrompt(t *testing.T, answers ...string) *[]string {
+	t.Helper()
+	var asked []string
+	prev := promptSecret
+	promptSecret = func(name string) (string, error) {
+		asked = append(asked, name)
+		if len(answers) == 0 {
+			return "", errNoTerminal
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+	t.Cleanup(func() { promptSecret = prev })
+	return &asked
+}
+
+func TestSecretCode(t *testing.T) {
+	asked := fakePrompt(t, "1111")
+	t.Setenv(envSimPin, "2222")
+
+	if code, err := secretCode("3333", "--pin", envSimPin, "PIN"); err != nil || code != "3333" {
+		t.Errorf("flag: secretCode = %q, %v, want the flag value", code, err)
+	}
+	if code, err := secretCode("", "--pin", envSimPin, "PIN"); err != nil || code != "2222" {
+		t.Errorf("environment: secretCode = %q, %v, want the environment value", code, err)
+	}
+	t.Setenv(envSimPin, "")
+	if code, err := secretCode("", "--pin", envSimPin, "PIN"); err != nil || code != "1111" {
+		t.Errorf("prompt: secretCode = %q, %v, want the typed value", code, err)
+	}
+	_, err := secretCode("", "--pin", envSimPin, "PIN")
+	if want := "no pin given, pass --pin, set MMCTL_SIM_PIN or run in a terminal to type it"; err == nil || err.Error() != want {
+		t.Errorf("no terminal: error = %v, want %q", err, want)
+	}
+	if len(*asked) != 2 {
+		t.Errorf("prompted %d times, want 2", len(*asked))
+	}
+}
+
+func TestNewSecretCode(t *testing.T) {
+	t.Setenv(envSimNewPin, "")
+	fakePrompt(t, "4321", "4321", "4321", "4312")
+
+	if code, err := newSecretCode("", "--new", envSimNewPin, "New PIN"); err != nil || code != "4321" {
+		t.Errorf("newSecretCode = %q, %v", code, err)
+	}
+	if _, err := newSecretCode("", "--new", envSimNewPin, "New PIN"); err == nil || err.Error() != "the new pins do not match" {
+		t.Errorf("mismatch: error = %v", err)
+	}
+	if code, err := newSecretCode("1234", "--new", envSimNewPin, "New PIN"); err != nil || code != "1234" {
+		t.Errorf("flag: newSecretCode = %q, %v", code, err)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	for _, tt := range []struct {
+		code     string
+		min, max int
+		wantErr  string
+	}{
+		{code: "1234", min: 4, max: 8},
+		{code: "12345678", min: 8, max: 8},
+		{code: "123", min: 4, max: 8, wantErr: "invalid PIN (must be 4 to 8 digits)"},
+		{code: "12a4", min: 4, max: 8, wantErr: "invalid PIN (must be 4 to 8 digits)"},
+		{code: "1234567", min: 8, max: 8, wantErr: "invalid PIN (must be 8 digits)"},
+	} {
+		err := validateCode("PIN", tt.code, tt.min, tt.max)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("validateCode(%q, %d, %d) = %v, want %q", tt.code, tt.min, tt.max, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckPukAttempts(t *testing.T) {
+	puk := func(left uint32) map[modemmanager.MMModemLock]uint32 {
+		return map[modemmanager.MMModemLock]uint32{modemmanager.MmModemLockSimPuk: left}
+	}
+	for _, tt := range []struct {
+		retries map[modemmanager.MMModemLock]uint32
+		force   bool
+		wantErr string
+	}{
+		{retries: nil},
+		{retries: puk(10)},
+		{retries: puk(2)},
+		{retries: puk(1), wantErr: "only 1 PUK attempt left"},
+		{retries: puk(1), force: true},
+		{retries: puk(0), force: true, wantErr: "no PUK attempts left"},
+	} {
+		err := checkPukAttempts(tt.retries, tt.force)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)) {
+			t.Errorf("checkPukAttempts(%v, force %v) = %v, want %q", tt.retries, tt.force, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAttemptsError(t *testing.T) {
+	modem := mocks.NewMockModem()
+	wrong := errors.New("incorrect password")
+
+	if got := attemptsError(modem, modemmanager.MmModemLockSimPin, "failed to send PIN", wrong).Error(); got != "failed to send PIN: incorrect password" {
+		t.Errorf("without retries = %q", got)
+	}
+	modem.UnlockRetriesValue = map[modemmanager.MMModemLock]uint32{modemmanager.MmModemLockSimPin: 2, modemmanager.MmModemLockSimPuk: 10}
+	if got := attemptsError(modem, modemmanager.MmModemLockSimPin, "failed to send PIN", wrong).Error(); got != "failed to send PIN: incorrect password (2 PIN attempts left)" {
+		t.Errorf("with retries = %q", got)
+	}
+	modem.UnlockRetriesValue[modemmanager.MmModemLockSimPin] = 0
+	err := attemptsError(modem, modemmanager.MmModemLockSimPin, "failed to send PIN", wrong)
+	if got := err.Error(); got != "failed to send PIN: incorrect password (no PIN attempts left, unlock the SIM with the PUK)" {
+		t.Errorf("blocked = %q", got)
+	}
+	if !errors.Is(err, wrong) {
+		t.Error("attemptsError does not wrap the error")
+	}
+}
+
+func TestWaitForUnlock(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	modem := &advancingModem{
+		MockModem: mocks.NewMockModem(), fake: fake, start: fake.Now(), step: time.Second, reads: make(chan struct{}, 100),
+		states: []modemmanager.MMModemState{modemmanager.MmModemStateLocked, modemmanager.MmModemStateLocked, modemmanager.MmModemStateInitializing},
+	}
+	done := make(chan error, 1)
+	go func() { done <- waitForUnlock(modem, nil, 30*time.Second, time.Second) }()
+	for i := 0; i < 2; i++ {
+		<-modem.reads
+		fake.BlockUntilTimers(2)
+		fake.Advance(time.Second)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("waitForUnlock error: %v", err)
+	}
+
+	modem = &advancingModem{
+		MockModem: mocks.NewMockModem(), fake: fake, start: fake.Now(), step: time.Second, reads: make(chan struct{}, 100),
+		states: []modemmanager.MMModemState{modemmanager.MmModemStateLocked},
+	}
+	go func() { done <- waitForUnlock(modem, nil, 30*time.Second, time.Second) }()
+	<-modem.reads // the timers are set up before the first read
+	fake.Advance(30 * time.Second)
+	var exit *exitError
+	if err := <-done; !errors.As(err, &exit) || exit.code != exitTimeout {
+		t.Errorf("waitForUnlock error = %v, want a timeout", err)
+	}
+}
@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
+	"unicode/utf8"
 
+	"github.com/maltegrosse/go-modemmanager"
 	"github.com/spf13/cobra"
 )
 
@@ -39,12 +45,41 @@ This command group provides operations for:
 		Short: "Send an SMS message",
 		Long: `Send an SMS message to a phone number.
 
-The message will be sent using the modem's messaging interface.`,
+The message will be sent using the modem's messaging interface.
+
+Texts longer than 160 characters of the GSM alphabet, or 70 when they need
+UCS-2 such as non-Latin text and emoji, are sent as concatenated parts of 153
+or 67 characters. mmctl prints how many parts a message needs and sends
+several only with --yes or when they fit --max-parts.
+
+--number may be repeated or hold a comma separated list; the message is sent
+to each recipient in turn and the command fails if any of them failed.`,
 		Example: `  # Send simple SMS
   mmctl sms send -m 0 --number +1234567890 --text "Hello World"
 
   # Send SMS with verbose output
-  mmctl sms send -m 0 --number +1234567890 --text "Test" --verbose`,
+  mmctl sms send -m 0 --number +1234567890 --text "Test" --verbose
+
+  # Send a flash (class 0) message shown on screen without being stored
+  mmctl sms send -m 0 --number +1234567890 --text "Urgent" --flash
+
+  # Send binary data
+  mmctl sms send -m 0 --number +1234567890 --data-hex 0102ff
+
+  # Request a delivery report and wait for it
+  mmctl sms send -m 0 --number +1234567890 --text "Ping" --delivery-report
+
+  # Use another SMS center than the one stored on the SIM
+  mmctl sms send -m 0 --number +1234567890 --text "Hello" --smsc +491710760000
+
+  # Send command output read from stdin
+  journalctl -p err -n 5 | mmctl sms send -m 0 --number +1234567890 --text - --max-parts 3
+
+  # Send the same text to several recipients
+  mmctl sms send -m 0 --number +1234567890,+1987654321 --text-file alert.txt
+
+  # Send a long text as up to 3 concatenated parts
+  mmctl sms send -m 0 --number +1234567890 --text "$(cat notice.txt)" --max-parts 3`,
 		RunE: runSmsSend,
 	}
 
@@ -53,10 +88,19 @@ The message will be sent using the modem's messaging interface.`,
 		Short: "List SMS messages",
 		Long: `List all SMS messages stored on the modem.
 
-This includes received, sent, and draft messages.`,
+This includes received, sent, and draft messages, newest first. Messages
+without a timestamp, such as sent ones, come last. The index shown is the one
+--sms-index of read and delete refers to; it shifts when messages arrive or
+are deleted, while the path does not.`,
 		Example: `  # List all messages
   mmctl sms list -m 0
 
+  # Received messages from one number since the start of the year
+  mmctl sms list -m 0 --state received --from +491234567 --since 2024-01-01
+
+  # Messages of the last week, oldest first
+  mmctl sms list -m 0 --since 7d --sort oldest
+
   # List in JSON format
   mmctl sms list -m 0 --json`,
 		RunE: runSmsList,
@@ -65,10 +109,14 @@ This includes received, sent, and draft messages.`,
 	smsReadCmd = &cobra.Command{
 		Use:   "read",
 		Short: "Read an SMS message",
-		Long:  `Display the content of a specific SMS message.`,
+		Long: `Display the content of a specific SMS message, selected by its index in
+'mmctl sms list' or, stable while messages arrive, by its path with --sms-path.`,
 		Example: `  # Read message at index 0
   mmctl sms read -m 0 --sms-index 0
 
+  # Read message by path
+  mmctl sms read -m 0 --sms-path /org/freedesktop/ModemManager1/SMS/12
+
   # Read message in JSON format
   mmctl sms read -m 0 --sms-index 0 --json`,
 		RunE: runSmsRead,
@@ -76,18 +124,49 @@ This includes received, sent, and draft messages.`,
 
 	smsDeleteCmd = &cobra.Command{
 		Use:   "delete",
-		Short: "Delete an SMS message",
-		Long:  `Delete a specific SMS message from the modem.`,
+		Short: "Delete SMS messages",
+		Long: `Delete a specific SMS message from the modem, selected by its index in
+'mmctl sms list' or by its path with --sms-path, which unlike the index does
+not shift when a message arrives in between.
+
+With --all, or --state and --older-than, several messages are deleted at
+once after confirming the number of messages at a prompt or with --yes.
+Messages without a timestamp are never older than --older-than.`,
 		Example: `  # Delete message at index 0
-  mmctl sms delete -m 0 --sms-index 0`,
+  mmctl sms delete -m 0 --sms-index 0
+
+  # Delete message by path
+  mmctl sms delete -m 0 --sms-path /org/freedesktop/ModemManager1/SMS/12
+
+  # Delete received messages older than 30 days
+  mmctl sms delete -m 0 --state received --older-than 30d
+
+  # Delete all messages without a prompt
+  mmctl sms delete -m 0 --all --yes`,
 		RunE: runSmsDelete,
 	}
 
 	// SMS flags
-	smsNumber   string
-	smsText     string
-	smsIndex    int
-	smsValidity int
+	smsNumbers       []string
+	smsTextFile      string
+	smsText          string
+	smsIndex         int
+	smsValidity      int
+	smsFlash         bool
+	smsDataHex       string
+	smsConfirm       bool
+	smsMaxParts      int
+	smsSmsc          string
+	smsReport        bool
+	smsReportTimeout time.Duration
+	smsPath          string
+	smsStates        []string
+	smsFrom          string
+	smsSince         string
+	smsSort          string
+	smsDeleteAll     bool
+	smsOlderThan     string
+	smsDeleteConfirm bool
 )
 
 func init() {
@@ -100,20 +179,73 @@ func init() {
 	smsCmd.AddCommand(smsDeleteCmd)
 
 	// Send command flags
-	smsSendCmd.Flags().StringVarP(&smsNumber, "number", "n", "", "Recipient phone number (required)")
-	smsSendCmd.Flags().StringVarP(&smsText, "text", "t", "", "Message text (required)")
+	smsSendCmd.Flags().StringSliceVarP(&smsNumbers, "number", "n", nil, "Recipient phone number, repeated or comma separated for several (required)")
+	smsSendCmd.Flags().StringVarP(&smsText, "text", "t", "", "Message text, - to read it from stdin (required)")
+	smsSendCmd.Flags().StringVar(&smsTextFile, "text-file", "", "Read the message text from a file, - for stdin")
 	smsSendCmd.Flags().IntVar(&smsValidity, "validity", 0, "Message validity period in minutes (0 = default)")
+	smsSendCmd.Flags().StringVar(&smsSmsc, "smsc", "", "SMS center number to use instead of the default one")
+	smsSendCmd.Flags().BoolVar(&smsReport, "delivery-report", false, "Request a delivery report and wait for it")
+	smsSendCmd.Flags().DurationVar(&smsReportTimeout, "delivery-timeout", 2*time.Minute, "Maximum time to wait for the delivery report")
+	smsSendCmd.Flags().BoolVar(&smsFlash, "flash", false, "Send as a flash message (class 0)")
+	smsSendCmd.Flags().StringVar(&smsDataHex, "data-hex", "", "Send binary data given as hex instead of text")
+	smsSendCmd.Flags().BoolVar(&smsConfirm, "yes", false, "Send a message needing several parts")
+	smsSendCmd.Flags().IntVar(&smsMaxParts, "max-parts", 0, "Send a message of at most this many parts (0 = ask for --yes)")
 	smsSendCmd.MarkFlagRequired("number")
-	smsSendCmd.MarkFlagRequired("text")
+	smsSendCmd.MarkFlagsOneRequired("text", "text-file", "data-hex")
+	smsSendCmd.MarkFlagsMutuallyExclusive("text", "text-file", "data-hex")
+	smsSendCmd.MarkFlagsMutuallyExclusive("flash", "data-hex")
+
+	// List command flags
+	smsListCmd.Flags().StringSliceVar(&smsStates, "state", nil, "Only messages in these states: stored, receiving, received, sending, sent")
+	smsListCmd.Flags().StringVar(&smsFrom, "from", "", "Only messages from or to this number")
+	smsListCmd.Flags().StringVar(&smsSince, "since", "", "Only messages since a date (2024-01-01), RFC 3339 time or age (7d)")
+	smsListCmd.Flags().StringVar(&smsSort, "sort", "newest", "Order by timestamp: newest or oldest first")
 
 	// Read and delete command flags
 	smsReadCmd.Flags().IntVarP(&smsIndex, "sms-index", "i", 0, "SMS message index")
-	smsReadCmd.MarkFlagRequired("sms-index")
+	smsReadCmd.Flags().StringVar(&smsPath, "sms-path", "", "SMS object path, or the number it ends in")
+	smsReadCmd.MarkFlagsOneRequired("sms-index", "sms-path")
+	smsReadCmd.MarkFlagsMutuallyExclusive("sms-index", "sms-path")
 	smsDeleteCmd.Flags().IntVarP(&smsIndex, "sms-index", "i", 0, "SMS message index")
-	smsDeleteCmd.MarkFlagRequired("sms-index")
+	smsDeleteCmd.Flags().StringVar(&smsPath, "sms-path", "", "SMS object path, or the number it ends in")
+	smsDeleteCmd.Flags().BoolVar(&smsDeleteAll, "all", false, "Delete all messages")
+	smsDeleteCmd.Flags().StringSliceVar(&smsStates, "state", nil, "Delete the messages in these states")
+	smsDeleteCmd.Flags().StringVar(&smsOlderThan, "older-than", "", "Delete the messages older than this age, e.g. 30d")
+	smsDeleteCmd.Flags().BoolVar(&smsDeleteConfirm, "yes", false, "Delete several messages without asking for confirmation")
+	smsDeleteCmd.MarkFlagsOneRequired("sms-index", "sms-path", "all", "state", "older-than")
+	smsDeleteCmd.MarkFlagsMutuallyExclusive("sms-index", "sms-path", "all", "state")
+	smsDeleteCmd.MarkFlagsMutuallyExclusive("sms-index", "sms-path", "all", "older-than")
 }
 
 func runSmsSend(cmd *cobra.Command, args []string) error {
+	numbers, err := parseRecipients(smsNumbers)
+	if err != nil {
+		return err
+	}
+	text, err := smsMessageText(smsText, smsTextFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	props, err := buildSmsProperties(numbers[0], text, smsDataHex, smsFlash)
+	if err != nil {
+		return err
+	}
+	if err := smsSendOptions(&props, smsValidity, smsSmsc, smsReport); err != nil {
+		return err
+	}
+
+	segments := countSmsSegments(props.Text)
+	if props.Data != nil {
+		segments = countDataSegments(props.Data)
+	}
+	if segments.Parts > 1 {
+		fmt.Fprintf(os.Stderr, "This will send %s\n", segments)
+	}
+	if err := checkSmsParts(segments, smsConfirm, smsMaxParts); err != nil {
+		return err
+	}
+
 	modem, err := getModem()
 	if err != nil {
 		return err
@@ -126,38 +258,250 @@ func runSmsSend(cmd *cobra.Command, args []string) error {
 	}
 
 	if verbose {
-		fmt.Printf("Sending SMS to %s\n", smsNumber)
-		fmt.Printf("Message: %s\n", smsText)
+		fmt.Printf("Sending SMS to %s\n", strings.Join(numbers, ", "))
+		if props.Text != "" {
+			fmt.Printf("Message: %s\n", props.Text)
+		} else {
+			fmt.Printf("Data: %d bytes\n", len(props.Data))
+		}
+	}
+
+	if smsFlash {
+		fmt.Fprintln(os.Stderr, "Warning: many networks and handsets ignore flash messages or deliver them as normal SMS")
+	}
+
+	// Recipients are sent to one after the other
+	results := make([]smsSendResult, len(numbers))
+	failed := 0
+	for i, number := range numbers {
+		props.Number = number
+		results[i] = sendSms(messaging, props, segments)
+		if results[i].err != nil {
+			failed++
+		}
+		if !jsonOutput {
+			printSmsSendResult(results[i], len(numbers) > 1)
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return err
+		}
+	}
+	switch {
+	case len(numbers) == 1:
+		return results[0].err
+	case failed > 0:
+		return fmt.Errorf("SMS to %d of %d recipients failed", failed, len(numbers))
+	}
+	return nil
+}
+
+// smsSendResult is the outcome of sending to one recipient
+type smsSendResult struct {
+	Number        string `json:"number"`
+	Sent          bool   `json:"sent"`
+	Parts         int    `json:"parts"`
+	Path          string `json:"path,omitempty"`
+	DeliveryState string `json:"delivery_state,omitempty"`
+	Delivered     bool   `json:"delivered,omitempty"`
+	Error         string `json:"error,omitempty"`
+	err           error
+}
+
+// sendSms creates and sends the message props and, if requested, waits for
+// its delivery report
+func sendSms(messaging modemmanager.ModemMessaging, props modemmanager.SmsProperties, segments smsSegments) smsSendResult {
+	result := smsSendResult{Number: props.Number, Parts: segments.Parts}
+	fail := func(err error) smsSendResult {
+		result.err = err
+		result.Error = err.Error()
+		return result
 	}
 
-	// Create SMS
-	sms, err := messaging.CreateSms(smsNumber, smsText)
+	// Create SMS with the full text, ModemManager splits it into parts
+	sms, err := messaging.CreateSmsWithProperties(props)
 	if err != nil {
-		return fmt.Errorf("failed to create SMS: %w", err)
+		return fail(fmt.Errorf("failed to create SMS: %w", err))
+	}
+	result.Path = string(sms.GetObjectPath())
+
+	// Verify the daemon kept the requested class
+	if props.Class != nil {
+		class, err := sms.GetClass()
+		if err != nil {
+			return fail(fmt.Errorf("failed to read back SMS class: %w", err))
+		}
+		if err := verifyFlashClass(class); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
 	}
 
 	if verbose {
-		fmt.Println("SMS created, sending...")
+		fmt.Fprintf(os.Stderr, "SMS to %s created, sending...\n", props.Number)
 	}
 
 	// Send SMS
 	if err := sms.Send(); err != nil {
-		return fmt.Errorf("failed to send SMS: %w", err)
+		return fail(fmt.Errorf("failed to send SMS: %w", err))
 	}
 
-	fmt.Println("✓ SMS sent successfully")
-
+	state, err := sms.GetState()
+	if err != nil {
+		return fail(fmt.Errorf("failed to read back SMS state: %w", err))
+	}
 	if verbose {
-		// Get SMS state
-		if state, err := sms.GetState(); err == nil {
-			fmt.Printf("Final state: %s\n", state.String())
+		fmt.Fprintf(os.Stderr, "Final state: %s\n", state.String())
+	}
+	if state != modemmanager.MmSmsStateSent {
+		return fail(fmt.Errorf("SMS not sent, its state is %s", strings.ToLower(state.String())))
+	}
+	result.Sent = true
+
+	if props.DeliveryReportRequest {
+		fmt.Fprintf(os.Stderr, "Waiting up to %s for the delivery report of %s...\n", smsReportTimeout, props.Number)
+		delivery, err := waitForDelivery(sms.GetDeliveryState, func(state modemmanager.MMSmsDeliveryState) {
+			fmt.Fprintf(os.Stderr, "Delivery state: %s\n", deliveryStateName(state))
+		}, smsReportTimeout, deliveryPollInterval)
+		result.DeliveryState = deliveryStateName(delivery)
+		if err != nil {
+			return fail(err)
+		}
+		result.Delivered = true
+	}
+	return result
+}
+
+// printSmsSendResult prints the outcome for one recipient, naming it when
+// there are several
+func printSmsSendResult(result smsSendResult, several bool) {
+	to := ""
+	if several {
+		to = " to " + result.Number
+	}
+	if result.err != nil {
+		if several {
+			fmt.Fprintf(os.Stderr, "✗ SMS%s failed: %v\n", to, result.err)
+		}
+		return
+	}
+	if result.Parts > 1 {
+		fmt.Printf("✓ SMS sent successfully%s (%d parts)\n", to, result.Parts)
+	} else {
+		fmt.Printf("✓ SMS sent successfully%s\n", to)
+	}
+	if result.Delivered {
+		fmt.Printf("✓ Delivered%s (%s)\n", to, result.DeliveryState)
+	}
+}
+
+// maxSmsTextSize limits text read from a file or stdin; 255 parts of UCS-2
+// text hold far less
+const maxSmsTextSize = 64 * 1024
+
+// smsMessageText returns the text to send: --text, or read from the file
+// --text-file or, with "-", from stdin. Trailing newlines of read text are
+// dropped.
+func smsMessageText(text, file string, stdin io.Reader) (string, error) {
+	var source io.Reader
+	switch {
+	case text == "-" || file == "-":
+		source = stdin
+	case file != "":
+		f, err := os.Open(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read --text-file: %w", err)
+		}
+		defer f.Close()
+		source = f
+	default:
+		return text, nil
+	}
+
+	read, err := io.ReadAll(io.LimitReader(source, maxSmsTextSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read message text: %w", err)
+	}
+	if len(read) > maxSmsTextSize {
+		return "", fmt.Errorf("message text is longer than %d KiB", maxSmsTextSize/1024)
+	}
+	if !utf8.Valid(read) {
+		return "", errors.New("message text is not valid UTF-8")
+	}
+	text = strings.TrimRight(string(read), "\r\n")
+	if text == "" {
+		return "", errors.New("message text is empty")
+	}
+	return text, nil
+}
+
+// parseRecipients returns the numbers given with --number, which may be
+// repeated and hold comma separated lists, without blanks and duplicates
+func parseRecipients(numbers []string) ([]string, error) {
+	var recipients []string
+	seen := make(map[string]bool)
+	for _, number := range numbers {
+		number = strings.TrimSpace(number)
+		if number == "" || seen[number] {
+			continue
+		}
+		seen[number] = true
+		recipients = append(recipients, number)
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipient number given")
+	}
+	return recipients, nil
+}
+
+// buildSmsProperties returns the properties of a message to send, either as
+// text or as hex-encoded binary data.
+func buildSmsProperties(number, text, dataHex string, flash bool) (modemmanager.SmsProperties, error) {
+	props := modemmanager.SmsProperties{
+		Number: number,
+		Text:   text,
+	}
+
+	if dataHex != "" {
+		data, err := hex.DecodeString(strings.TrimPrefix(dataHex, "0x"))
+		if err != nil {
+			return props, fmt.Errorf("invalid --data-hex: %w", err)
 		}
+		props.Data = data
 	}
 
+	if flash {
+		if props.Text == "" {
+			return props, fmt.Errorf("--flash requires --text")
+		}
+		class := int32(0)
+		props.Class = &class
+	}
+
+	return props, nil
+}
+
+// verifyFlashClass checks the class read back from a created flash message.
+func verifyFlashClass(class int32) error {
+	if class != 0 {
+		return fmt.Errorf("ModemManager did not keep message class 0 (got %d), it will be sent as a normal SMS", class)
+	}
 	return nil
 }
 
 func runSmsList(cmd *cobra.Command, args []string) error {
+	filter, err := smsListFilter()
+	if err != nil {
+		return err
+	}
+	oldestFirst, err := parseSmsSort(smsSort)
+	if err != nil {
+		return err
+	}
+
 	modem, err := getModem()
 	if err != nil {
 		return err
@@ -179,11 +523,14 @@ func runSmsList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list messages: %w", err)
 	}
 
-	if len(messages) == 0 {
+	if len(messages) == 0 && !jsonOutput {
 		fmt.Println("No messages found")
 		return nil
 	}
 
+	entries := filterSmsEntries(readSmsEntries(messages), filter)
+	sortSmsEntries(entries, oldestFirst)
+
 	// Collect message information
 	type smsInfo struct {
 		Index     int       `json:"index"`
@@ -195,39 +542,17 @@ func runSmsList(cmd *cobra.Command, args []string) error {
 		Storage   string    `json:"storage"`
 	}
 
-	var smsInfos []smsInfo
-	for i, sms := range messages {
-		info := smsInfo{
-			Index: i,
-			Path:  string(sms.GetObjectPath()),
-		}
-
-		// Get number
-		if number, err := sms.GetNumber(); err == nil {
-			info.Number = number
-		}
-
-		// Get text
-		if text, err := sms.GetText(); err == nil {
-			info.Text = text
-		}
-
-		// Get state
-		if state, err := sms.GetState(); err == nil {
-			info.State = state.String()
-		}
-
-		// Get timestamp
-		if timestamp, err := sms.GetTimestamp(); err == nil {
-			info.Timestamp = timestamp
-		}
-
-		// Get storage
-		if storage, err := sms.GetStorage(); err == nil {
-			info.Storage = storage.String()
-		}
-
-		smsInfos = append(smsInfos, info)
+	smsInfos := []smsInfo{}
+	for _, entry := range entries {
+		smsInfos = append(smsInfos, smsInfo{
+			Index:     entry.Index,
+			Path:      string(entry.Sms.GetObjectPath()),
+			Number:    entry.Number,
+			Text:      entry.Text,
+			State:     entry.State.String(),
+			Timestamp: entry.Timestamp,
+			Storage:   entry.Storage,
+		})
 	}
 
 	// Output
@@ -237,6 +562,11 @@ func runSmsList(cmd *cobra.Command, args []string) error {
 		return encoder.Encode(smsInfos)
 	}
 
+	if len(smsInfos) == 0 {
+		fmt.Printf("No matching messages (%d in total)\n", len(messages))
+		return nil
+	}
+
 	// Table output
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	defer w.Flush()
@@ -245,10 +575,7 @@ func runSmsList(cmd *cobra.Command, args []string) error {
 	fmt.Fprintln(w, "-----\t------\t-----\t---------\t-------")
 
 	for _, msg := range smsInfos {
-		timestamp := ""
-		if !msg.Timestamp.IsZero() {
-			timestamp = msg.Timestamp.Format("2006-01-02 15:04")
-		}
+		timestamp := humanTimestamp(msg.Timestamp)
 
 		text := msg.Text
 		if len(text) > 50 {
@@ -271,7 +598,7 @@ func runSmsList(cmd *cobra.Command, args []string) error {
 
 	if verbose {
 		fmt.Fprintln(w)
-		fmt.Fprintf(w, "Total messages: %d\n", len(smsInfos))
+		fmt.Fprintf(w, "Total messages: %d of %d\n", len(smsInfos), len(messages))
 	}
 
 	return nil
@@ -295,15 +622,14 @@ func runSmsRead(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list messages: %w", err)
 	}
 
-	if smsIndex >= len(messages) {
-		return fmt.Errorf("SMS index %d out of range (0-%d)", smsIndex, len(messages)-1)
+	sms, index, err := selectSms(messages, smsIndex, smsPath)
+	if err != nil {
+		return err
 	}
 
-	sms := messages[smsIndex]
-
 	// Collect SMS details
 	info := make(map[string]interface{})
-	info["index"] = smsIndex
+	info["index"] = index
 	info["path"] = string(sms.GetObjectPath())
 
 	if number, err := sms.GetNumber(); err == nil {
@@ -389,6 +715,11 @@ func runSmsRead(cmd *cobra.Command, args []string) error {
 }
 
 func runSmsDelete(cmd *cobra.Command, args []string) error {
+	filter, err := smsDeleteFilter()
+	if err != nil {
+		return err
+	}
+
 	modem, err := getModem()
 	if err != nil {
 		return err
@@ -406,23 +737,96 @@ func runSmsDelete(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to list messages: %w", err)
 	}
 
-	if smsIndex >= len(messages) {
-		return fmt.Errorf("SMS index %d out of range (0-%d)", smsIndex, len(messages)-1)
-	}
+	if cmd.Flags().Changed("sms-index") || smsPath != "" {
+		sms, _, err := selectSms(messages, smsIndex, smsPath)
+		if err != nil {
+			return err
+		}
 
-	sms := messages[smsIndex]
+		if verbose {
+			if number, err := sms.GetNumber(); err == nil {
+				fmt.Printf("Deleting SMS from %s\n", number)
+			}
+		}
 
-	if verbose {
-		if number, err := sms.GetNumber(); err == nil {
-			fmt.Printf("Deleting SMS from %s\n", number)
+		// Delete the message
+		if err := messaging.Delete(sms); err != nil {
+			return fmt.Errorf("failed to delete SMS: %w", err)
 		}
+
+		fmt.Println("✓ SMS deleted successfully")
+		return nil
 	}
 
-	// Delete the message
-	if err := messaging.Delete(sms); err != nil {
-		return fmt.Errorf("failed to delete SMS: %w", err)
+	entries := filterSmsEntries(readSmsEntries(messages), filter)
+	if len(entries) == 0 {
+		fmt.Println("No matching messages to delete")
+		return nil
+	}
+	if err := confirmDisruption([]string{fmt.Sprintf("delete %s", pluralMessages(len(entries)))}, smsDeleteConfirm); err != nil {
+		return err
+	}
+
+	deleted := 0
+	for _, entry := range entries {
+		if err := messaging.Delete(entry.Sms); err != nil {
+			return fmt.Errorf("failed to delete SMS %s after deleting %s: %w", entry.Sms.GetObjectPath(), pluralMessages(deleted), err)
+		}
+		deleted++
 	}
 
-	fmt.Println("✓ SMS deleted successfully")
+	fmt.Printf("✓ Deleted %s\n", pluralMessages(deleted))
 	return nil
 }
+
+// smsListFilter builds the filter of sms list from its flags
+func smsListFilter() (smsFilter, error) {
+	var filter smsFilter
+	var err error
+	if filter.states, err = parseSmsStates(smsStates); err != nil {
+		return filter, err
+	}
+	filter.from = smsFrom
+	if smsSince != "" {
+		if filter.since, err = parseSince(smsSince, clk.Now()); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// smsDeleteFilter builds the filter of bulk sms delete from its flags;
+// --all leaves it empty
+func smsDeleteFilter() (smsFilter, error) {
+	var filter smsFilter
+	var err error
+	if filter.states, err = parseSmsStates(smsStates); err != nil {
+		return filter, err
+	}
+	if smsOlderThan != "" {
+		age, err := parseAge(smsOlderThan)
+		if err != nil {
+			return filter, err
+		}
+		filter.before = clk.Now().Add(-age)
+	}
+	return filter, nil
+}
+
+// parseSmsSort resolves --sort, reporting whether the oldest come first
+func parseSmsSort(order string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "newest", "":
+		return false, nil
+	case "oldest":
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown sort order %q, expected newest or oldest", order)
+}
+
+func pluralMessages(n int) string {
+	if n == 1 {
+		return "1 message"
+	}
+	return fmt.Sprintf("%d messages", n)
+}
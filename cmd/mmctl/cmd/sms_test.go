@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildSmsPropertiesFlash(t *testing.T) {
+	props, err := buildSmsProperties("+1234567890", "Urgent", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := props.ToMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class, ok := m["class"].(int32); !ok || class != 0 {
+		t.Errorf("class = %#v, want int32(0)", m["class"])
+	}
+}
+
+func TestBuildSmsPropertiesNoClassByDefault(t *testing.T) {
+	props, err := buildSmsProperties("+1234567890", "Hello", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := props.ToMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["class"]; ok {
+		t.Errorf("class set without --flash: %#v", m["class"])
+	}
+}
+
+func TestBuildSmsPropertiesDataHex(t *testing.T) {
+	props, err := buildSmsProperties("+1234567890", "", "0x01ff", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(props.Data) != 2 || props.Data[0] != 0x01 || props.Data[1] != 0xff {
+		t.Errorf("data = %x, want 01ff", props.Data)
+	}
+
+	if _, err := buildSmsProperties("+1234567890", "", "zz", false); err == nil {
+		t.Error("expected error for invalid hex")
+	}
+	if _, err := buildSmsProperties("+1234567890", "", "01", true); err == nil {
+		t.Error("expected error for --flash with binary data")
+	}
+}
+
+func TestVerifyFlashClass(t *testing.T) {
+	if err := verifyFlashClass(0); err != nil {
+		t.Errorf("verifyFlashClass(0) = %v, want nil", err)
+	}
+	for _, class := range []int32{-1, 1, 2, 3} {
+		if err := verifyFlashClass(class); err == nil {
+			t.Errorf("verifyFlashClass(%d) = nil, want error", class)
+		}
+	}
+}
+
+func TestSmsMessageText(t *testing.T) {
+	if text, err := smsMessageText("Hello\n", "", strings.NewReader("ignored")); err != nil || text != "Hello\n" {
+		t.Errorf("--text: smsMessageText = %q, %v, want the text unchanged", text, err)
+	}
+	if text, err := smsMessageText("-", "", strings.NewReader("disk full\nsecond line\r\n\n")); err != nil || text != "disk full\nsecond line" {
+		t.Errorf("stdin: smsMessageText = %q, %v", text, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "alert.txt")
+	if err := os.WriteFile(file, []byte("from a file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if text, err := smsMessageText("", file, nil); err != nil || text != "from a file" {
+		t.Errorf("--text-file: smsMessageText = %q, %v", text, err)
+	}
+	if text, err := smsMessageText("", "-", strings.NewReader("piped")); err != nil || text != "piped" {
+		t.Errorf("--text-file -: smsMessageText = %q, %v", text, err)
+	}
+
+	bad := map[string]io.Reader{
+		"empty":        strings.NewReader("\n\n"),
+		"too large":    strings.NewReader(strings.Repeat("a", maxSmsTextSize+1)),
+		"invalid UTF8": strings.NewReader("\xff\xfe"),
+	}
+	for name, stdin := range bad {
+		if text, err := smsMessageText("-", "", stdin); err == nil {
+			t.Errorf("%s: smsMessageText = %q, want an error", name, text)
+		}
+	}
+	if _, err := smsMessageText("", filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("missing file accepted")
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	numbers, err := parseRecipients([]string{"+491234", " +495678", "", "+491234"})
+	if err != nil || strings.Join(numbers, ",") != "+491234,+495678" {
+		t.Errorf("parseRecipients = %v, %v", numbers, err)
+	}
+	if _, err := parseRecipients([]string{" "}); err == nil {
+		t.Error("blank recipients accepted")
+	}
+}
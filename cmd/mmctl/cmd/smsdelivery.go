@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// deliveryPollInterval is how often the delivery state is re-read while
+// waiting for a report
+const deliveryPollInterval = 2 * time.Second
+
+// maxSmsValidity is the longest relative validity a message can carry, 63
+// weeks
+const maxSmsValidity = 63 * 7 * 24 * 60 // minutes
+
+var smscPattern = regexp.MustCompile(`^\+?[0-9]{3,20}$`)
+
+// smsSendOptions checks --validity and --smsc and sets them and the
+// delivery report request on props
+func smsSendOptions(props *modemmanager.SmsProperties, validity int, smsc string, deliveryReport bool) error {
+	if validity < 0 || validity > maxSmsValidity {
+		return fmt.Errorf("invalid --validity %d, expected 0 to %d minutes (63 weeks)", validity, maxSmsValidity)
+	}
+	smsc = strings.ReplaceAll(smsc, " ", "")
+	if smsc != "" && !smscPattern.MatchString(smsc) {
+		return fmt.Errorf("invalid --smsc %q, expected an international number such as +491710760000", smsc)
+	}
+	props.Validity = uint32(validity)
+	props.Smsc = smsc
+	props.DeliveryReportRequest = deliveryReport
+	return nil
+}
+
+// deliveryStateName prints a delivery state in words, e.g. "temporary error
+// sme busy"
+func deliveryStateName(state modemmanager.MMSmsDeliveryState) string {
+	name := state.String()
+	if strings.HasPrefix(name, "MMSmsDeliveryState(") {
+		return fmt.Sprintf("0x%02x", uint32(state))
+	}
+	var words strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			words.WriteByte(' ')
+		}
+		words.WriteRune(unicode.ToLower(r))
+	}
+	return words.String()
+}
+
+// deliveryPending reports whether the message may still be delivered: no
+// report has arrived or the SC is still trying after a temporary error
+func deliveryPending(state modemmanager.MMSmsDeliveryState) bool {
+	return state == modemmanager.MmSmsDeliveryStateUnknown || (state >= 0x20 && state < 0x40)
+}
+
+// deliveryCompleted reports whether state is one of the completed states
+func deliveryCompleted(state modemmanager.MMSmsDeliveryState) bool {
+	return state < 0x20
+}
+
+// waitForDelivery re-reads the delivery state until a final report arrives
+// and calls report on every change. A failed delivery is an error.
+func waitForDelivery(read func() (modemmanager.MMSmsDeliveryState, error), report func(modemmanager.MMSmsDeliveryState), timeout, interval time.Duration) (modemmanager.MMSmsDeliveryState, error) {
+	last := modemmanager.MmSmsDeliveryStateUnknown
+	err := pollUntil(context.Background(), nil, timeout, interval, func() (bool, error) {
+		state, err := read()
+		if err != nil {
+			return false, fmt.Errorf("failed to read delivery state: %w", err)
+		}
+		if state != last {
+			last = state
+			report(state)
+		}
+		return !deliveryPending(state), nil
+	})
+	switch {
+	case errors.Is(err, errWaitTimeout) && last == modemmanager.MmSmsDeliveryStateUnknown:
+		return last, &exitError{code: exitTimeout, err: fmt.Errorf("no delivery report within %s", timeout)}
+	case errors.Is(err, errWaitTimeout):
+		return last, &exitError{code: exitTimeout, err: fmt.Errorf("not delivered within %s, last report: %s", timeout, deliveryStateName(last))}
+	case err != nil:
+		return last, err
+	case !deliveryCompleted(last):
+		return last, fmt.Errorf("SMS not delivered: %s", deliveryStateName(last))
+	}
+	return last, nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+func TestSmsSendOptions(t *testing.T) {
+	var props modemmanager.SmsProperties
+	if err := smsSendOptions(&props, 60, "+49 171 0760000", true); err != nil {
+		t.Fatal(err)
+	}
+	if props.Validity != 60 || props.Smsc != "+491710760000" || !props.DeliveryReportRequest {
+		t.Errorf("props = %+v", props)
+	}
+
+	for _, bad := range []struct {
+		validity int
+		smsc     string
+	}{{-1, ""}, {maxSmsValidity + 1, ""}, {0, "+49-171"}, {0, "+12"}, {0, "smsc"}} {
+		if err := smsSendOptions(&props, bad.validity, bad.smsc, false); err == nil {
+			t.Errorf("smsSendOptions(%d, %q) accepted", bad.validity, bad.smsc)
+		}
+	}
+}
+
+func TestDeliveryStateName(t *testing.T) {
+	tests := map[modemmanager.MMSmsDeliveryState]string{
+		modemmanager.MmSmsDeliveryStateCompletedReceived:        "completed received",
+		modemmanager.MmSmsDeliveryStateTemporaryErrorSmeBusy:    "temporary error sme busy",
+		modemmanager.MmSmsDeliveryStateErrorNotObtainable:       "error not obtainable",
+		modemmanager.MmSmsDeliveryStateUnknown:                  "unknown",
+		modemmanager.MMSmsDeliveryState(0x7f):                   "0x7f",
+		modemmanager.MmSmsDeliveryStateTemporaryFatalErrorInSme: "temporary fatal error in sme",
+	}
+	for state, want := range tests {
+		if got := deliveryStateName(state); got != want {
+			t.Errorf("deliveryStateName(%#x) = %q, want %q", uint32(state), got, want)
+		}
+	}
+}
+
+func TestWaitForDelivery(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+	states := []modemmanager.MMSmsDeliveryState{
+		modemmanager.MmSmsDeliveryStateUnknown,
+		modemmanager.MmSmsDeliveryStateTemporaryErrorSmeBusy,
+		modemmanager.MmSmsDeliveryStateCompletedReceived,
+	}
+	reads := make(chan struct{}, 10)
+	read := func() (modemmanager.MMSmsDeliveryState, error) {
+		defer func() { reads <- struct{}{} }()
+		state := states[0]
+		if len(states) > 1 {
+			states = states[1:]
+		}
+		return state, nil
+	}
+	var reported []string
+	report := func(state modemmanager.MMSmsDeliveryState) { reported = append(reported, deliveryStateName(state)) }
+
+	type result struct {
+		state modemmanager.MMSmsDeliveryState
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		state, err := waitForDelivery(read, report, time.Minute, time.Second)
+		done <- result{state, err}
+	}()
+	<-reads
+	fake.Advance(time.Second)
+	<-reads
+	fake.Advance(time.Second)
+	<-reads
+	if r := <-done; r.err != nil || r.state != modemmanager.MmSmsDeliveryStateCompletedReceived {
+		t.Errorf("waitForDelivery = %v, %v", r.state, r.err)
+	}
+	if strings.Join(reported, ",") != "temporary error sme busy,completed received" {
+		t.Errorf("reported %v", reported)
+	}
+
+	failed := func() (modemmanager.MMSmsDeliveryState, error) {
+		return modemmanager.MmSmsDeliveryStateErrorNotObtainable, nil
+	}
+	if _, err := waitForDelivery(failed, func(modemmanager.MMSmsDeliveryState) {}, time.Minute, time.Second); err == nil ||
+		err.Error() != "SMS not delivered: error not obtainable" {
+		t.Errorf("permanent error: error = %v", err)
+	}
+
+	// The deadlines of the calls above are still pending
+	fake = useFakeClock(t, time.Unix(0, 0))
+	go func() {
+		state, err := waitForDelivery(func() (modemmanager.MMSmsDeliveryState, error) {
+			return modemmanager.MmSmsDeliveryStateUnknown, nil
+		}, func(modemmanager.MMSmsDeliveryState) {}, 5*time.Second, time.Second)
+		done <- result{state, err}
+	}()
+	fake.BlockUntilTimers(2)
+	fake.Advance(5 * time.Second)
+	if r := <-done; ExitCode(r.err) != exitTimeout || r.err.Error() != "no delivery report within 5s" {
+		t.Errorf("timeout: error = %v", r.err)
+	}
+}
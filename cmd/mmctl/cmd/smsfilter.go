@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// smsStateNames are the states accepted by --state
+var smsStateNames = map[string]modemmanager.MMSmsState{
+	"unknown":   modemmanager.MmSmsStateUnknown,
+	"stored":    modemmanager.MmSmsStateStored,
+	"receiving": modemmanager.MmSmsStateReceiving,
+	"received":  modemmanager.MmSmsStateReceived,
+	"sending":   modemmanager.MmSmsStateSending,
+	"sent":      modemmanager.MmSmsStateSent,
+}
+
+// parseSmsStates resolves the --state values, which may be repeated or
+// comma separated
+func parseSmsStates(names []string) (map[modemmanager.MMSmsState]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	states := make(map[modemmanager.MMSmsState]bool)
+	for _, name := range names {
+		state, ok := smsStateNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown SMS state %q, expected stored, receiving, received, sending, sent or unknown", name)
+		}
+		states[state] = true
+	}
+	return states, nil
+}
+
+// smsEntry is a message with its index in the list ModemManager returns,
+// which --sms-index refers to
+type smsEntry struct {
+	Index     int
+	Sms       modemmanager.Sms
+	Number    string
+	Text      string
+	State     modemmanager.MMSmsState
+	Timestamp time.Time
+	Storage   string
+}
+
+// readSmsEntries reads the properties of messages; those that cannot be
+// read are left empty
+func readSmsEntries(messages []modemmanager.Sms) []smsEntry {
+	entries := make([]smsEntry, len(messages))
+	for i, sms := range messages {
+		entry := smsEntry{Index: i, Sms: sms}
+		entry.Number, _ = sms.GetNumber()
+		entry.Text, _ = sms.GetText()
+		entry.State, _ = sms.GetState()
+		entry.Timestamp, _ = sms.GetTimestamp()
+		if storage, err := sms.GetStorage(); err == nil {
+			entry.Storage = storage.String()
+		}
+		entries[i] = entry
+	}
+	return entries
+}
+
+// smsFilter selects messages; zero fields match everything
+type smsFilter struct {
+	states map[modemmanager.MMSmsState]bool
+	from   string    // number, spaces and dashes ignored
+	since  time.Time // timestamp at or after
+	before time.Time // timestamp before
+}
+
+func normaliseNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(number))
+}
+
+// matches reports whether entry passes the filter. Messages without a
+// timestamp, such as ones stored or sent here, never match a time bound.
+func (f smsFilter) matches(entry smsEntry) bool {
+	if f.states != nil && !f.states[entry.State] {
+		return false
+	}
+	if f.from != "" && normaliseNumber(entry.Number) != normaliseNumber(f.from) {
+		return false
+	}
+	if !f.since.IsZero() && (entry.Timestamp.IsZero() || entry.Timestamp.Before(f.since)) {
+		return false
+	}
+	if !f.before.IsZero() && (entry.Timestamp.IsZero() || !entry.Timestamp.Before(f.before)) {
+		return false
+	}
+	return true
+}
+
+// filterSmsEntries returns the entries passing f
+func filterSmsEntries(entries []smsEntry, f smsFilter) []smsEntry {
+	var matching []smsEntry
+	for _, entry := range entries {
+		if f.matches(entry) {
+			matching = append(matching, entry)
+		}
+	}
+	return matching
+}
+
+// sortSmsEntries sorts by timestamp, newest first unless oldestFirst is
+// set. Messages without a timestamp go last, in index order.
+func sortSmsEntries(entries []smsEntry, oldestFirst bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].Timestamp, entries[j].Timestamp
+		switch {
+		case a.IsZero() || b.IsZero():
+			return !a.IsZero() && b.IsZero()
+		case oldestFirst:
+			return a.Before(b)
+		default:
+			return a.After(b)
+		}
+	})
+}
+
+// selectSms returns the message chosen with --sms-path or, if path is empty,
+// --sms-index. A path may be given in full or as the number it ends in.
+func selectSms(messages []modemmanager.Sms, index int, path string) (modemmanager.Sms, int, error) {
+	if path != "" {
+		if !strings.HasPrefix(path, "/") {
+			path = modemmanager.SmsObjectPathPrefix + path
+		}
+		for i, sms := range messages {
+			if string(sms.GetObjectPath()) == path {
+				return sms, i, nil
+			}
+		}
+		return nil, 0, fmt.Errorf("no SMS at path %s", path)
+	}
+	if len(messages) == 0 {
+		return nil, 0, fmt.Errorf("no messages found")
+	}
+	if index < 0 || index >= len(messages) {
+		return nil, 0, fmt.Errorf("SMS index %d out of range (0-%d)", index, len(messages)-1)
+	}
+	return messages[index], index, nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+	"github.com/spf13/cobra"
+)
+
+// testMessages returns received messages with the paths SMS/10, SMS/11 and
+// SMS/12, an hour apart, and a sent one without a timestamp
+func testMessages() []modemmanager.Sms {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	var messages []modemmanager.Sms
+	for i := 0; i < 3; i++ {
+		sms := mocks.NewMockSms()
+		sms.ObjectPathValue = dbus.ObjectPath(fmt.Sprint(modemmanager.SmsObjectPathPrefix, 10+i))
+		sms.TimestampValue = start.Add(time.Duration(i) * time.Hour)
+		messages = append(messages, sms)
+	}
+	sent := mocks.NewMockSms()
+	sent.ObjectPathValue = modemmanager.SmsObjectPathPrefix + "13"
+	sent.StateValue = modemmanager.MmSmsStateSent
+	sent.NumberValue = "+49 123-456"
+	return append(messages, sent)
+}
+
+func entryIndexes(entries []smsEntry) []int {
+	indexes := make([]int, len(entries))
+	for i, entry := range entries {
+		indexes[i] = entry.Index
+	}
+	return indexes
+}
+
+func TestParseSmsStates(t *testing.T) {
+	states, err := parseSmsStates([]string{"Received", " sent"})
+	if err != nil || len(states) != 2 || !states[modemmanager.MmSmsStateReceived] || !states[modemmanager.MmSmsStateSent] {
+		t.Errorf("parseSmsStates = %v, %v", states, err)
+	}
+	if states, err := parseSmsStates(nil); states != nil || err != nil {
+		t.Errorf("parseSmsStates(nil) = %v, %v, want no filter", states, err)
+	}
+	if _, err := parseSmsStates([]string{"received", "read"}); err == nil {
+		t.Error("unknown state accepted")
+	}
+}
+
+func TestFilterSmsEntries(t *testing.T) {
+	entries := readSmsEntries(testMessages())
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter smsFilter
+		want   string
+	}{
+		{"none", smsFilter{}, "[0 1 2 3]"},
+		{"state", smsFilter{states: map[modemmanager.MMSmsState]bool{modemmanager.MmSmsStateSent: true}}, "[3]"},
+		{"from", smsFilter{from: "+49123456"}, "[3]"},
+		{"since", smsFilter{since: at}, "[1 2]"},
+		{"before", smsFilter{before: at}, "[0]"},
+		{"state and since", smsFilter{states: map[modemmanager.MMSmsState]bool{modemmanager.MmSmsStateReceived: true}, since: at.Add(time.Hour)}, "[2]"},
+	}
+	for _, test := range tests {
+		if got := fmt.Sprint(entryIndexes(filterSmsEntries(entries, test.filter))); got != test.want {
+			t.Errorf("%s: indexes = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSortSmsEntries(t *testing.T) {
+	entries := readSmsEntries(testMessages())
+	sortSmsEntries(entries, false)
+	if got := fmt.Sprint(entryIndexes(entries)); got != "[2 1 0 3]" {
+		t.Errorf("newest first = %s, want [2 1 0 3]", got)
+	}
+	sortSmsEntries(entries, true)
+	if got := fmt.Sprint(entryIndexes(entries)); got != "[0 1 2 3]" {
+		t.Errorf("oldest first = %s, want [0 1 2 3]", got)
+	}
+}
+
+func TestSelectSms(t *testing.T) {
+	messages := testMessages()
+	tests := []struct {
+		index int
+		path  string
+		want  int
+	}{
+		{1, "", 1},
+		{0, string(modemmanager.SmsObjectPathPrefix) + "12", 2},
+		{0, "13", 3},
+	}
+	for _, test := range tests {
+		sms, index, err := selectSms(messages, test.index, test.path)
+		if err != nil || index != test.want || sms != messages[test.want] {
+			t.Errorf("selectSms(%d, %q) = %d, %v, want %d", test.index, test.path, index, err, test.want)
+		}
+	}
+
+	for _, bad := range []struct {
+		index int
+		path  string
+	}{{4, ""}, {-1, ""}, {0, "9"}} {
+		if _, _, err := selectSms(messages, bad.index, bad.path); err == nil {
+			t.Errorf("selectSms(%d, %q) succeeded", bad.index, bad.path)
+		}
+	}
+	if _, _, err := selectSms(nil, 0, ""); err == nil {
+		t.Error("selectSms without messages succeeded")
+	}
+}
+
+func TestSmsDeleteFilter(t *testing.T) {
+	useFakeClock(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC))
+	defer func() { smsStates, smsOlderThan = nil, "" }()
+
+	smsStates, smsOlderThan = []string{"received"}, "30d"
+	filter, err := smsDeleteFilter()
+	if err != nil || !filter.states[modemmanager.MmSmsStateReceived] || !filter.before.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("smsDeleteFilter = %+v, %v", filter, err)
+	}
+	smsStates, smsOlderThan = nil, "soon"
+	if _, err := smsDeleteFilter(); err == nil {
+		t.Error("bad --older-than accepted")
+	}
+}
+
+func TestSmsPathFlag(t *testing.T) {
+	defer func() { modemPath, smsPath = "", "" }()
+	modem := string(modemmanager.ModemPathFromIndex(0))
+	for _, cmd := range []*cobra.Command{smsReadCmd, smsDeleteCmd} {
+		modemPath, smsPath = "", ""
+		if err := cmd.ParseFlags([]string{"-p", modem, "--sms-path", "3"}); err != nil {
+			t.Errorf("%s: %v", cmd.Name(), err)
+			continue
+		}
+		if modemPath != modem || smsPath != "3" {
+			t.Errorf("%s: modem path %q, SMS path %q, want %q and 3", cmd.Name(), modemPath, smsPath, modem)
+		}
+	}
+}
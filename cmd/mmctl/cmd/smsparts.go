@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SMS encodings as printed
+const (
+	smsEncodingGSM7 = "gsm-7"
+	smsEncodingUCS2 = "ucs-2"
+	smsEncoding8Bit = "8-bit"
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet, whose characters take one
+// septet. The escape character itself is left out.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension holds the characters of the extension table, which take an
+// escape septet and their own
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// Payload of a single message and of each part of a concatenated one, whose
+// user data header takes 6 bytes
+const (
+	gsm7Single    = 160 // septets
+	gsm7PerPart   = 153
+	ucs2Single    = 70 // UTF-16 code units
+	ucs2PerPart   = 67
+	dataSingle    = 140 // bytes
+	dataPerPart   = 134
+	smsPartsLimit = 255 // parts a concatenated message can have
+)
+
+// smsSegments describes how a message is split into parts
+type smsSegments struct {
+	Encoding string `json:"encoding"`
+	Units    int    `json:"units"` // septets, UTF-16 code units or bytes
+	Parts    int    `json:"parts"`
+}
+
+func (s smsSegments) String() string {
+	unit := map[string]string{smsEncodingGSM7: "septets", smsEncodingUCS2: "UTF-16 code units", smsEncoding8Bit: "bytes"}[s.Encoding]
+	return fmt.Sprintf("%d message parts (encoding %s, %d %s)", s.Parts, s.Encoding, s.Units, unit)
+}
+
+// gsm7Septets returns the septets text takes in the GSM 7 bit alphabet, or
+// false if it needs UCS-2
+func gsm7Septets(text string) ([]int, bool) {
+	var septets []int
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r):
+			septets = append(septets, 1)
+		case strings.ContainsRune(gsm7Extension, r):
+			septets = append(septets, 2)
+		default:
+			return nil, false
+		}
+	}
+	return septets, true
+}
+
+// ucs2Units returns the UTF-16 code units of each character of text;
+// characters outside the basic plane, such as most emoji, take a surrogate
+// pair
+func ucs2Units(text string) []int {
+	var units []int
+	for _, r := range text {
+		if r > 0xFFFF {
+			units = append(units, 2)
+		} else {
+			units = append(units, 1)
+		}
+	}
+	return units
+}
+
+// countParts packs characters of the given sizes into parts. Characters
+// are never split between parts, so a part may hold less than perPart.
+func countParts(sizes []int, single, perPart int) (units, parts int) {
+	for _, size := range sizes {
+		units += size
+	}
+	if units <= single {
+		return units, 1
+	}
+	used := 0
+	parts = 1
+	for _, size := range sizes {
+		if used+size > perPart {
+			parts++
+			used = 0
+		}
+		used += size
+	}
+	return units, parts
+}
+
+// countSmsSegments returns the encoding and parts of text: GSM 7 bit if all
+// characters are in its alphabet, else UCS-2
+func countSmsSegments(text string) smsSegments {
+	if septets, ok := gsm7Septets(text); ok {
+		units, parts := countParts(septets, gsm7Single, gsm7PerPart)
+		return smsSegments{Encoding: smsEncodingGSM7, Units: units, Parts: parts}
+	}
+	units, parts := countParts(ucs2Units(text), ucs2Single, ucs2PerPart)
+	return smsSegments{Encoding: smsEncodingUCS2, Units: units, Parts: parts}
+}
+
+// countDataSegments returns the parts of binary data
+func countDataSegments(data []byte) smsSegments {
+	sizes := make([]int, len(data))
+	for i := range sizes {
+		sizes[i] = 1
+	}
+	units, parts := countParts(sizes, dataSingle, dataPerPart)
+	return smsSegments{Encoding: smsEncoding8Bit, Units: units, Parts: parts}
+}
+
+// checkSmsParts refuses messages of several parts unless confirmed with
+// --yes or allowed by maxParts
+func checkSmsParts(segments smsSegments, confirmed bool, maxParts int) error {
+	switch {
+	case segments.Parts > smsPartsLimit:
+		return fmt.Errorf("message needs %d parts, at most %d can be concatenated", segments.Parts, smsPartsLimit)
+	case maxParts > 0 && segments.Parts > maxParts:
+		return fmt.Errorf("message needs %d parts, more than --max-parts %d", segments.Parts, maxParts)
+	case segments.Parts > 1 && !confirmed && maxParts == 0:
+		return fmt.Errorf("message needs %d parts, confirm with --yes or --max-parts %d", segments.Parts, segments.Parts)
+	}
+	return nil
+}
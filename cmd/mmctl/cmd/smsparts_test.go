@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountSmsSegments(t *testing.T) {
+	a := func(n int) string { return strings.Repeat("a", n) }
+	cyrillic := func(n int) string { return strings.Repeat("ж", n) }
+	const emoji = "😀"
+
+	tests := []struct {
+		name     string
+		text     string
+		encoding string
+		units    int
+		parts    int
+	}{
+		{"empty", "", smsEncodingGSM7, 0, 1},
+		{"single full", a(160), smsEncodingGSM7, 160, 1},
+		{"one over", a(161), smsEncodingGSM7, 161, 2},
+		{"two full parts", a(306), smsEncodingGSM7, 306, 2},
+		{"third part", a(307), smsEncodingGSM7, 307, 3},
+		{"accent outside the alphabet", "Grüße aus München, çà é", smsEncodingUCS2, 23, 1},
+		{"basic alphabet only", "Grüße aus Köln ÄÖÜ@£$¥èéùìò", smsEncodingGSM7, 27, 1},
+		{"extension counts twice", a(159) + "€", smsEncodingGSM7, 161, 2},
+		{"escape pair not split", a(152) + "{" + a(152), smsEncodingGSM7, 306, 3},
+		{"newlines", "a\r\nb", smsEncodingGSM7, 4, 1},
+		{"UCS-2 single full", cyrillic(70), smsEncodingUCS2, 70, 1},
+		{"UCS-2 one over", cyrillic(71), smsEncodingUCS2, 71, 2},
+		{"UCS-2 two full parts", cyrillic(134), smsEncodingUCS2, 134, 2},
+		{"UCS-2 third part", cyrillic(135), smsEncodingUCS2, 135, 3},
+		{"mixed script", "Hello ж", smsEncodingUCS2, 7, 1},
+		{"emoji take two units", strings.Repeat(emoji, 35), smsEncodingUCS2, 70, 1},
+		{"emoji over", strings.Repeat(emoji, 36), smsEncodingUCS2, 72, 2},
+		{"surrogate pair not split", cyrillic(66) + emoji + cyrillic(66), smsEncodingUCS2, 134, 3},
+		{"emoji in Latin text", a(69) + emoji, smsEncodingUCS2, 71, 2},
+		{"CJK", strings.Repeat("漢", 68), smsEncodingUCS2, 68, 1},
+	}
+	for _, tt := range tests {
+		got := countSmsSegments(tt.text)
+		if got.Encoding != tt.encoding || got.Units != tt.units || got.Parts != tt.parts {
+			t.Errorf("%s: countSmsSegments = %+v, want %s, %d units, %d parts", tt.name, got, tt.encoding, tt.units, tt.parts)
+		}
+	}
+}
+
+func TestCountDataSegments(t *testing.T) {
+	for size, parts := range map[int]int{1: 1, 140: 1, 141: 2, 268: 2, 269: 3} {
+		got := countDataSegments(make([]byte, size))
+		if got.Encoding != smsEncoding8Bit || got.Units != size || got.Parts != parts {
+			t.Errorf("countDataSegments(%d bytes) = %+v, want %d parts", size, got, parts)
+		}
+	}
+}
+
+func TestSmsSegmentsString(t *testing.T) {
+	got := countSmsSegments(strings.Repeat("ж", 71)).String()
+	if got != "2 message parts (encoding ucs-2, 71 UTF-16 code units)" {
+		t.Errorf("String = %q", got)
+	}
+}
+
+func TestCheckSmsParts(t *testing.T) {
+	one := smsSegments{Encoding: smsEncodingGSM7, Units: 10, Parts: 1}
+	three := smsSegments{Encoding: smsEncodingGSM7, Units: 400, Parts: 3}
+	tests := []struct {
+		segments  smsSegments
+		confirmed bool
+		maxParts  int
+		want      string
+	}{
+		{one, false, 0, ""},
+		{three, false, 0, "message needs 3 parts, confirm with --yes or --max-parts 3"},
+		{three, true, 0, ""},
+		{three, false, 3, ""},
+		{three, false, 2, "message needs 3 parts, more than --max-parts 2"},
+		{three, true, 2, "message needs 3 parts, more than --max-parts 2"},
+		{smsSegments{Parts: 256}, true, 0, "message needs 256 parts, at most 255 can be concatenated"},
+	}
+	for _, tt := range tests {
+		err := checkSmsParts(tt.segments, tt.confirmed, tt.maxParts)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("checkSmsParts(%d parts, %v, %d) = %q, want %q", tt.segments.Parts, tt.confirmed, tt.maxParts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+// smsWatchInterval is how often sms watch looks for its modem and re-reads
+// messages still being received
+const smsWatchInterval = 2 * time.Second
+
+// Environment of the --exec hook
+const (
+	envSmsNumber    = "MMCTL_SMS_NUMBER"
+	envSmsText      = "MMCTL_SMS_TEXT"
+	envSmsTimestamp = "MMCTL_SMS_TIMESTAMP"
+	envSmsPath      = "MMCTL_SMS_PATH"
+	envSmsModem     = "MMCTL_MODEM_PATH"
+)
+
+var (
+	smsWatchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Print incoming SMS messages as they arrive",
+		Long: `Wait for incoming SMS messages and print the sender, timestamp and text of
+each one once it is completely received. Messages received before the watch
+started are not printed; see 'mmctl sms list' for those.
+
+With --exec a command is run by sh for every message, with the message in the
+environment variables MMCTL_SMS_NUMBER, MMCTL_SMS_TEXT, MMCTL_SMS_TIMESTAMP,
+MMCTL_SMS_PATH and MMCTL_MODEM_PATH. With --delete the message is deleted
+from the modem after it was printed and the command, if any, succeeded.
+
+If the modem disappears, e.g. while it resets, the watch waits for it to come
+back on the same device and continues. Stop it with Ctrl-C.`,
+		Example: `  # Print incoming messages
+  mmctl sms watch -m 0
+
+  # Forward messages as JSON lines and keep the modem storage empty
+  mmctl sms watch -m 0 --json-lines --delete | ./forward.py
+
+  # Run a script for every message
+  mmctl sms watch -m 0 --exec 'logger -t sms "$MMCTL_SMS_NUMBER: $MMCTL_SMS_TEXT"'`,
+		Args: cobra.NoArgs,
+		RunE: runSmsWatch,
+	}
+
+	// Flags
+	smsWatchDelete    bool
+	smsWatchExec      string
+	smsWatchJSONLines bool
+)
+
+func init() {
+	smsCmd.AddCommand(smsWatchCmd)
+
+	smsWatchCmd.Flags().BoolVar(&smsWatchDelete, "delete", false, "Delete each message from the modem after handling it")
+	smsWatchCmd.Flags().StringVar(&smsWatchExec, "exec", "", "Command run by sh for every message, see the help for its environment")
+	smsWatchCmd.Flags().BoolVar(&smsWatchJSONLines, "json-lines", false, "Print one JSON object per message")
+}
+
+// receivedSms is a message printed by sms watch
+type receivedSms struct {
+	Time      time.Time       `json:"time"`
+	Modem     dbus.ObjectPath `json:"modem"`
+	Path      dbus.ObjectPath `json:"path"`
+	Number    string          `json:"number"`
+	Timestamp string          `json:"timestamp,omitempty"`
+	Text      string          `json:"text"`
+}
+
+func newReceivedSms(modem dbus.ObjectPath, sms modemmanager.Sms) receivedSms {
+	msg := receivedSms{Time: clk.Now(), Modem: modem, Path: sms.GetObjectPath()}
+	msg.Number, _ = sms.GetNumber()
+	msg.Text, _ = sms.GetText()
+	if timestamp, err := sms.GetTimestamp(); err == nil && !timestamp.IsZero() {
+		msg.Timestamp = timestamp.Format(time.RFC3339)
+	}
+	return msg
+}
+
+// formatReceivedSms prints a message on one line as
+// "+491234 at 2024-03-01T11:30:00+01:00: text"
+func formatReceivedSms(msg receivedSms) string {
+	at := ""
+	if msg.Timestamp != "" {
+		at = " at " + msg.Timestamp
+	}
+	return fmt.Sprintf("%s%s: %s", orDash(msg.Number), at, strings.ReplaceAll(msg.Text, "\n", " "))
+}
+
+// smsHookEnv returns the environment of the --exec hook for msg
+func smsHookEnv(msg receivedSms) []string {
+	return []string{
+		envSmsNumber + "=" + msg.Number,
+		envSmsText + "=" + msg.Text,
+		envSmsTimestamp + "=" + msg.Timestamp,
+		envSmsPath + "=" + string(msg.Path),
+		envSmsModem + "=" + string(msg.Modem),
+	}
+}
+
+// smsWatch is the subscription of sms watch to the messaging interface of
+// its modem
+type smsWatch struct {
+	messaging modemmanager.ModemMessaging
+	signals   <-chan *dbus.Signal
+	path      dbus.ObjectPath
+	// pending holds messages whose parts are still being received
+	pending map[dbus.ObjectPath]modemmanager.Sms
+}
+
+// attach subscribes to the messaging interface of modem, unless already
+// subscribed to it, and reports whether it is subscribed
+func (w *smsWatch) attach(modem modemmanager.Modem) bool {
+	if w.messaging != nil && w.path == modem.GetObjectPath() {
+		return true
+	}
+	w.detach()
+	messaging, err := modem.GetMessaging()
+	if err != nil {
+		return false
+	}
+	w.messaging = messaging
+	w.signals = messaging.SubscribeAdded()
+	w.path = modem.GetObjectPath()
+	w.pending = make(map[dbus.ObjectPath]modemmanager.Sms)
+	return true
+}
+
+// detach drops the subscription, if any
+func (w *smsWatch) detach() {
+	if w.messaging == nil {
+		return
+	}
+	w.messaging.Unsubscribe()
+	w.messaging, w.signals, w.path, w.pending = nil, nil, "", nil
+}
+
+// runSmsWatchLoop calls handle for every message received by the modem
+// returned by find until ctx is done. The modem is looked up again every
+// interval; while find returns none the watch waits for it, calling notify
+// when it is lost and found again. An error of handle ends the loop.
+func runSmsWatchLoop(ctx context.Context, find func() (modemmanager.Modem, error), interval time.Duration, notify func(string), handle func(modemmanager.ModemMessaging, modemmanager.Sms) error) error {
+	var w smsWatch
+	defer w.detach()
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	lost := false
+	for {
+		modem, err := find()
+		switch {
+		case err == nil && modem != nil && w.attach(modem):
+			if lost {
+				notify(fmt.Sprintf("modem back as %s, watching again", modem.GetObjectPath()))
+				lost = false
+			}
+		case !lost:
+			w.detach()
+			notify("modem gone, waiting for it to come back")
+			lost = true
+		}
+
+		// Messages being received are complete once in the received state
+		for path, sms := range w.pending {
+			state, err := sms.GetState()
+			if err != nil {
+				delete(w.pending, path)
+				continue
+			}
+			if state == modemmanager.MmSmsStateReceived {
+				delete(w.pending, path)
+				if err := handle(w.messaging, sms); err != nil {
+					return err
+				}
+			}
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C():
+				break wait
+			case sig := <-w.signals:
+				// The channel receives every signal routed to the connection
+				if sig.Path != w.path || sig.Name != monitorSmsAdded {
+					continue
+				}
+				sms, received, err := w.messaging.ParseAdded(sig)
+				if err != nil || !received {
+					continue
+				}
+				if state, err := sms.GetState(); err != nil || state != modemmanager.MmSmsStateReceived {
+					w.pending[sms.GetObjectPath()] = sms
+					continue
+				}
+				if err := handle(w.messaging, sms); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// findWatchedModem returns the modem at path or, once it has reset and is
+// back under a new path, the one on device. It returns nil while there is
+// none.
+func findWatchedModem(mm modemmanager.ModemManager, path dbus.ObjectPath, device string) (modemmanager.Modem, error) {
+	modems, err := mm.GetModems()
+	if err != nil {
+		return nil, err
+	}
+	for _, modem := range modems {
+		if modem.GetObjectPath() == path {
+			return modem, nil
+		}
+	}
+	if device == "" {
+		return nil, nil
+	}
+	for _, modem := range modems {
+		if d, err := modem.GetDevice(); err == nil && d == device {
+			return modem, nil
+		}
+	}
+	return nil, nil
+}
+
+// handleReceivedSms prints msg, runs the hook and deletes the message as
+// requested. Failures of the hook and of deleting are reported but do not
+// end the watch.
+func handleReceivedSms(ctx context.Context, messaging modemmanager.ModemMessaging, sms modemmanager.Sms, print func(receivedSms) error, hook string, remove bool) error {
+	msg := newReceivedSms(messaging.GetObjectPath(), sms)
+	if err := print(msg); err != nil {
+		return err
+	}
+
+	if hook != "" {
+		command := exec.CommandContext(ctx, "sh", "-c", hook)
+		command.Env = append(os.Environ(), smsHookEnv(msg)...)
+		command.Stdout = os.Stderr
+		command.Stderr = os.Stderr
+		if err := command.Run(); err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Warning: --exec failed for %s, keeping the message: %v\n", msg.Path, err)
+			}
+			return nil
+		}
+	}
+
+	if remove {
+		if err := messaging.Delete(sms); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to delete %s: %v\n", msg.Path, err)
+		}
+	}
+	return nil
+}
+
+func runSmsWatch(cmd *cobra.Command, args []string) error {
+	modem, err := getModem()
+	if err != nil {
+		return err
+	}
+	if _, err := modem.GetMessaging(); err != nil {
+		return fmt.Errorf("failed to get messaging interface: %w", err)
+	}
+	mm, err := connectModemManager()
+	if err != nil {
+		return err
+	}
+	path := modem.GetObjectPath()
+	device, _ := modem.GetDevice()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	encoder := json.NewEncoder(os.Stdout)
+	lines := smsWatchJSONLines || jsonOutput
+	print := func(msg receivedSms) error {
+		if lines {
+			return encoder.Encode(msg)
+		}
+		_, err := fmt.Println(formatReceivedSms(msg))
+		return err
+	}
+	notify := func(text string) {
+		fmt.Fprintf(os.Stderr, "%s %s\n", clk.Now().Format("15:04:05"), text)
+	}
+
+	if !lines {
+		fmt.Fprintf(os.Stderr, "Watching %s for incoming SMS, press Ctrl-C to stop.\n", path)
+	}
+	return runSmsWatchLoop(ctx, func() (modemmanager.Modem, error) {
+		return findWatchedModem(mm, path, device)
+	}, smsWatchInterval, notify, func(messaging modemmanager.ModemMessaging, sms modemmanager.Sms) error {
+		return handleReceivedSms(ctx, messaging, sms, print, smsWatchExec, smsWatchDelete)
+	})
+}
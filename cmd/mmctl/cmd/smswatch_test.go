@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestFormatReceivedSms(t *testing.T) {
+	msg := receivedSms{Number: "+491234", Timestamp: "2024-03-01T11:30:00+01:00", Text: "see\nyou"}
+	if got := formatReceivedSms(msg); got != "+491234 at 2024-03-01T11:30:00+01:00: see you" {
+		t.Errorf("formatReceivedSms = %q", got)
+	}
+	if got := formatReceivedSms(receivedSms{Text: "hi"}); got != "-: hi" {
+		t.Errorf("without number and timestamp: formatReceivedSms = %q", got)
+	}
+}
+
+func TestHandleReceivedSms(t *testing.T) {
+	messaging := mocks.NewMockModemMessaging()
+	sms := mocks.NewMockSms()
+	sms.NumberValue = "+491234"
+	messaging.MessagesValue = []modemmanager.Sms{sms}
+
+	var printed []receivedSms
+	print := func(msg receivedSms) error {
+		printed = append(printed, msg)
+		return nil
+	}
+
+	// A failing hook keeps the message
+	if err := handleReceivedSms(context.Background(), messaging, sms, print, `test "$MMCTL_SMS_NUMBER" = +499999`, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(messaging.MessagesValue) != 1 {
+		t.Error("message deleted although the hook failed")
+	}
+
+	if err := handleReceivedSms(context.Background(), messaging, sms, print, `test "$MMCTL_SMS_NUMBER" = +491234 && test "$MMCTL_SMS_TEXT" = Hello`, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(messaging.MessagesValue) != 0 {
+		t.Error("message not deleted after the hook succeeded")
+	}
+	if len(printed) != 2 || printed[0].Number != "+491234" || printed[0].Modem != messaging.ObjectPathValue {
+		t.Errorf("printed %+v", printed)
+	}
+}
+
+func TestRunSmsWatchLoop(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+
+	first, second := mocks.NewMockModem(), mocks.NewMockModem()
+	second.ObjectPathValue = modemmanager.ModemPathFromIndex(1)
+	messaging, back := mocks.NewMockModemMessaging(), mocks.NewMockModemMessaging()
+	back.ObjectPathValue = second.ObjectPathValue
+	messaging.SignalChan = make(chan *dbus.Signal, 10)
+	first.MessagingValue, second.MessagingValue = messaging, back
+
+	newSms := func(n string, state modemmanager.MMSmsState) *mocks.MockSms {
+		sms := mocks.NewMockSms()
+		sms.ObjectPathValue = dbus.ObjectPath(modemmanager.SmsObjectPathPrefix + n)
+		sms.StateValue = state
+		messaging.MessagesValue = append(messaging.MessagesValue, sms)
+		return sms
+	}
+	complete := newSms("1", modemmanager.MmSmsStateReceived)
+	created := newSms("2", modemmanager.MmSmsStateStored)
+	partial := newSms("3", modemmanager.MmSmsStateReceiving)
+	last := newSms("4", modemmanager.MmSmsStateReceived)
+
+	// The modem is there for two reads, gone for one and then back
+	reads := make(chan struct{}, 10)
+	n := 0
+	find := func() (modemmanager.Modem, error) {
+		n++
+		defer func() { reads <- struct{}{} }()
+		switch {
+		case n <= 2:
+			return first, nil
+		case n == 3:
+			return nil, nil
+		}
+		return second, nil
+	}
+	notes := make(chan string, 10)
+	handled := make(chan dbus.ObjectPath, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runSmsWatchLoop(ctx, find, time.Second, func(text string) { notes <- text }, func(_ modemmanager.ModemMessaging, sms modemmanager.Sms) error {
+			handled <- sms.GetObjectPath()
+			return nil
+		})
+	}()
+
+	<-reads
+	messaging.SignalChan <- messaging.AddedSignal(complete, true)
+	other := messaging.AddedSignal(complete, true)
+	other.Path = second.ObjectPathValue
+	messaging.SignalChan <- other
+	messaging.SignalChan <- messaging.AddedSignal(created, false)
+	messaging.SignalChan <- messaging.AddedSignal(partial, true)
+	messaging.SignalChan <- messaging.AddedSignal(last, true)
+	for _, want := range []*mocks.MockSms{complete, last} {
+		if got := <-handled; got != want.ObjectPathValue {
+			t.Errorf("handled %s, want %s", got, want.ObjectPathValue)
+		}
+	}
+
+	// The partial message is handled once complete
+	partial.StateValue = modemmanager.MmSmsStateReceived
+	fake.Advance(time.Second)
+	<-reads
+	if got := <-handled; got != partial.ObjectPathValue {
+		t.Errorf("handled %s, want the partial message", got)
+	}
+
+	fake.Advance(time.Second)
+	<-reads
+	if note := <-notes; !strings.Contains(note, "modem gone") {
+		t.Errorf("note = %q", note)
+	}
+	if messaging.Unsubscribed != 1 {
+		t.Errorf("lost modem unsubscribed %d times, want once", messaging.Unsubscribed)
+	}
+
+	fake.Advance(time.Second)
+	<-reads
+	if note := <-notes; !strings.Contains(note, "modem back as "+string(second.ObjectPathValue)) {
+		t.Errorf("note = %q", note)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("error = %v", err)
+	}
+	if back.Unsubscribed != 1 {
+		t.Errorf("Unsubscribe called %d times on exit, want once", back.Unsubscribed)
+	}
+	if len(handled) != 0 {
+		t.Errorf("%d more messages handled", len(handled))
+	}
+}
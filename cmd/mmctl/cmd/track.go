@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/gpx"
+)
+
+// locationRecorder writes the locations read by location follow in a file
+// format. Close must be called once following stops, also on errors, so that
+// everything recorded ends up in the output.
+type locationRecorder interface {
+	Add(location modemmanager.CurrentLocation) error
+	Close() error
+}
+
+// gpxRecorder records GPS fixes as a GPX track
+type gpxRecorder struct {
+	track        *gpx.Track
+	path         string    // rewritten every saveInterval, empty to write to out on Close
+	out          io.Writer // receives the track, or the summary if path is set
+	saveInterval time.Duration
+	lastSave     time.Time
+	unsaved      bool
+	last         modemmanager.GpsRawLocation
+}
+
+// newGpxRecorder returns a recorder writing to path, or to out if path is
+// empty. The file is created right away to fail early on a bad path.
+func newGpxRecorder(path string, out io.Writer, saveInterval time.Duration) (*gpxRecorder, error) {
+	r := &gpxRecorder{track: gpx.NewTrack("mmctl", ""), path: path, out: out, saveInterval: saveInterval}
+	if path != "" {
+		if err := r.save(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *gpxRecorder) Add(location modemmanager.CurrentLocation) error {
+	gps := location.GpsRaw
+	if gps.Latitude == 0 && gps.Longitude == 0 {
+		return nil
+	}
+	// Reads between two fixes return the last one again
+	if gps.Latitude == r.last.Latitude && gps.Longitude == r.last.Longitude &&
+		gps.Altitude == r.last.Altitude && gps.UtcTime.Equal(r.last.UtcTime) {
+		return nil
+	}
+	r.last = gps
+
+	point := gpx.Point{Latitude: gps.Latitude, Longitude: gps.Longitude, Time: gps.UtcTime}
+	// ModemManager leaves the altitude out without a 3D fix, which reads as 0
+	if gps.Altitude != 0 {
+		altitude := gps.Altitude
+		point.Elevation = &altitude
+	}
+	if err := r.track.Add(point); err != nil {
+		// Skip a bogus fix rather than stop recording
+		return nil
+	}
+	r.unsaved = true
+
+	if r.path != "" && clk.Now().Sub(r.lastSave) >= r.saveInterval {
+		return r.save()
+	}
+	return nil
+}
+
+func (r *gpxRecorder) save() error {
+	if err := r.track.WriteFile(r.path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", r.path, err)
+	}
+	r.lastSave = clk.Now()
+	r.unsaved = false
+	return nil
+}
+
+func (r *gpxRecorder) Close() error {
+	if r.path == "" {
+		_, err := r.track.WriteTo(r.out)
+		return err
+	}
+	if r.unsaved {
+		if err := r.save(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(r.out, "✓ Wrote %d track points to %s\n", r.track.Len(), r.path)
+	return nil
+}
+
+// nmeaRecorder dumps NMEA sentences, one per line. ModemManager keeps the
+// latest sentence of each type, so sentences that were already in the
+// previous read are skipped.
+type nmeaRecorder struct {
+	file    *os.File  // nil when writing to out
+	w       io.Writer // file or out
+	out     io.Writer // receives the sentences, or the summary if file is set
+	path    string
+	last    map[string]bool
+	written int
+}
+
+// newNmeaRecorder returns a recorder writing to a new file at path, or to out
+// if path is empty
+func newNmeaRecorder(path string, out io.Writer) (*nmeaRecorder, error) {
+	r := &nmeaRecorder{w: out, out: out, path: path}
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		r.file, r.w = file, file
+	}
+	return r, nil
+}
+
+func (r *nmeaRecorder) Add(location modemmanager.CurrentLocation) error {
+	current := make(map[string]bool, len(location.GpsNmea.NmeaSentences))
+	for _, sentence := range location.GpsNmea.NmeaSentences {
+		current[sentence] = true
+		if r.last[sentence] {
+			continue
+		}
+		if _, err := fmt.Fprintln(r.w, sentence); err != nil {
+			return fmt.Errorf("failed to write NMEA sentence: %w", err)
+		}
+		r.written++
+	}
+	r.last = current
+	return nil
+}
+
+func (r *nmeaRecorder) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	if err := r.file.Sync(); err != nil {
+		r.file.Close()
+		return fmt.Errorf("failed to write %s: %w", r.path, err)
+	}
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", r.path, err)
+	}
+	fmt.Fprintf(r.out, "✓ Wrote %d NMEA sentences to %s\n", r.written, r.path)
+	return nil
+}
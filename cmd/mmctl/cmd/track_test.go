@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+)
+
+func gpsFix(lat, lon, alt float64, utc time.Time) modemmanager.CurrentLocation {
+	return modemmanager.CurrentLocation{GpsRaw: modemmanager.GpsRawLocation{Latitude: lat, Longitude: lon, Altitude: alt, UtcTime: utc}}
+}
+
+func TestGpxRecorder(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	path := filepath.Join(t.TempDir(), "track.gpx")
+	var out bytes.Buffer
+	r, err := newGpxRecorder(path, &out, 30*time.Second)
+	if err != nil {
+		t.Fatalf("newGpxRecorder: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "<trkseg></trkseg>") {
+		t.Errorf("empty track not written on start:\n%s", data)
+	}
+
+	utc := time.Date(2026, 10, 16, 12, 30, 15, 0, time.UTC)
+	for _, location := range []modemmanager.CurrentLocation{
+		gpsFix(52.520008, 13.404954, 34.5, utc),
+		gpsFix(52.520008, 13.404954, 34.5, utc), // read again before the next fix
+		{},                                      // no fix
+		gpsFix(52.520112, 13.405201, 0, utc.Add(2*time.Second)),
+	} {
+		if err := r.Add(location); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "<trkpt") {
+		t.Errorf("file rewritten before --save-interval:\n%s", data)
+	}
+
+	fake.Advance(30 * time.Second)
+	if err := r.Add(gpsFix(52.5203, 13.4055, 35, utc.Add(4*time.Second))); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "<trkpt") != 3 {
+		t.Errorf("file not rewritten after --save-interval:\n%s", data)
+	}
+
+	if err := r.Add(gpsFix(52.5204, 13.4056, 35, utc.Add(6*time.Second))); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	if strings.Count(got, "<trkpt") != 4 {
+		t.Errorf("file not rewritten on Close:\n%s", got)
+	}
+	if !strings.Contains(got, `<trkpt lat="52.520112" lon="13.405201">
+        <time>2026-10-16T12:30:17Z</time>`) {
+		t.Errorf("fix without altitude written with an elevation:\n%s", got)
+	}
+	if out.String() != "✓ Wrote 4 track points to "+path+"\n" {
+		t.Errorf("summary = %q", out.String())
+	}
+
+	if _, err := newGpxRecorder(filepath.Join(t.TempDir(), "missing", "track.gpx"), &out, time.Second); err == nil {
+		t.Error("newGpxRecorder succeeded for a missing directory")
+	}
+}
+
+func TestGpxRecorderStdout(t *testing.T) {
+	var out bytes.Buffer
+	r, err := newGpxRecorder("", &out, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Add(gpsFix(52.520008, 13.404954, 34.5, time.Time{}))
+	if out.Len() != 0 {
+		t.Errorf("track written before Close: %q", out.String())
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `<trkpt lat="52.520008" lon="13.404954">`) || !strings.HasSuffix(out.String(), "</gpx>\n") {
+		t.Errorf("Close wrote\n%s", out.String())
+	}
+}
+
+func TestNmeaRecorder(t *testing.T) {
+	nmea := func(sentences ...string) modemmanager.CurrentLocation {
+		return modemmanager.CurrentLocation{GpsNmea: modemmanager.GpsNmeaLocation{NmeaSentences: sentences}}
+	}
+	gga1 := "$GPGGA,123015.00,5231.2005,N,01324.2973,E,1,08,0.9,34.5,M,46.9,M,,*5C"
+	gga2 := "$GPGGA,123017.00,5231.2067,N,01324.3121,E,1,08,0.9,34.6,M,46.9,M,,*5A"
+	rmc := "$GPRMC,123015.00,A,5231.2005,N,01324.2973,E,0.0,0.0,161026,,,A*6E"
+
+	path := filepath.Join(t.TempDir(), "drive.nmea")
+	var out bytes.Buffer
+	r, err := newNmeaRecorder(path, &out)
+	if err != nil {
+		t.Fatalf("newNmeaRecorder: %v", err)
+	}
+	for _, location := range []modemmanager.CurrentLocation{nmea(gga1, rmc), nmea(gga1, rmc), nmea(gga2, rmc), {}} {
+		if err := r.Add(location); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if want := gga1 + "\n" + rmc + "\n" + gga2 + "\n"; string(data) != want {
+		t.Errorf("file content\n%s\nwant\n%s", data, want)
+	}
+	if out.String() != "✓ Wrote 3 NMEA sentences to "+path+"\n" {
+		t.Errorf("summary = %q", out.String())
+	}
+
+	out.Reset()
+	r, _ = newNmeaRecorder("", &out)
+	r.Add(nmea(gga1))
+	if err := r.Close(); err != nil || out.String() != gga1+"\n" {
+		t.Errorf("stdout output = %q, %v", out.String(), err)
+	}
+}
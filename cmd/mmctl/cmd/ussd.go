@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ussdCmd = &cobra.Command{
+		Use:   "ussd",
+		Short: "Run USSD sessions",
+		Long: `Send USSD codes, such as prepaid balance queries, and answer the network.
+
+Replies are printed as decoded by ModemManager, whether the network sent them
+GSM-7 or UCS-2 encoded. Carriers sometimes never answer; after --timeout the
+session is cancelled so that the next one can start.`,
+	}
+
+	ussdInitiateCmd = &cobra.Command{
+		Use:   "initiate <command>",
+		Short: "Start a USSD session",
+		Example: `  # Query the prepaid balance
+  mmctl ussd initiate -m 0 "*100#"`,
+		Args: cobra.ExactArgs(1),
+		RunE: runUssdInitiate,
+	}
+
+	ussdRespondCmd = &cobra.Command{
+		Use:   "respond <response>",
+		Short: "Answer the network in an ongoing USSD session",
+		Example: `  # Choose menu entry 1
+  mmctl ussd respond -m 0 "1"`,
+		Args: cobra.ExactArgs(1),
+		RunE: runUssdRespond,
+	}
+
+	ussdCancelCmd = &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel the ongoing USSD session",
+		Example: `  # End the session of modem 0
+  mmctl ussd cancel -m 0`,
+		RunE: runUssdCancel,
+	}
+
+	ussdStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Show the USSD session state",
+		Long: `Show the USSD session state and any pending network notification or
+network request.`,
+		Example: `  # Show the session of modem 0
+  mmctl ussd status -m 0 --json`,
+		RunE: runUssdStatus,
+	}
+
+	// Flags
+	ussdTimeout time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(ussdCmd)
+	ussdCmd.AddCommand(ussdInitiateCmd)
+	ussdCmd.AddCommand(ussdRespondCmd)
+	ussdCmd.AddCommand(ussdCancelCmd)
+	ussdCmd.AddCommand(ussdStatusCmd)
+
+	ussdCmd.PersistentFlags().DurationVar(&ussdTimeout, "timeout", 30*time.Second, "Maximum time to wait for the network")
+}
+
+// ussdResult is the outcome of initiate and respond
+type ussdResult struct {
+	Reply            string `json:"reply"`
+	State            string `json:"state"`
+	ResponseExpected bool   `json:"response_expected"`
+}
+
+// getUssd returns the USSD interface of the selected modem
+func getUssd() (modemmanager.Ussd, error) {
+	modem, err := getModem()
+	if err != nil {
+		return nil, err
+	}
+	modem3gpp, err := modem.Get3gpp()
+	if err != nil {
+		return nil, fmt.Errorf("modem does not support USSD: %w", err)
+	}
+	ussd, err := modem3gpp.GetUssd()
+	if err != nil {
+		return nil, fmt.Errorf("modem does not support USSD: %w", err)
+	}
+	return ussd, nil
+}
+
+func runUssdInitiate(cmd *cobra.Command, args []string) error {
+	ussd, err := getUssd()
+	if err != nil {
+		return err
+	}
+	result, err := ussdExchange(ussd, "initiate", func() (string, error) { return ussd.Initiate(args[0]) }, ussdTimeout)
+	if err != nil {
+		return err
+	}
+	return printUssdResult(result)
+}
+
+func runUssdRespond(cmd *cobra.Command, args []string) error {
+	ussd, err := getUssd()
+	if err != nil {
+		return err
+	}
+	result, err := ussdExchange(ussd, "respond", func() (string, error) { return ussd.Respond(args[0]) }, ussdTimeout)
+	if err != nil {
+		return err
+	}
+	return printUssdResult(result)
+}
+
+func runUssdCancel(cmd *cobra.Command, args []string) error {
+	ussd, err := getUssd()
+	if err != nil {
+		return err
+	}
+	if err := ussd.Cancel(); err != nil {
+		return fmt.Errorf("failed to cancel USSD session: %w", err)
+	}
+	fmt.Println("✓ USSD session cancelled")
+	return nil
+}
+
+func runUssdStatus(cmd *cobra.Command, args []string) error {
+	ussd, err := getUssd()
+	if err != nil {
+		return err
+	}
+	state, err := ussd.GetState()
+	if err != nil {
+		return fmt.Errorf("failed to get USSD state: %w", err)
+	}
+	notification, _ := ussd.GetNetworkNotification()
+	request, _ := ussd.GetNetworkRequest()
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"state":                state.String(),
+			"response_expected":    state == modemmanager.MmModem3gppUssdSessionStateUserResponse,
+			"network_notification": notification,
+			"network_request":      request,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "State:\t%s\n", state)
+	if notification != "" {
+		fmt.Fprintf(w, "Notification:\t%s\n", notification)
+	}
+	if request != "" {
+		fmt.Fprintf(w, "Request:\t%s\n", request)
+	}
+	return nil
+}
+
+// ussdExchange sends a command or response with call and reads the session
+// state after the reply. Without a reply within timeout it cancels the
+// session, which would otherwise block the next one.
+func ussdExchange(ussd modemmanager.Ussd, action string, call func() (string, error), timeout time.Duration) (ussdResult, error) {
+	reply, err := withTimeout(timeout, call)
+	switch {
+	case errors.Is(err, errCallTimeout):
+		msg := fmt.Sprintf("no reply from the network within %s", timeout)
+		if _, err := withTimeout(5*time.Second, func() (struct{}, error) { return struct{}{}, ussd.Cancel() }); err != nil {
+			msg += fmt.Sprintf(", cancelling the session failed: %v", err)
+		} else {
+			msg += ", session cancelled"
+		}
+		return ussdResult{}, &exitError{code: exitTimeout, err: errors.New(msg)}
+	case err != nil:
+		return ussdResult{}, fmt.Errorf("USSD %s failed: %w", action, err)
+	}
+
+	result := ussdResult{Reply: reply, State: modemmanager.MmModem3gppUssdSessionStateUnknown.String()}
+	if state, err := ussd.GetState(); err == nil {
+		result.State = state.String()
+		result.ResponseExpected = state == modemmanager.MmModem3gppUssdSessionStateUserResponse
+	}
+	return result, nil
+}
+
+func printUssdResult(result ussdResult) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	fmt.Println(result.Reply)
+	if result.ResponseExpected {
+		fmt.Println("\nThe network expects a response, send it with: mmctl ussd respond <response>")
+	}
+	return nil
+}
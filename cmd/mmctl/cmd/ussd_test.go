@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestUssdExchange(t *testing.T) {
+	ussd := mocks.NewMockUssd()
+	ussd.StateValue = modemmanager.MmModem3gppUssdSessionStateUserResponse
+
+	result, err := ussdExchange(ussd, "initiate", func() (string, error) { return ussd.Initiate("*100#") }, time.Minute)
+	want := ussdResult{Reply: "Your balance is 10.00 EUR", State: "UserResponse", ResponseExpected: true}
+	if err != nil || result != want {
+		t.Errorf("ussdExchange = %+v, %v, want %+v", result, err, want)
+	}
+
+	ussd.StateValue = modemmanager.MmModem3gppUssdSessionStateIdle
+	ussd.RespondReply = "Bye"
+	result, err = ussdExchange(ussd, "respond", func() (string, error) { return ussd.Respond("1") }, time.Minute)
+	if want := (ussdResult{Reply: "Bye", State: "Idle"}); err != nil || result != want {
+		t.Errorf("ussdExchange = %+v, %v, want %+v", result, err, want)
+	}
+
+	ussd.InitiateError = errors.New("operation not allowed")
+	_, err = ussdExchange(ussd, "initiate", func() (string, error) { return ussd.Initiate("*100#") }, time.Minute)
+	if err == nil || err.Error() != "USSD initiate failed: operation not allowed" {
+		t.Errorf("ussdExchange error = %v", err)
+	}
+}
+
+func TestUssdExchangeTimeout(t *testing.T) {
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	ussd := mocks.NewMockUssd()
+	done := make(chan error, 1)
+	go func() {
+		_, err := ussdExchange(ussd, "initiate", func() (string, error) { select {} }, 30*time.Second)
+		done <- err
+	}()
+	fake.BlockUntilTimers(1)
+	fake.Advance(30 * time.Second)
+
+	err := <-done
+	var exit *exitError
+	if !errors.As(err, &exit) || exit.code != exitTimeout || err.Error() != "no reply from the network within 30s, session cancelled" {
+		t.Errorf("ussdExchange error = %v, want a timeout", err)
+	}
+	if !ussd.Cancelled {
+		t.Error("the session was not cancelled after the timeout")
+	}
+}
@@ -0,0 +1,496 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	voiceCmd = &cobra.Command{
+		Use:   "voice",
+		Short: "Place and manage voice calls",
+		Long: `Place, answer and hang up voice calls and send DTMF tones.
+
+Calls are selected with --call, given as an object path or as the index
+printed by 'mmctl voice calls'. Without --call the command acts on the only
+call it applies to.`,
+	}
+
+	voiceCallsCmd = &cobra.Command{
+		Use:   "calls",
+		Short: "List calls",
+		Example: `  # List the calls of modem 0
+  mmctl voice calls -m 0 --json`,
+		RunE: runVoiceCalls,
+	}
+
+	voiceDialCmd = &cobra.Command{
+		Use:   "dial",
+		Short: "Place a call",
+		Long: `Place a call to --number and print its state changes until it is answered
+or ends.
+
+If the call is not answered within --timeout it is hung up. Ctrl-C hangs up
+the call as well, rather than leaving it ringing.`,
+		Example: `  # Call a number and wait up to a minute for an answer
+  mmctl voice dial -m 0 --number +4915112345678`,
+		RunE: runVoiceDial,
+	}
+
+	voiceHangupCmd = &cobra.Command{
+		Use:   "hangup",
+		Short: "Hang up a call",
+		Example: `  # Hang up the call in progress
+  mmctl voice hangup -m 0
+
+  # Hang up all calls
+  mmctl voice hangup -m 0 --all`,
+		RunE: runVoiceHangup,
+	}
+
+	voiceAcceptCmd = &cobra.Command{
+		Use:   "accept",
+		Short: "Answer a ringing incoming call",
+		Example: `  # Answer the incoming call
+  mmctl voice accept -m 0`,
+		RunE: runVoiceAccept,
+	}
+
+	voiceDtmfCmd = &cobra.Command{
+		Use:   "dtmf",
+		Short: "Send DTMF tones in an active call",
+		Example: `  # Choose menu entries in a voice menu
+  mmctl voice dtmf -m 0 --call 0 --tones "123#"`,
+		RunE: runVoiceDtmf,
+	}
+
+	// Flags
+	voiceNumber  string
+	voiceTimeout time.Duration
+	voiceCall    string
+	voiceAll     bool
+	voiceTones   string
+)
+
+func init() {
+	rootCmd.AddCommand(voiceCmd)
+	voiceCmd.AddCommand(voiceCallsCmd)
+	voiceCmd.AddCommand(voiceDialCmd)
+	voiceCmd.AddCommand(voiceHangupCmd)
+	voiceCmd.AddCommand(voiceAcceptCmd)
+	voiceCmd.AddCommand(voiceDtmfCmd)
+
+	voiceDialCmd.Flags().StringVar(&voiceNumber, "number", "", "Number to call")
+	voiceDialCmd.Flags().DurationVar(&voiceTimeout, "timeout", 60*time.Second, "Maximum time to wait for an answer")
+	voiceDialCmd.MarkFlagRequired("number")
+
+	voiceHangupCmd.Flags().StringVar(&voiceCall, "call", "", "Call to hang up (object path or index)")
+	voiceHangupCmd.Flags().BoolVar(&voiceAll, "all", false, "Hang up all calls")
+	voiceHangupCmd.MarkFlagsMutuallyExclusive("call", "all")
+
+	voiceAcceptCmd.Flags().StringVar(&voiceCall, "call", "", "Call to answer (object path or index)")
+
+	voiceDtmfCmd.Flags().StringVar(&voiceCall, "call", "", "Call to send the tones in (object path or index)")
+	voiceDtmfCmd.Flags().StringVar(&voiceTones, "tones", "", "DTMF tones to send (0-9, A-D, * and #)")
+	voiceDtmfCmd.MarkFlagRequired("tones")
+}
+
+// voiceCallInfo is a call as printed by voice calls
+type voiceCallInfo struct {
+	Path        string `json:"path"`
+	Direction   string `json:"direction"`
+	Number      string `json:"number"`
+	State       string `json:"state"`
+	StateReason string `json:"state_reason"`
+}
+
+// callStateNames are the call states as printed for humans
+var callStateNames = map[modemmanager.MMCallState]string{
+	modemmanager.MmCallStateUnknown:    "unknown",
+	modemmanager.MmCallStateDialing:    "dialing",
+	modemmanager.MmCallStateRingingOut: "ringing",
+	modemmanager.MmCallStateRingingIn:  "ringing-in",
+	modemmanager.MmCallStateActive:     "active",
+	modemmanager.MmCallStateHeld:       "held",
+	modemmanager.MmCallStateWaiting:    "waiting",
+	modemmanager.MmCallStateTerminated: "terminated",
+}
+
+// callReasonTexts describe why a call changed state
+var callReasonTexts = map[modemmanager.MMCallStateReason]string{
+	modemmanager.MmCallStateReasonOutgoingStarted:  "outgoing call started",
+	modemmanager.MmCallStateReasonIncomingNew:      "new incoming call",
+	modemmanager.MmCallStateReasonAccepted:         "answered",
+	modemmanager.MmCallStateReasonTerminated:       "hung up",
+	modemmanager.MmCallStateReasonRefusedOrBusy:    "refused or busy",
+	modemmanager.MmCallStateReasonError:            "wrong number or network error",
+	modemmanager.MmCallStateReasonAudioSetupFailed: "audio setup failed",
+	modemmanager.MmCallStateReasonTransferred:      "transferred",
+	modemmanager.MmCallStateReasonDeflected:        "deflected",
+}
+
+func callStateName(state modemmanager.MMCallState) string {
+	if name, ok := callStateNames[state]; ok {
+		return name
+	}
+	return strings.ToLower(state.String())
+}
+
+// errNoAnswer is returned by followCall when the call was not answered in time
+var errNoAnswer = errors.New("no answer")
+
+// getVoice returns the Voice interface of the selected modem and its calls.
+// The interface getters do not check whether the modem has the interface,
+// so listing the calls doubles as that check.
+func getVoice() (modemmanager.ModemVoice, []modemmanager.Call, error) {
+	modem, err := getModem()
+	if err != nil {
+		return nil, nil, err
+	}
+	voice, err := modem.GetVoice()
+	if err != nil {
+		return nil, nil, fmt.Errorf("voice not supported by this modem: %w", err)
+	}
+	calls, err := voice.ListCalls()
+	if err != nil {
+		if voiceUnsupported(err) {
+			return nil, nil, errors.New("voice not supported by this modem")
+		}
+		return nil, nil, fmt.Errorf("failed to list calls: %w", err)
+	}
+	return voice, calls, nil
+}
+
+// voiceUnsupported reports whether err says the modem lacks the Voice
+// interface, as data-only modems do
+func voiceUnsupported(err error) bool {
+	switch dbusErrorName(err) {
+	case "org.freedesktop.DBus.Error.UnknownMethod",
+		"org.freedesktop.DBus.Error.UnknownInterface",
+		"org.freedesktop.DBus.Error.UnknownObject",
+		modemmanager.ErrorCorePrefix + "Unsupported":
+		return true
+	}
+	return false
+}
+
+func runVoiceCalls(cmd *cobra.Command, args []string) error {
+	_, calls, err := getVoice()
+	if err != nil {
+		return err
+	}
+
+	infos := make([]voiceCallInfo, len(calls))
+	for i, call := range calls {
+		info := voiceCallInfo{Path: string(call.GetObjectPath())}
+		if direction, err := call.GetDirection(); err == nil {
+			info.Direction = direction.String()
+		}
+		info.Number, _ = call.GetNumber()
+		if state, err := call.GetState(); err == nil {
+			info.State = state.String()
+		}
+		if reason, err := call.GetStateReason(); err == nil {
+			info.StateReason = reason.String()
+		}
+		infos[i] = info
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(infos)
+	}
+
+	if len(calls) == 0 {
+		fmt.Println("No calls")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "Index\tPath\tDirection\tNumber\tState\n")
+	fmt.Fprintf(w, "-----\t----\t---------\t------\t-----\n")
+	for i, call := range calls {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i, infos[i].Path, orDash(strings.ToLower(infos[i].Direction)), orDash(infos[i].Number), describeCallState(call))
+	}
+	return nil
+}
+
+func runVoiceDial(cmd *cobra.Command, args []string) error {
+	if err := validateDialNumber(voiceNumber); err != nil {
+		return err
+	}
+	voice, _, err := getVoice()
+	if err != nil {
+		return err
+	}
+	call, err := voice.CreateCall(voiceNumber)
+	if err != nil {
+		return fmt.Errorf("failed to create call: %w", err)
+	}
+
+	// Ctrl-C hangs up the call, restore the default behaviour after the
+	// first one so that a second one exits right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	signals := call.SubscribeStateChanged()
+	defer call.Unsubscribe()
+	if err := call.Start(); err != nil {
+		// Do not leave the unused call object behind
+		voice.DeleteCall(call)
+		return fmt.Errorf("failed to start call to %s: %w", voiceNumber, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	err = followCall(ctx, call, signals, voiceTimeout, time.Second, func(state modemmanager.MMCallState, reason modemmanager.MMCallStateReason) {
+		if jsonOutput {
+			encoder.Encode(map[string]string{"state": state.String(), "state_reason": reason.String()})
+			return
+		}
+		line := callStateName(state)
+		if text := callReasonTexts[reason]; text != "" {
+			line += " (" + text + ")"
+		}
+		fmt.Printf("%s  %s\n", clk.Now().Format("15:04:05"), line)
+	})
+	path := call.GetObjectPath()
+	switch {
+	case errors.Is(err, context.Canceled):
+		if err := call.Hangup(); err != nil {
+			return fmt.Errorf("interrupted, failed to hang up call %s: %w", path, err)
+		}
+		return fmt.Errorf("interrupted, hung up call %s", path)
+	case errors.Is(err, errNoAnswer):
+		msg := fmt.Sprintf("no answer within %s", voiceTimeout)
+		if err := call.Hangup(); err != nil {
+			msg += fmt.Sprintf(", hanging up call %s failed: %v", path, err)
+		} else {
+			msg += ", call hung up"
+		}
+		return &exitError{code: exitTimeout, err: errors.New(msg)}
+	case err != nil:
+		return err
+	}
+
+	if !jsonOutput {
+		fmt.Printf("✓ Call to %s answered, hang up with: mmctl voice hangup --call %s\n", voiceNumber, path)
+	}
+	return nil
+}
+
+func runVoiceHangup(cmd *cobra.Command, args []string) error {
+	voice, calls, err := getVoice()
+	if err != nil {
+		return err
+	}
+	if voiceAll {
+		if err := voice.HangupAll(); err != nil {
+			return fmt.Errorf("failed to hang up calls: %w", err)
+		}
+		fmt.Println("✓ Hung up all calls")
+		return nil
+	}
+
+	call, err := selectCall(calls, voiceCall, "in progress", func(state modemmanager.MMCallState) bool {
+		return state != modemmanager.MmCallStateTerminated
+	})
+	if err != nil {
+		return err
+	}
+	if err := call.Hangup(); err != nil {
+		return fmt.Errorf("failed to hang up call %s: %w", call.GetObjectPath(), err)
+	}
+	fmt.Printf("✓ Hung up call %s\n", call.GetObjectPath())
+	return nil
+}
+
+func runVoiceAccept(cmd *cobra.Command, args []string) error {
+	_, calls, err := getVoice()
+	if err != nil {
+		return err
+	}
+	ringing := func(state modemmanager.MMCallState) bool {
+		return state == modemmanager.MmCallStateRingingIn
+	}
+	call, err := selectCall(calls, voiceCall, "ringing", ringing)
+	if err != nil {
+		return err
+	}
+	if state, err := call.GetState(); err == nil && !ringing(state) {
+		return fmt.Errorf("call %s is %s, only ringing incoming calls can be accepted", call.GetObjectPath(), callStateName(state))
+	}
+	if err := call.Accept(); err != nil {
+		return fmt.Errorf("failed to accept call %s: %w", call.GetObjectPath(), err)
+	}
+	number, _ := call.GetNumber()
+	fmt.Printf("✓ Accepted call from %s\n", orDash(number))
+	return nil
+}
+
+func runVoiceDtmf(cmd *cobra.Command, args []string) error {
+	tones, err := validateDtmfTones(voiceTones)
+	if err != nil {
+		return err
+	}
+	_, calls, err := getVoice()
+	if err != nil {
+		return err
+	}
+	active := func(state modemmanager.MMCallState) bool {
+		return state == modemmanager.MmCallStateActive
+	}
+	call, err := selectCall(calls, voiceCall, "active", active)
+	if err != nil {
+		return err
+	}
+	if state, err := call.GetState(); err == nil && !active(state) {
+		return fmt.Errorf("call %s is %s, DTMF tones need an active call", call.GetObjectPath(), callStateName(state))
+	}
+	if err := call.SendDtmf(tones); err != nil {
+		return fmt.Errorf("failed to send DTMF tones: %w", err)
+	}
+	fmt.Printf("✓ Sent DTMF tones %s\n", tones)
+	return nil
+}
+
+// validateDialNumber accepts digits, * and #, with an optional leading +
+func validateDialNumber(number string) error {
+	digits := strings.TrimPrefix(number, "+")
+	valid := digits != ""
+	for _, c := range digits {
+		valid = valid && (c >= '0' && c <= '9' || c == '*' || c == '#')
+	}
+	if !valid {
+		return fmt.Errorf("invalid number %q (digits, * and # with an optional leading +)", number)
+	}
+	return nil
+}
+
+// validateDtmfTones returns tones in upper case if they are valid DTMF tones
+func validateDtmfTones(tones string) (string, error) {
+	tones = strings.ToUpper(tones)
+	valid := tones != ""
+	for _, c := range tones {
+		valid = valid && (c >= '0' && c <= '9' || c >= 'A' && c <= 'D' || c == '*' || c == '#')
+	}
+	if !valid {
+		return "", fmt.Errorf("invalid DTMF tones %q (0-9, A-D, * and #)", tones)
+	}
+	return tones, nil
+}
+
+// describeCallState returns the state of call as printed in tables
+func describeCallState(call modemmanager.Call) string {
+	state, err := call.GetState()
+	if err != nil {
+		return "-"
+	}
+	return callStateName(state)
+}
+
+// selectCall returns the call given by spec, an object path or index. With
+// an empty spec it returns the only call whose state matches, described by
+// what in errors.
+func selectCall(calls []modemmanager.Call, spec, what string, match func(modemmanager.MMCallState) bool) (modemmanager.Call, error) {
+	list := func(calls []modemmanager.Call) string {
+		lines := make([]string, len(calls))
+		for i, call := range calls {
+			number, _ := call.GetNumber()
+			lines[i] = fmt.Sprintf("  %d: %s %s %s", i, call.GetObjectPath(), orDash(number), describeCallState(call))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if spec != "" {
+		if len(calls) == 0 {
+			return nil, errors.New("no calls found")
+		}
+		if index, err := strconv.Atoi(spec); err == nil {
+			if index < 0 || index >= len(calls) {
+				return nil, fmt.Errorf("call index %d out of range (0-%d)", index, len(calls)-1)
+			}
+			return calls[index], nil
+		}
+		for _, call := range calls {
+			if string(call.GetObjectPath()) == spec {
+				return call, nil
+			}
+		}
+		return nil, fmt.Errorf("no call at path %s, available calls:\n%s", spec, list(calls))
+	}
+
+	var matches []modemmanager.Call
+	for _, call := range calls {
+		if state, err := call.GetState(); err == nil && match(state) {
+			matches = append(matches, call)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no call is %s", what)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("%d calls are %s, select one with --call:\n%s", len(matches), what, list(calls))
+}
+
+// followCall reports every state change of call until it is answered. It
+// re-reads the state on every signal and interval tick, and returns
+// errNoAnswer after timeout, ctx.Err() once ctx is done, and an error with
+// the reason if the call ends unanswered.
+func followCall(ctx context.Context, call modemmanager.Call, signals <-chan *dbus.Signal, timeout, interval time.Duration, report func(modemmanager.MMCallState, modemmanager.MMCallStateReason)) error {
+	deadline := clk.After(timeout)
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := modemmanager.MMCallState(-1)
+	for {
+		if state, err := call.GetState(); err == nil && state != last {
+			last = state
+			reason, _ := call.GetStateReason()
+			report(state, reason)
+			switch state {
+			case modemmanager.MmCallStateActive:
+				return nil
+			case modemmanager.MmCallStateTerminated:
+				text := callReasonTexts[reason]
+				if text == "" {
+					text = "hung up"
+				}
+				return fmt.Errorf("call ended before it was answered: %s", text)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return errNoAnswer
+		case _, ok := <-signals:
+			if !ok {
+				signals = nil
+			}
+		case <-ticker.C():
+		}
+	}
+}
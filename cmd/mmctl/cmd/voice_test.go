@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func testCalls(states ...modemmanager.MMCallState) []modemmanager.Call {
+	calls := make([]modemmanager.Call, len(states))
+	for i, state := range states {
+		call := mocks.NewMockCall()
+		call.ObjectPathValue = modemmanager.CallObjectPathPrefix + dbus.ObjectPath(string(rune('3'+i)))
+		call.StateValue = state
+		calls[i] = call
+	}
+	return calls
+}
+
+func TestSelectCall(t *testing.T) {
+	const (
+		active     = modemmanager.MmCallStateActive
+		ringingIn  = modemmanager.MmCallStateRingingIn
+		terminated = modemmanager.MmCallStateTerminated
+	)
+	isActive := func(state modemmanager.MMCallState) bool { return state == active }
+
+	tests := []struct {
+		name     string
+		calls    []modemmanager.Call
+		spec     string
+		wantPath dbus.ObjectPath
+		wantErr  string
+	}{
+		{name: "only active call", calls: testCalls(terminated, active, ringingIn), wantPath: "/org/freedesktop/ModemManager1/Call/4"},
+		{name: "index", calls: testCalls(terminated, active), spec: "0", wantPath: "/org/freedesktop/ModemManager1/Call/3"},
+		{name: "path", calls: testCalls(terminated, active), spec: "/org/freedesktop/ModemManager1/Call/4", wantPath: "/org/freedesktop/ModemManager1/Call/4"},
+		{name: "none active", calls: testCalls(terminated, ringingIn), wantErr: "no call is active"},
+		{name: "no calls", spec: "0", wantErr: "no calls found"},
+		{name: "index out of range", calls: testCalls(active), spec: "1", wantErr: "call index 1 out of range (0-0)"},
+		{
+			name: "unknown path", calls: testCalls(active), spec: "/org/freedesktop/ModemManager1/Call/9",
+			wantErr: "no call at path /org/freedesktop/ModemManager1/Call/9, available calls:\n  0: /org/freedesktop/ModemManager1/Call/3 +1234567890 active",
+		},
+		{
+			name: "several active", calls: testCalls(active, active),
+			wantErr: "2 calls are active, select one with --call:\n  0: /org/freedesktop/ModemManager1/Call/3 +1234567890 active\n  1: ",
+		},
+	}
+	for _, tt := range tests {
+		call, err := selectCall(tt.calls, tt.spec, "active", isActive)
+		if tt.wantErr != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+		} else if call.GetObjectPath() != tt.wantPath {
+			t.Errorf("%s: selected %s, want %s", tt.name, call.GetObjectPath(), tt.wantPath)
+		}
+	}
+}
+
+func TestValidateDialNumber(t *testing.T) {
+	for _, number := range []string{"+4915112345678", "112", "*100#"} {
+		if err := validateDialNumber(number); err != nil {
+			t.Errorf("validateDialNumber(%q) = %v", number, err)
+		}
+	}
+	for _, number := range []string{"", "+", "0151 1234", "+49-151", "++49"} {
+		if err := validateDialNumber(number); err == nil {
+			t.Errorf("validateDialNumber(%q) succeeded, want an error", number)
+		}
+	}
+}
+
+func TestValidateDtmfTones(t *testing.T) {
+	if tones, err := validateDtmfTones("123#*abcd"); err != nil || tones != "123#*ABCD" {
+		t.Errorf("validateDtmfTones = %q, %v", tones, err)
+	}
+	for _, tones := range []string{"", "12E", "1 2"} {
+		if _, err := validateDtmfTones(tones); err == nil {
+			t.Errorf("validateDtmfTones(%q) succeeded, want an error", tones)
+		}
+	}
+}
+
+func TestVoiceUnsupported(t *testing.T) {
+	if !voiceUnsupported(dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}) {
+		t.Error("UnknownMethod not taken as a modem without voice")
+	}
+	if voiceUnsupported(errors.New("timeout")) || voiceUnsupported(&dbus.Error{Name: modemmanager.ErrorCorePrefix + "Failed"}) {
+		t.Error("other errors taken as a modem without voice")
+	}
+}
+
+// steppingCall returns the next of its states on every read, repeating the
+// last one, and sends on reads after every read
+type steppingCall struct {
+	*mocks.MockCall
+	mu     sync.Mutex
+	states []modemmanager.MMCallState
+	reason modemmanager.MMCallStateReason
+	reads  chan struct{}
+}
+
+func (c *steppingCall) GetState() (modemmanager.MMCallState, error) {
+	c.mu.Lock()
+	state := c.states[0]
+	if len(c.states) > 1 {
+		c.states = c.states[1:]
+	}
+	c.mu.Unlock()
+	c.reads <- struct{}{}
+	return state, nil
+}
+
+func (c *steppingCall) GetStateReason() (modemmanager.MMCallStateReason, error) {
+	return c.reason, nil
+}
+
+func TestFollowCall(t *testing.T) {
+	const (
+		dialing    = modemmanager.MmCallStateDialing
+		ringing    = modemmanager.MmCallStateRingingOut
+		active     = modemmanager.MmCallStateActive
+		terminated = modemmanager.MmCallStateTerminated
+	)
+	tests := []struct {
+		name    string
+		states  []modemmanager.MMCallState
+		reason  modemmanager.MMCallStateReason
+		cancel  bool
+		want    []modemmanager.MMCallState
+		wantErr string
+	}{
+		{name: "answered", states: []modemmanager.MMCallState{dialing, dialing, ringing, active}, want: []modemmanager.MMCallState{dialing, ringing, active}},
+		{
+			name: "busy", states: []modemmanager.MMCallState{dialing, terminated}, reason: modemmanager.MmCallStateReasonRefusedOrBusy,
+			want: []modemmanager.MMCallState{dialing, terminated}, wantErr: "call ended before it was answered: refused or busy",
+		},
+		{name: "no answer", states: []modemmanager.MMCallState{ringing}, want: []modemmanager.MMCallState{ringing}, wantErr: errNoAnswer.Error()},
+		{name: "interrupted", states: []modemmanager.MMCallState{ringing}, cancel: true, want: []modemmanager.MMCallState{ringing}, wantErr: context.Canceled.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Unix(0, 0))
+			prev := clk
+			clk = fake
+			t.Cleanup(func() { clk = prev })
+
+			call := &steppingCall{MockCall: mocks.NewMockCall(), states: tt.states, reason: tt.reason, reads: make(chan struct{}, 100)}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var got []modemmanager.MMCallState
+			done := make(chan error, 1)
+			go func() {
+				done <- followCall(ctx, call, nil, 10*time.Second, time.Second, func(state modemmanager.MMCallState, reason modemmanager.MMCallStateReason) {
+					got = append(got, state)
+				})
+			}()
+
+			var err error
+		loop:
+			for {
+				select {
+				case err = <-done:
+					break loop
+				case <-call.reads:
+					if tt.cancel {
+						cancel()
+						continue
+					}
+					// The timers are set up before the first read
+					fake.Advance(time.Second)
+				}
+			}
+
+			if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("followCall error = %v, want %q", err, tt.wantErr)
+			}
+			if strings.Join(stateNames(got), ",") != strings.Join(stateNames(tt.want), ",") {
+				t.Errorf("reported %v, want %v", stateNames(got), stateNames(tt.want))
+			}
+		})
+	}
+}
+
+func stateNames(states []modemmanager.MMCallState) []string {
+	names := make([]string, len(states))
+	for i, state := range states {
+		names[i] = callStateName(state)
+	}
+	return names
+}
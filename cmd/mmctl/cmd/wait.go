@@ -0,0 +1,507 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of conditions accepted by --for
+const (
+	waitState           = "state"
+	waitRegistration    = "registration"
+	waitBearerConnected = "bearer-connected"
+	waitSimPresent      = "sim-present"
+	waitSignal          = "signal"
+)
+
+var (
+	waitCmd = &cobra.Command{
+		Use:   "wait",
+		Short: "Block until the modem satisfies one or more conditions",
+		Long: `Block until the modem satisfies the conditions given with --for or --state,
+then exit 0.
+
+Conditions:
+  state=<state>              modem state at or above <state> in the order
+                             failed < unknown < initializing < locked < disabled
+                             < disabling < enabling < enabled < searching
+                             < registered < disconnecting < connecting < connected
+                             (state=failed only matches the failed state)
+  registration=home|roaming  registered with the home or a roaming network;
+                             several values may be given separated by commas
+  bearer-connected           at least one bearer is connected
+  sim-present                a SIM card is available
+  signal>=N[%]               signal quality of at least N percent
+
+--state <state> is short for --for state=<state>. With several conditions all
+must hold, unless --any is given.
+
+With --for-modem a missing modem is not an error: the modem list is rescanned
+every --interval until a modem matching the selection flags appears, then
+the conditions are checked. --timeout covers both.
+
+The modem is re-checked on every property change signal and every
+--interval, whichever comes first. With --verbose every state the modem
+passes through is printed to stderr.
+
+Exit codes:
+  0  conditions satisfied
+  1  other error
+  2  timeout expired
+  3  modem disappeared
+  4  modem entered the failed state (unless waiting for state=failed)`,
+		Example: `  # Block until modem 0 is registered
+  mmctl wait -m 0 --for state=registered --timeout 90s
+
+  # At boot, wait for the modem with this IMEI to show up and register
+  mmctl wait --for-modem --imei 866758040123456 --state registered --timeout 300s
+
+  # Wait for a roaming registration with usable signal
+  mmctl wait -m 0 --for registration=roaming --for 'signal>=30%'
+
+  # Stop waiting once a bearer is up or the modem failed
+  mmctl wait -m 0 --for bearer-connected --for state=failed --any`,
+		Args: cobra.NoArgs,
+		RunE: runWait,
+	}
+
+	// Flags
+	waitFor       []string
+	waitStateFlag string
+	waitForModem  bool
+	waitAll       bool
+	waitAny       bool
+	waitTimeout   time.Duration
+	waitInterval  time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(waitCmd)
+
+	waitCmd.Flags().StringArrayVar(&waitFor, "for", nil, "Condition to wait for (repeatable)")
+	waitCmd.Flags().StringVar(&waitStateFlag, "state", "", "Modem state to reach at least, e.g. enabled, registered or connected")
+	waitCmd.Flags().BoolVar(&waitForModem, "for-modem", false, "Wait for the selected modem to appear first")
+	waitCmd.Flags().BoolVar(&waitAll, "all", false, "Wait until all conditions hold (default)")
+	waitCmd.Flags().BoolVar(&waitAny, "any", false, "Wait until any condition holds")
+	waitCmd.Flags().DurationVar(&waitTimeout, "timeout", 60*time.Second, "Maximum time to wait")
+	waitCmd.Flags().DurationVar(&waitInterval, "interval", 2*time.Second, "Polling interval when no change signal arrives")
+	waitCmd.MarkFlagsMutuallyExclusive("all", "any")
+}
+
+// errModemGone is returned by snapshot reads once the modem has disappeared
+var errModemGone = errors.New("modem disappeared")
+
+// errCallTimeout is returned by withTimeout when the call did not finish in time
+var errCallTimeout = errors.New("call timed out")
+
+// withTimeout returns the result of call, or errCallTimeout after timeout.
+// D-Bus calls cannot be cancelled, so call then finishes in the background.
+func withTimeout[T any](timeout time.Duration, call func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-clk.After(timeout):
+		var zero T
+		return zero, errCallTimeout
+	}
+}
+
+// waitCondition is a single parsed --for condition
+type waitCondition struct {
+	kind          string
+	spec          string // as given on the command line
+	state         modemmanager.MMModemState
+	registrations []string
+	signal        uint32
+}
+
+// modemSnapshot holds the modem properties conditions are evaluated on
+type modemSnapshot struct {
+	State           modemmanager.MMModemState `json:"-"`
+	Registration    string                    `json:"registration,omitempty"` // home, roaming or empty
+	BearerConnected bool                      `json:"bearer_connected"`
+	SimPresent      bool                      `json:"sim_present"`
+	Signal          uint32                    `json:"signal_quality"`
+}
+
+// parseWaitCondition parses a --for argument
+func parseWaitCondition(spec string) (waitCondition, error) {
+	c := waitCondition{spec: spec}
+	switch {
+	case spec == waitBearerConnected || spec == waitSimPresent:
+		c.kind = spec
+		return c, nil
+
+	case strings.HasPrefix(spec, waitState+"="):
+		c.kind = waitState
+		name := strings.TrimPrefix(spec, waitState+"=")
+		state, ok := parseModemState(name)
+		if !ok {
+			return c, fmt.Errorf("unknown modem state %q in %q", name, spec)
+		}
+		c.state = state
+		return c, nil
+
+	case strings.HasPrefix(spec, waitRegistration+"="):
+		c.kind = waitRegistration
+		for _, value := range strings.Split(strings.TrimPrefix(spec, waitRegistration+"="), ",") {
+			value = strings.ToLower(strings.TrimSpace(value))
+			if value != "home" && value != "roaming" {
+				return c, fmt.Errorf("invalid registration %q in %q, expected home or roaming", value, spec)
+			}
+			c.registrations = append(c.registrations, value)
+		}
+		return c, nil
+
+	case strings.HasPrefix(spec, waitSignal+">="):
+		c.kind = waitSignal
+		value := strings.TrimSuffix(strings.TrimPrefix(spec, waitSignal+">="), "%")
+		percent, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+		if err != nil || percent > 100 {
+			return c, fmt.Errorf("invalid signal threshold in %q, expected 0-100", spec)
+		}
+		c.signal = uint32(percent)
+		return c, nil
+	}
+	return c, fmt.Errorf("unknown condition %q, expected state=, registration=, bearer-connected, sim-present or signal>=", spec)
+}
+
+// parseModemState resolves a state name such as "registered"
+func parseModemState(name string) (modemmanager.MMModemState, bool) {
+	for s := modemmanager.MmModemStateFailed; s <= modemmanager.MmModemStateConnected; s++ {
+		if strings.EqualFold(s.String(), name) {
+			return s, true
+		}
+	}
+	return modemmanager.MmModemStateUnknown, false
+}
+
+// holds reports whether the condition is satisfied by a snapshot
+func (c waitCondition) holds(s modemSnapshot) bool {
+	switch c.kind {
+	case waitState:
+		// Failed sorts below every other state, so >= would always match
+		if c.state == modemmanager.MmModemStateFailed {
+			return s.State == modemmanager.MmModemStateFailed
+		}
+		return s.State >= c.state
+	case waitRegistration:
+		for _, r := range c.registrations {
+			if s.Registration == r {
+				return true
+			}
+		}
+		return false
+	case waitBearerConnected:
+		return s.BearerConnected
+	case waitSimPresent:
+		return s.SimPresent
+	case waitSignal:
+		return s.Signal >= c.signal
+	}
+	return false
+}
+
+// conditionsHold combines the conditions with all (default) or any semantics
+func conditionsHold(conds []waitCondition, s modemSnapshot, matchAny bool) bool {
+	for _, c := range conds {
+		if c.holds(s) == matchAny {
+			return matchAny
+		}
+	}
+	return !matchAny
+}
+
+// registrationClass reduces a 3GPP registration state to home, roaming or ""
+func registrationClass(state modemmanager.MMModem3gppRegistrationState) string {
+	switch state {
+	case modemmanager.MmModem3gppRegistrationStateHome,
+		modemmanager.MmModem3gppRegistrationStateHomeSmsOnly,
+		modemmanager.MmModem3gppRegistrationStateHomeCsfbNotPreferred:
+		return "home"
+	case modemmanager.MmModem3gppRegistrationStateRoaming,
+		modemmanager.MmModem3gppRegistrationStateRoamingSmsOnly,
+		modemmanager.MmModem3gppRegistrationStateRoamingCsfbNotPreferred:
+		return "roaming"
+	default:
+		return ""
+	}
+}
+
+// errWaitTimeout is returned by pollUntil when the timeout expired
+var errWaitTimeout = errors.New("wait timed out")
+
+// pollUntil calls check until it reports done or returns an error, which
+// ends the wait. check runs right away and again on every signal and every
+// interval tick. pollUntil gives up with errWaitTimeout after timeout and
+// with the context error once ctx is done.
+func pollUntil(ctx context.Context, signals <-chan *dbus.Signal, timeout, interval time.Duration, check func() (bool, error)) error {
+	deadline := clk.After(timeout)
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if done, err := check(); done || err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return errWaitTimeout
+		case _, ok := <-signals:
+			if !ok {
+				signals = nil
+			}
+		case <-ticker.C():
+		}
+	}
+}
+
+// waitUntil reads the modem until the conditions hold. It re-reads on every
+// signal and on every interval tick, and gives up after timeout. report is
+// called with every modem state seen, starting with the first one. A modem
+// that enters the failed state ends the wait unless a condition asks for it.
+func waitUntil(read func() (modemSnapshot, error), signals <-chan *dbus.Signal, conds []waitCondition, matchAny bool, timeout, interval time.Duration, report func(state modemmanager.MMModemState)) (modemSnapshot, error) {
+	var snapshot modemSnapshot
+	var lastErr error
+	seen := false
+	err := pollUntil(context.Background(), signals, timeout, interval, func() (bool, error) {
+		s, err := read()
+		switch {
+		case errors.Is(err, errModemGone):
+			return false, &exitError{code: exitModemGone, err: err}
+		case err != nil:
+			// Properties can be briefly unavailable while the modem
+			// changes state, try again on the next change
+			lastErr = err
+			return false, nil
+		}
+		if !seen || s.State != snapshot.State {
+			report(s.State)
+		}
+		seen, snapshot = true, s
+
+		if conditionsHold(conds, s, matchAny) {
+			return true, nil
+		}
+		if s.State == modemmanager.MmModemStateFailed && !waitsForFailed(conds) {
+			return false, &exitError{code: exitFailed, err: fmt.Errorf("modem entered the failed state while waiting for %s", describeConditions(conds, matchAny))}
+		}
+		return false, nil
+	})
+	if errors.Is(err, errWaitTimeout) {
+		err = fmt.Errorf("timed out after %s waiting for %s", timeout, describeConditions(conds, matchAny))
+		if lastErr != nil {
+			err = fmt.Errorf("%w (last error: %v)", err, lastErr)
+		}
+		return snapshot, &exitError{code: exitTimeout, err: err}
+	}
+	return snapshot, err
+}
+
+// waitsForFailed reports whether a condition is state=failed
+func waitsForFailed(conds []waitCondition) bool {
+	for _, c := range conds {
+		if c.kind == waitState && c.state == modemmanager.MmModemStateFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForModemToAppear calls find until it returns a modem, rescanning every
+// interval. Errors, such as no modem found yet or ModemManager not running,
+// are retried and the last one is named on timeout.
+func waitForModemToAppear(find func() (modemmanager.Modem, error), timeout, interval time.Duration) (modemmanager.Modem, error) {
+	var modem modemmanager.Modem
+	var lastErr error
+	err := pollUntil(context.Background(), nil, timeout, interval, func() (bool, error) {
+		m, err := find()
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+		modem = m
+		return true, nil
+	})
+	if errors.Is(err, errWaitTimeout) {
+		err = fmt.Errorf("no modem appeared after %s", timeout)
+		if lastErr != nil {
+			err = fmt.Errorf("%w (last error: %v)", err, lastErr)
+		}
+		return nil, &exitError{code: exitTimeout, err: err}
+	}
+	return modem, err
+}
+
+func describeConditions(conds []waitCondition, matchAny bool) string {
+	specs := make([]string, len(conds))
+	for i, c := range conds {
+		specs[i] = c.spec
+	}
+	if matchAny {
+		return strings.Join(specs, " or ")
+	}
+	return strings.Join(specs, " and ")
+}
+
+// readModemSnapshot reads the properties used by the wait conditions
+func readModemSnapshot(modem modemmanager.Modem) (modemSnapshot, error) {
+	var s modemSnapshot
+	state, err := modem.GetState()
+	if err != nil {
+		if modemGone(modem.GetObjectPath()) {
+			return s, fmt.Errorf("%w: %s", errModemGone, modem.GetObjectPath())
+		}
+		return s, fmt.Errorf("failed to get modem state: %w", err)
+	}
+	s.State = state
+
+	if modem3gpp, err := modem.Get3gpp(); err == nil {
+		if registration, err := modem3gpp.GetRegistrationState(); err == nil {
+			s.Registration = registrationClass(registration)
+		}
+	}
+	if bearers, err := modemmanager.ListModemBearers(modem); err == nil {
+		for _, bearer := range bearers {
+			if connected, err := bearer.GetConnected(); err == nil && connected {
+				s.BearerConnected = true
+				break
+			}
+		}
+	}
+	if sim, err := modem.GetSim(); err == nil {
+		s.SimPresent = sim.GetObjectPath() != "/"
+	}
+	if percent, _, err := modem.GetSignalQuality(); err == nil {
+		s.Signal = percent
+	}
+	return s, nil
+}
+
+// modemGone reports whether ModemManager no longer lists the modem. It
+// returns false if ModemManager cannot be asked.
+func modemGone(path dbus.ObjectPath) bool {
+	mm, err := modemmanager.NewModemManager()
+	if err != nil {
+		return false
+	}
+	modems, err := mm.GetModems()
+	if err != nil {
+		return false
+	}
+	for _, modem := range modems {
+		if modem.GetObjectPath() == path {
+			return false
+		}
+	}
+	return true
+}
+
+func runWait(cmd *cobra.Command, args []string) error {
+	specs := waitFor
+	if waitStateFlag != "" {
+		specs = append(specs, waitState+"="+waitStateFlag)
+	}
+	conds := make([]waitCondition, 0, len(specs))
+	for _, spec := range specs {
+		c, err := parseWaitCondition(spec)
+		if err != nil {
+			return err
+		}
+		conds = append(conds, c)
+	}
+	if len(conds) == 0 && !waitForModem {
+		return fmt.Errorf("nothing to wait for, give --for, --state or --for-modem")
+	}
+	if waitInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	start := clk.Now()
+	var modem modemmanager.Modem
+	var err error
+	if waitForModem {
+		modem, err = waitForModemToAppear(getModem, waitTimeout, waitInterval)
+		if err == nil && verbose {
+			fmt.Fprintf(os.Stderr, "Modem %s appeared after %s\n", modem.GetObjectPath(), humanDuration(clk.Now().Sub(start)))
+		}
+	} else {
+		modem, err = getModem()
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot modemSnapshot
+	satisfied := "modem present"
+	if len(conds) > 0 {
+		satisfied = describeConditions(conds, waitAny)
+
+		// State, signal quality, SIM and 3GPP registration changes all arrive as
+		// PropertiesChanged on the modem path; bearers are covered by polling
+		signals := modem.SubscribePropertiesChanged()
+		defer modem.Unsubscribe()
+
+		last := ""
+		report := func(state modemmanager.MMModemState) {
+			name := strings.ToLower(state.String())
+			if verbose {
+				if last == "" {
+					fmt.Fprintf(os.Stderr, "Modem %s\n", name)
+				} else {
+					fmt.Fprintf(os.Stderr, "Modem %s → %s\n", last, name)
+				}
+			}
+			last = name
+		}
+		snapshot, err = waitUntil(func() (modemSnapshot, error) { return readModemSnapshot(modem) }, signals, conds, waitAny, waitTimeout-clk.Now().Sub(start), waitInterval, report)
+		if err != nil {
+			return err
+		}
+	} else if snapshot, err = readModemSnapshot(modem); err != nil {
+		return err
+	}
+	elapsed := clk.Now().Sub(start)
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"satisfied":       satisfied,
+			"elapsed_seconds": elapsed.Seconds(),
+			"path":            modem.GetObjectPath(),
+			"state":           strings.ToLower(snapshot.State.String()),
+			"modem":           snapshot,
+		})
+	}
+	if verbose {
+		fmt.Printf("Satisfied %s after %s (state %s)\n", satisfied, humanDuration(elapsed), strings.ToLower(snapshot.State.String()))
+	}
+	return nil
+}
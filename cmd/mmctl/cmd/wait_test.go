@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestParseWaitCondition(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    waitCondition
+		wantErr bool
+	}{
+		{spec: "state=registered", want: waitCondition{kind: waitState, state: modemmanager.MmModemStateRegistered}},
+		{spec: "state=Connected", want: waitCondition{kind: waitState, state: modemmanager.MmModemStateConnected}},
+		{spec: "state=failed", want: waitCondition{kind: waitState, state: modemmanager.MmModemStateFailed}},
+		{spec: "registration=home", want: waitCondition{kind: waitRegistration, registrations: []string{"home"}}},
+		{spec: "registration=home,roaming", want: waitCondition{kind: waitRegistration, registrations: []string{"home", "roaming"}}},
+		{spec: "bearer-connected", want: waitCondition{kind: waitBearerConnected}},
+		{spec: "sim-present", want: waitCondition{kind: waitSimPresent}},
+		{spec: "signal>=30%", want: waitCondition{kind: waitSignal, signal: 30}},
+		{spec: "signal>=0", want: waitCondition{kind: waitSignal}},
+		{spec: "state=online", wantErr: true},
+		{spec: "registration=denied", wantErr: true},
+		{spec: "signal>=101", wantErr: true},
+		{spec: "signal>=-1", wantErr: true},
+		{spec: "signal>50", wantErr: true},
+		{spec: "connected", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWaitCondition(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseWaitCondition(%q) = %+v, want error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseWaitCondition(%q) error: %v", tt.spec, err)
+			continue
+		}
+		tt.want.spec = tt.spec
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("parseWaitCondition(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func mustConditions(t *testing.T, specs ...string) []waitCondition {
+	t.Helper()
+	conds := make([]waitCondition, len(specs))
+	for i, spec := range specs {
+		c, err := parseWaitCondition(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conds[i] = c
+	}
+	return conds
+}
+
+func TestConditionsHold(t *testing.T) {
+	registered := modemSnapshot{State: modemmanager.MmModemStateRegistered, Registration: "roaming", SimPresent: true, Signal: 40}
+	failed := modemSnapshot{State: modemmanager.MmModemStateFailed}
+
+	tests := []struct {
+		specs    []string
+		matchAny bool
+		snapshot modemSnapshot
+		want     bool
+	}{
+		{[]string{"state=registered"}, false, registered, true},
+		{[]string{"state=enabled"}, false, registered, true},
+		{[]string{"state=connected"}, false, registered, false},
+		{[]string{"state=failed"}, false, registered, false},
+		{[]string{"state=failed"}, false, failed, true},
+		{[]string{"state=unknown"}, false, failed, false},
+		{[]string{"registration=home"}, false, registered, false},
+		{[]string{"registration=home,roaming"}, false, registered, true},
+		{[]string{"sim-present"}, false, registered, true},
+		{[]string{"bearer-connected"}, false, registered, false},
+		{[]string{"signal>=40"}, false, registered, true},
+		{[]string{"signal>=41"}, false, registered, false},
+		{[]string{"state=registered", "signal>=30"}, false, registered, true},
+		{[]string{"state=registered", "bearer-connected"}, false, registered, false},
+		{[]string{"state=registered", "bearer-connected"}, true, registered, true},
+		{[]string{"state=connected", "bearer-connected"}, true, registered, false},
+	}
+	for _, tt := range tests {
+		if got := conditionsHold(mustConditions(t, tt.specs...), tt.snapshot, tt.matchAny); got != tt.want {
+			t.Errorf("conditionsHold(%v, any=%v, %+v) = %v, want %v", tt.specs, tt.matchAny, tt.snapshot, got, tt.want)
+		}
+	}
+}
+
+func TestRegistrationClass(t *testing.T) {
+	tests := map[modemmanager.MMModem3gppRegistrationState]string{
+		modemmanager.MmModem3gppRegistrationStateHome:                    "home",
+		modemmanager.MmModem3gppRegistrationStateHomeSmsOnly:             "home",
+		modemmanager.MmModem3gppRegistrationStateRoamingCsfbNotPreferred: "roaming",
+		modemmanager.MmModem3gppRegistrationStateSearching:               "",
+		modemmanager.MmModem3gppRegistrationStateDenied:                  "",
+	}
+	for state, want := range tests {
+		if got := registrationClass(state); got != want {
+			t.Errorf("registrationClass(%v) = %q, want %q", state, got, want)
+		}
+	}
+}
+
+// fakeModemReads serves snapshots to waitUntil and counts the reads
+type fakeModemReads struct {
+	mu       sync.Mutex
+	snapshot modemSnapshot
+	err      error
+	reads    chan struct{}
+}
+
+func (f *fakeModemReads) set(s modemSnapshot, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.snapshot, f.err = s, err
+}
+
+func (f *fakeModemReads) read() (modemSnapshot, error) {
+	f.mu.Lock()
+	s, err := f.snapshot, f.err
+	f.mu.Unlock()
+	f.reads <- struct{}{}
+	return s, err
+}
+
+type waitResult struct {
+	snapshot modemSnapshot
+	err      error
+}
+
+// startWait runs waitUntil with a fake clock and returns its result channel
+func startWait(t *testing.T, modem *fakeModemReads, signals <-chan *dbus.Signal, specs ...string) (*clock.Fake, <-chan waitResult) {
+	t.Helper()
+	fake := clock.NewFake(time.Unix(0, 0))
+	prev := clk
+	clk = fake
+	t.Cleanup(func() { clk = prev })
+
+	conds := mustConditions(t, specs...)
+	done := make(chan waitResult, 1)
+	go func() {
+		s, err := waitUntil(modem.read, signals, conds, false, time.Minute, 5*time.Second, func(modemmanager.MMModemState) {})
+		done <- waitResult{s, err}
+	}()
+	return fake, done
+}
+
+func TestWaitUntilSignal(t *testing.T) {
+	modem := &fakeModemReads{snapshot: modemSnapshot{State: modemmanager.MmModemStateSearching}, reads: make(chan struct{})}
+	signals := make(chan *dbus.Signal)
+	_, done := startWait(t, modem, signals, "state=registered")
+
+	<-modem.reads
+	modem.set(modemSnapshot{State: modemmanager.MmModemStateRegistered}, nil)
+	signals <- &dbus.Signal{Name: "org.freedesktop.DBus.Properties.PropertiesChanged"}
+	<-modem.reads
+
+	res := <-done
+	if res.err != nil || res.snapshot.State != modemmanager.MmModemStateRegistered {
+		t.Errorf("waitUntil = %+v, %v, want registered without error", res.snapshot, res.err)
+	}
+}
+
+func TestWaitUntilPollingFallback(t *testing.T) {
+	modem := &fakeModemReads{reads: make(chan struct{})}
+	// A closed signal channel must not cause busy reads
+	signals := make(chan *dbus.Signal)
+	close(signals)
+	fake, done := startWait(t, modem, signals, "bearer-connected")
+
+	<-modem.reads
+	<-modem.reads // after the closed channel was noticed
+	modem.set(modemSnapshot{BearerConnected: true}, nil)
+	fake.BlockUntilTimers(2)
+	fake.Advance(5 * time.Second)
+	<-modem.reads
+
+	if res := <-done; res.err != nil {
+		t.Errorf("waitUntil error: %v", res.err)
+	}
+}
+
+func TestWaitUntilTimeout(t *testing.T) {
+	modem := &fakeModemReads{err: errors.New("property not available"), reads: make(chan struct{}, 100)}
+	fake, done := startWait(t, modem, nil, "state=connected")
+
+	fake.BlockUntilTimers(2)
+	fake.Advance(time.Minute)
+
+	res := <-done
+	if code := ExitCode(res.err); code != exitTimeout {
+		t.Fatalf("exit code = %d (%v), want %d", code, res.err, exitTimeout)
+	}
+	if msg := res.err.Error(); !strings.Contains(msg, "state=connected") || !strings.Contains(msg, "property not available") {
+		t.Errorf("timeout error %q does not name the condition and the last read error", msg)
+	}
+}
+
+func TestWaitUntilModemGone(t *testing.T) {
+	modem := &fakeModemReads{snapshot: modemSnapshot{State: modemmanager.MmModemStateSearching}, reads: make(chan struct{})}
+	signals := make(chan *dbus.Signal)
+	_, done := startWait(t, modem, signals, "state=registered")
+
+	<-modem.reads
+	modem.set(modemSnapshot{}, fmt.Errorf("%w: /org/freedesktop/ModemManager1/Modem/0", errModemGone))
+	signals <- &dbus.Signal{}
+	<-modem.reads
+
+	res := <-done
+	if code := ExitCode(res.err); code != exitModemGone {
+		t.Errorf("exit code = %d (%v), want %d", code, res.err, exitModemGone)
+	}
+}
+
+func TestWaitUntilFailedState(t *testing.T) {
+	modem := &fakeModemReads{snapshot: modemSnapshot{State: modemmanager.MmModemStateEnabling}, reads: make(chan struct{})}
+	signals := make(chan *dbus.Signal)
+	fake := useFakeClock(t, time.Unix(0, 0))
+
+	var seen []modemmanager.MMModemState
+	done := make(chan waitResult, 1)
+	go func() {
+		s, err := waitUntil(modem.read, signals, mustConditions(t, "state=registered"), false, time.Minute, 5*time.Second, func(state modemmanager.MMModemState) {
+			seen = append(seen, state)
+		})
+		done <- waitResult{s, err}
+	}()
+
+	<-modem.reads
+	fake.BlockUntilTimers(2)
+	fake.Advance(5 * time.Second) // unchanged, not reported again
+	<-modem.reads
+	modem.set(modemSnapshot{State: modemmanager.MmModemStateFailed}, nil)
+	signals <- &dbus.Signal{}
+	<-modem.reads
+
+	res := <-done
+	if code := ExitCode(res.err); code != exitFailed {
+		t.Errorf("exit code = %d (%v), want %d", code, res.err, exitFailed)
+	}
+	if fmt.Sprint(seen) != fmt.Sprint([]modemmanager.MMModemState{modemmanager.MmModemStateEnabling, modemmanager.MmModemStateFailed}) {
+		t.Errorf("reported %v, want enabling and failed", seen)
+	}
+
+	// Waiting for the failed state is satisfied by it
+	modem.reads = make(chan struct{}, 1)
+	if _, err := waitUntil(modem.read, nil, mustConditions(t, "state=registered", "state=failed"), true, time.Minute, 5*time.Second, func(modemmanager.MMModemState) {}); err != nil {
+		t.Errorf("state=failed: error = %v", err)
+	}
+}
+
+func TestPollUntil(t *testing.T) {
+	useFakeClock(t, time.Unix(0, 0))
+	stop := errors.New("stop")
+	checks := 0
+	signals := make(chan *dbus.Signal, 2)
+	signals <- &dbus.Signal{}
+	signals <- &dbus.Signal{}
+	err := pollUntil(context.Background(), signals, time.Minute, time.Hour, func() (bool, error) {
+		checks++
+		if checks == 3 {
+			return false, stop
+		}
+		return false, nil
+	})
+	if err != stop || checks != 3 {
+		t.Errorf("pollUntil = %v after %d checks, want the check error after 3", err, checks)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := pollUntil(ctx, nil, time.Minute, time.Hour, func() (bool, error) { return true, nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: error = %v", err)
+	}
+}
+
+func TestWaitForModemToAppear(t *testing.T) {
+	fake := useFakeClock(t, time.Unix(0, 0))
+	modem := mocks.NewMockModem()
+	finds := make(chan struct{}, 10)
+	n := 0
+	find := func() (modemmanager.Modem, error) {
+		n++
+		defer func() { finds <- struct{}{} }()
+		if n < 3 {
+			return nil, errors.New("no modems found")
+		}
+		return modem, nil
+	}
+
+	done := make(chan error, 1)
+	var got modemmanager.Modem
+	go func() {
+		var err error
+		got, err = waitForModemToAppear(find, time.Minute, 2*time.Second)
+		done <- err
+	}()
+	for i := 0; i < 2; i++ {
+		<-finds
+		fake.Advance(2 * time.Second)
+	}
+	if err := <-done; err != nil || got != modem {
+		t.Errorf("waitForModemToAppear = %v, %v", got, err)
+	}
+
+	go func() {
+		_, err := waitForModemToAppear(func() (modemmanager.Modem, error) { return nil, errors.New("no modems found") }, 10*time.Second, 2*time.Second)
+		done <- err
+	}()
+	fake.BlockUntilTimers(2)
+	fake.Advance(10 * time.Second)
+	err := <-done
+	if ExitCode(err) != exitTimeout || err.Error() != "no modem appeared after 10s (last error: no modems found)" {
+		t.Errorf("timeout: error = %v", err)
+	}
+}
+
+func TestExitCodeDefault(t *testing.T) {
+	if code := ExitCode(errors.New("failed")); code != 1 {
+		t.Errorf("ExitCode = %d, want 1", code)
+	}
+}
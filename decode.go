@@ -0,0 +1,64 @@
+package modemmanager
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// DecodeError is returned when ModemManager sends a value of a different type
+// than the library expects, usually because the daemon version differs from
+// the one the decoder was written against.
+type DecodeError struct {
+	Property string // property, signal argument or dictionary key
+	Expected string // expected D-Bus signature
+	Actual   string // D-Bus signature of the received value, "nil" if there was none
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("unexpected variant type for '%s': expected %s, got %s", e.Property, e.Expected, e.Actual)
+}
+
+// logf receives a message for every DecodeError, see SetLogger
+var logf atomic.Pointer[func(format string, args ...interface{})]
+
+// SetLogger sets a function receiving a message for every value the library
+// could not decode, in addition to the returned error. Pass nil to disable.
+// log.Printf is a suitable logger.
+func SetLogger(l func(format string, args ...interface{})) {
+	if l == nil {
+		logf.Store(nil)
+		return
+	}
+	logf.Store(&l)
+}
+
+// decode converts a value received from ModemManager to T, returning a
+// DecodeError naming property if it has a different type.
+func decode[T any](property string, v interface{}) (T, error) {
+	value, ok := v.(T)
+	if !ok {
+		var zero T
+		err := &DecodeError{Property: property, Expected: signatureOf(zero), Actual: signatureOf(v)}
+		if l := logf.Load(); l != nil {
+			(*l)("modemmanager: %v", err)
+		}
+		return zero, err
+	}
+	return value, nil
+}
+
+// signatureOf returns the D-Bus signature of v, or its Go type for values
+// that have no D-Bus representation.
+func signatureOf(v interface{}) (sig string) {
+	if v == nil {
+		return "nil"
+	}
+	defer func() {
+		if recover() != nil {
+			sig = fmt.Sprintf("%T", v)
+		}
+	}()
+	return dbus.SignatureOf(v).String()
+}
@@ -0,0 +1,175 @@
+package modemmanager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// propertyObject is a bus object whose properties all hold the same value
+type propertyObject struct {
+	dbus.BusObject
+	value interface{}
+}
+
+func (o propertyObject) GetProperty(p string) (dbus.Variant, error) {
+	if o.value == nil {
+		return dbus.Variant{}, nil
+	}
+	return dbus.MakeVariant(o.value), nil
+}
+
+// wrongValues are fed to every decoder; none of them fits all decoders, and
+// each decoder must reject most of them without panicking
+var wrongValues = []interface{}{
+	nil,
+	int16(-1),
+	uint32(7),
+	int32(7),
+	"text",
+	true,
+	[]interface{}{},
+	[]interface{}{"a"},
+	[]interface{}{uint32(1), "not-bool"},
+	[]interface{}{"x", uint64(1)},
+	[][]interface{}{{int32(1), uint32(2)}},
+	map[string]dbus.Variant{"k": dbus.MakeVariant(int32(1))},
+	[]byte{1, 2},
+	dbus.ObjectPath("/x"),
+}
+
+func TestDecode(t *testing.T) {
+	if v, err := decode[uint32]("Prop", uint32(3)); err != nil || v != 3 {
+		t.Errorf("decode[uint32](3) = %v, %v", v, err)
+	}
+
+	_, err := decode[int32]("State", uint32(3))
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("decode[int32](uint32) error = %v, want *DecodeError", err)
+	}
+	if decodeErr.Property != "State" || decodeErr.Expected != "i" || decodeErr.Actual != "u" {
+		t.Errorf("DecodeError = %+v, want State, i, u", decodeErr)
+	}
+	if !strings.Contains(err.Error(), "'State'") {
+		t.Errorf("error %q does not name the property", err)
+	}
+
+	if _, err := decode[string]("Name", nil); err == nil || !strings.Contains(err.Error(), "got nil") {
+		t.Errorf("decode[string](nil) error = %v, want got nil", err)
+	}
+	if _, err := decode[string]("Name", make(chan int)); err == nil || !strings.Contains(err.Error(), "chan int") {
+		t.Errorf("decode of a value without signature error = %v, want Go type", err)
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	var logged []string
+	SetLogger(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	defer SetLogger(nil)
+
+	decode[bool]("Enabled", "yes")
+	decode[bool]("Enabled", true)
+	if len(logged) != 1 || !strings.Contains(logged[0], "Enabled") {
+		t.Errorf("logged %q, want one message naming Enabled", logged)
+	}
+
+	SetLogger(nil)
+	decode[bool]("Enabled", "yes")
+	if len(logged) != 1 {
+		t.Errorf("logged after SetLogger(nil): %q", logged[1:])
+	}
+}
+
+// propertyDecoders calls every generic property getter
+func propertyDecoders(d *dbusBase) map[string]func() error {
+	return map[string]func() error{
+		"object":              func() error { _, err := d.getObjectProperty("P"); return err },
+		"slice object":        func() error { _, err := d.getSliceObjectProperty("P"); return err },
+		"bool":                func() error { _, err := d.getBoolProperty("P"); return err },
+		"string":              func() error { _, err := d.getStringProperty("P"); return err },
+		"slice string":        func() error { _, err := d.getSliceStringProperty("P"); return err },
+		"slice slice byte":    func() error { _, err := d.getSliceSliceByteProperty("P"); return err },
+		"pair":                func() error { _, err := d.getPairProperty("P"); return err },
+		"slice slice pair":    func() error { _, err := d.getSliceSlicePairProperty("P"); return err },
+		"map string variant":  func() error { _, err := d.getMapStringVariantProperty("P"); return err },
+		"map uint32 uint32":   func() error { _, err := d.getMapUint32Uint32Property("P"); return err },
+		"map uint32 iface":    func() error { _, err := d.getMapUint32InterfaceProperty("P"); return err },
+		"map uint32 variant":  func() error { _, err := d.getMapUint32VariantProperty("P"); return err },
+		"timestamp":           func() error { _, err := d.getTimestampProperty("P"); return err },
+		"uint8":               func() error { _, err := d.getUint8Property("P"); return err },
+		"uint32":              func() error { _, err := d.getUint32Property("P"); return err },
+		"int32":               func() error { _, err := d.getInt32Property("P"); return err },
+		"int64":               func() error { _, err := d.getInt64Property("P"); return err },
+		"float32":             func() error { _, err := d.getFloat32Property("P"); return err },
+		"float64":             func() error { _, err := d.getFloat64Property("P"); return err },
+		"uint64":              func() error { _, err := d.getUint64Property("P"); return err },
+		"slice uint32":        func() error { _, err := d.getSliceUint32Property("P"); return err },
+		"slice slice uint32":  func() error { _, err := d.getSliceSliceUint32Property("P"); return err },
+		"slice map variant":   func() error { _, err := d.getSliceMapStringVariantProperty("P"); return err },
+		"slice map interface": func() error { _, err := d.getSliceMapStringInterfaceProperty("P"); return err },
+		"slice byte":          func() error { _, err := d.getSliceByteProperty("P"); return err },
+	}
+}
+
+// modemDecoders calls the modem getters that decode compound values
+func modemDecoders(m *modem) map[string]func() error {
+	return map[string]func() error{
+		"GetSignalQuality": func() error { _, _, err := m.GetSignalQuality(); return err },
+		"GetPorts":         func() error { _, err := m.GetPorts(); return err },
+		"GetUpdateSettings": func() error {
+			_, err := modemFirmware{dbusBase: m.dbusBase}.GetUpdateSettings()
+			return err
+		},
+		"GetPendingNetworkInitiatedSessions": func() error {
+			_, err := modemOma{dbusBase: m.dbusBase}.GetPendingNetworkInitiatedSessions()
+			return err
+		},
+		"GetPco": func() error { _, err := modem3gpp{dbusBase: m.dbusBase}.GetPco(); return err },
+	}
+}
+
+func TestDecodersRejectWrongTypes(t *testing.T) {
+	for _, value := range wrongValues {
+		m := &modem{dbusBase: dbusBase{obj: propertyObject{value: value}}}
+		decoders := propertyDecoders(&m.dbusBase)
+		for name, f := range modemDecoders(m) {
+			decoders[name] = f
+		}
+
+		for name, f := range decoders {
+			err := func() (err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("panic: %v", r)
+						t.Errorf("%s decoder panicked on %#v: %v", name, value, r)
+					}
+				}()
+				return f()
+			}()
+			var decodeErr *DecodeError
+			if err != nil && !errors.As(err, &decodeErr) {
+				t.Errorf("%s decoder on %#v returned %v, want a DecodeError", name, value, err)
+			}
+		}
+	}
+}
+
+func TestSignalQualityDecodeError(t *testing.T) {
+	m := &modem{dbusBase: dbusBase{obj: propertyObject{value: []interface{}{int32(50), true}}}}
+	_, _, err := m.GetSignalQuality()
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Expected != "u" || decodeErr.Actual != "i" {
+		t.Errorf("GetSignalQuality error = %v, want uint32 expected, int32 received", err)
+	}
+
+	m.obj = propertyObject{value: []interface{}{uint32(50), true}}
+	if percent, recent, err := m.GetSignalQuality(); err != nil || percent != 50 || !recent {
+		t.Errorf("GetSignalQuality() = %d, %v, %v, want 50, true", percent, recent, err)
+	}
+}
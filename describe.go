@@ -0,0 +1,97 @@
+package modemmanager
+
+import "fmt"
+
+/* User-facing descriptions of reasons and failure causes */
+
+// Severity is a hint on how prominently a described reason should be shown to a user.
+type Severity uint32
+
+const (
+	SeverityInfo    Severity = 0 // Expected, nothing to act on.
+	SeverityWarning Severity = 1 // Unexpected, but the modem may recover on its own.
+	SeverityError   Severity = 2 // The modem cannot be used until the cause is fixed.
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", uint32(s))
+	}
+}
+
+// Describe returns a short sentence explaining why the modem state changed, and a severity hint.
+func (r MMModemStateChangeReason) Describe() (string, Severity) {
+	switch r {
+	case MmModemStateChangeReasonUnknown:
+		return "State changed for an unknown reason", SeverityInfo
+	case MmModemStateChangeReasonUserRequested:
+		return "State change was requested by the user", SeverityInfo
+	case MmModemStateChangeReasonSuspend:
+		return "State changed because the system is suspending", SeverityInfo
+	case MmModemStateChangeReasonFailure:
+		return "State changed due to an unrecoverable failure", SeverityError
+	default:
+		return fmt.Sprintf("State changed for an unrecognized reason (%d)", uint32(r)), SeverityWarning
+	}
+}
+
+// Describe returns a short sentence explaining why the modem is in the failed state, and a severity hint.
+func (r MMModemStateFailedReason) Describe() (string, Severity) {
+	switch r {
+	case MmModemStateFailedReasonNone:
+		return "The modem has not failed", SeverityInfo
+	case MmModemStateFailedReasonUnknown:
+		return "The modem failed for an unknown reason", SeverityError
+	case MmModemStateFailedReasonSimMissing:
+		return "A SIM card is required but missing", SeverityError
+	case MmModemStateFailedReasonSimError:
+		return "The SIM card is present but unusable", SeverityError
+	case MmModemStateFailedReasonUnknownCapabilities:
+		return "The modem capabilities could not be determined", SeverityError
+	case MmModemStateFailedReasonEsimWithoutProfiles:
+		return "The eSIM has no profiles installed", SeverityError
+	default:
+		return fmt.Sprintf("The modem failed for an unrecognized reason (%d)", uint32(r)), SeverityError
+	}
+}
+
+// Describe returns a short sentence explaining a connection error, and a severity hint.
+func (e MMConnectionError) Describe() (string, Severity) {
+	switch e {
+	case MmConnectionErrorUnknown:
+		return "The connection failed for an unknown reason", SeverityWarning
+	case MmConnectionErrorNoCarrier:
+		return "The connection was lost or no carrier was detected", SeverityWarning
+	case MmConnectionErrorNoDialtone:
+		return "No dial tone was detected", SeverityWarning
+	case MmConnectionErrorBusy:
+		return "The remote end is busy", SeverityWarning
+	case MmConnectionErrorNoAnswer:
+		return "The remote end did not answer", SeverityWarning
+	default:
+		return fmt.Sprintf("The connection failed with an unrecognized error (%d)", uint32(e)), SeverityWarning
+	}
+}
+
+// connectionErrorNames maps the D-Bus error names used by ModemManager for bearer connection errors.
+var connectionErrorNames = map[string]MMConnectionError{
+	ErrorConnectionPrefix + "Unknown":    MmConnectionErrorUnknown,
+	ErrorConnectionPrefix + "NoCarrier":  MmConnectionErrorNoCarrier,
+	ErrorConnectionPrefix + "NoDialtone": MmConnectionErrorNoDialtone,
+	ErrorConnectionPrefix + "Busy":       MmConnectionErrorBusy,
+	ErrorConnectionPrefix + "NoAnswer":   MmConnectionErrorNoAnswer,
+}
+
+// ConnectionErrorFromName returns the connection error for a D-Bus error name
+// such as "org.freedesktop.ModemManager1.Error.Connection.NoCarrier".
+func ConnectionErrorFromName(name string) (MMConnectionError, bool) {
+	e, ok := connectionErrorNames[name]
+	return e, ok
+}
@@ -0,0 +1,67 @@
+package modemmanager
+
+import (
+	"strings"
+	"testing"
+)
+
+// isStringerFallback reports whether s is the stringer output for a value
+// outside the enum, e.g. "MMConnectionError(5)".
+func isStringerFallback(s string) bool {
+	return strings.Contains(s, "(")
+}
+
+func TestModemStateChangeReasonDescribe(t *testing.T) {
+	n := 0
+	for r := MMModemStateChangeReason(0); !isStringerFallback(r.String()); r++ {
+		text, _ := r.Describe()
+		if text == "" || strings.Contains(text, "unrecognized") {
+			t.Errorf("%v has no description", r)
+		}
+		n++
+	}
+	if n != 4 {
+		t.Errorf("iterated %d reasons, want 4", n)
+	}
+	if text, _ := MMModemStateChangeReason(99).Describe(); !strings.Contains(text, "99") {
+		t.Errorf("fallback description %q does not include the value", text)
+	}
+}
+
+func TestModemStateFailedReasonDescribe(t *testing.T) {
+	n := 0
+	for r := MMModemStateFailedReason(0); !isStringerFallback(r.String()); r++ {
+		text, severity := r.Describe()
+		if text == "" || strings.Contains(text, "unrecognized") {
+			t.Errorf("%v has no description", r)
+		}
+		if r != MmModemStateFailedReasonNone && severity != SeverityError {
+			t.Errorf("%v severity = %v, want error", r, severity)
+		}
+		n++
+	}
+	if n != 6 {
+		t.Errorf("iterated %d reasons, want 6", n)
+	}
+}
+
+func TestConnectionErrorDescribe(t *testing.T) {
+	n := 0
+	for e := MMConnectionError(0); !isStringerFallback(e.String()); e++ {
+		text, _ := e.Describe()
+		if text == "" || strings.Contains(text, "unrecognized") {
+			t.Errorf("%v has no description", e)
+		}
+		n++
+	}
+	if n != len(connectionErrorNames) {
+		t.Errorf("iterated %d errors, but %d error names are mapped", n, len(connectionErrorNames))
+	}
+
+	if e, ok := ConnectionErrorFromName("org.freedesktop.ModemManager1.Error.Connection.NoCarrier"); !ok || e != MmConnectionErrorNoCarrier {
+		t.Errorf("ConnectionErrorFromName(NoCarrier) = %v, %v", e, ok)
+	}
+	if _, ok := ConnectionErrorFromName("org.freedesktop.DBus.Error.Failed"); ok {
+		t.Error("ConnectionErrorFromName accepted a non-connection error")
+	}
+}
@@ -759,6 +759,7 @@ const (
 	MmBearerIpFamilyIpv4   MMBearerIpFamily = 1 << 0     // IPv4.
 	MmBearerIpFamilyIpv6   MMBearerIpFamily = 1 << 1     // IPv6.
 	MmBearerIpFamilyIpv4v6 MMBearerIpFamily = 1 << 2     // IPv4 and IPv6.
+	MmBearerIpFamilyNonIp  MMBearerIpFamily = 1 << 3     // Non-IP bearer. Since: 1.20.
 	MmBearerIpFamilyAny    MMBearerIpFamily = 0xFFFFFFFF // Mask specifying all IP families.
 
 )
@@ -767,7 +768,7 @@ const (
 func (i MMBearerIpFamily) GetAllIPFamilies() []MMBearerIpFamily {
 
 	return []MMBearerIpFamily{MmBearerIpFamilyIpv4, MmBearerIpFamilyIpv6,
-		MmBearerIpFamilyIpv4v6}
+		MmBearerIpFamilyIpv4v6, MmBearerIpFamilyNonIp}
 }
 
 // BitmaskToSlice bitmask to slice
@@ -891,7 +892,7 @@ const (
 func (f MMModem3gppFacility) GetAllFacilities() []MMModem3gppFacility {
 
 	return []MMModem3gppFacility{MmModem3gppFacilitySim,
-		MmModem3gppFacilityFixedDialing, MmModem3gppFacilityPhSim, MmModem3gppFacilityNetPers,
+		MmModem3gppFacilityFixedDialing, MmModem3gppFacilityPhSim, MmModem3gppFacilityPhFsim, MmModem3gppFacilityNetPers,
 		MmModem3gppFacilityNetSubPers, MmModem3gppFacilityProviderPers, MmModem3gppFacilityCorpPers}
 }
 
@@ -1170,6 +1171,7 @@ type MMLoggingLevel string
 const (
 	MMLoggingLevelError   MMLoggingLevel = "ERR"   // logging level error.
 	MMLoggingLevelWarning MMLoggingLevel = "WARN"  // logging level warning.
+	MMLoggingLevelInfo    MMLoggingLevel = "INFO"  // logging level info.
 	MMLoggingLevelDebug   MMLoggingLevel = "DEBUG" // logging level debug.
 
 )
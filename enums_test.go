@@ -0,0 +1,46 @@
+package modemmanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIpFamilyBitmaskToSlice(t *testing.T) {
+	var family MMBearerIpFamily
+	tests := []struct {
+		bitmask uint32
+		want    []MMBearerIpFamily
+	}{
+		{0, nil},
+		{uint32(MmBearerIpFamilyIpv4), []MMBearerIpFamily{MmBearerIpFamilyIpv4}},
+		{uint32(MmBearerIpFamilyIpv4 | MmBearerIpFamilyIpv6 | MmBearerIpFamilyIpv4v6), []MMBearerIpFamily{MmBearerIpFamilyIpv4, MmBearerIpFamilyIpv6, MmBearerIpFamilyIpv4v6}},
+		{uint32(MmBearerIpFamilyNonIp), []MMBearerIpFamily{MmBearerIpFamilyNonIp}},
+		// "Any" sets every bit and so decomposes into all known families
+		{uint32(MmBearerIpFamilyAny), []MMBearerIpFamily{MmBearerIpFamilyIpv4, MmBearerIpFamilyIpv6, MmBearerIpFamilyIpv4v6, MmBearerIpFamilyNonIp}},
+	}
+	for _, tt := range tests {
+		if got := family.BitmaskToSlice(tt.bitmask); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BitmaskToSlice(%#x) = %v, want %v", tt.bitmask, got, tt.want)
+		}
+	}
+
+	all := family.GetAllIPFamilies()
+	if got := family.BitmaskToSlice(family.SliceToBitmask(all)); !reflect.DeepEqual(got, all) {
+		t.Errorf("round trip of all families = %v, want %v", got, all)
+	}
+}
+
+func TestFacilityBitmaskToSlice(t *testing.T) {
+	var facility MMModem3gppFacility
+	for bit := 0; bit < 8; bit++ {
+		want := []MMModem3gppFacility{MMModem3gppFacility(1 << bit)}
+		if got := facility.BitmaskToSlice(1 << bit); !reflect.DeepEqual(got, want) {
+			t.Errorf("BitmaskToSlice(%#x) = %v, want %v", 1<<bit, got, want)
+		}
+	}
+
+	all := facility.GetAllFacilities()
+	if got := facility.BitmaskToSlice(facility.SliceToBitmask(all)); !reflect.DeepEqual(got, all) {
+		t.Errorf("round trip of all facilities = %v, want %v", got, all)
+	}
+}
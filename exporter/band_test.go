@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestBandMetrics(t *testing.T) {
+	e := NewExporter(&fakeSource{}, WithBandMetrics(true))
+	modem := &fakeModem{
+		deviceID:     "dev",
+		currentBands: []modemmanager.MMModemBand{modemmanager.MmModemBandEutran20, modemmanager.MmModemBandEutran3},
+		bands: []modemmanager.MMModemBand{
+			modemmanager.MmModemBandEutran1, modemmanager.MmModemBandEutran3,
+			modemmanager.MmModemBandEutran7, modemmanager.MmModemBandEutran20,
+		},
+	}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModemMetrics(ch, modem, "dev")
+	})
+
+	var bands []string
+	for _, m := range metrics {
+		if m.name == "modemmanager_modem_current_band" {
+			bands = append(bands, m.labels["band"])
+		}
+	}
+	if len(bands) != 2 || bands[0] != "Eutran3" || bands[1] != "Eutran20" {
+		t.Errorf("current bands = %v, want [Eutran3 Eutran20]", bands)
+	}
+	if m, ok := findMetric(metrics, "modemmanager_modem_supported_band_count"); !ok || m.value != 4 {
+		t.Errorf("supported_band_count = %v (emitted %v), want 4", m.value, ok)
+	}
+}
+
+func TestBandMetricsDisabledByDefault(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", currentBands: []modemmanager.MMModemBand{modemmanager.MmModemBandEutran3}}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModemMetrics(ch, modem, "dev")
+	})
+	for _, name := range []string{"modemmanager_modem_current_band", "modemmanager_modem_supported_band_count"} {
+		if _, ok := findMetric(metrics, name); ok {
+			t.Errorf("%s emitted without WithBandMetrics", name)
+		}
+	}
+}
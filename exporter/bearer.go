@@ -0,0 +1,217 @@
+package exporter
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WithBearerPathLabels labels bearer metrics with the bearer object path
+// instead of the stable bearer label. ModemManager allocates a new path for
+// every bearer it creates, so each reconnect starts new series.
+func WithBearerPathLabels(enabled bool) Option {
+	return func(e *Exporter) {
+		e.bearerPathLabels = enabled
+	}
+}
+
+// initBearerDescs creates the descriptors of the bearer metrics, which are
+// labelled according to WithBearerPathLabels.
+func (e *Exporter) initBearerDescs() {
+	bearerLabel := "bearer"
+	infoLabels := []string{"device_id", "bearer", "bearer_path", "interface", "ip_method", "ip_address"}
+	if e.bearerPathLabels {
+		bearerLabel = "bearer_path"
+		infoLabels = []string{"device_id", "bearer_path", "interface", "ip_method", "ip_address"}
+	}
+
+	e.bearerInfo = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "info"),
+		"Bearer information",
+		infoLabels,
+		nil,
+	)
+	e.bearerConnected = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "connected"),
+		"Bearer connection status (1 = connected, 0 = disconnected)",
+		[]string{"device_id", bearerLabel},
+		nil,
+	)
+	e.bearerIp6Info = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "ip6_info"),
+		"Bearer IPv6 configuration",
+		[]string{"device_id", bearerLabel, "ip_method", "ip_address", "ip_prefix"},
+		nil,
+	)
+	e.bearerSuspended = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "suspended"),
+		"Whether the bearer is suspended by the network (1 = suspended, 0 = not suspended)",
+		[]string{"device_id", bearerLabel},
+		nil,
+	)
+	e.bearerIpTimeout = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "ip_timeout_seconds"),
+		"Maximum time to wait for a successful IP establishment",
+		[]string{"device_id", bearerLabel},
+		nil,
+	)
+	e.bearerAttempts = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "connection_attempts_total"),
+		"Number of connection attempts done with the bearer",
+		[]string{"device_id", bearerLabel},
+		nil,
+	)
+	e.bearerFailedAttempts = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "failed_attempts_total"),
+		"Number of failed connection attempts done with the bearer",
+		[]string{"device_id", bearerLabel},
+		nil,
+	)
+	e.bearerRxBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "received_bytes_total"),
+		"Bytes received in all connections of the bearer",
+		[]string{"device_id", bearerLabel},
+		nil,
+	)
+	e.bearerTxBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bearer", "transmitted_bytes_total"),
+		"Bytes transmitted in all connections of the bearer",
+		[]string{"device_id", bearerLabel},
+		nil,
+	)
+}
+
+// bearerLabels returns the bearer label value of each bearer, which must be
+// sorted by path. By default this is the APN and IP type of the bearer, e.g.
+// "internet/ipv4v6", which the bearer ModemManager creates on reconnect
+// shares with the one it replaces. A bearer whose settings cannot be read
+// is labelled by its position, e.g. "slot1". Bearers sharing a value get
+// "#2", "#3" and so on appended in path order.
+func (e *Exporter) bearerLabels(bearers []modemmanager.Bearer) []string {
+	labels := make([]string, len(bearers))
+	seen := make(map[string]int)
+	for i, bearer := range bearers {
+		if e.bearerPathLabels {
+			labels[i] = string(bearer.GetObjectPath())
+			continue
+		}
+
+		label := "slot" + strconv.Itoa(i)
+		if properties, err := bearer.GetProperties(); err == nil {
+			label = properties.APN
+			if label == "" {
+				label = "default"
+			}
+			if properties.IPType != modemmanager.MmBearerIpFamilyNone {
+				label += "/" + ipFamilyToString(properties.IPType)
+			}
+		}
+		seen[label]++
+		if n := seen[label]; n > 1 {
+			label += "#" + strconv.Itoa(n)
+		}
+		labels[i] = label
+	}
+	return labels
+}
+
+// watchBearers follows the Connected property of the bearers of a modem
+// until the watch is closed, so disconnects between scrapes are counted.
+// The bearer list is read again every resync interval to pick up bearers
+// ModemManager created since.
+func (e *Exporter) watchBearers(w *modemWatch, modem modemmanager.Modem, deviceID string) {
+	ticker := e.clock.NewTicker(e.eventResync)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer ticker.Stop()
+
+		var wg sync.WaitGroup
+		watched := make(map[dbus.ObjectPath]chan struct{})
+		defer func() {
+			for _, stop := range watched {
+				close(stop)
+			}
+			wg.Wait()
+		}()
+
+		for {
+			bearers, err := modemmanager.ListModemBearers(modem)
+			if err == nil {
+				current := make(map[dbus.ObjectPath]bool)
+				for _, bearer := range bearers {
+					path := bearer.GetObjectPath()
+					current[path] = true
+					if _, ok := watched[path]; ok {
+						continue
+					}
+					stop := make(chan struct{})
+					watched[path] = stop
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						e.watchBearerConnected(bearer, deviceID, stop)
+					}()
+				}
+				for path, stop := range watched {
+					if !current[path] {
+						close(stop)
+						delete(watched, path)
+					}
+				}
+			}
+
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+}
+
+// watchBearerConnected records the connected state of a bearer from its
+// PropertiesChanged signals until stop is closed.
+func (e *Exporter) watchBearerConnected(bearer modemmanager.Bearer, deviceID string, stop <-chan struct{}) {
+	properties, err := bearer.GetProperties()
+	if err != nil {
+		e.logger.Debug("Error getting settings of bearer", "bearer", bearer.GetObjectPath(), "err", err)
+		return
+	}
+	path := bearer.GetObjectPath()
+
+	// Subscribe before reading the state so no change is lost in between
+	signals := bearer.SubscribePropertiesChanged()
+	defer bearer.Unsubscribe()
+	if connected, err := bearer.GetConnected(); err == nil {
+		e.observeBearerConnected(deviceID, properties.APN, connected)
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case sig, ok := <-signals:
+			if !ok {
+				return
+			}
+			// The channel receives every signal routed to the connection
+			if sig.Path != path || sig.Name != propertiesChangedSignal {
+				continue
+			}
+			iface, changed, _, err := bearer.ParsePropertiesChanged(sig)
+			if err != nil || iface != modemmanager.BearerInterface {
+				continue
+			}
+			if value, ok := changed["Connected"]; ok {
+				if connected, ok := value.Value().(bool); ok {
+					e.observeBearerConnected(deviceID, properties.APN, connected)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package exporter
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectBearerIPv6(t *testing.T) {
+	modem := &fakeModem{deviceID: "dev", bearers: []modemmanager.Bearer{
+		&fakeBearer{
+			path:      "/org/freedesktop/ModemManager1/Bearer/0",
+			iface:     "wwan0",
+			connected: true,
+			ip4:       modemmanager.BearerIpConfig{Method: modemmanager.MmBearerIpMethodStatic, Address: "10.0.0.2", Prefix: 30},
+			ip6:       modemmanager.BearerIpConfig{Method: modemmanager.MmBearerIpMethodDhcp, Address: "2001:db8::1", Prefix: 64},
+		},
+		&fakeBearer{
+			path:      "/org/freedesktop/ModemManager1/Bearer/1",
+			iface:     "wwan1",
+			connected: true,
+			ip4:       modemmanager.BearerIpConfig{Method: modemmanager.MmBearerIpMethodDhcp, Address: "10.0.1.2"},
+			ip6Err:    errNotSupported,
+		},
+	}}
+	e := NewExporter(&fakeSource{}, WithBearerPathLabels(true))
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectBearerMetrics(ch, modem, "dev")
+	})
+
+	var ip6 []collectedMetric
+	for _, m := range metrics {
+		if m.name == "modemmanager_bearer_ip6_info" {
+			ip6 = append(ip6, m)
+		}
+	}
+	if len(ip6) != 1 {
+		t.Fatalf("got %d bearer_ip6_info series, want 1", len(ip6))
+	}
+	want := map[string]string{
+		"bearer_path": "/org/freedesktop/ModemManager1/Bearer/0",
+		"ip_method":   "dhcp",
+		"ip_address":  "2001:db8::1",
+		"ip_prefix":   "64",
+	}
+	for k, v := range want {
+		if ip6[0].labels[k] != v {
+			t.Errorf("bearer_ip6_info label %s = %q, want %q", k, ip6[0].labels[k], v)
+		}
+	}
+
+	info, _ := findMetric(metrics, "modemmanager_bearer_info")
+	if info.labels["ip_method"] != "static" {
+		t.Errorf("bearer_info ip_method = %q, want static", info.labels["ip_method"])
+	}
+}
+
+func TestCollectBearerSuspendedAndIPTimeout(t *testing.T) {
+	modem := &fakeModem{deviceID: "dev", bearers: []modemmanager.Bearer{
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/0", connected: true, suspended: true, ipTimeout: 20},
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/1", connected: false, ipTimeout: 30},
+	}}
+	e := NewExporter(&fakeSource{}, WithBearerPathLabels(true))
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectBearerMetrics(ch, modem, "dev")
+	})
+
+	suspended := make(map[string]float64)
+	timeouts := make(map[string]float64)
+	for _, m := range metrics {
+		switch m.name {
+		case "modemmanager_bearer_suspended":
+			suspended[m.labels["bearer_path"]] = m.value
+		case "modemmanager_bearer_ip_timeout_seconds":
+			timeouts[m.labels["bearer_path"]] = m.value
+		}
+	}
+
+	wantSuspended := map[string]float64{
+		"/org/freedesktop/ModemManager1/Bearer/0": 1,
+		"/org/freedesktop/ModemManager1/Bearer/1": 0,
+	}
+	wantTimeouts := map[string]float64{
+		"/org/freedesktop/ModemManager1/Bearer/0": 20,
+		"/org/freedesktop/ModemManager1/Bearer/1": 30,
+	}
+	for path, v := range wantSuspended {
+		if got, ok := suspended[path]; !ok || got != v {
+			t.Errorf("bearer_suspended{%s} = %v (emitted %v), want %v", path, got, ok, v)
+		}
+	}
+	for path, v := range wantTimeouts {
+		if got, ok := timeouts[path]; !ok || got != v {
+			t.Errorf("bearer_ip_timeout_seconds{%s} = %v (emitted %v), want %v", path, got, ok, v)
+		}
+	}
+}
+
+func TestCollectBearerLifetimeStats(t *testing.T) {
+	modem := &fakeModem{deviceID: "dev", bearers: []modemmanager.Bearer{
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/0", connected: true, stats: modemmanager.BearerStats{
+			RxBytes: 10, TxBytes: 20, Attempts: 3, FailedAttempts: 1, TotalRxBytes: 1000, TotalTxBytes: 2000,
+		}},
+		// Older daemons only report the current connection
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/1", connected: true, stats: modemmanager.BearerStats{
+			RxBytes: 10, TxBytes: 20, Duration: 60,
+		}},
+	}}
+	e := NewExporter(&fakeSource{}, WithBearerPathLabels(true))
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectBearerMetrics(ch, modem, "dev")
+	})
+
+	want := map[string]float64{
+		"modemmanager_bearer_connection_attempts_total": 3,
+		"modemmanager_bearer_failed_attempts_total":     1,
+		"modemmanager_bearer_received_bytes_total":      1000,
+		"modemmanager_bearer_transmitted_bytes_total":   2000,
+	}
+	got := make(map[string]float64)
+	for _, m := range metrics {
+		if _, ok := want[m.name]; !ok {
+			continue
+		}
+		if m.labels["bearer_path"] != "/org/freedesktop/ModemManager1/Bearer/0" {
+			t.Errorf("%s emitted for %s without lifetime statistics", m.name, m.labels["bearer_path"])
+			continue
+		}
+		got[m.name] = m.value
+	}
+	for name, v := range want {
+		if g, ok := got[name]; !ok || g != v {
+			t.Errorf("%s = %v (emitted %v), want %v", name, g, ok, v)
+		}
+	}
+}
+
+// listOnlyModem is a modem whose ModemManager lacks the Bearers property and
+// only answers the deprecated ListBearers method
+type listOnlyModem struct {
+	*fakeModem
+	listed []modemmanager.Bearer
+}
+
+func (m listOnlyModem) GetBearers() ([]modemmanager.Bearer, error) {
+	return nil, dbus.Error{Name: "org.freedesktop.DBus.Error.InvalidArgs"}
+}
+
+func (m listOnlyModem) ListBearers() ([]modemmanager.Bearer, error) {
+	return m.listed, nil
+}
+
+func TestCollectBearerEnumeration(t *testing.T) {
+	bearers := []modemmanager.Bearer{&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/0", iface: "wwan0", connected: true}}
+	tests := []struct {
+		name  string
+		modem modemmanager.Modem
+	}{
+		{"property", &fakeModem{deviceID: "dev", bearers: bearers}},
+		{"list method", listOnlyModem{fakeModem: &fakeModem{deviceID: "dev"}, listed: bearers}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExporter(&fakeSource{})
+			metrics := gather(t, func(ch chan<- prometheus.Metric) {
+				e.collectBearerMetrics(ch, tt.modem, "dev")
+			})
+			info, ok := findMetric(metrics, "modemmanager_bearer_info")
+			if !ok {
+				t.Fatal("bearer_info missing")
+			}
+			if info.labels["bearer_path"] != "/org/freedesktop/ModemManager1/Bearer/0" {
+				t.Errorf("bearer_info bearer_path = %q", info.labels["bearer_path"])
+			}
+		})
+	}
+}
+
+func TestStableBearerLabels(t *testing.T) {
+	internet := modemmanager.BearerProperty{APN: "internet", IPType: modemmanager.MmBearerIpFamilyIpv4v6}
+	modem := &fakeModem{deviceID: "dev", bearers: []modemmanager.Bearer{
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/4", connected: true, settings: internet},
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/5", settings: modemmanager.BearerProperty{APN: "iot"}},
+	}}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}})
+
+	labelSets := func() map[string]string {
+		sets := make(map[string]string)
+		for _, m := range gather(t, e.Collect) {
+			switch m.name {
+			case "modemmanager_bearer_connected":
+				if _, ok := m.labels["bearer_path"]; ok {
+					t.Errorf("bearer_connected carries bearer_path %q", m.labels["bearer_path"])
+				}
+				sets[m.labels["bearer"]] = "connected=" + strconv.FormatFloat(m.value, 'g', -1, 64)
+			case "modemmanager_bearer_info":
+				sets["info "+m.labels["bearer"]] = m.labels["bearer_path"]
+			}
+		}
+		return sets
+	}
+
+	before := labelSets()
+	want := map[string]string{
+		"internet/ipv4v6":      "connected=1",
+		"iot":                  "connected=0",
+		"info internet/ipv4v6": "/org/freedesktop/ModemManager1/Bearer/4",
+		"info iot":             "/org/freedesktop/ModemManager1/Bearer/5",
+	}
+	if !reflect.DeepEqual(before, want) {
+		t.Fatalf("series = %v, want %v", before, want)
+	}
+
+	// Reconnecting replaces the bearer with a new object of the same settings
+	reconnected := &fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/6", connected: true, settings: internet}
+	modem.bearers = []modemmanager.Bearer{modem.bearers[1], reconnected}
+	after := labelSets()
+	if after["internet/ipv4v6"] != "connected=1" || after["iot"] != "connected=0" || len(after) != len(before) {
+		t.Errorf("series after reconnect = %v, want the label sets of %v", after, before)
+	}
+	if got := after["info internet/ipv4v6"]; got != "/org/freedesktop/ModemManager1/Bearer/6" {
+		t.Errorf("bearer_info bearer_path after reconnect = %q, want the new path", got)
+	}
+
+	// Removed bearers are no longer emitted
+	modem.bearers = []modemmanager.Bearer{reconnected}
+	if got := labelSets(); len(got) != 2 || got["iot"] != "" {
+		t.Errorf("series after removing a bearer = %v", got)
+	}
+}
+
+func TestBearerLabelsDisambiguate(t *testing.T) {
+	same := modemmanager.BearerProperty{APN: "internet", IPType: modemmanager.MmBearerIpFamilyIpv4}
+	bearers := []modemmanager.Bearer{
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/0", settings: same},
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/1", settings: same},
+		&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/2"},
+	}
+	got := NewExporter(&fakeSource{}).bearerLabels(bearers)
+	want := []string{"internet/ipv4", "internet/ipv4#2", "default"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bearerLabels = %v, want %v", got, want)
+	}
+
+	paths := NewExporter(&fakeSource{}, WithBearerPathLabels(true)).bearerLabels(bearers)
+	if paths[1] != "/org/freedesktop/ModemManager1/Bearer/1" {
+		t.Errorf("bearerLabels with path labels = %v", paths)
+	}
+}
+
+func TestBearerDisconnectsCounted(t *testing.T) {
+	internet := modemmanager.BearerProperty{APN: "internet", IPType: modemmanager.MmBearerIpFamilyIpv4}
+	modem := &fakeModem{deviceID: "dev", bearers: []modemmanager.Bearer{}}
+	source := &fakeSource{modems: []modemmanager.Modem{modem}}
+	e := NewExporter(source)
+	disconnects := func() float64 {
+		t.Helper()
+		m, ok := findMetric(gather(t, e.Collect), "modemmanager_bearer_disconnects_total")
+		if !ok {
+			t.Fatal("bearer_disconnects_total missing")
+		}
+		return m.value
+	}
+
+	steps := []struct {
+		name    string
+		bearers []modemmanager.Bearer
+		want    float64
+	}{
+		{"connect", []modemmanager.Bearer{&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/0", connected: true, settings: internet}}, 0},
+		{"disconnect", []modemmanager.Bearer{&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/0", settings: internet}}, 1},
+		{"reconnect with a new bearer", []modemmanager.Bearer{&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/1", connected: true, settings: internet}}, 1},
+		{"bearer deleted while connected", []modemmanager.Bearer{}, 2},
+		{"still no bearer", []modemmanager.Bearer{}, 2},
+	}
+	for _, step := range steps {
+		modem.bearers = step.bearers
+		if got := disconnects(); got != step.want {
+			t.Errorf("%s: disconnects_total = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	// The counter survives the modem being briefly gone
+	source.modems = nil
+	gather(t, e.Collect)
+	source.modems = []modemmanager.Modem{modem}
+	if got := disconnects(); got != 2 {
+		t.Errorf("disconnects_total after the modem came back = %v, want 2", got)
+	}
+}
+
+func TestBearerDisconnectsFromSignals(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	bearer := &fakeBearer{
+		path:      "/org/freedesktop/ModemManager1/Bearer/0",
+		connected: true,
+		settings:  modemmanager.BearerProperty{APN: "internet"},
+		changes:   make(chan *dbus.Signal),
+	}
+	modem := &fakeModem{path: "/Modem/0", deviceID: "dev", stateChanges: make(chan *dbus.Signal), bearers: []modemmanager.Bearer{bearer}}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}}, WithClock(clk))
+	stop := startEvents(t, e, clk)
+	defer stop()
+
+	connected := func(path dbus.ObjectPath, value bool) *dbus.Signal {
+		return &dbus.Signal{Path: path, Name: propertiesChangedSignal, Body: []interface{}{
+			modemmanager.BearerInterface, map[string]dbus.Variant{"Connected": dbus.MakeVariant(value)},
+		}}
+	}
+	// A disconnect and reconnect between two scrapes, and a signal of
+	// another bearer on the shared connection
+	bearer.changes <- connected(bearer.path, false)
+	bearer.changes <- connected(bearer.path, true)
+	bearer.changes <- connected("/org/freedesktop/ModemManager1/Bearer/7", false)
+	bearer.changes <- &dbus.Signal{Path: bearer.path, Name: "org.example.Flush"}
+
+	m, ok := findMetric(gather(t, e.collectBearerDisconnects), "modemmanager_bearer_disconnects_total")
+	if !ok || m.value != 1 {
+		t.Errorf("disconnects_total = %v (emitted %v), want 1", m.value, ok)
+	}
+}
@@ -0,0 +1,259 @@
+package exporter
+
+import (
+	"sort"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Full names of the voice and call signals
+const (
+	callAddedSignal        = modemmanager.ModemVoiceInterface + "." + modemmanager.ModemVoiceSignalCallAdded
+	callDeletedSignal      = modemmanager.ModemVoiceInterface + "." + modemmanager.ModemVoiceSignalCallDeleted
+	callStateChangedSignal = modemmanager.CallInterface + "." + modemmanager.CallSignalStateChanged
+)
+
+// callCounters holds the voice call counters of one modem
+type callCounters struct {
+	total  map[string]uint64 // by direction
+	missed uint64
+	active map[dbus.ObjectPath]bool // nil while the modem is not watched
+	calls  map[dbus.ObjectPath]*trackedCall
+}
+
+// trackedCall is a call seen through CallAdded that has not been deleted yet
+type trackedCall struct {
+	incoming   bool
+	everActive bool
+	ended      bool // terminated or deleted; missed calls are counted once
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// watchVoiceCalls counts the calls of a modem from CallAdded and CallDeleted
+// signals and follows the state of each call until it is deleted.
+func (e *Exporter) watchVoiceCalls(w *modemWatch, voice modemmanager.ModemVoice, path dbus.ObjectPath, deviceID string) {
+	added := voice.SubscribeCallAdded()
+	deleted := voice.SubscribeCallDeleted()
+
+	e.mu.Lock()
+	counters, ok := e.callCounters[deviceID]
+	if !ok {
+		counters = &callCounters{total: map[string]uint64{
+			callDirectionToString(modemmanager.MmCallDirectionIncoming): 0,
+			callDirectionToString(modemmanager.MmCallDirectionOutgoing): 0,
+		}}
+		e.callCounters[deviceID] = counters
+	}
+	counters.active = make(map[dbus.ObjectPath]bool)
+	counters.calls = make(map[dbus.ObjectPath]*trackedCall)
+	e.mu.Unlock()
+
+	// Calls in progress when the watch starts
+	if calls, err := voice.GetCalls(); err == nil {
+		for _, call := range calls {
+			e.trackCall(deviceID, call)
+		}
+	}
+
+	handle := func(sig *dbus.Signal) {
+		if sig.Path != path {
+			return
+		}
+		switch sig.Name {
+		case callAddedSignal:
+			call, err := voice.ParseCallAdded(sig)
+			if err != nil {
+				e.logger.Debug("Error parsing added call", "device_id", deviceID, "err", err)
+				return
+			}
+			e.trackCall(deviceID, call)
+		case callDeletedSignal:
+			callPath, err := voice.ParseCallDeleted(sig)
+			if err != nil {
+				e.logger.Debug("Error parsing deleted call", "device_id", deviceID, "err", err)
+				return
+			}
+			e.untrackCall(deviceID, callPath)
+		}
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer voice.Unsubscribe()
+		defer e.untrackAllCalls(deviceID)
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case sig, ok := <-added:
+				if !ok {
+					return
+				}
+				handle(sig)
+			case sig, ok := <-deleted:
+				if !ok {
+					return
+				}
+				handle(sig)
+			}
+		}
+	}()
+}
+
+// trackCall counts a new call and follows its StateChanged signals
+func (e *Exporter) trackCall(deviceID string, call modemmanager.Call) {
+	path := call.GetObjectPath()
+	direction, err := call.GetDirection()
+	if err != nil {
+		e.logger.Debug("Error getting direction of call", "call", path, "err", err)
+		return
+	}
+
+	t := &trackedCall{
+		incoming: direction == modemmanager.MmCallDirectionIncoming,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	e.mu.Lock()
+	counters := e.callCounters[deviceID]
+	if _, ok := counters.calls[path]; ok {
+		e.mu.Unlock()
+		return
+	}
+	counters.calls[path] = t
+	counters.total[callDirectionToString(direction)]++
+	e.mu.Unlock()
+
+	// Subscribe before reading the state so no change is lost in between
+	signals := call.SubscribeStateChanged()
+	if state, err := call.GetState(); err == nil {
+		e.recordCallState(deviceID, path, state)
+	}
+
+	go func() {
+		defer close(t.done)
+		defer call.Unsubscribe()
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case sig, ok := <-signals:
+				if !ok {
+					return
+				}
+				if sig.Path != path || sig.Name != callStateChangedSignal {
+					continue
+				}
+				_, newState, _, err := call.ParseStateChanged(sig)
+				if err != nil {
+					e.logger.Debug("Error parsing state change of call", "call", path, "err", err)
+					continue
+				}
+				e.recordCallState(deviceID, path, newState)
+			}
+		}
+	}()
+}
+
+func (e *Exporter) recordCallState(deviceID string, path dbus.ObjectPath, state modemmanager.MMCallState) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	counters := e.callCounters[deviceID]
+	t, ok := counters.calls[path]
+	if !ok {
+		return
+	}
+
+	switch state {
+	case modemmanager.MmCallStateActive:
+		t.everActive = true
+		counters.active[path] = true
+	case modemmanager.MmCallStateTerminated:
+		delete(counters.active, path)
+		counters.endCall(t)
+	default:
+		delete(counters.active, path)
+	}
+}
+
+// endCall counts an incoming call that ended without being answered
+func (c *callCounters) endCall(t *trackedCall) {
+	if t.ended {
+		return
+	}
+	t.ended = true
+	if t.incoming && !t.everActive {
+		c.missed++
+	}
+}
+
+// untrackCall stops following a deleted call. A call deleted before its
+// Terminated state was seen still counts as missed if it was never answered.
+func (e *Exporter) untrackCall(deviceID string, path dbus.ObjectPath) {
+	e.mu.Lock()
+	counters := e.callCounters[deviceID]
+	t, ok := counters.calls[path]
+	if ok {
+		delete(counters.calls, path)
+		delete(counters.active, path)
+		counters.endCall(t)
+	}
+	e.mu.Unlock()
+
+	if ok {
+		close(t.stop)
+		<-t.done
+	}
+}
+
+// untrackAllCalls stops following the calls of a modem that is no longer watched
+func (e *Exporter) untrackAllCalls(deviceID string) {
+	e.mu.Lock()
+	counters := e.callCounters[deviceID]
+	calls := counters.calls
+	counters.calls = nil
+	counters.active = nil
+	e.mu.Unlock()
+
+	for _, t := range calls {
+		close(t.stop)
+		<-t.done
+	}
+}
+
+// collectCallCounters emits the voice call counters accumulated by Start
+func (e *Exporter) collectCallCounters(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	deviceIDs := make([]string, 0, len(e.callCounters))
+	for deviceID := range e.callCounters {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+
+	for _, deviceID := range deviceIDs {
+		counters := e.callCounters[deviceID]
+
+		directions := make([]string, 0, len(counters.total))
+		for direction := range counters.total {
+			directions = append(directions, direction)
+		}
+		sort.Strings(directions)
+		for _, direction := range directions {
+			ch <- prometheus.MustNewConstMetric(e.voiceCallsTotal, prometheus.CounterValue, float64(counters.total[direction]), deviceID, direction)
+		}
+
+		ch <- prometheus.MustNewConstMetric(e.voiceMissedCallsTotal, prometheus.CounterValue, float64(counters.missed), deviceID)
+		if counters.active != nil {
+			ch <- prometheus.MustNewConstMetric(e.voiceActiveCalls, prometheus.GaugeValue, float64(len(counters.active)), deviceID)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+)
+
+func callSignal(name string, modem, call dbus.ObjectPath) *dbus.Signal {
+	return &dbus.Signal{Path: modem, Name: name, Body: []interface{}{call}}
+}
+
+func callStateChanged(path dbus.ObjectPath, oldState, newState modemmanager.MMCallState) *dbus.Signal {
+	return &dbus.Signal{
+		Path: path,
+		Name: callStateChangedSignal,
+		Body: []interface{}{int32(oldState), int32(newState), uint32(modemmanager.MmCallStateReasonUnknown)},
+	}
+}
+
+// callMetrics returns the call counters of dev keyed by metric name and direction
+func callMetrics(t *testing.T, e *Exporter) map[string]float64 {
+	t.Helper()
+	got := make(map[string]float64)
+	for _, m := range gather(t, e.collectCallCounters) {
+		key := m.name
+		if direction, ok := m.labels["direction"]; ok {
+			key += "/" + direction
+		}
+		got[key] = m.value
+	}
+	return got
+}
+
+func expectCallMetrics(t *testing.T, e *Exporter, want map[string]float64) {
+	t.Helper()
+	got := callMetrics(t, e)
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v (all: %v)", k, got[k], v, got)
+		}
+	}
+}
+
+// watchedCalls returns the number of calls tracked for dev, or -1 while dev
+// is not watched
+func watchedCalls(e *Exporter) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	counters, ok := e.callCounters["dev"]
+	if !ok || counters.calls == nil {
+		return -1
+	}
+	return len(counters.calls)
+}
+
+func TestVoiceCallLifecycle(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	voice := &fakeVoice{signals: make(chan *dbus.Signal), added: make(map[dbus.ObjectPath]*fakeCall)}
+	modem := &fakeModem{path: "/Modem/0", deviceID: "dev", stateChanges: make(chan *dbus.Signal), voice: voice}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}}, WithClock(clk))
+	stop := startEvents(t, e, clk)
+	waitFor(t, "voice watch", func() bool { return watchedCalls(e) == 0 })
+
+	addCall := func(path dbus.ObjectPath, direction modemmanager.MMCallDirection, state modemmanager.MMCallState) *fakeCall {
+		call := &fakeCall{path: path, direction: direction, state: state, stateChanges: make(chan *dbus.Signal)}
+		voice.added[path] = call
+		voice.signals <- callSignal(callAddedSignal, "/Modem/0", path)
+		flush(t, voice.signals)
+		return call
+	}
+	deleteCall := func(path dbus.ObjectPath) {
+		voice.signals <- callSignal(callDeletedSignal, "/Modem/0", path)
+		flush(t, voice.signals)
+	}
+
+	expectCallMetrics(t, e, map[string]float64{
+		"modemmanager_voice_calls_total/incoming": 0,
+		"modemmanager_voice_calls_total/outgoing": 0,
+		"modemmanager_voice_missed_calls_total":   0,
+		"modemmanager_voice_active_calls":         0,
+	})
+
+	// Ring-only wake-up: rings, then terminates without being answered
+	ring := addCall("/Call/1", modemmanager.MmCallDirectionIncoming, modemmanager.MmCallStateRingingIn)
+	ring.stateChanges <- callStateChanged("/Call/1", modemmanager.MmCallStateRingingIn, modemmanager.MmCallStateTerminated)
+	flush(t, ring.stateChanges)
+	deleteCall("/Call/1")
+	if ring.unsubscribed.Load() != 1 {
+		t.Errorf("deleted call unsubscribed %d times, want 1", ring.unsubscribed.Load())
+	}
+
+	// Answered call
+	answered := addCall("/Call/2", modemmanager.MmCallDirectionIncoming, modemmanager.MmCallStateRingingIn)
+	answered.stateChanges <- callStateChanged("/Call/2", modemmanager.MmCallStateRingingIn, modemmanager.MmCallStateActive)
+	flush(t, answered.stateChanges)
+	expectCallMetrics(t, e, map[string]float64{"modemmanager_voice_active_calls": 1})
+	answered.stateChanges <- callStateChanged("/Call/2", modemmanager.MmCallStateActive, modemmanager.MmCallStateTerminated)
+	flush(t, answered.stateChanges)
+	deleteCall("/Call/2")
+
+	// Outgoing call that is never answered is not missed
+	dialed := addCall("/Call/3", modemmanager.MmCallDirectionOutgoing, modemmanager.MmCallStateDialing)
+	dialed.stateChanges <- callStateChanged("/Call/3", modemmanager.MmCallStateDialing, modemmanager.MmCallStateTerminated)
+	flush(t, dialed.stateChanges)
+	deleteCall("/Call/3")
+
+	// Deleted while ringing, the Terminated state was never signalled
+	addCall("/Call/4", modemmanager.MmCallDirectionIncoming, modemmanager.MmCallStateRingingIn)
+	deleteCall("/Call/4")
+
+	// Signals of other calls and modems are ignored
+	voice.signals <- callSignal(callDeletedSignal, "/Modem/1", "/Call/2")
+	flush(t, voice.signals)
+
+	expectCallMetrics(t, e, map[string]float64{
+		"modemmanager_voice_calls_total/incoming": 3,
+		"modemmanager_voice_calls_total/outgoing": 1,
+		"modemmanager_voice_missed_calls_total":   2,
+		"modemmanager_voice_active_calls":         0,
+	})
+
+	if tracked := watchedCalls(e); tracked != 0 {
+		t.Errorf("%d calls still tracked after deletion, want 0", tracked)
+	}
+
+	stop()
+	if voice.unsubscribed.Load() != 1 {
+		t.Errorf("voice Unsubscribe called %d times on shutdown, want 1", voice.unsubscribed.Load())
+	}
+	if _, ok := callMetrics(t, e)["modemmanager_voice_active_calls"]; ok {
+		t.Error("active_calls emitted for a modem that is no longer watched")
+	}
+}
+
+func TestVoiceCallsInProgressAtStart(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	active := &fakeCall{path: "/Call/7", direction: modemmanager.MmCallDirectionIncoming, state: modemmanager.MmCallStateActive, stateChanges: make(chan *dbus.Signal)}
+	voice := &fakeVoice{signals: make(chan *dbus.Signal), calls: []modemmanager.Call{active}}
+	modem := &fakeModem{path: "/Modem/0", deviceID: "dev", stateChanges: make(chan *dbus.Signal), voice: voice}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}}, WithClock(clk))
+	stop := startEvents(t, e, clk)
+	waitFor(t, "call in progress", func() bool { return callMetrics(t, e)["modemmanager_voice_active_calls"] == 1 })
+
+	expectCallMetrics(t, e, map[string]float64{
+		"modemmanager_voice_calls_total/incoming": 1,
+		"modemmanager_voice_active_calls":         1,
+	})
+
+	stop()
+	if active.unsubscribed.Load() != 1 {
+		t.Errorf("tracked call unsubscribed %d times on shutdown, want 1", active.unsubscribed.Load())
+	}
+}
@@ -0,0 +1,83 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCDMAMetrics(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{
+		deviceID:     "dev",
+		capabilities: []modemmanager.MMModemCapability{modemmanager.MmModemCapabilityCdmaEvdo},
+		cdma: &fakeCdma{
+			cdma1x:     modemmanager.MmModemCdmaRegistrationStateHome,
+			evdo:       modemmanager.MmModemCdmaRegistrationStateRoaming,
+			activation: modemmanager.MmModemCdmaActivationStateActivated,
+			sid:        4183,
+			nid:        modemmanager.ModemCdmaNidUnknown,
+		},
+	}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectCDMAMetrics(ch, modem, "dev")
+	})
+
+	labels := map[string]string{
+		"modemmanager_modem_cdma1x_registration_state": "home",
+		"modemmanager_modem_evdo_registration_state":   "roaming",
+		"modemmanager_modem_cdma_activation_state":     "activated",
+	}
+	for name, state := range labels {
+		if m, ok := findMetric(metrics, name); !ok || m.labels["state"] != state {
+			t.Errorf("%s = %v (emitted %v), want state %s", name, m.labels, ok, state)
+		}
+	}
+	if m, ok := findMetric(metrics, "modemmanager_modem_cdma_sid"); !ok || m.value != 4183 {
+		t.Errorf("cdma_sid = %v (emitted %v), want 4183", m.value, ok)
+	}
+	if _, ok := findMetric(metrics, "modemmanager_modem_cdma_nid"); ok {
+		t.Error("cdma_nid emitted although unknown")
+	}
+}
+
+func TestCDMAMetricsSkippedWithoutCapability(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	// An LTE modem: GetCdma succeeds but every property read would fail
+	modem := &fakeModem{
+		deviceID:     "dev",
+		capabilities: []modemmanager.MMModemCapability{modemmanager.MmModemCapabilityGsmUmts, modemmanager.MmModemCapabilityLte},
+		cdma:         &fakeCdma{sid: 1},
+	}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModemMetrics(ch, modem, "dev")
+	})
+	for _, m := range metrics {
+		if strings.Contains(m.name, "cdma") || strings.Contains(m.name, "evdo") {
+			t.Errorf("%s emitted for a modem without CDMA capability", m.name)
+		}
+	}
+}
+
+func TestCDMAStateStrings(t *testing.T) {
+	seen := make(map[string]bool)
+	for s := modemmanager.MmModemCdmaRegistrationStateUnknown; s <= modemmanager.MmModemCdmaRegistrationStateRoaming; s++ {
+		str := cdmaRegistrationStateToString(s)
+		if seen[str] {
+			t.Errorf("cdmaRegistrationStateToString(%v) = %q is not unique", s, str)
+		}
+		seen[str] = true
+	}
+	seen = make(map[string]bool)
+	for s := modemmanager.MmModemCdmaActivationStateUnknown; s <= modemmanager.MmModemCdmaActivationStateActivated; s++ {
+		str := cdmaActivationStateToString(s)
+		if seen[str] {
+			t.Errorf("cdmaActivationStateToString(%v) = %q is not unique", s, str)
+		}
+		seen[str] = true
+	}
+}
@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// debugState is the JSON document served by DebugHandler
+type debugState struct {
+	CollectionMode  CollectionMode  `json:"collection_mode"`
+	ModemIdentifier ModemIdentifier `json:"modem_identifier"` // what device_id holds
+	DaemonDown      bool            `json:"daemon_down"`
+	SignalRate      string          `json:"signal_rate,omitempty"`
+	ScrapeErrors    uint64          `json:"scrape_errors_total"`
+	Modems          []debugModem    `json:"modems"`
+}
+
+type debugModem struct {
+	DeviceID          string               `json:"device_id"`
+	LastUpdated       map[string]time.Time `json:"last_updated,omitempty"`
+	ConnectedSince    *time.Time           `json:"connected_since,omitempty"`
+	FailedSince       *time.Time           `json:"failed_since,omitempty"`
+	RegistrationState string               `json:"registration_state,omitempty"`
+	BearerConnected   map[string]bool      `json:"bearer_connected,omitempty"`
+	SignalSetup       *debugSignalSetup    `json:"signal_setup,omitempty"`
+	Snapshot          *debugSnapshot       `json:"snapshot,omitempty"`
+}
+
+type debugSignalSetup struct {
+	Configured bool   `json:"configured"`
+	Reason     string `json:"reason,omitempty"`
+	Rate       string `json:"rate,omitempty"`
+}
+
+// debugSnapshot describes the modem snapshot kept in EventCollection mode,
+// where the device_id label was resolved once for the modem at ObjectPath
+type debugSnapshot struct {
+	ObjectPath dbus.ObjectPath `json:"object_path"`
+	Subscribed bool            `json:"subscribed"`
+	Metrics    int             `json:"metrics"`
+}
+
+// DebugHandler returns a handler serving the state the exporter keeps about
+// each modem as JSON: when each sub-collector last succeeded, the signal
+// setup outcome and, in EventCollection mode, the snapshot and whether its
+// PropertiesChanged subscription works. It does not read the modems.
+func (e *Exporter) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(e.debugState()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// debugState copies the per-modem state under the lock
+func (e *Exporter) debugState() debugState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	state := debugState{
+		CollectionMode:  e.collectionMode,
+		ModemIdentifier: e.modemIdentifier,
+		DaemonDown:      e.daemonDown,
+		ScrapeErrors:    e.scrapeErrorsTotal,
+		Modems:          []debugModem{},
+	}
+	if e.signalPaths != nil {
+		state.SignalRate = e.signalRate.String()
+	}
+
+	modems := make(map[string]*debugModem)
+	modem := func(deviceID string) *debugModem {
+		m, ok := modems[deviceID]
+		if !ok {
+			m = &debugModem{DeviceID: deviceID}
+			modems[deviceID] = m
+		}
+		return m
+	}
+	for deviceID, st := range e.devices {
+		m := modem(deviceID)
+		if len(st.lastUpdated) > 0 {
+			m.LastUpdated = make(map[string]time.Time, len(st.lastUpdated))
+			for collector, t := range st.lastUpdated {
+				m.LastUpdated[collector] = t
+			}
+		}
+		if !st.connectedSince.IsZero() {
+			m.ConnectedSince = &st.connectedSince
+		}
+		if !st.failedSince.IsZero() {
+			m.FailedSince = &st.failedSince
+		}
+		if st.registrationStateSeen {
+			m.RegistrationState = registrationStateToString(st.registrationState)
+		}
+		if len(st.bearerConnected) > 0 {
+			m.BearerConnected = make(map[string]bool, len(st.bearerConnected))
+			for apn, connected := range st.bearerConnected {
+				m.BearerConnected[apn] = connected
+			}
+		}
+	}
+	for deviceID, result := range e.signalSetup {
+		setup := &debugSignalSetup{Configured: result.configured, Reason: result.reason}
+		if result.rate > 0 {
+			setup.Rate = result.rate.String()
+		}
+		modem(deviceID).SignalSetup = setup
+	}
+	for deviceID, snapshot := range e.snapshots {
+		modem(deviceID).Snapshot = &debugSnapshot{
+			ObjectPath: snapshot.modem.GetObjectPath(),
+			Subscribed: !snapshot.poll,
+			Metrics:    len(snapshot.metrics),
+		}
+	}
+
+	for _, m := range modems {
+		state.Modems = append(state.Modems, *m)
+	}
+	sort.Slice(state.Modems, func(i, j int) bool {
+		return state.Modems[i].DeviceID < state.Modems[j].DeviceID
+	})
+	return state
+}
@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+// getDebugState serves the debug endpoint of e and decodes the response
+func getDebugState(t *testing.T, e *Exporter) debugState {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	e.DebugHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/modems", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var state debugState
+	if err := json.NewDecoder(recorder.Body).Decode(&state); err != nil {
+		t.Fatalf("decoding %s: %v", recorder.Body, err)
+	}
+	return state
+}
+
+func TestDebugHandler(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	manager := mocks.NewMockModemManager()
+	modem := mockModem(manager)
+	modem.StateValue = modemmanager.MmModemStateConnected
+	modem.SignalValue = mocks.NewMockModemSignal()
+	e := NewExporter(ModemManagerSource{Manager: manager}, WithClock(clock.NewFake(now)))
+	if err := e.SetupSignalMonitoring(10 * time.Second); err != nil {
+		t.Fatalf("SetupSignalMonitoring() error = %v", err)
+	}
+	gather(t, e.Collect)
+
+	state := getDebugState(t, e)
+	if state.CollectionMode != PollCollection || state.DaemonDown || state.SignalRate != "10s" {
+		t.Errorf("state = %+v, want poll collection with the daemon up and a 10s signal rate", state)
+	}
+	if len(state.Modems) != 1 {
+		t.Fatalf("got %d modems, want 1", len(state.Modems))
+	}
+	m := state.Modems[0]
+	if m.DeviceID != "mock-0000" {
+		t.Errorf("device_id = %q, want mock-0000", m.DeviceID)
+	}
+	if got := m.LastUpdated["info"]; !got.Equal(now) {
+		t.Errorf("last_updated[info] = %v, want %v", got, now)
+	}
+	if m.SignalSetup == nil || !m.SignalSetup.Configured || m.SignalSetup.Rate != "10s" {
+		t.Errorf("signal_setup = %+v, want configured at 10s", m.SignalSetup)
+	}
+	if m.Snapshot != nil {
+		t.Errorf("snapshot = %+v, want none in poll mode", m.Snapshot)
+	}
+}
+
+func TestDebugHandlerSnapshots(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	modem := &liveModem{fakeModem: &fakeModem{path: "/Modem/0", deviceID: "dev", stateChanges: make(chan *dbus.Signal)}}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}}, WithClock(clk), WithCollectionMode(EventCollection))
+	stop := startEvents(t, e, clk)
+	defer stop()
+	waitFor(t, "watch", func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.snapshots["dev"] != nil
+	})
+
+	state := getDebugState(t, e)
+	if len(state.Modems) != 1 || state.Modems[0].Snapshot == nil {
+		t.Fatalf("modems = %+v, want one with a snapshot", state.Modems)
+	}
+	want := debugSnapshot{ObjectPath: "/Modem/0", Subscribed: false}
+	if got := *state.Modems[0].Snapshot; got != want {
+		t.Errorf("snapshot = %+v, want %+v", got, want)
+	}
+}
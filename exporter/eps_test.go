@@ -0,0 +1,79 @@
+package exporter
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// epsBearers returns the apn/ip_type pairs of initial_eps_bearer_info
+func epsBearers(metrics []collectedMetric) []string {
+	var bearers []string
+	for _, m := range metrics {
+		if m.name == "modemmanager_modem_3gpp_initial_eps_bearer_info" {
+			bearers = append(bearers, m.labels["apn"]+"/"+m.labels["ip_type"])
+		}
+	}
+	return bearers
+}
+
+func TestEpsMetrics(t *testing.T) {
+	internet := modemmanager.BearerProperty{APN: "internet", IPType: modemmanager.MmBearerIpFamilyIpv4v6}
+	tests := []struct {
+		name      string
+		modem3gpp *fake3gpp
+		mode      string
+		bearers   []string
+	}{
+		{
+			name:      "attached with the settings",
+			modem3gpp: &fake3gpp{epsMode: modemmanager.MmModem3gppEpsUeModeOperationCsps2, epsSettings: internet, epsBearer: &fakeBearer{settings: internet}},
+			mode:      "csps2",
+			bearers:   []string{"internet/ipv4v6"},
+		},
+		{
+			name: "network overrode the settings",
+			modem3gpp: &fake3gpp{epsMode: modemmanager.MmModem3gppEpsUeModeOperationPs2, epsSettings: internet,
+				epsBearer: &fakeBearer{settings: modemmanager.BearerProperty{APN: "ims", IPType: modemmanager.MmBearerIpFamilyIpv6}}},
+			mode:    "ps2",
+			bearers: []string{"internet/ipv4v6", "ims/ipv6"},
+		},
+		{
+			name:      "not attached",
+			modem3gpp: &fake3gpp{epsMode: modemmanager.MmModem3gppEpsUeModeOperationPs1, epsSettings: internet},
+			mode:      "ps1",
+			bearers:   []string{"internet/ipv4v6"},
+		},
+		{
+			name:      "3G only",
+			modem3gpp: &fake3gpp{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modem := &fakeModem{deviceID: "dev", modem3gpp: tt.modem3gpp}
+			e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}})
+			metrics := gather(t, func(ch chan<- prometheus.Metric) {
+				e.collect3GPPMetrics(ch, modem, "dev")
+			})
+
+			mode, ok := findMetric(metrics, "modemmanager_modem_3gpp_eps_ue_mode")
+			if tt.mode == "" && ok {
+				t.Errorf("eps_ue_mode emitted as %q", mode.labels["mode"])
+			}
+			if tt.mode != "" && (!ok || mode.labels["mode"] != tt.mode) {
+				t.Errorf("eps_ue_mode = %v (emitted %v), want mode %s", mode.labels, ok, tt.mode)
+			}
+			if got := epsBearers(metrics); !slices.Equal(got, tt.bearers) {
+				t.Errorf("initial_eps_bearer_info = %v, want %v", got, tt.bearers)
+			}
+
+			// Failing EPS reads are not scrape errors
+			if last, _ := findMetric(gather(t, e.Collect), "modemmanager_scrape_last_errors"); last.value != 0 {
+				t.Errorf("scrape_last_errors = %v, want 0", last.value)
+			}
+		})
+	}
+}
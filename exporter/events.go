@@ -0,0 +1,241 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stateChangedSignal is the full name of the modem StateChanged signal
+const stateChangedSignal = modemmanager.ModemInterface + "." + modemmanager.ModemSignalStateChanged
+
+// stateTransition identifies one modemmanager_modem_state_transitions_total series
+type stateTransition struct {
+	deviceID string
+	oldState string
+	newState string
+	reason   string
+}
+
+// modemWatch holds the running signal subscriptions of one modem
+type modemWatch struct {
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Start runs the event loop that counts modem state transitions and voice
+// calls until ctx is cancelled. Subscriptions are reconciled against the modem list every resync
+// interval, which picks up hot-plugged modems and resubscribes after a
+// ModemManager restart. The same reconciliation applies the rate of
+// SetupSignalMonitoring to new modems. With WithNameOwnerChanges a restart
+// is noticed right away, and every subscription and signal rate is set up
+// again even if the modems keep their object paths. In EventCollection mode Start also
+// keeps the modem snapshots Collect serves. Start blocks; run it in its own
+// goroutine.
+func (e *Exporter) Start(ctx context.Context) {
+	ticker := e.clock.NewTicker(e.eventResync)
+	defer ticker.Stop()
+
+	if e.collectionMode == EventCollection {
+		e.mu.Lock()
+		e.snapshots = make(map[string]*modemSnapshot)
+		e.mu.Unlock()
+		// Collect polls again once Start returns
+		defer func() {
+			e.mu.Lock()
+			e.snapshots = nil
+			e.mu.Unlock()
+		}()
+	}
+
+	var ownerChanges <-chan *dbus.Signal
+	if e.subscribeOwner != nil {
+		signals, cancel, err := e.subscribeOwner()
+		if err != nil {
+			e.logger.Warn("Error watching for ModemManager restarts", "err", err)
+		} else {
+			defer cancel()
+			ownerChanges = signals
+		}
+	}
+
+	watches := make(map[dbus.ObjectPath]*modemWatch)
+	defer func() {
+		for path, w := range watches {
+			w.close()
+			delete(watches, path)
+		}
+	}()
+
+	for {
+		modems, err := e.modems()
+		if err != nil {
+			e.logger.Warn("Error getting modems for state events", "err", err)
+			modems = nil
+		}
+		e.syncWatches(watches, modems)
+		e.syncSignalSetup(modems, err)
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				break wait
+			case sig := <-ownerChanges:
+				if isNewDaemon(sig) {
+					e.logger.Info("ModemManager restarted, setting up modems again")
+					e.daemonReplaced()
+					e.syncWatches(watches, nil)
+					break wait
+				}
+			}
+		}
+	}
+}
+
+// syncWatches subscribes to new modems and drops subscriptions of modems
+// that are gone. If ModemManager is unreachable modems is nil and all
+// subscriptions are dropped, as a restarted daemon exports its modems under
+// new object paths.
+func (e *Exporter) syncWatches(watches map[dbus.ObjectPath]*modemWatch, modems []modemmanager.Modem) {
+	// Drop stale watches first, a restarted daemon may export the same
+	// device under a new path
+	current := make(map[dbus.ObjectPath]bool)
+	for _, modem := range modems {
+		current[modem.GetObjectPath()] = true
+	}
+	for path, w := range watches {
+		if !current[path] {
+			w.close()
+			delete(watches, path)
+		}
+	}
+
+	for _, modem := range modems {
+		path := modem.GetObjectPath()
+		if _, ok := watches[path]; ok {
+			continue
+		}
+
+		deviceID, err := e.modemLabel(modem)
+		if err != nil {
+			e.logger.Warn("Error getting device identifier", "modem", path, "err", err)
+			continue
+		}
+		w := &modemWatch{stop: make(chan struct{})}
+		if e.collectionMode == EventCollection {
+			changed := make(chan struct{}, 1)
+			ok := e.watchModemState(w, modem, deviceID, changed)
+			e.watchModemSnapshot(w, modem, deviceID, changed, ok)
+		} else {
+			e.watchModemState(w, modem, deviceID, nil)
+		}
+		if voice, err := modem.GetVoice(); err == nil {
+			e.watchVoiceCalls(w, voice, path, deviceID)
+		}
+		e.watchBearers(w, modem, deviceID)
+		watches[path] = w
+	}
+}
+
+// watchModemState counts the StateChanged signals of a modem until the
+// watch is closed. If changed is not nil it also subscribes to
+// PropertiesChanged and notifies changed of every property change of the
+// modem without blocking; the result reports whether that subscription
+// succeeded.
+func (e *Exporter) watchModemState(w *modemWatch, modem modemmanager.Modem, deviceID string, changed chan<- struct{}) bool {
+	signals := modem.SubscribeStateChanged()
+	path := modem.GetObjectPath()
+
+	// The library delivers both signals on one channel per modem
+	var properties <-chan *dbus.Signal
+	subscribed := true
+	if changed != nil {
+		properties = modem.SubscribePropertiesChanged()
+		subscribed = properties != nil
+		if properties == signals {
+			properties = nil
+		}
+	}
+
+	handle := func(sig *dbus.Signal) {
+		// The channel receives every signal routed to the connection
+		if sig.Path != path {
+			return
+		}
+		switch sig.Name {
+		case stateChangedSignal:
+			oldState, newState, reason, err := modem.ParseStateChanged(sig)
+			if err != nil {
+				e.logger.Debug("Error parsing state change", "device_id", deviceID, "err", err)
+				return
+			}
+			e.recordStateTransition(deviceID, oldState, newState, reason)
+		case propertiesChangedSignal:
+			if changed != nil {
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer modem.Unsubscribe()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case sig, ok := <-signals:
+				if !ok {
+					return
+				}
+				handle(sig)
+			case sig, ok := <-properties:
+				if !ok {
+					properties = nil
+					continue
+				}
+				handle(sig)
+			}
+		}
+	}()
+	return subscribed
+}
+
+func (w *modemWatch) close() {
+	close(w.stop)
+	w.wg.Wait()
+}
+
+func (e *Exporter) recordStateTransition(deviceID string, oldState, newState modemmanager.MMModemState, reason modemmanager.MMModemStateChangeReason) {
+	key := stateTransition{
+		deviceID: deviceID,
+		oldState: stateToString(oldState),
+		newState: stateToString(newState),
+		reason:   stateChangeReasonToString(reason),
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.transitions[key]++
+}
+
+// collectStateTransitions emits the transition counters accumulated by Start
+func (e *Exporter) collectStateTransitions(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, key := range sortedTransitions(e.transitions) {
+		ch <- prometheus.MustNewConstMetric(e.modemStateTransitions, prometheus.CounterValue, float64(e.transitions[key]),
+			key.deviceID, key.oldState, key.newState, key.reason)
+	}
+}
@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+)
+
+func stateChanged(path dbus.ObjectPath, oldState, newState modemmanager.MMModemState, reason modemmanager.MMModemStateChangeReason) *dbus.Signal {
+	return &dbus.Signal{
+		Path: path,
+		Name: stateChangedSignal,
+		Body: []interface{}{int32(oldState), int32(newState), uint32(reason)},
+	}
+}
+
+// flush sends a signal the watch ignores. The subscription channel is
+// unbuffered, so once it is accepted every earlier signal has been handled.
+func flush(t *testing.T, ch chan<- *dbus.Signal) {
+	t.Helper()
+	select {
+	case ch <- &dbus.Signal{Name: "org.example.Ignored"}:
+	case <-time.After(time.Second):
+		t.Fatal("state watch is not reading its subscription")
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func transitionCount(t *testing.T, e *Exporter, labels map[string]string) float64 {
+	t.Helper()
+	var total float64
+	for _, m := range gather(t, e.collectStateTransitions) {
+		match := true
+		for k, v := range labels {
+			if m.labels[k] != v {
+				match = false
+			}
+		}
+		if match {
+			total += m.value
+		}
+	}
+	return total
+}
+
+func startEvents(t *testing.T, e *Exporter, clk *clock.Fake) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Start(ctx)
+	}()
+	clk.BlockUntilTimers(1)
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestStateTransitionsCounted(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	modem := &fakeModem{path: "/Modem/0", deviceID: "dev", stateChanges: make(chan *dbus.Signal)}
+	mm := &fakeSource{modems: []modemmanager.Modem{modem}}
+	e := NewExporter(mm, WithClock(clk))
+	stop := startEvents(t, e, clk)
+
+	modem.stateChanges <- stateChanged("/Modem/0", modemmanager.MmModemStateConnected, modemmanager.MmModemStateRegistered, modemmanager.MmModemStateChangeReasonUnknown)
+	modem.stateChanges <- stateChanged("/Modem/0", modemmanager.MmModemStateRegistered, modemmanager.MmModemStateConnected, modemmanager.MmModemStateChangeReasonUserRequested)
+	modem.stateChanges <- stateChanged("/Modem/0", modemmanager.MmModemStateConnected, modemmanager.MmModemStateRegistered, modemmanager.MmModemStateChangeReasonUnknown)
+	// Signals of other objects share the connection and must be ignored
+	modem.stateChanges <- stateChanged("/Modem/1", modemmanager.MmModemStateConnected, modemmanager.MmModemStateRegistered, modemmanager.MmModemStateChangeReasonUnknown)
+	flush(t, modem.stateChanges)
+
+	if got := transitionCount(t, e, map[string]string{"device_id": "dev", "old_state": "connected", "new_state": "registered", "reason": "unknown"}); got != 2 {
+		t.Errorf("connected->registered = %v, want 2", got)
+	}
+	if got := transitionCount(t, e, map[string]string{"device_id": "dev", "old_state": "registered", "new_state": "connected", "reason": "user_requested"}); got != 1 {
+		t.Errorf("registered->connected = %v, want 1", got)
+	}
+
+	stop()
+	if modem.unsubscribed.Load() != 1 {
+		t.Errorf("Unsubscribe called %d times on shutdown, want 1", modem.unsubscribed.Load())
+	}
+}
+
+func TestStateTransitionsFollowModemList(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	first := &fakeModem{path: "/Modem/0", deviceID: "dev", stateChanges: make(chan *dbus.Signal)}
+	mm := &fakeSource{modems: []modemmanager.Modem{first}}
+	e := NewExporter(mm, WithClock(clk), WithEventResync(time.Minute))
+	stop := startEvents(t, e, clk)
+	defer stop()
+
+	first.stateChanges <- stateChanged("/Modem/0", modemmanager.MmModemStateRegistered, modemmanager.MmModemStateConnected, modemmanager.MmModemStateChangeReasonUnknown)
+	flush(t, first.stateChanges)
+
+	// ModemManager restarts: the daemon is briefly gone, then exports the
+	// same device under a new object path.
+	mm.set(nil, errors.New("ModemManager is not running"))
+	clk.Advance(time.Minute)
+	waitFor(t, "unsubscribe of vanished modem", func() bool { return first.unsubscribed.Load() == 1 })
+
+	second := &fakeModem{path: "/Modem/1", deviceID: "dev", stateChanges: make(chan *dbus.Signal)}
+	mm.set([]modemmanager.Modem{second}, nil)
+	clk.Advance(time.Minute)
+
+	second.stateChanges <- stateChanged("/Modem/1", modemmanager.MmModemStateRegistered, modemmanager.MmModemStateConnected, modemmanager.MmModemStateChangeReasonUnknown)
+	flush(t, second.stateChanges)
+
+	if got := transitionCount(t, e, map[string]string{"device_id": "dev", "new_state": "connected"}); got != 2 {
+		t.Errorf("transitions across restart = %v, want 2", got)
+	}
+}
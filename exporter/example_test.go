@@ -0,0 +1,54 @@
+package exporter_test
+
+import (
+	"fmt"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/exporter"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// trackedModems is a ModemSource backed by the modem list of a daemon that
+// already keeps track of its modems.
+type trackedModems struct {
+	modems []modemmanager.Modem
+}
+
+func (t *trackedModems) Modems() ([]modemmanager.Modem, error) {
+	return t.modems, nil
+}
+
+func (t *trackedModems) Version() (string, error) {
+	return "1.22.0", nil
+}
+
+// A program that already tracks its modems can register the Exporter with its
+// own ModemSource instead of letting it list every modem of ModemManager.
+func ExampleNewExporter_customSource() {
+	modem := mocks.NewMockModem()
+	modem.DeviceIdentifierValue = "gateway-lte"
+	source := &trackedModems{modems: []modemmanager.Modem{modem}}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter.NewExporter(source))
+
+	families, err := registry.Gather()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, mf := range families {
+		if mf.GetName() != "modemmanager_modem_info" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "device_id" {
+					fmt.Println(label.GetValue())
+				}
+			}
+		}
+	}
+	// Output: gateway-lte
+}
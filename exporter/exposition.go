@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ExpositionStats records the size of the last metrics response so operators
+// can see the cost of the enabled collectors. It is itself a collector
+// exporting the recorded values.
+type ExpositionStats struct {
+	bytes  atomic.Int64
+	series atomic.Int64
+
+	bytesDesc  *prometheus.Desc
+	seriesDesc *prometheus.Desc
+}
+
+// NewExpositionStats returns a new ExpositionStats.
+func NewExpositionStats() *ExpositionStats {
+	return &ExpositionStats{
+		bytesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exposition", "bytes"),
+			"Size of the last metrics response body in bytes, after compression",
+			nil,
+			nil,
+		),
+		seriesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exposition", "series_count"),
+			"Number of series in the last metrics response",
+			nil,
+			nil,
+		),
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (s *ExpositionStats) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.bytesDesc
+	ch <- s.seriesDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (s *ExpositionStats) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(s.bytesDesc, prometheus.GaugeValue, float64(s.bytes.Load()))
+	ch <- prometheus.MustNewConstMetric(s.seriesDesc, prometheus.GaugeValue, float64(s.series.Load()))
+}
+
+// WrapHandler returns a handler recording the number of body bytes written by h.
+func (s *ExpositionStats) WrapHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &countingResponseWriter{ResponseWriter: w}
+		h.ServeHTTP(cw, r)
+		s.bytes.Store(cw.written)
+	})
+}
+
+// WrapGatherer returns a gatherer recording the number of series gathered by g.
+func (s *ExpositionStats) WrapGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		series := 0
+		for _, mf := range families {
+			series += len(mf.GetMetric())
+		}
+		s.series.Store(int64(series))
+		return families, err
+	})
+}
+
+// countingResponseWriter counts the bytes written to the wrapped ResponseWriter.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (w *countingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush passes flushes through so streaming responses keep working.
+func (w *countingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// newExpositionTestHandler serves a registry with three series plus the
+// exposition stats themselves.
+func newExpositionTestHandler(t *testing.T, disableCompression bool) (*ExpositionStats, http.Handler) {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge", Help: "Test gauge"}, []string{"id"})
+	for _, id := range []string{"a", "b", "c"} {
+		gauge.WithLabelValues(id).Set(1)
+	}
+	registry.MustRegister(gauge)
+
+	stats := NewExpositionStats()
+	registry.MustRegister(stats)
+
+	handler := stats.WrapHandler(promhttp.HandlerFor(stats.WrapGatherer(registry), promhttp.HandlerOpts{
+		DisableCompression: disableCompression,
+	}))
+	return stats, handler
+}
+
+func TestExpositionStatsRecordsBytesAndSeries(t *testing.T) {
+	stats, handler := newExpositionTestHandler(t, true)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got, want := stats.bytes.Load(), int64(rec.Body.Len()); got != want {
+		t.Errorf("exposition bytes = %d, want %d", got, want)
+	}
+	// Three test series plus the two exposition gauges
+	if got := stats.series.Load(); got != 5 {
+		t.Errorf("series count = %d, want 5", got)
+	}
+
+	// The recorded values are exported on the next scrape
+	metrics := gather(t, stats.Collect)
+	if m, ok := findMetric(metrics, "modemmanager_exposition_bytes"); !ok || m.value != float64(rec.Body.Len()) {
+		t.Errorf("modemmanager_exposition_bytes = %v (emitted %v), want %d", m.value, ok, rec.Body.Len())
+	}
+	if m, ok := findMetric(metrics, "modemmanager_exposition_series_count"); !ok || m.value != 5 {
+		t.Errorf("modemmanager_exposition_series_count = %v (emitted %v), want 5", m.value, ok)
+	}
+}
+
+func TestExpositionStatsCountsCompressedBytes(t *testing.T) {
+	stats, handler := newExpositionTestHandler(t, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+	if got, want := stats.bytes.Load(), int64(rec.Body.Len()); got != want {
+		t.Errorf("exposition bytes = %d, want compressed size %d", got, want)
+	}
+
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(zr); err != nil {
+		t.Fatalf("invalid gzip body: %v", err)
+	}
+}
+
+func TestExpositionDisableCompression(t *testing.T) {
+	_, handler := newExpositionTestHandler(t, true)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if enc := rec.Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q with compression disabled", enc)
+	}
+}
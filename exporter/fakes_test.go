@@ -0,0 +1,593 @@
+package exporter
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// fakeSource is a ModemSource over a fixed modem list. The other fakes embed
+// the library interfaces and override only what the tests need; calling
+// anything else panics on the nil embedded value.
+
+type fakeSource struct {
+	mu         sync.Mutex
+	modems     []modemmanager.Modem
+	err        error
+	versionErr error
+	listings   int // calls of Modems
+}
+
+// set replaces the modem list while an event loop may be reading it
+func (f *fakeSource) set(modems []modemmanager.Modem, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modems, f.err = modems, err
+}
+
+func (f *fakeSource) Version() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return "1.20.0", f.versionErr
+}
+
+func (f *fakeSource) Modems() ([]modemmanager.Modem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listings++
+	return f.modems, f.err
+}
+
+var errNotSupported = errors.New("not supported by fake")
+
+type fakeModem struct {
+	modemmanager.Modem
+	path         dbus.ObjectPath
+	deviceID     string
+	equipmentID  string // "000000000000000" if empty
+	state        modemmanager.MMModemState
+	failedReason modemmanager.MMModemStateFailedReason
+	signal       modemmanager.ModemSignal
+	signalErr    error
+	location     modemmanager.ModemLocation
+	firmware     modemmanager.ModemFirmware
+	timeIface    modemmanager.ModemTime
+	voice        modemmanager.ModemVoice
+	capabilities []modemmanager.MMModemCapability
+	cdma         modemmanager.ModemCdma
+	oma          modemmanager.ModemOma
+	ipFamilies   []modemmanager.MMBearerIpFamily
+	carrier      [2]string // configuration name and revision
+	ownNumbers   []string
+	accessTechs  []modemmanager.MMModemAccessTechnology // nil fails the read
+	currentModes modemmanager.Mode
+	modesErr     error
+	currentBands []modemmanager.MMModemBand
+	bands        []modemmanager.MMModemBand
+	bearers      []modemmanager.Bearer
+	sim          modemmanager.Sim
+	messaging    modemmanager.ModemMessaging
+	modem3gpp    modemmanager.Modem3gpp
+	stateChanges chan *dbus.Signal
+	unsubscribed atomic.Int32
+}
+
+func (f *fakeModem) GetObjectPath() dbus.ObjectPath {
+	return f.path
+}
+
+func (f *fakeModem) SubscribeStateChanged() <-chan *dbus.Signal {
+	return f.stateChanges
+}
+
+func (f *fakeModem) ParseStateChanged(v *dbus.Signal) (modemmanager.MMModemState, modemmanager.MMModemState, modemmanager.MMModemStateChangeReason, error) {
+	if len(v.Body) != 3 {
+		return 0, 0, 0, errors.New("bad StateChanged body")
+	}
+	oldState, ok1 := v.Body[0].(int32)
+	newState, ok2 := v.Body[1].(int32)
+	reason, ok3 := v.Body[2].(uint32)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, 0, 0, errors.New("bad StateChanged body")
+	}
+	return modemmanager.MMModemState(oldState), modemmanager.MMModemState(newState), modemmanager.MMModemStateChangeReason(reason), nil
+}
+
+func (f *fakeModem) Unsubscribe() {
+	f.unsubscribed.Add(1)
+}
+
+func (f *fakeModem) GetDeviceIdentifier() (string, error) {
+	return f.deviceID, nil
+}
+
+func (f *fakeModem) GetModel() (string, error) {
+	return "fake", nil
+}
+
+func (f *fakeModem) GetManufacturer() (string, error) { return "fake", nil }
+func (f *fakeModem) GetRevision() (string, error)     { return "1", nil }
+func (f *fakeModem) GetEquipmentIdentifier() (string, error) {
+	if f.equipmentID == "" {
+		return "000000000000000", nil
+	}
+	return f.equipmentID, nil
+}
+func (f *fakeModem) GetDevice() (string, error)           { return "/sys/devices/fake", nil }
+func (f *fakeModem) GetPlugin() (string, error)           { return "generic", nil }
+func (f *fakeModem) GetPrimaryPort() (string, error)      { return "cdc-wdm0", nil }
+func (f *fakeModem) GetMaxBearers() (uint32, error)       { return 1, nil }
+func (f *fakeModem) GetMaxActiveBearers() (uint32, error) { return 1, nil }
+
+func (f *fakeModem) GetSupportedIpFamilies() ([]modemmanager.MMBearerIpFamily, error) {
+	if f.ipFamilies == nil {
+		return nil, errNotSupported
+	}
+	return f.ipFamilies, nil
+}
+
+func (f *fakeModem) GetCarrierConfiguration() (string, error)         { return f.carrier[0], nil }
+func (f *fakeModem) GetCarrierConfigurationRevision() (string, error) { return f.carrier[1], nil }
+func (f *fakeModem) GetOwnNumbers() ([]string, error)                 { return f.ownNumbers, nil }
+
+func (f *fakeModem) GetState() (modemmanager.MMModemState, error) {
+	return f.state, nil
+}
+
+func (f *fakeModem) GetStateFailedReason() (modemmanager.MMModemStateFailedReason, error) {
+	return f.failedReason, nil
+}
+
+func (f *fakeModem) GetPowerState() (modemmanager.MMModemPowerState, error) {
+	return modemmanager.MmModemPowerStateOn, nil
+}
+
+func (f *fakeModem) GetSignalQuality() (uint32, bool, error) {
+	return 0, false, errNotSupported
+}
+
+func (f *fakeModem) GetAccessTechnologies() ([]modemmanager.MMModemAccessTechnology, error) {
+	if f.accessTechs == nil {
+		return nil, errNotSupported
+	}
+	return f.accessTechs, nil
+}
+
+func (f *fakeModem) GetUnlockRequired() (modemmanager.MMModemLock, error) {
+	return modemmanager.MmModemLockNone, nil
+}
+
+func (f *fakeModem) GetBearers() ([]modemmanager.Bearer, error) {
+	if f.bearers == nil {
+		return nil, errNotSupported
+	}
+	return f.bearers, nil
+}
+
+func (f *fakeModem) GetSim() (modemmanager.Sim, error) {
+	if f.sim == nil {
+		return nil, errNotSupported
+	}
+	return f.sim, nil
+}
+
+func (f *fakeModem) Get3gpp() (modemmanager.Modem3gpp, error) {
+	if f.modem3gpp == nil {
+		return nil, errNotSupported
+	}
+	return f.modem3gpp, nil
+}
+
+func (f *fakeModem) GetCurrentCapabilities() ([]modemmanager.MMModemCapability, error) {
+	return f.capabilities, nil
+}
+
+// GetCdma returns the interface even if it is not set, like the library does
+func (f *fakeModem) GetCdma() (modemmanager.ModemCdma, error) {
+	if f.cdma == nil {
+		return &fakeCdma{err: errNotSupported}, nil
+	}
+	return f.cdma, nil
+}
+
+func (f *fakeModem) GetOma() (modemmanager.ModemOma, error) {
+	if f.oma == nil {
+		return &fakeOma{err: errNotSupported}, nil
+	}
+	return f.oma, nil
+}
+
+func (f *fakeModem) GetMessaging() (modemmanager.ModemMessaging, error) {
+	if f.messaging == nil {
+		return nil, errNotSupported
+	}
+	return f.messaging, nil
+}
+
+func (f *fakeModem) GetSignal() (modemmanager.ModemSignal, error) {
+	if f.signal == nil && f.signalErr == nil {
+		return nil, errNotSupported
+	}
+	return f.signal, f.signalErr
+}
+
+func (f *fakeModem) GetFirmware() (modemmanager.ModemFirmware, error) {
+	if f.firmware == nil {
+		return nil, errNotSupported
+	}
+	return f.firmware, nil
+}
+
+func (f *fakeModem) GetTime() (modemmanager.ModemTime, error) {
+	if f.timeIface == nil {
+		return nil, errNotSupported
+	}
+	return f.timeIface, nil
+}
+
+func (f *fakeModem) GetCurrentModes() (modemmanager.Mode, error) {
+	return f.currentModes, f.modesErr
+}
+
+func (f *fakeModem) GetCurrentBands() ([]modemmanager.MMModemBand, error) {
+	return f.currentBands, nil
+}
+
+func (f *fakeModem) GetSupportedBands() ([]modemmanager.MMModemBand, error) {
+	return f.bands, nil
+}
+
+func (f *fakeModem) GetVoice() (modemmanager.ModemVoice, error) {
+	if f.voice == nil {
+		return nil, errNotSupported
+	}
+	return f.voice, nil
+}
+
+func (f *fakeModem) GetLocation() (modemmanager.ModemLocation, error) {
+	if f.location == nil {
+		return nil, errNotSupported
+	}
+	return f.location, nil
+}
+
+type fakeSim struct {
+	modemmanager.Sim
+	imsi    string
+	imsiErr error // e.g. while the SIM is locked
+}
+
+func (f *fakeSim) GetObjectPath() dbus.ObjectPath   { return "/org/freedesktop/ModemManager1/SIM/0" }
+func (f *fakeSim) GetImsi() (string, error)         { return f.imsi, f.imsiErr }
+func (f *fakeSim) GetOperatorName() (string, error) { return "", nil }
+
+type fake3gpp struct {
+	modemmanager.Modem3gpp
+	imei        string
+	epsMode     modemmanager.MMModem3gppEpsUeModeOperation // unknown fails the EPS reads, as on 3G-only modems
+	epsSettings modemmanager.BearerProperty
+	epsBearer   modemmanager.Bearer // nil while not attached
+	locks       []modemmanager.MMModem3gppFacility
+	ussd        modemmanager.Ussd // nil without the USSD interface
+}
+
+func (f *fake3gpp) GetImei() (string, error) { return f.imei, nil }
+
+func (f *fake3gpp) GetRegistrationState() (modemmanager.MMModem3gppRegistrationState, error) {
+	return modemmanager.MmModem3gppRegistrationStateHome, nil
+}
+
+func (f *fake3gpp) GetOperatorCode() (string, error) { return "", nil }
+func (f *fake3gpp) GetOperatorName() (string, error) { return "", nil }
+
+func (f *fake3gpp) GetEnabledFacilityLocks() ([]modemmanager.MMModem3gppFacility, error) {
+	return f.locks, nil
+}
+
+func (f *fake3gpp) GetEpsUeModeOperation() (modemmanager.MMModem3gppEpsUeModeOperation, error) {
+	if f.epsMode == modemmanager.MmModem3gppEpsUeModeOperationUnknown {
+		return f.epsMode, errNotSupported
+	}
+	return f.epsMode, nil
+}
+
+func (f *fake3gpp) GetInitialEpsBearerSettings() (modemmanager.BearerProperty, error) {
+	if f.epsMode == modemmanager.MmModem3gppEpsUeModeOperationUnknown {
+		return f.epsSettings, errNotSupported
+	}
+	return f.epsSettings, nil
+}
+
+func (f *fake3gpp) GetInitialEpsBearer() (modemmanager.Bearer, error) {
+	if f.epsBearer == nil {
+		return nil, errNotSupported
+	}
+	return f.epsBearer, nil
+}
+
+func (f *fake3gpp) GetUssd() (modemmanager.Ussd, error) {
+	if f.ussd == nil {
+		return nil, errNotSupported
+	}
+	return f.ussd, nil
+}
+
+type fakeUssd struct {
+	modemmanager.Ussd
+	state modemmanager.MMModem3gppUssdSessionState
+	err   error
+}
+
+func (f *fakeUssd) GetState() (modemmanager.MMModem3gppUssdSessionState, error) {
+	return f.state, f.err
+}
+
+type fakeMessaging struct {
+	modemmanager.ModemMessaging
+	defaultStorage modemmanager.MMSmsStorage
+	storages       []modemmanager.MMSmsStorage
+	storageErr     error
+}
+
+func (f *fakeMessaging) GetMessages() ([]modemmanager.Sms, error) { return nil, nil }
+
+func (f *fakeMessaging) GetDefaultStorage() (modemmanager.MMSmsStorage, error) {
+	return f.defaultStorage, f.storageErr
+}
+
+func (f *fakeMessaging) GetSupportedStorages() ([]modemmanager.MMSmsStorage, error) {
+	return f.storages, f.storageErr
+}
+
+type fakeSignal struct {
+	modemmanager.ModemSignal
+	setupErr  error
+	setupRate uint32
+	setups    chan uint32 // receives each rate instead of setupRate if set
+}
+
+func (f *fakeSignal) Setup(rate uint32) error {
+	if f.setups != nil {
+		f.setups <- rate
+	} else {
+		f.setupRate = rate
+	}
+	return f.setupErr
+}
+
+type fakeLocation struct {
+	modemmanager.ModemLocation
+	sources  []modemmanager.MMModemLocationSource
+	signals  bool
+	location modemmanager.CurrentLocation
+	current  modemmanager.CurrentLocation
+}
+
+func (f *fakeLocation) GetEnabledLocationSources() ([]modemmanager.MMModemLocationSource, error) {
+	return f.sources, nil
+}
+
+func (f *fakeLocation) GetSignalsLocation() (bool, error) {
+	return f.signals, nil
+}
+
+func (f *fakeLocation) GetLocation() (modemmanager.CurrentLocation, error) {
+	return f.location, nil
+}
+
+func (f *fakeLocation) GetCurrentLocation() (modemmanager.CurrentLocation, error) {
+	return f.current, nil
+}
+
+type fakeBearer struct {
+	modemmanager.Bearer
+	path      dbus.ObjectPath
+	iface     string
+	connected bool
+	ip4       modemmanager.BearerIpConfig
+	ip6       modemmanager.BearerIpConfig
+	ip6Err    error
+	suspended bool
+	ipTimeout uint32
+	stats     modemmanager.BearerStats
+	settings  modemmanager.BearerProperty
+	changes   chan *dbus.Signal // PropertiesChanged signals, nil to never deliver any
+}
+
+func (f *fakeBearer) SubscribePropertiesChanged() <-chan *dbus.Signal {
+	return f.changes
+}
+
+func (f *fakeBearer) ParsePropertiesChanged(v *dbus.Signal) (string, map[string]dbus.Variant, []string, error) {
+	return v.Body[0].(string), v.Body[1].(map[string]dbus.Variant), nil, nil
+}
+
+func (f *fakeBearer) Unsubscribe() {}
+
+func (f *fakeBearer) GetObjectPath() dbus.ObjectPath {
+	return f.path
+}
+
+func (f *fakeBearer) GetInterface() (string, error) {
+	return f.iface, nil
+}
+
+func (f *fakeBearer) GetConnected() (bool, error) {
+	return f.connected, nil
+}
+
+func (f *fakeBearer) GetIp4Config() (modemmanager.BearerIpConfig, error) {
+	return f.ip4, nil
+}
+
+func (f *fakeBearer) GetIp6Config() (modemmanager.BearerIpConfig, error) {
+	return f.ip6, f.ip6Err
+}
+
+func (f *fakeBearer) GetSuspended() (bool, error) {
+	return f.suspended, nil
+}
+
+func (f *fakeBearer) GetIpTimeout() (uint32, error) {
+	return f.ipTimeout, nil
+}
+
+func (f *fakeBearer) GetStats() (modemmanager.BearerStats, error) {
+	return f.stats, nil
+}
+
+func (f *fakeBearer) GetProperties() (modemmanager.BearerProperty, error) {
+	return f.settings, nil
+}
+
+type fakeFirmware struct {
+	modemmanager.ModemFirmware
+	images   []modemmanager.FirmwareProperty
+	settings modemmanager.UpdateSettingsProperty
+}
+
+func (f *fakeFirmware) List() ([]modemmanager.FirmwareProperty, error) {
+	return f.images, nil
+}
+
+func (f *fakeFirmware) GetUpdateSettings() (modemmanager.UpdateSettingsProperty, error) {
+	return f.settings, nil
+}
+
+type fakeTime struct {
+	modemmanager.ModemTime
+	networkTime time.Time
+	timeErr     error
+	timezone    modemmanager.ModemTimeZone
+	timezoneErr error
+}
+
+func (f *fakeTime) GetNetworkTime() (time.Time, error) {
+	return f.networkTime, f.timeErr
+}
+
+func (f *fakeTime) GetNetworkTimezone() (modemmanager.ModemTimeZone, error) {
+	return f.timezone, f.timezoneErr
+}
+
+type fakeCdma struct {
+	modemmanager.ModemCdma
+	cdma1x     modemmanager.MMModemCdmaRegistrationState
+	evdo       modemmanager.MMModemCdmaRegistrationState
+	activation modemmanager.MMModemCdmaActivationState
+	sid, nid   uint32
+	err        error
+}
+
+func (f *fakeCdma) GetCdma1xRegistrationState() (modemmanager.MMModemCdmaRegistrationState, error) {
+	return f.cdma1x, f.err
+}
+
+func (f *fakeCdma) GetEvdoRegistrationState() (modemmanager.MMModemCdmaRegistrationState, error) {
+	return f.evdo, f.err
+}
+
+func (f *fakeCdma) GetActivationState() (modemmanager.MMModemCdmaActivationState, error) {
+	return f.activation, f.err
+}
+
+func (f *fakeCdma) GetSid() (uint32, error) { return f.sid, f.err }
+func (f *fakeCdma) GetNid() (uint32, error) { return f.nid, f.err }
+
+type fakeOma struct {
+	modemmanager.ModemOma
+	features    []modemmanager.MMOmaFeature
+	state       modemmanager.MMOmaSessionState
+	sessionType modemmanager.MMOmaSessionType
+	err         error
+}
+
+func (f *fakeOma) GetFeatures() ([]modemmanager.MMOmaFeature, error) {
+	return f.features, f.err
+}
+
+func (f *fakeOma) GetSessionState() (modemmanager.MMOmaSessionState, error) {
+	return f.state, f.err
+}
+
+func (f *fakeOma) GetSessionType() (modemmanager.MMOmaSessionType, error) {
+	return f.sessionType, f.err
+}
+
+type fakeVoice struct {
+	modemmanager.ModemVoice
+	calls         []modemmanager.Call
+	emergencyOnly bool
+	signals       chan *dbus.Signal             // CallAdded and CallDeleted
+	added         map[dbus.ObjectPath]*fakeCall // calls returned by ParseCallAdded
+	unsubscribed  atomic.Int32
+}
+
+func (f *fakeVoice) SubscribeCallAdded() <-chan *dbus.Signal {
+	return f.signals
+}
+
+func (f *fakeVoice) SubscribeCallDeleted() <-chan *dbus.Signal {
+	return f.signals
+}
+
+func (f *fakeVoice) ParseCallAdded(v *dbus.Signal) (modemmanager.Call, error) {
+	call, ok := f.added[v.Body[0].(dbus.ObjectPath)]
+	if !ok {
+		return nil, errors.New("unknown call")
+	}
+	return call, nil
+}
+
+func (f *fakeVoice) ParseCallDeleted(v *dbus.Signal) (dbus.ObjectPath, error) {
+	return v.Body[0].(dbus.ObjectPath), nil
+}
+
+func (f *fakeVoice) Unsubscribe() {
+	f.unsubscribed.Add(1)
+}
+
+func (f *fakeVoice) GetCalls() ([]modemmanager.Call, error) {
+	return f.calls, nil
+}
+
+func (f *fakeVoice) GetEmergencyOnly() (bool, error) {
+	return f.emergencyOnly, nil
+}
+
+type fakeCall struct {
+	modemmanager.Call
+	path         dbus.ObjectPath
+	state        modemmanager.MMCallState
+	direction    modemmanager.MMCallDirection
+	stateChanges chan *dbus.Signal
+	unsubscribed atomic.Int32
+}
+
+func (f *fakeCall) GetObjectPath() dbus.ObjectPath {
+	return f.path
+}
+
+func (f *fakeCall) SubscribeStateChanged() <-chan *dbus.Signal {
+	return f.stateChanges
+}
+
+func (f *fakeCall) ParseStateChanged(v *dbus.Signal) (modemmanager.MMCallState, modemmanager.MMCallState, modemmanager.MMCallStateReason, error) {
+	return modemmanager.MMCallState(v.Body[0].(int32)), modemmanager.MMCallState(v.Body[1].(int32)), modemmanager.MMCallStateReason(v.Body[2].(uint32)), nil
+}
+
+func (f *fakeCall) Unsubscribe() {
+	f.unsubscribed.Add(1)
+}
+
+func (f *fakeCall) GetState() (modemmanager.MMCallState, error) {
+	return f.state, nil
+}
+
+func (f *fakeCall) GetDirection() (modemmanager.MMCallDirection, error) {
+	return f.direction, nil
+}
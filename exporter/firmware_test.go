@@ -0,0 +1,80 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFirmwareMetrics(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{
+		deviceID: "dev",
+		firmware: &fakeFirmware{
+			images: []modemmanager.FirmwareProperty{
+				{ImageType: modemmanager.MmFirmwareImageTypeGobi, UniqueId: "02.24.05.06_GENERIC", Selected: true},
+				{ImageType: modemmanager.MmFirmwareImageTypeGeneric, UniqueId: "02.20.03.00_VODAFONE"},
+			},
+			settings: modemmanager.UpdateSettingsProperty{
+				UpdateMethods: []modemmanager.MMModemFirmwareUpdateMethod{
+					modemmanager.MmModemFirmwareUpdateMethodFastboot,
+					modemmanager.MmModemFirmwareUpdateMethodQmiPdc,
+				},
+			},
+		},
+	}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectFirmwareMetrics(ch, modem, "dev")
+	})
+
+	var images []map[string]string
+	var methods []string
+	for _, m := range metrics {
+		switch m.name {
+		case "modemmanager_firmware_info":
+			images = append(images, m.labels)
+		case "modemmanager_firmware_update_method":
+			methods = append(methods, m.labels["method"])
+		}
+	}
+
+	if len(images) != 2 {
+		t.Fatalf("got %d firmware_info series, want 2", len(images))
+	}
+	if images[0]["version"] != "02.24.05.06_GENERIC" || images[0]["selected"] != "true" || images[0]["type"] != "gobi" {
+		t.Errorf("selected image labels = %v", images[0])
+	}
+	if images[1]["version"] != "02.20.03.00_VODAFONE" || images[1]["selected"] != "false" || images[1]["type"] != "generic" {
+		t.Errorf("installed image labels = %v", images[1])
+	}
+	if len(methods) != 2 || methods[0] != "fastboot" || methods[1] != "qmi_pdc" {
+		t.Errorf("update methods = %v, want [fastboot qmi_pdc]", methods)
+	}
+}
+
+func TestFirmwareMetricsWithoutInterface(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectFirmwareMetrics(ch, &fakeModem{deviceID: "dev"}, "dev")
+	})
+	if len(metrics) != 0 {
+		t.Errorf("got %d metrics for a modem without firmware interface, want none", len(metrics))
+	}
+}
+
+func TestFirmwareUpdateMethodNoneSkipped(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", firmware: &fakeFirmware{
+		settings: modemmanager.UpdateSettingsProperty{
+			UpdateMethods: []modemmanager.MMModemFirmwareUpdateMethod{modemmanager.MmModemFirmwareUpdateMethodNone},
+		},
+	}}
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectFirmwareMetrics(ch, modem, "dev")
+	})
+	if _, ok := findMetric(metrics, "modemmanager_firmware_update_method"); ok {
+		t.Error("update_method emitted although no method is supported")
+	}
+}
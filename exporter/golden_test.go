@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata from the current output")
+
+// volatileMetrics change on every scrape and are left out of the golden files
+var volatileMetrics = map[string]bool{
+	"modemmanager_scrape_duration_seconds":         true,
+	"modemmanager_scrape_collect_duration_seconds": true,
+	"modemmanager_modem_collect_duration_seconds":  true,
+}
+
+// goldenScenarios are compared with testdata/<name>.prom. setup adjusts a
+// MockModemManager holding one default modem, see mockModem.
+var goldenScenarios = []struct {
+	name  string
+	setup func(manager *mocks.MockModemManager)
+}{
+	{"healthy_lte", func(manager *mocks.MockModemManager) {
+		modem := mockModem(manager)
+		modem.StateValue = modemmanager.MmModemStateConnected
+		modem.SignalValue = mocks.NewMockModemSignal()
+		bearer := mocks.NewMockBearer()
+		bearer.ConnectedValue = true
+		modem.BearersValue = []modemmanager.Bearer{bearer}
+	}},
+	{"sim_locked", func(manager *mocks.MockModemManager) {
+		modem := mockModem(manager)
+		modem.StateValue = modemmanager.MmModemStateLocked
+		modem.UnlockRequiredValue = modemmanager.MmModemLockSimPin
+		modem.AccessTechnologiesValue = nil
+		modem.SignalQualityPercent = 0
+		modem.SignalQualityRecent = false
+		modem.BearersValue = nil
+		modem3gpp := mocks.NewMockModem3gpp()
+		modem3gpp.RegistrationStateValue = modemmanager.MmModem3gppRegistrationStateIdle
+		modem3gpp.OperatorCodeValue = ""
+		modem3gpp.OperatorNameValue = ""
+		modem3gpp.FacilityLocksValue = []modemmanager.MMModem3gppFacility{modemmanager.MmModem3gppFacilitySim}
+		modem.Modem3gppValue = modem3gpp
+	}},
+	{"attach_apn_overridden", func(manager *mocks.MockModemManager) {
+		modem3gpp := mocks.NewMockModem3gpp()
+		modem3gpp.InitialEpsSettings = modemmanager.BearerProperty{APN: "iot.example", IPType: modemmanager.MmBearerIpFamilyIpv4v6}
+		mockModem(manager).Modem3gppValue = modem3gpp
+	}},
+	{"no_signal_interface", func(manager *mocks.MockModemManager) {
+		mockModem(manager).SignalValue = nil
+	}},
+	{"modemmanager_unreachable", func(manager *mocks.MockModemManager) {
+		unreachable := dbus.Error{Name: "org.freedesktop.DBus.Error.ServiceUnknown"}
+		manager.GetVersionError = unreachable
+		manager.GetModemsError = unreachable
+	}},
+	{"modemmanager_not_responding", func(manager *mocks.MockModemManager) {
+		// The modems are not scraped while ModemManager does not answer
+		manager.GetVersionError = dbus.Error{Name: "org.freedesktop.DBus.Error.NoReply"}
+	}},
+}
+
+// mockModem returns the first modem of manager
+func mockModem(manager *mocks.MockModemManager) *mocks.MockModem {
+	return manager.ModemsValue[0].(*mocks.MockModem)
+}
+
+func TestGoldenMetrics(t *testing.T) {
+	for _, scenario := range goldenScenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			manager := mocks.NewMockModemManager()
+			scenario.setup(manager)
+			e := NewExporter(ModemManagerSource{Manager: manager}, WithClock(clock.NewFake(time.Unix(1700000000, 0))))
+			compareGolden(t, e, filepath.Join("testdata", scenario.name+".prom"))
+		})
+	}
+}
+
+// compareGolden compares the non-volatile metrics of c with a golden file in
+// the text format, or rewrites the file with -update. c is collected once, as
+// collecting advances counters such as scrape_errors_total.
+func compareGolden(t *testing.T, c prometheus.Collector, path string) {
+	t.Helper()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c)
+	gathered, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var families []*dto.MetricFamily
+	for _, family := range gathered {
+		if !volatileMetrics[family.GetName()] {
+			families = append(families, family)
+		}
+	}
+
+	if *update {
+		var out bytes.Buffer
+		for _, family := range families {
+			if _, err := expfmt.MetricFamilyToText(&out, family); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	golden, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v, run go test -update to create it", err)
+	}
+	defer golden.Close()
+
+	scraped := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return families, nil })
+	if err := testutil.GatherAndCompare(scraped, golden); err != nil {
+		t.Errorf("%s: %v\nrun go test -update if the change is intended", path, err)
+	}
+}
@@ -2,10 +2,17 @@ package exporter
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/godbus/dbus/v5"
 	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -13,23 +20,83 @@ const (
 	namespace = "modemmanager"
 )
 
+// durationBuckets of the collection duration histograms span a single fast
+// D-Bus round trip to a modem timing out on every property read.
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
 // Exporter collects ModemManager metrics and exports them using
 // the prometheus client library.
 type Exporter struct {
-	mm modemmanager.ModemManager
+	source  ModemSource // guarded by mu once reconnect may replace it
+	connect func() (ModemSource, error)
+
+	// Options
+	failedModemGrace    time.Duration
+	bandMetrics         bool
+	ownNumbers          bool
+	accessTechSeries    bool
+	bearerPathLabels    bool
+	timestamps          bool
+	eventResync         time.Duration
+	collectionMode      CollectionMode
+	snapshotRefresh     time.Duration
+	modemIdentifier     ModemIdentifier
+	locationPrecision   LocationPrecision
+	locationDecimals    int
+	modemFilter         func(modemmanager.Modem) bool
+	clock               clock.Clock
+	logger              *slog.Logger
+	signalRateOverrides map[string]time.Duration                    // by equipment or device identifier
+	subscribeOwner      func() (<-chan *dbus.Signal, func(), error) // see WithNameOwnerChanges
+	options             []Option                                    // as passed to NewExporter, for probe exporters
+
+	// State kept between scrapes
+	mu                sync.Mutex
+	devices           map[string]*deviceState
+	signalSetup       map[string]signalSetupResult
+	signalPaths       map[dbus.ObjectPath]bool // modems the signal rate was applied to, nil until SetupSignalMonitoring
+	transitions       map[stateTransition]uint64
+	callCounters      map[string]*callCounters
+	snapshots         map[string]*modemSnapshot // by device ID, nil unless Start runs in EventCollection mode
+	probes            map[dbus.ObjectPath]*Exporter
+	scrapeErrorsTotal uint64
+	signalRate        time.Duration // last rate passed to SetupSignalMonitoring
+	daemonDown        bool          // ModemManager did not answer the last scrape
+	scraped           bool          // a scrape succeeded, see Ready
 
 	// ModemManager info
-	mmInfo *prometheus.Desc
+	mmInfo   *prometheus.Desc
+	mmUp     *prometheus.Desc
+	mmModems *prometheus.Desc
 
 	// Modem info
 	modemInfo             *prometheus.Desc
 	modemState            *prometheus.Desc
 	modemPowerState       *prometheus.Desc
 	modemSignalQuality    *prometheus.Desc
+	modemSignalRecent     *prometheus.Desc
 	modemAccessTech       *prometheus.Desc
+	modemAccessTechActive *prometheus.Desc
 	modemUnlockRequired   *prometheus.Desc
 	modemMaxBearers       *prometheus.Desc
 	modemMaxActiveBearers *prometheus.Desc
+	modemBearers          *prometheus.Desc
+	modemIPFamily         *prometheus.Desc
+	modemCarrierConfig    *prometheus.Desc
+	modemOwnNumber        *prometheus.Desc
+	modemFailedReason     *prometheus.Desc
+	modemSuppressed       *prometheus.Desc
+	modemConnectedSince   *prometheus.Desc
+	modemStateTransitions *prometheus.Desc
+	modemLastUpdated      *prometheus.Desc
+
+	// Mode metrics
+	modemModeAllowed   *prometheus.Desc
+	modemModePreferred *prometheus.Desc
+
+	// Band metrics
+	modemCurrentBand        *prometheus.Desc
+	modemSupportedBandCount *prometheus.Desc
 
 	// Signal metrics (LTE)
 	signalLteRssi *prometheus.Desc
@@ -55,38 +122,186 @@ type Exporter struct {
 	signalEvdoSinr *prometheus.Desc
 	signalEvdoIo   *prometheus.Desc
 
+	// Signal setup status
+	signalSetupConfigured *prometheus.Desc
+	signalRefreshRate     *prometheus.Desc
+
 	// Bearer metrics
 	bearerInfo      *prometheus.Desc
 	bearerConnected *prometheus.Desc
+	bearerIp6Info   *prometheus.Desc
+	bearerSuspended *prometheus.Desc
+	bearerIpTimeout *prometheus.Desc
+
+	// Bearer lifetime statistics
+	bearerAttempts       *prometheus.Desc
+	bearerFailedAttempts *prometheus.Desc
+	bearerRxBytes        *prometheus.Desc
+	bearerTxBytes        *prometheus.Desc
+	bearerDisconnects    *prometheus.Desc
 
 	// SIM metrics
 	simInfo *prometheus.Desc
 
 	// 3GPP metrics
-	modem3gppRegistrationState *prometheus.Desc
-	modem3gppOperatorCode      *prometheus.Desc
-	modem3gppOperatorName      *prometheus.Desc
+	modem3gppRegistrationState  *prometheus.Desc
+	modem3gppOperatorCode       *prometheus.Desc
+	modem3gppOperatorName       *prometheus.Desc
+	modem3gppRoaming            *prometheus.Desc
+	modem3gppRegistrationDenied *prometheus.Desc
+	modem3gppInitialEpsBearer   *prometheus.Desc
+	modem3gppEpsUeMode          *prometheus.Desc
+	modem3gppFacilityLock       *prometheus.Desc
+
+	// USSD metrics
+	ussdSessionState *prometheus.Desc
+
+	// CDMA metrics
+	modemCdma1xRegistrationState *prometheus.Desc
+	modemEvdoRegistrationState   *prometheus.Desc
+	modemCdmaActivationState     *prometheus.Desc
+	modemCdmaSid                 *prometheus.Desc
+	modemCdmaNid                 *prometheus.Desc
+
+	// OMA device management metrics
+	omaSessionState   *prometheus.Desc
+	omaSessionType    *prometheus.Desc
+	omaFeatureEnabled *prometheus.Desc
 
 	// Messaging metrics
-	messagingSupported *prometheus.Desc
-	smsCount           *prometheus.Desc
+	messagingSupported        *prometheus.Desc
+	smsCount                  *prometheus.Desc
+	messagingDefaultStorage   *prometheus.Desc
+	messagingSupportedStorage *prometheus.Desc
 
 	// Location metrics
-	locationEnabled   *prometheus.Desc
-	locationLatitude  *prometheus.Desc
-	locationLongitude *prometheus.Desc
-	locationAltitude  *prometheus.Desc
+	locationEnabled       *prometheus.Desc
+	locationSourceEnabled *prometheus.Desc
+	locationLatitude      *prometheus.Desc
+	locationLongitude     *prometheus.Desc
+	locationAltitude      *prometheus.Desc
+	location3gppInfo      *prometheus.Desc
+	location3gppCellID    *prometheus.Desc
+	locationGpsSpeed      *prometheus.Desc
+	locationGpsHeading    *prometheus.Desc
+	locationGpsSatUsed    *prometheus.Desc
+	locationGpsUtcTime    *prometheus.Desc
+	locationGpsHdop       *prometheus.Desc
+	locationFix           *prometheus.Desc
+
+	// Firmware metrics
+	firmwareInfo         *prometheus.Desc
+	firmwareUpdateMethod *prometheus.Desc
+
+	// Time metrics
+	timeNetworkOffset  *prometheus.Desc
+	timeTimezoneOffset *prometheus.Desc
+
+	// Voice metrics
+	voiceCalls         *prometheus.Desc
+	voiceEmergencyOnly *prometheus.Desc
+
+	// Voice call event metrics
+	voiceCallsTotal       *prometheus.Desc
+	voiceActiveCalls      *prometheus.Desc
+	voiceMissedCallsTotal *prometheus.Desc
 
 	// Scrape metrics
-	scrapeDuration *prometheus.Desc
-	scrapeSuccess  *prometheus.Desc
-	scrapeErrors   *prometheus.Desc
+	scrapeDuration   *prometheus.Desc
+	scrapeSuccess    *prometheus.Desc
+	scrapeErrors     *prometheus.Desc
+	scrapeLastErrors *prometheus.Desc
+
+	// Collection duration histograms
+	scrapeDurations prometheus.Histogram
+	modemDurations  *prometheus.HistogramVec
+}
+
+// Option configures optional Exporter behaviour.
+type Option func(*Exporter)
+
+// WithFailedModemGrace reduces modems that stay in the failed state for
+// longer than grace to a minimal metric set. Zero disables suppression.
+func WithFailedModemGrace(grace time.Duration) Option {
+	return func(e *Exporter) {
+		e.failedModemGrace = grace
+	}
+}
+
+// WithBandMetrics enables the per-band metrics. They are off by default as
+// band lists can have 40 and more entries per modem.
+func WithBandMetrics(enabled bool) Option {
+	return func(e *Exporter) {
+		e.bandMetrics = enabled
+	}
+}
+
+// WithOwnNumbers exports the phone numbers of the SIM, which are on by
+// default. Deployments that treat MSISDNs as sensitive can turn them off.
+func WithOwnNumbers(enabled bool) Option {
+	return func(e *Exporter) {
+		e.ownNumbers = enabled
+	}
+}
+
+// WithAccessTechnologySeries exports modem_access_technology_active for
+// every known technology, which is on by default and adds 15 series per
+// modem.
+func WithAccessTechnologySeries(enabled bool) Option {
+	return func(e *Exporter) {
+		e.accessTechSeries = enabled
+	}
 }
 
-// NewExporter returns a new ModemManager exporter.
-func NewExporter(mm modemmanager.ModemManager) *Exporter {
-	return &Exporter{
-		mm: mm,
+// WithEventResync sets how often Start reconciles its StateChanged
+// subscriptions with the modems known to ModemManager.
+func WithEventResync(interval time.Duration) Option {
+	return func(e *Exporter) {
+		e.eventResync = interval
+	}
+}
+
+// WithClock replaces the time source used for grace periods and timestamps.
+func WithClock(c clock.Clock) Option {
+	return func(e *Exporter) {
+		e.clock = c
+	}
+}
+
+// WithTimestamps stamps the metrics of each modem with the time they were
+// read from the modem instead of leaving the timestamp to the scraper. Off
+// by default, as explicit timestamps disable Prometheus staleness handling.
+func WithTimestamps(enabled bool) Option {
+	return func(e *Exporter) {
+		e.timestamps = enabled
+	}
+}
+
+// NewExporter returns a new exporter for the modems of source. Use
+// ModemManagerSource to export every modem known to ModemManager. With a nil
+// source ModemManager is reported as down until SetSource or WithReconnect
+// provides one.
+func NewExporter(source ModemSource, opts ...Option) *Exporter {
+	if source == nil {
+		source = disconnectedSource{}
+	}
+	e := &Exporter{
+		source:            source,
+		eventResync:       30 * time.Second,
+		collectionMode:    PollCollection,
+		snapshotRefresh:   time.Minute,
+		ownNumbers:        true,
+		accessTechSeries:  true,
+		modemIdentifier:   DeviceIDIdentifier,
+		locationPrecision: FullLocation,
+		clock:             clock.Real,
+		logger:            slog.Default(),
+		devices:           make(map[string]*deviceState),
+		signalSetup:       make(map[string]signalSetupResult),
+		transitions:       make(map[stateTransition]uint64),
+		callCounters:      make(map[string]*callCounters),
+		probes:            make(map[dbus.ObjectPath]*Exporter),
+		options:           opts,
 
 		// ModemManager info
 		mmInfo: prometheus.NewDesc(
@@ -95,12 +310,24 @@ func NewExporter(mm modemmanager.ModemManager) *Exporter {
 			[]string{"version"},
 			nil,
 		),
+		mmUp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "up"),
+			"Whether ModemManager answered on D-Bus (1 = yes, 0 = no)",
+			nil,
+			nil,
+		),
+		mmModems: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "modems"),
+			"Number of modems known to ModemManager",
+			nil,
+			nil,
+		),
 
 		// Modem info
 		modemInfo: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "modem", "info"),
 			"Modem device information",
-			[]string{"device_id", "manufacturer", "model", "revision", "equipment_id", "device", "plugin", "primary_port"},
+			[]string{"device_id", "manufacturer", "model", "revision", "equipment_id", "device", "plugin", "primary_port", "device_identifier", "imei", "imsi"},
 			nil,
 		),
 		modemState: prometheus.NewDesc(
@@ -121,12 +348,24 @@ func NewExporter(mm modemmanager.ModemManager) *Exporter {
 			[]string{"device_id"},
 			nil,
 		),
+		modemSignalRecent: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "signal_quality_recent"),
+			"Whether the signal quality was measured recently (1) or is a value cached by the modem (0)",
+			[]string{"device_id"},
+			nil,
+		),
 		modemAccessTech: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "modem", "access_technology"),
 			"Current access technology (enumeration)",
 			[]string{"device_id", "technology"},
 			nil,
 		),
+		modemAccessTechActive: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "access_technology_active"),
+			"Whether the modem currently uses the access technology (1 = yes, 0 = no)",
+			[]string{"device_id", "technology"},
+			nil,
+		),
 		modemUnlockRequired: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "modem", "unlock_required"),
 			"Type of unlock required (0 = none)",
@@ -145,6 +384,90 @@ func NewExporter(mm modemmanager.ModemManager) *Exporter {
 			[]string{"device_id"},
 			nil,
 		),
+		modemBearers: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "bearers"),
+			"Number of bearers of the modem by connection status",
+			[]string{"device_id", "connected"},
+			nil,
+		),
+		bearerDisconnects: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "bearer", "disconnects_total"),
+			"Number of observed transitions of a bearer APN from connected to disconnected",
+			[]string{"device_id"},
+			nil,
+		),
+		modemIPFamily: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "ip_family_supported"),
+			"Whether the modem supports the IP family (1 = yes, 0 = no)",
+			[]string{"device_id", "family"},
+			nil,
+		),
+		modemCarrierConfig: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "carrier_config_info"),
+			"Carrier configuration selected in the modem",
+			[]string{"device_id", "name", "revision"},
+			nil,
+		),
+		modemOwnNumber: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "own_number_info"),
+			"Phone number (MSISDN) of the modem as provisioned on the SIM",
+			[]string{"device_id", "number"},
+			nil,
+		),
+		modemFailedReason: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "failed_reason"),
+			"Reason the modem is in the failed state",
+			[]string{"device_id", "reason"},
+			nil,
+		),
+		modemConnectedSince: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "connected_since_timestamp_seconds"),
+			"Time the modem was first observed continuously connected, as a Unix timestamp",
+			[]string{"device_id"},
+			nil,
+		),
+		modemStateTransitions: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "state_transitions_total"),
+			"Number of modem state changes signalled by ModemManager",
+			[]string{"device_id", "old_state", "new_state", "reason"},
+			nil,
+		),
+		modemLastUpdated: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "last_updated_timestamp_seconds"),
+			"Unix time a sub-collector last read the modem successfully",
+			[]string{"device_id", "collector"},
+			nil,
+		),
+		modemModeAllowed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "mode_allowed"),
+			"Access technology mode the modem is currently allowed to use",
+			[]string{"device_id", "mode"},
+			nil,
+		),
+		modemModePreferred: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "mode_preferred"),
+			"Preferred access technology mode among the allowed ones",
+			[]string{"device_id", "mode"},
+			nil,
+		),
+		modemCurrentBand: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "current_band"),
+			"Band the modem is currently allowed to use",
+			[]string{"device_id", "band"},
+			nil,
+		),
+		modemSupportedBandCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "supported_band_count"),
+			"Number of bands supported by the modem",
+			[]string{"device_id"},
+			nil,
+		),
+		modemSuppressed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "suppressed"),
+			"Whether metrics are suppressed because the modem stayed failed beyond the grace period (1 = yes, 0 = no)",
+			[]string{"device_id"},
+			nil,
+		),
 
 		// Signal metrics (LTE)
 		signalLteRssi: prometheus.NewDesc(
@@ -240,17 +563,17 @@ func NewExporter(mm modemmanager.ModemManager) *Exporter {
 			nil,
 		),
 
-		// Bearer metrics
-		bearerInfo: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "bearer", "info"),
-			"Bearer information",
-			[]string{"device_id", "bearer_path", "interface", "ip_method", "ip_address"},
+		// Signal setup status
+		signalSetupConfigured: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "signal", "setup_configured"),
+			"Whether extended signal Setup succeeded with the requested rate (1 = yes, 0 = no)",
+			[]string{"device_id", "reason"},
 			nil,
 		),
-		bearerConnected: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "bearer", "connected"),
-			"Bearer connection status (1 = connected, 0 = disconnected)",
-			[]string{"device_id", "bearer_path"},
+		signalRefreshRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "signal", "refresh_rate_seconds"),
+			"Extended signal refresh rate applied to the modem with Signal.Setup (0 = polling disabled)",
+			[]string{"device_id"},
 			nil,
 		),
 
@@ -281,6 +604,96 @@ func NewExporter(mm modemmanager.ModemManager) *Exporter {
 			[]string{"device_id", "operator_name"},
 			nil,
 		),
+		modem3gppRoaming: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem_3gpp", "roaming"),
+			"Whether the modem is registered on a roaming network (1 = roaming, 0 = home)",
+			[]string{"device_id"},
+			nil,
+		),
+		modem3gppRegistrationDenied: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem_3gpp", "registration_denied_total"),
+			"Number of observed transitions into the denied registration state",
+			[]string{"device_id"},
+			nil,
+		),
+		modem3gppInitialEpsBearer: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem_3gpp", "initial_eps_bearer_info"),
+			"Attach APN configured for LTE registration, and the one in use if it differs",
+			[]string{"device_id", "apn", "ip_type"},
+			nil,
+		),
+		modem3gppEpsUeMode: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem_3gpp", "eps_ue_mode"),
+			"UE mode of operation for EPS (1 = current mode)",
+			[]string{"device_id", "mode"},
+			nil,
+		),
+		modem3gppFacilityLock: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem_3gpp", "facility_lock"),
+			"Whether a PIN or personalization lock is enabled for the facility (1 = enabled, 0 = disabled)",
+			[]string{"device_id", "facility"},
+			nil,
+		),
+
+		// USSD metrics
+		ussdSessionState: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "ussd", "session_state"),
+			"State of the USSD session (1 = current state)",
+			[]string{"device_id", "state"},
+			nil,
+		),
+
+		// CDMA metrics
+		modemCdma1xRegistrationState: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "cdma1x_registration_state"),
+			"CDMA 1x registration state (1 = active)",
+			[]string{"device_id", "state"},
+			nil,
+		),
+		modemEvdoRegistrationState: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "evdo_registration_state"),
+			"EVDO registration state (1 = active)",
+			[]string{"device_id", "state"},
+			nil,
+		),
+		modemCdmaActivationState: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "cdma_activation_state"),
+			"CDMA activation state (1 = active)",
+			[]string{"device_id", "state"},
+			nil,
+		),
+		modemCdmaSid: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "cdma_sid"),
+			"System Identifier of the serving CDMA 1x network",
+			[]string{"device_id"},
+			nil,
+		),
+		modemCdmaNid: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "modem", "cdma_nid"),
+			"Network Identifier of the serving CDMA 1x network",
+			[]string{"device_id"},
+			nil,
+		),
+
+		// OMA device management metrics
+		omaSessionState: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "oma", "session_state"),
+			"State of the current OMA device management session (1 = active)",
+			[]string{"device_id", "state"},
+			nil,
+		),
+		omaSessionType: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "oma", "session_type"),
+			"Type of the current OMA device management session (1 = active)",
+			[]string{"device_id", "type"},
+			nil,
+		),
+		omaFeatureEnabled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "oma", "features_enabled"),
+			"Whether an OMA device management feature is enabled",
+			[]string{"device_id", "feature"},
+			nil,
+		),
 
 		// Messaging metrics
 		messagingSupported: prometheus.NewDesc(
@@ -295,14 +708,32 @@ func NewExporter(mm modemmanager.ModemManager) *Exporter {
 			[]string{"device_id"},
 			nil,
 		),
+		messagingDefaultStorage: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "messaging", "default_storage"),
+			"Storage used for received and stored SMS (1 = default)",
+			[]string{"device_id", "storage"},
+			nil,
+		),
+		messagingSupportedStorage: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "messaging", "supported_storage"),
+			"Storage supported for storing and receiving SMS (1 = supported)",
+			[]string{"device_id", "storage"},
+			nil,
+		),
 
 		// Location metrics
 		locationEnabled: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "location", "enabled"),
-			"Whether location services are enabled (1 = yes, 0 = no)",
+			"Whether any location source is enabled (1 = yes, 0 = no)",
 			[]string{"device_id"},
 			nil,
 		),
+		locationSourceEnabled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "source_enabled"),
+			"Enabled location source (1 = enabled)",
+			[]string{"device_id", "source"},
+			nil,
+		),
 		locationLatitude: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "location", "latitude_degrees"),
 			"Current latitude in degrees",
@@ -321,6 +752,114 @@ func NewExporter(mm modemmanager.ModemManager) *Exporter {
 			[]string{"device_id"},
 			nil,
 		),
+		location3gppInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "3gpp_info"),
+			"3GPP serving cell information (LAC, TAC and CID in upper-case hexadecimal)",
+			[]string{"device_id", "mcc", "mnc", "lac", "tac", "cid"},
+			nil,
+		),
+		location3gppCellID: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "3gpp_cell_id"),
+			"3GPP serving cell identifier as a number",
+			[]string{"device_id"},
+			nil,
+		),
+		locationGpsSpeed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "gps_speed_kmh"),
+			"GPS speed over ground in km/h",
+			[]string{"device_id"},
+			nil,
+		),
+		locationGpsHeading: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "gps_heading_degrees"),
+			"GPS course over ground in degrees",
+			[]string{"device_id"},
+			nil,
+		),
+		locationGpsSatUsed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "gps_satellites_used"),
+			"Number of satellites used in the GPS fix",
+			[]string{"device_id"},
+			nil,
+		),
+		locationGpsHdop: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "gps_hdop"),
+			"Horizontal dilution of precision of the GPS fix, lower is more accurate",
+			[]string{"device_id"},
+			nil,
+		),
+		locationFix: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "fix"),
+			"Whether the modem has a valid GPS fix (1 = yes, 0 = no)",
+			[]string{"device_id"},
+			nil,
+		),
+		locationGpsUtcTime: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "location", "gps_utc_timestamp_seconds"),
+			"UTC time of the GPS fix as a Unix timestamp",
+			[]string{"device_id"},
+			nil,
+		),
+
+		// Firmware metrics
+		firmwareInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "firmware", "info"),
+			"Firmware image installed on the modem",
+			[]string{"device_id", "selected", "version", "type"},
+			nil,
+		),
+		firmwareUpdateMethod: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "firmware", "update_method"),
+			"Firmware update method supported by the modem",
+			[]string{"device_id", "method"},
+			nil,
+		),
+
+		// Time metrics
+		timeNetworkOffset: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "time", "network_offset_seconds"),
+			"Network time minus the exporter's local clock at the moment of the query",
+			[]string{"device_id"},
+			nil,
+		),
+		timeTimezoneOffset: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "time", "timezone_offset_minutes"),
+			"Timezone offset from UTC reported by the network, including DST",
+			[]string{"device_id"},
+			nil,
+		),
+
+		// Voice metrics
+		voiceCalls: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "voice", "calls"),
+			"Number of call objects by state and direction",
+			[]string{"device_id", "state", "direction"},
+			nil,
+		),
+		voiceEmergencyOnly: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "voice", "emergency_only"),
+			"Whether only emergency calls are allowed (1 = yes, 0 = no)",
+			[]string{"device_id"},
+			nil,
+		),
+		voiceCallsTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "voice", "calls_total"),
+			"Number of voice calls observed through CallAdded signals",
+			[]string{"device_id", "direction"},
+			nil,
+		),
+		voiceActiveCalls: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "voice", "active_calls"),
+			"Number of voice calls currently in the active state",
+			[]string{"device_id"},
+			nil,
+		),
+		voiceMissedCallsTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "voice", "missed_calls_total"),
+			"Number of incoming voice calls that ended without ever being active",
+			[]string{"device_id"},
+			nil,
+		),
 
 		// Scrape metrics
 		scrapeDuration: prometheus.NewDesc(
@@ -337,24 +876,72 @@ func NewExporter(mm modemmanager.ModemManager) *Exporter {
 		),
 		scrapeErrors: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "scrape", "errors_total"),
-			"Total number of errors during scrape",
+			"Total number of errors during all scrapes",
+			nil,
+			nil,
+		),
+		scrapeLastErrors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape", "last_errors"),
+			"Number of errors during the last scrape",
 			nil,
 			nil,
 		),
+
+		// Collection duration histograms
+		scrapeDurations: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:                   namespace,
+			Subsystem:                   "scrape",
+			Name:                        "collect_duration_seconds",
+			Help:                        "Histogram of scrape durations in seconds",
+			Buckets:                     durationBuckets,
+			NativeHistogramBucketFactor: 1.1,
+		}),
+		modemDurations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:                   namespace,
+			Subsystem:                   "modem",
+			Name:                        "collect_duration_seconds",
+			Help:                        "Histogram of the time spent reading the metrics of a modem in seconds",
+			Buckets:                     durationBuckets,
+			NativeHistogramBucketFactor: 1.1,
+		}, []string{"device_id"}),
+	}
+
+	for _, opt := range opts {
+		opt(e)
 	}
+	e.initBearerDescs()
+
+	return e
 }
 
 // Describe implements the prometheus.Collector interface.
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.mmInfo
+	ch <- e.mmUp
+	ch <- e.mmModems
 	ch <- e.modemInfo
 	ch <- e.modemState
 	ch <- e.modemPowerState
 	ch <- e.modemSignalQuality
+	ch <- e.modemSignalRecent
 	ch <- e.modemAccessTech
+	ch <- e.modemAccessTechActive
 	ch <- e.modemUnlockRequired
 	ch <- e.modemMaxBearers
 	ch <- e.modemMaxActiveBearers
+	ch <- e.modemBearers
+	ch <- e.modemIPFamily
+	ch <- e.modemCarrierConfig
+	ch <- e.modemOwnNumber
+	ch <- e.modemFailedReason
+	ch <- e.modemSuppressed
+	ch <- e.modemConnectedSince
+	ch <- e.modemStateTransitions
+	ch <- e.modemLastUpdated
+	ch <- e.modemModeAllowed
+	ch <- e.modemModePreferred
+	ch <- e.modemCurrentBand
+	ch <- e.modemSupportedBandCount
 	ch <- e.signalLteRssi
 	ch <- e.signalLteRsrq
 	ch <- e.signalLteRsrp
@@ -369,21 +956,68 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- e.signalEvdoEcio
 	ch <- e.signalEvdoSinr
 	ch <- e.signalEvdoIo
+	ch <- e.signalSetupConfigured
+	ch <- e.signalRefreshRate
 	ch <- e.bearerInfo
 	ch <- e.bearerConnected
+	ch <- e.bearerIp6Info
+	ch <- e.bearerSuspended
+	ch <- e.bearerIpTimeout
+	ch <- e.bearerAttempts
+	ch <- e.bearerFailedAttempts
+	ch <- e.bearerRxBytes
+	ch <- e.bearerTxBytes
+	ch <- e.bearerDisconnects
 	ch <- e.simInfo
 	ch <- e.modem3gppRegistrationState
 	ch <- e.modem3gppOperatorCode
 	ch <- e.modem3gppOperatorName
+	ch <- e.modem3gppRoaming
+	ch <- e.modem3gppRegistrationDenied
+	ch <- e.modem3gppInitialEpsBearer
+	ch <- e.modem3gppEpsUeMode
+	ch <- e.modem3gppFacilityLock
+	ch <- e.ussdSessionState
+	ch <- e.modemCdma1xRegistrationState
+	ch <- e.modemEvdoRegistrationState
+	ch <- e.modemCdmaActivationState
+	ch <- e.modemCdmaSid
+	ch <- e.modemCdmaNid
+	ch <- e.omaSessionState
+	ch <- e.omaSessionType
+	ch <- e.omaFeatureEnabled
 	ch <- e.messagingSupported
 	ch <- e.smsCount
+	ch <- e.messagingDefaultStorage
+	ch <- e.messagingSupportedStorage
 	ch <- e.locationEnabled
+	ch <- e.locationSourceEnabled
 	ch <- e.locationLatitude
 	ch <- e.locationLongitude
 	ch <- e.locationAltitude
+	ch <- e.location3gppInfo
+	ch <- e.location3gppCellID
+	ch <- e.locationGpsSpeed
+	ch <- e.locationGpsHeading
+	ch <- e.locationGpsSatUsed
+	ch <- e.locationGpsUtcTime
+	ch <- e.locationGpsHdop
+	ch <- e.locationFix
+	ch <- e.firmwareInfo
+	ch <- e.firmwareUpdateMethod
+	ch <- e.timeNetworkOffset
+	ch <- e.timeTimezoneOffset
+	ch <- e.voiceCalls
+	ch <- e.voiceEmergencyOnly
+	ch <- e.voiceCallsTotal
+	ch <- e.voiceActiveCalls
+	ch <- e.voiceMissedCallsTotal
 	ch <- e.scrapeDuration
 	ch <- e.scrapeSuccess
 	ch <- e.scrapeErrors
+	ch <- e.scrapeLastErrors
+	e.scrapeDurations.Describe(ch)
+	e.modemDurations.Describe(ch)
 }
 
 // Collect implements the prometheus.Collector interface.
@@ -392,48 +1026,144 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	errorCount := 0
 	success := 1.0
 
-	// Collect ModemManager version
-	if version, err := e.mm.GetVersion(); err == nil {
-		ch <- prometheus.MustNewConstMetric(e.mmInfo, prometheus.GaugeValue, 1.0, version)
-	} else {
-		log.Printf("Error getting ModemManager version: %v", err)
-		errorCount++
+	// Check that ModemManager answers, reconnecting once if the daemon is gone
+	version, err := e.modemSource().Version()
+	if err != nil && isDaemonGone(err) && e.reconnect() {
+		version, err = e.modemSource().Version()
 	}
+	up := 0.0
+	if err == nil {
+		up = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(e.mmUp, prometheus.GaugeValue, up)
+	e.observeDaemon(err == nil)
 
-	// Collect modem metrics
-	modems, err := e.mm.GetModems()
 	if err != nil {
-		log.Printf("Error getting modems: %v", err)
+		// Listing the modems would only wait for the same failure again
+		e.logger.Error("Error getting ModemManager version", "err", err)
 		errorCount++
 		success = 0.0
 	} else {
-		for _, modem := range modems {
-			if err := e.collectModemMetrics(ch, modem); err != nil {
-				log.Printf("Error collecting metrics for modem: %v", err)
-				errorCount++
-			}
+		ch <- prometheus.MustNewConstMetric(e.mmInfo, prometheus.GaugeValue, 1.0, version)
+	}
+
+	// Collect modem metrics, from the snapshots of Start in EventCollection mode
+	if err == nil && !e.collectSnapshots(ch) {
+		modemErrors, err := e.pollModems(ch)
+		errorCount += modemErrors
+		if err != nil {
+			e.logger.Error("Error getting modems", "err", err)
+			errorCount++
+			success = 0.0
 		}
 	}
 
+	e.collectStateTransitions(ch)
+	e.collectCallCounters(ch)
+	e.collectBearerDisconnects(ch)
+	e.collectLastUpdated(ch)
+
 	// Export scrape metrics
 	duration := time.Since(start).Seconds()
 	ch <- prometheus.MustNewConstMetric(e.scrapeDuration, prometheus.GaugeValue, duration)
 	ch <- prometheus.MustNewConstMetric(e.scrapeSuccess, prometheus.GaugeValue, success)
-	ch <- prometheus.MustNewConstMetric(e.scrapeErrors, prometheus.CounterValue, float64(errorCount))
+	e.mu.Lock()
+	e.scrapeErrorsTotal += uint64(errorCount)
+	errorsTotal := e.scrapeErrorsTotal
+	if success == 1 {
+		e.scraped = true
+	}
+	e.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(e.scrapeErrors, prometheus.CounterValue, float64(errorsTotal))
+	ch <- prometheus.MustNewConstMetric(e.scrapeLastErrors, prometheus.GaugeValue, float64(errorCount))
+	e.scrapeDurations.Observe(duration)
+	e.scrapeDurations.Collect(ch)
+	e.modemDurations.Collect(ch)
 }
 
-func (e *Exporter) collectModemMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem) error {
-	deviceID, err := modem.GetDeviceIdentifier()
+// pollModems reads and emits the metrics of every modem of the source. It
+// returns the number of modems that could not be read, and an error if the
+// modems could not be listed.
+func (e *Exporter) pollModems(ch chan<- prometheus.Metric) (int, error) {
+	modems, err := e.modems()
 	if err != nil {
-		return fmt.Errorf("failed to get device identifier: %w", err)
+		return 0, err
+	}
+	ch <- prometheus.MustNewConstMetric(e.mmModems, prometheus.GaugeValue, float64(len(modems)))
+	ordered, errs := modemsByDeviceID(modems, e.modemLabel)
+	for _, err := range errs {
+		e.logger.Warn("Error collecting metrics for modem", "err", err)
 	}
 
+	seen := make(map[string]bool)
+	for _, m := range ordered {
+		modemCh, flush := ch, func() {}
+		if e.timestamps {
+			modemCh, flush = timestamped(ch, e.clock.Now())
+		}
+		e.collectModemMetrics(modemCh, m.modem, m.deviceID)
+		flush()
+		seen[m.deviceID] = true
+	}
+	e.forgetMissingDevices(seen)
+	return len(errs), nil
+}
+
+// timestamped returns a channel whose metrics are forwarded to ch with the
+// explicit timestamp ts. flush waits until all sent metrics are forwarded.
+func timestamped(ch chan<- prometheus.Metric, ts time.Time) (stamped chan<- prometheus.Metric, flush func()) {
+	in := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for m := range in {
+			ch <- prometheus.NewMetricWithTimestamp(ts, m)
+		}
+	}()
+	return in, func() {
+		close(in)
+		<-done
+	}
+}
+
+// collectModemMetrics collects all metrics of a modem.
+func (e *Exporter) collectModemMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	start := time.Now()
+	defer func() {
+		e.observeCollectDuration(deviceID, time.Since(start))
+	}()
+
 	// Collect basic modem info
 	e.collectModemInfo(ch, modem, deviceID)
 
+	// Modems failed for longer than the grace period only get a minimal metric set
+	suppressed := false
+	if state, err := modem.GetState(); err == nil {
+		suppressed = e.observeFailedState(deviceID, state == modemmanager.MmModemStateFailed)
+	}
+	if e.failedModemGrace > 0 {
+		suppressedValue := 0.0
+		if suppressed {
+			suppressedValue = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(e.modemSuppressed, prometheus.GaugeValue, suppressedValue, deviceID)
+	}
+	if suppressed {
+		e.collectSuppressedModem(ch, modem, deviceID)
+		return
+	}
+
 	// Collect modem state
 	e.collectModemState(ch, modem, deviceID)
 
+	// Collect mode metrics
+	e.collectModeMetrics(ch, modem, deviceID)
+
+	// Collect band metrics
+	if e.bandMetrics {
+		e.collectBandMetrics(ch, modem, deviceID)
+	}
+
 	// Collect signal metrics
 	e.collectSignalMetrics(ch, modem, deviceID)
 
@@ -446,30 +1176,51 @@ func (e *Exporter) collectModemMetrics(ch chan<- prometheus.Metric, modem modemm
 	// Collect 3GPP metrics
 	e.collect3GPPMetrics(ch, modem, deviceID)
 
+	// Collect CDMA metrics
+	e.collectCDMAMetrics(ch, modem, deviceID)
+
+	// Collect OMA device management metrics
+	e.collectOMAMetrics(ch, modem, deviceID)
+
 	// Collect messaging metrics
 	e.collectMessagingMetrics(ch, modem, deviceID)
 
 	// Collect location metrics
 	e.collectLocationMetrics(ch, modem, deviceID)
 
-	return nil
+	// Collect firmware metrics
+	e.collectFirmwareMetrics(ch, modem, deviceID)
+
+	// Collect network time metrics
+	e.collectTimeMetrics(ch, modem, deviceID)
+
+	// Collect voice metrics
+	e.collectVoiceMetrics(ch, modem, deviceID)
 }
 
 func (e *Exporter) collectModemInfo(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
-	manufacturer, _ := modem.GetManufacturer()
-	model, _ := modem.GetModel()
+	manufacturer, manufacturerErr := modem.GetManufacturer()
+	model, modelErr := modem.GetModel()
 	revision, _ := modem.GetRevision()
 	equipmentID, _ := modem.GetEquipmentIdentifier()
 	device, _ := modem.GetDevice()
 	plugin, _ := modem.GetPlugin()
 	primaryPort, _ := modem.GetPrimaryPort()
 
+	// All identifiers, whichever of them populates device_id
+	deviceIdentifier, _ := modem.GetDeviceIdentifier()
+	imei := modemIMEI(modem)
+	imsi := modemIMSI(modem)
+
 	ch <- prometheus.MustNewConstMetric(
 		e.modemInfo,
 		prometheus.GaugeValue,
 		1.0,
-		deviceID, manufacturer, model, revision, equipmentID, device, plugin, primaryPort,
+		deviceID, manufacturer, model, revision, equipmentID, device, plugin, primaryPort, deviceIdentifier, imei, imsi,
 	)
+	if manufacturerErr == nil && modelErr == nil {
+		e.observeCollected(deviceID, "info")
+	}
 
 	// Max bearers
 	if maxBearers, err := modem.GetMaxBearers(); err == nil {
@@ -479,6 +1230,52 @@ func (e *Exporter) collectModemInfo(ch chan<- prometheus.Metric, modem modemmana
 	if maxActiveBearers, err := modem.GetMaxActiveBearers(); err == nil {
 		ch <- prometheus.MustNewConstMetric(e.modemMaxActiveBearers, prometheus.GaugeValue, float64(maxActiveBearers), deviceID)
 	}
+
+	// Supported IP families, one series per known family
+	if families, err := modem.GetSupportedIpFamilies(); err == nil {
+		supported := make(map[modemmanager.MMBearerIpFamily]bool)
+		for _, family := range families {
+			supported[family] = true
+		}
+		for _, family := range modemmanager.MmBearerIpFamilyNone.GetAllIPFamilies() {
+			value := 0.0
+			if supported[family] {
+				value = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(e.modemIPFamily, prometheus.GaugeValue, value, deviceID, ipFamilyToString(family))
+		}
+	}
+
+	// Carrier configuration, empty on modems without carrier config support
+	if name, err := modem.GetCarrierConfiguration(); err == nil && name != "" {
+		revision, _ := modem.GetCarrierConfigurationRevision()
+		ch <- prometheus.MustNewConstMetric(e.modemCarrierConfig, prometheus.GaugeValue, 1.0, deviceID, name, revision)
+	}
+
+	// Own numbers, empty when the carrier does not provision them on the SIM
+	if e.ownNumbers {
+		if numbers, err := modem.GetOwnNumbers(); err == nil {
+			seen := make(map[string]bool)
+			for _, number := range numbers {
+				if number == "" || seen[number] {
+					continue
+				}
+				seen[number] = true
+				ch <- prometheus.MustNewConstMetric(e.modemOwnNumber, prometheus.GaugeValue, 1.0, deviceID, number)
+			}
+		}
+	}
+}
+
+// collectSuppressedModem emits the reduced metric set for a modem that has
+// been failed for longer than the grace period.
+func (e *Exporter) collectSuppressedModem(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	ch <- prometheus.MustNewConstMetric(e.modemState, prometheus.GaugeValue, 1.0, deviceID, stateToString(modemmanager.MmModemStateFailed))
+	e.observeConnectedState(deviceID, false)
+
+	if reason, err := modem.GetStateFailedReason(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.modemFailedReason, prometheus.GaugeValue, 1.0, deviceID, failedReasonToString(reason))
+	}
 }
 
 func (e *Exporter) collectModemState(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
@@ -486,6 +1283,19 @@ func (e *Exporter) collectModemState(ch chan<- prometheus.Metric, modem modemman
 	if state, err := modem.GetState(); err == nil {
 		stateStr := stateToString(state)
 		ch <- prometheus.MustNewConstMetric(e.modemState, prometheus.GaugeValue, 1.0, deviceID, stateStr)
+
+		// Failed reason, only while failed so the series disappears on recovery
+		if state == modemmanager.MmModemStateFailed {
+			if reason, err := modem.GetStateFailedReason(); err == nil {
+				ch <- prometheus.MustNewConstMetric(e.modemFailedReason, prometheus.GaugeValue, 1.0, deviceID, failedReasonToString(reason))
+			}
+		}
+
+		// Connected since, only while connected
+		if since := e.observeConnectedState(deviceID, state == modemmanager.MmModemStateConnected); !since.IsZero() {
+			ch <- prometheus.MustNewConstMetric(e.modemConnectedSince, prometheus.GaugeValue, float64(since.UnixNano())/1e9, deviceID)
+		}
+		e.observeCollected(deviceID, "state")
 	}
 
 	// Power state
@@ -495,8 +1305,13 @@ func (e *Exporter) collectModemState(ch chan<- prometheus.Metric, modem modemman
 	}
 
 	// Signal quality
-	if quality, _, err := modem.GetSignalQuality(); err == nil {
+	if quality, recent, err := modem.GetSignalQuality(); err == nil {
 		ch <- prometheus.MustNewConstMetric(e.modemSignalQuality, prometheus.GaugeValue, float64(quality), deviceID)
+		recentValue := 0.0
+		if recent {
+			recentValue = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(e.modemSignalRecent, prometheus.GaugeValue, recentValue, deviceID)
 	}
 
 	// Access technology
@@ -506,6 +1321,21 @@ func (e *Exporter) collectModemState(ch chan<- prometheus.Metric, modem modemman
 			techStr := accessTechToString(accessTechs[0])
 			ch <- prometheus.MustNewConstMetric(e.modemAccessTech, prometheus.GaugeValue, 1.0, deviceID, techStr)
 		}
+
+		// One series per known technology
+		if e.accessTechSeries {
+			var active modemmanager.MMModemAccessTechnology
+			for _, tech := range accessTechs {
+				active |= tech
+			}
+			for _, t := range accessTechNames {
+				activeValue := 0.0
+				if active&t.tech != 0 {
+					activeValue = 1.0
+				}
+				ch <- prometheus.MustNewConstMetric(e.modemAccessTechActive, prometheus.GaugeValue, activeValue, deviceID, t.name)
+			}
+		}
 	}
 
 	// Unlock required
@@ -514,7 +1344,53 @@ func (e *Exporter) collectModemState(ch chan<- prometheus.Metric, modem modemman
 	}
 }
 
+func (e *Exporter) collectModeMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	modes, err := modem.GetCurrentModes()
+	if err != nil {
+		return
+	}
+
+	for _, mode := range modes.Allowed.Modes() {
+		ch <- prometheus.MustNewConstMetric(e.modemModeAllowed, prometheus.GaugeValue, 1.0, deviceID, mode.String())
+	}
+	// No preference is reported as MmModemModeNone
+	if modes.Preferred != modemmanager.MmModemModeNone {
+		ch <- prometheus.MustNewConstMetric(e.modemModePreferred, prometheus.GaugeValue, 1.0, deviceID, modes.Preferred.String())
+	}
+	e.observeCollected(deviceID, "modes")
+}
+
+func (e *Exporter) collectBandMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	if bands, err := modem.GetCurrentBands(); err == nil {
+		sorted := append([]modemmanager.MMModemBand(nil), bands...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		for _, band := range sorted {
+			ch <- prometheus.MustNewConstMetric(e.modemCurrentBand, prometheus.GaugeValue, 1.0, deviceID, bandToString(band))
+		}
+		e.observeCollected(deviceID, "bands")
+	}
+
+	if bands, err := modem.GetSupportedBands(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.modemSupportedBandCount, prometheus.GaugeValue, float64(len(bands)), deviceID)
+	}
+}
+
 func (e *Exporter) collectSignalMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	// Signal setup status, only known for modems SetupSignalMonitoring ran against
+	e.mu.Lock()
+	setup, ok := e.signalSetup[deviceID]
+	e.mu.Unlock()
+	if ok {
+		configuredValue := 0.0
+		if setup.configured {
+			configuredValue = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(e.signalSetupConfigured, prometheus.GaugeValue, configuredValue, deviceID, setup.reason)
+		if setup.configured {
+			ch <- prometheus.MustNewConstMetric(e.signalRefreshRate, prometheus.GaugeValue, setup.rate.Seconds(), deviceID)
+		}
+	}
+
 	signal, err := modem.GetSignal()
 	if err != nil {
 		// Signal interface might not be available
@@ -572,42 +1448,90 @@ func (e *Exporter) collectSignalMetrics(ch chan<- prometheus.Metric, modem modem
 			ch <- prometheus.MustNewConstMetric(e.signalEvdoIo, prometheus.GaugeValue, evdo.Io, deviceID)
 		}
 	}
+	e.observeCollected(deviceID, "signal")
 }
 
 func (e *Exporter) collectBearerMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
-	bearers, err := modem.GetBearers()
+	bearers, err := modemmanager.ListModemBearers(modem)
 	if err != nil {
 		return
 	}
+	sortBearersByPath(bearers)
+	labels := e.bearerLabels(bearers)
 
-	for _, bearer := range bearers {
-		// Bearer info
+	connectedCount := 0
+	apnConnected := make(map[string]bool)
+	for i, bearer := range bearers {
+		// Bearer info, the only metric carrying the path unless it is the label
 		iface, _ := bearer.GetInterface()
 		connected, _ := bearer.GetConnected()
-		bearerPath := bearer.GetObjectPath()
+		bearerLabel := labels[i]
+		if properties, err := bearer.GetProperties(); err == nil {
+			apnConnected[properties.APN] = apnConnected[properties.APN] || connected
+		}
 
 		ipConfig, err := bearer.GetIp4Config()
 		ipMethod := ""
 		ipAddress := ""
 		if err == nil {
-			ipMethod = fmt.Sprint(ipConfig.Method)
+			ipMethod = ipMethodToString(ipConfig.Method)
 			ipAddress = ipConfig.Address
 		}
 
-		ch <- prometheus.MustNewConstMetric(
-			e.bearerInfo,
-			prometheus.GaugeValue,
-			1.0,
-			deviceID, string(bearerPath), iface, ipMethod, ipAddress,
-		)
+		infoLabels := []string{deviceID, bearerLabel}
+		if !e.bearerPathLabels {
+			infoLabels = append(infoLabels, string(bearer.GetObjectPath()))
+		}
+		infoLabels = append(infoLabels, iface, ipMethod, ipAddress)
+		ch <- prometheus.MustNewConstMetric(e.bearerInfo, prometheus.GaugeValue, 1.0, infoLabels...)
 
 		// Bearer connected status
 		connectedValue := 0.0
 		if connected {
 			connectedValue = 1.0
+			connectedCount++
+		}
+		ch <- prometheus.MustNewConstMetric(e.bearerConnected, prometheus.GaugeValue, connectedValue, deviceID, bearerLabel)
+
+		// Bearer suspended status, emitted for connected and disconnected bearers alike
+		if suspended, err := bearer.GetSuspended(); err == nil {
+			suspendedValue := 0.0
+			if suspended {
+				suspendedValue = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(e.bearerSuspended, prometheus.GaugeValue, suspendedValue, deviceID, bearerLabel)
+		}
+
+		// IP timeout
+		if ipTimeout, err := bearer.GetIpTimeout(); err == nil {
+			ch <- prometheus.MustNewConstMetric(e.bearerIpTimeout, prometheus.GaugeValue, float64(ipTimeout), deviceID, bearerLabel)
+		}
+
+		// IPv6 configuration, v4-only bearers have none and may return an error
+		if ip6Config, err := bearer.GetIp6Config(); err == nil && ip6Config.Address != "" {
+			ch <- prometheus.MustNewConstMetric(
+				e.bearerIp6Info,
+				prometheus.GaugeValue,
+				1.0,
+				deviceID, bearerLabel, ipMethodToString(ip6Config.Method), ip6Config.Address, strconv.FormatUint(uint64(ip6Config.Prefix), 10),
+			)
+		}
+
+		// Lifetime statistics, reported since ModemManager 1.14. Older daemons
+		// leave them zero, and a bearer counts at least one attempt once used.
+		if stats, err := bearer.GetStats(); err == nil && stats.Attempts > 0 {
+			ch <- prometheus.MustNewConstMetric(e.bearerAttempts, prometheus.CounterValue, float64(stats.Attempts), deviceID, bearerLabel)
+			ch <- prometheus.MustNewConstMetric(e.bearerFailedAttempts, prometheus.CounterValue, float64(stats.FailedAttempts), deviceID, bearerLabel)
+			ch <- prometheus.MustNewConstMetric(e.bearerRxBytes, prometheus.CounterValue, float64(stats.TotalRxBytes), deviceID, bearerLabel)
+			ch <- prometheus.MustNewConstMetric(e.bearerTxBytes, prometheus.CounterValue, float64(stats.TotalTxBytes), deviceID, bearerLabel)
 		}
-		ch <- prometheus.MustNewConstMetric(e.bearerConnected, prometheus.GaugeValue, connectedValue, deviceID, string(bearerPath))
 	}
+
+	// Bearer counts, both emitted so a modem without bearers reports zero
+	ch <- prometheus.MustNewConstMetric(e.modemBearers, prometheus.GaugeValue, float64(connectedCount), deviceID, "true")
+	ch <- prometheus.MustNewConstMetric(e.modemBearers, prometheus.GaugeValue, float64(len(bearers)-connectedCount), deviceID, "false")
+	e.observeBearers(deviceID, apnConnected)
+	e.observeCollected(deviceID, "bearers")
 }
 
 func (e *Exporter) collectSIMMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
@@ -626,6 +1550,7 @@ func (e *Exporter) collectSIMMetrics(ch chan<- prometheus.Metric, modem modemman
 		1.0,
 		deviceID, string(simPath), imsi, operatorName,
 	)
+	e.observeCollected(deviceID, "sim")
 }
 
 func (e *Exporter) collect3GPPMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
@@ -638,6 +1563,20 @@ func (e *Exporter) collect3GPPMetrics(ch chan<- prometheus.Metric, modem modemma
 	if regState, err := modem3gpp.GetRegistrationState(); err == nil {
 		regStateStr := registrationStateToString(regState)
 		ch <- prometheus.MustNewConstMetric(e.modem3gppRegistrationState, prometheus.GaugeValue, 1.0, deviceID, regStateStr)
+
+		// Roaming indicator, only exported while registered
+		if roaming, registered := registrationStateRoaming(regState); registered {
+			roamingValue := 0.0
+			if roaming {
+				roamingValue = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(e.modem3gppRoaming, prometheus.GaugeValue, roamingValue, deviceID)
+		}
+
+		// Registration denied transitions
+		denied := e.observeRegistrationState(deviceID, regState)
+		ch <- prometheus.MustNewConstMetric(e.modem3gppRegistrationDenied, prometheus.CounterValue, float64(denied), deviceID)
+		e.observeCollected(deviceID, "3gpp")
 	}
 
 	// Operator code
@@ -649,6 +1588,126 @@ func (e *Exporter) collect3GPPMetrics(ch chan<- prometheus.Metric, modem modemma
 	if operatorName, err := modem3gpp.GetOperatorName(); err == nil && operatorName != "" {
 		ch <- prometheus.MustNewConstMetric(e.modem3gppOperatorName, prometheus.GaugeValue, 1.0, deviceID, operatorName)
 	}
+
+	// Facility locks, e.g. a device locked to an operator (net-pers)
+	if locks, err := modem3gpp.GetEnabledFacilityLocks(); err == nil {
+		var facility modemmanager.MMModem3gppFacility
+		for _, f := range facility.GetAllFacilities() {
+			enabledValue := 0.0
+			if slices.Contains(locks, f) {
+				enabledValue = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(e.modem3gppFacilityLock, prometheus.GaugeValue, enabledValue, deviceID, facilityToString(f))
+		}
+	}
+
+	e.collectEpsMetrics(ch, modem3gpp, deviceID)
+	e.collectUssdMetrics(ch, modem3gpp, deviceID)
+}
+
+// collectEpsMetrics exports the LTE attach settings. The properties only
+// exist on LTE capable modems, so read errors are expected and ignored.
+func (e *Exporter) collectEpsMetrics(ch chan<- prometheus.Metric, modem3gpp modemmanager.Modem3gpp, deviceID string) {
+	if mode, err := modem3gpp.GetEpsUeModeOperation(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.modem3gppEpsUeMode, prometheus.GaugeValue, 1.0, deviceID, epsUeModeToString(mode))
+	}
+
+	// The configured settings, and the bearer used for the last attach if
+	// its APN or IP type differs, which usually means the network overrode
+	// the settings
+	settings, err := modem3gpp.GetInitialEpsBearerSettings()
+	settingsKnown := err == nil
+	if settingsKnown {
+		ch <- prometheus.MustNewConstMetric(e.modem3gppInitialEpsBearer, prometheus.GaugeValue, 1.0, deviceID, settings.APN, ipFamilyToString(settings.IPType))
+	}
+	bearer, err := modem3gpp.GetInitialEpsBearer()
+	if err != nil {
+		return
+	}
+	live, err := bearer.GetProperties()
+	if err != nil {
+		return
+	}
+	if !settingsKnown || live.APN != settings.APN || live.IPType != settings.IPType {
+		ch <- prometheus.MustNewConstMetric(e.modem3gppInitialEpsBearer, prometheus.GaugeValue, 1.0, deviceID, live.APN, ipFamilyToString(live.IPType))
+	}
+}
+
+// collectUssdMetrics exports the state of the USSD session. Most data-only
+// modules have no USSD interface, so read errors are ignored.
+func (e *Exporter) collectUssdMetrics(ch chan<- prometheus.Metric, modem3gpp modemmanager.Modem3gpp, deviceID string) {
+	ussd, err := modem3gpp.GetUssd()
+	if err != nil {
+		return
+	}
+	if state, err := ussd.GetState(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.ussdSessionState, prometheus.GaugeValue, 1.0, deviceID, ussdSessionStateToString(state))
+	}
+}
+
+func (e *Exporter) collectCDMAMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	// The CDMA interface object is created without checking that it exists,
+	// so look at the capabilities first instead of failing every property read
+	capabilities, err := modem.GetCurrentCapabilities()
+	if err != nil || !slices.Contains(capabilities, modemmanager.MmModemCapabilityCdmaEvdo) {
+		return
+	}
+	cdma, err := modem.GetCdma()
+	if err != nil {
+		return
+	}
+
+	if state, err := cdma.GetCdma1xRegistrationState(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.modemCdma1xRegistrationState, prometheus.GaugeValue, 1.0, deviceID, cdmaRegistrationStateToString(state))
+		e.observeCollected(deviceID, "cdma")
+	}
+	if state, err := cdma.GetEvdoRegistrationState(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.modemEvdoRegistrationState, prometheus.GaugeValue, 1.0, deviceID, cdmaRegistrationStateToString(state))
+	}
+	if state, err := cdma.GetActivationState(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.modemCdmaActivationState, prometheus.GaugeValue, 1.0, deviceID, cdmaActivationStateToString(state))
+	}
+
+	// SID and NID, only known while registered with a CDMA 1x network
+	if sid, err := cdma.GetSid(); err == nil && sid != modemmanager.ModemCdmaSidUnknown {
+		ch <- prometheus.MustNewConstMetric(e.modemCdmaSid, prometheus.GaugeValue, float64(sid), deviceID)
+	}
+	if nid, err := cdma.GetNid(); err == nil && nid != modemmanager.ModemCdmaNidUnknown {
+		ch <- prometheus.MustNewConstMetric(e.modemCdmaNid, prometheus.GaugeValue, float64(nid), deviceID)
+	}
+}
+
+func (e *Exporter) collectOMAMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	oma, err := modem.GetOma()
+	if err != nil {
+		return
+	}
+	// Only a few CDMA modems implement the interface; the features read
+	// fails on all others
+	features, err := oma.GetFeatures()
+	if err != nil {
+		return
+	}
+
+	enabled := make(map[modemmanager.MMOmaFeature]bool)
+	for _, feature := range features {
+		enabled[feature] = true
+	}
+	for _, feature := range modemmanager.MmOmaFeatureNone.GetAllFeatures() {
+		value := 0.0
+		if enabled[feature] {
+			value = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(e.omaFeatureEnabled, prometheus.GaugeValue, value, deviceID, omaFeatureToString(feature))
+	}
+	e.observeCollected(deviceID, "oma")
+
+	if state, err := oma.GetSessionState(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.omaSessionState, prometheus.GaugeValue, 1.0, deviceID, omaSessionStateToString(state))
+	}
+	if sessionType, err := oma.GetSessionType(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.omaSessionType, prometheus.GaugeValue, 1.0, deviceID, omaSessionTypeToString(sessionType))
+	}
 }
 
 func (e *Exporter) collectMessagingMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
@@ -663,6 +1722,18 @@ func (e *Exporter) collectMessagingMetrics(ch chan<- prometheus.Metric, modem mo
 	// Get SMS count
 	if messages, err := messaging.GetMessages(); err == nil {
 		ch <- prometheus.MustNewConstMetric(e.smsCount, prometheus.GaugeValue, float64(len(messages)), deviceID)
+		e.observeCollected(deviceID, "messaging")
+	}
+
+	// Storage configuration, e.g. a full ME default storage next to an
+	// empty SIM storage
+	if storage, err := messaging.GetDefaultStorage(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.messagingDefaultStorage, prometheus.GaugeValue, 1.0, deviceID, smsStorageToString(storage))
+	}
+	if storages, err := messaging.GetSupportedStorages(); err == nil {
+		for _, storage := range storages {
+			ch <- prometheus.MustNewConstMetric(e.messagingSupportedStorage, prometheus.GaugeValue, 1.0, deviceID, smsStorageToString(storage))
+		}
 	}
 }
 
@@ -673,30 +1744,207 @@ func (e *Exporter) collectLocationMetrics(ch chan<- prometheus.Metric, modem mod
 		return
 	}
 
-	// Check if location is enabled
-	if signalsLocation, err := location.GetSignalsLocation(); err == nil {
-		enabledValue := 0.0
-		if signalsLocation {
-			enabledValue = 1.0
-		}
-		ch <- prometheus.MustNewConstMetric(e.locationEnabled, prometheus.GaugeValue, enabledValue, deviceID)
-
-		// Get location data if enabled
-		if signalsLocation {
-			if loc, err := location.GetLocation(); err == nil {
-				// Export GPS location if available
-				if loc.GpsRaw.Latitude != 0 || loc.GpsRaw.Longitude != 0 {
-					ch <- prometheus.MustNewConstMetric(e.locationLatitude, prometheus.GaugeValue, loc.GpsRaw.Latitude, deviceID)
-					ch <- prometheus.MustNewConstMetric(e.locationLongitude, prometheus.GaugeValue, loc.GpsRaw.Longitude, deviceID)
-					if loc.GpsRaw.Altitude != 0 {
-						ch <- prometheus.MustNewConstMetric(e.locationAltitude, prometheus.GaugeValue, loc.GpsRaw.Altitude, deviceID)
-					}
-				}
+	// Enabled location sources
+	sources, err := location.GetEnabledLocationSources()
+	if err != nil {
+		return
+	}
+	enabledValue := 0.0
+	if len(sources) > 0 {
+		enabledValue = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(e.locationEnabled, prometheus.GaugeValue, enabledValue, deviceID)
+	for _, source := range sources {
+		ch <- prometheus.MustNewConstMetric(e.locationSourceEnabled, prometheus.GaugeValue, 1.0, deviceID, locationSourceToString(source))
+	}
+	if len(sources) == 0 {
+		e.observeCollected(deviceID, "location")
+		return
+	}
+
+	// The Location property is only kept up to date while location is
+	// signalled, otherwise ask for the current location explicitly
+	signalsLocation, _ := location.GetSignalsLocation()
+	var loc modemmanager.CurrentLocation
+	if signalsLocation {
+		loc, err = location.GetLocation()
+	} else {
+		loc, err = location.GetCurrentLocation()
+	}
+	if err != nil {
+		return
+	}
+
+	// Export GPS location if available, as precise as configured
+	hasPosition := loc.GpsRaw.Latitude != 0 || loc.GpsRaw.Longitude != 0
+	if hasPosition && e.locationPrecision != PresenceOnlyLocation {
+		latitude, longitude := loc.GpsRaw.Latitude, loc.GpsRaw.Longitude
+		if e.locationPrecision == RoundedLocation {
+			latitude = truncateCoordinate(latitude, e.locationDecimals)
+			longitude = truncateCoordinate(longitude, e.locationDecimals)
+		}
+		ch <- prometheus.MustNewConstMetric(e.locationLatitude, prometheus.GaugeValue, latitude, deviceID)
+		ch <- prometheus.MustNewConstMetric(e.locationLongitude, prometheus.GaugeValue, longitude, deviceID)
+		if loc.GpsRaw.Altitude != 0 {
+			ch <- prometheus.MustNewConstMetric(e.locationAltitude, prometheus.GaugeValue, loc.GpsRaw.Altitude, deviceID)
+		}
+	}
+
+	// Export GPS fix details from the NMEA trace if available
+	hasFix := e.collectGpsFix(ch, loc.GpsNmea.NmeaSentences, deviceID)
+	fixValue := 0.0
+	if hasPosition || hasFix {
+		fixValue = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(e.locationFix, prometheus.GaugeValue, fixValue, deviceID)
+
+	// Export 3GPP serving cell if available
+	e.collect3GPPLocation(ch, loc.ThreeGppLacCi, deviceID)
+	e.observeCollected(deviceID, "location")
+}
+
+// collectGpsFix exports the fix details of an NMEA trace and reports
+// whether the trace holds a valid fix.
+func (e *Exporter) collectGpsFix(ch chan<- prometheus.Metric, sentences []string, deviceID string) bool {
+	if len(sentences) == 0 {
+		return false
+	}
+
+	fix := parseNMEA(sentences)
+	if fix.hasSpeed {
+		ch <- prometheus.MustNewConstMetric(e.locationGpsSpeed, prometheus.GaugeValue, fix.speedKmh, deviceID)
+	}
+	if fix.hasHeading {
+		ch <- prometheus.MustNewConstMetric(e.locationGpsHeading, prometheus.GaugeValue, fix.headingDegrees, deviceID)
+	}
+	if fix.hasSatellitesUsed {
+		ch <- prometheus.MustNewConstMetric(e.locationGpsSatUsed, prometheus.GaugeValue, float64(fix.satellitesUsed), deviceID)
+	}
+	if fix.hasUtcTime {
+		ch <- prometheus.MustNewConstMetric(e.locationGpsUtcTime, prometheus.GaugeValue, float64(fix.utcTime.UnixNano())/1e9, deviceID)
+	}
+	if fix.hasHdop {
+		ch <- prometheus.MustNewConstMetric(e.locationGpsHdop, prometheus.GaugeValue, fix.hdop, deviceID)
+	}
+	return fix.valid
+}
+
+func (e *Exporter) collect3GPPLocation(ch chan<- prometheus.Metric, cell modemmanager.ThreeGppLacCiLocation, deviceID string) {
+	if cell.Mcc == "" && cell.Ci == "" {
+		return
+	}
+
+	lac := normalizeHex(cell.Lac)
+	tac := normalizeHex(cell.Tac)
+	cid := normalizeHex(cell.Ci)
+
+	ch <- prometheus.MustNewConstMetric(
+		e.location3gppInfo,
+		prometheus.GaugeValue,
+		1.0,
+		deviceID, cell.Mcc, cell.Mnc, lac, tac, cid,
+	)
+
+	if cellID, err := parseHex(cid); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.location3gppCellID, prometheus.GaugeValue, float64(cellID), deviceID)
+	}
+}
+
+func (e *Exporter) collectFirmwareMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	firmware, err := modem.GetFirmware()
+	if err != nil {
+		return
+	}
+
+	if images, err := firmware.List(); err == nil {
+		for _, image := range images {
+			ch <- prometheus.MustNewConstMetric(
+				e.firmwareInfo,
+				prometheus.GaugeValue,
+				1.0,
+				deviceID, strconv.FormatBool(image.Selected), image.UniqueId, firmwareImageTypeToString(image.ImageType),
+			)
+		}
+		e.observeCollected(deviceID, "firmware")
+	}
+
+	if settings, err := firmware.GetUpdateSettings(); err == nil {
+		for _, method := range settings.UpdateMethods {
+			if method == modemmanager.MmModemFirmwareUpdateMethodNone {
+				continue
 			}
+			ch <- prometheus.MustNewConstMetric(e.firmwareUpdateMethod, prometheus.GaugeValue, 1.0, deviceID, firmwareUpdateMethodToString(method))
 		}
 	}
 }
 
+func (e *Exporter) collectTimeMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	modemTime, err := modem.GetTime()
+	if err != nil {
+		return
+	}
+
+	// Compare against the local clock halfway through the D-Bus round trip
+	before := e.clock.Now()
+	networkTime, err := modemTime.GetNetworkTime()
+	after := e.clock.Now()
+	if err == nil {
+		local := before.Add(after.Sub(before) / 2)
+		ch <- prometheus.MustNewConstMetric(e.timeNetworkOffset, prometheus.GaugeValue, networkTime.Sub(local).Seconds(), deviceID)
+		e.observeCollected(deviceID, "time")
+	}
+
+	if timezone, err := modemTime.GetNetworkTimezone(); err == nil {
+		ch <- prometheus.MustNewConstMetric(e.timeTimezoneOffset, prometheus.GaugeValue, float64(timezone.Offset), deviceID)
+	}
+}
+
+func (e *Exporter) collectVoiceMetrics(ch chan<- prometheus.Metric, modem modemmanager.Modem, deviceID string) {
+	voice, err := modem.GetVoice()
+	if err != nil {
+		return
+	}
+
+	if emergencyOnly, err := voice.GetEmergencyOnly(); err == nil {
+		emergencyValue := 0.0
+		if emergencyOnly {
+			emergencyValue = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(e.voiceEmergencyOnly, prometheus.GaugeValue, emergencyValue, deviceID)
+	}
+
+	// The Calls property is cheap to read and usually empty
+	calls, err := voice.GetCalls()
+	if err != nil {
+		return
+	}
+	type callKey struct{ state, direction string }
+	counts := make(map[callKey]int)
+	for _, call := range calls {
+		state, err := call.GetState()
+		if err != nil {
+			continue
+		}
+		direction, _ := call.GetDirection()
+		counts[callKey{callStateToString(state), callDirectionToString(direction)}]++
+	}
+
+	keys := make([]callKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].state != keys[j].state {
+			return keys[i].state < keys[j].state
+		}
+		return keys[i].direction < keys[j].direction
+	})
+	for _, key := range keys {
+		ch <- prometheus.MustNewConstMetric(e.voiceCalls, prometheus.GaugeValue, float64(counts[key]), deviceID, key.state, key.direction)
+	}
+	e.observeCollected(deviceID, "voice")
+}
+
 // Helper functions to convert enums to strings
 func stateToString(state modemmanager.MMModemState) string {
 	switch state {
@@ -731,6 +1979,132 @@ func stateToString(state modemmanager.MMModemState) string {
 	}
 }
 
+func stateChangeReasonToString(reason modemmanager.MMModemStateChangeReason) string {
+	switch reason {
+	case modemmanager.MmModemStateChangeReasonUnknown:
+		return "unknown"
+	case modemmanager.MmModemStateChangeReasonUserRequested:
+		return "user_requested"
+	case modemmanager.MmModemStateChangeReasonSuspend:
+		return "suspend"
+	case modemmanager.MmModemStateChangeReasonFailure:
+		return "failure"
+	default:
+		return "unknown"
+	}
+}
+
+func firmwareImageTypeToString(imageType modemmanager.MMFirmwareImageType) string {
+	switch imageType {
+	case modemmanager.MmFirmwareImageTypeGeneric:
+		return "generic"
+	case modemmanager.MmFirmwareImageTypeGobi:
+		return "gobi"
+	default:
+		return "unknown"
+	}
+}
+
+func firmwareUpdateMethodToString(method modemmanager.MMModemFirmwareUpdateMethod) string {
+	switch method {
+	case modemmanager.MmModemFirmwareUpdateMethodNone:
+		return "none"
+	case modemmanager.MmModemFirmwareUpdateMethodFastboot:
+		return "fastboot"
+	case modemmanager.MmModemFirmwareUpdateMethodQmiPdc:
+		return "qmi_pdc"
+	default:
+		return "unknown"
+	}
+}
+
+func callStateToString(state modemmanager.MMCallState) string {
+	switch state {
+	case modemmanager.MmCallStateDialing:
+		return "dialing"
+	case modemmanager.MmCallStateRingingOut:
+		return "ringing-out"
+	case modemmanager.MmCallStateRingingIn:
+		return "ringing-in"
+	case modemmanager.MmCallStateActive:
+		return "active"
+	case modemmanager.MmCallStateHeld:
+		return "held"
+	case modemmanager.MmCallStateWaiting:
+		return "waiting"
+	case modemmanager.MmCallStateTerminated:
+		return "terminated"
+	default:
+		return "unknown"
+	}
+}
+
+func callDirectionToString(direction modemmanager.MMCallDirection) string {
+	switch direction {
+	case modemmanager.MmCallDirectionIncoming:
+		return "incoming"
+	case modemmanager.MmCallDirectionOutgoing:
+		return "outgoing"
+	default:
+		return "unknown"
+	}
+}
+
+func epsUeModeToString(mode modemmanager.MMModem3gppEpsUeModeOperation) string {
+	switch mode {
+	case modemmanager.MmModem3gppEpsUeModeOperationPs1:
+		return "ps1"
+	case modemmanager.MmModem3gppEpsUeModeOperationPs2:
+		return "ps2"
+	case modemmanager.MmModem3gppEpsUeModeOperationCsps1:
+		return "csps1"
+	case modemmanager.MmModem3gppEpsUeModeOperationCsps2:
+		return "csps2"
+	default:
+		return "unknown"
+	}
+}
+
+func ussdSessionStateToString(state modemmanager.MMModem3gppUssdSessionState) string {
+	switch state {
+	case modemmanager.MmModem3gppUssdSessionStateIdle:
+		return "idle"
+	case modemmanager.MmModem3gppUssdSessionStateActive:
+		return "active"
+	case modemmanager.MmModem3gppUssdSessionStateUserResponse:
+		return "user-response"
+	default:
+		return "unknown"
+	}
+}
+
+func facilityToString(facility modemmanager.MMModem3gppFacility) string {
+	switch facility {
+	case modemmanager.MmModem3gppFacilitySim:
+		return "sim"
+	case modemmanager.MmModem3gppFacilityFixedDialing:
+		return "fixed-dialing"
+	case modemmanager.MmModem3gppFacilityPhSim:
+		return "ph-sim"
+	case modemmanager.MmModem3gppFacilityPhFsim:
+		return "ph-fsim"
+	case modemmanager.MmModem3gppFacilityNetPers:
+		return "net-pers"
+	case modemmanager.MmModem3gppFacilityNetSubPers:
+		return "net-sub-pers"
+	case modemmanager.MmModem3gppFacilityProviderPers:
+		return "provider-pers"
+	case modemmanager.MmModem3gppFacilityCorpPers:
+		return "corp-pers"
+	default:
+		return "unknown"
+	}
+}
+
+func bandToString(band modemmanager.MMModemBand) string {
+	return strings.TrimPrefix(band.String(), "MmModemBand")
+}
+
 func powerStateToString(state modemmanager.MMModemPowerState) string {
 	switch state {
 	case modemmanager.MmModemPowerStateUnknown:
@@ -746,23 +2120,215 @@ func powerStateToString(state modemmanager.MMModemPowerState) string {
 	}
 }
 
+func failedReasonToString(reason modemmanager.MMModemStateFailedReason) string {
+	switch reason {
+	case modemmanager.MmModemStateFailedReasonNone:
+		return "none"
+	case modemmanager.MmModemStateFailedReasonUnknown:
+		return "unknown"
+	case modemmanager.MmModemStateFailedReasonSimMissing:
+		return "sim_missing"
+	case modemmanager.MmModemStateFailedReasonSimError:
+		return "sim_error"
+	case modemmanager.MmModemStateFailedReasonUnknownCapabilities:
+		return "unknown_capabilities"
+	case modemmanager.MmModemStateFailedReasonEsimWithoutProfiles:
+		return "esim_without_profiles"
+	default:
+		return "unknown"
+	}
+}
+
+func ipFamilyToString(family modemmanager.MMBearerIpFamily) string {
+	switch family {
+	case modemmanager.MmBearerIpFamilyIpv4:
+		return "ipv4"
+	case modemmanager.MmBearerIpFamilyIpv6:
+		return "ipv6"
+	case modemmanager.MmBearerIpFamilyIpv4v6:
+		return "ipv4v6"
+	case modemmanager.MmBearerIpFamilyNonIp:
+		return "non-ip"
+	case modemmanager.MmBearerIpFamilyAny:
+		return "any"
+	default:
+		return "unknown"
+	}
+}
+
+func ipMethodToString(method modemmanager.MMBearerIpMethod) string {
+	switch method {
+	case modemmanager.MmBearerIpMethodPpp:
+		return "ppp"
+	case modemmanager.MmBearerIpMethodStatic:
+		return "static"
+	case modemmanager.MmBearerIpMethodDhcp:
+		return "dhcp"
+	default:
+		return "unknown"
+	}
+}
+
+// accessTechNames names the access technologies, the most advanced of each
+// family first
+var accessTechNames = []struct {
+	tech modemmanager.MMModemAccessTechnology
+	name string
+}{
+	{modemmanager.MmModemAccessTechnologyLte, "lte"},
+	{modemmanager.MmModemAccessTechnologyHspaPlus, "hspa_plus"},
+	{modemmanager.MmModemAccessTechnologyHspa, "hspa"},
+	{modemmanager.MmModemAccessTechnologyHsupa, "hsupa"},
+	{modemmanager.MmModemAccessTechnologyHsdpa, "hsdpa"},
+	{modemmanager.MmModemAccessTechnologyUmts, "umts"},
+	{modemmanager.MmModemAccessTechnologyEdge, "edge"},
+	{modemmanager.MmModemAccessTechnologyGprs, "gprs"},
+	{modemmanager.MmModemAccessTechnologyGsm, "gsm"},
+	{modemmanager.MmModemAccessTechnologyGsmCompact, "gsm_compact"},
+	{modemmanager.MmModemAccessTechnologyEvdob, "evdob"},
+	{modemmanager.MmModemAccessTechnologyEvdoa, "evdoa"},
+	{modemmanager.MmModemAccessTechnologyEvdo0, "evdo0"},
+	{modemmanager.MmModemAccessTechnology1xrtt, "1xrtt"},
+	{modemmanager.MmModemAccessTechnologyPots, "pots"},
+}
+
+// accessTechToString returns the name of the most advanced technology in a
+// bitmask of access technologies.
 func accessTechToString(tech modemmanager.MMModemAccessTechnology) string {
-	// This is a simplified version - you might want to handle multiple technologies
-	switch {
-	case tech&modemmanager.MmModemAccessTechnologyLte != 0:
-		return "lte"
-	case tech&modemmanager.MmModemAccessTechnologyHspaPlus != 0:
-		return "hspa_plus"
-	case tech&modemmanager.MmModemAccessTechnologyHspa != 0:
-		return "hspa"
-	case tech&modemmanager.MmModemAccessTechnologyUmts != 0:
-		return "umts"
-	case tech&modemmanager.MmModemAccessTechnologyEdge != 0:
-		return "edge"
-	case tech&modemmanager.MmModemAccessTechnologyGprs != 0:
-		return "gprs"
-	case tech&modemmanager.MmModemAccessTechnologyGsm != 0:
-		return "gsm"
+	for _, t := range accessTechNames {
+		if tech&t.tech != 0 {
+			return t.name
+		}
+	}
+	return "unknown"
+}
+
+func smsStorageToString(storage modemmanager.MMSmsStorage) string {
+	switch storage {
+	case modemmanager.MmSmsStorageSm:
+		return "sm"
+	case modemmanager.MmSmsStorageMe:
+		return "me"
+	case modemmanager.MmSmsStorageMt:
+		return "mt"
+	case modemmanager.MmSmsStorageSr:
+		return "sr"
+	case modemmanager.MmSmsStorageBm:
+		return "bm"
+	case modemmanager.MmSmsStorageTa:
+		return "ta"
+	default:
+		return "unknown"
+	}
+}
+
+func locationSourceToString(source modemmanager.MMModemLocationSource) string {
+	switch source {
+	case modemmanager.MmModemLocationSource3gppLacCi:
+		return "3gpp-lac-ci"
+	case modemmanager.MmModemLocationSourceGpsRaw:
+		return "gps-raw"
+	case modemmanager.MmModemLocationSourceGpsNmea:
+		return "gps-nmea"
+	case modemmanager.MmModemLocationSourceCdmaBs:
+		return "cdma-bs"
+	case modemmanager.MmModemLocationSourceGpsUnmanaged:
+		return "gps-unmanaged"
+	case modemmanager.MmModemLocationSourceAgpsMsa:
+		return "agps-msa"
+	case modemmanager.MmModemLocationSourceAgpsMsb:
+		return "agps-msb"
+	default:
+		return "unknown"
+	}
+}
+
+func cdmaRegistrationStateToString(state modemmanager.MMModemCdmaRegistrationState) string {
+	switch state {
+	case modemmanager.MmModemCdmaRegistrationStateRegistered:
+		return "registered"
+	case modemmanager.MmModemCdmaRegistrationStateHome:
+		return "home"
+	case modemmanager.MmModemCdmaRegistrationStateRoaming:
+		return "roaming"
+	default:
+		return "unknown"
+	}
+}
+
+func cdmaActivationStateToString(state modemmanager.MMModemCdmaActivationState) string {
+	switch state {
+	case modemmanager.MmModemCdmaActivationStateNotActivated:
+		return "not_activated"
+	case modemmanager.MmModemCdmaActivationStateActivating:
+		return "activating"
+	case modemmanager.MmModemCdmaActivationStatePartiallyActivated:
+		return "partially_activated"
+	case modemmanager.MmModemCdmaActivationStateActivated:
+		return "activated"
+	default:
+		return "unknown"
+	}
+}
+
+func omaFeatureToString(feature modemmanager.MMOmaFeature) string {
+	switch feature {
+	case modemmanager.MmOmaFeatureDeviceProvisioning:
+		return "device_provisioning"
+	case modemmanager.MmOmaFeaturePrlUpdate:
+		return "prl_update"
+	case modemmanager.MmOmaFeatureHandsFreeActivation:
+		return "hands_free_activation"
+	default:
+		return "unknown"
+	}
+}
+
+func omaSessionStateToString(state modemmanager.MMOmaSessionState) string {
+	switch state {
+	case modemmanager.MmOmaSessionStateFailed:
+		return "failed"
+	case modemmanager.MmOmaSessionStateStarted:
+		return "started"
+	case modemmanager.MmOmaSessionStateRetrying:
+		return "retrying"
+	case modemmanager.MmOmaSessionStateConnecting:
+		return "connecting"
+	case modemmanager.MmOmaSessionStateConnected:
+		return "connected"
+	case modemmanager.MmOmaSessionStateAuthenticated:
+		return "authenticated"
+	case modemmanager.MmOmaSessionStateMdnDownloaded:
+		return "mdn_downloaded"
+	case modemmanager.MmOmaSessionStateMsidDownloaded:
+		return "msid_downloaded"
+	case modemmanager.MmOmaSessionStatePrlDownloaded:
+		return "prl_downloaded"
+	case modemmanager.MmOmaSessionStateMipProfileDownloaded:
+		return "mip_profile_downloaded"
+	case modemmanager.MmOmaSessionStateCompleted:
+		return "completed"
+	default:
+		return "unknown"
+	}
+}
+
+func omaSessionTypeToString(sessionType modemmanager.MMOmaSessionType) string {
+	switch sessionType {
+	case modemmanager.MmOmaSessionTypeClientInitiatedDeviceConfigure:
+		return "client_initiated_device_configure"
+	case modemmanager.MmOmaSessionTypeClientInitiatedPrlUpdate:
+		return "client_initiated_prl_update"
+	case modemmanager.MmOmaSessionTypeClientInitiatedHandsFreeActivation:
+		return "client_initiated_hands_free_activation"
+	case modemmanager.MmOmaSessionTypeNetworkInitiatedDeviceConfigure:
+		return "network_initiated_device_configure"
+	case modemmanager.MmOmaSessionTypeNetworkInitiatedPrlUpdate:
+		return "network_initiated_prl_update"
+	case modemmanager.MmOmaSessionTypeDeviceInitiatedPrlUpdate:
+		return "device_initiated_prl_update"
+	case modemmanager.MmOmaSessionTypeDeviceInitiatedHandsFreeActivation:
+		return "device_initiated_hands_free_activation"
 	default:
 		return "unknown"
 	}
@@ -782,7 +2348,56 @@ func registrationStateToString(state modemmanager.MMModem3gppRegistrationState)
 		return "unknown"
 	case modemmanager.MmModem3gppRegistrationStateRoaming:
 		return "roaming"
+	case modemmanager.MmModem3gppRegistrationStateHomeSmsOnly:
+		return "home_sms_only"
+	case modemmanager.MmModem3gppRegistrationStateRoamingSmsOnly:
+		return "roaming_sms_only"
+	case modemmanager.MmModem3gppRegistrationStateEmergencyOnly:
+		return "emergency_only"
+	case modemmanager.MmModem3gppRegistrationStateHomeCsfbNotPreferred:
+		return "home_csfb_not_preferred"
+	case modemmanager.MmModem3gppRegistrationStateRoamingCsfbNotPreferred:
+		return "roaming_csfb_not_preferred"
 	default:
 		return "unknown"
 	}
 }
+
+// registrationStateRoaming reports whether the given registration state is a
+// roaming variant, and whether the modem is registered at all. Emergency-only
+// attachment is not considered a registration.
+func registrationStateRoaming(state modemmanager.MMModem3gppRegistrationState) (roaming bool, registered bool) {
+	switch state {
+	case modemmanager.MmModem3gppRegistrationStateHome,
+		modemmanager.MmModem3gppRegistrationStateHomeSmsOnly,
+		modemmanager.MmModem3gppRegistrationStateHomeCsfbNotPreferred:
+		return false, true
+	case modemmanager.MmModem3gppRegistrationStateRoaming,
+		modemmanager.MmModem3gppRegistrationStateRoamingSmsOnly,
+		modemmanager.MmModem3gppRegistrationStateRoamingCsfbNotPreferred:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// normalizeHex returns a hexadecimal identifier as reported by ModemManager in
+// canonical form: upper-case, without a 0x prefix and without leading zeros.
+// An empty or all-zero value yields an empty string.
+func normalizeHex(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 1 && value[0] == '0' && (value[1] == 'x' || value[1] == 'X') {
+		value = value[2:]
+	}
+	return strings.ToUpper(strings.TrimLeft(value, "0"))
+}
+
+// parseHex parses a hexadecimal LAC, TAC or cell identifier. NR cell
+// identities take 36 bits.
+func parseHex(value string) (uint64, error) {
+	value = normalizeHex(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty hexadecimal value")
+	}
+	return strconv.ParseUint(value, 16, 64)
+}
@@ -0,0 +1,204 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRegistrationStateRoaming(t *testing.T) {
+	tests := []struct {
+		state      modemmanager.MMModem3gppRegistrationState
+		roaming    bool
+		registered bool
+	}{
+		{modemmanager.MmModem3gppRegistrationStateIdle, false, false},
+		{modemmanager.MmModem3gppRegistrationStateHome, false, true},
+		{modemmanager.MmModem3gppRegistrationStateSearching, false, false},
+		{modemmanager.MmModem3gppRegistrationStateDenied, false, false},
+		{modemmanager.MmModem3gppRegistrationStateUnknown, false, false},
+		{modemmanager.MmModem3gppRegistrationStateRoaming, true, true},
+		{modemmanager.MmModem3gppRegistrationStateHomeSmsOnly, false, true},
+		{modemmanager.MmModem3gppRegistrationStateRoamingSmsOnly, true, true},
+		{modemmanager.MmModem3gppRegistrationStateEmergencyOnly, false, false},
+		{modemmanager.MmModem3gppRegistrationStateHomeCsfbNotPreferred, false, true},
+		{modemmanager.MmModem3gppRegistrationStateRoamingCsfbNotPreferred, true, true},
+		{modemmanager.MMModem3gppRegistrationState(99), false, false},
+	}
+
+	for _, tt := range tests {
+		roaming, registered := registrationStateRoaming(tt.state)
+		if roaming != tt.roaming || registered != tt.registered {
+			t.Errorf("registrationStateRoaming(%v) = (%v, %v), want (%v, %v)",
+				tt.state, roaming, registered, tt.roaming, tt.registered)
+		}
+	}
+}
+
+func TestRegistrationStateToString(t *testing.T) {
+	for state := modemmanager.MmModem3gppRegistrationStateIdle; state <= modemmanager.MmModem3gppRegistrationStateRoamingCsfbNotPreferred; state++ {
+		if state != modemmanager.MmModem3gppRegistrationStateUnknown && registrationStateToString(state) == "unknown" {
+			t.Errorf("registrationStateToString(%v) has no mapping", state)
+		}
+	}
+}
+
+func TestFacilityToString(t *testing.T) {
+	want := []string{"sim", "fixed-dialing", "ph-sim", "ph-fsim", "net-pers", "net-sub-pers", "provider-pers", "corp-pers"}
+	for bit, name := range want {
+		if got := facilityToString(modemmanager.MMModem3gppFacility(1 << bit)); got != name {
+			t.Errorf("facilityToString(1 << %d) = %q, want %q", bit, got, name)
+		}
+	}
+}
+
+func TestFacilityLockMetrics(t *testing.T) {
+	modem := &fakeModem{deviceID: "dev", modem3gpp: &fake3gpp{locks: []modemmanager.MMModem3gppFacility{
+		modemmanager.MmModem3gppFacilitySim, modemmanager.MmModem3gppFacilityNetPers,
+	}}}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}})
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collect3GPPMetrics(ch, modem, "dev")
+	})
+
+	locks := make(map[string]float64)
+	for _, m := range metrics {
+		if m.name == "modemmanager_modem_3gpp_facility_lock" {
+			locks[m.labels["facility"]] = m.value
+		}
+	}
+	if len(locks) != 8 {
+		t.Errorf("facility_lock emitted for %d facilities, want 8", len(locks))
+	}
+	for facility, value := range locks {
+		want := 0.0
+		if facility == "sim" || facility == "net-pers" {
+			want = 1.0
+		}
+		if value != want {
+			t.Errorf("facility_lock{facility=%q} = %v, want %v", facility, value, want)
+		}
+	}
+}
+
+func TestAccessTechToString(t *testing.T) {
+	tests := map[modemmanager.MMModemAccessTechnology]string{
+		modemmanager.MmModemAccessTechnologyUnknown:                                            "unknown",
+		modemmanager.MmModemAccessTechnologyLte:                                                "lte",
+		modemmanager.MmModemAccessTechnologyUmts | modemmanager.MmModemAccessTechnologyHsdpa:   "hsdpa",
+		modemmanager.MmModemAccessTechnologyGsm | modemmanager.MmModemAccessTechnologyEdge:     "edge",
+		modemmanager.MmModemAccessTechnology1xrtt | modemmanager.MmModemAccessTechnologyEvdoa:  "evdoa",
+		modemmanager.MmModemAccessTechnologyGsmCompact:                                         "gsm_compact",
+		modemmanager.MmModemAccessTechnologyLte | modemmanager.MmModemAccessTechnologyHspaPlus: "lte",
+	}
+	for tech, want := range tests {
+		if got := accessTechToString(tech); got != want {
+			t.Errorf("accessTechToString(%#x) = %q, want %q", uint32(tech), got, want)
+		}
+	}
+
+	// Every technology of the library has a name
+	var tech modemmanager.MMModemAccessTechnology
+	for _, tech := range tech.GetAllTechnologies() {
+		if accessTechToString(tech) == "unknown" {
+			t.Errorf("no name for technology %#x", uint32(tech))
+		}
+	}
+}
+
+func TestAccessTechnologyActive(t *testing.T) {
+	modem := &fakeModem{deviceID: "dev", accessTechs: []modemmanager.MMModemAccessTechnology{
+		modemmanager.MmModemAccessTechnologyUmts | modemmanager.MmModemAccessTechnologyHsdpa,
+	}}
+	active := func(e *Exporter) map[string]float64 {
+		metrics := gather(t, func(ch chan<- prometheus.Metric) {
+			e.collectModemState(ch, modem, "dev")
+		})
+		series := make(map[string]float64)
+		for _, m := range metrics {
+			if m.name == "modemmanager_modem_access_technology_active" {
+				series[m.labels["technology"]] = m.value
+			}
+		}
+		return series
+	}
+
+	series := active(NewExporter(&fakeSource{}))
+	if len(series) != len(accessTechNames) {
+		t.Errorf("access_technology_active emitted for %d technologies, want %d", len(series), len(accessTechNames))
+	}
+	for tech, value := range series {
+		want := 0.0
+		if tech == "umts" || tech == "hsdpa" {
+			want = 1.0
+		}
+		if value != want {
+			t.Errorf("access_technology_active{technology=%q} = %v, want %v", tech, value, want)
+		}
+	}
+
+	if series := active(NewExporter(&fakeSource{}, WithAccessTechnologySeries(false))); len(series) != 0 {
+		t.Errorf("access_technology_active emitted with the option disabled: %v", series)
+	}
+}
+
+func TestCollectMockModemManager(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(NewExporter(ModemManagerSource{Manager: mocks.NewMockModemManager()}))
+
+	expected := `
+# HELP modemmanager_info ModemManager daemon version information
+# TYPE modemmanager_info gauge
+modemmanager_info{version="1.12.8-mock"} 1
+# HELP modemmanager_modem_signal_quality_percent Signal quality as a percentage (0-100)
+# TYPE modemmanager_modem_signal_quality_percent gauge
+modemmanager_modem_signal_quality_percent{device_id="mock-0000"} 75
+# HELP modemmanager_modem_state Current modem state (enumeration)
+# TYPE modemmanager_modem_state gauge
+modemmanager_modem_state{device_id="mock-0000",state="registered"} 1
+# HELP modemmanager_scrape_success Whether the scrape was successful (1 = yes, 0 = no)
+# TYPE modemmanager_scrape_success gauge
+modemmanager_scrape_success 1
+# HELP modemmanager_up Whether ModemManager answered on D-Bus (1 = yes, 0 = no)
+# TYPE modemmanager_up gauge
+modemmanager_up 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"modemmanager_info",
+		"modemmanager_modem_signal_quality_percent",
+		"modemmanager_modem_state",
+		"modemmanager_scrape_success",
+		"modemmanager_up",
+	); err != nil {
+		t.Error(err)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	present := make(map[string]bool)
+	for _, family := range families {
+		present[family.GetName()] = true
+	}
+	for _, name := range []string{
+		"modemmanager_modem_info",
+		"modemmanager_modem_access_technology",
+		"modemmanager_modem_power_state",
+		"modemmanager_modem_3gpp_registration_state",
+		"modemmanager_modem_3gpp_operator_code",
+		"modemmanager_sim_info",
+		"modemmanager_bearer_info",
+		"modemmanager_bearer_connected",
+		"modemmanager_scrape_duration_seconds",
+		"modemmanager_scrape_errors_total",
+	} {
+		if !present[name] {
+			t.Errorf("metric family %s missing from scrape", name)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package exporter
+
+import "context"
+
+// CheckHealth asks ModemManager for its version, returning ctx.Err() if it
+// does not answer before ctx is done. It does not reconnect like Collect.
+func (e *Exporter) CheckHealth(ctx context.Context) error {
+	_, err := withContext(ctx, e.modemSource().Version)
+	return err
+}
+
+// Ready reports whether a scrape since the exporter was created reached
+// ModemManager and listed the modems. Errors of single modems do not count.
+func (e *Exporter) Ready() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.scraped
+}
+
+// withContext returns the result of call, or ctx.Err() if ctx is done first.
+// ModemManager calls do not take a context, so call then finishes in the
+// background.
+func withContext[T any](ctx context.Context, call func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+func TestCheckHealthAndReady(t *testing.T) {
+	manager := mocks.NewMockModemManager()
+	manager.GetVersionError = errors.New("dbus: connection closed")
+	e := NewExporter(ModemManagerSource{Manager: manager})
+
+	if err := e.CheckHealth(context.Background()); err == nil {
+		t.Error("CheckHealth() = nil while ModemManager fails")
+	}
+	gather(t, e.Collect)
+	if e.Ready() {
+		t.Error("Ready() after a failed scrape")
+	}
+
+	manager.GetVersionError = nil
+	if err := e.CheckHealth(context.Background()); err != nil {
+		t.Errorf("CheckHealth() = %v, want nil", err)
+	}
+	if e.Ready() {
+		t.Error("Ready() before a successful scrape")
+	}
+	gather(t, e.Collect)
+	if !e.Ready() {
+		t.Error("not Ready() after a successful scrape")
+	}
+
+	// Ready stays true, CheckHealth follows ModemManager
+	manager.GetVersionError = errors.New("dbus: connection closed")
+	gather(t, e.Collect)
+	if !e.Ready() {
+		t.Error("not Ready() after ModemManager failed again")
+	}
+	if err := e.CheckHealth(context.Background()); err == nil {
+		t.Error("CheckHealth() = nil while ModemManager fails")
+	}
+}
+
+func TestCheckHealthTimeout(t *testing.T) {
+	source := blockingSource{release: make(chan struct{})}
+	defer close(source.release)
+	e := NewExporter(source)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.CheckHealth(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("CheckHealth() = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// ModemIdentifier selects the value of the device_id label of modem metrics.
+type ModemIdentifier string
+
+const (
+	// DeviceIDIdentifier uses the device identifier hash of ModemManager.
+	DeviceIDIdentifier ModemIdentifier = "device-id"
+
+	// IMEIIdentifier uses the IMEI of 3GPP modems.
+	IMEIIdentifier ModemIdentifier = "imei"
+
+	// IMSIIdentifier uses the IMSI of the SIM card, which identifies the
+	// subscription rather than the modem.
+	IMSIIdentifier ModemIdentifier = "imsi"
+
+	// EquipmentIDIdentifier uses the equipment identifier, the IMEI or ESN
+	// depending on the modem.
+	EquipmentIDIdentifier ModemIdentifier = "equipment-id"
+)
+
+// ParseModemIdentifier parses "device-id", "imei", "imsi" or "equipment-id".
+func ParseModemIdentifier(s string) (ModemIdentifier, error) {
+	switch id := ModemIdentifier(s); id {
+	case DeviceIDIdentifier, IMEIIdentifier, IMSIIdentifier, EquipmentIDIdentifier:
+		return id, nil
+	default:
+		return "", fmt.Errorf("unknown modem identifier %q, expected device-id, imei, imsi or equipment-id", s)
+	}
+}
+
+// WithModemIdentifier sets which identifier populates the device_id label.
+// Modems whose chosen identifier cannot be read, such as the IMSI behind a
+// locked SIM, are labelled with their device identifier instead. In
+// EventCollection mode the label is resolved once, when Start begins watching
+// the modem.
+func WithModemIdentifier(id ModemIdentifier) Option {
+	return func(e *Exporter) {
+		e.modemIdentifier = id
+	}
+}
+
+// modemLabel returns the device_id label value of a modem. It fails only if
+// the device identifier, used as fallback, cannot be read.
+func (e *Exporter) modemLabel(modem modemmanager.Modem) (string, error) {
+	deviceID, err := modem.GetDeviceIdentifier()
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	switch e.modemIdentifier {
+	case IMEIIdentifier:
+		id = modemIMEI(modem)
+	case IMSIIdentifier:
+		id = modemIMSI(modem)
+	case EquipmentIDIdentifier:
+		id, _ = modem.GetEquipmentIdentifier()
+	}
+	if id == "" {
+		return deviceID, nil
+	}
+	return id, nil
+}
+
+// modemIMEI returns the IMEI of a modem, or "" if it has none or it cannot
+// be read.
+func modemIMEI(modem modemmanager.Modem) string {
+	modem3gpp, err := modem.Get3gpp()
+	if err != nil {
+		return ""
+	}
+	imei, _ := modem3gpp.GetImei()
+	return imei
+}
+
+// modemIMSI returns the IMSI of the SIM in a modem, or "" if there is no SIM
+// or the IMSI cannot be read, as with a locked SIM.
+func modemIMSI(modem modemmanager.Modem) string {
+	sim, err := modem.GetSim()
+	if err != nil || sim.GetObjectPath() == "/" {
+		return ""
+	}
+	imsi, _ := sim.GetImsi()
+	return imsi
+}
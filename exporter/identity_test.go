@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+func TestModemIdentifierLabel(t *testing.T) {
+	identified := func() *fakeModem {
+		return &fakeModem{
+			deviceID:  "hash",
+			sim:       &fakeSim{imsi: "310260123456789"},
+			modem3gpp: &fake3gpp{imei: "490154203237518"},
+		}
+	}
+	locked := identified()
+	locked.sim = &fakeSim{imsiErr: errors.New("SIM PIN required")}
+	noSim := identified()
+	noSim.sim = nil
+	cdmaOnly := identified()
+	cdmaOnly.modem3gpp = nil
+
+	tests := []struct {
+		name       string
+		identifier ModemIdentifier
+		modem      *fakeModem
+		want       string
+	}{
+		{"device id", DeviceIDIdentifier, identified(), "hash"},
+		{"imei", IMEIIdentifier, identified(), "490154203237518"},
+		{"imsi", IMSIIdentifier, identified(), "310260123456789"},
+		{"equipment id", EquipmentIDIdentifier, identified(), "000000000000000"},
+		{"imsi of locked sim falls back", IMSIIdentifier, locked, "hash"},
+		{"imsi without sim falls back", IMSIIdentifier, noSim, "hash"},
+		{"imei without 3gpp falls back", IMEIIdentifier, cdmaOnly, "hash"},
+	}
+	for _, tt := range tests {
+		e := NewExporter(&fakeSource{modems: []modemmanager.Modem{tt.modem}}, WithModemIdentifier(tt.identifier))
+		info, ok := findMetric(gather(t, e.Collect), "modemmanager_modem_info")
+		if !ok {
+			t.Errorf("%s: modem_info not emitted", tt.name)
+			continue
+		}
+		if got := info.labels["device_id"]; got != tt.want {
+			t.Errorf("%s: device_id = %q, want %q", tt.name, got, tt.want)
+		}
+		if got := info.labels["device_identifier"]; got != "hash" {
+			t.Errorf("%s: device_identifier = %q, want hash", tt.name, got)
+		}
+	}
+}
+
+func TestModemInfoCarriesAllIdentifiers(t *testing.T) {
+	modem := &fakeModem{
+		deviceID:  "hash",
+		sim:       &fakeSim{imsi: "310260123456789"},
+		modem3gpp: &fake3gpp{imei: "490154203237518"},
+	}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}}, WithModemIdentifier(IMSIIdentifier))
+	info, _ := findMetric(gather(t, e.Collect), "modemmanager_modem_info")
+	want := map[string]string{
+		"device_id":         "310260123456789",
+		"device_identifier": "hash",
+		"imei":              "490154203237518",
+		"imsi":              "310260123456789",
+		"equipment_id":      "000000000000000",
+	}
+	for label, value := range want {
+		if got := info.labels[label]; got != value {
+			t.Errorf("%s = %q, want %q", label, got, value)
+		}
+	}
+}
+
+func TestParseModemIdentifier(t *testing.T) {
+	for _, s := range []string{"device-id", "imei", "imsi", "equipment-id"} {
+		if id, err := ParseModemIdentifier(s); err != nil || string(id) != s {
+			t.Errorf("ParseModemIdentifier(%q) = %q, %v", s, id, err)
+		}
+	}
+	if _, err := ParseModemIdentifier("serial"); err == nil {
+		t.Error("ParseModemIdentifier accepted serial")
+	}
+}
@@ -0,0 +1,52 @@
+package exporter
+
+import (
+	"fmt"
+	"math"
+)
+
+// LocationPrecision selects how much of the GPS position is exported.
+type LocationPrecision string
+
+const (
+	// FullLocation exports the coordinates as reported by the modem.
+	FullLocation LocationPrecision = "full"
+
+	// RoundedLocation truncates latitude and longitude to the number of
+	// decimal places passed to WithLocationPrecision.
+	RoundedLocation LocationPrecision = "rounded"
+
+	// PresenceOnlyLocation exports no coordinates, only whether the modem
+	// has a fix and the fix quality.
+	PresenceOnlyLocation LocationPrecision = "presence-only"
+)
+
+// ParseLocationPrecision parses "full", "rounded" or "presence-only".
+func ParseLocationPrecision(s string) (LocationPrecision, error) {
+	switch p := LocationPrecision(s); p {
+	case FullLocation, RoundedLocation, PresenceOnlyLocation:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown location precision %q, expected full, rounded or presence-only", s)
+	}
+}
+
+// WithLocationPrecision limits the exported GPS position, e.g. to keep exact
+// coordinates out of a shared Prometheus. decimals is the number of decimal
+// places kept by RoundedLocation; two places are about 1 km.
+func WithLocationPrecision(precision LocationPrecision, decimals int) Option {
+	return func(e *Exporter) {
+		e.locationPrecision = precision
+		e.locationDecimals = decimals
+	}
+}
+
+// truncateCoordinate cuts a coordinate in degrees to decimals places towards
+// zero, so a rounded position never points further than the real one.
+func truncateCoordinate(degrees float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	// Round away the binary representation error first, 0.29 * 100 is
+	// 28.999999999999996
+	scaled := math.Round(degrees*scale*1e6) / 1e6
+	return math.Trunc(scaled) / scale
+}
@@ -0,0 +1,226 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNormalizeHex(t *testing.T) {
+	tests := map[string]string{
+		"84CD":   "84CD",
+		"84cd":   "84CD",
+		"0x2baf": "2BAF",
+		"00D301": "D301",
+		"0":      "",
+		"":       "",
+	}
+	for in, want := range tests {
+		if got := normalizeHex(in); got != want {
+			t.Errorf("normalizeHex(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseHex(t *testing.T) {
+	tests := map[string]uint64{
+		"D30156":    0xD30156,
+		"0x2baf":    0x2BAF,
+		"00D30156":  0xD30156,
+		"FA2B3C4D5": 0xFA2B3C4D5, // 36-bit NR cell identity
+	}
+	for in, want := range tests {
+		if v, err := parseHex(in); err != nil || v != want {
+			t.Errorf("parseHex(%s) = %d, %v, want %d", in, v, err, want)
+		}
+	}
+	for _, bad := range []string{"", "XYZ", "1FFFFFFFFFFFFFFFF"} {
+		if v, err := parseHex(bad); err == nil {
+			t.Errorf("parseHex(%q) = %d, expected error", bad, v)
+		}
+	}
+}
+
+func TestCollectLocation3GPPWithoutGPS(t *testing.T) {
+	loc := &fakeLocation{
+		sources: []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSource3gppLacCi},
+		signals: false,
+		current: modemmanager.CurrentLocation{
+			ThreeGppLacCi: modemmanager.ThreeGppLacCiLocation{
+				Mcc: "262", Mnc: "01", Lac: "84cd", Ci: "00D30156", Tac: "6FFE",
+			},
+		},
+	}
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", location: loc}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectLocationMetrics(ch, modem, "dev")
+	})
+
+	info, ok := findMetric(metrics, "modemmanager_location_3gpp_info")
+	if !ok {
+		t.Fatal("modemmanager_location_3gpp_info not emitted")
+	}
+	want := map[string]string{"device_id": "dev", "mcc": "262", "mnc": "01", "lac": "84CD", "tac": "6FFE", "cid": "D30156"}
+	for k, v := range want {
+		if info.labels[k] != v {
+			t.Errorf("label %s = %q, want %q", k, info.labels[k], v)
+		}
+	}
+
+	cellID, ok := findMetric(metrics, "modemmanager_location_3gpp_cell_id")
+	if !ok || cellID.value != float64(0xD30156) {
+		t.Errorf("modemmanager_location_3gpp_cell_id = %v (emitted %v), want %v", cellID.value, ok, float64(0xD30156))
+	}
+
+	if _, ok := findMetric(metrics, "modemmanager_location_latitude_degrees"); ok {
+		t.Error("latitude emitted for modem without GPS")
+	}
+}
+
+func TestCollectLocationPolledOnly(t *testing.T) {
+	loc := &fakeLocation{
+		sources: []modemmanager.MMModemLocationSource{
+			modemmanager.MmModemLocationSource3gppLacCi,
+			modemmanager.MmModemLocationSourceGpsRaw,
+		},
+		signals: false,
+		// The Location property is stale while signals are disabled
+		location: modemmanager.CurrentLocation{
+			GpsRaw: modemmanager.GpsRawLocation{Latitude: 1, Longitude: 1},
+		},
+		current: modemmanager.CurrentLocation{
+			GpsRaw: modemmanager.GpsRawLocation{Latitude: 52.5, Longitude: 13.4},
+		},
+	}
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", location: loc}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectLocationMetrics(ch, modem, "dev")
+	})
+
+	if m, ok := findMetric(metrics, "modemmanager_location_enabled"); !ok || m.value != 1 {
+		t.Errorf("location_enabled = %v (emitted %v), want 1", m.value, ok)
+	}
+
+	sources := make(map[string]bool)
+	for _, m := range metrics {
+		if m.name == "modemmanager_location_source_enabled" {
+			sources[m.labels["source"]] = true
+		}
+	}
+	if len(sources) != 2 || !sources["3gpp-lac-ci"] || !sources["gps-raw"] {
+		t.Errorf("enabled sources = %v, want 3gpp-lac-ci and gps-raw", sources)
+	}
+
+	if m, ok := findMetric(metrics, "modemmanager_location_latitude_degrees"); !ok || m.value != 52.5 {
+		t.Errorf("latitude = %v (emitted %v), want 52.5 from GetCurrentLocation", m.value, ok)
+	}
+}
+
+func TestCollectLocationNoSourcesEnabled(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", location: &fakeLocation{}}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectLocationMetrics(ch, modem, "dev")
+	})
+
+	if len(metrics) != 1 || metrics[0].name != "modemmanager_location_enabled" || metrics[0].value != 0 {
+		t.Errorf("metrics = %+v, want only location_enabled 0", metrics)
+	}
+}
+
+func TestLocationSourceToString(t *testing.T) {
+	var source modemmanager.MMModemLocationSource
+	for _, s := range source.GetAllSources() {
+		if locationSourceToString(s) == "unknown" {
+			t.Errorf("locationSourceToString(%v) has no mapping", s)
+		}
+	}
+}
+
+func TestTruncateCoordinate(t *testing.T) {
+	tests := []struct {
+		degrees  float64
+		decimals int
+		want     float64
+	}{
+		{38.889722, 2, 38.88},
+		{-77.008889, 2, -77.0},
+		{-77.008889, 3, -77.008},
+		{-33.8688, 1, -33.8},
+		{0.29, 2, 0.29}, // 0.29 * 100 is 28.999999999999996
+		{-0.29, 2, -0.29},
+		{52.519999, 0, 52},
+		{-52.519999, 0, -52},
+	}
+	for _, tt := range tests {
+		if got := truncateCoordinate(tt.degrees, tt.decimals); got != tt.want {
+			t.Errorf("truncateCoordinate(%v, %d) = %v, want %v", tt.degrees, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParseLocationPrecision(t *testing.T) {
+	for _, s := range []string{"full", "rounded", "presence-only"} {
+		if p, err := ParseLocationPrecision(s); err != nil || string(p) != s {
+			t.Errorf("ParseLocationPrecision(%q) = %q, %v", s, p, err)
+		}
+	}
+	if _, err := ParseLocationPrecision("exact"); err == nil {
+		t.Error("ParseLocationPrecision(exact) succeeded")
+	}
+}
+
+func TestCollectLocationPrecision(t *testing.T) {
+	loc := &fakeLocation{
+		sources: []modemmanager.MMModemLocationSource{modemmanager.MmModemLocationSourceGpsRaw, modemmanager.MmModemLocationSourceGpsNmea},
+		current: modemmanager.CurrentLocation{
+			GpsRaw: modemmanager.GpsRawLocation{Latitude: -33.856784, Longitude: 151.215297, Altitude: 12},
+			GpsNmea: modemmanager.GpsNmeaLocation{NmeaSentences: []string{
+				"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+			}},
+		},
+	}
+	modem := &fakeModem{deviceID: "dev", location: loc}
+
+	tests := []struct {
+		precision LocationPrecision
+		latitude  float64 // 0 if not exported
+		longitude float64
+	}{
+		{FullLocation, -33.856784, 151.215297},
+		{RoundedLocation, -33.85, 151.21},
+		{PresenceOnlyLocation, 0, 0},
+	}
+	for _, tt := range tests {
+		e := NewExporter(&fakeSource{}, WithLocationPrecision(tt.precision, 2))
+		metrics := gather(t, func(ch chan<- prometheus.Metric) {
+			e.collectLocationMetrics(ch, modem, "dev")
+		})
+
+		latitude, _ := findMetric(metrics, "modemmanager_location_latitude_degrees")
+		longitude, _ := findMetric(metrics, "modemmanager_location_longitude_degrees")
+		if latitude.value != tt.latitude || longitude.value != tt.longitude {
+			t.Errorf("%s: position = %v, %v, want %v, %v", tt.precision, latitude.value, longitude.value, tt.latitude, tt.longitude)
+		}
+		_, altitude := findMetric(metrics, "modemmanager_location_altitude_meters")
+		if altitude == (tt.precision == PresenceOnlyLocation) {
+			t.Errorf("%s: altitude emitted = %v", tt.precision, altitude)
+		}
+
+		// Fix presence and quality are exported in every mode
+		if m, ok := findMetric(metrics, "modemmanager_location_fix"); !ok || m.value != 1 {
+			t.Errorf("%s: location_fix = %v (emitted %v), want 1", tt.precision, m.value, ok)
+		}
+		for _, name := range []string{"modemmanager_location_gps_satellites_used", "modemmanager_location_gps_hdop"} {
+			if _, ok := findMetric(metrics, name); !ok {
+				t.Errorf("%s: %s missing", tt.precision, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRateLimitEntries bounds the messages a rate limit handler remembers.
+// Entries older than the interval are dropped once it is reached.
+const maxRateLimitEntries = 1024
+
+// WithLogger sets the logger of the exporter, slog.Default() by default.
+// Errors of single modems are logged at debug or warn level, losing the
+// connection to ModemManager at error level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(e *Exporter) {
+		e.logger = logger
+	}
+}
+
+// NewRateLimitHandler returns a handler that passes a message with the same
+// level and attributes to next at most once per interval, e.g. an error
+// logged on every scrape while a modem is unplugged. The first message after
+// the interval carries the number of dropped repeats as "repeated".
+func NewRateLimitHandler(next slog.Handler, interval time.Duration) slog.Handler {
+	return &rateLimitHandler{
+		next:     next,
+		interval: interval,
+		state:    &rateLimitState{now: time.Now, seen: make(map[string]*rateLimitEntry)},
+	}
+}
+
+type rateLimitHandler struct {
+	next     slog.Handler
+	interval time.Duration
+	scope    string // attributes and groups added by WithAttrs and WithGroup
+	state    *rateLimitState
+}
+
+// rateLimitState is shared by a handler and those derived from it
+type rateLimitState struct {
+	mu   sync.Mutex
+	now  func() time.Time
+	seen map[string]*rateLimitEntry
+}
+
+type rateLimitEntry struct {
+	logged  time.Time
+	dropped int
+}
+
+func (h *rateLimitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *rateLimitHandler) Handle(ctx context.Context, r slog.Record) error {
+	var key strings.Builder
+	key.WriteString(h.scope)
+	key.WriteString(r.Level.String())
+	key.WriteString(" ")
+	key.WriteString(r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		key.WriteString(" ")
+		key.WriteString(a.String())
+		return true
+	})
+
+	s := h.state
+	s.mu.Lock()
+	now := s.now()
+	entry, ok := s.seen[key.String()]
+	if ok && now.Sub(entry.logged) < h.interval {
+		entry.dropped++
+		s.mu.Unlock()
+		return nil
+	}
+	dropped := 0
+	if ok {
+		dropped = entry.dropped
+	} else if len(s.seen) >= maxRateLimitEntries {
+		for k, old := range s.seen {
+			if now.Sub(old.logged) >= h.interval {
+				delete(s.seen, k)
+			}
+		}
+	}
+	s.seen[key.String()] = &rateLimitEntry{logged: now}
+	s.mu.Unlock()
+
+	if dropped > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int("repeated", dropped))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *rateLimitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scope := h.scope
+	for _, a := range attrs {
+		scope += a.String() + " "
+	}
+	return &rateLimitHandler{next: h.next.WithAttrs(attrs), interval: h.interval, scope: scope, state: h.state}
+}
+
+func (h *rateLimitHandler) WithGroup(name string) slog.Handler {
+	return &rateLimitHandler{next: h.next.WithGroup(name), interval: h.interval, scope: h.scope + name + ".", state: h.state}
+}
@@ -0,0 +1,54 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitHandler(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Unix(1700000000, 0)
+	handler := NewRateLimitHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}), time.Minute)
+	handler.(*rateLimitHandler).state.now = func() time.Time { return now }
+	logger := slog.New(handler)
+
+	for range 3 {
+		logger.Warn("Error collecting metrics for modem", "err", "timeout")
+	}
+	logger.Warn("Error collecting metrics for modem", "err", "unplugged")
+	logger.With("device_id", "dev").Warn("Error collecting metrics for modem", "err", "timeout")
+	now = now.Add(time.Minute)
+	logger.Warn("Error collecting metrics for modem", "err", "timeout")
+
+	want := []string{
+		`level=WARN msg="Error collecting metrics for modem" err=timeout`,
+		`level=WARN msg="Error collecting metrics for modem" err=unplugged`,
+		`level=WARN msg="Error collecting metrics for modem" device_id=dev err=timeout`,
+		`level=WARN msg="Error collecting metrics for modem" err=timeout repeated=2`,
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("logged\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelError}))
+	e := NewExporter(&fakeSource{versionErr: errors.New("timeout")}, WithLogger(logger))
+	gather(t, e.Collect)
+
+	if !strings.Contains(out.String(), `"level":"ERROR","msg":"Error getting ModemManager version","err":"timeout"`) {
+		t.Errorf("lost connection not logged at error level:\n%s", out.String())
+	}
+}
@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSmsStorageToString(t *testing.T) {
+	want := map[modemmanager.MMSmsStorage]string{
+		modemmanager.MmSmsStorageUnknown: "unknown",
+		modemmanager.MmSmsStorageSm:      "sm",
+		modemmanager.MmSmsStorageMe:      "me",
+		modemmanager.MmSmsStorageMt:      "mt",
+		modemmanager.MmSmsStorageSr:      "sr",
+		modemmanager.MmSmsStorageBm:      "bm",
+		modemmanager.MmSmsStorageTa:      "ta",
+	}
+	for storage, name := range want {
+		if got := smsStorageToString(storage); got != name {
+			t.Errorf("smsStorageToString(%d) = %q, want %q", storage, got, name)
+		}
+	}
+}
+
+func TestMessagingStorageMetrics(t *testing.T) {
+	messaging := &fakeMessaging{
+		defaultStorage: modemmanager.MmSmsStorageMe,
+		storages:       []modemmanager.MMSmsStorage{modemmanager.MmSmsStorageSm, modemmanager.MmSmsStorageMe},
+	}
+	modem := &fakeModem{deviceID: "dev", messaging: messaging}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}})
+	collect := func(ch chan<- prometheus.Metric) {
+		e.collectMessagingMetrics(ch, modem, "dev")
+	}
+
+	metrics := gather(t, collect)
+	if m, ok := findMetric(metrics, "modemmanager_messaging_default_storage"); !ok || m.labels["storage"] != "me" {
+		t.Errorf("default_storage = %v (emitted %v), want storage me", m.labels, ok)
+	}
+	var supported []string
+	for _, m := range metrics {
+		if m.name == "modemmanager_messaging_supported_storage" {
+			supported = append(supported, m.labels["storage"])
+		}
+	}
+	if !slices.Equal(supported, []string{"sm", "me"}) {
+		t.Errorf("supported_storage = %v, want [sm me]", supported)
+	}
+
+	// Unreadable storages leave the other messaging metrics in place
+	messaging.storageErr = errNotSupported
+	metrics = gather(t, collect)
+	if _, ok := findMetric(metrics, "modemmanager_messaging_default_storage"); ok {
+		t.Error("default_storage emitted without a readable storage")
+	}
+	if _, ok := findMetric(metrics, "modemmanager_messaging_sms_count"); !ok {
+		t.Error("sms_count missing")
+	}
+}
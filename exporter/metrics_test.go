@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// collectedMetric is a decoded metric emitted by a collect function.
+type collectedMetric struct {
+	name   string
+	labels map[string]string
+	value  float64
+	tsMs   int64 // explicit timestamp in milliseconds, 0 if none
+}
+
+// gather runs collect and decodes every metric it sends.
+func gather(t *testing.T, collect func(ch chan<- prometheus.Metric)) []collectedMetric {
+	t.Helper()
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collect(ch)
+		close(ch)
+	}()
+
+	var out []collectedMetric
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+
+		c := collectedMetric{name: descName(m.Desc()), labels: make(map[string]string)}
+		c.tsMs = pb.GetTimestampMs()
+		for _, lp := range pb.GetLabel() {
+			c.labels[lp.GetName()] = lp.GetValue()
+		}
+		switch {
+		case pb.Gauge != nil:
+			c.value = pb.GetGauge().GetValue()
+		case pb.Counter != nil:
+			c.value = pb.GetCounter().GetValue()
+		case pb.Untyped != nil:
+			c.value = pb.GetUntyped().GetValue()
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// descName extracts the fully-qualified metric name from a Desc.
+func descName(d *prometheus.Desc) string {
+	s := d.String()
+	start := strings.Index(s, `fqName: "`) + len(`fqName: "`)
+	end := strings.Index(s[start:], `"`)
+	return s[start : start+end]
+}
+
+// findMetric returns the first metric with the given name.
+func findMetric(metrics []collectedMetric, name string) (collectedMetric, bool) {
+	for _, m := range metrics {
+		if m.name == name {
+			return m, true
+		}
+	}
+	return collectedMetric{}, false
+}
@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The mocks package does not build against the current Modem interface, so
+// these use the local fakes; CurrentModesValue would carry the same Mode.
+
+func TestModeMetrics(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", currentModes: modemmanager.Mode{
+		Allowed:   modemmanager.NewModeMask(modemmanager.MmModemMode3g, modemmanager.MmModemMode4g),
+		Preferred: modemmanager.MmModemMode4g,
+	}}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModeMetrics(ch, modem, "dev")
+	})
+
+	var allowed []string
+	for _, m := range metrics {
+		if m.name == "modemmanager_modem_mode_allowed" {
+			allowed = append(allowed, m.labels["mode"])
+		}
+	}
+	if len(allowed) != 2 || allowed[0] != "3g" || allowed[1] != "4g" {
+		t.Errorf("allowed modes = %v, want [3g 4g]", allowed)
+	}
+	if m, ok := findMetric(metrics, "modemmanager_modem_mode_preferred"); !ok || m.labels["mode"] != "4g" {
+		t.Errorf("preferred mode = %v (emitted %v), want 4g", m.labels["mode"], ok)
+	}
+}
+
+func TestModeMetricsWithoutPreference(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", currentModes: modemmanager.Mode{
+		Allowed: modemmanager.NewModeMask(modemmanager.MmModemMode2g, modemmanager.MmModemMode3g, modemmanager.MmModemMode4g),
+	}}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModeMetrics(ch, modem, "dev")
+	})
+	if _, ok := findMetric(metrics, "modemmanager_modem_mode_preferred"); ok {
+		t.Error("mode_preferred emitted although no mode is preferred")
+	}
+	if len(metrics) != 3 {
+		t.Errorf("got %d metrics, want 3 allowed modes", len(metrics))
+	}
+}
+
+func TestModeMetricsError(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", modesErr: errors.New("property not available")}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModeMetrics(ch, modem, "dev")
+	})
+	if len(metrics) != 0 {
+		t.Errorf("got %d metrics for a failed GetCurrentModes, want none", len(metrics))
+	}
+}
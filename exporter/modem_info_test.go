@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func ipFamilies(metrics []collectedMetric) map[string]float64 {
+	families := make(map[string]float64)
+	for _, m := range metrics {
+		if m.name == "modemmanager_modem_ip_family_supported" {
+			families[m.labels["family"]] = m.value
+		}
+	}
+	return families
+}
+
+func TestIPFamilyMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		families []modemmanager.MMBearerIpFamily
+		want     map[string]float64
+	}{
+		{
+			name:     "dual stack",
+			families: []modemmanager.MMBearerIpFamily{modemmanager.MmBearerIpFamilyIpv4, modemmanager.MmBearerIpFamilyIpv6, modemmanager.MmBearerIpFamilyIpv4v6},
+			want:     map[string]float64{"ipv4": 1, "ipv6": 1, "ipv4v6": 1, "non-ip": 0},
+		},
+		{
+			name:     "ipv4 only",
+			families: []modemmanager.MMBearerIpFamily{modemmanager.MmBearerIpFamilyIpv4},
+			want:     map[string]float64{"ipv4": 1, "ipv6": 0, "ipv4v6": 0, "non-ip": 0},
+		},
+		{
+			name:     "any",
+			families: modemmanager.MmBearerIpFamilyNone.BitmaskToSlice(uint32(modemmanager.MmBearerIpFamilyAny)),
+			want:     map[string]float64{"ipv4": 1, "ipv6": 1, "ipv4v6": 1, "non-ip": 1},
+		},
+		{
+			name:     "none",
+			families: []modemmanager.MMBearerIpFamily{},
+			want:     map[string]float64{"ipv4": 0, "ipv6": 0, "ipv4v6": 0, "non-ip": 0},
+		},
+	}
+	for _, tt := range tests {
+		e := NewExporter(&fakeSource{})
+		modem := &fakeModem{deviceID: "dev", ipFamilies: tt.families}
+		got := ipFamilies(gather(t, func(ch chan<- prometheus.Metric) {
+			e.collectModemInfo(ch, modem, "dev")
+		}))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: families = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for family, want := range tt.want {
+			if got[family] != want {
+				t.Errorf("%s: %s = %v, want %v", tt.name, family, got[family], want)
+			}
+		}
+	}
+}
+
+func TestIPFamilyMetricsUnavailable(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModemInfo(ch, &fakeModem{deviceID: "dev"}, "dev")
+	})
+	if got := ipFamilies(metrics); len(got) != 0 {
+		t.Errorf("families = %v for a modem without the property, want none", got)
+	}
+}
+
+func TestCarrierConfigInfo(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", carrier: [2]string{"VZW_Generic", "05010820"}}
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModemInfo(ch, modem, "dev")
+	})
+	m, ok := findMetric(metrics, "modemmanager_modem_carrier_config_info")
+	if !ok {
+		t.Fatal("carrier_config_info not emitted")
+	}
+	if m.labels["name"] != "VZW_Generic" || m.labels["revision"] != "05010820" {
+		t.Errorf("carrier_config_info labels = %v", m.labels)
+	}
+
+	metrics = gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModemInfo(ch, &fakeModem{deviceID: "dev"}, "dev")
+	})
+	if _, ok := findMetric(metrics, "modemmanager_modem_carrier_config_info"); ok {
+		t.Error("carrier_config_info emitted for a modem without carrier configuration")
+	}
+}
+
+func ownNumbers(metrics []collectedMetric) []string {
+	var numbers []string
+	for _, m := range metrics {
+		if m.name == "modemmanager_modem_own_number_info" {
+			numbers = append(numbers, m.labels["number"])
+		}
+	}
+	return numbers
+}
+
+func TestOwnNumberInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		numbers []string
+		opts    []Option
+		want    []string
+	}{
+		{name: "one number", numbers: []string{"+4915112345678"}, want: []string{"+4915112345678"}},
+		{name: "several numbers", numbers: []string{"+4915112345678", "+4915187654321"}, want: []string{"+4915112345678", "+4915187654321"}},
+		{name: "not provisioned", numbers: nil},
+		{name: "disabled", numbers: []string{"+4915112345678"}, opts: []Option{WithOwnNumbers(false)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExporter(&fakeSource{}, tt.opts...)
+			metrics := gather(t, func(ch chan<- prometheus.Metric) {
+				e.collectModemInfo(ch, &fakeModem{deviceID: "dev", ownNumbers: tt.numbers}, "dev")
+			})
+			if got := ownNumbers(metrics); !slices.Equal(got, tt.want) {
+				t.Errorf("own_number_info = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const knotsToKmh = 1.852
+
+// gpsFix holds the fix details parsed from an NMEA trace. Fields are only
+// valid when the matching has* flag is set, so a modem without a fix yet
+// does not export zeros.
+type gpsFix struct {
+	speedKmh          float64
+	hasSpeed          bool
+	headingDegrees    float64
+	hasHeading        bool
+	satellitesUsed    int
+	hasSatellitesUsed bool
+	utcTime           time.Time
+	hasUtcTime        bool
+	hdop              float64
+	hasHdop           bool
+	valid             bool // a GGA or RMC sentence reported a fix
+}
+
+// parseNMEA extracts fix details from GGA, RMC and VTG sentences. Sentences
+// with a bad checksum or without a valid fix are ignored. Later sentences
+// override earlier ones.
+func parseNMEA(sentences []string) gpsFix {
+	var fix gpsFix
+	for _, sentence := range sentences {
+		fields, err := splitNMEASentence(sentence)
+		if err != nil || len(fields[0]) < 5 {
+			continue
+		}
+
+		// Talker ID (GP, GN, GL, ...) is ignored
+		switch fields[0][len(fields[0])-3:] {
+		case "GGA":
+			parseGGA(fields, &fix)
+		case "RMC":
+			parseRMC(fields, &fix)
+		case "VTG":
+			parseVTG(fields, &fix)
+		}
+	}
+	return fix
+}
+
+// splitNMEASentence validates the checksum of a sentence if present and
+// returns its comma-separated fields, starting with the address field.
+func splitNMEASentence(sentence string) ([]string, error) {
+	sentence = strings.TrimSpace(sentence)
+	if !strings.HasPrefix(sentence, "$") {
+		return nil, fmt.Errorf("missing start delimiter")
+	}
+	sentence = sentence[1:]
+
+	if idx := strings.IndexByte(sentence, '*'); idx >= 0 {
+		want, err := strconv.ParseUint(sentence[idx+1:], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum: %w", err)
+		}
+		var sum byte
+		for i := 0; i < idx; i++ {
+			sum ^= sentence[i]
+		}
+		if sum != byte(want) {
+			return nil, fmt.Errorf("checksum mismatch")
+		}
+		sentence = sentence[:idx]
+	}
+
+	return strings.Split(sentence, ","), nil
+}
+
+// parseGGA reads the number of satellites used and the HDOP from a GGA sentence with a fix.
+func parseGGA(fields []string, fix *gpsFix) {
+	// $xxGGA,time,lat,N,lon,E,quality,satellites,hdop,alt,M,sep,M,age,station
+	if len(fields) < 8 {
+		return
+	}
+	if fields[6] == "" || fields[6] == "0" {
+		return
+	}
+	fix.valid = true
+	if n, err := strconv.Atoi(fields[7]); err == nil {
+		fix.satellitesUsed = n
+		fix.hasSatellitesUsed = true
+	}
+	if len(fields) > 8 {
+		if hdop, err := strconv.ParseFloat(fields[8], 64); err == nil {
+			fix.hdop = hdop
+			fix.hasHdop = true
+		}
+	}
+}
+
+// parseRMC reads speed, heading and the full UTC timestamp from an RMC sentence with a valid fix.
+func parseRMC(fields []string, fix *gpsFix) {
+	// $xxRMC,time,status,lat,N,lon,E,speed(knots),course,date,magvar,E
+	if len(fields) < 10 || fields[2] != "A" {
+		return
+	}
+	fix.valid = true
+	if knots, err := strconv.ParseFloat(fields[7], 64); err == nil {
+		fix.speedKmh = knots * knotsToKmh
+		fix.hasSpeed = true
+	}
+	if course, err := strconv.ParseFloat(fields[8], 64); err == nil {
+		fix.headingDegrees = course
+		fix.hasHeading = true
+	}
+	if t, err := parseNMEATime(fields[9], fields[1]); err == nil {
+		fix.utcTime = t
+		fix.hasUtcTime = true
+	}
+}
+
+// parseVTG reads speed and heading from a VTG sentence.
+func parseVTG(fields []string, fix *gpsFix) {
+	// $xxVTG,course,T,course,M,speed,N,speed,K,mode
+	if len(fields) < 9 {
+		return
+	}
+	if len(fields) > 9 && fields[9] == "N" {
+		return
+	}
+	if kmh, err := strconv.ParseFloat(fields[7], 64); err == nil {
+		fix.speedKmh = kmh
+		fix.hasSpeed = true
+	}
+	if course, err := strconv.ParseFloat(fields[1], 64); err == nil {
+		fix.headingDegrees = course
+		fix.hasHeading = true
+	}
+}
+
+// parseNMEATime combines an NMEA date (ddmmyy) and time (hhmmss[.sss]) into a UTC timestamp.
+func parseNMEATime(date, clock string) (time.Time, error) {
+	if len(date) != 6 || len(clock) < 6 {
+		return time.Time{}, fmt.Errorf("invalid date or time")
+	}
+	layout := "020106150405"
+	if len(clock) > 6 {
+		layout += "." + strings.Repeat("0", len(clock)-7)
+	}
+	return time.ParseInLocation(layout, date+clock, time.UTC)
+}
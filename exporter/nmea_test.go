@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSplitNMEASentenceChecksum(t *testing.T) {
+	if _, err := splitNMEASentence("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47"); err != nil {
+		t.Errorf("valid sentence rejected: %v", err)
+	}
+	if _, err := splitNMEASentence("$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48"); err == nil {
+		t.Error("sentence with bad checksum accepted")
+	}
+	if _, err := splitNMEASentence("GPGGA,123519"); err == nil {
+		t.Error("sentence without start delimiter accepted")
+	}
+	if _, err := splitNMEASentence("$GPGGA,123519,,,,,0,00,,,M,,M,,"); err != nil {
+		t.Errorf("sentence without checksum rejected: %v", err)
+	}
+}
+
+func TestParseNMEAFix(t *testing.T) {
+	fix := parseNMEA([]string{
+		"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+		"$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A",
+	})
+
+	if !fix.hasSatellitesUsed || fix.satellitesUsed != 8 {
+		t.Errorf("satellites = %d (%v), want 8", fix.satellitesUsed, fix.hasSatellitesUsed)
+	}
+	if !fix.hasSpeed || math.Abs(fix.speedKmh-22.4*knotsToKmh) > 1e-9 {
+		t.Errorf("speed = %v (%v), want %v", fix.speedKmh, fix.hasSpeed, 22.4*knotsToKmh)
+	}
+	if !fix.hasHeading || fix.headingDegrees != 84.4 {
+		t.Errorf("heading = %v (%v), want 84.4", fix.headingDegrees, fix.hasHeading)
+	}
+	want := time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC)
+	if !fix.hasUtcTime || !fix.utcTime.Equal(want) {
+		t.Errorf("utc time = %v (%v), want %v", fix.utcTime, fix.hasUtcTime, want)
+	}
+	if !fix.hasHdop || fix.hdop != 0.9 {
+		t.Errorf("hdop = %v (%v), want 0.9", fix.hdop, fix.hasHdop)
+	}
+	if !fix.valid {
+		t.Error("fix not reported as valid")
+	}
+}
+
+func TestParseNMEAVTGAndFractionalTime(t *testing.T) {
+	fix := parseNMEA([]string{
+		"$GNRMC,083559.00,A,4717.11437,N,00833.91522,E,0.004,77.52,091202,,,A",
+		"$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K,A",
+	})
+
+	if !fix.hasSpeed || fix.speedKmh != 10.2 {
+		t.Errorf("speed = %v (%v), want 10.2 from VTG", fix.speedKmh, fix.hasSpeed)
+	}
+	if !fix.hasHeading || fix.headingDegrees != 54.7 {
+		t.Errorf("heading = %v (%v), want 54.7 from VTG", fix.headingDegrees, fix.hasHeading)
+	}
+	want := time.Date(2002, 12, 9, 8, 35, 59, 0, time.UTC)
+	if !fix.hasUtcTime || !fix.utcTime.Equal(want) {
+		t.Errorf("utc time = %v (%v), want %v", fix.utcTime, fix.hasUtcTime, want)
+	}
+}
+
+func TestParseNMEANoFix(t *testing.T) {
+	fix := parseNMEA([]string{
+		"$GPGGA,,,,,,0,00,99.99,,,,,,",
+		"$GPRMC,,V,,,,,,,,,,N",
+		"$GPVTG,,,,,,,,,N",
+		"$GPGSV,1,1,00",
+		"garbage",
+	})
+
+	if fix.hasSpeed || fix.hasHeading || fix.hasSatellitesUsed || fix.hasUtcTime || fix.hasHdop || fix.valid {
+		t.Errorf("fields reported without a fix: %+v", fix)
+	}
+}
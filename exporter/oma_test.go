@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestOMAMetrics(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{
+		deviceID: "dev",
+		oma: &fakeOma{
+			features:    []modemmanager.MMOmaFeature{modemmanager.MmOmaFeaturePrlUpdate},
+			state:       modemmanager.MmOmaSessionStateFailed,
+			sessionType: modemmanager.MmOmaSessionTypeNetworkInitiatedPrlUpdate,
+		},
+	}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectOMAMetrics(ch, modem, "dev")
+	})
+
+	if m, ok := findMetric(metrics, "modemmanager_oma_session_state"); !ok || m.labels["state"] != "failed" {
+		t.Errorf("oma_session_state = %v (emitted %v), want state failed", m.labels, ok)
+	}
+	if m, ok := findMetric(metrics, "modemmanager_oma_session_type"); !ok || m.labels["type"] != "network_initiated_prl_update" {
+		t.Errorf("oma_session_type = %v (emitted %v), want type network_initiated_prl_update", m.labels, ok)
+	}
+
+	features := make(map[string]float64)
+	for _, m := range metrics {
+		if m.name == "modemmanager_oma_features_enabled" {
+			features[m.labels["feature"]] = m.value
+		}
+	}
+	want := map[string]float64{"device_provisioning": 0, "prl_update": 1, "hands_free_activation": 0}
+	for feature, v := range want {
+		if got, ok := features[feature]; !ok || got != v {
+			t.Errorf("oma_features_enabled{%s} = %v (emitted %v), want %v", feature, got, ok, v)
+		}
+	}
+}
+
+func TestOMAMetricsSkippedWithoutInterface(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev"}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectModemMetrics(ch, modem, "dev")
+	})
+	for _, m := range metrics {
+		if strings.HasPrefix(m.name, "modemmanager_oma_") {
+			t.Errorf("%s emitted for a modem without the OMA interface", m.name)
+		}
+	}
+}
+
+// expectKnown checks that every value but the unknown one has its own label
+func expectKnown[T comparable](t *testing.T, name string, toString func(T) string, unknown T, values []T) {
+	t.Helper()
+	seen := map[string]bool{toString(unknown): true}
+	for _, v := range values {
+		str := toString(v)
+		if seen[str] {
+			t.Errorf("%s(%v) = %q is not unique", name, v, str)
+		}
+		seen[str] = true
+	}
+}
+
+func TestOMAEnumStrings(t *testing.T) {
+	var features modemmanager.MMOmaFeature
+	expectKnown(t, "omaFeatureToString", omaFeatureToString, modemmanager.MmOmaFeatureNone, features.GetAllFeatures())
+
+	expectKnown(t, "omaSessionStateToString", omaSessionStateToString, modemmanager.MmOmaSessionStateUnknown, []modemmanager.MMOmaSessionState{
+		modemmanager.MmOmaSessionStateFailed,
+		modemmanager.MmOmaSessionStateStarted,
+		modemmanager.MmOmaSessionStateRetrying,
+		modemmanager.MmOmaSessionStateConnecting,
+		modemmanager.MmOmaSessionStateConnected,
+		modemmanager.MmOmaSessionStateAuthenticated,
+		modemmanager.MmOmaSessionStateMdnDownloaded,
+		modemmanager.MmOmaSessionStateMsidDownloaded,
+		modemmanager.MmOmaSessionStatePrlDownloaded,
+		modemmanager.MmOmaSessionStateMipProfileDownloaded,
+		modemmanager.MmOmaSessionStateCompleted,
+	})
+
+	expectKnown(t, "omaSessionTypeToString", omaSessionTypeToString, modemmanager.MmOmaSessionTypeUnknown, []modemmanager.MMOmaSessionType{
+		modemmanager.MmOmaSessionTypeClientInitiatedDeviceConfigure,
+		modemmanager.MmOmaSessionTypeClientInitiatedPrlUpdate,
+		modemmanager.MmOmaSessionTypeClientInitiatedHandsFreeActivation,
+		modemmanager.MmOmaSessionTypeNetworkInitiatedDeviceConfigure,
+		modemmanager.MmOmaSessionTypeNetworkInitiatedPrlUpdate,
+		modemmanager.MmOmaSessionTypeDeviceInitiatedPrlUpdate,
+		modemmanager.MmOmaSessionTypeDeviceInitiatedHandsFreeActivation,
+	})
+}
@@ -0,0 +1,71 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// Metrics are emitted in a stable order so that consecutive expositions can
+// be diffed: modems by device identifier, bearers by object path, and series
+// kept in maps by their label values. Slices returned by the library for
+// bitmask properties (location sources, bands, modes) are already in enum
+// order and are emitted as they are.
+
+// identifiedModem is a modem together with its device identifier
+type identifiedModem struct {
+	deviceID string
+	modem    modemmanager.Modem
+}
+
+// modemsByDeviceID resolves the device_id label of each modem with label and
+// returns the modems sorted by it. Modems whose label cannot be read are left
+// out and reported as errors.
+func modemsByDeviceID(modems []modemmanager.Modem, label func(modemmanager.Modem) (string, error)) ([]identifiedModem, []error) {
+	var (
+		ordered []identifiedModem
+		errs    []error
+	)
+	for _, modem := range modems {
+		deviceID, err := label(modem)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get device identifier of %s: %w", modem.GetObjectPath(), err))
+			continue
+		}
+		ordered = append(ordered, identifiedModem{deviceID: deviceID, modem: modem})
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].deviceID < ordered[j].deviceID
+	})
+	return ordered, errs
+}
+
+// sortBearersByPath sorts bearers in place by D-Bus object path
+func sortBearersByPath(bearers []modemmanager.Bearer) {
+	sort.SliceStable(bearers, func(i, j int) bool {
+		return bearers[i].GetObjectPath() < bearers[j].GetObjectPath()
+	})
+}
+
+// sortedTransitions returns the keys of a transition counter map in label order
+func sortedTransitions(transitions map[stateTransition]uint64) []stateTransition {
+	keys := make([]stateTransition, 0, len(transitions))
+	for key := range transitions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.deviceID != b.deviceID {
+			return a.deviceID < b.deviceID
+		}
+		if a.oldState != b.oldState {
+			return a.oldState < b.oldState
+		}
+		if a.newState != b.newState {
+			return a.newState < b.newState
+		}
+		return a.reason < b.reason
+	})
+	return keys
+}
@@ -0,0 +1,85 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// exposition renders collected metrics in emission order, one line each.
+// Durations and update times are skipped as they differ between runs.
+func exposition(metrics []collectedMetric) string {
+	var b strings.Builder
+	for _, m := range metrics {
+		if volatileMetrics[m.name] || m.name == "modemmanager_modem_last_updated_timestamp_seconds" {
+			continue
+		}
+		keys := make([]string, 0, len(m.labels))
+		for k := range m.labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = fmt.Sprintf("%s=%q", k, m.labels[k])
+		}
+		fmt.Fprintf(&b, "%s{%s} %g\n", m.name, strings.Join(pairs, ","), m.value)
+	}
+	return b.String()
+}
+
+func TestCollectOrderIsDeterministic(t *testing.T) {
+	bearers := func() []modemmanager.Bearer {
+		return []modemmanager.Bearer{
+			&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/2", iface: "wwan1"},
+			&fakeBearer{path: "/org/freedesktop/ModemManager1/Bearer/1", iface: "wwan0"},
+		}
+	}
+	mm := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "ccc", state: modemmanager.MmModemStateConnected, bearers: bearers()},
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/1", deviceID: "aaa", state: modemmanager.MmModemStateRegistered, bearers: bearers()},
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/2", deviceID: "bbb", state: modemmanager.MmModemStateConnected},
+	}}
+	e := NewExporter(mm)
+	e.recordStateTransition("ccc", modemmanager.MmModemStateRegistered, modemmanager.MmModemStateConnected, modemmanager.MmModemStateChangeReasonUnknown)
+	e.recordStateTransition("aaa", modemmanager.MmModemStateConnected, modemmanager.MmModemStateRegistered, modemmanager.MmModemStateChangeReasonUnknown)
+	e.recordStateTransition("aaa", modemmanager.MmModemStateConnected, modemmanager.MmModemStateRegistered, modemmanager.MmModemStateChangeReasonFailure)
+
+	first := gather(t, e.Collect)
+	for i := 0; i < 5; i++ {
+		if got, want := exposition(gather(t, e.Collect)), exposition(first); got != want {
+			t.Fatalf("scrape %d differs from the first:\n%s\nwant:\n%s", i+2, got, want)
+		}
+	}
+
+	var modems, bearerPaths []string
+	for _, m := range first {
+		switch m.name {
+		case "modemmanager_modem_info":
+			modems = append(modems, m.labels["device_id"])
+		case "modemmanager_bearer_info":
+			if m.labels["device_id"] == "aaa" {
+				bearerPaths = append(bearerPaths, m.labels["bearer_path"])
+			}
+		}
+	}
+	if want := []string{"aaa", "bbb", "ccc"}; strings.Join(modems, ",") != strings.Join(want, ",") {
+		t.Errorf("modem order = %v, want %v", modems, want)
+	}
+	if len(bearerPaths) != 2 || bearerPaths[0] > bearerPaths[1] {
+		t.Errorf("bearer order = %v, want sorted by path", bearerPaths)
+	}
+
+	var transitions []string
+	for _, m := range first {
+		if m.name == "modemmanager_modem_state_transitions_total" {
+			transitions = append(transitions, m.labels["device_id"]+"/"+m.labels["reason"])
+		}
+	}
+	if want := "aaa/failure,aaa/unknown,ccc/unknown"; strings.Join(transitions, ",") != want {
+		t.Errorf("transition order = %v, want %s", transitions, want)
+	}
+}
@@ -0,0 +1,62 @@
+package exporter
+
+import "context"
+
+// ModemOverview summarizes a modem for a status page. Fields that could not
+// be read are left empty.
+type ModemOverview struct {
+	DeviceID      string
+	State         string
+	Operator      string
+	SignalQuality uint32 // percent
+	SignalValid   bool   // SignalQuality was read
+
+	// SignalSetup is "configured" or the reason SetupSignalMonitoring failed
+	// for the modem, empty if it did not run against it
+	SignalSetup string
+}
+
+// Overview reads the state, operator and signal quality of every exported
+// modem. It returns ctx.Err() if ctx is done first; ModemManager calls do not
+// take a context, so the reads then finish in the background.
+func (e *Exporter) Overview(ctx context.Context) ([]ModemOverview, error) {
+	return withContext(ctx, e.overview)
+}
+
+func (e *Exporter) overview() ([]ModemOverview, error) {
+	modems, err := e.modems()
+	if err != nil {
+		return nil, err
+	}
+
+	overviews := make([]ModemOverview, 0, len(modems))
+	for _, modem := range modems {
+		deviceID, err := e.modemLabel(modem)
+		if err != nil {
+			continue
+		}
+		overview := ModemOverview{DeviceID: deviceID}
+		if state, err := modem.GetState(); err == nil {
+			overview.State = stateToString(state)
+		}
+		if modem3gpp, err := modem.Get3gpp(); err == nil {
+			overview.Operator, _ = modem3gpp.GetOperatorName()
+		}
+		if quality, _, err := modem.GetSignalQuality(); err == nil {
+			overview.SignalQuality = quality
+			overview.SignalValid = true
+		}
+
+		e.mu.Lock()
+		if setup, ok := e.signalSetup[deviceID]; ok {
+			overview.SignalSetup = setup.reason
+			if setup.configured {
+				overview.SignalSetup = "configured"
+			}
+		}
+		e.mu.Unlock()
+
+		overviews = append(overviews, overview)
+	}
+	return overviews, nil
+}
@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+// blockingSource does not answer until release is closed
+type blockingSource struct {
+	release chan struct{}
+}
+
+func (s blockingSource) Modems() ([]modemmanager.Modem, error) {
+	<-s.release
+	return nil, nil
+}
+
+func (s blockingSource) Version() (string, error) {
+	<-s.release
+	return "1.22.0", nil
+}
+
+func TestOverview(t *testing.T) {
+	manager := mocks.NewMockModemManager()
+	modem := mockModem(manager)
+	modem.StateValue = modemmanager.MmModemStateConnected
+	modem.SignalQualityPercent = 73
+	modem3gpp := mocks.NewMockModem3gpp()
+	modem3gpp.OperatorNameValue = "<b>Evil & Co</b>"
+	modem.Modem3gppValue = modem3gpp
+	modem.SignalValue = mocks.NewMockModemSignal()
+	e := NewExporter(ModemManagerSource{Manager: manager})
+	if err := e.SetupSignalMonitoring(5 * time.Second); err != nil {
+		t.Fatalf("SetupSignalMonitoring() error = %v", err)
+	}
+
+	got, err := e.Overview(context.Background())
+	if err != nil {
+		t.Fatalf("Overview() error = %v", err)
+	}
+	want := []ModemOverview{{
+		DeviceID:      "mock-0000",
+		State:         "connected",
+		Operator:      "<b>Evil & Co</b>",
+		SignalQuality: 73,
+		SignalValid:   true,
+		SignalSetup:   "configured",
+	}}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Overview() = %+v, want %+v", got, want)
+	}
+}
+
+func TestOverviewTimeout(t *testing.T) {
+	source := blockingSource{release: make(chan struct{})}
+	defer close(source.release)
+	e := NewExporter(source)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := e.Overview(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Overview() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ProbeHandler returns a handler serving the metrics of the single modem
+// given by the modem query parameter, an object path or an mmcli index, so
+// each modem can be scraped as its own target. Every response carries
+// probe_success and probe_duration_seconds; the modem metrics are only
+// included if the modem exists.
+//
+// Each probed modem is read by its own Exporter, created with the options
+// passed to NewExporter and restricted to that modem, and kept between
+// probes so counters and connected_since survive. The Exporter itself is
+// not affected.
+func (e *Exporter) ProbeHandler(opts promhttp.HandlerOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, err := parseProbeTarget(r.URL.Query().Get("modem"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		start := time.Now()
+		probeSuccess := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_success",
+			Help: "Whether the probed modem was found (1 = yes, 0 = no)",
+		})
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(probeSuccess)
+		if probe, ok := e.probeExporter(path); ok {
+			registry.MustRegister(probe)
+			probeSuccess.Set(1)
+		}
+
+		// Gatherers are gathered in order, so the duration includes reading
+		// the modem
+		timing := prometheus.NewRegistry()
+		timing.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "probe_duration_seconds",
+			Help: "Time the probe took in seconds",
+		}, func() float64 {
+			return time.Since(start).Seconds()
+		}))
+
+		promhttp.HandlerFor(prometheus.Gatherers{registry, timing}, opts).ServeHTTP(w, r)
+	})
+}
+
+// parseProbeTarget resolves the modem query parameter to an object path
+func parseProbeTarget(target string) (dbus.ObjectPath, error) {
+	switch {
+	case target == "":
+		return "", fmt.Errorf("modem parameter is missing")
+	case strings.HasPrefix(target, "/"):
+		path := dbus.ObjectPath(target)
+		if !path.IsValid() {
+			return "", fmt.Errorf("invalid modem object path %q", target)
+		}
+		return path, nil
+	}
+	index, err := strconv.Atoi(target)
+	if err != nil || index < 0 {
+		return "", fmt.Errorf("invalid modem %q, expected an object path or index", target)
+	}
+	return modemmanager.ModemPathFromIndex(index), nil
+}
+
+// probeExporter returns the exporter for the modem at path, creating it on
+// first use. It reports false, forgetting any previous exporter, if the
+// modem does not exist or the modems cannot be listed.
+func (e *Exporter) probeExporter(path dbus.ObjectPath) (*Exporter, bool) {
+	modems, err := e.modems()
+	if err != nil && isDaemonGone(err) && e.reconnect() {
+		modems, err = e.modems()
+	}
+	found := false
+	if err == nil {
+		for _, modem := range modems {
+			if modem.GetObjectPath() == path {
+				found = true
+				break
+			}
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !found {
+		delete(e.probes, path)
+		return nil, false
+	}
+	probe, ok := e.probes[path]
+	if !ok {
+		opts := append(append([]Option(nil), e.options...), WithModemPaths(path))
+		probe = NewExporter(probeSource{e}, opts...)
+		// The probe reconnects through the Exporter it borrows the source from
+		probe.connect = nil
+		e.probes[path] = probe
+	}
+	return probe, true
+}
+
+// probeSource is the ModemSource of a probe exporter. It borrows the current
+// source of the Exporter the probe was created by.
+type probeSource struct {
+	e *Exporter
+}
+
+func (s probeSource) Modems() ([]modemmanager.Modem, error) {
+	return s.e.modemSource().Modems()
+}
+
+func (s probeSource) Version() (string, error) {
+	return s.e.modemSource().Version()
+}
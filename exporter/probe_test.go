@@ -0,0 +1,102 @@
+package exporter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func probe(t *testing.T, e *Exporter, query string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	e.ProbeHandler(promhttp.HandlerOpts{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/probe?"+query, nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, string(body)
+}
+
+func TestProbeHandler(t *testing.T) {
+	source := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "first", state: modemmanager.MmModemStateRegistered},
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/3", deviceID: "second", state: modemmanager.MmModemStateConnected},
+	}}
+	e := NewExporter(source)
+
+	for _, query := range []string{"modem=3", "modem=/org/freedesktop/ModemManager1/Modem/3"} {
+		code, body := probe(t, e, query)
+		if code != http.StatusOK {
+			t.Fatalf("%s: status %d", query, code)
+		}
+		for _, want := range []string{"probe_success 1", "probe_duration_seconds", `device_id="second"`, "modemmanager_up 1"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: %q missing from\n%s", query, want, body)
+			}
+		}
+		if strings.Contains(body, `device_id="first"`) {
+			t.Errorf("%s: metrics of another modem in\n%s", query, body)
+		}
+	}
+
+	code, body := probe(t, e, "modem=7")
+	if code != http.StatusOK || !strings.Contains(body, "probe_success 0") {
+		t.Errorf("unknown modem: status %d, body\n%s", code, body)
+	}
+	if strings.Contains(body, "modemmanager_") {
+		t.Errorf("unknown modem: modem metrics in\n%s", body)
+	}
+
+	for _, query := range []string{"", "modem=", "modem=first", "modem=-1"} {
+		if code, _ := probe(t, e, query); code != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want %d", query, code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestProbeKeepsStateBetweenProbes(t *testing.T) {
+	source := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "dev", state: modemmanager.MmModemStateConnected},
+	}}
+	e := NewExporter(source)
+
+	_, body := probe(t, e, "modem=0")
+	first := e.probes["/org/freedesktop/ModemManager1/Modem/0"]
+	_, again := probe(t, e, "modem=0")
+	if first == nil || e.probes["/org/freedesktop/ModemManager1/Modem/0"] != first {
+		t.Fatal("probe exporter not reused")
+	}
+	if !strings.Contains(body, "modemmanager_modem_connected_since_timestamp_seconds") ||
+		!strings.Contains(again, "modemmanager_modem_connected_since_timestamp_seconds") {
+		t.Error("connected_since missing from probe")
+	}
+
+	source.modems = nil
+	probe(t, e, "modem=0")
+	if _, ok := e.probes["/org/freedesktop/ModemManager1/Modem/0"]; ok {
+		t.Error("probe exporter of a vanished modem was kept")
+	}
+}
+
+func TestModemFilter(t *testing.T) {
+	source := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "kept"},
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/1", deviceID: "dropped"},
+	}}
+	e := NewExporter(source, WithModemPaths("/org/freedesktop/ModemManager1/Modem/0"))
+
+	var modems []string
+	for _, m := range gather(t, e.Collect) {
+		if m.name == "modemmanager_modem_info" {
+			modems = append(modems, m.labels["device_id"])
+		}
+	}
+	if len(modems) != 1 || modems[0] != "kept" {
+		t.Errorf("modem_info emitted for %v, want [kept]", modems)
+	}
+}
@@ -0,0 +1,154 @@
+package exporter
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// nameOwnerChangedSignal is the full name of the bus daemon signal announcing
+// a new owner of a bus name
+const nameOwnerChangedSignal = "org.freedesktop.DBus.NameOwnerChanged"
+
+// D-Bus errors returned while ModemManager is not on the bus
+var daemonGoneErrors = map[string]bool{
+	"org.freedesktop.DBus.Error.ServiceUnknown": true,
+	"org.freedesktop.DBus.Error.NameHasNoOwner": true,
+	"org.freedesktop.DBus.Error.NoReply":        true,
+	"org.freedesktop.DBus.Error.Disconnected":   true,
+}
+
+// WithReconnect lets the exporter replace its source when ModemManager
+// disappears from the bus, e.g. while the daemon restarts after an upgrade.
+// connect is called at most once per scrape.
+func WithReconnect(connect func() (ModemSource, error)) Option {
+	return func(e *Exporter) {
+		e.connect = connect
+	}
+}
+
+// WithNameOwnerChanges lets Start notice a ModemManager restart that falls
+// between two resyncs, when the new daemon exports its modems under the same
+// object paths and no call fails. Start calls subscribe for the
+// NameOwnerChanged signals of the bus daemon and cancel once it returns;
+// SubscribeNameOwnerChanged subscribes on the system bus.
+func WithNameOwnerChanges(subscribe func() (signals <-chan *dbus.Signal, cancel func(), err error)) Option {
+	return func(e *Exporter) {
+		e.subscribeOwner = subscribe
+	}
+}
+
+// SubscribeNameOwnerChanged subscribes to the NameOwnerChanged signals of the
+// ModemManager bus name on the system bus, for WithNameOwnerChanges. The
+// channel must be drained until cancel is called.
+func SubscribeNameOwnerChanged() (<-chan *dbus.Signal, func(), error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = conn.AddMatchSignal(
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchOption("arg0", modemmanager.ModemManagerBusName),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	return signals, func() { conn.RemoveSignal(signals) }, nil
+}
+
+// isNewDaemon reports whether sig announces a new owner of the ModemManager
+// bus name, i.e. a started or restarted daemon. The channel of the shared
+// connection receives other signals as well.
+func isNewDaemon(sig *dbus.Signal) bool {
+	if sig.Name != nameOwnerChangedSignal || len(sig.Body) != 3 {
+		return false
+	}
+	name, _ := sig.Body[0].(string)
+	newOwner, _ := sig.Body[2].(string)
+	return name == modemmanager.ModemManagerBusName && newOwner != ""
+}
+
+// isDaemonGone reports whether err means ModemManager could not be reached
+func isDaemonGone(err error) bool {
+	if errors.Is(err, dbus.ErrClosed) || errors.Is(err, ErrNotConnected) {
+		return true
+	}
+	var dbusErr dbus.Error
+	var dbusErrPtr *dbus.Error
+	switch {
+	case errors.As(err, &dbusErr):
+		return daemonGoneErrors[dbusErr.Name]
+	case errors.As(err, &dbusErrPtr):
+		return daemonGoneErrors[dbusErrPtr.Name]
+	default:
+		return false
+	}
+}
+
+// modemSource returns the current source, which reconnect may replace
+func (e *Exporter) modemSource() ModemSource {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.source
+}
+
+// SetSource replaces the source of the exporter, e.g. once a connection to
+// ModemManager made in the background succeeds.
+func (e *Exporter) SetSource(source ModemSource) {
+	e.mu.Lock()
+	e.source = source
+	e.mu.Unlock()
+}
+
+// reconnect replaces the source with a new connection to ModemManager. It
+// reports whether a new source is in place.
+func (e *Exporter) reconnect() bool {
+	if e.connect == nil {
+		return false
+	}
+	source, err := e.connect()
+	if err != nil {
+		e.logger.Error("Error reconnecting to ModemManager", "err", err)
+		return false
+	}
+
+	e.SetSource(source)
+	e.daemonReplaced()
+	e.logger.Info("Reconnected to ModemManager")
+	return true
+}
+
+// daemonReplaced records that a new ModemManager instance took over, which
+// has forgotten the signal refresh rate of every modem. observeDaemon applies
+// the rate again once the daemon answers, and Start once modems appear.
+func (e *Exporter) daemonReplaced() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.daemonDown = true
+	if e.signalPaths != nil {
+		e.signalPaths = make(map[dbus.ObjectPath]bool)
+	}
+}
+
+// observeDaemon records whether ModemManager answered the scrape. When it
+// answers again after being unreachable, the signal refresh rate is applied
+// again as a restarted daemon has forgotten it.
+func (e *Exporter) observeDaemon(up bool) {
+	e.mu.Lock()
+	cameBack := up && e.daemonDown
+	e.daemonDown = !up
+	rate := e.signalRate
+	enabled := e.signalPaths != nil
+	e.mu.Unlock()
+
+	if cameBack && enabled {
+		e.logger.Info("ModemManager is back, setting up signal monitoring again")
+		if err := e.SetupSignalMonitoring(rate); err != nil {
+			e.logger.Warn("Failed to setup signal monitoring", "err", err)
+		}
+	}
+}
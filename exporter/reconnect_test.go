@@ -0,0 +1,185 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/mocks"
+)
+
+var errServiceUnknown = dbus.Error{Name: "org.freedesktop.DBus.Error.ServiceUnknown"}
+
+func TestIsDaemonGone(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errServiceUnknown, true},
+		{&dbus.Error{Name: "org.freedesktop.DBus.Error.NoReply"}, true},
+		{fmt.Errorf("get modems: %w", dbus.Error{Name: "org.freedesktop.DBus.Error.NameHasNoOwner"}), true},
+		{dbus.ErrClosed, true},
+		{ErrNotConnected, true},
+		{dbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"}, false},
+		{dbus.Error{Name: "org.freedesktop.ModemManager1.Error.Core.Failed"}, false},
+		{errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := isDaemonGone(tt.err); got != tt.want {
+			t.Errorf("isDaemonGone(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCollectReconnects(t *testing.T) {
+	stale := &fakeSource{err: errServiceUnknown, versionErr: errServiceUnknown}
+	fresh := &fakeSource{modems: []modemmanager.Modem{&fakeModem{deviceID: "dev"}}}
+	connects := 0
+	e := NewExporter(stale, WithReconnect(func() (ModemSource, error) {
+		connects++
+		return fresh, nil
+	}))
+
+	metrics := gather(t, e.Collect)
+	if connects != 1 {
+		t.Errorf("connect called %d times, want 1", connects)
+	}
+	if m, ok := findMetric(metrics, "modemmanager_up"); !ok || m.value != 1 {
+		t.Errorf("up = %v (emitted %v), want 1 after reconnecting", m.value, ok)
+	}
+	if _, ok := findMetric(metrics, "modemmanager_modem_info"); !ok {
+		t.Error("modem metrics missing after reconnecting")
+	}
+
+	// The new source is kept for later scrapes
+	gather(t, e.Collect)
+	if connects != 1 {
+		t.Errorf("connect called %d times after a healthy scrape, want 1", connects)
+	}
+}
+
+func TestExporterWithoutSource(t *testing.T) {
+	e := NewExporter(nil)
+	metrics := gather(t, e.Collect)
+	if m, ok := findMetric(metrics, "modemmanager_up"); !ok || m.value != 0 {
+		t.Errorf("up = %v (emitted %v), want 0 without a source", m.value, ok)
+	}
+
+	e.SetSource(&fakeSource{modems: []modemmanager.Modem{&fakeModem{deviceID: "dev"}}})
+	metrics = gather(t, e.Collect)
+	if m, ok := findMetric(metrics, "modemmanager_up"); !ok || m.value != 1 {
+		t.Errorf("up = %v (emitted %v), want 1 after SetSource", m.value, ok)
+	}
+	if _, ok := findMetric(metrics, "modemmanager_modem_info"); !ok {
+		t.Error("modem metrics missing after SetSource")
+	}
+
+	// A scrape connects through WithReconnect
+	connects := 0
+	e = NewExporter(nil, WithReconnect(func() (ModemSource, error) {
+		connects++
+		return &fakeSource{}, nil
+	}))
+	if m, _ := findMetric(gather(t, e.Collect), "modemmanager_up"); m.value != 1 || connects != 1 {
+		t.Errorf("up = %v after %d connects, want 1 after 1", m.value, connects)
+	}
+}
+
+func TestCollectDaemonDown(t *testing.T) {
+	down := &fakeSource{err: errServiceUnknown, versionErr: errServiceUnknown}
+	e := NewExporter(down, WithReconnect(func() (ModemSource, error) {
+		return down, nil
+	}))
+
+	metrics := gather(t, e.Collect)
+	if m, ok := findMetric(metrics, "modemmanager_up"); !ok || m.value != 0 {
+		t.Errorf("up = %v (emitted %v), want 0 while ModemManager is gone", m.value, ok)
+	}
+	if m, ok := findMetric(metrics, "modemmanager_scrape_success"); !ok || m.value != 0 {
+		t.Errorf("scrape_success = %v (emitted %v), want 0", m.value, ok)
+	}
+
+	// Other errors do not trigger a reconnect
+	reconnected := false
+	e = NewExporter(&fakeSource{versionErr: errors.New("boom")}, WithReconnect(func() (ModemSource, error) {
+		reconnected = true
+		return down, nil
+	}))
+	gather(t, e.Collect)
+	if reconnected {
+		t.Error("reconnected on an error that does not mean ModemManager is gone")
+	}
+}
+
+func TestCollectSkipsModemsWhileDown(t *testing.T) {
+	source := &fakeSource{modems: []modemmanager.Modem{&fakeModem{deviceID: "dev"}}, versionErr: errors.New("timeout")}
+	e := NewExporter(source)
+
+	metrics := gather(t, e.Collect)
+	if metrics[0].name != "modemmanager_up" || metrics[0].value != 0 {
+		t.Errorf("first metric = %+v, want modemmanager_up 0", metrics[0])
+	}
+	if source.listings != 0 {
+		t.Errorf("modems listed %d times while ModemManager is down", source.listings)
+	}
+	if _, ok := findMetric(metrics, "modemmanager_modem_info"); ok {
+		t.Error("modem metrics emitted while ModemManager is down")
+	}
+
+	source.versionErr = nil
+	metrics = gather(t, e.Collect)
+	if metrics[0].name != "modemmanager_up" || metrics[0].value != 1 {
+		t.Errorf("first metric = %+v, want modemmanager_up 1", metrics[0])
+	}
+	if _, ok := findMetric(metrics, "modemmanager_modem_info"); !ok {
+		t.Error("modem metrics missing once ModemManager answers")
+	}
+}
+
+func TestSignalSetupReappliedAfterRestart(t *testing.T) {
+	signal := &fakeSignal{}
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{&fakeModem{deviceID: "dev", signal: signal}}})
+	if err := e.SetupSignalMonitoring(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restarted daemon forgets the rate
+	signal.setupRate = 0
+	e.observeDaemon(true)
+	if signal.setupRate != 0 {
+		t.Fatal("Setup called again while ModemManager stayed up")
+	}
+	e.observeDaemon(false)
+	if signal.setupRate != 0 {
+		t.Fatal("Setup called while ModemManager is gone")
+	}
+	e.observeDaemon(true)
+	if signal.setupRate != 5 {
+		t.Errorf("Setup rate = %d after ModemManager came back, want 5", signal.setupRate)
+	}
+}
+
+func TestCollectReappliesSignalSetupAfterReconnect(t *testing.T) {
+	// Collect reads the signal values as well
+	stale := &fakeSource{modems: []modemmanager.Modem{&fakeModem{deviceID: "dev", signal: &fakeSignal{ModemSignal: mocks.NewMockModemSignal()}}}}
+	signal := &fakeSignal{ModemSignal: mocks.NewMockModemSignal()}
+	fresh := &fakeSource{modems: []modemmanager.Modem{&fakeModem{deviceID: "dev", signal: signal}}}
+	e := NewExporter(stale, WithReconnect(func() (ModemSource, error) { return fresh, nil }))
+	if err := e.SetupSignalMonitoring(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// ModemManager restarts between two scrapes, the scrape reconnects
+	stale.versionErr = errServiceUnknown
+	stale.set(nil, errServiceUnknown)
+	metrics := gather(t, e.Collect)
+	if m, ok := findMetric(metrics, "modemmanager_up"); !ok || m.value != 1 {
+		t.Fatalf("up = %v (emitted %v), want 1 after reconnecting", m.value, ok)
+	}
+	if signal.setupRate != 5 {
+		t.Errorf("Setup rate on the new daemon = %d, want 5", signal.setupRate)
+	}
+}
@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScrapeErrorsAccumulate(t *testing.T) {
+	source := &fakeSource{}
+	e := NewExporter(source)
+
+	scrape := func() (total, last float64) {
+		t.Helper()
+		metrics := gather(t, e.Collect)
+		totalMetric, ok := findMetric(metrics, "modemmanager_scrape_errors_total")
+		if !ok {
+			t.Fatal("scrape_errors_total not emitted")
+		}
+		lastMetric, ok := findMetric(metrics, "modemmanager_scrape_last_errors")
+		if !ok {
+			t.Fatal("scrape_last_errors not emitted")
+		}
+		return totalMetric.value, lastMetric.value
+	}
+
+	steps := []struct {
+		err       error
+		wantTotal float64
+		wantLast  float64
+	}{
+		{errors.New("ModemManager not running"), 1, 1},
+		{errors.New("ModemManager not running"), 2, 1},
+		{nil, 2, 0},
+		{errors.New("ModemManager not running"), 3, 1},
+	}
+	for i, step := range steps {
+		source.set(nil, step.err)
+		total, last := scrape()
+		if total != step.wantTotal || last != step.wantLast {
+			t.Errorf("scrape %d: errors_total = %v, last_errors = %v, want %v and %v", i+1, total, last, step.wantTotal, step.wantLast)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// Reasons reported by the signal_setup_configured metric.
+const (
+	signalSetupReasonNone         = "none"
+	signalSetupReasonUnsupported  = "unsupported"
+	signalSetupReasonAccessDenied = "access-denied"
+	signalSetupReasonError        = "error"
+)
+
+// signalSetupResult records the outcome of the last Signal.Setup call for a modem.
+type signalSetupResult struct {
+	configured bool
+	reason     string
+	rate       time.Duration // the rate passed to Setup
+}
+
+// ParseSignalRateOverride parses "<id>=<duration>", e.g. "860000000000000=1s",
+// where id is the equipment or device identifier of a modem. ModemManager
+// takes the rate in whole seconds; 0 disables polling for the modem.
+func ParseSignalRateOverride(s string) (id string, rate time.Duration, err error) {
+	id, value, ok := strings.Cut(s, "=")
+	if !ok || id == "" {
+		return "", 0, fmt.Errorf("invalid signal rate override %q, expected <id>=<duration>", s)
+	}
+	rate, err = time.ParseDuration(value)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid signal rate override %q: %w", s, err)
+	}
+	if rate < 0 || rate%time.Second != 0 {
+		return "", 0, fmt.Errorf("invalid signal rate override %q: rate must be a non-negative number of whole seconds", s)
+	}
+	return id, rate, nil
+}
+
+// WithSignalRateOverrides sets signal refresh rates for single modems, keyed
+// by equipment or device identifier, in place of the rate passed to
+// SetupSignalMonitoring.
+func WithSignalRateOverrides(overrides map[string]time.Duration) Option {
+	return func(e *Exporter) {
+		e.signalRateOverrides = overrides
+	}
+}
+
+// SetupSignalMonitoring asks ModemManager to poll each modem for extended
+// signal strength data at the given rate. The outcome per modem is kept and
+// exported as modemmanager_signal_setup_configured. The rate is applied again
+// whenever ModemManager comes back after being unreachable, and Start applies
+// it to modems that appear later. Modems matching WithSignalRateOverrides get
+// their own rate; with a rate of 0 only those modems are configured.
+func (e *Exporter) SetupSignalMonitoring(rate time.Duration) error {
+	e.mu.Lock()
+	e.signalRate = rate
+	e.signalPaths = make(map[dbus.ObjectPath]bool)
+	e.mu.Unlock()
+
+	modems, err := e.modems()
+	if err != nil {
+		return fmt.Errorf("failed to get modems: %w", err)
+	}
+
+	if len(modems) == 0 {
+		e.logger.Info("No modems found")
+		return nil
+	}
+
+	e.logger.Info("Setting up signal monitoring", "modems", len(modems))
+
+	for _, modem := range modems {
+		e.configureModemSignal(modem, rate)
+	}
+
+	return nil
+}
+
+// syncSignalSetup applies the signal refresh rate of SetupSignalMonitoring to
+// modems that appeared since, e.g. after a USB replug or mmcli --scan. Modems
+// are tracked by object path and configured once, whatever the outcome. If
+// ModemManager is unreachable every path is forgotten, as the restarted
+// daemon has forgotten the rate of every modem. A restart between two syncs
+// leaves the paths as they were; daemonReplaced forgets them on
+// NameOwnerChanged.
+func (e *Exporter) syncSignalSetup(modems []modemmanager.Modem, err error) {
+	e.mu.Lock()
+	rate := e.signalRate
+	enabled := e.signalPaths != nil
+	if enabled && err != nil {
+		e.signalPaths = make(map[dbus.ObjectPath]bool)
+	}
+	e.mu.Unlock()
+	if !enabled || err != nil {
+		return
+	}
+
+	current := make(map[dbus.ObjectPath]bool)
+	for _, modem := range modems {
+		path := modem.GetObjectPath()
+		current[path] = true
+		e.mu.Lock()
+		configured := e.signalPaths[path]
+		e.mu.Unlock()
+		if !configured {
+			e.configureModemSignal(modem, rate)
+		}
+	}
+
+	e.mu.Lock()
+	for path := range e.signalPaths {
+		if !current[path] {
+			delete(e.signalPaths, path)
+		}
+	}
+	e.mu.Unlock()
+}
+
+// configureModemSignal sets up the signal refresh rate of a modem and
+// records the outcome. A modem without a device identifier yet is left
+// unconfigured so the next sync tries again.
+func (e *Exporter) configureModemSignal(modem modemmanager.Modem, rate time.Duration) {
+	deviceID, err := e.modemLabel(modem)
+	if err != nil {
+		e.logger.Warn("Failed to get device identifier", "modem", modem.GetObjectPath(), "err", err)
+		return
+	}
+
+	rate, ok := e.signalRateFor(modem, rate)
+	if !ok {
+		e.mu.Lock()
+		e.signalPaths[modem.GetObjectPath()] = true
+		e.mu.Unlock()
+		return
+	}
+
+	model, err := modem.GetModel()
+	if err != nil {
+		model = "unknown"
+	}
+
+	e.logger.Debug("Configuring modem", "device_id", deviceID, "model", model)
+
+	result := e.setupModemSignal(modem, rate)
+	e.mu.Lock()
+	e.signalSetup[deviceID] = result
+	e.signalPaths[modem.GetObjectPath()] = true
+	e.mu.Unlock()
+
+	if result.configured {
+		e.logger.Info("Signal monitoring enabled", "device_id", deviceID, "refresh_rate", rate)
+	}
+}
+
+// signalRateFor returns the refresh rate of a modem: the override matching
+// its equipment or device identifier, else rate. ok is false if neither
+// applies, leaving the modem at the ModemManager default.
+func (e *Exporter) signalRateFor(modem modemmanager.Modem, rate time.Duration) (time.Duration, bool) {
+	if equipmentID, err := modem.GetEquipmentIdentifier(); err == nil {
+		if override, ok := e.signalRateOverrides[equipmentID]; ok {
+			return override, true
+		}
+	}
+	if deviceID, err := modem.GetDeviceIdentifier(); err == nil {
+		if override, ok := e.signalRateOverrides[deviceID]; ok {
+			return override, true
+		}
+	}
+	return rate, rate > 0
+}
+
+// setupModemSignal configures the signal refresh rate of a single modem and
+// classifies any failure.
+func (e *Exporter) setupModemSignal(modem modemmanager.Modem, rate time.Duration) signalSetupResult {
+	signal, err := modem.GetSignal()
+	if err != nil {
+		e.logger.Warn("Signal interface not available", "modem", modem.GetObjectPath(), "err", err)
+		return signalSetupResult{configured: false, reason: classifySignalSetupError(err)}
+	}
+
+	rateSeconds := uint32(rate.Seconds())
+	if err := signal.Setup(rateSeconds); err != nil {
+		e.logger.Warn("Failed to setup signal monitoring", "modem", modem.GetObjectPath(), "err", err)
+		return signalSetupResult{configured: false, reason: classifySignalSetupError(err)}
+	}
+
+	return signalSetupResult{configured: true, reason: signalSetupReasonNone, rate: rate}
+}
+
+// classifySignalSetupError maps a Signal.Setup failure to a metric reason.
+func classifySignalSetupError(err error) string {
+	var dbusErr dbus.Error
+	var dbusErrPtr *dbus.Error
+	name := ""
+	switch {
+	case errors.As(err, &dbusErr):
+		name = dbusErr.Name
+	case errors.As(err, &dbusErrPtr):
+		name = dbusErrPtr.Name
+	default:
+		return signalSetupReasonError
+	}
+
+	switch name {
+	case "org.freedesktop.DBus.Error.AccessDenied",
+		"org.freedesktop.DBus.Error.AuthFailed",
+		"org.freedesktop.DBus.Error.InteractiveAuthorizationRequired",
+		modemmanager.ErrorCorePrefix + "Unauthorized":
+		return signalSetupReasonAccessDenied
+	case "org.freedesktop.DBus.Error.UnknownMethod",
+		"org.freedesktop.DBus.Error.UnknownInterface",
+		"org.freedesktop.DBus.Error.UnknownObject",
+		"org.freedesktop.DBus.Error.NotSupported",
+		modemmanager.ErrorCorePrefix + "Unsupported":
+		return signalSetupReasonUnsupported
+	default:
+		return signalSetupReasonError
+	}
+}
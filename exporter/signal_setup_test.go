@@ -0,0 +1,239 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestClassifySignalSetupError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"access denied", dbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"}, signalSetupReasonAccessDenied},
+		{"mm unauthorized", dbus.Error{Name: "org.freedesktop.ModemManager1.Error.Core.Unauthorized"}, signalSetupReasonAccessDenied},
+		{"unknown method", dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}, signalSetupReasonUnsupported},
+		{"unknown interface", &dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownInterface"}, signalSetupReasonUnsupported},
+		{"mm unsupported", dbus.Error{Name: "org.freedesktop.ModemManager1.Error.Core.Unsupported"}, signalSetupReasonUnsupported},
+		{"wrapped", fmt.Errorf("setup: %w", dbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"}), signalSetupReasonAccessDenied},
+		{"other dbus error", dbus.Error{Name: "org.freedesktop.ModemManager1.Error.Core.Failed"}, signalSetupReasonError},
+		{"plain error", errors.New("boom"), signalSetupReasonError},
+	}
+
+	for _, tt := range tests {
+		if got := classifySignalSetupError(tt.err); got != tt.want {
+			t.Errorf("%s: classifySignalSetupError() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetupSignalMonitoring(t *testing.T) {
+	okSignal := &fakeSignal{}
+	mm := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{deviceID: "ok", signal: okSignal},
+		&fakeModem{deviceID: "denied", signal: &fakeSignal{setupErr: dbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"}}},
+		&fakeModem{deviceID: "unsupported", signal: &fakeSignal{setupErr: dbus.Error{Name: "org.freedesktop.DBus.Error.UnknownMethod"}}},
+		&fakeModem{deviceID: "error", signal: &fakeSignal{setupErr: errors.New("timeout")}},
+	}}
+
+	e := NewExporter(mm)
+	if err := e.SetupSignalMonitoring(5 * time.Second); err != nil {
+		t.Fatalf("SetupSignalMonitoring() error = %v", err)
+	}
+
+	if okSignal.setupRate != 5 {
+		t.Errorf("Setup rate = %d, want 5", okSignal.setupRate)
+	}
+
+	want := map[string]signalSetupResult{
+		"ok":          {configured: true, reason: signalSetupReasonNone, rate: 5 * time.Second},
+		"denied":      {configured: false, reason: signalSetupReasonAccessDenied},
+		"unsupported": {configured: false, reason: signalSetupReasonUnsupported},
+		"error":       {configured: false, reason: signalSetupReasonError},
+	}
+	for id, w := range want {
+		if got := e.signalSetup[id]; got != w {
+			t.Errorf("signalSetup[%q] = %+v, want %+v", id, got, w)
+		}
+	}
+}
+
+func TestSyncSignalSetupConfiguresNewModems(t *testing.T) {
+	first := &fakeSignal{}
+	source := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "first", signal: first},
+	}}
+	e := NewExporter(source)
+
+	// Nothing is configured before SetupSignalMonitoring chose a rate
+	e.syncSignalSetup(source.modems, nil)
+	if first.setupRate != 0 {
+		t.Fatal("Setup called without a rate")
+	}
+
+	if err := e.SetupSignalMonitoring(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// A hot-plugged modem is configured once, the known one is left alone
+	first.setupRate = 0
+	second := &fakeSignal{}
+	source.modems = append(source.modems,
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/1", deviceID: "second", signal: second})
+	e.syncSignalSetup(source.modems, nil)
+	if first.setupRate != 0 {
+		t.Error("Setup called again for a configured modem")
+	}
+	if second.setupRate != 5 {
+		t.Errorf("Setup rate of the new modem = %d, want 5", second.setupRate)
+	}
+	second.setupRate = 0
+	e.syncSignalSetup(source.modems, nil)
+	if second.setupRate != 0 {
+		t.Error("Setup called again on the next sync")
+	}
+	if got := e.signalSetup["second"]; !got.configured {
+		t.Errorf("signalSetup[second] = %+v, want configured", got)
+	}
+
+	// A restarted daemon may export its modems under the same paths again
+	e.syncSignalSetup(nil, errors.New("ModemManager not running"))
+	e.syncSignalSetup(source.modems, nil)
+	if first.setupRate != 5 || second.setupRate != 5 {
+		t.Errorf("Setup rates after a restart = %d, %d, want 5", first.setupRate, second.setupRate)
+	}
+}
+
+func TestParseSignalRateOverride(t *testing.T) {
+	tests := []struct {
+		in      string
+		id      string
+		rate    time.Duration
+		wantErr bool
+	}{
+		{in: "860000000000000=1s", id: "860000000000000", rate: time.Second},
+		{in: "a1b2c3=1m", id: "a1b2c3", rate: time.Minute},
+		{in: "a1b2c3=0s", id: "a1b2c3"},
+		{in: "a1b2c3", wantErr: true},
+		{in: "=5s", wantErr: true},
+		{in: "a1b2c3=fast", wantErr: true},
+		{in: "a1b2c3=-5s", wantErr: true},
+		{in: "a1b2c3=1500ms", wantErr: true},
+	}
+	for _, tt := range tests {
+		id, rate, err := ParseSignalRateOverride(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSignalRateOverride(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if id != tt.id || rate != tt.rate {
+			t.Errorf("ParseSignalRateOverride(%q) = %q, %v, want %q, %v", tt.in, id, rate, tt.id, tt.rate)
+		}
+	}
+}
+
+func TestSignalRateOverrides(t *testing.T) {
+	tracker := &fakeSignal{}
+	backup := &fakeSignal{}
+	other := &fakeSignal{}
+	modems := []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "tracker", equipmentID: "860000000000001", signal: tracker},
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/1", deviceID: "backup", signal: backup},
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/2", deviceID: "other", signal: other},
+	}
+	overrides := map[string]time.Duration{"860000000000001": time.Second, "backup": time.Minute}
+
+	e := NewExporter(&fakeSource{modems: modems}, WithSignalRateOverrides(overrides))
+	if err := e.SetupSignalMonitoring(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if tracker.setupRate != 1 || backup.setupRate != 60 || other.setupRate != 5 {
+		t.Errorf("Setup rates = %d, %d, %d, want 1, 60, 5", tracker.setupRate, backup.setupRate, other.setupRate)
+	}
+	for deviceID, want := range map[string]float64{"tracker": 1, "backup": 60, "other": 5} {
+		// Without a signal interface only the setup metrics are collected
+		metrics := gather(t, func(ch chan<- prometheus.Metric) {
+			e.collectSignalMetrics(ch, &fakeModem{deviceID: deviceID}, deviceID)
+		})
+		if m, ok := findMetric(metrics, "modemmanager_signal_refresh_rate_seconds"); !ok || m.value != want {
+			t.Errorf("refresh_rate_seconds of %s = %v (emitted %v), want %v", deviceID, m.value, ok, want)
+		}
+	}
+
+	// Without a global rate only the overridden modems are configured
+	tracker.setupRate, backup.setupRate, other.setupRate = 0, 0, 0
+	e = NewExporter(&fakeSource{modems: modems}, WithSignalRateOverrides(overrides))
+	if err := e.SetupSignalMonitoring(0); err != nil {
+		t.Fatal(err)
+	}
+	if tracker.setupRate != 1 || backup.setupRate != 60 {
+		t.Errorf("Setup rates of overridden modems = %d, %d, want 1, 60", tracker.setupRate, backup.setupRate)
+	}
+	if _, ok := e.signalSetup["other"]; ok || other.setupRate != 0 {
+		t.Error("modem without override configured with a global rate of 0")
+	}
+}
+
+func TestIsNewDaemon(t *testing.T) {
+	changed := func(name, oldOwner, newOwner string) *dbus.Signal {
+		return &dbus.Signal{Name: nameOwnerChangedSignal, Body: []interface{}{name, oldOwner, newOwner}}
+	}
+	tests := []struct {
+		sig  *dbus.Signal
+		want bool
+	}{
+		{changed(modemmanager.ModemManagerBusName, ":1.4", ":1.9"), true},
+		{changed(modemmanager.ModemManagerBusName, "", ":1.9"), true},
+		{changed(modemmanager.ModemManagerBusName, ":1.4", ""), false},
+		{changed("org.freedesktop.NetworkManager", "", ":1.9"), false},
+		{&dbus.Signal{Name: propertiesChangedSignal, Body: []interface{}{modemmanager.ModemManagerBusName, "", ":1.9"}}, false},
+	}
+	for _, tt := range tests {
+		if got := isNewDaemon(tt.sig); got != tt.want {
+			t.Errorf("isNewDaemon(%s %v) = %v, want %v", tt.sig.Name, tt.sig.Body, got, tt.want)
+		}
+	}
+}
+
+func TestSignalSetupReappliedOnNameOwnerChanged(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	signal := &fakeSignal{setups: make(chan uint32, 1)}
+	source := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "dev", signal: signal, stateChanges: make(chan *dbus.Signal)},
+	}}
+	owner := make(chan *dbus.Signal)
+	cancelled := false
+	e := NewExporter(source, WithClock(clk), WithNameOwnerChanges(func() (<-chan *dbus.Signal, func(), error) {
+		return owner, func() { cancelled = true }, nil
+	}))
+	if err := e.SetupSignalMonitoring(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	<-signal.setups
+
+	// ModemManager restarts between two resyncs and exports the modem under
+	// the same path; no call fails
+	stop := startEvents(t, e, clk)
+	owner <- &dbus.Signal{Name: nameOwnerChangedSignal, Body: []interface{}{modemmanager.ModemManagerBusName, ":1.4", ":1.9"}}
+	select {
+	case rate := <-signal.setups:
+		if rate != 5 {
+			t.Errorf("Setup rate after the restart = %d, want 5", rate)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Setup not called after the owner of the ModemManager bus name changed")
+	}
+
+	stop()
+	if !cancelled {
+		t.Error("NameOwnerChanged subscription not cancelled when Start returned")
+	}
+}
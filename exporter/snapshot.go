@@ -0,0 +1,176 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// propertiesChangedSignal is the full name of the D-Bus PropertiesChanged signal
+const propertiesChangedSignal = "org.freedesktop.DBus.Properties.PropertiesChanged"
+
+// CollectionMode selects how Collect reads the metrics of each modem.
+type CollectionMode string
+
+const (
+	// PollCollection reads every modem on each scrape.
+	PollCollection CollectionMode = "poll"
+
+	// EventCollection serves the metrics of each modem from a snapshot kept
+	// by Start. A snapshot is re-read when the modem emits PropertiesChanged
+	// and at least every refresh interval, for values ModemManager does not
+	// signal such as extended signal data and bearer statistics.
+	EventCollection CollectionMode = "events"
+)
+
+// ParseCollectionMode parses "poll" or "events".
+func ParseCollectionMode(s string) (CollectionMode, error) {
+	switch mode := CollectionMode(s); mode {
+	case PollCollection, EventCollection:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown collection mode %q, expected poll or events", s)
+	}
+}
+
+// WithCollectionMode sets how Collect reads modem metrics. EventCollection
+// only takes effect while Start runs; Collect polls otherwise.
+func WithCollectionMode(mode CollectionMode) Option {
+	return func(e *Exporter) {
+		e.collectionMode = mode
+	}
+}
+
+// WithSnapshotRefresh sets how often Start re-reads each modem in
+// EventCollection mode when no PropertiesChanged signal arrives. Zero only
+// re-reads on signals, leaving extended signal data and bearer statistics
+// at their value from the last property change.
+func WithSnapshotRefresh(interval time.Duration) Option {
+	return func(e *Exporter) {
+		e.snapshotRefresh = interval
+	}
+}
+
+// modemSnapshot holds the metrics of one modem as last read by Start
+type modemSnapshot struct {
+	modem   modemmanager.Modem
+	metrics []prometheus.Metric // nil until the first read completed
+	poll    bool                // subscription failed, Collect reads the modem itself
+}
+
+// watchModemSnapshot keeps the snapshot of a modem up to date until the
+// watch is closed. changed receives a value for every PropertiesChanged
+// signal of the modem; ok is false if the subscription failed, in which case
+// Collect polls the modem instead.
+func (e *Exporter) watchModemSnapshot(w *modemWatch, modem modemmanager.Modem, deviceID string, changed <-chan struct{}, ok bool) {
+	snapshot := &modemSnapshot{modem: modem, poll: !ok}
+	e.mu.Lock()
+	e.snapshots[deviceID] = snapshot
+	e.mu.Unlock()
+	if !ok {
+		e.logger.Warn("Subscribing to property changes failed, polling the modem on each scrape", "device_id", deviceID)
+		return
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer e.dropSnapshot(deviceID, snapshot)
+
+		var refresh <-chan time.Time
+		if e.snapshotRefresh > 0 {
+			ticker := e.clock.NewTicker(e.snapshotRefresh)
+			defer ticker.Stop()
+			refresh = ticker.C()
+		}
+		for {
+			e.refreshSnapshot(snapshot, deviceID)
+
+			// Changes arriving while reading are coalesced into one re-read
+			select {
+			case <-w.stop:
+				return
+			case <-changed:
+			case <-refresh:
+			}
+		}
+	}()
+}
+
+// refreshSnapshot reads the metrics of a modem into its snapshot
+func (e *Exporter) refreshSnapshot(snapshot *modemSnapshot, deviceID string) {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		modemCh, flush := chan<- prometheus.Metric(ch), func() {}
+		if e.timestamps {
+			modemCh, flush = timestamped(ch, e.clock.Now())
+		}
+		e.collectModemMetrics(modemCh, snapshot.modem, deviceID)
+		flush()
+	}()
+
+	metrics := []prometheus.Metric{}
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	e.mu.Lock()
+	snapshot.metrics = metrics
+	e.mu.Unlock()
+}
+
+// dropSnapshot removes the snapshot of a modem that is no longer watched,
+// unless a new watch of the same device replaced it already
+func (e *Exporter) dropSnapshot(deviceID string, snapshot *modemSnapshot) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.snapshots[deviceID] == snapshot {
+		delete(e.snapshots, deviceID)
+	}
+}
+
+// collectSnapshots emits the snapshot of every watched modem, reading modems
+// whose subscription failed directly. It returns false if Start is not
+// running in EventCollection mode, in which case nothing was emitted.
+func (e *Exporter) collectSnapshots(ch chan<- prometheus.Metric) bool {
+	e.mu.Lock()
+	if e.snapshots == nil {
+		e.mu.Unlock()
+		return false
+	}
+	deviceIDs := make([]string, 0, len(e.snapshots))
+	for deviceID := range e.snapshots {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+	snapshots := make([]modemSnapshot, len(deviceIDs))
+	for i, deviceID := range deviceIDs {
+		snapshots[i] = *e.snapshots[deviceID]
+	}
+	e.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(e.mmModems, prometheus.GaugeValue, float64(len(snapshots)))
+	seen := make(map[string]bool)
+	for i, snapshot := range snapshots {
+		deviceID := deviceIDs[i]
+		seen[deviceID] = true
+		if snapshot.poll {
+			modemCh, flush := ch, func() {}
+			if e.timestamps {
+				modemCh, flush = timestamped(ch, e.clock.Now())
+			}
+			e.collectModemMetrics(modemCh, snapshot.modem, deviceID)
+			flush()
+			continue
+		}
+		for _, m := range snapshot.metrics {
+			ch <- m
+		}
+	}
+	e.forgetMissingDevices(seen)
+	return true
+}
@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+)
+
+// liveModem is a fakeModem whose state may change while Start reads it. It
+// counts state reads to tell snapshots from polling.
+type liveModem struct {
+	*fakeModem
+	liveState  atomic.Int32
+	reads      atomic.Int32
+	properties chan *dbus.Signal // nil fails the subscription
+}
+
+func (m *liveModem) GetState() (modemmanager.MMModemState, error) {
+	m.reads.Add(1)
+	return modemmanager.MMModemState(m.liveState.Load()), nil
+}
+
+func (m *liveModem) SubscribePropertiesChanged() <-chan *dbus.Signal {
+	return m.properties
+}
+
+func propertiesChanged(path dbus.ObjectPath) *dbus.Signal {
+	return &dbus.Signal{
+		Path: path,
+		Name: propertiesChangedSignal,
+		Body: []interface{}{modemmanager.ModemInterface, map[string]dbus.Variant{}, []string{}},
+	}
+}
+
+// collectedState returns the state label of modemmanager_modem_state, or ""
+func collectedState(t *testing.T, e *Exporter) string {
+	t.Helper()
+	m, _ := findMetric(gather(t, e.Collect), "modemmanager_modem_state")
+	return m.labels["state"]
+}
+
+func TestEventCollectionServesSnapshots(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	modem := &liveModem{
+		fakeModem:  &fakeModem{path: "/Modem/0", deviceID: "dev", stateChanges: make(chan *dbus.Signal)},
+		properties: make(chan *dbus.Signal),
+	}
+	modem.liveState.Store(int32(modemmanager.MmModemStateRegistered))
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}}, WithClock(clk), WithCollectionMode(EventCollection))
+	stop := startEvents(t, e, clk)
+	waitFor(t, "first snapshot", func() bool { return collectedState(t, e) == "registered" })
+
+	// Scrapes are served without reading the modem
+	reads := modem.reads.Load()
+	collectedState(t, e)
+	collectedState(t, e)
+	if got := modem.reads.Load(); got != reads {
+		t.Errorf("scrapes read the modem state %d times in events mode, want 0", got-reads)
+	}
+
+	// A property change re-reads the modem
+	modem.liveState.Store(int32(modemmanager.MmModemStateConnected))
+	modem.properties <- propertiesChanged("/Modem/0")
+	waitFor(t, "snapshot after property change", func() bool { return collectedState(t, e) == "connected" })
+
+	// Changes of other objects are ignored
+	modem.liveState.Store(int32(modemmanager.MmModemStateDisabled))
+	reads = modem.reads.Load()
+	modem.properties <- propertiesChanged("/Modem/1")
+	flush(t, modem.properties)
+	if got := modem.reads.Load(); got != reads {
+		t.Errorf("property change of another modem re-read the modem")
+	}
+
+	// Values without signals are refreshed every interval
+	clk.BlockUntilTimers(2)
+	clk.Advance(time.Minute)
+	waitFor(t, "periodic refresh", func() bool { return collectedState(t, e) == "disabled" })
+
+	// Without Start, Collect polls again
+	stop()
+	reads = modem.reads.Load()
+	collectedState(t, e)
+	if modem.reads.Load() == reads {
+		t.Error("Collect did not read the modem after Start returned")
+	}
+}
+
+func TestEventCollectionFallsBackToPolling(t *testing.T) {
+	clk := clock.NewFake(time.Unix(0, 0))
+	modem := &liveModem{fakeModem: &fakeModem{path: "/Modem/0", deviceID: "dev", stateChanges: make(chan *dbus.Signal)}}
+	modem.liveState.Store(int32(modemmanager.MmModemStateRegistered))
+	e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}}, WithClock(clk), WithCollectionMode(EventCollection))
+	stop := startEvents(t, e, clk)
+	defer stop()
+	waitFor(t, "watch", func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.snapshots["dev"] != nil
+	})
+
+	for i := 0; i < 2; i++ {
+		reads := modem.reads.Load()
+		if state := collectedState(t, e); state != "registered" {
+			t.Errorf("state = %q, want registered", state)
+		}
+		if modem.reads.Load() == reads {
+			t.Error("modem without subscription was not polled")
+		}
+	}
+}
+
+func TestParseCollectionMode(t *testing.T) {
+	for _, s := range []string{"poll", "events"} {
+		if mode, err := ParseCollectionMode(s); err != nil || string(mode) != s {
+			t.Errorf("ParseCollectionMode(%q) = %q, %v", s, mode, err)
+		}
+	}
+	if _, err := ParseCollectionMode("push"); err == nil {
+		t.Error("ParseCollectionMode accepted push")
+	}
+}
@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/maltegrosse/go-modemmanager"
+)
+
+// ModemSource provides the modems the Exporter collects metrics for. It lets
+// programs that already track their modems embed the Exporter with a
+// filtered, cached or synthetic set of modems.
+type ModemSource interface {
+	// Modems returns the modems to export, in any order.
+	Modems() ([]modemmanager.Modem, error)
+
+	// Version returns the ModemManager version exported by modemmanager_info.
+	Version() (string, error)
+}
+
+// ModemManagerSource is a ModemSource that lists every modem known to
+// ModemManager on each call.
+type ModemManagerSource struct {
+	Manager modemmanager.ModemManager
+}
+
+// Modems implements ModemSource.
+func (s ModemManagerSource) Modems() ([]modemmanager.Modem, error) {
+	return s.Manager.GetModems()
+}
+
+// Version implements ModemSource.
+func (s ModemManagerSource) Version() (string, error) {
+	return s.Manager.GetVersion()
+}
+
+// ErrNotConnected is returned for an Exporter created without a source
+// until it gets one.
+var ErrNotConnected = errors.New("not connected to ModemManager")
+
+// disconnectedSource is the source of an Exporter created without one
+type disconnectedSource struct{}
+
+func (disconnectedSource) Modems() ([]modemmanager.Modem, error) { return nil, ErrNotConnected }
+func (disconnectedSource) Version() (string, error)              { return "", ErrNotConnected }
+
+// WithModemFilter restricts the exporter to the modems of its source for
+// which keep returns true. Metrics of other modems are not read.
+func WithModemFilter(keep func(modemmanager.Modem) bool) Option {
+	return func(e *Exporter) {
+		e.modemFilter = keep
+	}
+}
+
+// WithModemPaths restricts the exporter to the modems with the given object
+// paths.
+func WithModemPaths(paths ...dbus.ObjectPath) Option {
+	return WithModemFilter(func(modem modemmanager.Modem) bool {
+		return slices.Contains(paths, modem.GetObjectPath())
+	})
+}
+
+// modems returns the modems of the source that pass the modem filter
+func (e *Exporter) modems() ([]modemmanager.Modem, error) {
+	modems, err := e.modemSource().Modems()
+	if err != nil || e.modemFilter == nil {
+		return modems, err
+	}
+	kept := make([]modemmanager.Modem, 0, len(modems))
+	for _, modem := range modems {
+		if e.modemFilter(modem) {
+			kept = append(kept, modem)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,212 @@
+package exporter
+
+import (
+	"sort"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// deviceState holds what the exporter remembers about a modem between
+// scrapes. It is keyed by device identifier rather than object path so it
+// survives the modem being re-enumerated after a reset.
+type deviceState struct {
+	registrationState     modemmanager.MMModem3gppRegistrationState
+	registrationStateSeen bool
+	registrationDenied    uint64
+	failedSince           time.Time
+	connectedSince        time.Time
+	lastUpdated           map[string]time.Time // by sub-collector name
+	bearerConnected       map[string]bool      // by APN, nil until bearers were read
+	bearerDisconnects     uint64
+}
+
+// deviceStateLocked returns the state for deviceID, creating it on first use.
+// The caller must hold e.mu.
+func (e *Exporter) deviceStateLocked(deviceID string) *deviceState {
+	st, ok := e.devices[deviceID]
+	if !ok {
+		st = &deviceState{}
+		e.devices[deviceID] = st
+	}
+	return st
+}
+
+// observeRegistrationState records the current 3GPP registration state of a
+// modem and returns the number of transitions into the denied state seen so
+// far. A modem first observed in the denied state counts as a transition.
+func (e *Exporter) observeRegistrationState(deviceID string, state modemmanager.MMModem3gppRegistrationState) uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.deviceStateLocked(deviceID)
+	if state == modemmanager.MmModem3gppRegistrationStateDenied &&
+		(!st.registrationStateSeen || st.registrationState != modemmanager.MmModem3gppRegistrationStateDenied) {
+		st.registrationDenied++
+	}
+	st.registrationState = state
+	st.registrationStateSeen = true
+	return st.registrationDenied
+}
+
+// observeFailedState records whether a modem is currently in the failed state
+// and reports whether it has been failed continuously for longer than the
+// configured grace period, in which case its metrics are suppressed.
+func (e *Exporter) observeFailedState(deviceID string, failed bool) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.deviceStateLocked(deviceID)
+	if !failed {
+		st.failedSince = time.Time{}
+		return false
+	}
+
+	now := e.clock.Now()
+	if st.failedSince.IsZero() {
+		st.failedSince = now
+	}
+	return e.failedModemGrace > 0 && now.Sub(st.failedSince) > e.failedModemGrace
+}
+
+// observeConnectedState records whether a modem is currently connected and
+// returns the time it was first observed connected, or the zero time if it
+// is not connected.
+func (e *Exporter) observeConnectedState(deviceID string, connected bool) time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.deviceStateLocked(deviceID)
+	if !connected {
+		st.connectedSince = time.Time{}
+	} else if st.connectedSince.IsZero() {
+		st.connectedSince = e.clock.Now()
+	}
+	return st.connectedSince
+}
+
+// observeBearerConnected records the connected state of a bearer, keyed by
+// APN as ModemManager may recreate bearers under new paths, and counts a
+// disconnect on each connected to disconnected transition.
+func (e *Exporter) observeBearerConnected(deviceID, apn string, connected bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.deviceStateLocked(deviceID)
+	if st.bearerConnected == nil {
+		st.bearerConnected = make(map[string]bool)
+	}
+	if st.bearerConnected[apn] && !connected {
+		st.bearerDisconnects++
+	}
+	st.bearerConnected[apn] = connected
+}
+
+// observeBearers records the connected state of all bearers of a modem by
+// APN. A connected APN without a bearer any more counts as a disconnect, as
+// ModemManager may delete a bearer right after it disconnected.
+func (e *Exporter) observeBearers(deviceID string, connected map[string]bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.deviceStateLocked(deviceID)
+	for apn, wasConnected := range st.bearerConnected {
+		if _, ok := connected[apn]; !ok && wasConnected {
+			st.bearerDisconnects++
+		}
+	}
+	for apn, isConnected := range connected {
+		if st.bearerConnected[apn] && !isConnected {
+			st.bearerDisconnects++
+		}
+	}
+	st.bearerConnected = connected
+}
+
+// collectBearerDisconnects emits the disconnect counter of every modem whose
+// bearers were read. Like the update times it is kept outside snapshots, as
+// bearer property signals advance it between snapshot refreshes.
+func (e *Exporter) collectBearerDisconnects(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	deviceIDs := make([]string, 0, len(e.devices))
+	for deviceID, st := range e.devices {
+		if st.bearerConnected != nil {
+			deviceIDs = append(deviceIDs, deviceID)
+		}
+	}
+	sort.Strings(deviceIDs)
+	for _, deviceID := range deviceIDs {
+		ch <- prometheus.MustNewConstMetric(e.bearerDisconnects, prometheus.CounterValue, float64(e.devices[deviceID].bearerDisconnects), deviceID)
+	}
+}
+
+// observeCollected records that a sub-collector read a modem successfully.
+func (e *Exporter) observeCollected(deviceID, collector string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.deviceStateLocked(deviceID)
+	if st.lastUpdated == nil {
+		st.lastUpdated = make(map[string]time.Time)
+	}
+	st.lastUpdated[collector] = e.clock.Now()
+}
+
+// observeCollectDuration records how long reading the metrics of a modem
+// took. The state is created so forgetMissingDevices drops the histogram of
+// modems that are gone.
+func (e *Exporter) observeCollectDuration(deviceID string, d time.Duration) {
+	e.mu.Lock()
+	e.deviceStateLocked(deviceID)
+	e.mu.Unlock()
+	e.modemDurations.WithLabelValues(deviceID).Observe(d.Seconds())
+}
+
+// collectLastUpdated emits when each sub-collector last read each modem
+// successfully. The times are kept outside snapshots so they are exported on
+// every scrape in both collection modes.
+func (e *Exporter) collectLastUpdated(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	deviceIDs := make([]string, 0, len(e.devices))
+	for deviceID := range e.devices {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+	for _, deviceID := range deviceIDs {
+		lastUpdated := e.devices[deviceID].lastUpdated
+		collectors := make([]string, 0, len(lastUpdated))
+		for collector := range lastUpdated {
+			collectors = append(collectors, collector)
+		}
+		sort.Strings(collectors)
+		for _, collector := range collectors {
+			ch <- prometheus.MustNewConstMetric(e.modemLastUpdated, prometheus.GaugeValue,
+				float64(lastUpdated[collector].UnixNano())/1e9, deviceID, collector)
+		}
+	}
+}
+
+// forgetMissingDevices drops the state of modems that were not seen in the
+// last scrape. Counters are kept so they survive a modem being briefly gone
+// during a reset, only the transient state is cleared.
+func (e *Exporter) forgetMissingDevices(seen map[string]bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for deviceID, st := range e.devices {
+		if seen[deviceID] {
+			continue
+		}
+		e.modemDurations.DeleteLabelValues(deviceID)
+		if st.registrationDenied == 0 && st.bearerDisconnects == 0 {
+			delete(e.devices, deviceID)
+			continue
+		}
+		*st = deviceState{registrationDenied: st.registrationDenied, bearerDisconnects: st.bearerDisconnects}
+	}
+}
@@ -0,0 +1,173 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestObserveRegistrationStateCountsDeniedTransitions(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+
+	steps := []struct {
+		deviceID string
+		state    modemmanager.MMModem3gppRegistrationState
+		want     uint64
+	}{
+		{"a", modemmanager.MmModem3gppRegistrationStateHome, 0},
+		{"a", modemmanager.MmModem3gppRegistrationStateDenied, 1},
+		{"a", modemmanager.MmModem3gppRegistrationStateDenied, 1},
+		{"a", modemmanager.MmModem3gppRegistrationStateSearching, 1},
+		{"a", modemmanager.MmModem3gppRegistrationStateDenied, 2},
+		{"b", modemmanager.MmModem3gppRegistrationStateDenied, 1},
+		// Same device_id after a reset keeps its count
+		{"a", modemmanager.MmModem3gppRegistrationStateIdle, 2},
+		{"a", modemmanager.MmModem3gppRegistrationStateDenied, 3},
+	}
+
+	for i, step := range steps {
+		if got := e.observeRegistrationState(step.deviceID, step.state); got != step.want {
+			t.Errorf("step %d: observeRegistrationState(%q, %v) = %d, want %d", i, step.deviceID, step.state, got, step.want)
+		}
+	}
+}
+
+func TestConnectedSinceTracksConnection(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	modem := &fakeModem{deviceID: "dev", state: modemmanager.MmModemStateConnected}
+	mm := &fakeSource{modems: []modemmanager.Modem{modem}}
+	e := NewExporter(mm, WithClock(clk))
+
+	connectedSince := func() (float64, bool) {
+		m, ok := findMetric(gather(t, e.Collect), "modemmanager_modem_connected_since_timestamp_seconds")
+		return m.value, ok
+	}
+
+	if v, ok := connectedSince(); !ok || v != float64(start.Unix()) {
+		t.Fatalf("connected_since = %v (emitted %v), want %d", v, ok, start.Unix())
+	}
+
+	clk.Advance(time.Hour)
+	if v, ok := connectedSince(); !ok || v != float64(start.Unix()) {
+		t.Errorf("connected_since after an hour = %v (emitted %v), want unchanged %d", v, ok, start.Unix())
+	}
+
+	modem.state = modemmanager.MmModemStateRegistered
+	if _, ok := connectedSince(); ok {
+		t.Error("connected_since emitted while not connected")
+	}
+
+	clk.Advance(time.Minute)
+	modem.state = modemmanager.MmModemStateConnected
+	if v, ok := connectedSince(); !ok || v != float64(start.Add(time.Hour+time.Minute).Unix()) {
+		t.Errorf("connected_since after reconnect = %v (emitted %v), want reset", v, ok)
+	}
+}
+
+func TestForgetMissingDevices(t *testing.T) {
+	modem := &fakeModem{deviceID: "dev", state: modemmanager.MmModemStateConnected}
+	mm := &fakeSource{modems: []modemmanager.Modem{modem}}
+	e := NewExporter(mm)
+	e.observeRegistrationState("denied", modemmanager.MmModem3gppRegistrationStateDenied)
+
+	gather(t, e.Collect)
+	if _, ok := e.devices["dev"]; !ok {
+		t.Fatal("no state kept for connected modem")
+	}
+
+	mm.modems = nil
+	gather(t, e.Collect)
+	if _, ok := e.devices["dev"]; ok {
+		t.Error("state of vanished modem was not cleaned up")
+	}
+	if st, ok := e.devices["denied"]; !ok || st.registrationDenied != 1 {
+		t.Error("denied counter of vanished modem was dropped")
+	}
+}
+
+func TestLastUpdatedTimestamps(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	clk := clock.NewFake(start)
+	modem := &fakeModem{deviceID: "dev", state: modemmanager.MmModemStateRegistered}
+	mm := &fakeSource{modems: []modemmanager.Modem{modem}}
+	e := NewExporter(mm, WithClock(clk))
+
+	lastUpdated := func() map[string]float64 {
+		values := make(map[string]float64)
+		for _, m := range gather(t, e.Collect) {
+			if m.name == "modemmanager_modem_last_updated_timestamp_seconds" && m.labels["device_id"] == "dev" {
+				values[m.labels["collector"]] = m.value
+			}
+		}
+		return values
+	}
+
+	if got := lastUpdated(); got["state"] != float64(start.Unix()) || got["modes"] != float64(start.Unix()) {
+		t.Fatalf("last updated = %v, want state and modes at %d", got, start.Unix())
+	}
+
+	// A failing sub-collector keeps the time of its last success
+	clk.Advance(5 * time.Minute)
+	modem.modesErr = errors.New("modes unavailable")
+	got := lastUpdated()
+	if want := float64(start.Add(5 * time.Minute).Unix()); got["state"] != want {
+		t.Errorf("state last updated = %v, want %v", got["state"], want)
+	}
+	if want := float64(start.Unix()); got["modes"] != want {
+		t.Errorf("modes last updated = %v, want unchanged %v", got["modes"], want)
+	}
+	if _, ok := got["voice"]; ok {
+		t.Error("last updated emitted for a sub-collector that never succeeded")
+	}
+
+	mm.modems = nil
+	if got := lastUpdated(); len(got) != 0 {
+		t.Errorf("last updated of vanished modem = %v, want none", got)
+	}
+}
+
+func TestCollectDurationHistograms(t *testing.T) {
+	source := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "a"},
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/1", deviceID: "b"},
+	}}
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(NewExporter(source))
+
+	// counts returns the sample count of the histograms by device_id, with
+	// "" for the scrape histogram
+	counts := func() map[string]uint64 {
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string]uint64)
+		for _, family := range families {
+			switch family.GetName() {
+			case "modemmanager_scrape_collect_duration_seconds":
+				out[""] = family.GetMetric()[0].GetHistogram().GetSampleCount()
+			case "modemmanager_modem_collect_duration_seconds":
+				for _, m := range family.GetMetric() {
+					out[m.GetLabel()[0].GetValue()] = m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+		return out
+	}
+
+	counts()
+	if got := counts(); got[""] != 2 || got["a"] != 2 || got["b"] != 2 {
+		t.Errorf("sample counts after two scrapes = %v, want 2 each", got)
+	}
+
+	// The histogram of a vanished modem is dropped
+	source.modems = source.modems[:1]
+	if got := counts(); got[""] != 3 || got["a"] != 3 || len(got) != 2 {
+		t.Errorf("sample counts after b vanished = %v, want 3 for the scrape and a only", got)
+	}
+}
@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFailedModemSuppressedAfterGrace(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := NewExporter(&fakeSource{}, WithFailedModemGrace(24*time.Hour), WithClock(clk))
+
+	modem := &fakeModem{
+		deviceID:     "dev",
+		state:        modemmanager.MmModemStateFailed,
+		failedReason: modemmanager.MmModemStateFailedReasonSimMissing,
+	}
+	collect := func() []collectedMetric {
+		return gather(t, func(ch chan<- prometheus.Metric) {
+			e.collectModemMetrics(ch, modem, "dev")
+		})
+	}
+
+	// Within the grace period the full metric set is collected
+	metrics := collect()
+	if m, ok := findMetric(metrics, "modemmanager_modem_suppressed"); !ok || m.value != 0 {
+		t.Errorf("suppressed = %v (emitted %v), want 0", m.value, ok)
+	}
+	if _, ok := findMetric(metrics, "modemmanager_modem_power_state"); !ok {
+		t.Error("power_state missing before grace period elapsed")
+	}
+
+	clk.Advance(24*time.Hour + time.Second)
+	metrics = collect()
+	if m, ok := findMetric(metrics, "modemmanager_modem_suppressed"); !ok || m.value != 1 {
+		t.Errorf("suppressed = %v (emitted %v), want 1", m.value, ok)
+	}
+	if m, ok := findMetric(metrics, "modemmanager_modem_failed_reason"); !ok || m.labels["reason"] != "sim_missing" {
+		t.Errorf("failed_reason = %v (emitted %v), want sim_missing", m.labels, ok)
+	}
+	allowed := map[string]bool{
+		"modemmanager_modem_info":               true,
+		"modemmanager_modem_max_bearers":        true,
+		"modemmanager_modem_max_active_bearers": true,
+		"modemmanager_modem_state":              true,
+		"modemmanager_modem_failed_reason":      true,
+		"modemmanager_modem_suppressed":         true,
+	}
+	for _, m := range metrics {
+		if !allowed[m.name] {
+			t.Errorf("%s emitted for suppressed modem", m.name)
+		}
+	}
+
+	// Leaving the failed state lifts the suppression immediately
+	modem.state = modemmanager.MmModemStateEnabled
+	metrics = collect()
+	if m, ok := findMetric(metrics, "modemmanager_modem_suppressed"); !ok || m.value != 0 {
+		t.Errorf("suppressed = %v (emitted %v), want 0 after recovery", m.value, ok)
+	}
+	if _, ok := findMetric(metrics, "modemmanager_modem_power_state"); !ok {
+		t.Error("power_state missing after recovery")
+	}
+}
+
+func TestFailedReasonToString(t *testing.T) {
+	seen := make(map[string]bool)
+	for r := modemmanager.MmModemStateFailedReasonNone; r <= modemmanager.MmModemStateFailedReasonEsimWithoutProfiles; r++ {
+		s := failedReasonToString(r)
+		if r != modemmanager.MmModemStateFailedReasonUnknown && s == "unknown" {
+			t.Errorf("failedReasonToString(%v) has no mapping", r)
+		}
+		if seen[s] {
+			t.Errorf("failedReasonToString(%v) = %q is not unique", r, s)
+		}
+		seen[s] = true
+	}
+}
+
+func TestFailedReasonStrings(t *testing.T) {
+	want := map[modemmanager.MMModemStateFailedReason]string{
+		modemmanager.MmModemStateFailedReasonNone:                "none",
+		modemmanager.MmModemStateFailedReasonUnknown:             "unknown",
+		modemmanager.MmModemStateFailedReasonSimMissing:          "sim_missing",
+		modemmanager.MmModemStateFailedReasonSimError:            "sim_error",
+		modemmanager.MmModemStateFailedReasonUnknownCapabilities: "unknown_capabilities",
+		modemmanager.MmModemStateFailedReasonEsimWithoutProfiles: "esim_without_profiles",
+	}
+	for r, s := range want {
+		if got := failedReasonToString(r); got != s {
+			t.Errorf("failedReasonToString(%v) = %q, want %q", r, got, s)
+		}
+	}
+}
+
+func TestFailedReasonOnlyWhileFailed(t *testing.T) {
+	modem := &fakeModem{
+		deviceID:     "dev",
+		state:        modemmanager.MmModemStateFailed,
+		failedReason: modemmanager.MmModemStateFailedReasonSimError,
+	}
+	e := NewExporter(&fakeSource{})
+	collect := func() []collectedMetric {
+		return gather(t, func(ch chan<- prometheus.Metric) {
+			e.collectModemMetrics(ch, modem, "dev")
+		})
+	}
+
+	if m, ok := findMetric(collect(), "modemmanager_modem_failed_reason"); !ok || m.labels["reason"] != "sim_error" {
+		t.Errorf("failed_reason = %v (emitted %v), want sim_error", m.labels, ok)
+	}
+
+	modem.state = modemmanager.MmModemStateRegistered
+	if m, ok := findMetric(collect(), "modemmanager_modem_failed_reason"); ok {
+		t.Errorf("failed_reason = %v still emitted after recovery", m.labels)
+	}
+}
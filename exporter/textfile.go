@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// WriteTextfile writes metric families in the text exposition format to path,
+// e.g. for the node_exporter textfile collector. The file is written to a
+// temporary file in the same directory first and renamed over path, so
+// readers never see a partial file.
+func WriteTextfile(path string, families []*dto.MetricFamily) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	encoder := expfmt.NewEncoder(tmp, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", family.GetName(), err)
+		}
+	}
+	// CreateTemp creates the file readable by the owner only
+	if err := tmp.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ScrapeFailed reports whether gathered metrics of an Exporter record errors
+// of the scrape, as counted by modemmanager_scrape_last_errors.
+func ScrapeFailed(families []*dto.MetricFamily) bool {
+	for _, family := range families {
+		switch family.GetName() {
+		case namespace + "_scrape_success":
+			for _, m := range family.GetMetric() {
+				if m.GetGauge().GetValue() == 0 {
+					return true
+				}
+			}
+		case namespace + "_scrape_last_errors":
+			for _, m := range family.GetMetric() {
+				if m.GetGauge().GetValue() > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
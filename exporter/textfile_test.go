@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWriteTextfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "modemmanager.prom")
+	if err := os.WriteFile(path, []byte("stale\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "dev", state: modemmanager.MmModemStateRegistered},
+	}}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewExporter(source))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ScrapeFailed(families) {
+		t.Error("ScrapeFailed reported a successful scrape as failed")
+	}
+
+	if err := WriteTextfile(path, families); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# TYPE modemmanager_modem_state gauge", `modemmanager_modem_state{device_id="dev",state="registered"} 1`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("%q missing from\n%s", want, content)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("file mode = %v, %v, want 0644", info.Mode().Perm(), err)
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want 1", len(entries))
+	}
+}
+
+func TestWriteTextfileMissingDirectory(t *testing.T) {
+	if err := WriteTextfile(filepath.Join(t.TempDir(), "missing", "modemmanager.prom"), nil); err == nil {
+		t.Error("WriteTextfile succeeded without a directory")
+	}
+}
+
+func TestScrapeFailed(t *testing.T) {
+	gatherFrom := func(source *fakeSource) bool {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(NewExporter(source))
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ScrapeFailed(families)
+	}
+
+	if !gatherFrom(&fakeSource{err: errors.New("ModemManager not running")}) {
+		t.Error("failed listing not reported")
+	}
+	if !gatherFrom(&fakeSource{versionErr: errors.New("no version")}) {
+		t.Error("failed version read not reported")
+	}
+	if gatherFrom(&fakeSource{}) {
+		t.Error("scrape without modems reported as failed")
+	}
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTimeMetrics(t *testing.T) {
+	local := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := NewExporter(&fakeSource{}, WithClock(clock.NewFake(local)))
+	modem := &fakeModem{deviceID: "dev", timeIface: &fakeTime{
+		// Network is 90s ahead of the host, reported in CET
+		networkTime: local.Add(90 * time.Second).In(time.FixedZone("CET", 3600)),
+		timezone:    modemmanager.ModemTimeZone{Offset: 60},
+	}}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectTimeMetrics(ch, modem, "dev")
+	})
+
+	if m, ok := findMetric(metrics, "modemmanager_time_network_offset_seconds"); !ok || m.value != 90 {
+		t.Errorf("network_offset_seconds = %v (emitted %v), want 90", m.value, ok)
+	}
+	if m, ok := findMetric(metrics, "modemmanager_time_timezone_offset_minutes"); !ok || m.value != 60 {
+		t.Errorf("timezone_offset_minutes = %v (emitted %v), want 60", m.value, ok)
+	}
+}
+
+func TestTimeMetricsUnknownNetworkTime(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", timeIface: &fakeTime{
+		timeErr:     errors.New("network time is unknown"),
+		timezoneErr: errors.New("no timezone"),
+	}}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectTimeMetrics(ch, modem, "dev")
+	})
+	if len(metrics) != 0 {
+		t.Errorf("got %d metrics without network time, want none", len(metrics))
+	}
+}
+
+func TestTimeMetricsWithoutInterface(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectTimeMetrics(ch, &fakeModem{deviceID: "dev"}, "dev")
+	})
+	if len(metrics) != 0 {
+		t.Errorf("got %d metrics for a modem without time interface, want none", len(metrics))
+	}
+}
@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+)
+
+func TestTimestampsOnlyWhenEnabled(t *testing.T) {
+	readAt := time.Unix(1700000000, 0)
+	source := &fakeSource{modems: []modemmanager.Modem{
+		&fakeModem{path: "/org/freedesktop/ModemManager1/Modem/0", deviceID: "dev", state: modemmanager.MmModemStateRegistered},
+	}}
+
+	for _, enabled := range []bool{false, true} {
+		e := NewExporter(source, WithClock(clock.NewFake(readAt)), WithTimestamps(enabled))
+		metrics := gather(t, e.Collect)
+
+		stamped := 0
+		for _, m := range metrics {
+			if m.tsMs == 0 {
+				continue
+			}
+			stamped++
+			if m.labels["device_id"] != "dev" {
+				t.Errorf("%s has a timestamp but is not a modem metric", m.name)
+			}
+			if m.tsMs != readAt.UnixMilli() {
+				t.Errorf("%s timestamp = %d, want %d", m.name, m.tsMs, readAt.UnixMilli())
+			}
+		}
+
+		switch {
+		case !enabled && stamped > 0:
+			t.Errorf("%d metrics have a timestamp with timestamps disabled", stamped)
+		case enabled && stamped == 0:
+			t.Error("no metric has a timestamp with timestamps enabled")
+		}
+		if info, ok := findMetric(metrics, "modemmanager_modem_info"); enabled && (!ok || info.tsMs == 0) {
+			t.Errorf("modem_info not timestamped (emitted %v)", ok)
+		}
+		if success, ok := findMetric(metrics, "modemmanager_scrape_success"); !ok || success.tsMs != 0 {
+			t.Errorf("scrape_success timestamp = %d (emitted %v), want none", success.tsMs, ok)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestUssdSessionStateToString(t *testing.T) {
+	tests := map[modemmanager.MMModem3gppUssdSessionState]string{
+		modemmanager.MmModem3gppUssdSessionStateUnknown:      "unknown",
+		modemmanager.MmModem3gppUssdSessionStateIdle:         "idle",
+		modemmanager.MmModem3gppUssdSessionStateActive:       "active",
+		modemmanager.MmModem3gppUssdSessionStateUserResponse: "user-response",
+		modemmanager.MMModem3gppUssdSessionState(42):         "unknown",
+	}
+	for state, want := range tests {
+		if got := ussdSessionStateToString(state); got != want {
+			t.Errorf("ussdSessionStateToString(%d) = %q, want %q", state, got, want)
+		}
+	}
+}
+
+func TestUssdMetrics(t *testing.T) {
+	tests := []struct {
+		name  string
+		ussd  modemmanager.Ussd
+		state string
+	}{
+		{name: "waiting for the user", ussd: &fakeUssd{state: modemmanager.MmModem3gppUssdSessionStateUserResponse}, state: "user-response"},
+		{name: "idle", ussd: &fakeUssd{state: modemmanager.MmModem3gppUssdSessionStateIdle}, state: "idle"},
+		{name: "unreadable state", ussd: &fakeUssd{err: errNotSupported}},
+		{name: "no USSD interface"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modem := &fakeModem{deviceID: "dev", modem3gpp: &fake3gpp{ussd: tt.ussd}}
+			e := NewExporter(&fakeSource{modems: []modemmanager.Modem{modem}})
+			metrics := gather(t, func(ch chan<- prometheus.Metric) {
+				e.collect3GPPMetrics(ch, modem, "dev")
+			})
+
+			m, ok := findMetric(metrics, "modemmanager_ussd_session_state")
+			if tt.state == "" && ok {
+				t.Errorf("session_state emitted as %q", m.labels["state"])
+			}
+			if tt.state != "" && (!ok || m.labels["state"] != tt.state || m.value != 1) {
+				t.Errorf("session_state = %v %v (emitted %v), want state %s", m.labels, m.value, ok, tt.state)
+			}
+		})
+	}
+}
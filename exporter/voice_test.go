@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/maltegrosse/go-modemmanager"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestVoiceMetrics(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", voice: &fakeVoice{
+		emergencyOnly: true,
+		calls: []modemmanager.Call{
+			&fakeCall{state: modemmanager.MmCallStateActive, direction: modemmanager.MmCallDirectionOutgoing},
+			&fakeCall{state: modemmanager.MmCallStateRingingIn, direction: modemmanager.MmCallDirectionIncoming},
+			&fakeCall{state: modemmanager.MmCallStateRingingIn, direction: modemmanager.MmCallDirectionIncoming},
+			&fakeCall{state: modemmanager.MmCallStateTerminated, direction: modemmanager.MmCallDirectionIncoming},
+		},
+	}}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectVoiceMetrics(ch, modem, "dev")
+	})
+
+	if m, ok := findMetric(metrics, "modemmanager_voice_emergency_only"); !ok || m.value != 1 {
+		t.Errorf("emergency_only = %v (emitted %v), want 1", m.value, ok)
+	}
+
+	got := make(map[string]float64)
+	for _, m := range metrics {
+		if m.name == "modemmanager_voice_calls" {
+			got[m.labels["state"]+"/"+m.labels["direction"]] = m.value
+		}
+	}
+	want := map[string]float64{
+		"active/outgoing":     1,
+		"ringing-in/incoming": 2,
+		"terminated/incoming": 1,
+	}
+	if len(got) != len(want) {
+		t.Errorf("voice_calls series = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("voice_calls{%s} = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestVoiceMetricsNoCalls(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	modem := &fakeModem{deviceID: "dev", voice: &fakeVoice{}}
+
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectVoiceMetrics(ch, modem, "dev")
+	})
+	if m, ok := findMetric(metrics, "modemmanager_voice_emergency_only"); !ok || m.value != 0 {
+		t.Errorf("emergency_only = %v (emitted %v), want 0", m.value, ok)
+	}
+	if _, ok := findMetric(metrics, "modemmanager_voice_calls"); ok {
+		t.Error("voice_calls emitted without calls")
+	}
+}
+
+func TestVoiceMetricsWithoutInterface(t *testing.T) {
+	e := NewExporter(&fakeSource{})
+	metrics := gather(t, func(ch chan<- prometheus.Metric) {
+		e.collectVoiceMetrics(ch, &fakeModem{deviceID: "dev"}, "dev")
+	})
+	if len(metrics) != 0 {
+		t.Errorf("got %d metrics for a data-only modem, want none", len(metrics))
+	}
+}
@@ -0,0 +1,70 @@
+// Package buildinfo reports the version of the binaries from the module and
+// VCS information the go command embeds, unless overridden with -ldflags:
+//
+//	go build -ldflags "-X github.com/maltegrosse/go-modemmanager/internal/buildinfo.Version=v1.2.3"
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X to override the embedded information, e.g. in
+// packaging that builds from a tarball without VCS information
+var (
+	Version  string
+	Revision string
+)
+
+// Info describes a binary
+type Info struct {
+	Version   string // module version, "(devel)" if not built from a tagged module
+	Revision  string // VCS revision, "unknown" if not embedded
+	Dirty     bool   // built from a tree with uncommitted changes
+	GoVersion string
+}
+
+// Get returns the information of the running binary
+func Get() Info {
+	bi, ok := debug.ReadBuildInfo()
+	return fromBuildInfo(bi, ok)
+}
+
+func fromBuildInfo(bi *debug.BuildInfo, ok bool) Info {
+	info := Info{Version: "(devel)", Revision: "unknown", GoVersion: runtime.Version()}
+	if ok {
+		if bi.Main.Version != "" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Revision = setting.Value
+			case "vcs.modified":
+				info.Dirty = setting.Value == "true"
+			}
+		}
+	}
+
+	if Version != "" {
+		info.Version = Version
+	}
+	if Revision != "" {
+		// The embedded dirty flag belongs to the embedded revision
+		info.Revision, info.Dirty = Revision, false
+	}
+	return info
+}
+
+// String returns e.g. "v1.2.3 (revision 0123abcd, dirty, go1.23.4)"
+func (i Info) String() string {
+	revision := i.Revision
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if i.Dirty {
+		revision += ", dirty"
+	}
+	return fmt.Sprintf("%s (revision %s, %s)", i.Version, revision, i.GoVersion)
+}
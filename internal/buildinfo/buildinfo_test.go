@@ -0,0 +1,67 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	embedded := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123456789abcdef01234567"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	goVersion := runtime.Version()
+
+	tests := []struct {
+		name              string
+		bi                *debug.BuildInfo
+		ok                bool
+		version, revision string
+		want              Info
+		wantString        string
+	}{
+		{
+			name:       "embedded",
+			bi:         embedded,
+			ok:         true,
+			want:       Info{Version: "v1.2.3", Revision: "0123456789abcdef0123456789abcdef01234567", Dirty: true, GoVersion: goVersion},
+			wantString: "v1.2.3 (revision 0123456789ab, dirty, " + goVersion + ")",
+		},
+		{
+			name:       "no build info",
+			want:       Info{Version: "(devel)", Revision: "unknown", GoVersion: goVersion},
+			wantString: "(devel) (revision unknown, " + goVersion + ")",
+		},
+		{
+			name:     "ldflags",
+			bi:       embedded,
+			ok:       true,
+			version:  "1.0.0",
+			revision: "abc123",
+			want:     Info{Version: "1.0.0", Revision: "abc123", GoVersion: goVersion},
+		},
+		{
+			name:    "ldflags version only",
+			bi:      embedded,
+			ok:      true,
+			version: "1.0.0",
+			want:    Info{Version: "1.0.0", Revision: "0123456789abcdef0123456789abcdef01234567", Dirty: true, GoVersion: goVersion},
+		},
+	}
+
+	defer func(version, revision string) { Version, Revision = version, revision }(Version, Revision)
+	for _, tt := range tests {
+		Version, Revision = tt.version, tt.revision
+		got := fromBuildInfo(tt.bi, tt.ok)
+		if got != tt.want {
+			t.Errorf("%s: fromBuildInfo() = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if tt.wantString != "" && got.String() != tt.wantString {
+			t.Errorf("%s: String() = %q, want %q", tt.name, got.String(), tt.wantString)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when Advance is called.
+type Fake struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a pending After call or an active ticker.
+type fakeTimer struct {
+	deadline time.Time
+	period   time.Duration // zero for one-shot timers
+	ch       chan time.Time
+}
+
+// NewFake returns a Fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{deadline: f.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- f.now
+		return t.ch
+	}
+	f.addTimerLocked(t)
+	return t.ch
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{deadline: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.addTimerLocked(t)
+	return &fakeTicker{clock: f, timer: t}
+}
+
+// Advance moves the clock forward by d and fires every timer and ticker
+// that became due, in deadline order.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	end := f.now.Add(d)
+	for {
+		next := f.nextDueLocked(end)
+		if next == nil {
+			break
+		}
+		f.now = next.deadline
+		// Like time.Ticker, drop ticks for slow receivers
+		select {
+		case next.ch <- f.now:
+		default:
+		}
+		if next.period > 0 {
+			next.deadline = next.deadline.Add(next.period)
+		} else {
+			f.removeTimerLocked(next)
+		}
+	}
+	f.now = end
+}
+
+// BlockUntilTimers blocks until at least n timers or tickers are pending,
+// so a test can advance the clock only once the code under test waits on it.
+func (f *Fake) BlockUntilTimers(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
+
+func (f *Fake) addTimerLocked(t *fakeTimer) {
+	f.timers = append(f.timers, t)
+	f.cond.Broadcast()
+}
+
+func (f *Fake) removeTimerLocked(t *fakeTimer) {
+	for i, x := range f.timers {
+		if x == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+// nextDueLocked returns the timer with the earliest deadline not after end.
+func (f *Fake) nextDueLocked(end time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, t := range f.timers {
+		if t.deadline.After(end) {
+			continue
+		}
+		if next == nil || t.deadline.Before(next.deadline) {
+			next = t
+		}
+	}
+	return next
+}
+
+type fakeTicker struct {
+	clock *Fake
+	timer *fakeTimer
+}
+
+func (ft *fakeTicker) C() <-chan time.Time {
+	return ft.timer.ch
+}
+
+func (ft *fakeTicker) Stop() {
+	ft.clock.mu.Lock()
+	defer ft.clock.mu.Unlock()
+	ft.clock.removeTimerLocked(ft.timer)
+}
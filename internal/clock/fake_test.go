@@ -0,0 +1,105 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeNowAndAdvance(t *testing.T) {
+	f := NewFake(epoch)
+	if !f.Now().Equal(epoch) {
+		t.Fatalf("Now() = %v, want %v", f.Now(), epoch)
+	}
+	f.Advance(90 * time.Second)
+	if want := epoch.Add(90 * time.Second); !f.Now().Equal(want) {
+		t.Errorf("Now() = %v, want %v", f.Now(), want)
+	}
+}
+
+func TestFakeAfter(t *testing.T) {
+	f := NewFake(epoch)
+	ch := f.After(2 * time.Second)
+
+	f.Advance(time.Second)
+	select {
+	case <-ch:
+		t.Fatal("After fired early")
+	default:
+	}
+
+	f.Advance(time.Second)
+	select {
+	case got := <-ch:
+		if want := epoch.Add(2 * time.Second); !got.Equal(want) {
+			t.Errorf("After sent %v, want %v", got, want)
+		}
+	default:
+		t.Fatal("After did not fire")
+	}
+}
+
+func TestFakeAfterNonPositive(t *testing.T) {
+	f := NewFake(epoch)
+	select {
+	case <-f.After(0):
+	default:
+		t.Fatal("After(0) did not fire immediately")
+	}
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(epoch)
+	ticker := f.NewTicker(time.Second)
+
+	var ticks []time.Time
+	for i := 0; i < 3; i++ {
+		f.Advance(time.Second)
+		select {
+		case tick := <-ticker.C():
+			ticks = append(ticks, tick)
+		default:
+			t.Fatalf("tick %d missing", i)
+		}
+	}
+	if want := epoch.Add(3 * time.Second); !ticks[2].Equal(want) {
+		t.Errorf("third tick = %v, want %v", ticks[2], want)
+	}
+
+	// Slow receivers lose ticks, like time.Ticker
+	f.Advance(5 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Error("ticker buffered more than one tick")
+	default:
+	}
+
+	ticker.Stop()
+	f.Advance(time.Second)
+	select {
+	case <-ticker.C():
+		t.Error("stopped ticker fired")
+	default:
+	}
+}
+
+func TestFakeBlockUntilTimers(t *testing.T) {
+	f := NewFake(epoch)
+	done := make(chan struct{})
+
+	go func() {
+		<-f.After(time.Minute)
+		close(done)
+	}()
+
+	f.BlockUntilTimers(1)
+	f.Advance(time.Minute)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waiter not released after Advance")
+	}
+}
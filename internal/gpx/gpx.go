@@ -0,0 +1,160 @@
+// Package gpx writes GPS tracks as GPX 1.1 documents, as read by mapping and
+// drive test tools.
+package gpx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Namespace is the XML namespace of GPX 1.1
+const Namespace = "http://www.topografix.com/GPX/1/1"
+
+// Point is a track point
+type Point struct {
+	Latitude  float64   // decimal degrees, -90 to 90
+	Longitude float64   // decimal degrees, -180 to 180 (180 is written as -180)
+	Elevation *float64  // meters above sea level, nil if unknown
+	Time      time.Time // zero if unknown
+}
+
+// Track is a single segment track that points are added to
+type Track struct {
+	creator string
+	name    string
+	points  []Point
+}
+
+// NewTrack returns an empty track. creator names the writing program, as
+// required by GPX, and name is the optional track name.
+func NewTrack(creator, name string) *Track {
+	return &Track{creator: creator, name: name}
+}
+
+// Add appends p to the track
+func (t *Track) Add(p Point) error {
+	if p.Latitude < -90 || p.Latitude > 90 {
+		return fmt.Errorf("latitude %v out of range", p.Latitude)
+	}
+	if p.Longitude < -180 || p.Longitude > 180 {
+		return fmt.Errorf("longitude %v out of range", p.Longitude)
+	}
+	// The schema allows -180 but not 180
+	if p.Longitude == 180 {
+		p.Longitude = -180
+	}
+	t.points = append(t.points, p)
+	return nil
+}
+
+// Len returns the number of points
+func (t *Track) Len() int {
+	return len(t.points)
+}
+
+// The document structure, in the element order the schema requires
+type document struct {
+	XMLName xml.Name `xml:"gpx"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	Track   track    `xml:"trk"`
+}
+
+type track struct {
+	Name     string    `xml:"name,omitempty"`
+	Segments []segment `xml:"trkseg"`
+}
+
+type segment struct {
+	Points []point `xml:"trkpt"`
+}
+
+type point struct {
+	Latitude  string  `xml:"lat,attr"`
+	Longitude string  `xml:"lon,attr"`
+	Elevation *string `xml:"ele"`
+	Time      string  `xml:"time,omitempty"`
+}
+
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// WriteTo writes the track as a GPX document to w
+func (t *Track) WriteTo(w io.Writer) (int64, error) {
+	doc := document{Xmlns: Namespace, Version: "1.1", Creator: t.creator}
+	doc.Track.Name = t.name
+	seg := segment{Points: make([]point, len(t.points))}
+	for i, p := range t.points {
+		pt := point{Latitude: formatDecimal(p.Latitude), Longitude: formatDecimal(p.Longitude)}
+		if p.Elevation != nil {
+			ele := formatDecimal(*p.Elevation)
+			pt.Elevation = &ele
+		}
+		if !p.Time.IsZero() {
+			pt.Time = p.Time.UTC().Format(time.RFC3339Nano)
+		}
+		seg.Points[i] = pt
+	}
+	doc.Track.Segments = []segment{seg}
+
+	cw := &countingWriter{w: w}
+	if _, err := io.WriteString(cw, xml.Header); err != nil {
+		return cw.n, err
+	}
+	encoder := xml.NewEncoder(cw)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return cw.n, err
+	}
+	_, err := io.WriteString(cw, "\n")
+	return cw.n, err
+}
+
+// WriteFile writes the track to path. The document is written to a temporary
+// file in the same directory first and renamed over path, so readers never
+// see a partial file.
+func (t *Track) WriteFile(path string) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := t.WriteTo(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	// CreateTemp creates the file readable by the owner only
+	if err := tmp.Chmod(0o644); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
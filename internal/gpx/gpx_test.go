@@ -0,0 +1,258 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func elevation(v float64) *float64 {
+	return &v
+}
+
+func testTrack(t *testing.T) *Track {
+	track := NewTrack("mmctl", "drive test")
+	start := time.Date(2026, 10, 16, 12, 30, 15, 0, time.UTC)
+	for _, p := range []Point{
+		{Latitude: 52.520008, Longitude: 13.404954, Elevation: elevation(34.5), Time: start},
+		{Latitude: 52.520112, Longitude: 13.405201, Elevation: elevation(0), Time: start.Add(2 * time.Second)},
+		{Latitude: -33.8688, Longitude: 151.2093, Time: start.Add(4 * time.Second)},
+		{Latitude: 0, Longitude: 180, Elevation: elevation(-12.25)},
+		{Latitude: 90, Longitude: -180, Time: start.Add(250 * time.Millisecond).In(time.FixedZone("CEST", 2*3600))},
+	} {
+		if err := track.Add(p); err != nil {
+			t.Fatalf("Add(%+v): %v", p, err)
+		}
+	}
+	return track
+}
+
+func TestWriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := testTrack(t).WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo = %d, %v, wrote %d bytes", n, err, buf.Len())
+	}
+
+	want := xml.Header + `<gpx xmlns="http://www.topografix.com/GPX/1/1" version="1.1" creator="mmctl">
+  <trk>
+    <name>drive test</name>
+    <trkseg>
+      <trkpt lat="52.520008" lon="13.404954">
+        <ele>34.5</ele>
+        <time>2026-10-16T12:30:15Z</time>
+      </trkpt>
+      <trkpt lat="52.520112" lon="13.405201">
+        <ele>0</ele>
+        <time>2026-10-16T12:30:17Z</time>
+      </trkpt>
+      <trkpt lat="-33.8688" lon="151.2093">
+        <time>2026-10-16T12:30:19Z</time>
+      </trkpt>
+      <trkpt lat="0" lon="-180">
+        <ele>-12.25</ele>
+      </trkpt>
+      <trkpt lat="90" lon="-180">
+        <time>2026-10-16T12:30:15.25Z</time>
+      </trkpt>
+    </trkseg>
+  </trk>
+</gpx>
+`
+	if buf.String() != want {
+		t.Errorf("WriteTo wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+	if err := validate(&buf); err != nil {
+		t.Errorf("invalid GPX: %v", err)
+	}
+}
+
+func TestWriteToEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewTrack("mmctl", "").WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "<name>") {
+		t.Errorf("empty name written:\n%s", buf.String())
+	}
+	if err := validate(&buf); err != nil {
+		t.Errorf("invalid GPX: %v", err)
+	}
+}
+
+func TestAddOutOfRange(t *testing.T) {
+	track := NewTrack("mmctl", "")
+	for _, p := range []Point{{Latitude: 90.1}, {Latitude: -91}, {Longitude: 180.5}, {Longitude: -200}} {
+		if err := track.Add(p); err == nil {
+			t.Errorf("Add(%+v) succeeded, want an error", p)
+		}
+	}
+	if track.Len() != 0 {
+		t.Errorf("Len = %d after rejected points", track.Len())
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "track.gpx")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	track := testTrack(t)
+	if err := track.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	var buf bytes.Buffer
+	track.WriteTo(&buf)
+	if !bytes.Equal(data, buf.Bytes()) {
+		t.Errorf("file content differs from WriteTo")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the directory, want the temporary file removed", len(entries))
+	}
+
+	if err := track.WriteFile(filepath.Join(dir, "missing", "track.gpx")); err == nil {
+		t.Error("WriteFile into a missing directory succeeded")
+	}
+}
+
+// validate checks the parts of the GPX 1.1 schema the package writes: the
+// root element and its attributes, the element order of gpxType, trkType and
+// wptType, and the types of latitude, longitude, elevation and time
+func validate(r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+	var stack []string
+	// Index of the last child seen of each open element, to check the order
+	var order []int
+	sequences := map[string][]string{
+		"gpx":    {"metadata", "wpt", "rte", "trk", "extensions"},
+		"trk":    {"name", "cmt", "desc", "src", "link", "number", "type", "extensions", "trkseg"},
+		"trkseg": {"trkpt", "extensions"},
+		"trkpt":  {"ele", "time", "magvar", "geoidheight", "name", "cmt", "desc", "src", "link", "sym", "type", "fix", "sat", "hdop", "vdop", "pdop", "ageofdgpsdata", "dgpsid", "extensions"},
+	}
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		switch tok := token.(type) {
+		case xml.StartElement:
+			if tok.Name.Space != Namespace {
+				return fmt.Errorf("element %s in namespace %q", tok.Name.Local, tok.Name.Space)
+			}
+			name := tok.Name.Local
+			if len(stack) == 0 {
+				if name != "gpx" {
+					return fmt.Errorf("root element %s", name)
+				}
+				if err := validateRoot(tok.Attr); err != nil {
+					return err
+				}
+			} else {
+				parent := stack[len(stack)-1]
+				seq, ok := sequences[parent]
+				if !ok {
+					return fmt.Errorf("unexpected child %s of %s", name, parent)
+				}
+				idx := indexOf(seq, name)
+				if idx < 0 || idx < order[len(order)-1] {
+					return fmt.Errorf("element %s out of order in %s", name, parent)
+				}
+				order[len(order)-1] = idx
+			}
+			if name == "trkpt" {
+				if err := validatePoint(tok.Attr); err != nil {
+					return err
+				}
+			}
+			stack = append(stack, name)
+			order = append(order, 0)
+			text.Reset()
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			switch tok.Name.Local {
+			case "ele":
+				if _, err := strconv.ParseFloat(value, 64); err != nil {
+					return fmt.Errorf("ele %q is not a decimal", value)
+				}
+			case "time":
+				if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+					return fmt.Errorf("time %q is not a dateTime", value)
+				}
+			}
+			stack = stack[:len(stack)-1]
+			order = order[:len(order)-1]
+			text.Reset()
+		}
+	}
+	return nil
+}
+
+func validateRoot(attrs []xml.Attr) error {
+	var version, creator string
+	for _, attr := range attrs {
+		switch attr.Name.Local {
+		case "version":
+			version = attr.Value
+		case "creator":
+			creator = attr.Value
+		}
+	}
+	if version != "1.1" {
+		return fmt.Errorf("version %q, want 1.1", version)
+	}
+	if creator == "" {
+		return fmt.Errorf("creator missing")
+	}
+	return nil
+}
+
+func validatePoint(attrs []xml.Attr) error {
+	values := map[string]string{}
+	for _, attr := range attrs {
+		values[attr.Name.Local] = attr.Value
+	}
+	lat, err := strconv.ParseFloat(values["lat"], 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return fmt.Errorf("invalid lat %q", values["lat"])
+	}
+	lon, err := strconv.ParseFloat(values["lon"], 64)
+	if err != nil || lon < -180 || lon >= 180 {
+		return fmt.Errorf("invalid lon %q", values["lon"])
+	}
+	// xsd:decimal has no exponent notation
+	if strings.ContainsAny(values["lat"]+values["lon"], "eE") {
+		return fmt.Errorf("lat %q or lon %q in exponent notation", values["lat"], values["lon"])
+	}
+	return nil
+}
+
+func indexOf(seq []string, name string) int {
+	for i, s := range seq {
+		if s == name {
+			return i
+		}
+	}
+	return -1
+}
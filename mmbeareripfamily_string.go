@@ -12,13 +12,15 @@ func _() {
 	_ = x[MmBearerIpFamilyIpv4-1]
 	_ = x[MmBearerIpFamilyIpv6-2]
 	_ = x[MmBearerIpFamilyIpv4v6-4]
+	_ = x[MmBearerIpFamilyNonIp-8]
 	_ = x[MmBearerIpFamilyAny-4294967295]
 }
 
 const (
 	_MMBearerIpFamily_name_0 = "NoneIpv4Ipv6"
 	_MMBearerIpFamily_name_1 = "Ipv4v6"
-	_MMBearerIpFamily_name_2 = "Any"
+	_MMBearerIpFamily_name_2 = "NonIp"
+	_MMBearerIpFamily_name_3 = "Any"
 )
 
 var (
@@ -31,8 +33,10 @@ func (i MMBearerIpFamily) String() string {
 		return _MMBearerIpFamily_name_0[_MMBearerIpFamily_index_0[i]:_MMBearerIpFamily_index_0[i+1]]
 	case i == 4:
 		return _MMBearerIpFamily_name_1
-	case i == 4294967295:
+	case i == 8:
 		return _MMBearerIpFamily_name_2
+	case i == 4294967295:
+		return _MMBearerIpFamily_name_3
 	default:
 		return "MMBearerIpFamily(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
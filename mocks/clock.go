@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/maltegrosse/go-modemmanager/internal/clock"
+)
+
+// Clock is a test clock whose time only moves when Advance is called. Use
+// BlockUntilTimers to wait until the code under test is sleeping on it.
+type Clock = clock.Fake
+
+// NewClock returns a test clock set to now.
+func NewClock(now time.Time) *Clock {
+	return clock.NewFake(now)
+}
@@ -3,6 +3,7 @@ package mocks_test
 import (
 	"testing"
 
+	mm "github.com/maltegrosse/go-modemmanager"
 	"github.com/maltegrosse/go-modemmanager/mocks"
 )
 
@@ -67,7 +68,7 @@ func TestMockModem(t *testing.T) {
 	t.Logf("Modem state: %s", state.String())
 
 	// Test enabling modem
-	err = mockModem.Enable(true)
+	err = mockModem.Enable()
 	if err != nil {
 		t.Fatalf("Enable failed: %v", err)
 	}
@@ -88,7 +89,7 @@ func TestMockModemWithErrors(t *testing.T) {
 	mockModem.EnableError = &MockError{msg: "simulated enable error"}
 
 	// Test that error is returned
-	err := mockModem.Enable(true)
+	err := mockModem.Enable()
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -187,7 +188,7 @@ func TestMockBearer(t *testing.T) {
 	if err != nil {
 		t.Fatalf("GetStats failed: %v", err)
 	}
-	t.Logf("Stats: RX=%d bytes, TX=%d bytes", stats.BytesRx, stats.BytesTx)
+	t.Logf("Stats: RX=%d bytes, TX=%d bytes", stats.RxBytes, stats.TxBytes)
 
 	// Disconnect
 	err = mockBearer.Disconnect()
@@ -251,7 +252,7 @@ func TestMockModemSimple(t *testing.T) {
 	t.Logf("Status: %+v", status)
 
 	// Test connecting (returns a bearer)
-	bearer, err := mockSimple.Connect(status)
+	bearer, err := mockSimple.Connect(mm.SimpleProperties{Apn: "internet"})
 	if err != nil {
 		t.Fatalf("Connect failed: %v", err)
 	}
@@ -264,7 +265,7 @@ func TestMockModemSimple(t *testing.T) {
 	t.Logf("Bearer created at: %s", bearerPath)
 
 	// Test disconnecting
-	err = mockSimple.Disconnect(bearerPath)
+	err = mockSimple.Disconnect(bearer)
 	if err != nil {
 		t.Fatalf("Disconnect failed: %v", err)
 	}
@@ -320,7 +321,7 @@ func TestIntegrationScenario(t *testing.T) {
 	t.Logf("Modem: %s %s", manufacturer, model)
 
 	// Step 4: Enable modem
-	err = modem.Enable(true)
+	err = modem.Enable()
 	if err != nil {
 		t.Fatalf("Enable failed: %v", err)
 	}
@@ -344,7 +345,8 @@ func TestIntegrationScenario(t *testing.T) {
 
 	// Step 7: Create and connect bearer
 	mockModem := modem.(*mocks.MockModem)
-	bearer, err := mockModem.CreateBearer(mocks.NewMockBearer().GetProperties())
+	properties, _ := mocks.NewMockBearer().GetProperties()
+	bearer, err := mockModem.CreateBearer(properties)
 	if err != nil {
 		t.Fatalf("CreateBearer failed: %v", err)
 	}
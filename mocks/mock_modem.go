@@ -3,12 +3,46 @@ package mocks
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/godbus/dbus/v5"
 	mm "github.com/maltegrosse/go-modemmanager"
 )
 
+// ErrNotSupported is returned by the interface getters of MockModem and
+// MockModem3gpp for interfaces the mocks do not implement, the way
+// ModemManager reports an interface the modem lacks
+var ErrNotSupported = errors.New("mocks: interface not supported")
+
+// Compile-time checks that the mocks implement the library interfaces
+var (
+	_ mm.ModemManager   = (*MockModemManager)(nil)
+	_ mm.Modem          = (*MockModem)(nil)
+	_ mm.ModemSimple    = (*MockModemSimple)(nil)
+	_ mm.Modem3gpp      = (*MockModem3gpp)(nil)
+	_ mm.Ussd           = (*MockUssd)(nil)
+	_ mm.Bearer         = (*MockBearer)(nil)
+	_ mm.Sim            = (*MockSim)(nil)
+	_ mm.ModemSignal    = (*MockModemSignal)(nil)
+	_ mm.ModemLocation  = (*MockModemLocation)(nil)
+	_ mm.ModemVoice     = (*MockModemVoice)(nil)
+	_ mm.ModemMessaging = (*MockModemMessaging)(nil)
+	_ mm.Sms            = (*MockSms)(nil)
+	_ mm.Call           = (*MockCall)(nil)
+	_ mm.ModemTime      = (*MockModemTime)(nil)
+	_ mm.ModemFirmware  = (*MockModemFirmware)(nil)
+)
+
+// notSupported returns err, or ErrNotSupported if err is nil
+func notSupported(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrNotSupported
+}
+
 // MockModemManager is a mock implementation of the ModemManager interface
 type MockModemManager struct {
 	// Configurable return values
@@ -21,6 +55,12 @@ type MockModemManager struct {
 	GetVersionError    error
 	GetModemsError     error
 	SignalChan         chan *dbus.Signal
+
+	// Inhibited records the devices inhibited with InhibitDevice
+	Inhibited map[string]bool
+	// LoggingLevel and KernelEvents record SetLogging and ReportKernelEvent
+	LoggingLevel mm.MMLoggingLevel
+	KernelEvents []mm.EventProperties
 }
 
 // NewMockModemManager creates a new mock ModemManager with default values
@@ -41,15 +81,28 @@ func (m *MockModemManager) GetModems() ([]mm.Modem, error) {
 }
 
 func (m *MockModemManager) SetLogging(level mm.MMLoggingLevel) error {
+	if m.SetLoggingError == nil {
+		m.LoggingLevel = level
+	}
 	return m.SetLoggingError
 }
 
 func (m *MockModemManager) ReportKernelEvent(props mm.EventProperties) error {
+	if m.ReportEventError == nil {
+		m.KernelEvents = append(m.KernelEvents, props)
+	}
 	return m.ReportEventError
 }
 
 func (m *MockModemManager) InhibitDevice(uid string, inhibit bool) error {
-	return m.InhibitDeviceError
+	if m.InhibitDeviceError != nil {
+		return m.InhibitDeviceError
+	}
+	if m.Inhibited == nil {
+		m.Inhibited = make(map[string]bool)
+	}
+	m.Inhibited[uid] = inhibit
+	return nil
 }
 
 func (m *MockModemManager) GetVersion() (string, error) {
@@ -86,6 +139,7 @@ type MockModem struct {
 	SignalQualityRecent        bool
 	AccessTechnologiesValue    []mm.MMModemAccessTechnology
 	UnlockRequiredValue        mm.MMModemLock
+	UnlockRetriesValue         map[mm.MMModemLock]uint32
 	PowerStateValue            mm.MMModemPowerState
 	SupportedCapabilitiesValue [][]mm.MMModemCapability
 	CurrentCapabilitiesValue   []mm.MMModemCapability
@@ -93,9 +147,29 @@ type MockModem struct {
 	CurrentModesValue          mm.Mode
 	SupportedBandsValue        []mm.MMModemBand
 	CurrentBandsValue          []mm.MMModemBand
+	BearersValue               []mm.Bearer
+	PortsValue                 []mm.Port
+	PrimaryPortValue           string
+	OwnNumbersValue            []string
+	DriversValue               []string
+	PluginValue                string
+	Modem3gppValue             mm.Modem3gpp      // nil if the modem has no 3GPP interface
+	SimValue                   mm.Sim            // nil if the modem has no SIM
+	SignalValue                mm.ModemSignal    // nil if the modem has no Signal interface
+	LocationValue              mm.ModemLocation  // nil if the modem has no Location interface
+	VoiceValue                 mm.ModemVoice     // nil if the modem has no Voice interface
+	MessagingValue             mm.ModemMessaging // nil if the modem has no Messaging interface
+	TimeValue                  mm.ModemTime      // nil if the modem has no Time interface
+	FirmwareValue              mm.ModemFirmware  // nil if the modem has no Firmware interface
+
+	// SignalChan is returned by both subscriptions; if nil each gets a
+	// channel that never delivers. Unsubscribed counts Unsubscribe calls.
+	SignalChan   chan *dbus.Signal
+	Unsubscribed int
 
 	// Error values
 	EnableError            error
+	GetBearersError        error
 	ListBearsError         error
 	CreateBearerError      error
 	DeleteBearerError      error
@@ -117,7 +191,6 @@ type MockModem struct {
 	GetMessagingError      error
 	GetVoiceError          error
 	GetSimError            error
-	GetPropertiesError     error
 	GetStateError          error
 	GetMaxBearsError       error
 	GetMaxActiveBearsError error
@@ -126,7 +199,7 @@ type MockModem struct {
 // NewMockModem creates a new mock Modem with default values
 func NewMockModem() *MockModem {
 	return &MockModem{
-		ObjectPathValue:            "/org/freedesktop/ModemManager1/Modem/0",
+		ObjectPathValue:            mm.ModemPathFromIndex(0),
 		ManufacturerValue:          "MockModem Inc.",
 		ModelValue:                 "MockModem X1000",
 		RevisionValue:              "1.0.0",
@@ -140,11 +213,18 @@ func NewMockModem() *MockModem {
 		PowerStateValue:            mm.MmModemPowerStateOn,
 		SupportedCapabilitiesValue: [][]mm.MMModemCapability{{mm.MmModemCapabilityLte}},
 		CurrentCapabilitiesValue:   []mm.MMModemCapability{mm.MmModemCapabilityLte},
-		SupportedModesValue: []mm.
-			Mode{{AllowedModes: []mm.MMModemMode{mm.MmModemModeAny}}},
-		CurrentModesValue:   mm.Mode{AllowedModes: []mm.MMModemMode{mm.MmModemMode4g}},
-		SupportedBandsValue: []mm.MMModemBand{mm.MmModemBandEutran1, mm.MmModemBandEutran2},
-		CurrentBandsValue:   []mm.MMModemBand{mm.MmModemBandEutran1},
+		SupportedModesValue:        []mm.Mode{{Allowed: mm.ModeMask(mm.MmModemModeAny)}},
+		CurrentModesValue:          mm.Mode{Allowed: mm.NewModeMask(mm.MmModemMode4g)},
+		SupportedBandsValue:        []mm.MMModemBand{mm.MmModemBandEutran1, mm.MmModemBandEutran2},
+		CurrentBandsValue:          []mm.MMModemBand{mm.MmModemBandEutran1},
+		BearersValue:               []mm.Bearer{NewMockBearer()},
+		PortsValue:                 []mm.Port{{PortName: "cdc-wdm0", PortType: mm.MmModemPortTypeQmi}, {PortName: "ttyUSB2", PortType: mm.MmModemPortTypeAt}},
+		PrimaryPortValue:           "cdc-wdm0",
+		OwnNumbersValue:            []string{"+1234567890"},
+		DriversValue:               []string{"qmi_wwan", "option"},
+		PluginValue:                "generic",
+		Modem3gppValue:             NewMockModem3gpp(),
+		SimValue:                   NewMockSim(),
 	}
 }
 
@@ -160,42 +240,60 @@ func (m *MockModem) GetSimpleModem() (mm.ModemSimple, error) {
 }
 
 func (m *MockModem) Get3gpp() (mm.Modem3gpp, error) {
-	if m.Get3gppError != nil {
-		return nil, m.Get3gppError
+	if m.Get3gppError != nil || m.Modem3gppValue == nil {
+		return nil, notSupported(m.Get3gppError)
 	}
-	return NewMockModem3gpp(), nil
+	return m.Modem3gppValue, nil
 }
 
 func (m *MockModem) GetCdma() (mm.ModemCdma, error) {
-	return nil, m.GetCdmaError
+	return nil, notSupported(m.GetCdmaError)
 }
 
 func (m *MockModem) GetTime() (mm.ModemTime, error) {
-	return nil, m.GetTimeError
+	if m.GetTimeError != nil || m.TimeValue == nil {
+		return nil, notSupported(m.GetTimeError)
+	}
+	return m.TimeValue, nil
 }
 
 func (m *MockModem) GetFirmware() (mm.ModemFirmware, error) {
-	return nil, m.GetFirmwareError
+	if m.GetFirmwareError != nil || m.FirmwareValue == nil {
+		return nil, notSupported(m.GetFirmwareError)
+	}
+	return m.FirmwareValue, nil
 }
 
 func (m *MockModem) GetSignal() (mm.ModemSignal, error) {
-	return nil, m.GetSignalError
+	if m.GetSignalError != nil || m.SignalValue == nil {
+		return nil, notSupported(m.GetSignalError)
+	}
+	return m.SignalValue, nil
 }
 
 func (m *MockModem) GetOma() (mm.ModemOma, error) {
-	return nil, m.GetOmaError
+	return nil, notSupported(m.GetOmaError)
 }
 
 func (m *MockModem) GetLocation() (mm.ModemLocation, error) {
-	return nil, m.GetLocationError
+	if m.GetLocationError != nil || m.LocationValue == nil {
+		return nil, notSupported(m.GetLocationError)
+	}
+	return m.LocationValue, nil
 }
 
 func (m *MockModem) GetMessaging() (mm.ModemMessaging, error) {
-	return nil, m.GetMessagingError
+	if m.GetMessagingError != nil || m.MessagingValue == nil {
+		return nil, notSupported(m.GetMessagingError)
+	}
+	return m.MessagingValue, nil
 }
 
 func (m *MockModem) GetVoice() (mm.ModemVoice, error) {
-	return nil, m.GetVoiceError
+	if m.GetVoiceError != nil || m.VoiceValue == nil {
+		return nil, notSupported(m.GetVoiceError)
+	}
+	return m.VoiceValue, nil
 }
 
 func (m *MockModem) Enable() error {
@@ -208,16 +306,46 @@ func (m *MockModem) Disable() error {
 	return m.EnableError
 }
 
+// ListBearers implements the deprecated method ModemManager offers besides the
+// Bearers property, see modemmanager.ListModemBearers
 func (m *MockModem) ListBearers() ([]mm.Bearer, error) {
-	return []mm.Bearer{}, m.ListBearsError
+	if m.ListBearsError != nil {
+		return nil, m.ListBearsError
+	}
+	return m.BearersValue, nil
+}
+
+func (m *MockModem) GetBearers() ([]mm.Bearer, error) {
+	if m.GetBearersError != nil {
+		return nil, m.GetBearersError
+	}
+	return m.BearersValue, nil
 }
 
+// CreateBearer adds a disconnected bearer with property to BearersValue
 func (m *MockModem) CreateBearer(property mm.BearerProperty) (mm.Bearer, error) {
-	return NewMockBearer(), m.CreateBearerError
+	if m.CreateBearerError != nil {
+		return nil, m.CreateBearerError
+	}
+	bearer := NewMockBearer()
+	bearer.ObjectPathValue = mm.BearerObjectPathPrefix + dbus.ObjectPath(fmt.Sprint(len(m.BearersValue)))
+	bearer.PropertiesValue = property
+	m.BearersValue = append(m.BearersValue, bearer)
+	return bearer, nil
 }
 
+// DeleteBearer removes bearer from BearersValue
 func (m *MockModem) DeleteBearer(bearer mm.Bearer) error {
-	return m.DeleteBearerError
+	if m.DeleteBearerError != nil {
+		return m.DeleteBearerError
+	}
+	for i, b := range m.BearersValue {
+		if b.GetObjectPath() == bearer.GetObjectPath() {
+			m.BearersValue = append(m.BearersValue[:i:i], m.BearersValue[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no bearer at %s", bearer.GetObjectPath())
 }
 
 func (m *MockModem) Reset() error {
@@ -254,26 +382,20 @@ func (m *MockModem) Command(cmd string, timeout uint32) (string, error) {
 }
 
 func (m *MockModem) GetSim() (mm.Sim, error) {
-	if m.GetSimError != nil {
-		return nil, m.GetSimError
+	if m.GetSimError != nil || m.SimValue == nil {
+		return nil, notSupported(m.GetSimError)
 	}
-	return NewMockSim(), nil
-}
-
-func (m *MockModem) GetProperties() (mm.ModemProperty, error) {
-	return mm.ModemProperty{
-		Manufacturer:        m.ManufacturerValue,
-		Model:               m.ModelValue,
-		Revision:            m.RevisionValue,
-		EquipmentIdentifier: m.EquipmentIdentifierValue,
-		DeviceIdentifier:    m.DeviceIdentifierValue,
-	}, m.GetPropertiesError
+	return m.SimValue, nil
 }
 
 func (m *MockModem) GetState() (mm.MMModemState, error) {
 	return m.StateValue, m.GetStateError
 }
 
+func (m *MockModem) GetStateFailedReason() (mm.MMModemStateFailedReason, error) {
+	return mm.MmModemStateFailedReasonNone, nil
+}
+
 func (m *MockModem) GetSignalQuality() (percent uint32, recent bool, err error) {
 	return m.SignalQualityPercent, m.SignalQualityRecent, nil
 }
@@ -286,6 +408,14 @@ func (m *MockModem) GetUnlockRequired() (mm.MMModemLock, error) {
 	return m.UnlockRequiredValue, nil
 }
 
+func (m *MockModem) GetUnlockRetries() ([]mm.Pair, error) {
+	var retries []mm.Pair
+	for lock, count := range m.UnlockRetriesValue {
+		retries = append(retries, mm.NewPair(lock, count))
+	}
+	return retries, nil
+}
+
 func (m *MockModem) GetPowerState() (mm.MMModemPowerState, error) {
 	return m.PowerStateValue, nil
 }
@@ -318,6 +448,18 @@ func (m *MockModem) GetRevision() (string, error) {
 	return m.RevisionValue, nil
 }
 
+func (m *MockModem) GetHardwareRevision() (string, error) {
+	return "", nil
+}
+
+func (m *MockModem) GetCarrierConfiguration() (string, error) {
+	return "default", nil
+}
+
+func (m *MockModem) GetCarrierConfigurationRevision() (string, error) {
+	return "", nil
+}
+
 func (m *MockModem) GetEquipmentIdentifier() (string, error) {
 	return m.EquipmentIdentifierValue, nil
 }
@@ -326,8 +468,28 @@ func (m *MockModem) GetDeviceIdentifier() (string, error) {
 	return m.DeviceIdentifierValue, nil
 }
 
+func (m *MockModem) GetDevice() (string, error) {
+	return "/sys/devices/mock/usb1/1-1", nil
+}
+
+func (m *MockModem) GetDrivers() ([]string, error) {
+	return m.DriversValue, nil
+}
+
+func (m *MockModem) GetPlugin() (string, error) {
+	return m.PluginValue, nil
+}
+
+func (m *MockModem) GetPrimaryPort() (string, error) {
+	return m.PrimaryPortValue, nil
+}
+
+func (m *MockModem) GetPorts() ([]mm.Port, error) {
+	return m.PortsValue, nil
+}
+
 func (m *MockModem) GetOwnNumbers() ([]string, error) {
-	return []string{"+1234567890"}, nil
+	return m.OwnNumbersValue, nil
 }
 
 func (m *MockModem) GetSupportedModes() ([]mm.Mode, error) {
@@ -346,8 +508,8 @@ func (m *MockModem) GetCurrentBands() ([]mm.MMModemBand, error) {
 	return m.CurrentBandsValue, nil
 }
 
-func (m *MockModem) GetSupportedIpFamilies() (mm.MMBearerIpFamily, error) {
-	return mm.MmBearerIpFamilyIpv4 | mm.MmBearerIpFamilyIpv6, nil
+func (m *MockModem) GetSupportedIpFamilies() ([]mm.MMBearerIpFamily, error) {
+	return []mm.MMBearerIpFamily{mm.MmBearerIpFamilyIpv4, mm.MmBearerIpFamilyIpv6, mm.MmBearerIpFamilyIpv4v6}, nil
 }
 
 func (m *MockModem) MarshalJSON() ([]byte, error) {
@@ -361,6 +523,9 @@ func (m *MockModem) MarshalJSON() ([]byte, error) {
 }
 
 func (m *MockModem) SubscribeStateChanged() <-chan *dbus.Signal {
+	if m.SignalChan != nil {
+		return m.SignalChan
+	}
 	ch := make(chan *dbus.Signal, 10)
 	return ch
 }
@@ -370,6 +535,9 @@ func (m *MockModem) ParseStateChanged(v *dbus.Signal) (old mm.MMModemState, new
 }
 
 func (m *MockModem) SubscribePropertiesChanged() <-chan *dbus.Signal {
+	if m.SignalChan != nil {
+		return m.SignalChan
+	}
 	ch := make(chan *dbus.Signal, 10)
 	return ch
 }
@@ -378,7 +546,9 @@ func (m *MockModem) ParsePropertiesChanged(v *dbus.Signal) (interfaceName string
 	return "", nil, nil, nil
 }
 
-func (m *MockModem) Unsubscribe() {}
+func (m *MockModem) Unsubscribe() {
+	m.Unsubscribed++
+}
 
 // MockModemSimple is a mock implementation of ModemSimple interface
 type MockModemSimple struct {
@@ -393,8 +563,8 @@ type MockModemSimple struct {
 func NewMockModemSimple() *MockModemSimple {
 	return &MockModemSimple{
 		StatusValue:     mm.SimpleStatus{},
-		BearerPathValue: "/org/freedesktop/ModemManager1/Bearer/0",
-		ObjectPathValue: "/org/freedesktop/ModemManager1/Modem/0",
+		BearerPathValue: mm.BearerObjectPathPrefix + "0",
+		ObjectPathValue: mm.ModemPathFromIndex(0),
 	}
 }
 
@@ -409,7 +579,7 @@ func (m *MockModemSimple) Connect(property mm.SimpleProperties) (mm.Bearer, erro
 	return NewMockBearer(), nil
 }
 
-func (m *MockModemSimple) Disconnect(bearerPath dbus.ObjectPath) error {
+func (m *MockModemSimple) Disconnect(bearer mm.Bearer) error {
 	return m.DisconnectError
 }
 
@@ -424,17 +594,24 @@ type MockModem3gpp struct {
 	RegistrationStateValue mm.MMModem3gppRegistrationState
 	OperatorCodeValue      string
 	OperatorNameValue      string
+	EpsUeModeValue         mm.MMModem3gppEpsUeModeOperation
+	InitialEpsSettings     mm.BearerProperty
+	FacilityLocksValue     []mm.MMModem3gppFacility
 	RegisterError          error
 	ScanError              error
+	UssdValue              mm.Ussd // nil if the modem has no USSD support
+	GetUssdError           error
 }
 
 func NewMockModem3gpp() *MockModem3gpp {
 	return &MockModem3gpp{
-		ObjectPathValue:        "/org/freedesktop/ModemManager1/Modem/0",
+		ObjectPathValue:        mm.ModemPathFromIndex(0),
 		ImeiValue:              "123456789012345",
 		RegistrationStateValue: mm.MmModem3gppRegistrationStateHome,
 		OperatorCodeValue:      "310260",
 		OperatorNameValue:      "T-Mobile",
+		EpsUeModeValue:         mm.MmModem3gppEpsUeModeOperationPs2,
+		InitialEpsSettings:     mm.BearerProperty{APN: "internet", IPType: mm.MmBearerIpFamilyIpv4},
 	}
 }
 
@@ -443,21 +620,38 @@ func (m *MockModem3gpp) GetObjectPath() dbus.ObjectPath {
 }
 
 func (m *MockModem3gpp) GetUssd() (mm.Ussd, error) {
-	return nil, nil
+	if m.GetUssdError != nil || m.UssdValue == nil {
+		return nil, notSupported(m.GetUssdError)
+	}
+	return m.UssdValue, nil
 }
 
 func (m *MockModem3gpp) Register(operatorId string) error {
 	return m.RegisterError
 }
 
-func (m *MockModem3gpp) Scan() ([]mm.Modem3gppNetwork, error) {
-	return []mm.Modem3gppNetwork{
+func (m *MockModem3gpp) Scan() ([]mm.Network3Gpp, error) {
+	if m.ScanError != nil {
+		return nil, m.ScanError
+	}
+	return []mm.Network3Gpp{
 		{
-			OperatorLong:  "T-Mobile",
-			OperatorShort: "TMO",
-			OperatorCode:  "310260",
+			Status:           mm.MmModem3gppNetworkAvailabilityCurrent,
+			OperatorLong:     "T-Mobile",
+			OperatorShort:    "TMO",
+			OperatorCode:     "310260",
+			Mcc:              "310",
+			Mnc:              "260",
+			AccessTechnology: mm.MmModemAccessTechnologyLte,
 		},
-	}, m.ScanError
+	}, nil
+}
+
+func (m *MockModem3gpp) RequestScan() {}
+
+func (m *MockModem3gpp) GetScanResults() (mm.NetworkScanResult, error) {
+	networks, err := m.Scan()
+	return mm.NetworkScanResult{Networks: networks}, err
 }
 
 func (m *MockModem3gpp) GetImei() (string, error) {
@@ -472,6 +666,20 @@ func (m *MockModem3gpp) GetOperatorCode() (string, error) {
 	return m.OperatorCodeValue, nil
 }
 
+func (m *MockModem3gpp) GetMcc() (string, error) {
+	if len(m.OperatorCodeValue) < 5 {
+		return "", nil
+	}
+	return m.OperatorCodeValue[:3], nil
+}
+
+func (m *MockModem3gpp) GetMnc() (string, error) {
+	if len(m.OperatorCodeValue) < 5 {
+		return "", nil
+	}
+	return m.OperatorCodeValue[3:], nil
+}
+
 func (m *MockModem3gpp) GetOperatorName() (string, error) {
 	return m.OperatorNameValue, nil
 }
@@ -485,11 +693,11 @@ func (m *MockModem3gpp) SetInitialEpsBearerSettings(property mm.BearerProperty)
 }
 
 func (m *MockModem3gpp) GetEnabledFacilityLocks() ([]mm.MMModem3gppFacility, error) {
-	return []mm.MMModem3gppFacility{}, nil
+	return m.FacilityLocksValue, nil
 }
 
 func (m *MockModem3gpp) GetEpsUeModeOperation() (mm.MMModem3gppEpsUeModeOperation, error) {
-	return mm.MmModem3gppEpsUeModeOperationPsMode2, nil
+	return m.EpsUeModeValue, nil
 }
 
 func (m *MockModem3gpp) GetPco() ([]mm.RawPcoData, error) {
@@ -501,7 +709,7 @@ func (m *MockModem3gpp) GetInitialEpsBearer() (mm.Bearer, error) {
 }
 
 func (m *MockModem3gpp) GetInitialEpsBearerSettings() (mm.BearerProperty, error) {
-	return mm.BearerProperty{}, nil
+	return m.InitialEpsSettings, nil
 }
 
 func (m *MockModem3gpp) MarshalJSON() ([]byte, error) {
@@ -524,28 +732,818 @@ func (m *MockModem3gpp) ParsePropertiesChanged(v *dbus.Signal) (interfaceName st
 
 func (m *MockModem3gpp) Unsubscribe() {}
 
+// MockUssd is a mock implementation of Ussd interface
+type MockUssd struct {
+	ObjectPathValue          dbus.ObjectPath
+	StateValue               mm.MMModem3gppUssdSessionState
+	NetworkNotificationValue string
+	NetworkRequestValue      string
+	InitiateReply            string
+	RespondReply             string
+	InitiateError            error
+	RespondError             error
+	CancelError              error
+
+	// Cancelled is set by Cancel
+	Cancelled bool
+}
+
+func NewMockUssd() *MockUssd {
+	return &MockUssd{
+		ObjectPathValue: mm.ModemPathFromIndex(0),
+		StateValue:      mm.MmModem3gppUssdSessionStateIdle,
+		InitiateReply:   "Your balance is 10.00 EUR",
+	}
+}
+
+func (u *MockUssd) GetObjectPath() dbus.ObjectPath {
+	return u.ObjectPathValue
+}
+
+func (u *MockUssd) Initiate(command string) (string, error) {
+	return u.InitiateReply, u.InitiateError
+}
+
+func (u *MockUssd) Respond(response string) (string, error) {
+	return u.RespondReply, u.RespondError
+}
+
+func (u *MockUssd) Cancel() error {
+	u.Cancelled = true
+	return u.CancelError
+}
+
+func (u *MockUssd) GetState() (mm.MMModem3gppUssdSessionState, error) {
+	return u.StateValue, nil
+}
+
+func (u *MockUssd) GetNetworkNotification() (string, error) {
+	return u.NetworkNotificationValue, nil
+}
+
+func (u *MockUssd) GetNetworkRequest() (string, error) {
+	return u.NetworkRequestValue, nil
+}
+
+func (u *MockUssd) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"State":               u.StateValue,
+		"NetworkNotification": u.NetworkNotificationValue,
+		"NetworkRequest":      u.NetworkRequestValue,
+	})
+}
+
+// MockModemLocation is a mock implementation of ModemLocation interface
+type MockModemLocation struct {
+	ObjectPathValue      dbus.ObjectPath
+	CapabilitiesValue    []mm.MMModemLocationSource
+	EnabledValue         []mm.MMModemLocationSource
+	SignalsLocationValue bool
+	LocationValue        mm.CurrentLocation
+	GpsRefreshRateValue  uint32
+	SetupError           error
+	GetLocationError     error
+}
+
+func NewMockModemLocation() *MockModemLocation {
+	return &MockModemLocation{
+		ObjectPathValue:     mm.ModemPathFromIndex(0),
+		CapabilitiesValue:   []mm.MMModemLocationSource{mm.MmModemLocationSource3gppLacCi, mm.MmModemLocationSourceGpsRaw, mm.MmModemLocationSourceGpsNmea},
+		GpsRefreshRateValue: 30,
+	}
+}
+
+func (l *MockModemLocation) GetObjectPath() dbus.ObjectPath {
+	return l.ObjectPathValue
+}
+
+// Setup replaces the enabled sources, the way ModemManager does
+func (l *MockModemLocation) Setup(sources []mm.MMModemLocationSource, signalLocation bool) error {
+	if l.SetupError != nil {
+		return l.SetupError
+	}
+	l.EnabledValue = sources
+	l.SignalsLocationValue = signalLocation && len(sources) > 0
+	return nil
+}
+
+func (l *MockModemLocation) GetCurrentLocation() (mm.CurrentLocation, error) {
+	return l.LocationValue, l.GetLocationError
+}
+
+func (l *MockModemLocation) SetSuplServer(supl string) error {
+	return ErrNotSupported
+}
+
+func (l *MockModemLocation) InjectAssistanceData(data []byte) error {
+	return ErrNotSupported
+}
+
+func (l *MockModemLocation) SetGpsRefreshRate(rate uint32) error {
+	l.GpsRefreshRateValue = rate
+	return nil
+}
+
+func (l *MockModemLocation) GetCapabilities() ([]mm.MMModemLocationSource, error) {
+	return l.CapabilitiesValue, nil
+}
+
+func (l *MockModemLocation) GetSupportedAssistanceData() ([]mm.MMModemLocationAssistanceDataType, error) {
+	return nil, nil
+}
+
+func (l *MockModemLocation) GetEnabledLocationSources() ([]mm.MMModemLocationSource, error) {
+	return l.EnabledValue, nil
+}
+
+func (l *MockModemLocation) GetSignalsLocation() (bool, error) {
+	return l.SignalsLocationValue, nil
+}
+
+func (l *MockModemLocation) GetLocation() (mm.CurrentLocation, error) {
+	return l.LocationValue, l.GetLocationError
+}
+
+func (l *MockModemLocation) GetSuplServer() (string, error) {
+	return "", nil
+}
+
+func (l *MockModemLocation) GetAssistanceDataServers() ([]string, error) {
+	return nil, nil
+}
+
+func (l *MockModemLocation) GetGpsRefreshRate() (uint32, error) {
+	return l.GpsRefreshRateValue, nil
+}
+
+func (l *MockModemLocation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"Capabilities":    l.CapabilitiesValue,
+		"Enabled":         l.EnabledValue,
+		"SignalsLocation": l.SignalsLocationValue,
+		"Location":        l.LocationValue,
+	})
+}
+
+// MockModemTime is a mock implementation of ModemTime interface
+type MockModemTime struct {
+	ObjectPathValue  dbus.ObjectPath
+	NetworkTimeValue time.Time
+	TimezoneValue    mm.ModemTimeZone
+	NetworkTimeError error
+	TimezoneError    error
+}
+
+func NewMockModemTime() *MockModemTime {
+	return &MockModemTime{
+		ObjectPathValue:  mm.ModemPathFromIndex(0),
+		NetworkTimeValue: time.Date(2024, 3, 1, 12, 30, 45, 0, time.FixedZone("", 60*60)),
+		TimezoneValue:    mm.ModemTimeZone{Offset: 60},
+	}
+}
+
+func (t *MockModemTime) GetObjectPath() dbus.ObjectPath {
+	return t.ObjectPathValue
+}
+
+func (t *MockModemTime) GetNetworkTime() (time.Time, error) {
+	return t.NetworkTimeValue, t.NetworkTimeError
+}
+
+func (t *MockModemTime) GetNetworkTimezone() (mm.ModemTimeZone, error) {
+	return t.TimezoneValue, t.TimezoneError
+}
+
+func (t *MockModemTime) SubscribeNetworkTimeChanged() <-chan *dbus.Signal {
+	return make(chan *dbus.Signal, 10)
+}
+
+func (t *MockModemTime) ParseNetworkTimeChanged(v *dbus.Signal) (time.Time, error) {
+	return time.Time{}, ErrNotSupported
+}
+
+func (t *MockModemTime) Unsubscribe() {}
+
+func (t *MockModemTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"NetworkTimezone": t.TimezoneValue,
+	})
+}
+
+// MockModemFirmware is a mock implementation of ModemFirmware interface
+type MockModemFirmware struct {
+	ObjectPathValue     dbus.ObjectPath
+	ImagesValue         []mm.FirmwareProperty
+	UpdateSettingsValue mm.UpdateSettingsProperty
+	ListError           error
+	SelectError         error
+	UpdateSettingsError error
+}
+
+func NewMockModemFirmware() *MockModemFirmware {
+	return &MockModemFirmware{
+		ObjectPathValue: mm.ModemPathFromIndex(0),
+		ImagesValue: []mm.FirmwareProperty{
+			{ImageType: mm.MmFirmwareImageTypeGobi, UniqueId: "05.05.58.00_VZW", GobiPriVersion: "05.05.58.00", Selected: true},
+			{ImageType: mm.MmFirmwareImageTypeGobi, UniqueId: "05.05.58.00_ATT", GobiPriVersion: "05.05.58.00"},
+		},
+		UpdateSettingsValue: mm.UpdateSettingsProperty{
+			UpdateMethods: []mm.MMModemFirmwareUpdateMethod{mm.MmModemFirmwareUpdateMethodFastboot},
+			DeviceIds:     []string{"USB\\VID_1199&PID_9071&REV_0006", "USB\\VID_1199&PID_9071", "USB\\VID_1199"},
+			Version:       "SWI9X30C_02.24.05.06",
+			FastbootAt:    "AT!BOOTHOLD",
+		},
+	}
+}
+
+func (f *MockModemFirmware) GetObjectPath() dbus.ObjectPath {
+	return f.ObjectPathValue
+}
+
+func (f *MockModemFirmware) List() ([]mm.FirmwareProperty, error) {
+	if f.ListError != nil {
+		return nil, f.ListError
+	}
+	return f.ImagesValue, nil
+}
+
+// Select marks the image with uid as selected, the reset is not mocked
+func (f *MockModemFirmware) Select(uid string) error {
+	if f.SelectError != nil {
+		return f.SelectError
+	}
+	for i, image := range f.ImagesValue {
+		if image.UniqueId == uid {
+			for j := range f.ImagesValue {
+				f.ImagesValue[j].Selected = j == i
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("firmware image %s not found", uid)
+}
+
+func (f *MockModemFirmware) GetUpdateSettings() (mm.UpdateSettingsProperty, error) {
+	return f.UpdateSettingsValue, f.UpdateSettingsError
+}
+
+func (f *MockModemFirmware) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"UpdateSettings": f.UpdateSettingsValue,
+	})
+}
+
+// MockModemVoice is a mock implementation of ModemVoice interface
+type MockModemVoice struct {
+	ObjectPathValue    dbus.ObjectPath
+	CallsValue         []mm.Call
+	EmergencyOnlyValue bool
+	ListCallsError     error
+	CreateCallError    error
+	HangupAllError     error
+
+	// nextCall numbers the calls created by CreateCall
+	nextCall int
+}
+
+func NewMockModemVoice() *MockModemVoice {
+	return &MockModemVoice{ObjectPathValue: mm.ModemPathFromIndex(0)}
+}
+
+func (v *MockModemVoice) GetObjectPath() dbus.ObjectPath {
+	return v.ObjectPathValue
+}
+
+func (v *MockModemVoice) ListCalls() ([]mm.Call, error) {
+	return v.CallsValue, v.ListCallsError
+}
+
+func (v *MockModemVoice) DeleteCall(c mm.Call) error {
+	for i, call := range v.CallsValue {
+		if call.GetObjectPath() == c.GetObjectPath() {
+			v.CallsValue = append(v.CallsValue[:i], v.CallsValue[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("mocks: no such call")
+}
+
+// CreateCall adds an outgoing call to CallsValue
+func (v *MockModemVoice) CreateCall(number string, optionalParameters ...mm.Pair) (mm.Call, error) {
+	if v.CreateCallError != nil {
+		return nil, v.CreateCallError
+	}
+	call := NewMockCall()
+	call.ObjectPathValue = dbus.ObjectPath(fmt.Sprint(mm.CallObjectPathPrefix, v.nextCall))
+	call.NumberValue = number
+	v.nextCall++
+	v.CallsValue = append(v.CallsValue, call)
+	return call, nil
+}
+
+func (v *MockModemVoice) HoldAndAccept() error {
+	return ErrNotSupported
+}
+
+func (v *MockModemVoice) HangupAndAccept() error {
+	return ErrNotSupported
+}
+
+// HangupAll hangs up every call of CallsValue
+func (v *MockModemVoice) HangupAll() error {
+	if v.HangupAllError != nil {
+		return v.HangupAllError
+	}
+	for _, call := range v.CallsValue {
+		call.Hangup()
+	}
+	return nil
+}
+
+func (v *MockModemVoice) Transfer() error {
+	return ErrNotSupported
+}
+
+func (v *MockModemVoice) CallWaitingSetup(enable bool) error {
+	return ErrNotSupported
+}
+
+func (v *MockModemVoice) CallWaitingQuery(status bool) error {
+	return ErrNotSupported
+}
+
+func (v *MockModemVoice) GetCalls() ([]mm.Call, error) {
+	return v.ListCalls()
+}
+
+func (v *MockModemVoice) GetEmergencyOnly() (bool, error) {
+	return v.EmergencyOnlyValue, nil
+}
+
+func (v *MockModemVoice) SubscribeCallAdded() <-chan *dbus.Signal {
+	return make(chan *dbus.Signal, 10)
+}
+
+func (v *MockModemVoice) SubscribeCallDeleted() <-chan *dbus.Signal {
+	return make(chan *dbus.Signal, 10)
+}
+
+func (v *MockModemVoice) ParseCallAdded(s *dbus.Signal) (mm.Call, error) {
+	return nil, ErrNotSupported
+}
+
+func (v *MockModemVoice) ParseCallDeleted(s *dbus.Signal) (dbus.ObjectPath, error) {
+	return "", ErrNotSupported
+}
+
+func (v *MockModemVoice) Unsubscribe() {}
+
+func (v *MockModemVoice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"EmergencyOnly": v.EmergencyOnlyValue,
+	})
+}
+
+// MockModemMessaging is a mock implementation of ModemMessaging interface.
+// Messages created with it are added to MessagesValue.
+type MockModemMessaging struct {
+	ObjectPathValue     dbus.ObjectPath
+	MessagesValue       []mm.Sms
+	DefaultStorageValue mm.MMSmsStorage
+	ListError           error
+	DeleteError         error
+	CreateError         error
+
+	// SignalChan is returned by both subscriptions; if nil each gets a
+	// channel that never delivers. Unsubscribed counts Unsubscribe calls.
+	SignalChan   chan *dbus.Signal
+	Unsubscribed int
+
+	// nextSms numbers the messages created
+	nextSms int
+}
+
+func NewMockModemMessaging() *MockModemMessaging {
+	return &MockModemMessaging{
+		ObjectPathValue:     mm.ModemPathFromIndex(0),
+		DefaultStorageValue: mm.MmSmsStorageMe,
+	}
+}
+
+// AddedSignal returns the Added signal of the messaging interface for sms
+func (me *MockModemMessaging) AddedSignal(sms mm.Sms, received bool) *dbus.Signal {
+	return &dbus.Signal{
+		Path: me.ObjectPathValue,
+		Name: mm.ModemMessagingInterface + "." + mm.ModemMessagingSignalAdded,
+		Body: []interface{}{sms.GetObjectPath(), received},
+	}
+}
+
+func (me *MockModemMessaging) GetObjectPath() dbus.ObjectPath {
+	return me.ObjectPathValue
+}
+
+func (me *MockModemMessaging) List() ([]mm.Sms, error) {
+	return me.MessagesValue, me.ListError
+}
+
+func (me *MockModemMessaging) Delete(sms mm.Sms) error {
+	if me.DeleteError != nil {
+		return me.DeleteError
+	}
+	for i, message := range me.MessagesValue {
+		if message.GetObjectPath() == sms.GetObjectPath() {
+			me.MessagesValue = append(me.MessagesValue[:i], me.MessagesValue[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("mocks: no such SMS")
+}
+
+func (me *MockModemMessaging) CreateSms(number string, text string, optionalParameters ...mm.Pair) (mm.Sms, error) {
+	return me.CreateSmsWithProperties(mm.SmsProperties{Number: number, Text: text})
+}
+
+func (me *MockModemMessaging) CreateMms(number string, data []byte, optionalParameters ...mm.Pair) (mm.Sms, error) {
+	return me.CreateSmsWithProperties(mm.SmsProperties{Number: number, Data: data})
+}
+
+// CreateSmsWithProperties adds a stored message to MessagesValue
+func (me *MockModemMessaging) CreateSmsWithProperties(properties mm.SmsProperties) (mm.Sms, error) {
+	if me.CreateError != nil {
+		return nil, me.CreateError
+	}
+	if _, err := properties.ToMap(); err != nil {
+		return nil, err
+	}
+	sms := NewMockSms()
+	sms.ObjectPathValue = dbus.ObjectPath(fmt.Sprint(mm.SmsObjectPathPrefix, me.nextSms))
+	sms.PduTypeValue = mm.MmSmsPduTypeSubmit
+	sms.NumberValue = properties.Number
+	sms.TextValue = properties.Text
+	sms.DataValue = properties.Data
+	sms.SMSCValue = properties.Smsc
+	sms.DeliveryReportRequestValue = properties.DeliveryReportRequest
+	sms.StateValue = mm.MmSmsStateStored
+	me.nextSms++
+	me.MessagesValue = append(me.MessagesValue, sms)
+	return sms, nil
+}
+
+func (me *MockModemMessaging) GetMessages() ([]mm.Sms, error) {
+	return me.List()
+}
+
+func (me *MockModemMessaging) GetSupportedStorages() ([]mm.MMSmsStorage, error) {
+	return []mm.MMSmsStorage{me.DefaultStorageValue}, nil
+}
+
+func (me *MockModemMessaging) GetDefaultStorage() (mm.MMSmsStorage, error) {
+	return me.DefaultStorageValue, nil
+}
+
+func (me *MockModemMessaging) SubscribeAdded() <-chan *dbus.Signal {
+	if me.SignalChan != nil {
+		return me.SignalChan
+	}
+	return make(chan *dbus.Signal, 10)
+}
+
+// ParseAdded returns the message of MessagesValue the signal names
+func (me *MockModemMessaging) ParseAdded(v *dbus.Signal) (mm.Sms, bool, error) {
+	if len(v.Body) != 2 {
+		return nil, false, errors.New("mocks: malformed Added signal")
+	}
+	path, _ := v.Body[0].(dbus.ObjectPath)
+	received, _ := v.Body[1].(bool)
+	for _, sms := range me.MessagesValue {
+		if sms.GetObjectPath() == path {
+			return sms, received, nil
+		}
+	}
+	return nil, false, errors.New("mocks: no such SMS")
+}
+
+func (me *MockModemMessaging) SubscribeDeleted() <-chan *dbus.Signal {
+	return me.SubscribeAdded()
+}
+
+func (me *MockModemMessaging) Unsubscribe() {
+	me.Unsubscribed++
+}
+
+func (me *MockModemMessaging) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"Messages":       len(me.MessagesValue),
+		"DefaultStorage": me.DefaultStorageValue,
+	})
+}
+
+// MockSms is a mock implementation of Sms interface. Send moves the message
+// to the sent state.
+type MockSms struct {
+	ObjectPathValue            dbus.ObjectPath
+	StateValue                 mm.MMSmsState
+	PduTypeValue               mm.MMSmsPduType
+	NumberValue                string
+	TextValue                  string
+	DataValue                  []byte
+	SMSCValue                  string
+	ClassValue                 int32
+	DeliveryReportRequestValue bool
+	TimestampValue             time.Time
+	DeliveryStateValue         mm.MMSmsDeliveryState
+	StorageValue               mm.MMSmsStorage
+	SendError                  error
+}
+
+func NewMockSms() *MockSms {
+	return &MockSms{
+		ObjectPathValue:    mm.SmsObjectPathPrefix + "0",
+		StateValue:         mm.MmSmsStateReceived,
+		PduTypeValue:       mm.MmSmsPduTypeDeliver,
+		NumberValue:        "+1234567890",
+		TextValue:          "Hello",
+		DeliveryStateValue: mm.MmSmsDeliveryStateUnknown,
+		StorageValue:       mm.MmSmsStorageMe,
+	}
+}
+
+func (s *MockSms) GetObjectPath() dbus.ObjectPath {
+	return s.ObjectPathValue
+}
+
+func (s *MockSms) Send() error {
+	if s.SendError != nil {
+		return s.SendError
+	}
+	s.StateValue = mm.MmSmsStateSent
+	return nil
+}
+
+func (s *MockSms) Store(storage mm.MMSmsStorage) error {
+	s.StorageValue = storage
+	return nil
+}
+
+func (s *MockSms) GetState() (mm.MMSmsState, error) {
+	return s.StateValue, nil
+}
+
+func (s *MockSms) GetPduType() (mm.MMSmsPduType, error) {
+	return s.PduTypeValue, nil
+}
+
+func (s *MockSms) GetNumber() (string, error) {
+	return s.NumberValue, nil
+}
+
+func (s *MockSms) GetText() (string, error) {
+	return s.TextValue, nil
+}
+
+func (s *MockSms) GetData() ([]byte, error) {
+	return s.DataValue, nil
+}
+
+func (s *MockSms) GetSMSC() (string, error) {
+	return s.SMSCValue, nil
+}
+
+func (s *MockSms) GetValidity() (map[mm.MMSmsValidityType]interface{}, error) {
+	return map[mm.MMSmsValidityType]interface{}{}, nil
+}
+
+func (s *MockSms) GetClass() (int32, error) {
+	return s.ClassValue, nil
+}
+
+func (s *MockSms) GetTeleserviceId() (mm.MMSmsCdmaTeleserviceId, error) {
+	return 0, ErrNotSupported
+}
+
+func (s *MockSms) GetServiceCategory() (mm.MMSmsCdmaServiceCategory, error) {
+	return 0, ErrNotSupported
+}
+
+func (s *MockSms) GetDeliveryReportRequest() (bool, error) {
+	return s.DeliveryReportRequestValue, nil
+}
+
+func (s *MockSms) GetMessageReference() (mm.MMSmsPduType, error) {
+	return 0, nil
+}
+
+func (s *MockSms) GetTimestamp() (time.Time, error) {
+	return s.TimestampValue, nil
+}
+
+func (s *MockSms) GetDischargeTimestamp() (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (s *MockSms) GetDeliveryState() (mm.MMSmsDeliveryState, error) {
+	return s.DeliveryStateValue, nil
+}
+
+func (s *MockSms) GetStorage() (mm.MMSmsStorage, error) {
+	return s.StorageValue, nil
+}
+
+func (s *MockSms) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"Number": s.NumberValue,
+		"Text":   s.TextValue,
+		"State":  s.StateValue,
+	})
+}
+
+func (s *MockSms) SubscribePropertiesChanged() <-chan *dbus.Signal {
+	return make(chan *dbus.Signal, 10)
+}
+
+func (s *MockSms) ParsePropertiesChanged(v *dbus.Signal) (interfaceName string, changedProperties map[string]dbus.Variant, invalidatedProperties []string, err error) {
+	return "", nil, nil, nil
+}
+
+func (s *MockSms) Unsubscribe() {}
+
+// MockCall is a mock implementation of Call interface. Start, Accept and
+// Hangup move the call through its states.
+type MockCall struct {
+	ObjectPathValue  dbus.ObjectPath
+	StateValue       mm.MMCallState
+	StateReasonValue mm.MMCallStateReason
+	DirectionValue   mm.MMCallDirection
+	NumberValue      string
+	StartError       error
+	AcceptError      error
+	HangupError      error
+	SendDtmfError    error
+
+	// DtmfSent collects the tones passed to SendDtmf
+	DtmfSent string
+}
+
+func NewMockCall() *MockCall {
+	return &MockCall{
+		ObjectPathValue: mm.CallObjectPathPrefix + "0",
+		StateValue:      mm.MmCallStateUnknown,
+		DirectionValue:  mm.MmCallDirectionOutgoing,
+		NumberValue:     "+1234567890",
+	}
+}
+
+func (c *MockCall) GetObjectPath() dbus.ObjectPath {
+	return c.ObjectPathValue
+}
+
+func (c *MockCall) Start() error {
+	if c.StartError != nil {
+		return c.StartError
+	}
+	c.StateValue, c.StateReasonValue = mm.MmCallStateDialing, mm.MmCallStateReasonOutgoingStarted
+	return nil
+}
+
+func (c *MockCall) Accept() error {
+	if c.AcceptError != nil {
+		return c.AcceptError
+	}
+	c.StateValue, c.StateReasonValue = mm.MmCallStateActive, mm.MmCallStateReasonAccepted
+	return nil
+}
+
+func (c *MockCall) Deflect(number string) error {
+	return ErrNotSupported
+}
+
+func (c *MockCall) JoinMultiparty() error {
+	return ErrNotSupported
+}
+
+func (c *MockCall) LeaveMultiparty() error {
+	return ErrNotSupported
+}
+
+func (c *MockCall) Hangup() error {
+	if c.HangupError != nil {
+		return c.HangupError
+	}
+	c.StateValue, c.StateReasonValue = mm.MmCallStateTerminated, mm.MmCallStateReasonTerminated
+	return nil
+}
+
+func (c *MockCall) SendDtmf(dtmf string) error {
+	if c.SendDtmfError != nil {
+		return c.SendDtmfError
+	}
+	c.DtmfSent += dtmf
+	return nil
+}
+
+func (c *MockCall) GetState() (mm.MMCallState, error) {
+	return c.StateValue, nil
+}
+
+func (c *MockCall) GetStateReason() (mm.MMCallStateReason, error) {
+	return c.StateReasonValue, nil
+}
+
+func (c *MockCall) GetDirection() (mm.MMCallDirection, error) {
+	return c.DirectionValue, nil
+}
+
+func (c *MockCall) GetNumber() (string, error) {
+	return c.NumberValue, nil
+}
+
+func (c *MockCall) GetMultiparty() (bool, error) {
+	return false, nil
+}
+
+func (c *MockCall) GetAudioPort() (string, error) {
+	return "", nil
+}
+
+func (c *MockCall) GetAudioFormat() (mm.AudioFormat, error) {
+	return mm.AudioFormat{}, nil
+}
+
+func (c *MockCall) SubscribeDtmfReceived() <-chan *dbus.Signal {
+	return make(chan *dbus.Signal, 10)
+}
+
+func (c *MockCall) ParseDtmfReceived(v *dbus.Signal) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (c *MockCall) SubscribeStateChanged() <-chan *dbus.Signal {
+	return make(chan *dbus.Signal, 10)
+}
+
+func (c *MockCall) ParseStateChanged(v *dbus.Signal) (oldState mm.MMCallState, newState mm.MMCallState, reason mm.MMCallStateReason, err error) {
+	return 0, 0, 0, ErrNotSupported
+}
+
+func (c *MockCall) SubscribePropertiesChanged() <-chan *dbus.Signal {
+	return make(chan *dbus.Signal, 10)
+}
+
+func (c *MockCall) ParsePropertiesChanged(v *dbus.Signal) (interfaceName string, changedProperties map[string]dbus.Variant, invalidatedProperties []string, err error) {
+	return "", nil, nil, nil
+}
+
+func (c *MockCall) Unsubscribe() {}
+
+func (c *MockCall) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"State":     c.StateValue,
+		"Direction": c.DirectionValue,
+		"Number":    c.NumberValue,
+	})
+}
+
 // MockBearer is a mock implementation of Bearer interface
 type MockBearer struct {
 	ObjectPathValue dbus.ObjectPath
 	ConnectedValue  bool
 	InterfaceValue  string
-	Ipv4ConfigValue mm.IpConfig
-	Ipv6ConfigValue mm.IpConfig
+	Ipv4ConfigValue mm.BearerIpConfig
+	Ipv6ConfigValue mm.BearerIpConfig
+	PropertiesValue mm.BearerProperty
+	StatsValue      mm.BearerStats
 	ConnectError    error
 	DisconnectError error
+
+	// ConnectionErrorName and ConnectionErrorMessage are returned by GetConnectionError
+	ConnectionErrorName    string
+	ConnectionErrorMessage string
 }
 
 func NewMockBearer() *MockBearer {
 	return &MockBearer{
-		ObjectPathValue: "/org/freedesktop/ModemManager1/Bearer/0",
+		ObjectPathValue: mm.BearerObjectPathPrefix + "0",
 		ConnectedValue:  false,
 		InterfaceValue:  "wwan0",
-		Ipv4ConfigValue: mm.IpConfig{
+		Ipv4ConfigValue: mm.BearerIpConfig{
 			Method:  mm.MmBearerIpMethodStatic,
 			Address: "192.168.1.100",
 			Prefix:  24,
 			Gateway: "192.168.1.1",
-			Dns:     []string{"8.8.8.8", "8.8.4.4"},
+			Dns1:    "8.8.8.8",
+			Dns2:    "8.8.4.4",
+		},
+		PropertiesValue: mm.BearerProperty{
+			APN:          "internet",
+			IPType:       mm.MmBearerIpFamilyIpv4,
+			AllowRoaming: false,
+		},
+		StatsValue: mm.BearerStats{
+			StartDate: uint64(time.Now().Unix()),
+			RxBytes:   1024000,
+			TxBytes:   512000,
+			Attempts:  1,
 		},
 	}
 }
@@ -576,11 +1574,15 @@ func (b *MockBearer) GetSuspended() (bool, error) {
 	return false, nil
 }
 
-func (b *MockBearer) GetIp4Config() (mm.IpConfig, error) {
+func (b *MockBearer) GetConnectionError() (string, string, error) {
+	return b.ConnectionErrorName, b.ConnectionErrorMessage, nil
+}
+
+func (b *MockBearer) GetIp4Config() (mm.BearerIpConfig, error) {
 	return b.Ipv4ConfigValue, nil
 }
 
-func (b *MockBearer) GetIp6Config() (mm.IpConfig, error) {
+func (b *MockBearer) GetIp6Config() (mm.BearerIpConfig, error) {
 	return b.Ipv6ConfigValue, nil
 }
 
@@ -588,20 +1590,16 @@ func (b *MockBearer) GetIpTimeout() (uint32, error) {
 	return 20, nil
 }
 
+func (b *MockBearer) GetBearerType() (mm.MMBearerType, error) {
+	return mm.MmBearerTypeDefault, nil
+}
+
 func (b *MockBearer) GetProperties() (mm.BearerProperty, error) {
-	return mm.BearerProperty{
-		APN:          "internet",
-		IPType:       mm.MmBearerIpFamilyIpv4,
-		AllowRoaming: false,
-	}, nil
+	return b.PropertiesValue, nil
 }
 
 func (b *MockBearer) GetStats() (mm.BearerStats, error) {
-	return mm.BearerStats{
-		StartDate: time.Now().Unix(),
-		BytesRx:   1024000,
-		BytesTx:   512000,
-	}, nil
+	return b.StatsValue, nil
 }
 
 func (b *MockBearer) MarshalJSON() ([]byte, error) {
@@ -637,7 +1635,7 @@ type MockSim struct {
 
 func NewMockSim() *MockSim {
 	return &MockSim{
-		ObjectPathValue:         "/org/freedesktop/ModemManager1/Sim/0",
+		ObjectPathValue:         mm.SimObjectPathPrefix + "0",
 		SimIdentifierValue:      "89012345678901234567",
 		ImsiValue:               "310260123456789",
 		OperatorIdentifierValue: "310260",
@@ -681,6 +1679,10 @@ func (s *MockSim) GetOperatorName() (string, error) {
 	return s.OperatorNameValue, nil
 }
 
+func (s *MockSim) GetEmergencyNumbers() ([]string, error) {
+	return []string{"112", "911"}, nil
+}
+
 func (s *MockSim) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]interface{}{
 		"SimIdentifier":      s.SimIdentifierValue,
@@ -700,3 +1702,84 @@ func (s *MockSim) ParsePropertiesChanged(v *dbus.Signal) (interfaceName string,
 }
 
 func (s *MockSim) Unsubscribe() {}
+
+// MockModemSignal is a mock implementation of ModemSignal interface
+type MockModemSignal struct {
+	ObjectPathValue dbus.ObjectPath
+	RateValue       uint32
+	CdmaValue       mm.SignalProperty
+	EvdoValue       mm.SignalProperty
+	GsmValue        mm.SignalProperty
+	UmtsValue       mm.SignalProperty
+	LteValue        mm.SignalProperty
+	SetupError      error
+}
+
+// NewMockModemSignal returns extended signal information of an LTE modem
+// with fair reception
+func NewMockModemSignal() *MockModemSignal {
+	return &MockModemSignal{
+		ObjectPathValue: mm.ModemPathFromIndex(0),
+		RateValue:       10,
+		LteValue: mm.SignalProperty{
+			Type: mm.MMSignalPropertyTypeLte,
+			Rssi: -65,
+			Rsrq: -10,
+			Rsrp: -95,
+			Snr:  12.5,
+		},
+	}
+}
+
+func (s *MockModemSignal) GetObjectPath() dbus.ObjectPath {
+	return s.ObjectPathValue
+}
+
+func (s *MockModemSignal) Setup(rate uint32) error {
+	if s.SetupError != nil {
+		return s.SetupError
+	}
+	s.RateValue = rate
+	return nil
+}
+
+func (s *MockModemSignal) GetRate() (uint32, error) {
+	return s.RateValue, nil
+}
+
+func (s *MockModemSignal) GetCurrentSignals() ([]mm.SignalProperty, error) {
+	var current []mm.SignalProperty
+	for _, sp := range []mm.SignalProperty{s.CdmaValue, s.EvdoValue, s.GsmValue, s.UmtsValue, s.LteValue} {
+		if sp.Rssi != 0 {
+			current = append(current, sp)
+		}
+	}
+	return current, nil
+}
+
+func (s *MockModemSignal) GetCdma() (mm.SignalProperty, error) {
+	return s.CdmaValue, nil
+}
+
+func (s *MockModemSignal) GetEvdo() (mm.SignalProperty, error) {
+	return s.EvdoValue, nil
+}
+
+func (s *MockModemSignal) GetGsm() (mm.SignalProperty, error) {
+	return s.GsmValue, nil
+}
+
+func (s *MockModemSignal) GetUmts() (mm.SignalProperty, error) {
+	return s.UmtsValue, nil
+}
+
+func (s *MockModemSignal) GetLte() (mm.SignalProperty, error) {
+	return s.LteValue, nil
+}
+
+func (s *MockModemSignal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"Rate": s.RateValue,
+		"Lte":  s.LteValue,
+	})
+}
@@ -0,0 +1,66 @@
+package modemmanager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Well-known D-Bus names and object paths of the ModemManager daemon
+const (
+	// ModemManagerBusName is the well-known bus name owned by the daemon
+	ModemManagerBusName = "org.freedesktop.ModemManager1"
+
+	// ModemManagerInterface is the interface of the manager object; all other interfaces are prefixed with it
+	ModemManagerInterface = ModemManagerBusName
+
+	ModemManagerObjectPath     = "/org/freedesktop/ModemManager1"
+	modemManagerMainObjectPath = "/org/freedesktop/ModemManager/"
+
+	/* Object path prefixes, followed by a decimal index */
+	ModemObjectPathPrefix  = ModemManagerObjectPath + "/Modem/"
+	BearerObjectPathPrefix = ModemManagerObjectPath + "/Bearer/"
+	SimObjectPathPrefix    = ModemManagerObjectPath + "/SIM/"
+	SmsObjectPathPrefix    = ModemManagerObjectPath + "/SMS/"
+	CallObjectPathPrefix   = ModemManagerObjectPath + "/Call/"
+
+	/* Error name prefixes, followed by the error name, e.g. ErrorCorePrefix + "Unsupported" */
+	ErrorPrefix                = ModemManagerBusName + ".Error."
+	ErrorCorePrefix            = ErrorPrefix + "Core."
+	ErrorMobileEquipmentPrefix = ErrorPrefix + "MobileEquipment."
+	ErrorConnectionPrefix      = ErrorPrefix + "Connection."
+	ErrorSerialPrefix          = ErrorPrefix + "Serial."
+	ErrorMessagePrefix         = ErrorPrefix + "Message."
+	ErrorCdmaActivationPrefix  = ErrorPrefix + "CdmaActivation."
+)
+
+// ModemPathFromIndex returns the object path of the modem with the given index, as used by mmcli -m
+func ModemPathFromIndex(index int) dbus.ObjectPath {
+	return dbus.ObjectPath(ModemObjectPathPrefix + strconv.Itoa(index))
+}
+
+// IndexFromModemPath returns the index of a modem object path such as
+// /org/freedesktop/ModemManager1/Modem/3
+func IndexFromModemPath(path dbus.ObjectPath) (int, error) {
+	return indexFromPath(path, ModemObjectPathPrefix)
+}
+
+// indexFromPath parses the decimal index following prefix in path
+func indexFromPath(path dbus.ObjectPath, prefix string) (int, error) {
+	suffix, ok := strings.CutPrefix(string(path), prefix)
+	if !ok || suffix == "" {
+		return 0, fmt.Errorf("object path %q does not start with %s<index>", path, prefix)
+	}
+	for _, c := range suffix {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("object path %q has a non-numeric index", path)
+		}
+	}
+	index, err := strconv.Atoi(suffix)
+	if err != nil {
+		return 0, fmt.Errorf("object path %q has an invalid index: %w", path, err)
+	}
+	return index, nil
+}
@@ -0,0 +1,46 @@
+package modemmanager
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestModemPathFromIndex(t *testing.T) {
+	if got, want := ModemPathFromIndex(3), dbus.ObjectPath("/org/freedesktop/ModemManager1/Modem/3"); got != want {
+		t.Errorf("ModemPathFromIndex(3) = %q, want %q", got, want)
+	}
+	for _, i := range []int{0, 7, 12345} {
+		if got, err := IndexFromModemPath(ModemPathFromIndex(i)); err != nil || got != i {
+			t.Errorf("IndexFromModemPath(ModemPathFromIndex(%d)) = %d, %v", i, got, err)
+		}
+	}
+}
+
+func TestIndexFromModemPathMalformed(t *testing.T) {
+	for _, path := range []dbus.ObjectPath{
+		"",
+		"/",
+		"/org/freedesktop/ModemManager1",
+		"/org/freedesktop/ModemManager1/Modem/",
+		"/org/freedesktop/ModemManager1/Modem",
+		"/org/freedesktop/ModemManager1/Modem/-1",
+		"/org/freedesktop/ModemManager1/Modem/+1",
+		"/org/freedesktop/ModemManager1/Modem/1a",
+		"/org/freedesktop/ModemManager1/Modem/1/Extra",
+		"/org/freedesktop/ModemManager1/Modem/ 1",
+		"/org/freedesktop/ModemManager1/Modem/99999999999999999999999",
+		"/org/freedesktop/ModemManager1/Bearer/1",
+		"/org/freedesktop/ModemManager/Modem/1",
+	} {
+		if index, err := IndexFromModemPath(path); err == nil {
+			t.Errorf("IndexFromModemPath(%q) = %d, want error", path, index)
+		}
+	}
+}
+
+func TestConnectionErrorNames(t *testing.T) {
+	if e, ok := ConnectionErrorFromName("org.freedesktop.ModemManager1.Error.Connection.NoCarrier"); !ok || e != MmConnectionErrorNoCarrier {
+		t.Errorf("ConnectionErrorFromName(NoCarrier) = %v, %v", e, ok)
+	}
+}
@@ -45,6 +45,11 @@ type Pair struct {
 	a, b interface{}
 }
 
+// NewPair returns a pair of left and right, e.g. for mock implementations
+func NewPair(left, right interface{}) Pair {
+	return Pair{a: left, b: right}
+}
+
 // GetLeft returns left value
 func (p Pair) GetLeft() interface{} {
 	return p.a
@@ -127,21 +132,13 @@ func (d *dbusBase) parsePropertiesChanged(v *dbus.Signal) (interfaceName string,
 		err = errors.New("error by parsing property changed signal")
 		return
 	}
-	interfaceName, ok := v.Body[0].(string)
-	if !ok {
-		err = errors.New("error by parsing interface name")
-		return
-	}
-	changedProperties, ok = v.Body[1].(map[string]dbus.Variant)
-	if !ok {
-		err = errors.New("error by parsing changed properties map name")
+	if interfaceName, err = decode[string](dbusPropertiesChanged+".interface_name", v.Body[0]); err != nil {
 		return
 	}
-	invalidatedProperties, ok = v.Body[2].([]string)
-	if !ok {
-		err = errors.New("error by parsing invalidated properties")
+	if changedProperties, err = decode[map[string]dbus.Variant](dbusPropertiesChanged+".changed_properties", v.Body[1]); err != nil {
 		return
 	}
+	invalidatedProperties, err = decode[[]string](dbusPropertiesChanged+".invalidated_properties", v.Body[2])
 	return
 }
 
@@ -160,11 +157,7 @@ func (d *dbusBase) getObjectProperty(iface string) (value dbus.ObjectPath, err e
 	if err != nil {
 		return
 	}
-	value, ok := prop.(dbus.ObjectPath)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[dbus.ObjectPath](iface, prop)
 	return
 }
 
@@ -174,11 +167,7 @@ func (d *dbusBase) getSliceObjectProperty(iface string) (value []dbus.ObjectPath
 		return
 	}
 
-	value, ok := prop.([]dbus.ObjectPath)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[[]dbus.ObjectPath](iface, prop)
 	return
 }
 
@@ -187,11 +176,7 @@ func (d *dbusBase) getBoolProperty(iface string) (value bool, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(bool)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[bool](iface, prop)
 	return
 }
 
@@ -200,11 +185,7 @@ func (d *dbusBase) getStringProperty(iface string) (value string, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(string)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[string](iface, prop)
 	return
 }
 
@@ -213,11 +194,7 @@ func (d *dbusBase) getSliceStringProperty(iface string) (value []string, err err
 	if err != nil {
 		return
 	}
-	value, ok := prop.([]string)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[[]string](iface, prop)
 	return
 }
 
@@ -226,11 +203,7 @@ func (d *dbusBase) getSliceSliceByteProperty(iface string) (value [][]byte, err
 	if err != nil {
 		return
 	}
-	value, ok := prop.([][]byte)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[[][]byte](iface, prop)
 	return
 }
 func (d *dbusBase) getPairProperty(iface string) (value Pair, err error) {
@@ -238,9 +211,8 @@ func (d *dbusBase) getPairProperty(iface string) (value Pair, err error) {
 	if err != nil {
 		return
 	}
-	values, ok := prop.([]interface{})
-	if !ok {
-		err = makeErrVariantType(iface)
+	values, err := decode[[]interface{}](iface, prop)
+	if err != nil {
 		return
 	}
 	for idy, val := range values {
@@ -259,9 +231,8 @@ func (d *dbusBase) getSliceSlicePairProperty(iface string) (value []Pair, err er
 	if err != nil {
 		return
 	}
-	values, ok := prop.([][]interface{})
-	if !ok {
-		err = makeErrVariantType(iface)
+	values, err := decode[[][]interface{}](iface, prop)
+	if err != nil {
 		return
 	}
 	for _, xs := range values {
@@ -286,11 +257,7 @@ func (d *dbusBase) getMapStringVariantProperty(iface string) (value map[string]d
 	if err != nil {
 		return
 	}
-	value, ok := prop.(map[string]dbus.Variant)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[map[string]dbus.Variant](iface, prop)
 	return
 }
 
@@ -299,11 +266,7 @@ func (d *dbusBase) getMapUint32Uint32Property(iface string) (value map[uint32]ui
 	if err != nil {
 		return
 	}
-	value, ok := prop.(map[uint32]uint32)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[map[uint32]uint32](iface, prop)
 	return
 }
 func (d *dbusBase) getMapUint32InterfaceProperty(iface string) (value map[uint32]interface{}, err error) {
@@ -312,11 +275,7 @@ func (d *dbusBase) getMapUint32InterfaceProperty(iface string) (value map[uint32
 		return
 	}
 
-	value, ok := prop.(map[uint32]interface{})
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[map[uint32]interface{}](iface, prop)
 	return
 }
 func (d *dbusBase) getMapUint32VariantProperty(iface string) (value map[uint32]dbus.Variant, err error) {
@@ -325,11 +284,7 @@ func (d *dbusBase) getMapUint32VariantProperty(iface string) (value map[uint32]d
 		return
 	}
 
-	value, ok := prop.(map[uint32]dbus.Variant)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[map[uint32]dbus.Variant](iface, prop)
 	return
 }
 
@@ -338,9 +293,8 @@ func (d *dbusBase) getTimestampProperty(iface string) (value time.Time, err erro
 	if err != nil {
 		return
 	}
-	parsedValue, ok := prop.([]interface{})
-	if !ok {
-		err = makeErrVariantType(iface)
+	parsedValue, err := decode[[]interface{}](iface, prop)
+	if err != nil {
 		return
 	}
 	var sec uint64
@@ -349,16 +303,14 @@ func (d *dbusBase) getTimestampProperty(iface string) (value time.Time, err erro
 	msec = 0
 	for idx, val := range parsedValue {
 		if idx == 0 {
-			sec, ok = val.(uint64)
-			if !ok {
-				err = makeErrVariantType(iface)
+			sec, err = decode[uint64](iface, val)
+			if err != nil {
 				return
 			}
 		}
 		if idx == 1 {
-			msec, ok = val.(uint64)
-			if !ok {
-				err = makeErrVariantType(iface)
+			msec, err = decode[uint64](iface, val)
+			if err != nil {
 				return
 			}
 		}
@@ -372,11 +324,7 @@ func (d *dbusBase) getUint8Property(iface string) (value uint8, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(uint8)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[uint8](iface, prop)
 	return
 }
 
@@ -385,11 +333,7 @@ func (d *dbusBase) getUint32Property(iface string) (value uint32, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(uint32)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[uint32](iface, prop)
 	return
 }
 
@@ -402,11 +346,7 @@ func (d *dbusBase) getInt32Property(iface string) (value int32, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(int32)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[int32](iface, prop)
 	return
 }
 
@@ -415,11 +355,7 @@ func (d *dbusBase) getInt64Property(iface string) (value int64, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(int64)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[int64](iface, prop)
 	return
 }
 func (d *dbusBase) getFloat32Property(iface string) (value float32, err error) {
@@ -427,11 +363,7 @@ func (d *dbusBase) getFloat32Property(iface string) (value float32, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(float32)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[float32](iface, prop)
 	return
 }
 
@@ -440,11 +372,7 @@ func (d *dbusBase) getFloat64Property(iface string) (value float64, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(float64)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[float64](iface, prop)
 	return
 }
 
@@ -453,11 +381,7 @@ func (d *dbusBase) getUint64Property(iface string) (value uint64, err error) {
 	if err != nil {
 		return
 	}
-	value, ok := prop.(uint64)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[uint64](iface, prop)
 	return
 }
 
@@ -467,11 +391,7 @@ func (d *dbusBase) getSliceUint32Property(iface string) (value []uint32, err err
 		return
 	}
 
-	value, ok := prop.([]uint32)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[[]uint32](iface, prop)
 	return
 }
 
@@ -480,11 +400,7 @@ func (d *dbusBase) getSliceSliceUint32Property(iface string) (value [][]uint32,
 	if err != nil {
 		return
 	}
-	value, ok := prop.([][]uint32)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[[][]uint32](iface, prop)
 	return
 }
 
@@ -493,11 +409,7 @@ func (d *dbusBase) getSliceMapStringVariantProperty(iface string) (value []map[s
 	if err != nil {
 		return
 	}
-	value, ok := prop.([]map[string]dbus.Variant)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[[]map[string]dbus.Variant](iface, prop)
 	return
 }
 
@@ -506,11 +418,7 @@ func (d *dbusBase) getSliceMapStringInterfaceProperty(iface string) (value []map
 	if err != nil {
 		return
 	}
-	value, ok := prop.([]map[string]interface{})
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[[]map[string]interface{}](iface, prop)
 	return
 }
 
@@ -519,18 +427,10 @@ func (d *dbusBase) getSliceByteProperty(iface string) (value []byte, err error)
 	if err != nil {
 		return
 	}
-	value, ok := prop.([]byte)
-	if !ok {
-		err = makeErrVariantType(iface)
-		return
-	}
+	value, err = decode[[]byte](iface, prop)
 	return
 }
 
-func makeErrVariantType(iface string) error {
-	return fmt.Errorf("unexpected variant type for '%s'", iface)
-}
-
 func ip4ToString(ip uint32) string {
 	bs := []byte{0, 0, 0, 0}
 	binary.LittleEndian.PutUint32(bs, ip)